@@ -0,0 +1,103 @@
+// jvp-e2e 运行实例生命周期端到端测试
+//
+// libvirt 模式：针对已运行的 JVP（通常是容器内的 libvirtd + qemu TCG，见 docker-compose.e2e.yml）
+// 执行 RunInstance → SSH → Terminate；
+// fake 模式：在进程内启动 JVP 并注册 fake node，无需 libvirtd，适用于 CI 降级运行。
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/jimyag/jvp/internal/e2e"
+	"github.com/jimyag/jvp/internal/jvp"
+	"github.com/jimyag/jvp/internal/jvp/config"
+	"github.com/rs/zerolog"
+)
+
+func main() {
+	var cfg e2e.Config
+	var mode string
+	flag.StringVar(&mode, "mode", string(e2e.ModeLibvirt), "run mode: libvirt or fake")
+	flag.StringVar(&cfg.Endpoint, "endpoint", "http://127.0.0.1:7777", "JVP API endpoint (ignored in fake mode)")
+	flag.StringVar(&cfg.NodeName, "node", "", "node name (default: local, or e2e-fake in fake mode)")
+	flag.StringVar(&cfg.PoolName, "pool", "default", "storage pool name")
+	flag.StringVar(&cfg.TemplateID, "template", "", "cloud image template ID (required in libvirt mode)")
+	flag.StringVar(&cfg.NetworkType, "network-type", "network", "instance network type: network or bridge")
+	flag.StringVar(&cfg.NetworkSource, "network-source", "default", "instance network source")
+	flag.StringVar(&cfg.SSHUser, "ssh-user", "ubuntu", "SSH login user")
+	flag.DurationVar(&cfg.BootTimeout, "boot-timeout", 10*time.Minute, "timeout for instance boot and SSH")
+	flag.DurationVar(&cfg.PollInterval, "poll-interval", 5*time.Second, "poll interval")
+	flag.BoolVar(&cfg.KeepOnFail, "keep-on-fail", false, "keep instance and keypair on failure")
+	flag.Parse()
+
+	logger := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).With().Timestamp().Logger()
+	ctx := logger.WithContext(context.Background())
+
+	cfg.Mode = e2e.Mode(mode)
+	if cfg.Mode == e2e.ModeFake {
+		endpoint, err := startFakeServer(ctx)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to start in-process JVP server")
+		}
+		cfg.Endpoint = endpoint
+		cfg.PollInterval = 100 * time.Millisecond
+	}
+
+	suite, err := e2e.NewSuite(cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Invalid e2e config")
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	if err := e2e.NewClient(cfg.Endpoint).WaitReady(waitCtx, time.Second); err != nil {
+		logger.Fatal().Err(err).Msg("JVP API is not ready")
+	}
+
+	report := suite.Run(ctx)
+	fmt.Print(report.String())
+	if report.Failed() {
+		os.Exit(1)
+	}
+}
+
+// startFakeServer 在进程内启动使用独立数据目录的 JVP，返回 API 地址
+func startFakeServer(ctx context.Context) (string, error) {
+	dataDir, err := os.MkdirTemp("", "jvp-e2e-data-")
+	if err != nil {
+		return "", fmt.Errorf("create data dir: %w", err)
+	}
+
+	// 选一个空闲端口
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("find free port: %w", err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+
+	cfg, err := config.New()
+	if err != nil {
+		return "", err
+	}
+	cfg.DataDir = dataDir
+	cfg.Address = addr
+	cfg.LibvirtURI = "fake:///local"
+
+	server, err := jvp.New(cfg)
+	if err != nil {
+		return "", err
+	}
+	go func() {
+		if err := server.Run(ctx); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("In-process JVP server exited")
+		}
+	}()
+
+	return "http://" + addr, nil
+}
@@ -0,0 +1,51 @@
+// jvpm JVP 命令行工具
+//
+// 子命令：
+//
+//	ssh  通过控制面查询实例 IP、登录用户与关联密钥对，必要时经宿主机 ProxyJump，直接打开交互式 SSH
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jimyag/jvp/internal/e2e"
+)
+
+func main() {
+	defaultEndpoint := os.Getenv("JVP_ENDPOINT")
+	if defaultEndpoint == "" {
+		defaultEndpoint = "http://127.0.0.1:7777"
+	}
+	endpoint := flag.String("endpoint", defaultEndpoint, "JVP API endpoint (env: JVP_ENDPOINT)")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	client := e2e.NewClient(*endpoint)
+	var err error
+	switch flag.Arg(0) {
+	case "ssh":
+		err = runSSH(context.Background(), client, *endpoint, flag.Args()[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "jvpm: unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "jvpm: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: jvpm [-endpoint URL] <command> [arguments]\n\nCommands:\n")
+	fmt.Fprintf(os.Stderr, "  ssh    open an interactive SSH session to an instance\n\n")
+	flag.PrintDefaults()
+}
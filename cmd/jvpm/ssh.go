@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	"github.com/jimyag/jvp/internal/e2e"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+)
+
+// runSSH 查询实例的 SSH 登录信息并用 ssh 替换当前进程，实例 ID 之后的参数作为远程命令
+func runSSH(ctx context.Context, client *e2e.Client, endpoint string, args []string) error {
+	fs := flag.NewFlagSet("ssh", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: jvpm ssh [flags] <instance-id> [command...]\n\n")
+		fs.PrintDefaults()
+	}
+	node := fs.String("node", "local", "node name of the instance")
+	user := fs.String("l", "", "login user (default: the user the instance's key pairs were injected into)")
+	identity := fs.String("i", "", "private key file (default: ~/.ssh/<keypair name> or ~/.ssh/<keypair id> of a keypair injected into the instance)")
+	jump := fs.String("J", "", "jump host user@host[:port] (default: the node, when the instance is only reachable from its host)")
+	direct := fs.Bool("direct", false, "connect to the instance directly without a jump host")
+	_ = fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	instanceID := fs.Arg(0)
+
+	var resp entity.DescribeInstanceSSHTargetResponse
+	if err := client.Call(ctx, "describe-instance-ssh-target", &entity.DescribeInstanceSSHTargetRequest{
+		NodeName:   *node,
+		InstanceID: instanceID,
+	}, &resp); err != nil {
+		return err
+	}
+	target := resp.Target
+	if target == nil {
+		return fmt.Errorf("describe-instance-ssh-target returned no target")
+	}
+
+	loginUser := *user
+	if loginUser == "" {
+		loginUser = target.User
+	}
+	if loginUser == "" {
+		return fmt.Errorf("login user of instance %s is unknown (key pairs were injected into the image's default user), specify it with -l", instanceID)
+	}
+
+	sshArgs := []string{"ssh", "-p", fmt.Sprint(target.Port)}
+	if key := *identity; key != "" {
+		sshArgs = append(sshArgs, "-i", key)
+	} else if key := findKeyPairFile(target.KeyPairs); key != "" {
+		sshArgs = append(sshArgs, "-i", key)
+	} else if len(target.KeyPairs) > 0 {
+		fmt.Fprintf(os.Stderr, "jvpm: no private key found for keypair %s (%s), falling back to ssh defaults\n", target.KeyPairs[0].Name, target.KeyPairs[0].ID)
+	}
+	if proxy := proxyJump(target, *jump, *direct, endpoint); proxy != "" {
+		sshArgs = append(sshArgs, "-J", proxy)
+	}
+	sshArgs = append(sshArgs, loginUser+"@"+target.Host)
+	sshArgs = append(sshArgs, fs.Args()[1:]...)
+
+	sshPath, err := exec.LookPath("ssh")
+	if err != nil {
+		return fmt.Errorf("ssh client not found: %w", err)
+	}
+	return syscall.Exec(sshPath, sshArgs, os.Environ())
+}
+
+// findKeyPairFile 在 ~/.ssh 下按密钥对名称或 ID 查找私钥文件，找不到时返回空
+func findKeyPairFile(keyPairs []entity.KeyPair) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	for _, keyPair := range keyPairs {
+		for _, name := range []string{keyPair.Name, keyPair.ID} {
+			if name == "" {
+				continue
+			}
+			path := filepath.Join(home, ".ssh", name)
+			if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// proxyJump 选择跳板：显式指定优先；实例只能从宿主机访问时使用远程节点的 SSH 地址，本地节点使用 API 所在主机
+func proxyJump(target *entity.InstanceSSHTarget, jump string, direct bool, endpoint string) string {
+	if direct {
+		return ""
+	}
+	if jump != "" {
+		return jump
+	}
+	if !target.JumpRequired {
+		return ""
+	}
+	if target.ProxyJump != "" {
+		return target.ProxyJump
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Hostname() == "" {
+		return ""
+	}
+	switch parsed.Hostname() {
+	case "127.0.0.1", "localhost", "::1":
+		// 控制面与实例在同一主机，无需跳转
+		return ""
+	}
+	return "root@" + parsed.Hostname()
+}
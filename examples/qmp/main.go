@@ -3,28 +3,45 @@ package main
 import (
 	"fmt"
 	"log"
-	"time"
+	"os"
 
-	"github.com/digitalocean/go-qemu/qemu"
-	"github.com/digitalocean/go-qemu/qmp"
 	_ "github.com/jimmicro/version"
+	libvirtclient "github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/qmp"
 )
 
 func main() {
-	m, err := qmp.NewSocketMonitor("127.0.0.1", "4444", 10*time.Second)
+	domainName := "test"
+	if len(os.Args) > 1 {
+		domainName = os.Args[1]
+	}
+
+	// 连接到 libvirt，QMP 命令经 libvirt 的 monitor 通道转发，不需要 QEMU 单独开放 QMP socket
+	client, err := libvirtclient.New()
 	if err != nil {
-		log.Fatalf("failed to create qmp monitor: %v", err)
+		log.Fatalf("failed to connect to libvirt: %v", err)
 	}
+	defer client.Close()
 
-	d, err := qemu.NewDomain(m, "test")
+	monitor := qmp.New(client, domainName)
+
+	status, err := monitor.QueryStatus()
 	if err != nil {
-		log.Fatalf("failed to create qemu domain: %v", err)
+		log.Fatalf("failed to query status: %v", err)
 	}
-	ch, _, err := d.Events()
+	fmt.Printf("status: %s\n", status.Status)
+
+	jobs, err := monitor.QueryBlockJobs()
 	if err != nil {
-		log.Fatalf("failed to create qemu domain: %v", err)
+		log.Fatalf("failed to query block jobs: %v", err)
 	}
-	for event := range ch {
-		fmt.Println(event.Data)
+	for _, job := range jobs {
+		fmt.Printf("block job %s (%s): %d/%d ready=%v\n", job.Device, job.Type, job.Offset, job.Len, job.Ready)
+	}
+
+	migration, err := monitor.QueryMigrate()
+	if err != nil {
+		log.Fatalf("failed to query migration: %v", err)
 	}
+	fmt.Printf("migration: %s\n", migration.Status)
 }
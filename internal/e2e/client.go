@@ -0,0 +1,89 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jimyag/jvp/pkg/apierror"
+)
+
+// Client JVP HTTP API 客户端
+// 所有 action 均为 POST /api/{action}，请求与响应均为 JSON
+type Client struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewClient 创建 API 客户端，endpoint 形如 http://127.0.0.1:7777
+func NewClient(endpoint string) *Client {
+	return &Client{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		httpClient: &http.Client{
+			Timeout: 5 * time.Minute,
+		},
+	}
+}
+
+// Call 调用指定 action，resp 为 nil 时忽略响应体
+// 服务端返回错误时解析为 *apierror.ErrorResponse
+func (c *Client) Call(ctx context.Context, action string, req, resp any) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal %s request: %w", action, err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/api/"+action, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create %s request: %w", action, err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("call %s: %w", action, err)
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return fmt.Errorf("read %s response: %w", action, err)
+	}
+
+	if httpResp.StatusCode >= http.StatusBadRequest {
+		var errResp apierror.ErrorResponse
+		if err := json.Unmarshal(data, &errResp); err == nil && len(errResp.Errors) > 0 {
+			return fmt.Errorf("call %s: %w", action, &errResp)
+		}
+		return fmt.Errorf("call %s: HTTP %d: %s", action, httpResp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	if resp == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return fmt.Errorf("unmarshal %s response: %w", action, err)
+	}
+	return nil
+}
+
+// WaitReady 等待 API 服务可用
+func (c *Client) WaitReady(ctx context.Context, interval time.Duration) error {
+	for {
+		err := c.Call(ctx, "list-nodes", struct{}{}, nil)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for API ready: %w (last error: %v)", ctx.Err(), err)
+		case <-time.After(interval):
+		}
+	}
+}
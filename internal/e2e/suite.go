@@ -0,0 +1,482 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/api"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/rs/zerolog"
+)
+
+// Mode e2e 运行模式
+type Mode string
+
+const (
+	ModeLibvirt Mode = "libvirt" // 真实 libvirtd + qemu（无 KVM 时为 TCG），验证 SSH 登录
+	ModeFake    Mode = "fake"    // 使用 fake node（内存 FakeLibvirt），跳过依赖真实 guest 的步骤
+)
+
+// Config e2e 套件配置
+type Config struct {
+	Endpoint string // JVP API 地址（必填，如 http://127.0.0.1:7777）
+	Mode     Mode   // 运行模式（默认：libvirt）
+
+	NodeName string // 节点名称（libvirt 默认：local；fake 默认：e2e-fake）
+	PoolName string // 存储池名称（默认：default）
+	PoolPath string // fake 模式下创建的存储池路径（默认：/var/lib/jvp/e2e）
+	FakeURI  string // fake 模式下节点的 URI（默认：fake:///e2e）
+
+	TemplateID    string // 模板 ID（libvirt 模式必填，需为带 cloud-init 的 cloud image）
+	NetworkType   string // 网络类型（默认：network）
+	NetworkSource string // 网络源（默认：default，带 DHCP 的 NAT 网络）
+	MemoryMB      uint64 // 内存大小（默认：1024）
+	VCPUs         uint16 // vCPU 数量（默认：1）
+	SSHUser       string // SSH 登录用户（默认：ubuntu，RunInstance 把密钥注入发行版默认用户，与 Ubuntu cloud image 一致）
+
+	BootTimeout  time.Duration // 等待实例运行、获取 IP 与 SSH 可用的超时（默认：10m，TCG 启动较慢）
+	PollInterval time.Duration // 轮询间隔（默认：5s）
+	KeepOnFail   bool          // 失败时保留实例与密钥对，便于排查
+}
+
+// StepResult 单个步骤的执行结果
+type StepResult struct {
+	Name     string
+	Duration time.Duration
+	Skipped  bool
+	Err      error
+}
+
+// Report 套件执行报告
+type Report struct {
+	Mode       Mode
+	InstanceID string
+	Steps      []StepResult
+}
+
+// Failed 是否有步骤失败
+func (r *Report) Failed() bool {
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// String 格式化输出报告
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "e2e report (mode=%s, instance=%s)\n", r.Mode, r.InstanceID)
+	for _, step := range r.Steps {
+		status := "PASS"
+		switch {
+		case step.Err != nil:
+			status = "FAIL"
+		case step.Skipped:
+			status = "SKIP"
+		}
+		fmt.Fprintf(&b, "  [%s] %-20s %8s", status, step.Name, step.Duration.Round(time.Millisecond))
+		if step.Err != nil {
+			fmt.Fprintf(&b, "  %v", step.Err)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// errSkipped 步骤在当前模式下不适用
+var errSkipped = errors.New("skipped")
+
+// Suite 实例生命周期 e2e 套件
+// 流程：准备节点 → 创建密钥对 → RunInstance → 等待运行 → 校验 user-data → SSH 登录 → Terminate → 校验已删除
+type Suite struct {
+	cfg    Config
+	client *Client
+
+	keyPairID  string
+	publicKey  string
+	keyFile    string
+	instanceID string
+	ip         string
+}
+
+// NewSuite 创建 e2e 套件
+func NewSuite(cfg Config) (*Suite, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = ModeLibvirt
+	}
+	switch cfg.Mode {
+	case ModeLibvirt:
+		if cfg.TemplateID == "" {
+			return nil, fmt.Errorf("template ID is required in %s mode", cfg.Mode)
+		}
+		if cfg.NodeName == "" {
+			cfg.NodeName = "local"
+		}
+	case ModeFake:
+		if cfg.NodeName == "" {
+			cfg.NodeName = "e2e-fake"
+		}
+		if cfg.FakeURI == "" {
+			cfg.FakeURI = "fake:///e2e"
+		}
+		if cfg.PoolPath == "" {
+			cfg.PoolPath = "/var/lib/jvp/e2e"
+		}
+	default:
+		return nil, fmt.Errorf("unsupported mode: %s", cfg.Mode)
+	}
+	if cfg.PoolName == "" {
+		cfg.PoolName = "default"
+	}
+	if cfg.NetworkType == "" {
+		cfg.NetworkType = "network"
+	}
+	if cfg.NetworkSource == "" {
+		cfg.NetworkSource = "default"
+	}
+	if cfg.MemoryMB == 0 {
+		cfg.MemoryMB = 1024
+	}
+	if cfg.VCPUs == 0 {
+		cfg.VCPUs = 1
+	}
+	if cfg.SSHUser == "" {
+		cfg.SSHUser = "ubuntu"
+	}
+	if cfg.BootTimeout == 0 {
+		cfg.BootTimeout = 10 * time.Minute
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+
+	return &Suite{
+		cfg:    cfg,
+		client: NewClient(cfg.Endpoint),
+	}, nil
+}
+
+// Run 依次执行所有步骤，任一步骤失败后跳过后续步骤并执行清理
+func (s *Suite) Run(ctx context.Context) *Report {
+	report := &Report{Mode: s.cfg.Mode}
+
+	steps := []struct {
+		name string
+		fn   func(context.Context) error
+	}{
+		{"prepare-node", s.prepareNode},
+		{"create-keypair", s.createKeyPair},
+		{"run-instance", s.runInstance},
+		{"wait-running", s.waitRunning},
+		{"verify-user-data", s.verifyUserData},
+		{"ssh", s.ssh},
+		{"terminate", s.terminate},
+		{"verify-terminated", s.verifyTerminated},
+	}
+
+	failed := false
+	for _, step := range steps {
+		if failed {
+			report.Steps = append(report.Steps, StepResult{Name: step.name, Skipped: true})
+			continue
+		}
+		result := s.runStep(ctx, step.name, step.fn)
+		report.Steps = append(report.Steps, result)
+		failed = result.Err != nil
+	}
+
+	if !failed || !s.cfg.KeepOnFail {
+		report.Steps = append(report.Steps, s.runStep(ctx, "cleanup", s.cleanup))
+	}
+	report.InstanceID = s.instanceID
+	return report
+}
+
+func (s *Suite) runStep(ctx context.Context, name string, fn func(context.Context) error) StepResult {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().Str("step", name).Msg("Running e2e step")
+
+	start := time.Now()
+	err := fn(ctx)
+	result := StepResult{Name: name, Duration: time.Since(start)}
+	if errors.Is(err, errSkipped) {
+		result.Skipped = true
+		err = nil
+	}
+	result.Err = err
+
+	if err != nil {
+		logger.Error().Err(err).Str("step", name).Msg("E2E step failed")
+	}
+	return result
+}
+
+// prepareNode fake 模式下注册 fake node 并创建存储池；libvirt 模式下检查节点可用
+func (s *Suite) prepareNode(ctx context.Context) error {
+	if s.cfg.Mode == ModeLibvirt {
+		return s.client.Call(ctx, "describe-node", &api.DescribeNodeRequest{Name: s.cfg.NodeName}, nil)
+	}
+
+	if err := s.client.Call(ctx, "create-node", &api.CreateNodeRequest{
+		Name: s.cfg.NodeName,
+		URI:  s.cfg.FakeURI,
+		Type: entity.NodeTypeCompute,
+	}, nil); err != nil {
+		return err
+	}
+	return s.client.Call(ctx, "create-storage-pool", &entity.CreateStoragePoolRequest{
+		NodeName: s.cfg.NodeName,
+		Name:     s.cfg.PoolName,
+		Type:     "dir",
+		Path:     s.cfg.PoolPath,
+	}, nil)
+}
+
+// createKeyPair 创建密钥对，私钥写入临时文件供 SSH 使用
+func (s *Suite) createKeyPair(ctx context.Context) error {
+	var resp entity.CreateKeyPairResponse
+	if err := s.client.Call(ctx, "create-keypair", &entity.CreateKeyPairRequest{
+		Name:      fmt.Sprintf("e2e-%d", time.Now().UnixNano()),
+		Algorithm: "ed25519",
+	}, &resp); err != nil {
+		return err
+	}
+	if resp.KeyPair == nil {
+		return fmt.Errorf("create-keypair returned no keypair")
+	}
+	s.keyPairID = resp.KeyPair.ID
+	s.publicKey = strings.TrimSpace(resp.KeyPair.PublicKey)
+
+	dir, err := os.MkdirTemp("", "jvp-e2e-")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	s.keyFile = filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(s.keyFile, []byte(resp.PrivateKey), 0o600); err != nil {
+		return fmt.Errorf("write private key: %w", err)
+	}
+	return nil
+}
+
+func (s *Suite) runInstance(ctx context.Context) error {
+	req := &entity.RunInstanceRequest{
+		NodeName:      s.cfg.NodeName,
+		PoolName:      s.cfg.PoolName,
+		Name:          fmt.Sprintf("e2e-%d", time.Now().Unix()),
+		MemoryMB:      s.cfg.MemoryMB,
+		VCPUs:         s.cfg.VCPUs,
+		NetworkType:   s.cfg.NetworkType,
+		NetworkSource: s.cfg.NetworkSource,
+		KeyPairIDs:    []string{s.keyPairID},
+	}
+	if s.cfg.Mode == ModeLibvirt {
+		req.TemplateID = s.cfg.TemplateID
+	}
+
+	var resp entity.RunInstanceResponse
+	if err := s.client.Call(ctx, "run-instances", req, &resp); err != nil {
+		return err
+	}
+	if resp.Instance == nil {
+		return fmt.Errorf("run-instances returned no instance")
+	}
+	s.instanceID = resp.Instance.ID
+	return nil
+}
+
+// describeInstance 查询实例，不存在时返回 nil
+func (s *Suite) describeInstance(ctx context.Context) (*entity.Instance, error) {
+	var resp entity.DescribeInstancesResponse
+	if err := s.client.Call(ctx, "describe-instances", &entity.DescribeInstancesRequest{
+		NodeName:    s.cfg.NodeName,
+		InstanceIDs: []string{s.instanceID},
+		Include:     []string{entity.InstanceIncludeInterfaces, entity.InstanceIncludeDisks},
+	}, &resp); err != nil {
+		return nil, err
+	}
+	for i := range resp.Instances {
+		if resp.Instances[i].ID == s.instanceID {
+			return &resp.Instances[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// poll 按间隔重复执行 fn 直到返回 true 或超时
+func (s *Suite) poll(ctx context.Context, what string, fn func() (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.BootTimeout)
+	defer cancel()
+
+	var lastErr error
+	for {
+		done, err := fn()
+		if done {
+			return nil
+		}
+		lastErr = err
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("timeout waiting for %s: %w", what, lastErr)
+			}
+			return fmt.Errorf("timeout waiting for %s", what)
+		case <-time.After(s.cfg.PollInterval):
+		}
+	}
+}
+
+func (s *Suite) waitRunning(ctx context.Context) error {
+	return s.poll(ctx, "instance running", func() (bool, error) {
+		instance, err := s.describeInstance(ctx)
+		if err != nil {
+			return false, err
+		}
+		if instance == nil {
+			return false, fmt.Errorf("instance %s not found", s.instanceID)
+		}
+		if instance.State != "running" {
+			return false, fmt.Errorf("instance state is %s", instance.State)
+		}
+		return true, nil
+	})
+}
+
+// verifyUserData 校验 cidata 中注入了密钥对的公钥
+func (s *Suite) verifyUserData(ctx context.Context) error {
+	var resp entity.DescribeInstanceAttributeResponse
+	if err := s.client.Call(ctx, "describe-instance-attribute", &entity.DescribeInstanceAttributeRequest{
+		NodeName:   s.cfg.NodeName,
+		InstanceID: s.instanceID,
+		Attribute:  entity.InstanceAttributeUserData,
+	}, &resp); err != nil {
+		return err
+	}
+	if resp.UserData == nil {
+		return fmt.Errorf("instance has no user-data")
+	}
+	if !strings.Contains(*resp.UserData, s.publicKey) {
+		return fmt.Errorf("user-data does not contain the public key of keypair %s", s.keyPairID)
+	}
+	return nil
+}
+
+// ssh 等待 guest 获取 IP 并通过 SSH 登录执行命令
+func (s *Suite) ssh(ctx context.Context) error {
+	if s.cfg.Mode == ModeFake {
+		return errSkipped
+	}
+	if _, err := exec.LookPath("ssh"); err != nil {
+		return fmt.Errorf("ssh client not found: %w", err)
+	}
+
+	if err := s.poll(ctx, "instance IP", func() (bool, error) {
+		instance, err := s.describeInstance(ctx)
+		if err != nil {
+			return false, err
+		}
+		if instance == nil {
+			return false, fmt.Errorf("instance %s not found", s.instanceID)
+		}
+		for _, iface := range instance.Interfaces {
+			if len(iface.IPs) > 0 {
+				s.ip = iface.IPs[0]
+				return true, nil
+			}
+		}
+		return false, nil
+	}); err != nil {
+		return err
+	}
+
+	return s.poll(ctx, "ssh", func() (bool, error) {
+		cmd := exec.CommandContext(ctx, "ssh",
+			"-i", s.keyFile,
+			"-o", "StrictHostKeyChecking=no",
+			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", "BatchMode=yes",
+			"-o", "ConnectTimeout=10",
+			fmt.Sprintf("%s@%s", s.cfg.SSHUser, s.ip),
+			"cloud-init status --wait >/dev/null 2>&1; hostname",
+		)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return false, fmt.Errorf("ssh %s: %w: %s", s.ip, err, strings.TrimSpace(string(output)))
+		}
+		return true, nil
+	})
+}
+
+func (s *Suite) terminate(ctx context.Context) error {
+	var resp entity.TerminateInstancesResponse
+	if err := s.client.Call(ctx, "terminate-instances", &entity.TerminateInstancesRequest{
+		NodeName:      s.cfg.NodeName,
+		InstanceIDs:   []string{s.instanceID},
+		DeleteVolumes: true,
+	}, &resp); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Suite) verifyTerminated(ctx context.Context) error {
+	return s.poll(ctx, "instance terminated", func() (bool, error) {
+		instance, err := s.describeInstance(ctx)
+		if err != nil {
+			return false, err
+		}
+		return instance == nil, nil
+	})
+}
+
+// cleanup 尽力清理套件创建的资源（未被 Terminate 的实例、密钥对、fake node），汇总返回所有错误
+func (s *Suite) cleanup(ctx context.Context) error {
+	var errs []error
+
+	if s.instanceID != "" {
+		if instance, err := s.describeInstance(ctx); err == nil && instance != nil {
+			if err := s.client.Call(ctx, "terminate-instances", &entity.TerminateInstancesRequest{
+				NodeName:      s.cfg.NodeName,
+				InstanceIDs:   []string{s.instanceID},
+				DeleteVolumes: true,
+			}, nil); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if s.keyPairID != "" {
+		if err := s.client.Call(ctx, "delete-keypair", &entity.DeleteKeyPairRequest{KeyPairID: s.keyPairID}, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if s.keyFile != "" {
+		_ = os.RemoveAll(filepath.Dir(s.keyFile))
+	}
+
+	if s.cfg.Mode == ModeFake {
+		if err := s.client.Call(ctx, "delete-storage-pool", &entity.DeleteStoragePoolRequest{
+			NodeName:      s.cfg.NodeName,
+			PoolName:      s.cfg.PoolName,
+			DeleteVolumes: true,
+		}, nil); err != nil {
+			errs = append(errs, err)
+		}
+		if err := s.client.Call(ctx, "delete-node", &api.DeleteNodeRequest{Name: s.cfg.NodeName}, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -3,11 +3,15 @@ package api
 
 import (
 	"context"
+	"crypto/subtle"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jimyag/jvp/internal/jvp/config"
 	"github.com/jimyag/jvp/internal/jvp/service"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/ginx"
 	"github.com/rs/zerolog/log"
 )
 
@@ -15,17 +19,26 @@ type API struct {
 	engine *gin.Engine
 	server *http.Server
 
-	node        *NodeAPI
-	instance    *Instance
-	volume      *Volume
-	keypair     *KeyPair
-	consoleWS   *ConsoleWS
-	storagePool *StoragePoolAPI
-	template    *Template
-	snapshot    *Snapshot
-	network     *NetworkAPI
-	bridge      *BridgeAPI
-	frontendFS  http.FileSystem
+	node          *NodeAPI
+	instance      *Instance
+	volume        *Volume
+	keypair       *KeyPair
+	securityGroup *SecurityGroupAPI
+	consoleWS     *ConsoleWS
+	storagePool   *StoragePoolAPI
+	template      *Template
+	snapshot      *Snapshot
+	network       *NetworkAPI
+	bridge        *BridgeAPI
+	recycleBin    *RecycleBinAPI
+	quota         *QuotaAPI
+	usage         *UsageAPI
+	scheduler     *SchedulerAPI
+	task          *TaskAPI
+	tag           *TagAPI
+	errors        *ErrorsAPI
+	readOnly      *ReadOnlyAPI
+	frontendFS    http.FileSystem
 }
 
 func New(
@@ -33,11 +46,19 @@ func New(
 	instanceService *service.InstanceService,
 	volumeService *service.VolumeService,
 	keyPairService *service.KeyPairService,
+	securityGroupService *service.SecurityGroupService,
 	storagePoolService *service.StoragePoolService,
 	templateService *service.TemplateService,
 	snapshotService *service.SnapshotService,
 	networkService *service.NetworkService,
 	bridgeService *service.BridgeService,
+	recycleBinService *service.RecycleBinService,
+	quotaService *service.QuotaService,
+	usageService *service.UsageService,
+	scheduler *service.Scheduler,
+	taskService *service.TaskService,
+	tagService *service.TagService,
+	readOnlyMode *service.ReadOnlyMode,
 	cfg *config.Config,
 ) (*API, error) {
 	// 先禁用 Gin 的 debug 路由输出（避免打印带函数名的路由信息）
@@ -45,41 +66,117 @@ func New(
 	gin.SetMode(gin.ReleaseMode)
 
 	engine := gin.Default()
+	// handler 直接把 gin.Context 作为 context 传给 service，需要回退到请求上下文才能取到 span 与 logger
+	engine.ContextWithFallback = true
 	api := &API{
-		engine:      engine,
-		node:        NewNodeAPI(nodeService),
-		instance:    NewInstance(instanceService),
-		volume:      NewVolume(volumeService),
-		keypair:     NewKeyPair(keyPairService),
-		consoleWS:   NewConsoleWS(instanceService),
-		storagePool: NewStoragePoolAPI(storagePoolService),
-		template:    NewTemplate(templateService),
-		snapshot:    NewSnapshot(snapshotService),
-		network:     NewNetworkAPI(networkService),
-		bridge:      NewBridgeAPI(bridgeService),
+		engine:        engine,
+		node:          NewNodeAPI(nodeService),
+		instance:      NewInstance(instanceService),
+		volume:        NewVolume(volumeService),
+		keypair:       NewKeyPair(keyPairService),
+		securityGroup: NewSecurityGroupAPI(securityGroupService),
+		consoleWS:     NewConsoleWS(instanceService),
+		storagePool:   NewStoragePoolAPI(storagePoolService),
+		template:      NewTemplate(templateService),
+		snapshot:      NewSnapshot(snapshotService),
+		network:       NewNetworkAPI(networkService),
+		bridge:        NewBridgeAPI(bridgeService),
+		recycleBin:    NewRecycleBinAPI(recycleBinService),
+		quota:         NewQuotaAPI(quotaService),
+		usage:         NewUsageAPI(usageService),
+		scheduler:     NewSchedulerAPI(scheduler),
+		task:          NewTaskAPI(taskService),
+		tag:           NewTagAPI(tagService),
+		errors:        NewErrorsAPI(),
+		readOnly:      NewReadOnlyAPI(readOnlyMode),
 	}
 
 	apiGroup := engine.Group("/api")
+	apiGroup.Use(ginx.ResponseMiddleware(cfg.ResponseEnvelope), tracingMiddleware, operatorMiddleware, tenantMiddleware(cfg.AdminToken), readOnlyMiddleware(readOnlyMode), conditionalMiddleware)
 	api.node.RegisterRoutes(apiGroup)
 	api.instance.RegisterRoutes(apiGroup)
 	api.volume.RegisterRoutes(apiGroup)
 	api.keypair.RegisterRoutes(apiGroup)
+	api.securityGroup.RegisterRoutes(apiGroup)
 	api.consoleWS.RegisterRoutes(apiGroup)
 	api.storagePool.RegisterRoutes(apiGroup)
 	api.template.RegisterRoutes(apiGroup)
 	api.snapshot.RegisterRoutes(apiGroup)
 	api.network.RegisterRoutes(apiGroup)
 	api.bridge.RegisterRoutes(apiGroup)
+	api.recycleBin.RegisterRoutes(apiGroup)
+	api.quota.RegisterRoutes(apiGroup)
+	api.usage.RegisterRoutes(apiGroup)
+	api.scheduler.RegisterRoutes(apiGroup)
+	api.task.RegisterRoutes(apiGroup)
+	api.tag.RegisterRoutes(apiGroup)
+	api.errors.RegisterRoutes(apiGroup)
+	api.readOnly.RegisterRoutes(apiGroup)
 	api.mountFrontend()
 
 	api.server = &http.Server{
 		Addr:    cfg.Address,
 		Handler: engine,
 	}
+	if cfg.AdminToken == "" {
+		log.Warn().Msg("JVP_ADMIN_TOKEN is not set, " + tenantHeader + " is not authenticated and requests without it have admin scope")
+	}
 	log.Info().Str("address", cfg.Address).Msg("API server configured")
 	return api, nil
 }
 
+// operatorHeader 调用方声明操作者身份的请求头
+const operatorHeader = "X-JVP-Operator"
+
+// operatorMiddleware 记录请求的操作者（用于实例事件历史），未声明时使用客户端 IP
+func operatorMiddleware(c *gin.Context) {
+	operator := strings.TrimSpace(c.GetHeader(operatorHeader))
+	if operator == "" {
+		operator = c.ClientIP()
+	}
+	c.Set(service.OperatorContextKey, operator)
+	c.Next()
+}
+
+// tenantHeader 调用方声明所属租户的请求头
+const tenantHeader = "X-JVP-Tenant"
+
+// tenantMiddleware 记录请求的租户（用于模板可见性、配额等租户隔离）
+// 未配置管理员凭据时未声明租户的请求按管理员处理；配置后只有带正确凭据的请求是管理员，
+// 其余请求必须声明租户。管理员也可以声明租户，以该租户的身份操作
+func tenantMiddleware(adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenant := strings.TrimSpace(c.GetHeader(tenantHeader))
+		if adminToken != "" {
+			token, ok := bearerToken(c.GetHeader("Authorization"))
+			switch {
+			case ok && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1:
+				ginx.AbortWithError(c, apierror.NewErrorWithStatus("AuthFailure",
+					"the admin token is invalid", http.StatusUnauthorized))
+				return
+			case !ok && tenant == "":
+				ginx.AbortWithError(c, apierror.NewErrorWithStatus("AuthFailure",
+					"the request must declare "+tenantHeader+" or carry the admin token", http.StatusUnauthorized))
+				return
+			}
+		}
+		if tenant != "" {
+			c.Set(service.TenantContextKey, tenant)
+		}
+		c.Next()
+	}
+}
+
+// bearerToken 解析 Authorization: Bearer <token> 请求头
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
 func (a *API) Run(ctx context.Context) error {
 	errCh := make(chan error, 1)
 	go func() {
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimyag/jvp/internal/jvp/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// tenantEngine 只挂载 tenantMiddleware，响应体为请求的租户，管理员为 admin
+func tenantEngine(adminToken string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(tenantMiddleware(adminToken))
+	engine.GET("/tenant", func(c *gin.Context) {
+		tenant := c.GetString(service.TenantContextKey)
+		if tenant == "" {
+			tenant = "admin"
+		}
+		c.String(http.StatusOK, tenant)
+	})
+	return engine
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		adminToken    string
+		tenant        string
+		authorization string
+		wantStatus    int
+		wantTenant    string
+	}{
+		{name: "no token configured, no tenant", wantStatus: http.StatusOK, wantTenant: "admin"},
+		{name: "no token configured, tenant", tenant: "team-a", wantStatus: http.StatusOK, wantTenant: "team-a"},
+		{name: "no tenant, no credential", adminToken: "secret", wantStatus: http.StatusUnauthorized},
+		{name: "tenant", adminToken: "secret", tenant: "team-a", wantStatus: http.StatusOK, wantTenant: "team-a"},
+		{name: "admin", adminToken: "secret", authorization: "Bearer secret", wantStatus: http.StatusOK, wantTenant: "admin"},
+		{name: "admin on behalf of tenant", adminToken: "secret", tenant: "team-a", authorization: "bearer secret", wantStatus: http.StatusOK, wantTenant: "team-a"},
+		{name: "wrong token", adminToken: "secret", authorization: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token with tenant", adminToken: "secret", tenant: "team-a", authorization: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{name: "not a bearer token", adminToken: "secret", authorization: "Basic secret", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tenant", nil)
+			if tt.tenant != "" {
+				req.Header.Set(tenantHeader, tt.tenant)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			tenantEngine(tt.adminToken).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.wantTenant, rec.Body.String())
+			}
+		})
+	}
+}
@@ -51,7 +51,7 @@ func (a *BridgeAPI) ListBridges(ctx *gin.Context, req *entity.ListBridgesRequest
 
 // CreateBridge 创建网桥
 func (a *BridgeAPI) CreateBridge(ctx *gin.Context, req *entity.CreateBridgeRequest) (*entity.CreateBridgeResponse, error) {
-	bridge, err := a.bridgeService.CreateBridge(ctx.Request.Context(), req)
+	bridge, err := a.bridgeService.CreateBridge(service.WithDryRun(ctx.Request.Context(), req.DryRun), req)
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +63,7 @@ func (a *BridgeAPI) CreateBridge(ctx *gin.Context, req *entity.CreateBridgeReque
 
 // DeleteBridge 删除网桥
 func (a *BridgeAPI) DeleteBridge(ctx *gin.Context, req *entity.DeleteBridgeRequest) (*entity.DeleteBridgeResponse, error) {
-	if err := a.bridgeService.DeleteBridge(ctx.Request.Context(), req.NodeName, req.BridgeName); err != nil {
+	if err := a.bridgeService.DeleteBridge(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.NodeName, req.BridgeName); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimyag/jvp/internal/jvp/service"
+	"github.com/jimyag/jvp/pkg/ginx"
+)
+
+// readActionPrefixes 只读接口的动作前缀，这些接口的响应带 ETag 并支持 If-None-Match
+var readActionPrefixes = []string{"describe-", "list-", "get-"}
+
+// isReadAction 判断请求路径是否为只读接口
+func isReadAction(urlPath string) bool {
+	action := path.Base(urlPath)
+	for _, prefix := range readActionPrefixes {
+		if strings.HasPrefix(action, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionalMiddleware 处理条件请求
+// 修改接口的 If-Match 写入上下文，由 service 与资源版本号比较，不一致返回 412 PreconditionFailed；
+// 只读接口根据响应内容生成 ETag，If-None-Match 命中时返回 304 且不带响应体
+func conditionalMiddleware(c *gin.Context) {
+	if ifMatch := strings.TrimSpace(c.GetHeader("If-Match")); ifMatch != "" {
+		c.Set(service.IfMatchContextKey, trimETag(ifMatch))
+	}
+
+	if !isReadAction(c.Request.URL.Path) {
+		c.Next()
+		return
+	}
+
+	writer := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+	c.Writer = writer
+	c.Next()
+	c.Writer = writer.ResponseWriter
+
+	if writer.status != http.StatusOK {
+		writer.flush()
+		return
+	}
+	// 开启 envelope 时请求 ID 每次都不同，按去掉请求 ID 的内容计算
+	body := ginx.StableBody(c)
+	if body == nil {
+		body = writer.body.Bytes()
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		writer.ResponseWriter.WriteHeader(http.StatusNotModified)
+		writer.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	writer.flush()
+}
+
+// trimETag 去掉 ETag 的弱校验前缀与引号，得到版本号
+func trimETag(etag string) string {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	return strings.Trim(etag, `"`)
+}
+
+// etagMatches 判断 If-None-Match（可能包含多个以逗号分隔的 ETag）是否命中当前 ETag
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || trimETag(candidate) == trimETag(etag) {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedWriter 缓存只读接口的响应，待计算 ETag 后再写出
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+// flush 把缓存的状态码与响应体写到底层 ResponseWriter
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.WriteHeaderNow()
+	_, _ = w.ResponseWriter.Write(w.body.Bytes())
+}
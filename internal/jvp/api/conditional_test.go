@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimyag/jvp/pkg/ginx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type probeResponse struct {
+	Name string `json:"name"`
+}
+
+// conditionalEngine 挂载 ResponseMiddleware 与 conditionalMiddleware，describe-probe 返回固定内容
+func conditionalEngine(envelope bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(ginx.ResponseMiddleware(envelope), conditionalMiddleware)
+	engine.POST("/api/describe-probe", ginx.Adapt2(func(c *gin.Context) *probeResponse {
+		return &probeResponse{Name: "probe"}
+	}))
+	return engine
+}
+
+func TestConditionalETagStable(t *testing.T) {
+	for _, envelope := range []bool{false, true} {
+		engine := conditionalEngine(envelope)
+
+		first := httptest.NewRecorder()
+		engine.ServeHTTP(first, httptest.NewRequest(http.MethodPost, "/api/describe-probe", nil))
+		require.Equal(t, http.StatusOK, first.Code)
+		etag := first.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		if envelope {
+			assert.Contains(t, first.Body.String(), `"request_id"`)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/api/describe-probe", nil)
+		req.Header.Set("If-None-Match", etag)
+		second := httptest.NewRecorder()
+		engine.ServeHTTP(second, req)
+		assert.Equal(t, http.StatusNotModified, second.Code, "envelope=%v", envelope)
+		assert.Equal(t, etag, second.Header().Get("ETag"), "envelope=%v", envelope)
+		assert.Empty(t, second.Body.String())
+	}
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/ginx"
+)
+
+// ErrorsAPI 错误目录文档 API
+type ErrorsAPI struct{}
+
+// NewErrorsAPI 创建错误目录文档 API
+func NewErrorsAPI() *ErrorsAPI {
+	return &ErrorsAPI{}
+}
+
+// RegisterRoutes 注册路由
+func (a *ErrorsAPI) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/errors", ginx.Adapt2(a.ListErrorCodes))
+}
+
+// ListErrorCodes 返回机器可读的错误目录
+func (a *ErrorsAPI) ListErrorCodes(ctx *gin.Context) *entity.ListErrorCodesResponse {
+	return &entity.ListErrorCodesResponse{
+		ErrorCodes: apierror.ListErrorCodes(),
+	}
+}
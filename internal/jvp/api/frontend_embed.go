@@ -9,9 +9,24 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// static/ 由 task build 从 web-vite/dist 复制而来，仓库中只保留 .gitkeep，
+// 保证未构建前端时 go build 依然可用
+//
 //go:embed static/*
 var embeddedWeb embed.FS
 
+// frontendPlaceholder 未打包前端时返回的提示页面
+const frontendPlaceholder = `<!doctype html>
+<html lang="en">
+<head><meta charset="utf-8"><title>JVP</title></head>
+<body style="font-family: sans-serif; margin: 3em;">
+<h1>JVP</h1>
+<p>The web UI is not bundled in this binary. The API is available under <code>/api</code>.</p>
+<p>Build the binary with <code>task build</code> to embed the web UI.</p>
+</body>
+</html>
+`
+
 func newFrontendFS() http.FileSystem {
 	sub, err := fs.Sub(embeddedWeb, "static")
 	if err != nil {
@@ -51,7 +66,9 @@ func serveFrontend(frontendFS http.FileSystem) http.HandlerFunc {
 			}
 		}
 
-		http.NotFound(w, r)
+		// 未打包前端（如直接 go build），返回提示页面
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(frontendPlaceholder))
 	}
 }
 
@@ -2,6 +2,10 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jimyag/jvp/internal/jvp/entity"
@@ -18,9 +22,40 @@ type InstanceServiceInterface interface {
 	StopInstances(ctx context.Context, req *entity.StopInstancesRequest) ([]entity.InstanceStateChange, error)
 	StartInstances(ctx context.Context, req *entity.StartInstancesRequest) ([]entity.InstanceStateChange, error)
 	RebootInstances(ctx context.Context, req *entity.RebootInstancesRequest) ([]entity.InstanceStateChange, error)
-	ModifyInstanceAttribute(ctx context.Context, req *entity.ModifyInstanceAttributeRequest) (*entity.Instance, error)
+	RestoreInstance(ctx context.Context, req *entity.RestoreInstanceRequest) (*entity.RestoreInstanceResponse, error)
+	ModifyInstanceAttribute(ctx context.Context, req *entity.ModifyInstanceAttributeRequest) (*entity.ModifyInstanceAttributeResponse, error)
+	DescribeInstanceAttribute(ctx context.Context, req *entity.DescribeInstanceAttributeRequest) (*entity.DescribeInstanceAttributeResponse, error)
+	DescribeInstanceSSHTarget(ctx context.Context, req *entity.DescribeInstanceSSHTargetRequest) (*entity.InstanceSSHTarget, error)
+	DescribeAppTemplates(ctx context.Context, req *entity.DescribeAppTemplatesRequest) (*entity.DescribeAppTemplatesResponse, error)
+	DescribeInstanceQMP(ctx context.Context, req *entity.DescribeInstanceQMPRequest) (*entity.DescribeInstanceQMPResponse, error)
+	DescribeInstanceEvents(ctx context.Context, req *entity.DescribeInstanceEventsRequest) (*entity.DescribeInstanceEventsResponse, error)
+	GetInstanceOperationLogs(ctx context.Context, req *entity.GetInstanceOperationLogsRequest) (*entity.GetInstanceOperationLogsResponse, error)
 	ResetPassword(ctx context.Context, req *entity.ResetPasswordRequest) (*entity.ResetPasswordResponse, error)
 	GetConsoleInfo(ctx context.Context, req *entity.GetConsoleRequest) (*entity.GetConsoleResponse, error)
+	GetConsoleOutput(ctx context.Context, req *entity.GetConsoleOutputRequest) (*entity.GetConsoleOutputResponse, error)
+	CompleteInstanceInstall(ctx context.Context, req *entity.CompleteInstanceInstallRequest) (*entity.Instance, error)
+	FlattenInstanceDisk(ctx context.Context, req *entity.FlattenInstanceDiskRequest) (*entity.FlattenInstanceDiskResponse, error)
+	MigrateInstanceStorage(ctx context.Context, req *entity.MigrateInstanceStorageRequest) (*entity.MigrateInstanceStorageResponse, error)
+	ModifyDiskJobBandwidth(ctx context.Context, req *entity.ModifyDiskJobBandwidthRequest) (*entity.ModifyDiskJobBandwidthResponse, error)
+	ModifyInstanceNetworkBandwidth(ctx context.Context, req *entity.ModifyInstanceNetworkBandwidthRequest) (*entity.ModifyInstanceNetworkBandwidthResponse, error)
+	UpdateInstanceDevice(ctx context.Context, req *entity.UpdateInstanceDeviceRequest) (*entity.UpdateInstanceDeviceResponse, error)
+	AttachHostBlockDevice(ctx context.Context, req *entity.AttachHostBlockDeviceRequest) (*entity.AttachHostBlockDeviceResponse, error)
+	DetachHostBlockDevice(ctx context.Context, req *entity.DetachHostBlockDeviceRequest) (*entity.DetachHostBlockDeviceResponse, error)
+	ModifyInstanceCPUTune(ctx context.Context, req *entity.ModifyInstanceCPUTuneRequest) (*entity.ModifyInstanceCPUTuneResponse, error)
+	ModifyInstanceBlkioTune(ctx context.Context, req *entity.ModifyInstanceBlkioTuneRequest) (*entity.ModifyInstanceBlkioTuneResponse, error)
+	FreezeInstanceFS(ctx context.Context, req *entity.FreezeInstanceFSRequest) (*entity.FreezeInstanceFSResponse, error)
+	ThawInstanceFS(ctx context.Context, req *entity.ThawInstanceFSRequest) (*entity.ThawInstanceFSResponse, error)
+	ExportInstance(ctx context.Context, req *entity.ExportInstanceRequest) (*entity.InstanceExport, error)
+	OpenInstanceExport(ctx context.Context, nodeName, poolName, exportID string) (*service.InstanceExportDownload, error)
+	DeleteInstanceExport(ctx context.Context, req *entity.DeleteInstanceExportRequest) error
+	CreateInstanceImport(ctx context.Context, req *entity.CreateInstanceImportRequest) (*entity.InstanceImport, error)
+	UploadInstanceImportPart(ctx context.Context, req *entity.UploadInstanceImportPartRequest, body io.Reader) (int64, error)
+	CompleteInstanceImport(ctx context.Context, req *entity.CompleteInstanceImportRequest) (*entity.Instance, error)
+	AbortInstanceImport(ctx context.Context, req *entity.AbortInstanceImportRequest) error
+	RunInstanceGroupAction(ctx context.Context, req *entity.RunInstanceGroupActionRequest) (*entity.RunInstanceGroupActionResponse, error)
+	CreateV2VTask(ctx context.Context, req *entity.CreateV2VTaskRequest) (*entity.V2VTask, error)
+	GetV2VTask(ctx context.Context, taskID string) (*entity.V2VTask, error)
+	ListV2VTasks(ctx context.Context, req *entity.ListV2VTasksRequest) []*entity.V2VTask
 }
 
 type Instance struct {
@@ -40,9 +75,40 @@ func (i *Instance) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/stop-instances", ginx.Adapt5(i.StopInstances))
 	router.POST("/start-instances", ginx.Adapt5(i.StartInstances))
 	router.POST("/reboot-instances", ginx.Adapt5(i.RebootInstances))
+	router.POST("/run-instance-group-action", ginx.Adapt5(i.RunInstanceGroupAction))
+	router.POST("/restore-instance", ginx.Adapt5(i.RestoreInstance))
 	router.POST("/modify-instance-attribute", ginx.Adapt5(i.ModifyInstanceAttribute))
+	router.POST("/describe-instance-attribute", ginx.Adapt5(i.DescribeInstanceAttribute))
+	router.POST("/describe-instance-ssh-target", ginx.Adapt5(i.DescribeInstanceSSHTarget))
+	router.POST("/describe-app-templates", ginx.Adapt5(i.DescribeAppTemplates))
+	router.POST("/describe-instance-qmp", ginx.Adapt5(i.DescribeInstanceQMP))
+	router.POST("/describe-instance-events", ginx.Adapt5(i.DescribeInstanceEvents))
+	router.POST("/get-instance-operation-logs", ginx.Adapt5(i.GetInstanceOperationLogs))
+	router.POST("/complete-instance-install", ginx.Adapt5(i.CompleteInstanceInstall))
+	router.POST("/flatten-instance-disk", ginx.Adapt5(i.FlattenInstanceDisk))
+	router.POST("/migrate-instance-storage", ginx.Adapt5(i.MigrateInstanceStorage))
+	router.POST("/modify-disk-job-bandwidth", ginx.Adapt5(i.ModifyDiskJobBandwidth))
+	router.POST("/modify-instance-network-bandwidth", ginx.Adapt5(i.ModifyInstanceNetworkBandwidth))
+	router.POST("/update-instance-device", ginx.Adapt5(i.UpdateInstanceDevice))
+	router.POST("/attach-host-block-device", ginx.Adapt5(i.AttachHostBlockDevice))
+	router.POST("/detach-host-block-device", ginx.Adapt5(i.DetachHostBlockDevice))
+	router.POST("/modify-instance-cpu-tune", ginx.Adapt5(i.ModifyInstanceCPUTune))
+	router.POST("/modify-instance-blkio-tune", ginx.Adapt5(i.ModifyInstanceBlkioTune))
+	router.POST("/freeze-instance-fs", ginx.Adapt5(i.FreezeInstanceFS))
+	router.POST("/thaw-instance-fs", ginx.Adapt5(i.ThawInstanceFS))
 	router.POST("/reset-instance-password", ginx.Adapt5(i.ResetPassword))
 	router.POST("/get-instance-console", ginx.Adapt5(i.GetConsole))
+	router.POST("/get-console-output", ginx.Adapt5(i.GetConsoleOutput))
+	router.POST("/export-instance", ginx.Adapt5(i.ExportInstance))
+	router.GET("/download-instance-export/:node_name/:pool_name/:export_id", ginx.AdaptStream(i.DownloadInstanceExport))
+	router.POST("/delete-instance-export", ginx.Adapt5(i.DeleteInstanceExport))
+	router.POST("/create-instance-import", ginx.Adapt5(i.CreateInstanceImport))
+	router.PUT("/upload-instance-import-part/:node_name/:pool_name/:import_id/:part_number", ginx.AdaptUpload(i.UploadInstanceImportPart))
+	router.POST("/complete-instance-import", ginx.Adapt5(i.CompleteInstanceImport))
+	router.POST("/abort-instance-import", ginx.Adapt5(i.AbortInstanceImport))
+	router.POST("/create-v2v-task", ginx.Adapt5(i.CreateV2VTask))
+	router.POST("/get-v2v-task", ginx.Adapt5(i.GetV2VTask))
+	router.POST("/list-v2v-tasks", ginx.Adapt5(i.ListV2VTasks))
 }
 
 func (i *Instance) RunInstances(ctx *gin.Context, req *entity.RunInstanceRequest) (*entity.RunInstanceResponse, error) {
@@ -52,7 +118,7 @@ func (i *Instance) RunInstances(ctx *gin.Context, req *entity.RunInstanceRequest
 		Msg("RunInstances called")
 
 	// 调用 Instance Service 创建实例
-	instance, err := i.instanceService.RunInstance(ctx, req)
+	instance, err := i.instanceService.RunInstance(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -98,7 +164,7 @@ func (i *Instance) TerminateInstances(ctx *gin.Context, req *entity.TerminateIns
 		Strs("instanceIDs", req.InstanceIDs).
 		Msg("TerminateInstances called")
 
-	changes, err := i.instanceService.TerminateInstances(ctx, req)
+	changes, err := i.instanceService.TerminateInstances(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -122,7 +188,7 @@ func (i *Instance) StopInstances(ctx *gin.Context, req *entity.StopInstancesRequ
 		Bool("force", req.Force).
 		Msg("StopInstances called")
 
-	changes, err := i.instanceService.StopInstances(ctx, req)
+	changes, err := i.instanceService.StopInstances(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -145,7 +211,7 @@ func (i *Instance) StartInstances(ctx *gin.Context, req *entity.StartInstancesRe
 		Strs("instanceIDs", req.InstanceIDs).
 		Msg("StartInstances called")
 
-	changes, err := i.instanceService.StartInstances(ctx, req)
+	changes, err := i.instanceService.StartInstances(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -168,7 +234,7 @@ func (i *Instance) RebootInstances(ctx *gin.Context, req *entity.RebootInstances
 		Strs("instanceIDs", req.InstanceIDs).
 		Msg("RebootInstances called")
 
-	changes, err := i.instanceService.RebootInstances(ctx, req)
+	changes, err := i.instanceService.RebootInstances(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -185,6 +251,44 @@ func (i *Instance) RebootInstances(ctx *gin.Context, req *entity.RebootInstances
 	}, nil
 }
 
+func (i *Instance) RunInstanceGroupAction(ctx *gin.Context, req *entity.RunInstanceGroupActionRequest) (*entity.RunInstanceGroupActionResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("action", req.Action).
+		Msg("RunInstanceGroupAction called")
+
+	resp, err := i.instanceService.RunInstanceGroupAction(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to run instance group action")
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (i *Instance) RestoreInstance(ctx *gin.Context, req *entity.RestoreInstanceRequest) (*entity.RestoreInstanceResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Msg("RestoreInstance called")
+
+	response, err := i.instanceService.RestoreInstance(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to restore instance")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Msg("Instance restored successfully")
+
+	return response, nil
+}
+
 func (i *Instance) ModifyInstanceAttribute(ctx *gin.Context, req *entity.ModifyInstanceAttributeRequest) (*entity.ModifyInstanceAttributeResponse, error) {
 	logger := zerolog.Ctx(ctx)
 	logger.Info().
@@ -192,7 +296,7 @@ func (i *Instance) ModifyInstanceAttribute(ctx *gin.Context, req *entity.ModifyI
 		Interface("request", req).
 		Msg("ModifyInstanceAttribute called")
 
-	instance, err := i.instanceService.ModifyInstanceAttribute(ctx, req)
+	resp, err := i.instanceService.ModifyInstanceAttribute(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -204,11 +308,407 @@ func (i *Instance) ModifyInstanceAttribute(ctx *gin.Context, req *entity.ModifyI
 		Str("instanceID", req.InstanceID).
 		Msg("Instance attribute modified successfully")
 
-	return &entity.ModifyInstanceAttributeResponse{
+	return resp, nil
+}
+
+func (i *Instance) DescribeInstanceSSHTarget(ctx *gin.Context, req *entity.DescribeInstanceSSHTargetRequest) (*entity.DescribeInstanceSSHTargetResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Msg("DescribeInstanceSSHTarget called")
+
+	target, err := i.instanceService.DescribeInstanceSSHTarget(ctx, req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to describe instance SSH target")
+		return nil, err
+	}
+
+	return &entity.DescribeInstanceSSHTargetResponse{
+		Target: target,
+	}, nil
+}
+
+func (i *Instance) DescribeAppTemplates(ctx *gin.Context, req *entity.DescribeAppTemplatesRequest) (*entity.DescribeAppTemplatesResponse, error) {
+	return i.instanceService.DescribeAppTemplates(ctx, req)
+}
+
+func (i *Instance) DescribeInstanceQMP(ctx *gin.Context, req *entity.DescribeInstanceQMPRequest) (*entity.DescribeInstanceQMPResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Str("command", req.Command).
+		Msg("DescribeInstanceQMP called")
+
+	response, err := i.instanceService.DescribeInstanceQMP(ctx, req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to execute QMP command")
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (i *Instance) DescribeInstanceAttribute(ctx *gin.Context, req *entity.DescribeInstanceAttributeRequest) (*entity.DescribeInstanceAttributeResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("attribute", req.Attribute).
+		Msg("DescribeInstanceAttribute called")
+
+	response, err := i.instanceService.DescribeInstanceAttribute(ctx, req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to describe instance attribute")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("attribute", req.Attribute).
+		Msg("Instance attribute described successfully")
+
+	return response, nil
+}
+
+func (i *Instance) DescribeInstanceEvents(ctx *gin.Context, req *entity.DescribeInstanceEventsRequest) (*entity.DescribeInstanceEventsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Strs("eventTypes", req.EventTypes).
+		Msg("DescribeInstanceEvents called")
+
+	response, err := i.instanceService.DescribeInstanceEvents(ctx, req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to describe instance events")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Int("count", len(response.Events)).
+		Msg("Instance events described successfully")
+
+	return response, nil
+}
+
+func (i *Instance) GetInstanceOperationLogs(ctx *gin.Context, req *entity.GetInstanceOperationLogsRequest) (*entity.GetInstanceOperationLogsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Msg("GetInstanceOperationLogs called")
+
+	response, err := i.instanceService.GetInstanceOperationLogs(ctx, req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to get instance operation logs")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Int("count", len(response.Logs)).
+		Msg("Instance operation logs retrieved successfully")
+
+	return response, nil
+}
+
+func (i *Instance) CompleteInstanceInstall(ctx *gin.Context, req *entity.CompleteInstanceInstallRequest) (*entity.CompleteInstanceInstallResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Msg("CompleteInstanceInstall called")
+
+	instance, err := i.instanceService.CompleteInstanceInstall(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to complete instance install")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Msg("Instance install completed successfully")
+
+	return &entity.CompleteInstanceInstallResponse{
 		Instance: instance,
 	}, nil
 }
 
+func (i *Instance) FlattenInstanceDisk(ctx *gin.Context, req *entity.FlattenInstanceDiskRequest) (*entity.FlattenInstanceDiskResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device", req.Device).
+		Str("mode", req.Mode).
+		Msg("FlattenInstanceDisk called")
+
+	response, err := i.instanceService.FlattenInstanceDisk(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to flatten instance disk")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device", response.Device).
+		Str("status", response.Status).
+		Int("progress", response.Progress).
+		Msg("Instance disk flatten in progress or completed")
+
+	return response, nil
+}
+
+func (i *Instance) MigrateInstanceStorage(ctx *gin.Context, req *entity.MigrateInstanceStorageRequest) (*entity.MigrateInstanceStorageResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("target_pool", req.TargetPool).
+		Strs("devices", req.Devices).
+		Msg("MigrateInstanceStorage called")
+
+	response, err := i.instanceService.MigrateInstanceStorage(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to migrate instance storage")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Int("disk_count", len(response.Disks)).
+		Msg("Instance storage migration in progress or completed")
+
+	return response, nil
+}
+
+func (i *Instance) ModifyDiskJobBandwidth(ctx *gin.Context, req *entity.ModifyDiskJobBandwidthRequest) (*entity.ModifyDiskJobBandwidthResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device", req.Device).
+		Uint64("bandwidth_mib", req.BandwidthMiB).
+		Msg("ModifyDiskJobBandwidth called")
+
+	response, err := i.instanceService.ModifyDiskJobBandwidth(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to modify disk job bandwidth")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device", response.Device).
+		Str("job_type", response.JobType).
+		Msg("Disk job bandwidth modified")
+
+	return response, nil
+}
+
+func (i *Instance) ModifyInstanceNetworkBandwidth(ctx *gin.Context, req *entity.ModifyInstanceNetworkBandwidthRequest) (*entity.ModifyInstanceNetworkBandwidthResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("mac", req.MAC).
+		Msg("ModifyInstanceNetworkBandwidth called")
+
+	response, err := i.instanceService.ModifyInstanceNetworkBandwidth(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to modify instance network bandwidth")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("mac", response.MAC).
+		Msg("Instance network bandwidth modified")
+
+	return response, nil
+}
+
+func (i *Instance) UpdateInstanceDevice(ctx *gin.Context, req *entity.UpdateInstanceDeviceRequest) (*entity.UpdateInstanceDeviceResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device_type", req.DeviceType).
+		Str("device", req.Device).
+		Msg("UpdateInstanceDevice called")
+
+	response, err := i.instanceService.UpdateInstanceDevice(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to update instance device")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device", response.Device).
+		Bool("live", response.Live).
+		Msg("Instance device updated")
+
+	return response, nil
+}
+
+func (i *Instance) AttachHostBlockDevice(ctx *gin.Context, req *entity.AttachHostBlockDeviceRequest) (*entity.AttachHostBlockDeviceResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device_path", req.DevicePath).
+		Msg("AttachHostBlockDevice called")
+
+	response, err := i.instanceService.AttachHostBlockDevice(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to attach host block device")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device", response.Device).
+		Msg("Host block device attached")
+
+	return response, nil
+}
+
+func (i *Instance) DetachHostBlockDevice(ctx *gin.Context, req *entity.DetachHostBlockDeviceRequest) (*entity.DetachHostBlockDeviceResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device_path", req.DevicePath).
+		Msg("DetachHostBlockDevice called")
+
+	response, err := i.instanceService.DetachHostBlockDevice(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to detach host block device")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device", response.Device).
+		Msg("Host block device detached")
+
+	return response, nil
+}
+
+func (i *Instance) ModifyInstanceCPUTune(ctx *gin.Context, req *entity.ModifyInstanceCPUTuneRequest) (*entity.ModifyInstanceCPUTuneResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Uint64("shares", req.Shares).
+		Uint64("period", req.Period).
+		Int64("quota", req.Quota).
+		Msg("ModifyInstanceCPUTune called")
+
+	response, err := i.instanceService.ModifyInstanceCPUTune(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to modify instance CPU tune")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Msg("Instance CPU tune modified")
+
+	return response, nil
+}
+
+func (i *Instance) ModifyInstanceBlkioTune(ctx *gin.Context, req *entity.ModifyInstanceBlkioTuneRequest) (*entity.ModifyInstanceBlkioTuneResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Uint("weight", req.Weight).
+		Msg("ModifyInstanceBlkioTune called")
+
+	response, err := i.instanceService.ModifyInstanceBlkioTune(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to modify instance blkio tune")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Uint("weight", req.Weight).
+		Msg("Instance blkio tune modified")
+
+	return response, nil
+}
+
+func (i *Instance) FreezeInstanceFS(ctx *gin.Context, req *entity.FreezeInstanceFSRequest) (*entity.FreezeInstanceFSResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Strs("mountpoints", req.Mountpoints).
+		Int("timeout_seconds", req.TimeoutSeconds).
+		Msg("FreezeInstanceFS called")
+
+	response, err := i.instanceService.FreezeInstanceFS(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to freeze instance file systems")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Int("filesystems", response.Filesystems).
+		Str("thaw_deadline", response.ThawDeadline).
+		Msg("Instance file systems frozen")
+
+	return response, nil
+}
+
+func (i *Instance) ThawInstanceFS(ctx *gin.Context, req *entity.ThawInstanceFSRequest) (*entity.ThawInstanceFSResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Strs("mountpoints", req.Mountpoints).
+		Msg("ThawInstanceFS called")
+
+	response, err := i.instanceService.ThawInstanceFS(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to thaw instance file systems")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Int("filesystems", response.Filesystems).
+		Msg("Instance file systems thawed")
+
+	return response, nil
+}
+
 func (i *Instance) ResetPassword(ctx *gin.Context, req *entity.ResetPasswordRequest) (*entity.ResetPasswordResponse, error) {
 	logger := zerolog.Ctx(ctx)
 	logger.Info().
@@ -217,7 +717,7 @@ func (i *Instance) ResetPassword(ctx *gin.Context, req *entity.ResetPasswordRequ
 		Msg("ResetPassword called")
 
 	// 调用 Instance Service 重置密码
-	response, err := i.instanceService.ResetPassword(ctx, req)
+	response, err := i.instanceService.ResetPassword(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -257,3 +757,212 @@ func (i *Instance) GetConsole(ctx *gin.Context, req *entity.GetConsoleRequest) (
 
 	return response, nil
 }
+
+func (i *Instance) GetConsoleOutput(ctx *gin.Context, req *entity.GetConsoleOutputRequest) (*entity.GetConsoleOutputResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instance_id", req.InstanceID).
+		Int64("max_bytes", req.MaxBytes).
+		Msg("Getting instance console output")
+
+	response, err := i.instanceService.GetConsoleOutput(ctx, req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Str("instance_id", req.InstanceID).
+			Msg("Failed to get console output")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instance_id", req.InstanceID).
+		Bool("truncated", response.Truncated).
+		Msg("Console output retrieved successfully")
+
+	return response, nil
+}
+
+func (i *Instance) ExportInstance(ctx *gin.Context, req *entity.ExportInstanceRequest) (*entity.ExportInstanceResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("format", req.Format).
+		Str("snapshot_name", req.SnapshotName).
+		Msg("ExportInstance called")
+
+	export, err := i.instanceService.ExportInstance(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to export instance")
+		return nil, err
+	}
+
+	return &entity.ExportInstanceResponse{
+		Export: export,
+	}, nil
+}
+
+func (i *Instance) DownloadInstanceExport(ctx *gin.Context, req *entity.DownloadInstanceExportRequest) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("export_id", req.ExportID).
+		Msg("DownloadInstanceExport called")
+
+	download, err := i.instanceService.OpenInstanceExport(ctx, req.NodeName, req.PoolName, req.ExportID)
+	if err != nil {
+		return err
+	}
+
+	ctx.Header("Content-Type", "application/octet-stream")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", download.FileName))
+	ctx.Header("Content-Length", strconv.FormatInt(download.SizeB, 10))
+	ctx.Status(http.StatusOK)
+	if err := download.Stream(ctx.Writer); err != nil {
+		logger.Error().
+			Err(err).
+			Str("export_id", req.ExportID).
+			Msg("Failed to stream instance export")
+		return err
+	}
+	return nil
+}
+
+func (i *Instance) DeleteInstanceExport(ctx *gin.Context, req *entity.DeleteInstanceExportRequest) (*entity.DeleteInstanceExportResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("export_id", req.ExportID).
+		Msg("DeleteInstanceExport called")
+
+	if err := i.instanceService.DeleteInstanceExport(service.WithDryRun(ctx, req.DryRun), req); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to delete instance export")
+		return nil, err
+	}
+
+	return &entity.DeleteInstanceExportResponse{
+		Message: "Instance export deleted successfully",
+	}, nil
+}
+
+func (i *Instance) CreateInstanceImport(ctx *gin.Context, req *entity.CreateInstanceImportRequest) (*entity.CreateInstanceImportResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Msg("CreateInstanceImport called")
+
+	instanceImport, err := i.instanceService.CreateInstanceImport(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to create instance import")
+		return nil, err
+	}
+
+	return &entity.CreateInstanceImportResponse{
+		Import: instanceImport,
+	}, nil
+}
+
+func (i *Instance) UploadInstanceImportPart(ctx *gin.Context, req *entity.UploadInstanceImportPartRequest) (*entity.UploadInstanceImportPartResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("import_id", req.ImportID).
+		Int("part_number", req.PartNumber).
+		Msg("UploadInstanceImportPart called")
+
+	size, err := i.instanceService.UploadInstanceImportPart(service.WithDryRun(ctx, req.DryRun), req, ctx.Request.Body)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to upload instance import part")
+		return nil, err
+	}
+
+	return &entity.UploadInstanceImportPartResponse{
+		PartNumber: req.PartNumber,
+		SizeB:      size,
+	}, nil
+}
+
+func (i *Instance) CompleteInstanceImport(ctx *gin.Context, req *entity.CompleteInstanceImportRequest) (*entity.CompleteInstanceImportResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("import_id", req.ImportID).
+		Str("format", req.Format).
+		Str("name", req.Name).
+		Msg("CompleteInstanceImport called")
+
+	instance, err := i.instanceService.CompleteInstanceImport(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to complete instance import")
+		return nil, err
+	}
+
+	return &entity.CompleteInstanceImportResponse{
+		Instance: instance,
+	}, nil
+}
+
+func (i *Instance) AbortInstanceImport(ctx *gin.Context, req *entity.AbortInstanceImportRequest) (*entity.AbortInstanceImportResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("import_id", req.ImportID).
+		Msg("AbortInstanceImport called")
+
+	if err := i.instanceService.AbortInstanceImport(service.WithDryRun(ctx, req.DryRun), req); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to abort instance import")
+		return nil, err
+	}
+
+	return &entity.AbortInstanceImportResponse{
+		Message: "Instance import aborted successfully",
+	}, nil
+}
+
+func (i *Instance) CreateV2VTask(ctx *gin.Context, req *entity.CreateV2VTaskRequest) (*entity.CreateV2VTaskResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("source_type", req.SourceType).
+		Str("vm_name", req.VMName).
+		Msg("CreateV2VTask called")
+
+	task, err := i.instanceService.CreateV2VTask(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to create V2V task")
+		return nil, err
+	}
+
+	return &entity.CreateV2VTaskResponse{
+		Task: task,
+	}, nil
+}
+
+func (i *Instance) GetV2VTask(ctx *gin.Context, req *entity.GetV2VTaskRequest) (*entity.GetV2VTaskResponse, error) {
+	task, err := i.instanceService.GetV2VTask(ctx, req.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.GetV2VTaskResponse{
+		Task: task,
+	}, nil
+}
+
+func (i *Instance) ListV2VTasks(ctx *gin.Context, req *entity.ListV2VTasksRequest) (*entity.ListV2VTasksResponse, error) {
+	return &entity.ListV2VTasksResponse{
+		Tasks: i.instanceService.ListV2VTasks(ctx, req),
+	}, nil
+}
@@ -42,7 +42,7 @@ func (k *KeyPair) CreateKeyPair(ctx *gin.Context, req *entity.CreateKeyPairReque
 		Str("algorithm", req.Algorithm).
 		Msg("CreateKeyPair called")
 
-	response, err := k.keyPairService.CreateKeyPair(ctx, req)
+	response, err := k.keyPairService.CreateKeyPair(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -64,7 +64,7 @@ func (k *KeyPair) ImportKeyPair(ctx *gin.Context, req *entity.ImportKeyPairReque
 		Str("name", req.Name).
 		Msg("ImportKeyPair called")
 
-	response, err := k.keyPairService.ImportKeyPair(ctx, req)
+	response, err := k.keyPairService.ImportKeyPair(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -86,7 +86,7 @@ func (k *KeyPair) DeleteKeyPair(ctx *gin.Context, req *entity.DeleteKeyPairReque
 		Str("keypair_id", req.KeyPairID).
 		Msg("DeleteKeyPair called")
 
-	err := k.keyPairService.DeleteKeyPair(ctx, req.KeyPairID)
+	err := k.keyPairService.DeleteKeyPair(service.WithDryRun(ctx, req.DryRun), req.KeyPairID)
 	if err != nil {
 		logger.Error().
 			Err(err).
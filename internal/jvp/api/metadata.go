@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimyag/jvp/internal/jvp/service"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// MetadataServer 元数据服务的 HTTP 服务器，与管理 API 分开监听
+// guest 访问 169.254.169.254，由节点转发到该服务器，提供 EC2 兼容的 meta-data、user-data 与 cloud-init NoCloud 数据源：
+//   - /{version}/meta-data/...、/{version}/user-data：version 为 latest 或 EC2 元数据版本日期（如 2009-04-04）
+//   - /nocloud/meta-data、/nocloud/user-data、/nocloud/vendor-data、/nocloud/network-config
+type MetadataServer struct {
+	metadataService *service.MetadataService
+	server          *http.Server
+}
+
+// NewMetadataServer 创建元数据服务器
+func NewMetadataServer(metadataService *service.MetadataService, address string) *MetadataServer {
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	s := &MetadataServer{
+		metadataService: metadataService,
+		server: &http.Server{
+			Addr:    address,
+			Handler: engine,
+		},
+	}
+	engine.GET("/nocloud/:file", s.NoCloud)
+	engine.GET("/:version/meta-data/*path", s.EC2MetaData)
+	engine.GET("/:version/user-data", s.EC2UserData)
+	log.Info().Str("address", address).Msg("Metadata server configured")
+	return s
+}
+
+// Run 启动元数据服务器，ctx 结束时返回
+func (s *MetadataServer) Run(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errCh:
+		return err
+	}
+}
+
+// Shutdown 停止接收新请求并等待进行中的请求完成
+func (s *MetadataServer) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// NoCloud cloud-init NoCloud 数据源，实例的 SMBIOS system serial 为 ds=nocloud;s=http://169.254.169.254/nocloud/
+func (s *MetadataServer) NoCloud(c *gin.Context) {
+	instance, ok := s.lookupInstance(c)
+	if !ok {
+		return
+	}
+	switch c.Param("file") {
+	case "meta-data":
+		c.String(http.StatusOK, instance.MetaData)
+	case "user-data":
+		c.String(http.StatusOK, instance.UserData)
+	case "vendor-data":
+		c.String(http.StatusOK, "")
+	case "network-config":
+		if instance.NetworkConfig == "" {
+			c.String(http.StatusNotFound, "Not Found")
+			return
+		}
+		c.String(http.StatusOK, instance.NetworkConfig)
+	default:
+		c.String(http.StatusNotFound, "Not Found")
+	}
+}
+
+// EC2MetaData EC2 兼容的实例元数据
+func (s *MetadataServer) EC2MetaData(c *gin.Context) {
+	instance, ok := s.lookupInstance(c)
+	if !ok {
+		return
+	}
+	value, found := instance.EC2MetaData(c.Param("path"))
+	if !found {
+		c.String(http.StatusNotFound, "Not Found")
+		return
+	}
+	c.String(http.StatusOK, value)
+}
+
+// EC2UserData EC2 兼容的 user-data
+func (s *MetadataServer) EC2UserData(c *gin.Context) {
+	instance, ok := s.lookupInstance(c)
+	if !ok {
+		return
+	}
+	c.String(http.StatusOK, instance.UserData)
+}
+
+// lookupInstance 按 TCP 连接的源地址查找实例，不信任 X-Forwarded-For 等请求头，避免 guest 冒充其他实例
+func (s *MetadataServer) lookupInstance(c *gin.Context) (*service.MetadataInstance, bool) {
+	ctx := c.Request.Context()
+	ip := c.RemoteIP()
+	instance, err := s.metadataService.LookupInstance(ctx, ip)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var apiErr *apierror.Error
+		if errors.As(err, &apiErr) && apiErr.HTTPStatus != 0 {
+			status = apiErr.HTTPStatus
+		}
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("source_ip", ip).
+			Str("path", c.Request.URL.Path).
+			Msg("Metadata request rejected")
+		c.String(status, http.StatusText(status))
+		return nil, false
+	}
+	return instance, true
+}
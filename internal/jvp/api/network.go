@@ -18,6 +18,9 @@ type NetworkServiceInterface interface {
 	StartNetwork(ctx context.Context, nodeName, networkName string) (*entity.Network, error)
 	StopNetwork(ctx context.Context, nodeName, networkName string) (*entity.Network, error)
 	ListAvailableNetworkSources(ctx context.Context, nodeName string) (*entity.NetworkSources, error)
+	DescribeNetworkDHCPLeases(ctx context.Context, req *entity.DescribeNetworkDHCPLeasesRequest) ([]entity.DHCPLease, error)
+	ReleaseNetworkDHCPLease(ctx context.Context, req *entity.ReleaseNetworkDHCPLeaseRequest) ([]entity.DHCPLease, error)
+	CleanupOVSPorts(ctx context.Context, req *entity.CleanupOVSPortsRequest) (*entity.CleanupOVSPortsResponse, error)
 }
 
 // NetworkAPI 网络 API
@@ -41,6 +44,9 @@ func (a *NetworkAPI) RegisterRoutes(r *gin.RouterGroup) {
 	r.POST("/start-network", ginx.Adapt5(a.StartNetwork))
 	r.POST("/stop-network", ginx.Adapt5(a.StopNetwork))
 	r.POST("/list-network-sources", ginx.Adapt5(a.ListNetworkSources))
+	r.POST("/describe-network-dhcp-leases", ginx.Adapt5(a.DescribeNetworkDHCPLeases))
+	r.POST("/release-network-dhcp-lease", ginx.Adapt5(a.ReleaseNetworkDHCPLease))
+	r.POST("/cleanup-ovs-ports", ginx.Adapt5(a.CleanupOVSPorts))
 }
 
 // ListNetworks 列举网络
@@ -69,7 +75,7 @@ func (a *NetworkAPI) DescribeNetwork(ctx *gin.Context, req *entity.DescribeNetwo
 
 // CreateNetwork 创建网络
 func (a *NetworkAPI) CreateNetwork(ctx *gin.Context, req *entity.CreateNetworkRequest) (*entity.CreateNetworkResponse, error) {
-	network, err := a.networkService.CreateNetwork(ctx.Request.Context(), req)
+	network, err := a.networkService.CreateNetwork(service.WithDryRun(ctx.Request.Context(), req.DryRun), req)
 	if err != nil {
 		return nil, err
 	}
@@ -81,7 +87,7 @@ func (a *NetworkAPI) CreateNetwork(ctx *gin.Context, req *entity.CreateNetworkRe
 
 // DeleteNetwork 删除网络
 func (a *NetworkAPI) DeleteNetwork(ctx *gin.Context, req *entity.DeleteNetworkRequest) (*entity.DeleteNetworkResponse, error) {
-	if err := a.networkService.DeleteNetwork(ctx.Request.Context(), req.NodeName, req.NetworkName); err != nil {
+	if err := a.networkService.DeleteNetwork(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.NodeName, req.NetworkName); err != nil {
 		return nil, err
 	}
 
@@ -92,7 +98,7 @@ func (a *NetworkAPI) DeleteNetwork(ctx *gin.Context, req *entity.DeleteNetworkRe
 
 // StartNetwork 启动网络
 func (a *NetworkAPI) StartNetwork(ctx *gin.Context, req *entity.StartNetworkRequest) (*entity.StartNetworkResponse, error) {
-	network, err := a.networkService.StartNetwork(ctx.Request.Context(), req.NodeName, req.NetworkName)
+	network, err := a.networkService.StartNetwork(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.NodeName, req.NetworkName)
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +110,7 @@ func (a *NetworkAPI) StartNetwork(ctx *gin.Context, req *entity.StartNetworkRequ
 
 // StopNetwork 停止网络
 func (a *NetworkAPI) StopNetwork(ctx *gin.Context, req *entity.StopNetworkRequest) (*entity.StopNetworkResponse, error) {
-	network, err := a.networkService.StopNetwork(ctx.Request.Context(), req.NodeName, req.NetworkName)
+	network, err := a.networkService.StopNetwork(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.NodeName, req.NetworkName)
 	if err != nil {
 		return nil, err
 	}
@@ -125,3 +131,32 @@ func (a *NetworkAPI) ListNetworkSources(ctx *gin.Context, req *entity.ListNetwor
 		Sources: sources,
 	}, nil
 }
+
+// DescribeNetworkDHCPLeases 查询网络 DHCP 租约
+func (a *NetworkAPI) DescribeNetworkDHCPLeases(ctx *gin.Context, req *entity.DescribeNetworkDHCPLeasesRequest) (*entity.DescribeNetworkDHCPLeasesResponse, error) {
+	leases, err := a.networkService.DescribeNetworkDHCPLeases(ctx.Request.Context(), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.DescribeNetworkDHCPLeasesResponse{
+		Leases: leases,
+	}, nil
+}
+
+// ReleaseNetworkDHCPLease 释放网络 DHCP 租约
+func (a *NetworkAPI) ReleaseNetworkDHCPLease(ctx *gin.Context, req *entity.ReleaseNetworkDHCPLeaseRequest) (*entity.ReleaseNetworkDHCPLeaseResponse, error) {
+	released, err := a.networkService.ReleaseNetworkDHCPLease(service.WithDryRun(ctx.Request.Context(), req.DryRun), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.ReleaseNetworkDHCPLeaseResponse{
+		Released: released,
+	}, nil
+}
+
+// CleanupOVSPorts 清理节点上 OVS 的残留端口与防 MAC 欺骗流表
+func (a *NetworkAPI) CleanupOVSPorts(ctx *gin.Context, req *entity.CleanupOVSPortsRequest) (*entity.CleanupOVSPortsResponse, error) {
+	return a.networkService.CleanupOVSPorts(service.WithDryRun(ctx.Request.Context(), req.DryRun), req)
+}
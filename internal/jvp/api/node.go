@@ -17,13 +17,28 @@ type NodeServiceInterface interface {
 	DescribeNodePCI(ctx context.Context, nodeName string) ([]entity.PCIDevice, error)
 	DescribeNodeUSB(ctx context.Context, nodeName string) ([]entity.USBDevice, error)
 	DescribeNodeNet(ctx context.Context, nodeName string) (*service.NodeNetworkInfo, error)
+	DescribeNodeNetwork(ctx context.Context, nodeName string) (*entity.NodeNetwork, error)
 	DescribeNodeDisks(ctx context.Context, nodeName string) ([]entity.Disk, error)
+	ListNodeBlockDevices(ctx context.Context, nodeName string) ([]entity.HostBlockDevice, error)
+	DescribeNodeNUMA(ctx context.Context, nodeName string) ([]entity.NUMACellResources, error)
 	DescribeNodeGPU(ctx context.Context, nodeName string) ([]entity.GPUDevice, error)
 	DescribeNodeVMs(ctx context.Context, nodeName string) ([]service.NodeVMInfo, error)
-	CreateNode(ctx context.Context, name, uri string, nodeType entity.NodeType) (*entity.Node, error)
+	DescribeNodeFeatures(ctx context.Context, nodeName string, refresh bool) (*entity.NodeFeatures, error)
+	CreateNode(ctx context.Context, name, uri string, nodeType entity.NodeType, reserved entity.NodeResources, topology entity.NodeTopology, tlsConfig entity.NodeTLS) (*entity.Node, error)
 	DeleteNode(ctx context.Context, nodeName string) error
 	EnableNode(ctx context.Context, nodeName string) error
 	DisableNode(ctx context.Context, nodeName string) error
+	ModifyNodeReservedResources(ctx context.Context, nodeName string, reserved entity.NodeResources) (*entity.Node, error)
+	ModifyNodeTopology(ctx context.Context, nodeName string, topology entity.NodeTopology) (*entity.Node, error)
+	ModifyNodeOwnership(ctx context.Context, nodeName string, ownership entity.NodeOwnership) (*entity.Node, error)
+	ModifyNodeTLS(ctx context.Context, nodeName string, tlsConfig entity.NodeTLS) (*entity.Node, error)
+	DescribeTransferBandwidth(ctx context.Context) uint64
+	ModifyTransferBandwidth(ctx context.Context, bandwidthMiB uint64) error
+	DescribeNodeOperations(ctx context.Context, nodeName string) ([]entity.NodeOperation, uint64)
+	ModifyNodeOperationLimit(ctx context.Context, limit uint64) error
+	WatchResources(ctx context.Context, req *entity.WatchResourcesRequest) (*entity.WatchResourcesResponse, error)
+	DescribeRegions(ctx context.Context, req *entity.DescribeRegionsRequest) (*entity.DescribeRegionsResponse, error)
+	DescribeAvailabilityZones(ctx context.Context, req *entity.DescribeAvailabilityZonesRequest) (*entity.DescribeAvailabilityZonesResponse, error)
 }
 
 // NodeAPI 节点 API
@@ -46,13 +61,28 @@ func (a *NodeAPI) RegisterRoutes(r *gin.RouterGroup) {
 	r.POST("/describe-node-pci", ginx.Adapt5(a.DescribeNodePCI))
 	r.POST("/describe-node-usb", ginx.Adapt5(a.DescribeNodeUSB))
 	r.POST("/describe-node-net", ginx.Adapt5(a.DescribeNodeNet))
+	r.POST("/describe-node-network", ginx.Adapt5(a.DescribeNodeNetwork))
 	r.POST("/describe-node-disks", ginx.Adapt5(a.DescribeNodeDisks))
+	r.POST("/list-node-block-devices", ginx.Adapt5(a.ListNodeBlockDevices))
+	r.POST("/describe-node-numa", ginx.Adapt5(a.DescribeNodeNUMA))
 	r.POST("/describe-node-gpu", ginx.Adapt5(a.DescribeNodeGPU))
 	r.POST("/describe-node-vms", ginx.Adapt5(a.DescribeNodeVMs))
+	r.POST("/describe-node-features", ginx.Adapt5(a.DescribeNodeFeatures))
 	r.POST("/create-node", ginx.Adapt5(a.CreateNode))
 	r.POST("/delete-node", ginx.Adapt5(a.DeleteNode))
 	r.POST("/enable-node", ginx.Adapt5(a.EnableNode))
 	r.POST("/disable-node", ginx.Adapt5(a.DisableNode))
+	r.POST("/modify-node-reserved-resources", ginx.Adapt5(a.ModifyNodeReservedResources))
+	r.POST("/modify-node-topology", ginx.Adapt5(a.ModifyNodeTopology))
+	r.POST("/modify-node-ownership", ginx.Adapt5(a.ModifyNodeOwnership))
+	r.POST("/modify-node-tls", ginx.Adapt5(a.ModifyNodeTLS))
+	r.POST("/describe-transfer-bandwidth", ginx.Adapt5(a.DescribeTransferBandwidth))
+	r.POST("/modify-transfer-bandwidth", ginx.Adapt5(a.ModifyTransferBandwidth))
+	r.POST("/describe-node-operations", ginx.Adapt5(a.DescribeNodeOperations))
+	r.POST("/modify-node-operation-limit", ginx.Adapt5(a.ModifyNodeOperationLimit))
+	r.POST("/watch-resources", ginx.Adapt5(a.WatchResources))
+	r.POST("/describe-regions", ginx.Adapt5(a.DescribeRegions))
+	r.POST("/describe-availability-zones", ginx.Adapt5(a.DescribeAvailabilityZones))
 }
 
 // ListNodesRequest 列举节点请求
@@ -165,6 +195,21 @@ func (a *NodeAPI) DescribeNodeNet(ctx *gin.Context, req *DescribeNodeNetRequest)
 	return netInfo, nil
 }
 
+// DescribeNodeNetworkRequest 查询节点网络拓扑请求
+type DescribeNodeNetworkRequest struct {
+	Name string `json:"name" binding:"required"` // 节点名称
+}
+
+// DescribeNodeNetwork 查询节点网络拓扑（网桥、物理网卡、VLAN、bond、IP 段及 libvirt 虚拟网络）
+func (a *NodeAPI) DescribeNodeNetwork(ctx *gin.Context, req *DescribeNodeNetworkRequest) (*entity.NodeNetwork, error) {
+	topology, err := a.nodeService.DescribeNodeNetwork(ctx.Request.Context(), req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return topology, nil
+}
+
 // DescribeNodeDisksRequest 查询节点物理磁盘请求
 type DescribeNodeDisksRequest struct {
 	Name string `json:"name" binding:"required"` // 节点名称
@@ -185,11 +230,57 @@ func (a *NodeAPI) DescribeNodeDisks(ctx *gin.Context, req *DescribeNodeDisksRequ
 	return &DescribeNodeDisksResponse{Disks: disks}, nil
 }
 
+// ListNodeBlockDevicesRequest 列举节点块设备请求
+type ListNodeBlockDevicesRequest struct {
+	Name string `json:"name" binding:"required"` // 节点名称
+}
+
+// ListNodeBlockDevicesResponse 列举节点块设备响应
+type ListNodeBlockDevicesResponse struct {
+	Devices []entity.HostBlockDevice `json:"devices"`
+}
+
+// ListNodeBlockDevices 列举节点上可直通给实例的块设备（整盘、分区、LVM LV、zvol）
+func (a *NodeAPI) ListNodeBlockDevices(ctx *gin.Context, req *ListNodeBlockDevicesRequest) (*ListNodeBlockDevicesResponse, error) {
+	devices, err := a.nodeService.ListNodeBlockDevices(ctx.Request.Context(), req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListNodeBlockDevicesResponse{Devices: devices}, nil
+}
+
+// DescribeNodeNUMARequest 查询节点 NUMA cell 资源请求
+type DescribeNodeNUMARequest struct {
+	Name string `json:"name" binding:"required"` // 节点名称
+}
+
+// DescribeNodeNUMAResponse 查询节点 NUMA cell 资源响应
+type DescribeNodeNUMAResponse struct {
+	Cells []entity.NUMACellResources `json:"cells"`
+}
+
+// DescribeNodeNUMA 查询节点每个 NUMA cell 的剩余资源
+func (a *NodeAPI) DescribeNodeNUMA(ctx *gin.Context, req *DescribeNodeNUMARequest) (*DescribeNodeNUMAResponse, error) {
+	cells, err := a.nodeService.DescribeNodeNUMA(ctx.Request.Context(), req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DescribeNodeNUMAResponse{Cells: cells}, nil
+}
+
 // CreateNodeRequest 创建节点请求
 type CreateNodeRequest struct {
-	Name string          `json:"name" binding:"required"` // 节点名称（用于标识）
-	URI  string          `json:"uri" binding:"required"`  // Libvirt 连接 URI
-	Type entity.NodeType `json:"type"`                    // 节点类型（可选，默认 remote）
+	Name             string          `json:"name" binding:"required"`      // 节点名称（用于标识）
+	URI              string          `json:"uri" binding:"required"`       // Libvirt 连接 URI
+	Type             entity.NodeType `json:"type"`                         // 节点类型（可选，默认 remote）
+	ReservedCPUs     uint32          `json:"reserved_cpus,omitempty"`      // 预留给宿主机系统的 CPU 数（可选）
+	ReservedMemoryMB uint64          `json:"reserved_memory_mb,omitempty"` // 预留给宿主机系统的内存 MB（可选）
+	Zone             string          `json:"zone,omitempty"`               // 可用区（可选）
+	Rack             string          `json:"rack,omitempty"`               // 机架（可选）
+	TLS              entity.NodeTLS  `json:"tls,omitzero"`                 // qemu+tls 连接使用的证书（可选，默认使用 libvirt 默认位置）
+	DryRun           bool            `json:"dry_run,omitempty"`            // 仅做校验与容量预检，不执行变更
 }
 
 // CreateNode 创建节点
@@ -200,7 +291,9 @@ func (a *NodeAPI) CreateNode(ctx *gin.Context, req *CreateNodeRequest) (*entity.
 		nodeType = entity.NodeTypeRemote
 	}
 
-	node, err := a.nodeService.CreateNode(ctx.Request.Context(), req.Name, req.URI, nodeType)
+	reserved := entity.NodeResources{CPUs: req.ReservedCPUs, MemoryMB: req.ReservedMemoryMB}
+	topology := entity.NodeTopology{Zone: req.Zone, Rack: req.Rack}
+	node, err := a.nodeService.CreateNode(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.Name, req.URI, nodeType, reserved, topology, req.TLS)
 	if err != nil {
 		return nil, err
 	}
@@ -210,7 +303,8 @@ func (a *NodeAPI) CreateNode(ctx *gin.Context, req *CreateNodeRequest) (*entity.
 
 // DeleteNodeRequest 删除节点请求
 type DeleteNodeRequest struct {
-	Name string `json:"name" binding:"required"` // 节点名称
+	Name   string `json:"name" binding:"required"` // 节点名称
+	DryRun bool   `json:"dry_run,omitempty"`       // 仅做校验与容量预检，不执行变更
 }
 
 // DeleteNodeResponse 删除节点响应
@@ -220,7 +314,7 @@ type DeleteNodeResponse struct {
 
 // DeleteNode 删除节点
 func (a *NodeAPI) DeleteNode(ctx *gin.Context, req *DeleteNodeRequest) (*DeleteNodeResponse, error) {
-	if err := a.nodeService.DeleteNode(ctx.Request.Context(), req.Name); err != nil {
+	if err := a.nodeService.DeleteNode(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.Name); err != nil {
 		return nil, err
 	}
 
@@ -229,7 +323,8 @@ func (a *NodeAPI) DeleteNode(ctx *gin.Context, req *DeleteNodeRequest) (*DeleteN
 
 // EnableNodeRequest 启用节点请求
 type EnableNodeRequest struct {
-	Name string `json:"name" binding:"required"` // 节点名称
+	Name   string `json:"name" binding:"required"` // 节点名称
+	DryRun bool   `json:"dry_run,omitempty"`       // 仅做校验与容量预检，不执行变更
 }
 
 // EnableNodeResponse 启用节点响应
@@ -239,7 +334,7 @@ type EnableNodeResponse struct {
 
 // EnableNode 启用节点
 func (a *NodeAPI) EnableNode(ctx *gin.Context, req *EnableNodeRequest) (*EnableNodeResponse, error) {
-	if err := a.nodeService.EnableNode(ctx.Request.Context(), req.Name); err != nil {
+	if err := a.nodeService.EnableNode(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.Name); err != nil {
 		return nil, err
 	}
 
@@ -248,7 +343,8 @@ func (a *NodeAPI) EnableNode(ctx *gin.Context, req *EnableNodeRequest) (*EnableN
 
 // DisableNodeRequest 禁用节点请求
 type DisableNodeRequest struct {
-	Name string `json:"name" binding:"required"` // 节点名称
+	Name   string `json:"name" binding:"required"` // 节点名称
+	DryRun bool   `json:"dry_run,omitempty"`       // 仅做校验与容量预检，不执行变更
 }
 
 // DisableNodeResponse 禁用节点响应
@@ -258,13 +354,140 @@ type DisableNodeResponse struct {
 
 // DisableNode 禁用节点
 func (a *NodeAPI) DisableNode(ctx *gin.Context, req *DisableNodeRequest) (*DisableNodeResponse, error) {
-	if err := a.nodeService.DisableNode(ctx.Request.Context(), req.Name); err != nil {
+	if err := a.nodeService.DisableNode(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.Name); err != nil {
 		return nil, err
 	}
 
 	return &DisableNodeResponse{Message: "node disabled successfully"}, nil
 }
 
+// ModifyNodeReservedResourcesRequest 修改节点预留资源请求
+type ModifyNodeReservedResourcesRequest struct {
+	Name             string `json:"name" binding:"required"` // 节点名称
+	ReservedCPUs     uint32 `json:"reserved_cpus"`           // 预留给宿主机系统的 CPU 数
+	ReservedMemoryMB uint64 `json:"reserved_memory_mb"`      // 预留给宿主机系统的内存 MB
+	DryRun           bool   `json:"dry_run,omitempty"`       // 仅做校验与容量预检，不执行变更
+}
+
+// ModifyNodeReservedResources 修改节点预留给宿主机系统的资源，返回包含 capacity 与 allocatable 的节点信息
+func (a *NodeAPI) ModifyNodeReservedResources(ctx *gin.Context, req *ModifyNodeReservedResourcesRequest) (*entity.Node, error) {
+	reserved := entity.NodeResources{CPUs: req.ReservedCPUs, MemoryMB: req.ReservedMemoryMB}
+	return a.nodeService.ModifyNodeReservedResources(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.Name, reserved)
+}
+
+// ModifyNodeTopologyRequest 修改节点拓扑标签请求
+type ModifyNodeTopologyRequest struct {
+	Name   string `json:"name" binding:"required"` // 节点名称
+	Zone   string `json:"zone"`                    // 可用区，为空表示清除
+	Rack   string `json:"rack"`                    // 机架，为空表示清除
+	DryRun bool   `json:"dry_run,omitempty"`       // 仅做校验与容量预检，不执行变更
+}
+
+// ModifyNodeTopology 修改节点的可用区与机架标签
+func (a *NodeAPI) ModifyNodeTopology(ctx *gin.Context, req *ModifyNodeTopologyRequest) (*entity.Node, error) {
+	topology := entity.NodeTopology{Zone: req.Zone, Rack: req.Rack}
+	return a.nodeService.ModifyNodeTopology(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.Name, topology)
+}
+
+// ModifyNodeOwnershipRequest 修改节点文件属主修复策略请求，各字段均为空表示恢复使用全局默认
+type ModifyNodeOwnershipRequest struct {
+	Name     string `json:"name" binding:"required"` // 节点名称
+	Disabled bool   `json:"disabled"`                // 关闭属主修复，由 ACL 或存储池 permissions 处理
+	User     string `json:"user"`                    // QEMU 进程用户，如 RHEL 上为 qemu
+	Group    string `json:"group"`                   // QEMU 进程组
+	DryRun   bool   `json:"dry_run,omitempty"`       // 仅做校验，不执行变更
+}
+
+// ModifyNodeOwnership 修改节点的 VNC socket 目录与卷属主修复策略
+func (a *NodeAPI) ModifyNodeOwnership(ctx *gin.Context, req *ModifyNodeOwnershipRequest) (*entity.Node, error) {
+	ownership := entity.NodeOwnership{Disabled: req.Disabled, User: req.User, Group: req.Group}
+	return a.nodeService.ModifyNodeOwnership(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.Name, ownership)
+}
+
+// ModifyNodeTLSRequest 修改节点 TLS 证书请求，各字段均为空表示恢复使用 libvirt 默认位置
+type ModifyNodeTLSRequest struct {
+	Name   string `json:"name" binding:"required"` // 节点名称
+	DryRun bool   `json:"dry_run,omitempty"`       // 仅做校验与连接测试，不执行变更
+	entity.NodeTLS
+}
+
+// ModifyNodeTLS 修改节点 qemu+tls 连接使用的 CA、客户端证书与私钥
+func (a *NodeAPI) ModifyNodeTLS(ctx *gin.Context, req *ModifyNodeTLSRequest) (*entity.Node, error) {
+	return a.nodeService.ModifyNodeTLS(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.Name, req.NodeTLS)
+}
+
+// DescribeTransferBandwidthRequest 查询全局传输限速请求
+type DescribeTransferBandwidthRequest struct{}
+
+// TransferBandwidthResponse 全局传输限速响应
+type TransferBandwidthResponse struct {
+	BandwidthMiB uint64 `json:"bandwidth_mib"` // 全局限速（MiB/s），0 表示不限速
+}
+
+// DescribeTransferBandwidth 查询镜像下载、存储迁移等大文件传输的全局限速
+func (a *NodeAPI) DescribeTransferBandwidth(ctx *gin.Context, _ *DescribeTransferBandwidthRequest) (*TransferBandwidthResponse, error) {
+	return &TransferBandwidthResponse{
+		BandwidthMiB: a.nodeService.DescribeTransferBandwidth(ctx.Request.Context()),
+	}, nil
+}
+
+// ModifyTransferBandwidthRequest 调整全局传输限速请求
+type ModifyTransferBandwidthRequest struct {
+	BandwidthMiB uint64 `json:"bandwidth_mib"`     // 全局限速（MiB/s），0 表示不限速
+	DryRun       bool   `json:"dry_run,omitempty"` // 仅做校验与容量预检，不执行变更
+}
+
+// ModifyTransferBandwidth 调整全局传输限速，对之后启动的传输生效
+func (a *NodeAPI) ModifyTransferBandwidth(ctx *gin.Context, req *ModifyTransferBandwidthRequest) (*TransferBandwidthResponse, error) {
+	if err := a.nodeService.ModifyTransferBandwidth(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.BandwidthMiB); err != nil {
+		return nil, err
+	}
+
+	return &TransferBandwidthResponse{BandwidthMiB: req.BandwidthMiB}, nil
+}
+
+// DescribeNodeOperationsRequest 查询节点操作队列请求
+type DescribeNodeOperationsRequest struct {
+	Name string `json:"name"` // 节点名称，为空时为本地节点
+}
+
+// DescribeNodeOperationsResponse 节点操作队列响应
+type DescribeNodeOperationsResponse struct {
+	Limit      uint64                 `json:"limit"`      // 每节点并发上限，0 表示不限制
+	Operations []entity.NodeOperation `json:"operations"` // 执行中与排队中的操作
+}
+
+// DescribeNodeOperations 查询节点上执行中与排队中的创建实例、下载模板等操作
+func (a *NodeAPI) DescribeNodeOperations(ctx *gin.Context, req *DescribeNodeOperationsRequest) (*DescribeNodeOperationsResponse, error) {
+	operations, limit := a.nodeService.DescribeNodeOperations(ctx.Request.Context(), req.Name)
+	return &DescribeNodeOperationsResponse{Limit: limit, Operations: operations}, nil
+}
+
+// ModifyNodeOperationLimitRequest 调整节点操作并发上限请求
+type ModifyNodeOperationLimitRequest struct {
+	Limit  uint64 `json:"limit"`             // 每节点并发上限，0 表示不限制
+	DryRun bool   `json:"dry_run,omitempty"` // 仅做校验与容量预检，不执行变更
+}
+
+// NodeOperationLimitResponse 节点操作并发上限响应
+type NodeOperationLimitResponse struct {
+	Limit uint64 `json:"limit"` // 每节点并发上限，0 表示不限制
+}
+
+// ModifyNodeOperationLimit 调整每节点操作并发上限，调高后立即放行排队中的操作
+func (a *NodeAPI) ModifyNodeOperationLimit(ctx *gin.Context, req *ModifyNodeOperationLimitRequest) (*NodeOperationLimitResponse, error) {
+	if err := a.nodeService.ModifyNodeOperationLimit(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.Limit); err != nil {
+		return nil, err
+	}
+
+	return &NodeOperationLimitResponse{Limit: req.Limit}, nil
+}
+
+// WatchResources 监听资源变化（long-poll），没有新变化时等待到超时后返回空列表
+func (a *NodeAPI) WatchResources(ctx *gin.Context, req *entity.WatchResourcesRequest) (*entity.WatchResourcesResponse, error) {
+	return a.nodeService.WatchResources(ctx.Request.Context(), req)
+}
+
 // DescribeNodeGPURequest 查询节点 GPU 设备请求
 type DescribeNodeGPURequest struct {
 	Name string `json:"name" binding:"required"` // 节点名称
@@ -285,6 +508,27 @@ func (a *NodeAPI) DescribeNodeGPU(ctx *gin.Context, req *DescribeNodeGPURequest)
 	return &DescribeNodeGPUResponse{Devices: devices}, nil
 }
 
+// DescribeNodeFeaturesRequest 查询节点工具与能力探测结果请求
+type DescribeNodeFeaturesRequest struct {
+	Name    string `json:"name" binding:"required"` // 节点名称
+	Refresh bool   `json:"refresh,omitempty"`       // 是否重新探测（节点上安装工具后使用）
+}
+
+// DescribeNodeFeaturesResponse 查询节点工具与能力探测结果响应
+type DescribeNodeFeaturesResponse struct {
+	Features *entity.NodeFeatures `json:"features"`
+}
+
+// DescribeNodeFeatures 查询节点工具与能力探测结果
+func (a *NodeAPI) DescribeNodeFeatures(ctx *gin.Context, req *DescribeNodeFeaturesRequest) (*DescribeNodeFeaturesResponse, error) {
+	features, err := a.nodeService.DescribeNodeFeatures(ctx.Request.Context(), req.Name, req.Refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DescribeNodeFeaturesResponse{Features: features}, nil
+}
+
 // DescribeNodeVMsRequest 查询节点虚拟机请求
 type DescribeNodeVMsRequest struct {
 	Name string `json:"name" binding:"required"` // 节点名称
@@ -308,3 +552,13 @@ func (a *NodeAPI) DescribeNodeVMs(ctx *gin.Context, req *DescribeNodeVMsRequest)
 		VMs:   vms,
 	}, nil
 }
+
+// DescribeRegions 查询区域（兼容 EC2），整个集群对应一个区域
+func (a *NodeAPI) DescribeRegions(ctx *gin.Context, req *entity.DescribeRegionsRequest) (*entity.DescribeRegionsResponse, error) {
+	return a.nodeService.DescribeRegions(ctx.Request.Context(), req)
+}
+
+// DescribeAvailabilityZones 查询可用区（兼容 EC2），可用区由节点的 zone 标签分组得到
+func (a *NodeAPI) DescribeAvailabilityZones(ctx *gin.Context, req *entity.DescribeAvailabilityZonesRequest) (*entity.DescribeAvailabilityZonesResponse, error) {
+	return a.nodeService.DescribeAvailabilityZones(ctx.Request.Context(), req)
+}
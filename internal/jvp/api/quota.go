@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/internal/jvp/service"
+	"github.com/jimyag/jvp/pkg/ginx"
+	"github.com/rs/zerolog"
+)
+
+// QuotaServiceInterface 配额服务接口
+type QuotaServiceInterface interface {
+	DescribeQuotaUsage(ctx context.Context, req *entity.DescribeQuotaUsageRequest) (*entity.DescribeQuotaUsageResponse, error)
+	ModifyTenantQuota(ctx context.Context, req *entity.ModifyTenantQuotaRequest) (*entity.ModifyTenantQuotaResponse, error)
+}
+
+// QuotaAPI 配额 API
+type QuotaAPI struct {
+	quotaService QuotaServiceInterface
+}
+
+// NewQuotaAPI 创建配额 API
+func NewQuotaAPI(quotaService *service.QuotaService) *QuotaAPI {
+	return &QuotaAPI{
+		quotaService: quotaService,
+	}
+}
+
+// RegisterRoutes 注册路由 - Action 风格
+func (a *QuotaAPI) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/describe-quota-usage", ginx.Adapt5(a.DescribeQuotaUsage))
+	r.POST("/modify-tenant-quota", ginx.Adapt5(a.ModifyTenantQuota))
+}
+
+// DescribeQuotaUsage 查询各租户的资源使用量与配额上限
+func (a *QuotaAPI) DescribeQuotaUsage(ctx *gin.Context, req *entity.DescribeQuotaUsageRequest) (*entity.DescribeQuotaUsageResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("tenant", req.Tenant).
+		Msg("DescribeQuotaUsage called")
+
+	response, err := a.quotaService.DescribeQuotaUsage(ctx, req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to describe quota usage")
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// ModifyTenantQuota 修改租户配额上限
+func (a *QuotaAPI) ModifyTenantQuota(ctx *gin.Context, req *entity.ModifyTenantQuotaRequest) (*entity.ModifyTenantQuotaResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("tenant", req.Tenant).
+		Msg("ModifyTenantQuota called")
+
+	response, err := a.quotaService.ModifyTenantQuota(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to modify tenant quota")
+		return nil, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/internal/jvp/service"
+	"github.com/jimyag/jvp/pkg/ginx"
+	"github.com/rs/zerolog"
+)
+
+// ReadOnlyModeInterface 只读模式接口
+type ReadOnlyModeInterface interface {
+	Check() error
+	DescribeReadOnlyMode(ctx context.Context, req *entity.DescribeReadOnlyModeRequest) (*entity.DescribeReadOnlyModeResponse, error)
+	ModifyReadOnlyMode(ctx context.Context, req *entity.ModifyReadOnlyModeRequest) (*entity.ModifyReadOnlyModeResponse, error)
+}
+
+// readOnlyAllowedActions 只读模式下仍然放行的非只读前缀接口
+var readOnlyAllowedActions = map[string]bool{
+	"watch-resources":       true, // 长轮询资源变更，不修改状态
+	"modify-read-only-mode": true, // 关闭只读模式本身
+}
+
+// readOnlyMiddleware 只读模式下拒绝所有写接口，返回带原因的 ServiceUnavailable
+// GET 请求（控制台、下载导出文件、错误目录）与 describe-/list-/get- 接口不受影响
+func readOnlyMiddleware(mode ReadOnlyModeInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead ||
+			isReadAction(c.Request.URL.Path) || readOnlyAllowedActions[path.Base(c.Request.URL.Path)] {
+			c.Next()
+			return
+		}
+		if err := mode.Check(); err != nil {
+			zerolog.Ctx(c).Info().
+				Str("path", c.Request.URL.Path).
+				Msg("Write request rejected in read-only mode")
+			ginx.AbortWithError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// ReadOnlyAPI 只读模式 API
+type ReadOnlyAPI struct {
+	readOnlyMode ReadOnlyModeInterface
+}
+
+// NewReadOnlyAPI 创建只读模式 API
+func NewReadOnlyAPI(readOnlyMode *service.ReadOnlyMode) *ReadOnlyAPI {
+	return &ReadOnlyAPI{
+		readOnlyMode: readOnlyMode,
+	}
+}
+
+// RegisterRoutes 注册路由 - Action 风格
+func (a *ReadOnlyAPI) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/describe-read-only-mode", ginx.Adapt5(a.DescribeReadOnlyMode))
+	r.POST("/modify-read-only-mode", ginx.Adapt5(a.ModifyReadOnlyMode))
+}
+
+// DescribeReadOnlyMode 查询只读模式状态
+func (a *ReadOnlyAPI) DescribeReadOnlyMode(ctx *gin.Context, req *entity.DescribeReadOnlyModeRequest) (*entity.DescribeReadOnlyModeResponse, error) {
+	return a.readOnlyMode.DescribeReadOnlyMode(ctx, req)
+}
+
+// ModifyReadOnlyMode 开启或关闭只读模式
+func (a *ReadOnlyAPI) ModifyReadOnlyMode(ctx *gin.Context, req *entity.ModifyReadOnlyModeRequest) (*entity.ModifyReadOnlyModeResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Bool("enabled", req.Enabled).
+		Str("reason", req.Reason).
+		Msg("ModifyReadOnlyMode called")
+
+	response, err := a.readOnlyMode.ModifyReadOnlyMode(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to modify read-only mode")
+		return nil, err
+	}
+
+	return response, nil
+}
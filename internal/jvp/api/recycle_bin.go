@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/internal/jvp/service"
+	"github.com/jimyag/jvp/pkg/ginx"
+	"github.com/rs/zerolog"
+)
+
+// RecycleBinServiceInterface 回收站服务接口
+type RecycleBinServiceInterface interface {
+	DescribeRecycleBin(ctx context.Context, req *entity.DescribeRecycleBinRequest) (*entity.DescribeRecycleBinResponse, error)
+}
+
+// RecycleBinAPI 回收站 API，恢复操作分别由实例和卷的 API 提供
+type RecycleBinAPI struct {
+	recycleBinService RecycleBinServiceInterface
+}
+
+// NewRecycleBinAPI 创建回收站 API
+func NewRecycleBinAPI(recycleBinService *service.RecycleBinService) *RecycleBinAPI {
+	return &RecycleBinAPI{
+		recycleBinService: recycleBinService,
+	}
+}
+
+// RegisterRoutes 注册路由 - Action 风格
+func (a *RecycleBinAPI) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/describe-recycle-bin", ginx.Adapt5(a.DescribeRecycleBin))
+}
+
+// DescribeRecycleBin 查询回收站
+func (a *RecycleBinAPI) DescribeRecycleBin(ctx *gin.Context, req *entity.DescribeRecycleBinRequest) (*entity.DescribeRecycleBinResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("resource_type", req.ResourceType).
+		Msg("DescribeRecycleBin called")
+
+	response, err := a.recycleBinService.DescribeRecycleBin(ctx, req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to describe recycle bin")
+		return nil, err
+	}
+
+	return response, nil
+}
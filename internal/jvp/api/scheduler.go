@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/internal/jvp/service"
+	"github.com/jimyag/jvp/pkg/ginx"
+	"github.com/rs/zerolog"
+)
+
+// SchedulerServiceInterface 定时任务调度器接口
+type SchedulerServiceInterface interface {
+	DescribeScheduledTasks(ctx context.Context, req *entity.DescribeScheduledTasksRequest) (*entity.DescribeScheduledTasksResponse, error)
+	DescribeScheduledTaskHistory(ctx context.Context, req *entity.DescribeScheduledTaskHistoryRequest) (*entity.DescribeScheduledTaskHistoryResponse, error)
+}
+
+// SchedulerAPI 定时任务 API
+type SchedulerAPI struct {
+	scheduler SchedulerServiceInterface
+}
+
+// NewSchedulerAPI 创建定时任务 API
+func NewSchedulerAPI(scheduler *service.Scheduler) *SchedulerAPI {
+	return &SchedulerAPI{
+		scheduler: scheduler,
+	}
+}
+
+// RegisterRoutes 注册路由 - Action 风格
+func (a *SchedulerAPI) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/describe-scheduled-tasks", ginx.Adapt5(a.DescribeScheduledTasks))
+	r.POST("/describe-scheduled-task-history", ginx.Adapt5(a.DescribeScheduledTaskHistory))
+}
+
+// DescribeScheduledTasks 查询定时任务及下一次触发时间
+func (a *SchedulerAPI) DescribeScheduledTasks(ctx *gin.Context, req *entity.DescribeScheduledTasksRequest) (*entity.DescribeScheduledTasksResponse, error) {
+	return a.scheduler.DescribeScheduledTasks(ctx, req)
+}
+
+// DescribeScheduledTaskHistory 查询定时任务执行历史
+func (a *SchedulerAPI) DescribeScheduledTaskHistory(ctx *gin.Context, req *entity.DescribeScheduledTaskHistoryRequest) (*entity.DescribeScheduledTaskHistoryResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("name", req.Name).
+		Msg("DescribeScheduledTaskHistory called")
+
+	response, err := a.scheduler.DescribeScheduledTaskHistory(ctx, req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to describe scheduled task history")
+		return nil, err
+	}
+
+	return response, nil
+}
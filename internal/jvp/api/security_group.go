@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/internal/jvp/service"
+	"github.com/jimyag/jvp/pkg/ginx"
+	"github.com/rs/zerolog"
+)
+
+// SecurityGroupServiceInterface 安全组服务接口
+type SecurityGroupServiceInterface interface {
+	CreateSecurityGroup(ctx context.Context, req *entity.CreateSecurityGroupRequest) (*entity.SecurityGroup, error)
+	AuthorizeSecurityGroupIngress(ctx context.Context, req *entity.AuthorizeSecurityGroupRequest) (*entity.SecurityGroup, error)
+	AuthorizeSecurityGroupEgress(ctx context.Context, req *entity.AuthorizeSecurityGroupRequest) (*entity.SecurityGroup, error)
+	RevokeSecurityGroupIngress(ctx context.Context, req *entity.RevokeSecurityGroupRequest) (*entity.SecurityGroup, error)
+	RevokeSecurityGroupEgress(ctx context.Context, req *entity.RevokeSecurityGroupRequest) (*entity.SecurityGroup, error)
+	DescribeSecurityGroups(ctx context.Context, req *entity.DescribeSecurityGroupsRequest) ([]entity.SecurityGroup, error)
+	DeleteSecurityGroup(ctx context.Context, id string) error
+}
+
+// SecurityGroupAPI 安全组 API
+type SecurityGroupAPI struct {
+	securityGroupService SecurityGroupServiceInterface
+}
+
+// NewSecurityGroupAPI 创建安全组 API
+func NewSecurityGroupAPI(securityGroupService *service.SecurityGroupService) *SecurityGroupAPI {
+	return &SecurityGroupAPI{
+		securityGroupService: securityGroupService,
+	}
+}
+
+// RegisterRoutes 注册路由 - Action 风格
+func (a *SecurityGroupAPI) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/create-security-group", ginx.Adapt5(a.CreateSecurityGroup))
+	r.POST("/authorize-security-group-ingress", ginx.Adapt5(a.AuthorizeSecurityGroupIngress))
+	r.POST("/authorize-security-group-egress", ginx.Adapt5(a.AuthorizeSecurityGroupEgress))
+	r.POST("/revoke-security-group-ingress", ginx.Adapt5(a.RevokeSecurityGroupIngress))
+	r.POST("/revoke-security-group-egress", ginx.Adapt5(a.RevokeSecurityGroupEgress))
+	r.POST("/describe-security-groups", ginx.Adapt5(a.DescribeSecurityGroups))
+	r.POST("/delete-security-group", ginx.Adapt5(a.DeleteSecurityGroup))
+}
+
+// CreateSecurityGroup 创建安全组
+func (a *SecurityGroupAPI) CreateSecurityGroup(ctx *gin.Context, req *entity.CreateSecurityGroupRequest) (*entity.CreateSecurityGroupResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("name", req.Name).
+		Msg("CreateSecurityGroup called")
+
+	group, err := a.securityGroupService.CreateSecurityGroup(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to create security group")
+		return nil, err
+	}
+
+	return &entity.CreateSecurityGroupResponse{SecurityGroup: group}, nil
+}
+
+// AuthorizeSecurityGroupIngress 添加入方向规则
+func (a *SecurityGroupAPI) AuthorizeSecurityGroupIngress(ctx *gin.Context, req *entity.AuthorizeSecurityGroupRequest) (*entity.SecurityGroupRulesResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("security_group_id", req.SecurityGroupID).
+		Int("rules", len(req.Rules)).
+		Msg("AuthorizeSecurityGroupIngress called")
+
+	group, err := a.securityGroupService.AuthorizeSecurityGroupIngress(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to authorize security group ingress")
+		return nil, err
+	}
+
+	return &entity.SecurityGroupRulesResponse{SecurityGroup: group}, nil
+}
+
+// AuthorizeSecurityGroupEgress 添加出方向规则
+func (a *SecurityGroupAPI) AuthorizeSecurityGroupEgress(ctx *gin.Context, req *entity.AuthorizeSecurityGroupRequest) (*entity.SecurityGroupRulesResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("security_group_id", req.SecurityGroupID).
+		Int("rules", len(req.Rules)).
+		Msg("AuthorizeSecurityGroupEgress called")
+
+	group, err := a.securityGroupService.AuthorizeSecurityGroupEgress(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to authorize security group egress")
+		return nil, err
+	}
+
+	return &entity.SecurityGroupRulesResponse{SecurityGroup: group}, nil
+}
+
+// RevokeSecurityGroupIngress 删除入方向规则
+func (a *SecurityGroupAPI) RevokeSecurityGroupIngress(ctx *gin.Context, req *entity.RevokeSecurityGroupRequest) (*entity.SecurityGroupRulesResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("security_group_id", req.SecurityGroupID).
+		Int("rules", len(req.Rules)).
+		Msg("RevokeSecurityGroupIngress called")
+
+	group, err := a.securityGroupService.RevokeSecurityGroupIngress(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to revoke security group ingress")
+		return nil, err
+	}
+
+	return &entity.SecurityGroupRulesResponse{SecurityGroup: group}, nil
+}
+
+// RevokeSecurityGroupEgress 删除出方向规则
+func (a *SecurityGroupAPI) RevokeSecurityGroupEgress(ctx *gin.Context, req *entity.RevokeSecurityGroupRequest) (*entity.SecurityGroupRulesResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("security_group_id", req.SecurityGroupID).
+		Int("rules", len(req.Rules)).
+		Msg("RevokeSecurityGroupEgress called")
+
+	group, err := a.securityGroupService.RevokeSecurityGroupEgress(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to revoke security group egress")
+		return nil, err
+	}
+
+	return &entity.SecurityGroupRulesResponse{SecurityGroup: group}, nil
+}
+
+// DescribeSecurityGroups 描述安全组
+func (a *SecurityGroupAPI) DescribeSecurityGroups(ctx *gin.Context, req *entity.DescribeSecurityGroupsRequest) (*entity.DescribeSecurityGroupsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Interface("request", req).
+		Msg("DescribeSecurityGroups called")
+
+	groups, err := a.securityGroupService.DescribeSecurityGroups(ctx, req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to describe security groups")
+		return nil, err
+	}
+
+	return &entity.DescribeSecurityGroupsResponse{SecurityGroups: groups}, nil
+}
+
+// DeleteSecurityGroup 删除安全组
+func (a *SecurityGroupAPI) DeleteSecurityGroup(ctx *gin.Context, req *entity.DeleteSecurityGroupRequest) (*entity.DeleteSecurityGroupResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("security_group_id", req.SecurityGroupID).
+		Msg("DeleteSecurityGroup called")
+
+	if err := a.securityGroupService.DeleteSecurityGroup(service.WithDryRun(ctx, req.DryRun), req.SecurityGroupID); err != nil {
+		logger.Error().
+			Err(err).
+			Str("security_group_id", req.SecurityGroupID).
+			Msg("Failed to delete security group")
+		return nil, err
+	}
+
+	return &entity.DeleteSecurityGroupResponse{Return: true}, nil
+}
@@ -18,6 +18,7 @@ type SnapshotServiceInterface interface {
 	DeleteSnapshot(ctx context.Context, req *entity.DeleteSnapshotRequest) error
 	RevertSnapshot(ctx context.Context, req *entity.RevertSnapshotRequest) error
 	CloneFromSnapshot(ctx context.Context, req *entity.CloneFromSnapshotRequest) (*entity.Instance, error)
+	ExportSnapshot(ctx context.Context, req *entity.ExportSnapshotRequest) (*entity.InstanceExport, error)
 }
 
 type Snapshot struct {
@@ -37,6 +38,7 @@ func (s *Snapshot) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/delete-snapshot", ginx.Adapt5(s.DeleteSnapshot))
 	router.POST("/revert-snapshot", ginx.Adapt5(s.RevertSnapshot))
 	router.POST("/clone-from-snapshot", ginx.Adapt5(s.CloneFromSnapshot))
+	router.POST("/export-snapshot", ginx.Adapt5(s.ExportSnapshot))
 }
 
 func (s *Snapshot) CreateSnapshot(ctx *gin.Context, req *entity.CreateSnapshotRequest) (*entity.CreateSnapshotResponse, error) {
@@ -48,7 +50,7 @@ func (s *Snapshot) CreateSnapshot(ctx *gin.Context, req *entity.CreateSnapshotRe
 		Bool("with_memory", req.WithMemory).
 		Msg("API: CreateSnapshot called")
 
-	snapshot, err := s.snapshotService.CreateSnapshot(ctx, req)
+	snapshot, err := s.snapshotService.CreateSnapshot(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to create snapshot")
 		return nil, err
@@ -107,7 +109,7 @@ func (s *Snapshot) DeleteSnapshot(ctx *gin.Context, req *entity.DeleteSnapshotRe
 		Bool("disks_only", req.DisksOnly).
 		Msg("API: DeleteSnapshot called")
 
-	if err := s.snapshotService.DeleteSnapshot(ctx, req); err != nil {
+	if err := s.snapshotService.DeleteSnapshot(service.WithDryRun(ctx, req.DryRun), req); err != nil {
 		logger.Error().Err(err).Msg("Failed to delete snapshot")
 		return nil, err
 	}
@@ -127,7 +129,7 @@ func (s *Snapshot) RevertSnapshot(ctx *gin.Context, req *entity.RevertSnapshotRe
 		Bool("force", req.Force).
 		Msg("API: RevertSnapshot called")
 
-	if err := s.snapshotService.RevertSnapshot(ctx, req); err != nil {
+	if err := s.snapshotService.RevertSnapshot(service.WithDryRun(ctx, req.DryRun), req); err != nil {
 		logger.Error().Err(err).Msg("Failed to revert snapshot")
 		return nil, err
 	}
@@ -147,7 +149,7 @@ func (s *Snapshot) CloneFromSnapshot(ctx *gin.Context, req *entity.CloneFromSnap
 		Bool("flatten", req.Flatten).
 		Msg("API: CloneFromSnapshot called")
 
-	instance, err := s.snapshotService.CloneFromSnapshot(ctx, req)
+	instance, err := s.snapshotService.CloneFromSnapshot(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to clone from snapshot")
 		return nil, err
@@ -158,3 +160,24 @@ func (s *Snapshot) CloneFromSnapshot(ctx *gin.Context, req *entity.CloneFromSnap
 		Message:  "Instance cloned from snapshot successfully",
 	}, nil
 }
+
+func (s *Snapshot) ExportSnapshot(ctx *gin.Context, req *entity.ExportSnapshotRequest) (*entity.ExportSnapshotResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("vm_name", req.VMName).
+		Str("snapshot_name", req.SnapshotName).
+		Str("disk_target", req.DiskTarget).
+		Str("pool_name", req.PoolName).
+		Msg("API: ExportSnapshot called")
+
+	export, err := s.snapshotService.ExportSnapshot(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to export snapshot")
+		return nil, err
+	}
+
+	return &entity.ExportSnapshotResponse{
+		Export: export,
+	}, nil
+}
@@ -70,7 +70,7 @@ func (a *StoragePoolAPI) DescribeStoragePool(ctx *gin.Context, req *entity.Descr
 // CreateStoragePool 创建存储池
 func (a *StoragePoolAPI) CreateStoragePool(ctx *gin.Context, req *entity.CreateStoragePoolRequest) (*entity.CreateStoragePoolResponse, error) {
 	pool, err := a.storagePoolService.CreateStoragePool(
-		ctx.Request.Context(),
+		service.WithDryRun(ctx.Request.Context(), req.DryRun),
 		req.NodeName,
 		req.Name,
 		req.Type,
@@ -87,7 +87,7 @@ func (a *StoragePoolAPI) CreateStoragePool(ctx *gin.Context, req *entity.CreateS
 
 // DeleteStoragePool 删除存储池
 func (a *StoragePoolAPI) DeleteStoragePool(ctx *gin.Context, req *entity.DeleteStoragePoolRequest) (*entity.DeleteStoragePoolResponse, error) {
-	if err := a.storagePoolService.DeleteStoragePool(ctx.Request.Context(), req.NodeName, req.PoolName, req.DeleteVolumes); err != nil {
+	if err := a.storagePoolService.DeleteStoragePool(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.NodeName, req.PoolName, req.DeleteVolumes); err != nil {
 		return nil, err
 	}
 
@@ -103,7 +103,7 @@ func (a *StoragePoolAPI) DeleteStoragePool(ctx *gin.Context, req *entity.DeleteS
 
 // StartStoragePool 启动存储池
 func (a *StoragePoolAPI) StartStoragePool(ctx *gin.Context, req *entity.StartStoragePoolRequest) (*entity.StartStoragePoolResponse, error) {
-	pool, err := a.storagePoolService.StartStoragePool(ctx.Request.Context(), req.NodeName, req.PoolName)
+	pool, err := a.storagePoolService.StartStoragePool(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.NodeName, req.PoolName)
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +115,7 @@ func (a *StoragePoolAPI) StartStoragePool(ctx *gin.Context, req *entity.StartSto
 
 // StopStoragePool 停止存储池
 func (a *StoragePoolAPI) StopStoragePool(ctx *gin.Context, req *entity.StopStoragePoolRequest) (*entity.StopStoragePoolResponse, error) {
-	pool, err := a.storagePoolService.StopStoragePool(ctx.Request.Context(), req.NodeName, req.PoolName)
+	pool, err := a.storagePoolService.StopStoragePool(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.NodeName, req.PoolName)
 	if err != nil {
 		return nil, err
 	}
@@ -127,7 +127,7 @@ func (a *StoragePoolAPI) StopStoragePool(ctx *gin.Context, req *entity.StopStora
 
 // RefreshStoragePool 刷新存储池
 func (a *StoragePoolAPI) RefreshStoragePool(ctx *gin.Context, req *entity.RefreshStoragePoolRequest) (*entity.RefreshStoragePoolResponse, error) {
-	pool, err := a.storagePoolService.RefreshStoragePool(ctx.Request.Context(), req.NodeName, req.PoolName)
+	pool, err := a.storagePoolService.RefreshStoragePool(service.WithDryRun(ctx.Request.Context(), req.DryRun), req.NodeName, req.PoolName)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/internal/jvp/service"
+	"github.com/jimyag/jvp/pkg/ginx"
+	"github.com/rs/zerolog"
+)
+
+// TagServiceInterface 标签服务接口
+type TagServiceInterface interface {
+	CreateTags(ctx context.Context, req *entity.CreateTagsRequest) (*entity.CreateTagsResponse, error)
+	DeleteTags(ctx context.Context, req *entity.DeleteTagsRequest) (*entity.DeleteTagsResponse, error)
+	DescribeTags(ctx context.Context, req *entity.DescribeTagsRequest) (*entity.DescribeTagsResponse, error)
+}
+
+// TagAPI 实例、卷与模板的标签 API
+type TagAPI struct {
+	tagService TagServiceInterface
+}
+
+// NewTagAPI 创建标签 API
+func NewTagAPI(tagService *service.TagService) *TagAPI {
+	return &TagAPI{
+		tagService: tagService,
+	}
+}
+
+// RegisterRoutes 注册路由 - Action 风格
+func (a *TagAPI) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/create-tags", ginx.Adapt5(a.CreateTags))
+	r.POST("/delete-tags", ginx.Adapt5(a.DeleteTags))
+	r.POST("/describe-tags", ginx.Adapt5(a.DescribeTags))
+}
+
+// CreateTags 为资源添加标签
+func (a *TagAPI) CreateTags(ctx *gin.Context, req *entity.CreateTagsRequest) (*entity.CreateTagsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("resource_type", req.ResourceType).
+		Strs("resource_ids", req.ResourceIDs).
+		Msg("CreateTags called")
+
+	response, err := a.tagService.CreateTags(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to create tags")
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DeleteTags 删除资源标签
+func (a *TagAPI) DeleteTags(ctx *gin.Context, req *entity.DeleteTagsRequest) (*entity.DeleteTagsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("resource_type", req.ResourceType).
+		Strs("resource_ids", req.ResourceIDs).
+		Msg("DeleteTags called")
+
+	response, err := a.tagService.DeleteTags(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to delete tags")
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DescribeTags 查询资源标签
+func (a *TagAPI) DescribeTags(ctx *gin.Context, req *entity.DescribeTagsRequest) (*entity.DescribeTagsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("resource_type", req.ResourceType).
+		Msg("DescribeTags called")
+
+	response, err := a.tagService.DescribeTags(ctx, req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to describe tags")
+		return nil, err
+	}
+
+	return response, nil
+}
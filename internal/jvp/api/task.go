@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/internal/jvp/service"
+	"github.com/jimyag/jvp/pkg/ginx"
+	"github.com/rs/zerolog"
+)
+
+// TaskServiceInterface 任务服务接口
+type TaskServiceInterface interface {
+	CancelTask(ctx context.Context, req *entity.CancelTaskRequest) (*entity.CancelTaskResponse, error)
+}
+
+// TaskAPI 长任务 API，任务的查询分别由模板、实例的 API 提供
+type TaskAPI struct {
+	taskService TaskServiceInterface
+}
+
+// NewTaskAPI 创建任务 API
+func NewTaskAPI(taskService *service.TaskService) *TaskAPI {
+	return &TaskAPI{
+		taskService: taskService,
+	}
+}
+
+// RegisterRoutes 注册路由 - Action 风格
+func (a *TaskAPI) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/cancel-task", ginx.Adapt5(a.CancelTask))
+}
+
+// CancelTask 取消下载、V2V 或磁盘 block job
+func (a *TaskAPI) CancelTask(ctx *gin.Context, req *entity.CancelTaskRequest) (*entity.CancelTaskResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("task_id", req.TaskID).
+		Str("node_name", req.NodeName).
+		Str("instance_id", req.InstanceID).
+		Str("device", req.Device).
+		Msg("CancelTask called")
+
+	response, err := a.taskService.CancelTask(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to cancel task")
+		return nil, err
+	}
+
+	return response, nil
+}
@@ -49,7 +49,7 @@ func (t *Template) RegisterTemplate(ctx *gin.Context, req *entity.RegisterTempla
 		Str("pool_name", req.PoolName).
 		Msg("API: RegisterTemplate called")
 
-	result, err := t.templateService.RegisterTemplate(ctx, req)
+	result, err := t.templateService.RegisterTemplate(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to register template")
 		return nil, err
@@ -59,12 +59,14 @@ func (t *Template) RegisterTemplate(ctx *gin.Context, req *entity.RegisterTempla
 	if result.IsAsync {
 		return &entity.RegisterTemplateResponse{
 			DownloadTask: &entity.DownloadTask{
-				ID:         result.DownloadTask.ID,
-				NodeName:   result.DownloadTask.NodeName,
-				PoolName:   result.DownloadTask.PoolName,
-				VolumeName: result.DownloadTask.VolumeName,
-				Status:     string(result.DownloadTask.Status),
-				Error:      result.DownloadTask.Error,
+				ID:            result.DownloadTask.ID,
+				NodeName:      result.DownloadTask.NodeName,
+				PoolName:      result.DownloadTask.PoolName,
+				VolumeName:    result.DownloadTask.VolumeName,
+				BandwidthMiB:  result.DownloadTask.BandwidthMiB,
+				Status:        string(result.DownloadTask.Status),
+				QueuePosition: result.DownloadTask.QueuePosition,
+				Error:         result.DownloadTask.Error,
 			},
 		}, nil
 	}
@@ -111,7 +113,7 @@ func (t *Template) UpdateTemplate(ctx *gin.Context, req *entity.UpdateTemplateRe
 		Str("node_name", req.NodeName).
 		Msg("API: UpdateTemplate called")
 
-	template, err := t.templateService.UpdateTemplate(ctx, req)
+	template, err := t.templateService.UpdateTemplate(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to update template")
 		return nil, err
@@ -128,7 +130,7 @@ func (t *Template) DeleteTemplate(ctx *gin.Context, req *entity.DeleteTemplateRe
 		Bool("delete_volume", req.DeleteVolume).
 		Msg("API: DeleteTemplate called")
 
-	if err := t.templateService.DeleteTemplate(ctx, req); err != nil {
+	if err := t.templateService.DeleteTemplate(service.WithDryRun(ctx, req.DryRun), req); err != nil {
 		logger.Error().Err(err).Msg("Failed to delete template")
 		return nil, err
 	}
@@ -150,12 +152,14 @@ func (t *Template) GetDownloadTask(ctx *gin.Context, req *entity.GetDownloadTask
 
 	return &entity.GetDownloadTaskResponse{
 		Task: &entity.DownloadTask{
-			ID:         task.ID,
-			NodeName:   task.NodeName,
-			PoolName:   task.PoolName,
-			VolumeName: task.VolumeName,
-			Status:     string(task.Status),
-			Error:      task.Error,
+			ID:            task.ID,
+			NodeName:      task.NodeName,
+			PoolName:      task.PoolName,
+			VolumeName:    task.VolumeName,
+			BandwidthMiB:  task.BandwidthMiB,
+			Status:        string(task.Status),
+			QueuePosition: task.QueuePosition,
+			Error:         task.Error,
 		},
 	}, nil
 }
@@ -170,12 +174,14 @@ func (t *Template) ListDownloadTasks(ctx *gin.Context, _ *entity.ListDownloadTas
 	result := make([]*entity.DownloadTask, len(tasks))
 	for i, task := range tasks {
 		result[i] = &entity.DownloadTask{
-			ID:         task.ID,
-			NodeName:   task.NodeName,
-			PoolName:   task.PoolName,
-			VolumeName: task.VolumeName,
-			Status:     string(task.Status),
-			Error:      task.Error,
+			ID:            task.ID,
+			NodeName:      task.NodeName,
+			PoolName:      task.PoolName,
+			VolumeName:    task.VolumeName,
+			BandwidthMiB:  task.BandwidthMiB,
+			Status:        string(task.Status),
+			QueuePosition: task.QueuePosition,
+			Error:         task.Error,
 		}
 	}
 
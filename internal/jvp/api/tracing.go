@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimyag/jvp/pkg/tracing"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceIDHeader 响应中返回 trace ID 的响应头，便于按请求在追踪后端检索
+const traceIDHeader = "X-Trace-Id"
+
+// tracingMiddleware 为每个请求创建 server 类型的 span，请求头带有 W3C traceparent 时作为其子 span
+// span 与带 trace_id 字段的 logger 写入请求上下文，service 层的 libvirt、qemu-img、SSH 调用在其下创建子 span；
+// WebSocket 控制台连接持续时间不确定，不创建 span
+func tracingMiddleware(c *gin.Context) {
+	if c.IsWebsocket() {
+		c.Next()
+		return
+	}
+
+	// 请求上下文不随客户端断开而取消：与 handler 直接使用 gin.Context 时的行为保持一致，
+	// 避免创建实例等长操作在中途被取消而留下半成品
+	ctx := otel.GetTextMapPropagator().Extract(context.WithoutCancel(c.Request.Context()), propagation.HeaderCarrier(c.Request.Header))
+	ctx, span := tracing.Tracer().Start(ctx, c.Request.Method+" "+c.FullPath(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("http.route", c.FullPath()),
+			attribute.String("client.address", c.ClientIP()),
+		),
+	)
+	defer span.End()
+
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		c.Header(traceIDHeader, traceID)
+		if zerolog.DefaultContextLogger != nil {
+			logger := zerolog.DefaultContextLogger.With().Str("trace_id", traceID).Logger()
+			ctx = logger.WithContext(ctx)
+		}
+	}
+	c.Request = c.Request.WithContext(ctx)
+
+	c.Next()
+
+	status := c.Writer.Status()
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= 500 {
+		span.SetStatus(codes.Error, "")
+	}
+	if len(c.Errors) > 0 {
+		span.RecordError(c.Errors.Last())
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/internal/jvp/service"
+	"github.com/jimyag/jvp/pkg/ginx"
+	"github.com/rs/zerolog"
+)
+
+// UsageServiceInterface 用量记录服务接口
+type UsageServiceInterface interface {
+	DescribeUsageRecords(ctx context.Context, req *entity.DescribeUsageRecordsRequest) (*entity.DescribeUsageRecordsResponse, error)
+}
+
+// UsageAPI 用量记录 API
+type UsageAPI struct {
+	usageService UsageServiceInterface
+}
+
+// NewUsageAPI 创建用量记录 API
+func NewUsageAPI(usageService *service.UsageService) *UsageAPI {
+	return &UsageAPI{
+		usageService: usageService,
+	}
+}
+
+// RegisterRoutes 注册路由 - Action 风格
+func (a *UsageAPI) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/describe-usage-records", ginx.Adapt5(a.DescribeUsageRecords))
+	r.GET("/download-usage-records", ginx.AdaptStream(a.DownloadUsageRecords))
+}
+
+// DescribeUsageRecords 查询实例用量记录
+func (a *UsageAPI) DescribeUsageRecords(ctx *gin.Context, req *entity.DescribeUsageRecordsRequest) (*entity.DescribeUsageRecordsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Time("start_time", req.StartTime).
+		Time("end_time", req.EndTime).
+		Str("tenant", req.Tenant).
+		Msg("DescribeUsageRecords called")
+
+	response, err := a.usageService.DescribeUsageRecords(ctx, req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to describe usage records")
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DownloadUsageRecords 以 CSV 导出实例用量记录，过滤条件来自查询参数
+func (a *UsageAPI) DownloadUsageRecords(ctx *gin.Context, req *entity.DescribeUsageRecordsRequest) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Time("start_time", req.StartTime).
+		Time("end_time", req.EndTime).
+		Str("tenant", req.Tenant).
+		Msg("DownloadUsageRecords called")
+
+	response, err := a.usageService.DescribeUsageRecords(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("usage-%s.csv", time.Now().UTC().Format("20060102T150405Z"))
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	ctx.Status(http.StatusOK)
+	if err := service.WriteUsageRecordsCSV(ctx.Writer, response.Records); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to write usage records CSV")
+		return err
+	}
+	return nil
+}
@@ -14,10 +14,17 @@ import (
 type VolumeServiceInterface interface {
 	CreateVolume(ctx context.Context, req *entity.CreateVolumeRequest) (*entity.Volume, error)
 	CreateVolumeFromURL(ctx context.Context, req *entity.CreateVolumeFromURLRequest) (*entity.Volume, error)
+	CloneVolume(ctx context.Context, req *entity.CloneVolumeRequest) (*entity.Volume, error)
+	CompleteVolumeImport(ctx context.Context, req *entity.CompleteVolumeImportRequest) (*entity.Volume, error)
 	ListVolumes(ctx context.Context, req *entity.ListVolumesRequest) ([]entity.Volume, error)
 	DescribeVolume(ctx context.Context, req *entity.DescribeVolumeRequest) (*entity.Volume, error)
+	DescribeVolumeLineage(ctx context.Context, req *entity.DescribeVolumeLineageRequest) (*entity.VolumeLineage, error)
 	ResizeVolume(ctx context.Context, req *entity.ResizeVolumeRequest) (*entity.Volume, error)
 	DeleteVolume(ctx context.Context, req *entity.DeleteVolumeRequest) error
+	RestoreVolume(ctx context.Context, req *entity.RestoreVolumeRequest) (*entity.Volume, error)
+	ModifyVolumeAttribute(ctx context.Context, req *entity.ModifyVolumeAttributeRequest) (*entity.Volume, error)
+	AttachVolume(ctx context.Context, req *entity.AttachVolumeRequest) (*entity.VolumeAttachment, error)
+	DetachVolume(ctx context.Context, req *entity.DetachVolumeRequest) error
 }
 
 type Volume struct {
@@ -34,10 +41,17 @@ func (v *Volume) RegisterRoutes(router *gin.RouterGroup) {
 	// Action 风格 API
 	router.POST("/create-volume", ginx.Adapt5(v.CreateVolume))
 	router.POST("/create-volume-from-url", ginx.Adapt5(v.CreateVolumeFromURL))
+	router.POST("/clone-volume", ginx.Adapt5(v.CloneVolume))
+	router.POST("/complete-volume-import", ginx.Adapt5(v.CompleteVolumeImport))
 	router.POST("/list-volumes", ginx.Adapt5(v.ListVolumes))
 	router.POST("/describe-volume", ginx.Adapt5(v.DescribeVolume))
+	router.POST("/describe-volume-lineage", ginx.Adapt5(v.DescribeVolumeLineage))
 	router.POST("/resize-volume", ginx.Adapt5(v.ResizeVolume))
 	router.POST("/delete-volume", ginx.Adapt5(v.DeleteVolume))
+	router.POST("/restore-volume", ginx.Adapt5(v.RestoreVolume))
+	router.POST("/modify-volume-attribute", ginx.Adapt5(v.ModifyVolumeAttribute))
+	router.POST("/attach-volume", ginx.Adapt5(v.AttachVolume))
+	router.POST("/detach-volume", ginx.Adapt5(v.DetachVolume))
 }
 
 func (v *Volume) CreateVolume(ctx *gin.Context, req *entity.CreateVolumeRequest) (*entity.CreateVolumeResponse, error) {
@@ -50,7 +64,7 @@ func (v *Volume) CreateVolume(ctx *gin.Context, req *entity.CreateVolumeRequest)
 		Str("format", req.Format).
 		Msg("API: CreateVolume called")
 
-	volume, err := v.volumeService.CreateVolume(ctx, req)
+	volume, err := v.volumeService.CreateVolume(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -67,6 +81,32 @@ func (v *Volume) CreateVolume(ctx *gin.Context, req *entity.CreateVolumeRequest)
 	}, nil
 }
 
+func (v *Volume) CloneVolume(ctx *gin.Context, req *entity.CloneVolumeRequest) (*entity.CloneVolumeResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("source_volume_id", req.SourceVolumeID).
+		Str("name", req.Name).
+		Msg("API: CloneVolume called")
+
+	volume, err := v.volumeService.CloneVolume(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to clone volume")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("volume_id", volume.ID).
+		Msg("Volume cloned successfully")
+
+	return &entity.CloneVolumeResponse{
+		Volume: volume,
+	}, nil
+}
+
 func (v *Volume) ListVolumes(ctx *gin.Context, req *entity.ListVolumesRequest) (*entity.ListVolumesResponse, error) {
 	logger := zerolog.Ctx(ctx)
 	logger.Info().
@@ -116,6 +156,28 @@ func (v *Volume) DescribeVolume(ctx *gin.Context, req *entity.DescribeVolumeRequ
 	}, nil
 }
 
+func (v *Volume) DescribeVolumeLineage(ctx *gin.Context, req *entity.DescribeVolumeLineageRequest) (*entity.DescribeVolumeLineageResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("volume_id", req.VolumeID).
+		Str("path", req.Path).
+		Msg("API: DescribeVolumeLineage called")
+
+	lineage, err := v.volumeService.DescribeVolumeLineage(ctx, req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to describe volume lineage")
+		return nil, err
+	}
+
+	return &entity.DescribeVolumeLineageResponse{
+		Lineage: lineage,
+	}, nil
+}
+
 func (v *Volume) ResizeVolume(ctx *gin.Context, req *entity.ResizeVolumeRequest) (*entity.ResizeVolumeResponse, error) {
 	logger := zerolog.Ctx(ctx)
 	logger.Info().
@@ -125,7 +187,7 @@ func (v *Volume) ResizeVolume(ctx *gin.Context, req *entity.ResizeVolumeRequest)
 		Uint64("new_size_gb", req.NewSizeGB).
 		Msg("API: ResizeVolume called")
 
-	volume, err := v.volumeService.ResizeVolume(ctx, req)
+	volume, err := v.volumeService.ResizeVolume(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -151,7 +213,7 @@ func (v *Volume) DeleteVolume(ctx *gin.Context, req *entity.DeleteVolumeRequest)
 		Str("volume_id", req.VolumeID).
 		Msg("API: DeleteVolume called")
 
-	err := v.volumeService.DeleteVolume(ctx, req)
+	err := v.volumeService.DeleteVolume(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -168,6 +230,56 @@ func (v *Volume) DeleteVolume(ctx *gin.Context, req *entity.DeleteVolumeRequest)
 	}, nil
 }
 
+func (v *Volume) RestoreVolume(ctx *gin.Context, req *entity.RestoreVolumeRequest) (*entity.RestoreVolumeResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("volume_id", req.VolumeID).
+		Msg("API: RestoreVolume called")
+
+	volume, err := v.volumeService.RestoreVolume(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to restore volume")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("volume_id", volume.ID).
+		Msg("Volume restored successfully")
+
+	return &entity.RestoreVolumeResponse{
+		Volume: volume,
+	}, nil
+}
+
+func (v *Volume) ModifyVolumeAttribute(ctx *gin.Context, req *entity.ModifyVolumeAttributeRequest) (*entity.ModifyVolumeAttributeResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("volume_id", req.VolumeID).
+		Msg("API: ModifyVolumeAttribute called")
+
+	volume, err := v.volumeService.ModifyVolumeAttribute(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to modify volume attribute")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("volume_id", volume.ID).
+		Msg("Volume attribute modified successfully")
+
+	return &entity.ModifyVolumeAttributeResponse{
+		Volume: volume,
+	}, nil
+}
+
 func (v *Volume) CreateVolumeFromURL(ctx *gin.Context, req *entity.CreateVolumeFromURLRequest) (*entity.CreateVolumeFromURLResponse, error) {
 	logger := zerolog.Ctx(ctx)
 	logger.Info().
@@ -177,7 +289,7 @@ func (v *Volume) CreateVolumeFromURL(ctx *gin.Context, req *entity.CreateVolumeF
 		Str("url", req.URL).
 		Msg("API: CreateVolumeFromURL called")
 
-	volume, err := v.volumeService.CreateVolumeFromURL(ctx, req)
+	volume, err := v.volumeService.CreateVolumeFromURL(service.WithDryRun(ctx, req.DryRun), req)
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -194,3 +306,80 @@ func (v *Volume) CreateVolumeFromURL(ctx *gin.Context, req *entity.CreateVolumeF
 		Volume: volume,
 	}, nil
 }
+
+func (v *Volume) CompleteVolumeImport(ctx *gin.Context, req *entity.CompleteVolumeImportRequest) (*entity.CompleteVolumeImportResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("import_id", req.ImportID).
+		Str("format", req.Format).
+		Msg("API: CompleteVolumeImport called")
+
+	volume, err := v.volumeService.CompleteVolumeImport(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to complete volume import")
+		return nil, err
+	}
+
+	return &entity.CompleteVolumeImportResponse{
+		Volume: volume,
+	}, nil
+}
+
+func (v *Volume) AttachVolume(ctx *gin.Context, req *entity.AttachVolumeRequest) (*entity.AttachVolumeResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("volume_id", req.VolumeID).
+		Str("instance_id", req.InstanceID).
+		Str("bus", req.Bus).
+		Msg("API: AttachVolume called")
+
+	attachment, err := v.volumeService.AttachVolume(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to attach volume")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("volume_id", attachment.VolumeID).
+		Str("device", attachment.Device).
+		Msg("Volume attached successfully")
+
+	return &entity.AttachVolumeResponse{
+		Attachment: attachment,
+	}, nil
+}
+
+func (v *Volume) DetachVolume(ctx *gin.Context, req *entity.DetachVolumeRequest) (*entity.DetachVolumeResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("volume_id", req.VolumeID).
+		Str("instance_id", req.InstanceID).
+		Msg("API: DetachVolume called")
+
+	err := v.volumeService.DetachVolume(service.WithDryRun(ctx, req.DryRun), req)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to detach volume")
+		return nil, err
+	}
+
+	logger.Info().
+		Str("volume_id", req.VolumeID).
+		Str("instance_id", req.InstanceID).
+		Msg("Volume detached successfully")
+
+	return &entity.DetachVolumeResponse{
+		Message: "Volume detached successfully",
+	}, nil
+}
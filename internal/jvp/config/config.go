@@ -1,8 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -21,17 +25,269 @@ type Config struct {
 	DataDir string
 
 	Address string
+
+	// DefaultTimezone 是实例默认时区（如 Asia/Shanghai）
+	// 创建实例时 cloud-init 未指定时区则自动注入
+	// 可以通过环境变量 JVP_DEFAULT_TIMEZONE 配置
+	DefaultTimezone string
+
+	// DefaultLocale 是实例默认语言环境（如 en_US.UTF-8）
+	// 可以通过环境变量 JVP_DEFAULT_LOCALE 配置
+	DefaultLocale string
+
+	// DefaultNTPServers 是实例默认 NTP 服务器列表
+	// 可以通过环境变量 JVP_DEFAULT_NTP_SERVERS 配置，多个服务器用逗号分隔
+	DefaultNTPServers []string
+
+	// IDNamespaces 是否按资源类型隔离 ID 生成（实例、卷、快照等各自独立计数）
+	// 启用后可从 ID 中识别其资源类型来源
+	// 可以通过环境变量 JVP_ID_NAMESPACES 配置（true/false），默认关闭
+	IDNamespaces bool
+
+	// TransferBandwidthMiB 是大文件传输（镜像下载、存储迁移等）的全局限速（MiB/s），0 表示不限速
+	// 任务可单独指定限速覆盖全局值，运行时可通过 API 调整
+	// 可以通过环境变量 JVP_TRANSFER_BANDWIDTH_MIB 配置
+	TransferBandwidthMiB uint64
+
+	// RecycleRetentionDays 是回收站保留天数，0 表示不启用回收站（删除立即生效）
+	// 启用后删除实例和卷先进入回收站，到期后由后台任务物理清理
+	// 可以通过环境变量 JVP_RECYCLE_RETENTION_DAYS 配置
+	RecycleRetentionDays uint64
+
+	// NodeMaxConcurrentOperations 是每个节点同时执行的重 IO 操作（创建实例、下载模板）上限，0 表示不限制
+	// 超出上限的操作排队执行，运行时可通过 API 调整
+	// 可以通过环境变量 JVP_NODE_MAX_CONCURRENT_OPERATIONS 配置
+	NodeMaxConcurrentOperations uint64
+
+	// ShutdownTimeoutSeconds 是收到 SIGTERM/SIGINT 后优雅关停的最长等待时间（秒）
+	// 期间停止接收新请求，等待进行中的请求与后台操作完成，超时未完成的下载任务标记为中断
+	// 在 Kubernetes 中应小于 Pod 的 terminationGracePeriodSeconds
+	// 可以通过环境变量 JVP_SHUTDOWN_TIMEOUT_SECONDS 配置，默认 30
+	ShutdownTimeoutSeconds uint64
+
+	// TemplateCacheMaxGB 是每个存储池模板缓存目录（_template_cache_）的容量上限（GB），0 表示不限制
+	// 在其他节点注册的模板首次在本节点使用时拉取到缓存目录，超出上限时按最近使用时间清理未被引用的镜像
+	// 可以通过环境变量 JVP_TEMPLATE_CACHE_MAX_GB 配置
+	TemplateCacheMaxGB uint64
+
+	// TempDir 是节点上镜像转换（virt-v2v、OVA 解包）使用的临时目录，必须是绝对路径，为空时使用存储池的 _imports_ 目录
+	// 每个转换在其中创建独立子目录，转换结束（成功、失败或取消）后删除
+	// 可以通过环境变量 JVP_TEMP_DIR 配置
+	TempDir string
+
+	// TempQuotaGB 是每个节点上同时进行的转换可占用的临时空间总量上限（GB），0 表示不限制
+	// 超出配额的转换直接失败并提示等待其他转换结束，不排队
+	// 可以通过环境变量 JVP_TEMP_QUOTA_GB 配置
+	TempQuotaGB uint64
+
+	// OTLPEndpoint 是 OpenTelemetry trace 的 OTLP/HTTP 导出地址，如 http://otel-collector:4318，为空时不启用追踪
+	// 启用后每个 API 请求生成一个 trace，libvirt、qemu-img、zfs 与 SSH 调用记录为子 span
+	// 可以通过环境变量 JVP_OTLP_ENDPOINT 或 OTEL_EXPORTER_OTLP_ENDPOINT 配置
+	OTLPEndpoint string
+
+	// TraceSampleRatio 是新 trace 的采样比例 (0, 1]，请求头 traceparent 已采样的 trace 总是采样
+	// 可以通过环境变量 JVP_TRACE_SAMPLE_RATIO 配置，默认 1（全部采样）
+	TraceSampleRatio float64
+
+	// Region 是集群在 EC2 兼容接口（DescribeRegions、DescribeAvailabilityZones）中的区域名称
+	// 节点的 zone 标签映射为该区域下的可用区
+	// 可以通过环境变量 JVP_REGION 配置，默认 jvp
+	Region string
+
+	// ResponseEnvelope 成功响应是否统一包裹为 {request_id, metadata, data}
+	// 默认关闭以保持返回裸对象的旧行为，客户端也可以通过请求头 X-JVP-Envelope 按请求覆盖
+	// 可以通过环境变量 JVP_RESPONSE_ENVELOPE 配置
+	ResponseEnvelope bool
+
+	// ReadOnly 启动时是否处于只读（维护）模式，开启后所有写接口返回 ServiceUnavailable
+	// 运行期间可以通过 ModifyReadOnlyMode 接口切换，进程重启后回到该初始值
+	// 可以通过环境变量 JVP_READ_ONLY 配置，JVP_READ_ONLY_REASON 设置随错误返回的原因
+	ReadOnly       bool
+	ReadOnlyReason string
+
+	// DefaultNetworkType、DefaultNetworkSource 创建实例未指定 network_source 时使用的全局默认网络
+	// 未配置时桥接到 br0，节点上没有 br0 时回落到 libvirt default NAT 网络
+	// 可以通过环境变量 JVP_DEFAULT_NETWORK_TYPE（bridge、network，默认 bridge）与 JVP_DEFAULT_NETWORK_SOURCE 配置
+	DefaultNetworkType   string
+	DefaultNetworkSource string
+
+	// DHCPLeaseCacheSeconds 解析实例 IP 时 DHCP 租约缓存的有效期（秒），0 表示不缓存
+	// 缓存按节点保存，最早的租约过期或释放租约时提前失效
+	// 可以通过环境变量 JVP_DHCP_LEASE_CACHE_SECONDS 配置，默认 10
+	DHCPLeaseCacheSeconds uint64
+
+	// QEMUUser、QEMUGroup 修复 VNC socket 目录与以 root 创建的卷的属主时使用的 QEMU 进程用户与组
+	// 未配置时依次尝试 libvirt-qemu:kvm（Debian/Ubuntu）与 qemu:qemu（RHEL），节点可单独配置覆盖
+	// 可以通过环境变量 JVP_QEMU_USER、JVP_QEMU_GROUP 配置
+	QEMUUser  string
+	QEMUGroup string
+
+	// DisableOwnershipFix 是否关闭属主修复，由 ACL、libvirt dynamic_ownership 或存储池 permissions 处理
+	// 可以通过环境变量 JVP_DISABLE_OWNERSHIP_FIX 配置
+	DisableOwnershipFix bool
+
+	// StorageScanIntervalSeconds 定期刷新各节点存储池、发现手工放置或删除的卷的间隔（秒），0 表示不扫描
+	// 手工放入存储池 _templates_ 目录的镜像文件在连续两次扫描大小不变后自动注册为 public 模板
+	// 可以通过环境变量 JVP_STORAGE_SCAN_INTERVAL_SECONDS 配置，默认 60
+	StorageScanIntervalSeconds uint64
+
+	// UsageIntervalSeconds 采集实例 CPU 时间、磁盘占用与网络流量生成计费用量记录的间隔（秒），0 表示不采集
+	// 可以通过环境变量 JVP_USAGE_INTERVAL_SECONDS 配置，默认 3600（每条记录覆盖一小时）
+	UsageIntervalSeconds uint64
+
+	// UsageRetentionDays 用量记录保留天数，0 表示永久保留
+	// 可以通过环境变量 JVP_USAGE_RETENTION_DAYS 配置，默认 400
+	UsageRetentionDays uint64
+
+	// NodeClockSkewThresholdMs 节点时钟与控制面偏差的告警阈值（毫秒），0 表示不告警
+	// 跨节点的快照、事件与日志排序依赖节点时钟，超过阈值时列举节点在 warnings 中告警
+	// 可以通过环境变量 JVP_NODE_CLOCK_SKEW_THRESHOLD_MS 配置，默认 1000
+	NodeClockSkewThresholdMs uint64
+
+	// IPv6NDPProxy 是否为 libvirt 网络中 ipv6/dual 实例在节点上游网卡添加 NDP 代理
+	// 适用于上游路由器未把网络的 IPv6 前缀路由到节点、而是直接在链路上做邻居发现的机房
+	// 可以通过环境变量 JVP_IPV6_NDP_PROXY 配置
+	IPv6NDPProxy bool
+
+	// OVSPortCleanupIntervalSeconds 定期清理各节点 Open vSwitch 残留端口与防 MAC 欺骗流表的间隔（秒），0 表示不清理
+	// 可以通过环境变量 JVP_OVS_PORT_CLEANUP_INTERVAL_SECONDS 配置，默认 0（未使用 OVS 的节点无需开启）
+	OVSPortCleanupIntervalSeconds uint64
+
+	// MetadataAddress 元数据服务的监听地址（如 0.0.0.0:8775），为空时不启用
+	// guest 访问 169.254.169.254 获取 EC2 兼容的元数据与 user-data，按请求的源 IP 经 DHCP 租约找到实例，
+	// 节点需把 169.254.169.254:80 转发（DNAT）到该地址并保留源 IP
+	// 可以通过环境变量 JVP_METADATA_ADDRESS 配置
+	MetadataAddress string
+
+	// AdminToken 管理员凭据，配置后只有带 Authorization: Bearer <token> 的请求具有跨租户的管理员权限
+	// 其余请求必须通过请求头 X-JVP-Tenant 声明租户，未声明或凭据错误返回 401
+	// 未配置时不做租户认证：X-JVP-Tenant 只是按租户过滤与记录归属的约定，未声明租户的请求按管理员处理
+	// X-JVP-Tenant 本身不校验身份，多租户部署应由前置网关认证后设置该请求头并覆盖客户端传入的值
+	// 可以通过环境变量 JVP_ADMIN_TOKEN 配置
+	AdminToken string
 }
 
+// defaultShutdownTimeoutSeconds 默认优雅关停等待时间（秒）
+const defaultShutdownTimeoutSeconds = 30
+
+// defaultDHCPLeaseCacheSeconds 默认 DHCP 租约缓存有效期（秒）
+const defaultDHCPLeaseCacheSeconds = 10
+
+// defaultStorageScanIntervalSeconds 默认存储池扫描间隔（秒）
+const defaultStorageScanIntervalSeconds = 60
+
+// defaultUsageIntervalSeconds 默认用量采集间隔（秒）
+const defaultUsageIntervalSeconds = 3600
+
+// defaultUsageRetentionDays 默认用量记录保留天数
+const defaultUsageRetentionDays = 400
+
+// defaultNodeClockSkewThresholdMs 默认节点时钟偏差告警阈值（毫秒）
+const defaultNodeClockSkewThresholdMs = 1000
+
+// defaultRegion 默认区域名称
+const defaultRegion = "jvp"
+
 func New() (*Config, error) {
 	cfg := &Config{
-		LibvirtURI: getLibvirtURI(),
-		DataDir:    getDataDir(),
-		Address:    getAddress(),
+		LibvirtURI:                  getLibvirtURI(),
+		DataDir:                     getDataDir(),
+		Address:                     getAddress(),
+		DefaultTimezone:             os.Getenv("JVP_DEFAULT_TIMEZONE"),
+		DefaultLocale:               os.Getenv("JVP_DEFAULT_LOCALE"),
+		DefaultNTPServers:           getDefaultNTPServers(),
+		IDNamespaces:                getBoolEnv("JVP_ID_NAMESPACES"),
+		TransferBandwidthMiB:        getUintEnv("JVP_TRANSFER_BANDWIDTH_MIB"),
+		RecycleRetentionDays:        getUintEnv("JVP_RECYCLE_RETENTION_DAYS"),
+		NodeMaxConcurrentOperations: getUintEnv("JVP_NODE_MAX_CONCURRENT_OPERATIONS"),
+		ShutdownTimeoutSeconds:      getUintEnv("JVP_SHUTDOWN_TIMEOUT_SECONDS"),
+		TemplateCacheMaxGB:          getUintEnv("JVP_TEMPLATE_CACHE_MAX_GB"),
+		TempDir:                     os.Getenv("JVP_TEMP_DIR"),
+		TempQuotaGB:                 getUintEnv("JVP_TEMP_QUOTA_GB"),
+		OTLPEndpoint:                getOTLPEndpoint(),
+		TraceSampleRatio:            getFloatEnv("JVP_TRACE_SAMPLE_RATIO"),
+		Region:                      os.Getenv("JVP_REGION"),
+		ResponseEnvelope:            getBoolEnv("JVP_RESPONSE_ENVELOPE"),
+		ReadOnly:                    getBoolEnv("JVP_READ_ONLY"),
+		ReadOnlyReason:              os.Getenv("JVP_READ_ONLY_REASON"),
+		DefaultNetworkType:          os.Getenv("JVP_DEFAULT_NETWORK_TYPE"),
+		DefaultNetworkSource:        os.Getenv("JVP_DEFAULT_NETWORK_SOURCE"),
+		DHCPLeaseCacheSeconds:       getUintEnv("JVP_DHCP_LEASE_CACHE_SECONDS"),
+		QEMUUser:                    os.Getenv("JVP_QEMU_USER"),
+		QEMUGroup:                   os.Getenv("JVP_QEMU_GROUP"),
+		DisableOwnershipFix:         getBoolEnv("JVP_DISABLE_OWNERSHIP_FIX"),
+		StorageScanIntervalSeconds:  getUintEnv("JVP_STORAGE_SCAN_INTERVAL_SECONDS"),
+		UsageIntervalSeconds:        getUintEnv("JVP_USAGE_INTERVAL_SECONDS"),
+		UsageRetentionDays:          getUintEnv("JVP_USAGE_RETENTION_DAYS"),
+		NodeClockSkewThresholdMs:    getUintEnv("JVP_NODE_CLOCK_SKEW_THRESHOLD_MS"),
+		IPv6NDPProxy:                getBoolEnv("JVP_IPV6_NDP_PROXY"),
+
+		OVSPortCleanupIntervalSeconds: getUintEnv("JVP_OVS_PORT_CLEANUP_INTERVAL_SECONDS"),
+		MetadataAddress:               os.Getenv("JVP_METADATA_ADDRESS"),
+		AdminToken:                    os.Getenv("JVP_ADMIN_TOKEN"),
+	}
+	if _, ok := os.LookupEnv("JVP_DHCP_LEASE_CACHE_SECONDS"); !ok {
+		cfg.DHCPLeaseCacheSeconds = defaultDHCPLeaseCacheSeconds
+	}
+	if _, ok := os.LookupEnv("JVP_STORAGE_SCAN_INTERVAL_SECONDS"); !ok {
+		cfg.StorageScanIntervalSeconds = defaultStorageScanIntervalSeconds
+	}
+	if _, ok := os.LookupEnv("JVP_USAGE_INTERVAL_SECONDS"); !ok {
+		cfg.UsageIntervalSeconds = defaultUsageIntervalSeconds
+	}
+	if _, ok := os.LookupEnv("JVP_USAGE_RETENTION_DAYS"); !ok {
+		cfg.UsageRetentionDays = defaultUsageRetentionDays
+	}
+	if _, ok := os.LookupEnv("JVP_NODE_CLOCK_SKEW_THRESHOLD_MS"); !ok {
+		cfg.NodeClockSkewThresholdMs = defaultNodeClockSkewThresholdMs
+	}
+	if cfg.ShutdownTimeoutSeconds == 0 {
+		cfg.ShutdownTimeoutSeconds = defaultShutdownTimeoutSeconds
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultRegion
+	}
+	if cfg.TempDir != "" && !filepath.IsAbs(cfg.TempDir) {
+		return nil, fmt.Errorf("JVP_TEMP_DIR %q must be an absolute path", cfg.TempDir)
 	}
 	return cfg, nil
 }
 
+// ShutdownTimeout 返回优雅关停的最长等待时间
+func (c *Config) ShutdownTimeout() time.Duration {
+	return time.Duration(c.ShutdownTimeoutSeconds) * time.Second
+}
+
+// DHCPLeaseCacheTTL 返回 DHCP 租约缓存的有效期
+func (c *Config) DHCPLeaseCacheTTL() time.Duration {
+	return time.Duration(c.DHCPLeaseCacheSeconds) * time.Second
+}
+
+// StorageScanInterval 返回存储池扫描间隔
+func (c *Config) StorageScanInterval() time.Duration {
+	return time.Duration(c.StorageScanIntervalSeconds) * time.Second
+}
+
+// OVSPortCleanupInterval 返回 OVS 残留端口清理间隔
+func (c *Config) OVSPortCleanupInterval() time.Duration {
+	return time.Duration(c.OVSPortCleanupIntervalSeconds) * time.Second
+}
+
+// UsageInterval 返回用量采集间隔
+func (c *Config) UsageInterval() time.Duration {
+	return time.Duration(c.UsageIntervalSeconds) * time.Second
+}
+
+// UsageRetention 返回用量记录保留时长
+func (c *Config) UsageRetention() time.Duration {
+	return time.Duration(c.UsageRetentionDays) * 24 * time.Hour
+}
+
+// NodeClockSkewThreshold 返回节点时钟偏差告警阈值
+func (c *Config) NodeClockSkewThreshold() time.Duration {
+	return time.Duration(c.NodeClockSkewThresholdMs) * time.Millisecond
+}
+
 // getLibvirtURI 获取 libvirt URI，优先使用环境变量
 func getLibvirtURI() string {
 	// 1. 优先使用环境变量 LIBVIRT_URI
@@ -72,3 +328,54 @@ func getAddress() string {
 
 	return "0.0.0.0:7777"
 }
+
+// getOTLPEndpoint 获取 OTLP 导出地址，优先使用 JVP_OTLP_ENDPOINT，其次使用 OpenTelemetry 标准环境变量
+func getOTLPEndpoint() string {
+	if endpoint := os.Getenv("JVP_OTLP_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+}
+
+// getDefaultNTPServers 从环境变量 JVP_DEFAULT_NTP_SERVERS 解析 NTP 服务器列表
+func getDefaultNTPServers() []string {
+	value := os.Getenv("JVP_DEFAULT_NTP_SERVERS")
+	if value == "" {
+		return nil
+	}
+
+	var servers []string
+	for _, server := range strings.Split(value, ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// getBoolEnv 解析布尔类型的环境变量，未设置或无法解析时返回 false
+func getBoolEnv(key string) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return false
+	}
+	return value
+}
+
+// getUintEnv 解析无符号整数类型的环境变量，未设置或无法解析时返回 0
+func getUintEnv(key string) uint64 {
+	value, err := strconv.ParseUint(os.Getenv(key), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// getFloatEnv 解析浮点类型的环境变量，未设置或无法解析时返回 0
+func getFloatEnv(key string) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
@@ -0,0 +1,17 @@
+package entity
+
+// AppTemplate 内置应用模板，创建实例时通过 app_template 选择，自动合并对应的 cloud-init 软件包、文件与启动命令
+type AppTemplate struct {
+	Name        string   `json:"name"`               // 模板名称：docker-host, k8s-worker, nfs-server
+	Description string   `json:"description"`        // 模板描述
+	Packages    []string `json:"packages,omitempty"` // 安装的软件包
+	Commands    []string `json:"commands,omitempty"` // 启动后执行的命令
+}
+
+// DescribeAppTemplatesRequest 查询内置应用模板请求
+type DescribeAppTemplatesRequest struct{}
+
+// DescribeAppTemplatesResponse 查询内置应用模板响应
+type DescribeAppTemplatesResponse struct {
+	AppTemplates []AppTemplate `json:"app_templates"`
+}
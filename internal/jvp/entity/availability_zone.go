@@ -0,0 +1,46 @@
+package entity
+
+// 可用区状态
+const (
+	AvailabilityZoneStateAvailable   = "available"   // 至少有一个节点在线
+	AvailabilityZoneStateUnavailable = "unavailable" // 所有节点离线或处于维护模式
+)
+
+// Region 区域，对应当前 JVP 集群
+type Region struct {
+	RegionName  string `json:"region_name"`
+	OptInStatus string `json:"opt_in_status"` // 固定为 opt-in-not-required，与 EC2 保持一致
+}
+
+// DescribeRegionsRequest 查询区域请求
+type DescribeRegionsRequest struct {
+	RegionNames []string `json:"region_names,omitempty"` // 按名称过滤（可选）
+}
+
+// DescribeRegionsResponse 查询区域响应
+type DescribeRegionsResponse struct {
+	Regions []Region `json:"regions"`
+}
+
+// AvailabilityZone 可用区，由 zone 标签相同的节点组成
+type AvailabilityZone struct {
+	ZoneName   string   `json:"zone_name"`
+	ZoneID     string   `json:"zone_id"`     // <region>-<zone>
+	ZoneType   string   `json:"zone_type"`   // 固定为 availability-zone
+	State      string   `json:"state"`       // available, unavailable
+	RegionName string   `json:"region_name"` // 所属区域
+	GroupName  string   `json:"group_name"`  // 与 EC2 一致，等于区域名
+	NodeNames  []string `json:"node_names"`  // 可用区内的节点
+}
+
+// DescribeAvailabilityZonesRequest 查询可用区请求
+type DescribeAvailabilityZonesRequest struct {
+	ZoneNames []string `json:"zone_names,omitempty"` // 按名称过滤（可选）
+	ZoneIDs   []string `json:"zone_ids,omitempty"`   // 按 ID 过滤（可选）
+	Filters   []Filter `json:"filters,omitempty"`    // 支持 zone-name、zone-id、state、region-name
+}
+
+// DescribeAvailabilityZonesResponse 查询可用区响应
+type DescribeAvailabilityZonesResponse struct {
+	AvailabilityZones []AvailabilityZone `json:"availability_zones"`
+}
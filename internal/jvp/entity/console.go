@@ -4,17 +4,36 @@ package entity
 type GetConsoleRequest struct {
 	NodeName   string `json:"node_name" binding:"required"`   // 节点名称
 	InstanceID string `json:"instance_id" binding:"required"` // 实例 ID
-	Type       string `json:"type"`                           // vnc, serial, 为空则返回两种都支持的信息
+	Type       string `json:"type"`                           // vnc, spice, serial, 为空则返回 vnc 与 serial 的信息
 }
 
 // GetConsoleResponse 获取控制台连接信息响应
 type GetConsoleResponse struct {
 	InstanceID   string `json:"instance_id"`
-	VNCSocket    string `json:"vnc_socket,omitempty"`    // VNC Unix Socket 路径
-	VNCPort      int    `json:"vnc_port,omitempty"`      // VNC WebSocket 代理端口 (由前端连接)
-	VNCToken     string `json:"vnc_token,omitempty"`     // VNC 连接认证 token (可选)
-	SerialDevice string `json:"serial_device,omitempty"` // Serial PTY 设备路径
-	SerialPort   int    `json:"serial_port,omitempty"`   // Serial WebSocket 代理端口
-	SerialToken  string `json:"serial_token,omitempty"`  // Serial 连接认证 token (可选)
-	Type         string `json:"type"`                    // 返回的控制台类型: vnc, serial, both
+	VNCSocket    string `json:"vnc_socket,omitempty"`     // VNC Unix Socket 路径
+	VNCPort      int    `json:"vnc_port,omitempty"`       // VNC WebSocket 代理端口 (由前端连接)
+	VNCToken     string `json:"vnc_token,omitempty"`      // VNC 连接认证 token (可选)
+	SPICEListen  string `json:"spice_listen,omitempty"`   // SPICE 监听地址，为 127.0.0.1 时需通过 SSH 隧道连接
+	SPICEPort    int    `json:"spice_port,omitempty"`     // SPICE 端口
+	SPICETLSPort int    `json:"spice_tls_port,omitempty"` // SPICE TLS 端口
+	SerialDevice string `json:"serial_device,omitempty"`  // Serial PTY 设备路径
+	SerialPort   int    `json:"serial_port,omitempty"`    // Serial WebSocket 代理端口
+	SerialToken  string `json:"serial_token,omitempty"`   // Serial 连接认证 token (可选)
+	SerialLog    string `json:"serial_log,omitempty"`     // Serial 输出日志文件路径
+	Type         string `json:"type"`                     // 返回的控制台类型: vnc, spice, serial, both
+}
+
+// GetConsoleOutputRequest 获取串口输出日志请求
+type GetConsoleOutputRequest struct {
+	NodeName   string `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID string `json:"instance_id" binding:"required"` // 实例 ID
+	MaxBytes   int64  `json:"max_bytes"`                      // 最多返回日志末尾多少字节（默认 64KiB，-1 表示完整日志）
+}
+
+// GetConsoleOutputResponse 获取串口输出日志响应
+type GetConsoleOutputResponse struct {
+	InstanceID string `json:"instance_id"`
+	LogPath    string `json:"log_path"`  // 节点上的日志文件路径
+	Output     string `json:"output"`    // 日志内容
+	Truncated  bool   `json:"truncated"` // 是否只返回了日志末尾部分
 }
@@ -0,0 +1,8 @@
+package entity
+
+import "github.com/jimyag/jvp/pkg/apierror"
+
+// ListErrorCodesResponse 错误目录响应
+type ListErrorCodesResponse struct {
+	ErrorCodes []apierror.ErrorCode `json:"error_codes"` // 按代码排序
+}
@@ -1,22 +1,72 @@
 // Package entity 定义业务实体
 package entity
 
+import "time"
+
 // Instance 实例信息
 type Instance struct {
-	ID         string              `json:"id"`                    // Instance ID (domain name)
-	Name       string              `json:"name"`                  // 实例名称
-	State      string              `json:"state"`                 // 状态：running, stopped, pending, failed
-	NodeName   string              `json:"node_name"`             // 所在节点名称
-	TemplateID string              `json:"template_id,omitempty"` // 使用的模板 ID（可选，非 JVP 创建的 VM 为空）
-	MemoryMB   uint64              `json:"memory_mb"`             // 内存大小（MB）
-	VCPUs      uint16              `json:"vcpus"`                 // 虚拟 CPU 数量
-	CreatedAt  string              `json:"created_at"`            // 创建时间
-	StartedAt  string              `json:"started_at,omitempty"`  // 启动时间
-	DomainUUID string              `json:"domain_uuid"`           // Libvirt Domain UUID
-	DomainName string              `json:"domain_name"`           // Libvirt Domain 名称
-	Autostart  bool                `json:"autostart"`             // 是否开机自启动
-	Interfaces []InstanceInterface `json:"interfaces,omitempty"`  // 网络接口信息
-	Disks      []InstanceDisk      `json:"disks,omitempty"`       // 磁盘信息
+	ID                    string              `json:"id"`                      // Instance ID (domain name)
+	Name                  string              `json:"name"`                    // 实例名称
+	State                 string              `json:"state"`                   // 状态：running, stopped, pending, failed
+	NodeName              string              `json:"node_name"`               // 所在节点名称
+	Zone                  string              `json:"zone,omitempty"`          // 所在节点的可用区
+	Rack                  string              `json:"rack,omitempty"`          // 所在节点的机架
+	TemplateID            string              `json:"template_id,omitempty"`   // 使用的模板 ID（可选，非 JVP 创建的 VM 为空）
+	MemoryMB              uint64              `json:"memory_mb"`               // 内存大小（MB）
+	MaxMemoryMB           uint64              `json:"max_memory_mb"`           // 内存热插上限（MB），运行中热修改不能超过该值
+	VCPUs                 uint16              `json:"vcpus"`                   // 虚拟 CPU 数量
+	MaxVCPUs              uint16              `json:"max_vcpus"`               // vCPU 热插上限，运行中热修改不能超过该值
+	CPUTune               *CPUTune            `json:"cputune,omitempty"`       // CPU 权重与上限，未配置时为空
+	BlkioTune             *BlkioTune          `json:"blkiotune,omitempty"`     // 磁盘 IO 权重，未配置时为空
+	NUMATune              *NUMATune           `json:"numatune,omitempty"`      // NUMA 绑定，未绑定单个 cell 时为空
+	CreatedAt             string              `json:"created_at"`              // 创建时间
+	StartedAt             string              `json:"started_at,omitempty"`    // 启动时间
+	DomainUUID            string              `json:"domain_uuid"`             // Libvirt Domain UUID
+	DomainName            string              `json:"domain_name"`             // Libvirt Domain 名称
+	Autostart             bool                `json:"autostart"`               // 是否开机自启动
+	DisableAPITermination bool                `json:"disable_api_termination"` // 删除保护，开启后删除实例返回 OperationNotPermitted
+	Interfaces            []InstanceInterface `json:"interfaces,omitempty"`    // 网络接口信息
+	Disks                 []InstanceDisk      `json:"disks,omitempty"`         // 磁盘信息
+	Version               string              `json:"version,omitempty"`       // 配置版本号，配置变更后改变，修改请求可通过 If-Match 携带以避免覆盖并发修改
+	Revision              uint64              `json:"revision"`                // 最后一次变化的全局 revision，服务启动后未变化过时为 0
+	Owner                 string              `json:"owner,omitempty"`         // 所属租户，创建时取自请求头 X-JVP-Tenant，为空表示由管理员创建
+	Labels                map[string]string   `json:"labels,omitempty"`        // 标签，用于按组选择实例（如 env=staging）
+	CPUModel              string              `json:"cpu_model,omitempty"`     // CPU 型号，host-passthrough/host-model 时为模式名，未配置时为空
+	Platform              string              `json:"platform,omitempty"`      // guest 平台：linux, windows，guest-agent 尚未上报时为空
+	GuestOS               *GuestOSInfo        `json:"guest_os,omitempty"`      // guest-agent 上报的操作系统信息，尚未上报时为空
+
+	AddressFamily string `json:"address_family,omitempty"`  // 网卡地址族：ipv6, dual，为空表示 ipv4
+	MACSpoofCheck bool   `json:"mac_spoof_check,omitempty"` // OVS 端口是否开启防 MAC 欺骗
+}
+
+// 实例网卡地址族
+const (
+	AddressFamilyIPv4 = "ipv4"
+	AddressFamilyIPv6 = "ipv6"
+	AddressFamilyDual = "dual"
+)
+
+// cloud-init 配置的下发方式
+const (
+	CloudInitSourceISO      = "iso"      // 生成 cidata ISO 挂载为光驱
+	CloudInitSourceMetadata = "metadata" // guest 通过网络从元数据服务（169.254.169.254）拉取
+)
+
+// guest 平台
+const (
+	PlatformLinux   = "linux"
+	PlatformWindows = "windows"
+)
+
+// GuestOSInfo 通过 guest-agent（guest-get-osinfo）获取并缓存的 guest 操作系统信息
+type GuestOSInfo struct {
+	ID            string    `json:"id,omitempty"`             // 发行版 ID，如 ubuntu、centos，Windows 为 mswindows
+	Name          string    `json:"name,omitempty"`           // 操作系统名称
+	PrettyName    string    `json:"pretty_name,omitempty"`    // 完整名称，如 Ubuntu 22.04.4 LTS
+	VersionID     string    `json:"version_id,omitempty"`     // 版本号，如 22.04
+	KernelRelease string    `json:"kernel_release,omitempty"` // 内核版本（Windows 为 build 号）
+	Machine       string    `json:"machine,omitempty"`        // guest 内核报告的架构，如 x86_64
+	UpdatedAt     time.Time `json:"updated_at"`               // 最近一次从 guest-agent 获取的时间
 }
 
 // InstanceDisk 磁盘信息
@@ -24,32 +74,139 @@ type InstanceDisk struct {
 	Target      string `json:"target"`
 	Path        string `json:"path"`
 	Format      string `json:"format"`
+	Bus         string `json:"bus,omitempty"`
+	Serial      string `json:"serial,omitempty"`
+	Cache       string `json:"cache,omitempty"`
+	IO          string `json:"io,omitempty"`
+	Discard     string `json:"discard,omitempty"`
 	CapacityB   uint64 `json:"capacity_b,omitempty"`
 	AllocationB uint64 `json:"allocation_b,omitempty"`
 }
 
 // InstanceInterface 网络接口信息
 type InstanceInterface struct {
-	Name   string   `json:"name"`
-	Type   string   `json:"type"`
-	Source string   `json:"source"`
-	MAC    string   `json:"mac"`
-	IPs    []string `json:"ips,omitempty"`
+	Name      string              `json:"name"`
+	Type      string              `json:"type"`
+	Source    string              `json:"source"`
+	MAC       string              `json:"mac"`
+	IPs       []string            `json:"ips,omitempty"`
+	Bandwidth *InterfaceBandwidth `json:"bandwidth,omitempty"` // 网卡限速，未限速时为空
+
+	VirtualPort string `json:"virtual_port,omitempty"` // 虚拟交换机端口类型，接入 OVS 网桥时为 openvswitch
+	InterfaceID string `json:"interface_id,omitempty"` // OVS 端口的 iface-id（external_ids:iface-id），SDN 控制器按它关联端口
+	VLAN        int    `json:"vlan,omitempty"`         // OVS access 端口的 VLAN tag
+
+	SecurityGroupIDs []string `json:"security_group_ids,omitempty"` // 网卡关联的安全组
+}
+
+// InterfaceBandwidth 网卡限速（QoS），对应 interface 的 bandwidth 元素
+type InterfaceBandwidth struct {
+	Inbound  *BandwidthRate `json:"inbound,omitempty"`  // 入方向（宿主机 → 实例）
+	Outbound *BandwidthRate `json:"outbound,omitempty"` // 出方向（实例 → 宿主机）
+}
+
+// BandwidthRate 单方向限速参数
+type BandwidthRate struct {
+	Average uint64 `json:"average"`         // 平均速率（KiB/s），0 表示不限速
+	Peak    uint64 `json:"peak,omitempty"`  // 峰值速率（KiB/s），不能小于 average
+	Burst   uint64 `json:"burst,omitempty"` // 以峰值速率可突发的数据量（KiB）
+}
+
+// CPUTune CPU 调度参数（cputune），用于超卖环境下保证关键实例的 CPU 资源
+type CPUTune struct {
+	Shares uint64 `json:"shares,omitempty"` // CPU 权重，争抢时按比例分配（默认 1024，范围 2-262144）
+	Period uint64 `json:"period,omitempty"` // vCPU 调度周期（微秒，范围 1000-1000000）
+	Quota  int64  `json:"quota,omitempty"`  // 每个周期内每个 vCPU 可用的 CPU 时间（微秒，范围 1000-17592186044415），负数表示不限制
+}
+
+// BlkioTune 实例级磁盘 IO 调优参数（blkiotune），用于超卖环境下的 IO 隔离
+type BlkioTune struct {
+	Weight uint `json:"weight,omitempty"` // IO 权重，磁盘争抢时按比例分配（默认 500，范围 10-1000）
+}
+
+// NUMATune 实例的 NUMA 绑定（numatune），vCPU 只在 cell 内的 CPU 上调度，内存从该 cell 分配
+type NUMATune struct {
+	Node *uint32 `json:"node,omitempty"` // NUMA cell ID，为空时选择剩余资源足够且 vCPU 最空闲的 cell
+	Mode string  `json:"mode,omitempty"` // 内存绑定模式：strict（默认，cell 内存不足时启动失败）、preferred、restrictive
 }
 
 // RunInstanceRequest 创建实例请求
 type RunInstanceRequest struct {
-	NodeName      string          `json:"node_name" binding:"required"` // 目标节点名称
-	PoolName      string          `json:"pool_name" binding:"required"` // 目标存储池名称
-	TemplateID    string          `json:"template_id"`                  // 模板 ID（可选，如果不提供则创建空白 VM）
-	Name          string          `json:"name"`                         // 实例名称（可选，自动生成）
-	SizeGB        uint64          `json:"size_gb"`                      // 磁盘大小（GB）（可选，默认使用模板大小）
-	MemoryMB      uint64          `json:"memory_mb"`                    // 内存大小（MB）（可选，默认 2048MB）
-	VCPUs         uint16          `json:"vcpus"`                        // 虚拟 CPU 数量（可选，默认 2）
-	NetworkType   string          `json:"network_type,omitempty"`       // 网络类型：bridge, network（默认：bridge）
-	NetworkSource string          `json:"network_source,omitempty"`     // 网络源：网桥名称或网络名称（默认：br0）
-	UserData      *UserDataConfig `json:"user_data,omitempty"`          // UserData 配置（可选）
-	KeyPairIDs    []string        `json:"keypair_ids,omitempty"`        // 密钥对 ID 列表（可选）
+	NodeName              string              `json:"node_name"`                         // 目标节点名称（与 zone 至少指定一个；为空时在 zone 内自动选择节点）
+	Zone                  string              `json:"zone,omitempty"`                    // 目标可用区（可选）：未指定 node_name 时在该可用区内按机架分散选择节点；同时指定时校验节点属于该可用区
+	PoolName              string              `json:"pool_name" binding:"required"`      // 目标存储池名称
+	TemplateID            string              `json:"template_id"`                       // 模板 ID（可选，如果不提供则创建空白 VM）
+	TemplateNodeName      string              `json:"template_node_name,omitempty"`      // 模板所在节点（可选，默认为 node_name）；与 node_name 不同时首次使用会把模板拉取到目标节点的缓存
+	TemplatePoolName      string              `json:"template_pool_name,omitempty"`      // 模板所在存储池（可选，默认为 pool_name）
+	Name                  string              `json:"name"`                              // 实例名称（可选，自动生成）
+	SizeGB                uint64              `json:"size_gb"`                           // 磁盘大小（GB）（可选，默认使用模板大小）
+	MemoryMB              uint64              `json:"memory_mb"`                         // 内存大小（MB）（可选，默认 2048MB）
+	VCPUs                 uint16              `json:"vcpus"`                             // 虚拟 CPU 数量（可选，默认 2）
+	MaxMemoryMB           uint64              `json:"max_memory_mb,omitempty"`           // 内存热插上限（MB）（可选，默认等于 memory_mb，即运行中不能扩容）
+	MaxVCPUs              uint16              `json:"max_vcpus,omitempty"`               // vCPU 热插上限（可选，默认等于 vcpus，即运行中不能扩容）
+	CPUTune               *CPUTune            `json:"cputune,omitempty"`                 // CPU 权重与上限（可选）：shares、period、quota
+	BlkioTune             *BlkioTune          `json:"blkiotune,omitempty"`               // 磁盘 IO 权重（可选）
+	NUMATune              *NUMATune           `json:"numatune,omitempty"`                // NUMA 绑定（可选）：vCPU 与内存放在同一个 NUMA cell，适合大内存实例
+	NestedVirtualization  bool                `json:"nested_virtualization,omitempty"`   // 嵌套虚拟化（可选）：CPU 使用 host-passthrough 并暴露 vmx/svm，用于在实例内运行 KVM/minikube
+	Architecture          string              `json:"architecture,omitempty"`            // guest 架构：x86_64, i686, aarch64（默认：x86_64；aarch64 使用 virt 机器类型与 UEFI 固件，模板镜像需为同一架构）
+	MachineType           string              `json:"machine_type,omitempty"`            // 机器类型（可选，如 q35、virt；默认由架构决定）
+	DiskBus               string              `json:"disk_bus,omitempty"`                // 系统盘总线：virtio, scsi, nvme（默认：virtio；Windows 等对 SCSI 兼容性更好）
+	DiskQueues            int                 `json:"disk_queues,omitempty"`             // disk_bus=scsi 时 virtio-scsi 控制器的队列数（可选）
+	DiskIOThread          bool                `json:"disk_iothread,omitempty"`           // disk_bus=scsi 时为控制器分配独立 iothread（可选）
+	DiskDriver            *DiskDriverOptions  `json:"disk_driver,omitempty"`             // 系统盘 driver 参数（可选，未设置的字段使用 qcow2 的默认值 cache=none,io=threads,discard=unmap）
+	NetworkType           string              `json:"network_type,omitempty"`            // 网络类型：bridge, network, direct, ovs（默认：bridge）；ovs 接入 Open vSwitch 网桥
+	NetworkSource         string              `json:"network_source,omitempty"`          // 网络源：网桥名称（ovs 时为 OVS 网桥）或网络名称（默认：JVP_DEFAULT_NETWORK_SOURCE 或 br0，节点上没有 br0 时回落到 libvirt default 网络）；direct 时为宿主机网卡，见 DescribeNodeNetwork 的 direct_sources
+	NetworkDirectMode     string              `json:"network_direct_mode,omitempty"`     // network_type=direct 时的 macvtap 模式：vepa, bridge, private, passthrough（默认：bridge）
+	NetworkBandwidth      *InterfaceBandwidth `json:"network_bandwidth,omitempty"`       // 网卡限速（可选）：inbound/outbound 的 average、peak、burst
+	NetworkVLAN           int                 `json:"network_vlan,omitempty"`            // network_type=ovs 时端口的 VLAN tag（可选，1-4094）
+	NetworkInterfaceID    string              `json:"network_interface_id,omitempty"`    // network_type=ovs 时端口的 interfaceid（可选，UUID，默认自动生成），用于与 SDN 控制器（如 OVN）的逻辑端口关联
+	NetworkMACSpoofCheck  bool                `json:"network_mac_spoof_check,omitempty"` // network_type=ovs 时开启防 MAC 欺骗：OVS 端口只放行源 MAC 为实例 MAC 的报文（可选）
+	SecurityGroupIDs      []string            `json:"security_group_ids,omitempty"`      // 安全组 ID 列表（可选，最多 5 个，仅 network_type 为 bridge 或 network）：网卡只放行各安全组规则允许的流量
+	AddressFamily         string              `json:"address_family,omitempty"`          // 网卡地址族：ipv4, ipv6, dual（默认：ipv4）；ipv6/dual 需基于 cloud-init 模板创建，network_type=network 时网络需配置 ipv6_address
+	UserData              *UserDataConfig     `json:"user_data,omitempty"`               // UserData 配置（可选）
+	KeyPairIDs            []string            `json:"keypair_ids,omitempty"`             // 密钥对 ID 列表（可选）
+	DefaultUser           string              `json:"default_user,omitempty"`            // keypair_ids 注入的登录用户（可选，默认依次取 user_data 的第一个用户、模板的 os.default_user，都没有时注入发行版 cloud-init 默认用户）
+	LoginUsers            []LoginUser         `json:"login_users,omitempty"`             // 额外注入的登录用户（可选），每个用户注入各自的密钥对
+	AppTemplate           string              `json:"app_template,omitempty"`            // 内置应用模板（可选）：docker-host, k8s-worker, nfs-server，合并对应的软件包、文件与启动命令到 cloud-init 配置
+	CloudInitSource       string              `json:"cloud_init_source,omitempty"`       // cloud-init 配置的下发方式：iso, metadata（默认：iso）；metadata 不生成 cidata ISO，需启用元数据服务且 network_type=network
+	IgnitionConfig        string              `json:"ignition_config,omitempty"`         // Ignition 配置 JSON（可选）：Fedora CoreOS/Flatcar 模板自动使用 Ignition 代替 cloud-init，通过 fw_cfg 注入
+	SerialType            string              `json:"serial_type,omitempty"`             // 串口类型：pty, file, tcp（默认：pty，输出均会写入日志文件）
+	SerialTCPPort         int                 `json:"serial_tcp_port,omitempty"`         // serial_type=tcp 时的监听端口（仅监听 127.0.0.1）
+	InstallISO            string              `json:"install_iso,omitempty"`             // 安装 ISO 的卷 ID（可选，位于 pool_name 中；指定后创建空白盘并从 ISO 安装）
+	BootOrder             []string            `json:"boot_order,omitempty"`              // 启动设备顺序（可选）：hd, cdrom, network；如 ["hd", "cdrom"] 空盘时从 ISO 安装，装好后自动从硬盘启动
+	Kernel                string              `json:"kernel,omitempty"`                  // 直接引导的内核路径（可选，节点上的绝对路径）：跳过磁盘上的 bootloader，适合测试内核或无需完整镜像的最小 VM
+	Initrd                string              `json:"initrd,omitempty"`                  // 直接引导的 initrd 路径（可选，需要 kernel）
+	KernelCmdline         string              `json:"kernel_cmdline,omitempty"`          // 直接引导的内核命令行（可选，需要 kernel），如 console=ttyS0 root=/dev/vda1
+	Devices               *DeviceOptions      `json:"devices,omitempty"`                 // 可选设备开关（可选）：TPM、声卡、看门狗、RNG
+	SPICE                 *SPICEOptions       `json:"spice,omitempty"`                   // SPICE 图形协议（可选）：在 VNC 之外提供，支持剪贴板共享、分辨率自适应与 USB 重定向
+	QEMUArgs              []string            `json:"qemu_args,omitempty"`               // 透传给 QEMU 的附加命令行参数（可选，高级用法）：默认只允许 -device、-global、-smbios 等白名单选项
+	QEMUArgsUnsafe        bool                `json:"qemu_args_unsafe,omitempty"`        // 是否允许白名单以外的 QEMU 选项、file=/path= 子选项与宿主机设备直通（可选，仅管理员）
+	DisableAPITermination bool                `json:"disable_api_termination,omitempty"` // 删除保护（可选），开启后必须先关闭才能删除实例
+	Labels                map[string]string   `json:"labels,omitempty"`                  // 标签（可选），可按标签批量操作实例
+	DryRun                bool                `json:"dry_run,omitempty"`                 // 校验参数、网络与安全组，预检存储池空间、节点内存与 vCPU，并确认密钥对存在
+}
+
+// LoginUser 创建实例时注入的登录用户，用户不存在时由 cloud-init 创建并授予免密 sudo
+type LoginUser struct {
+	Name       string   `json:"name"`            // 用户名
+	KeyPairIDs []string `json:"keypair_ids"`     // 注入该用户的密钥对 ID 列表
+	Shell      string   `json:"shell,omitempty"` // 登录 Shell（默认：/bin/bash）
+}
+
+// DeviceOptions 实例可选设备开关
+type DeviceOptions struct {
+	TPM            bool   `json:"tpm,omitempty"`             // 是否启用 TPM 2.0（swtpm emulator，Windows 11 必需）
+	SoundModel     string `json:"sound_model,omitempty"`     // 声卡型号：ich9, ich6, ac97, es1370, sb16, usb（为空不添加）
+	WatchdogModel  string `json:"watchdog_model,omitempty"`  // 看门狗型号：i6300esb, ib700, diag288（为空不添加）
+	WatchdogAction string `json:"watchdog_action,omitempty"` // 看门狗触发动作：reset, shutdown, poweroff, pause, none, dump, inject-nmi（默认：reset）
+	DisableRNG     bool   `json:"disable_rng,omitempty"`     // 是否禁用 virtio-rng（默认启用）
+}
+
+// SPICEOptions 实例的 SPICE 图形协议配置
+type SPICEOptions struct {
+	Listen       string `json:"listen,omitempty"`        // 监听地址（默认：127.0.0.1），端口自动分配
+	TLS          bool   `json:"tls,omitempty"`           // 是否强制 TLS（需要宿主机 qemu.conf 开启 spice_tls 并配置证书）
+	USBRedirects int    `json:"usb_redirects,omitempty"` // USB 重定向通道数（默认：2，最多 4）
 }
 
 // UserDataConfig UserData 配置
@@ -114,19 +271,38 @@ type DescribeInstancesRequest struct {
 	Filters     []Filter `json:"filters,omitempty"`
 	MaxResults  int      `json:"max_results,omitempty"`
 	NextToken   string   `json:"next_token,omitempty"`
+	// Include 额外返回的重字段（interfaces、disks），默认只返回轻量字段
+	// interfaces 需要逐个网卡解析 IP，disks 需要读取 domain XML 并查询卷容量；version 依赖磁盘信息，只在包含 disks 时返回
+	Include []string `json:"include,omitempty"`
 }
 
+// DescribeInstances 可选的重字段
+const (
+	InstanceIncludeInterfaces = "interfaces" // 网卡及其 IP
+	InstanceIncludeDisks      = "disks"      // 磁盘及其容量
+)
+
 // DescribeInstancesResponse 描述实例响应
 type DescribeInstancesResponse struct {
 	Instances []Instance `json:"instances"`
 	NextToken string     `json:"nextToken,omitempty"`
 }
 
+// PageInfo 实现 ginx.Paginated，没有下一页时本页即全部结果
+func (r *DescribeInstancesResponse) PageInfo() (string, int) {
+	if r.NextToken != "" {
+		return r.NextToken, -1
+	}
+	return "", len(r.Instances)
+}
+
 // TerminateInstancesRequest 终止实例请求
 type TerminateInstancesRequest struct {
 	NodeName      string   `json:"node_name" binding:"required"`    // 节点名称
 	InstanceIDs   []string `json:"instance_ids" binding:"required"` // 实例 ID 列表
 	DeleteVolumes bool     `json:"delete_volumes,omitempty"`        // 是否同时删除磁盘
+	Permanent     bool     `json:"permanent,omitempty"`             // 跳过回收站直接删除（回收站未启用时总是直接删除）
+	DryRun        bool     `json:"dry_run,omitempty"`               // 校验实例存在与删除保护，不删除实例
 }
 
 // TerminateInstancesResponse 终止实例响应
@@ -139,6 +315,7 @@ type StopInstancesRequest struct {
 	NodeName    string   `json:"node_name" binding:"required"`    // 节点名称
 	InstanceIDs []string `json:"instance_ids" binding:"required"` // 实例 ID 列表
 	Force       bool     `json:"force,omitempty"`                 // 强制停止
+	DryRun      bool     `json:"dry_run,omitempty"`               // 只校验实例存在，不停止实例
 }
 
 // StopInstancesResponse 停止实例响应
@@ -150,6 +327,7 @@ type StopInstancesResponse struct {
 type StartInstancesRequest struct {
 	NodeName    string   `json:"node_name" binding:"required"`    // 节点名称
 	InstanceIDs []string `json:"instance_ids" binding:"required"` // 实例 ID 列表
+	DryRun      bool     `json:"dry_run,omitempty"`               // 只校验实例存在，不启动实例
 }
 
 // StartInstancesResponse 启动实例响应
@@ -161,6 +339,7 @@ type StartInstancesResponse struct {
 type RebootInstancesRequest struct {
 	NodeName    string   `json:"node_name" binding:"required"`    // 节点名称
 	InstanceIDs []string `json:"instance_ids" binding:"required"` // 实例 ID 列表
+	DryRun      bool     `json:"dry_run,omitempty"`               // 只校验实例存在，不重启实例
 }
 
 // RebootInstancesResponse 重启实例响应
@@ -177,26 +356,362 @@ type InstanceStateChange struct {
 
 // ModifyInstanceAttributeRequest 修改实例属性请求
 type ModifyInstanceAttributeRequest struct {
-	NodeName   string  `json:"node_name" binding:"required"`   // 节点名称
-	InstanceID string  `json:"instance_id" binding:"required"` // 实例 ID
-	MemoryMB   *uint64 `json:"memory_mb,omitempty"`            // 内存大小（MB），nil 表示不修改
-	VCPUs      *uint16 `json:"vcpus,omitempty"`                // VCPU 数量，nil 表示不修改
-	Name       *string `json:"name,omitempty"`                 // 实例名称，nil 表示不修改
-	Autostart  *bool   `json:"autostart,omitempty"`            // 是否自动启动，nil 表示不修改
-	Live       bool    `json:"live,omitempty"`                 // 是否热修改（如果实例正在运行）
+	NodeName              string             `json:"node_name" binding:"required"`      // 节点名称
+	InstanceID            string             `json:"instance_id" binding:"required"`    // 实例 ID
+	MemoryMB              *uint64            `json:"memory_mb,omitempty"`               // 内存大小（MB），nil 表示不修改
+	VCPUs                 *uint16            `json:"vcpus,omitempty"`                   // VCPU 数量，nil 表示不修改
+	Name                  *string            `json:"name,omitempty"`                    // 实例名称，nil 表示不修改
+	Autostart             *bool              `json:"autostart,omitempty"`               // 是否自动启动，nil 表示不修改
+	BootOrder             []string           `json:"boot_order,omitempty"`              // 按磁盘设备名排列的启动顺序（如 ["vda", "hda"]），下次启动生效
+	UserData              *string            `json:"user_data,omitempty"`               // 新的 user-data 内容，仅实例停止时可修改，下次启动时由 cloud-init 重新执行
+	DisableAPITermination *bool              `json:"disable_api_termination,omitempty"` // 删除保护，nil 表示不修改
+	Labels                *map[string]string `json:"labels,omitempty"`                  // 标签，整体替换，空对象表示清除全部标签，nil 表示不修改
+	Live                  bool               `json:"live,omitempty"`                    // 是否热修改（如果实例正在运行），仅能在 max_memory_mb / max_vcpus 范围内生效
+	DryRun                bool               `json:"dry_run,omitempty"`                 // 校验 If-Match、热插拔上限与标签，按修改后的规格预检节点内存与 vCPU，修改 user_data 时校验实例已停止
 }
 
 // ModifyInstanceAttributeResponse 修改实例属性响应
 type ModifyInstanceAttributeResponse struct {
+	Instance *Instance                 `json:"instance"`
+	Changes  []InstanceAttributeChange `json:"changes,omitempty"` // 内存、VCPU 修改的生效情况
+}
+
+// InstanceAttributeChange 内存或 VCPU 修改的生效情况：持久化配置在下次启动时生效，热修改在运行中的实例上立即生效
+type InstanceAttributeChange struct {
+	Attribute   string `json:"attribute"`            // 属性：memory_mb, vcpus
+	Persisted   bool   `json:"persisted"`            // 持久化配置是否已更新
+	LiveApplied bool   `json:"live_applied"`         // 是否已在运行中的实例上立即生效
+	LiveError   string `json:"live_error,omitempty"` // 热修改失败的原因，持久化配置已更新，重启实例后生效
+}
+
+// 可查询的实例属性名
+const (
+	InstanceAttributeUserData              = "userData"              // user-data 内容
+	InstanceAttributeDisableAPITermination = "disableApiTermination" // 删除保护
+)
+
+// DescribeInstanceAttributeRequest 查询实例属性请求
+type DescribeInstanceAttributeRequest struct {
+	NodeName   string `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID string `json:"instance_id" binding:"required"` // 实例 ID
+	Attribute  string `json:"attribute" binding:"required"`   // 属性名：userData / disableApiTermination
+}
+
+// DescribeInstanceAttributeResponse 查询实例属性响应
+type DescribeInstanceAttributeResponse struct {
+	InstanceID            string  `json:"instance_id"`                       // 实例 ID
+	UserData              *string `json:"user_data,omitempty"`               // user-data 内容（attribute=userData 时返回，实例未使用 cloud-init 时为空）
+	DisableAPITermination *bool   `json:"disable_api_termination,omitempty"` // 删除保护（attribute=disableApiTermination 时返回）
+}
+
+// CompleteInstanceInstallRequest 完成 ISO 安装请求
+type CompleteInstanceInstallRequest struct {
+	NodeName   string `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID string `json:"instance_id" binding:"required"` // 实例 ID
+	DryRun     bool   `json:"dry_run,omitempty"`              // 校验实例存在且有系统盘，不弹出安装 ISO
+}
+
+// CompleteInstanceInstallResponse 完成 ISO 安装响应
+type CompleteInstanceInstallResponse struct {
 	Instance *Instance `json:"instance"`
 }
 
+// 磁盘扁平化模式
+const (
+	FlattenModeOnline  = "online"  // 运行中实例，通过 blockpull 在线合入
+	FlattenModeOffline = "offline" // 关机实例，通过 qemu-img rebase -b "" 合入
+)
+
+// 磁盘后台任务（扁平化、存储迁移）状态
+const (
+	DiskJobStatusRunning   = "running"   // block job 执行中
+	DiskJobStatusCompleted = "completed" // 任务已完成
+)
+
+// FlattenInstanceDiskRequest 扁平化实例磁盘请求
+// 把 backing 链数据合入实例磁盘，解除对模板文件的依赖
+type FlattenInstanceDiskRequest struct {
+	NodeName     string `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID   string `json:"instance_id" binding:"required"` // 实例 ID
+	Device       string `json:"device"`                         // 磁盘设备名（如 vda），为空时使用系统盘
+	Mode         string `json:"mode"`                           // online / offline，为空时按实例状态自动选择
+	BandwidthMiB uint64 `json:"bandwidth_mib"`                  // 在线模式 blockpull 限速（MiB/s），0 使用全局限速
+	DryRun       bool   `json:"dry_run,omitempty"`              // 校验磁盘、实例状态与冲突的块任务，不启动合并
+}
+
+// FlattenInstanceDiskResponse 扁平化实例磁盘响应
+// 在线模式异步执行，重复调用同一磁盘可查询进度
+type FlattenInstanceDiskResponse struct {
+	InstanceID  string `json:"instance_id"`
+	Device      string `json:"device"`
+	Path        string `json:"path"`                   // 磁盘文件路径
+	Mode        string `json:"mode"`                   // online / offline
+	Status      string `json:"status"`                 // running / completed
+	BackingPath string `json:"backing_path,omitempty"` // 当前仍依赖的 backing file，完成后为空
+	Progress    int    `json:"progress"`               // 进度百分比
+}
+
+// MigrateInstanceStorageRequest 在线迁移实例磁盘到同一节点的其他存储池请求
+type MigrateInstanceStorageRequest struct {
+	NodeName     string   `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID   string   `json:"instance_id" binding:"required"` // 实例 ID
+	TargetPool   string   `json:"target_pool" binding:"required"` // 目标存储池
+	Devices      []string `json:"devices"`                        // 要迁移的磁盘设备名，为空时迁移所有磁盘
+	DeleteSource bool     `json:"delete_source"`                  // 切换完成后删除源文件
+	BandwidthMiB uint64   `json:"bandwidth_mib"`                  // 复制限速（MiB/s），0 使用全局限速
+	DryRun       bool     `json:"dry_run,omitempty"`              // 校验块任务与目标池中的同名卷，并预检目标池空间，不启动复制
+}
+
+// MigrateInstanceStorageResponse 在线迁移实例磁盘响应
+// 复制在后台执行，完成后自动切换；重复调用同一请求可查询进度
+type MigrateInstanceStorageResponse struct {
+	InstanceID string          `json:"instance_id"`
+	TargetPool string          `json:"target_pool"`
+	Disks      []DiskMigration `json:"disks"`
+}
+
+// DiskMigration 单块磁盘的迁移状态
+type DiskMigration struct {
+	Device     string `json:"device"`
+	SourcePath string `json:"source_path"`
+	TargetPath string `json:"target_path"`
+	Status     string `json:"status"`   // running / completed
+	Progress   int    `json:"progress"` // 进度百分比
+}
+
+// ModifyDiskJobBandwidthRequest 调整磁盘上正在执行的 block job 限速请求
+// 适用于迁移存储、在线扁平化、快照合并，调整立即生效
+type ModifyDiskJobBandwidthRequest struct {
+	NodeName     string `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID   string `json:"instance_id" binding:"required"` // 实例 ID
+	Device       string `json:"device" binding:"required"`      // 磁盘设备名（如 vda）
+	BandwidthMiB uint64 `json:"bandwidth_mib"`                  // 限速（MiB/s），0 表示不限速
+	DryRun       bool   `json:"dry_run,omitempty"`              // 只校验设备上有正在运行的块任务，不修改带宽
+}
+
+// ModifyDiskJobBandwidthResponse 调整 block job 限速响应
+type ModifyDiskJobBandwidthResponse struct {
+	InstanceID   string `json:"instance_id"`
+	Device       string `json:"device"`
+	JobType      string `json:"job_type"` // pull / copy / commit / active-commit
+	BandwidthMiB uint64 `json:"bandwidth_mib"`
+	Progress     int    `json:"progress"` // 进度百分比
+}
+
+// ModifyInstanceNetworkBandwidthRequest 修改实例网卡限速请求
+// 运行中实例立即生效（DomainSetInterfaceParameters），同时写入持久化配置
+type ModifyInstanceNetworkBandwidthRequest struct {
+	NodeName   string         `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID string         `json:"instance_id" binding:"required"` // 实例 ID
+	MAC        string         `json:"mac"`                            // 网卡 MAC 地址，为空时使用第一块网卡
+	Inbound    *BandwidthRate `json:"inbound,omitempty"`              // 入方向限速，nil 表示不修改，average 为 0 表示取消限速
+	Outbound   *BandwidthRate `json:"outbound,omitempty"`             // 出方向限速，nil 表示不修改，average 为 0 表示取消限速
+	DryRun     bool           `json:"dry_run,omitempty"`              // 校验带宽参数、网卡存在与 If-Match，不修改限速
+}
+
+// ModifyInstanceNetworkBandwidthResponse 修改实例网卡限速响应
+type ModifyInstanceNetworkBandwidthResponse struct {
+	InstanceID string              `json:"instance_id"`
+	MAC        string              `json:"mac"`
+	Bandwidth  *InterfaceBandwidth `json:"bandwidth,omitempty"` // 修改后的限速，已全部取消时为空
+}
+
+// UpdateInstanceDeviceRequest 修改实例已有设备配置请求
+// 统一通过 DomainUpdateDeviceFlags 修改：运行中实例 live+config 双写，关机实例只修改持久化配置
+type UpdateInstanceDeviceRequest struct {
+	NodeName   string         `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID string         `json:"instance_id" binding:"required"` // 实例 ID
+	DeviceType string         `json:"device_type" binding:"required"` // 设备类型：cdrom（更换/弹出介质）、disk（cache、IO 模式、discard）、interface（限速）
+	Device     string         `json:"device"`                         // cdrom 与 disk 为设备名（如 sda、vdb），interface 为 MAC 地址，为空时使用第一块网卡
+	PoolName   string         `json:"pool_name,omitempty"`            // cdrom：介质所在的存储池
+	Media      string         `json:"media,omitempty"`                // cdrom：新介质的 ISO 卷 ID，为空表示弹出
+	Cache      string         `json:"cache,omitempty"`                // disk：cache 模式（default、none、writethrough、writeback、directsync、unsafe）
+	IO         string         `json:"io,omitempty"`                   // disk：IO 模式（native、threads、io_uring）
+	Discard    string         `json:"discard,omitempty"`              // disk：discard 处理（unmap、ignore）
+	Inbound    *BandwidthRate `json:"inbound,omitempty"`              // interface：入方向限速，nil 表示不修改，average 为 0 表示取消限速
+	Outbound   *BandwidthRate `json:"outbound,omitempty"`             // interface：出方向限速，nil 表示不修改，average 为 0 表示取消限速
+	DryRun     bool           `json:"dry_run,omitempty"`              // 校验设备存在、介质卷存在与 If-Match，不修改设备
+}
+
+// UpdateInstanceDeviceResponse 修改实例设备配置响应
+type UpdateInstanceDeviceResponse struct {
+	InstanceID string `json:"instance_id"`
+	DeviceType string `json:"device_type"`
+	Device     string `json:"device"`
+	Live       bool   `json:"live"` // 是否已在运行中的实例上生效，为 false 时下次启动生效
+}
+
+// AttachHostBlockDeviceRequest 把宿主机块设备直通给实例请求
+// 以 disk type='block' 挂载，格式固定为 raw；运行中实例热插拔，同时写入持久化配置
+type AttachHostBlockDeviceRequest struct {
+	NodeName   string             `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID string             `json:"instance_id" binding:"required"` // 实例 ID
+	DevicePath string             `json:"device_path" binding:"required"` // 宿主机块设备路径（如 /dev/sdb、/dev/vg0/data、/dev/zvol/tank/db）
+	Device     string             `json:"device"`                         // 目标设备名(可选,如 vdb/sdb/nvme0n2,默认按总线自动分配)
+	Bus        string             `json:"bus"`                            // 磁盘总线: virtio/scsi/nvme (默认: virtio)
+	Serial     string             `json:"serial,omitempty"`               // 磁盘序列号(可选,最长 20 字节),guest 内可通过 /dev/disk/by-id 定位
+	DiskDriver *DiskDriverOptions `json:"disk_driver,omitempty"`          // 磁盘 driver 参数(可选,默认 cache=none,io=native,discard=unmap)
+	DryRun     bool               `json:"dry_run,omitempty"`              // 校验块设备存在且未被占用以及 If-Match，不附加设备
+}
+
+// AttachHostBlockDeviceResponse 把宿主机块设备直通给实例响应
+type AttachHostBlockDeviceResponse struct {
+	InstanceID string `json:"instance_id"`
+	DevicePath string `json:"device_path"`
+	Device     string `json:"device"` // 在实例中的设备名
+	Bus        string `json:"bus"`
+	Serial     string `json:"serial,omitempty"`
+}
+
+// DetachHostBlockDeviceRequest 从实例分离直通的宿主机块设备请求
+type DetachHostBlockDeviceRequest struct {
+	NodeName   string `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID string `json:"instance_id" binding:"required"` // 实例 ID
+	DevicePath string `json:"device_path" binding:"required"` // 直通时使用的宿主机块设备路径
+	DryRun     bool   `json:"dry_run,omitempty"`              // 校验块设备已附加到实例以及 If-Match，不分离设备
+}
+
+// DetachHostBlockDeviceResponse 从实例分离直通的宿主机块设备响应
+type DetachHostBlockDeviceResponse struct {
+	InstanceID string `json:"instance_id"`
+	DevicePath string `json:"device_path"`
+	Device     string `json:"device"` // 分离前在实例中的设备名
+}
+
+// ModifyInstanceCPUTuneRequest 修改实例 CPU 权重与上限请求
+// 运行中实例立即生效，同时写入持久化配置；为 0 的字段保持原值
+type ModifyInstanceCPUTuneRequest struct {
+	NodeName   string `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID string `json:"instance_id" binding:"required"` // 实例 ID
+	Shares     uint64 `json:"shares,omitempty"`               // CPU 权重（范围 2-262144，默认 1024）
+	Period     uint64 `json:"period,omitempty"`               // vCPU 调度周期（微秒，范围 1000-1000000）
+	Quota      int64  `json:"quota,omitempty"`                // 每个周期内每个 vCPU 可用的 CPU 时间（微秒），负数表示取消上限
+	DryRun     bool   `json:"dry_run,omitempty"`              // 校验参数范围、实例存在与 If-Match，不修改配置
+}
+
+// ModifyInstanceCPUTuneResponse 修改实例 CPU 权重与上限响应
+type ModifyInstanceCPUTuneResponse struct {
+	InstanceID string   `json:"instance_id"`
+	CPUTune    *CPUTune `json:"cputune,omitempty"` // 修改后的 CPU 调度参数
+}
+
+// ModifyInstanceBlkioTuneRequest 修改实例磁盘 IO 权重请求
+// 运行中实例立即生效，同时写入持久化配置
+type ModifyInstanceBlkioTuneRequest struct {
+	NodeName   string `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID string `json:"instance_id" binding:"required"` // 实例 ID
+	Weight     uint   `json:"weight" binding:"required"`      // IO 权重（范围 10-1000，默认 500）
+	DryRun     bool   `json:"dry_run,omitempty"`              // 校验权重范围、实例存在与 If-Match，不修改配置
+}
+
+// ModifyInstanceBlkioTuneResponse 修改实例磁盘 IO 权重响应
+type ModifyInstanceBlkioTuneResponse struct {
+	InstanceID string     `json:"instance_id"`
+	BlkioTune  *BlkioTune `json:"blkiotune,omitempty"` // 修改后的 IO 调优参数
+}
+
+// 实例事件类型
+const (
+	InstanceEventCreated      = "created"       // 创建实例
+	InstanceEventStarted      = "started"       // 启动实例
+	InstanceEventStopped      = "stopped"       // 停止实例
+	InstanceEventRebooted     = "rebooted"      // 重启实例
+	InstanceEventTerminated   = "terminated"    // 删除实例
+	InstanceEventModified     = "modified"      // 修改配置（CPU、内存、限速等）
+	InstanceEventMigrated     = "migrated"      // 迁移存储
+	InstanceEventCrashed      = "crashed"       // 实例崩溃（查询事件时对比 libvirt 状态发现）
+	InstanceEventRestored     = "restored"      // 从回收站恢复
+	InstanceEventStateChanged = "state-changed" // 非 JVP 发起的状态变化（如 guest 内关机）
+)
+
+// InstanceEventOperatorSystem 非用户发起的事件的操作者
+const InstanceEventOperatorSystem = "system"
+
+// InstanceEvent 实例事件历史记录
+type InstanceEvent struct {
+	InstanceID string    `json:"instance_id"`
+	NodeName   string    `json:"node_name"`
+	Type       string    `json:"type"`              // 事件类型：created, started, stopped, rebooted, terminated, modified, migrated, crashed, state-changed
+	State      string    `json:"state,omitempty"`   // 事件发生后的实例状态
+	Operator   string    `json:"operator"`          // 操作者：请求头 X-JVP-Operator，未提供时为客户端 IP；非用户发起时为 system
+	Message    string    `json:"message,omitempty"` // 事件详情
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// DescribeInstanceEventsRequest 查询实例事件历史请求
+type DescribeInstanceEventsRequest struct {
+	NodeName   string   `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID string   `json:"instance_id" binding:"required"` // 实例 ID
+	EventTypes []string `json:"event_types,omitempty"`          // 按事件类型过滤（可选）
+	MaxResults int      `json:"max_results,omitempty"`          // 最多返回条数（可选，默认全部）
+}
+
+// DescribeInstanceEventsResponse 查询实例事件历史响应，按时间倒序
+type DescribeInstanceEventsResponse struct {
+	Events []InstanceEvent `json:"events"`
+}
+
+// InstanceLogEntry 实例操作日志中的一条记录
+type InstanceLogEntry struct {
+	Time    time.Time      `json:"time"`
+	Level   string         `json:"level"`
+	Message string         `json:"message"`
+	Error   string         `json:"error,omitempty"`
+	TraceID string         `json:"trace_id,omitempty"` // 产生该日志的请求的 trace ID，同一次请求的日志相同
+	Fields  map[string]any `json:"fields,omitempty"`   // 其余上下文字段（如策略名、设备、进度）
+}
+
+// GetInstanceOperationLogsRequest 查询实例操作日志请求
+type GetInstanceOperationLogsRequest struct {
+	NodeName   string `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID string `json:"instance_id" binding:"required"` // 实例 ID
+	TraceID    string `json:"trace_id,omitempty"`             // 只返回某次请求的日志（可选，对应响应头 X-Trace-Id）
+	MaxResults int    `json:"max_results,omitempty"`          // 最多返回最近的条数（可选，默认全部）
+}
+
+// GetInstanceOperationLogsResponse 查询实例操作日志响应，按时间先后排列
+type GetInstanceOperationLogsResponse struct {
+	Logs []InstanceLogEntry `json:"logs"`
+}
+
+// FreezeInstanceFSRequest 冻结实例文件系统请求（guest-fsfreeze-freeze）
+// 用于备份前保证磁盘数据一致，超时未解冻时自动解冻
+type FreezeInstanceFSRequest struct {
+	NodeName       string   `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID     string   `json:"instance_id" binding:"required"` // 实例 ID
+	Mountpoints    []string `json:"mountpoints"`                    // 要冻结的挂载点，为空时冻结全部
+	TimeoutSeconds int      `json:"timeout_seconds"`                // 超时自动解冻（秒），0 使用默认 60 秒，最大 600 秒
+	DryRun         bool     `json:"dry_run,omitempty"`              // 校验超时参数与 guest-agent 可用，不冻结文件系统
+}
+
+// FreezeInstanceFSResponse 冻结实例文件系统响应
+type FreezeInstanceFSResponse struct {
+	InstanceID   string `json:"instance_id"`
+	Filesystems  int    `json:"filesystems"`   // 冻结的文件系统数量
+	ThawDeadline string `json:"thaw_deadline"` // 自动解冻时间（RFC3339）
+}
+
+// ThawInstanceFSRequest 解冻实例文件系统请求（guest-fsfreeze-thaw）
+type ThawInstanceFSRequest struct {
+	NodeName    string   `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID  string   `json:"instance_id" binding:"required"` // 实例 ID
+	Mountpoints []string `json:"mountpoints"`                    // 要解冻的挂载点，为空时解冻全部并取消自动解冻
+	DryRun      bool     `json:"dry_run,omitempty"`              // 只校验 guest-agent 可用，不解冻文件系统
+}
+
+// ThawInstanceFSResponse 解冻实例文件系统响应
+type ThawInstanceFSResponse struct {
+	InstanceID  string `json:"instance_id"`
+	Filesystems int    `json:"filesystems"` // 解冻的文件系统数量
+}
+
 // ResetPasswordRequest 重置密码请求
 type ResetPasswordRequest struct {
 	NodeName   string          `json:"node_name" binding:"required"`   // 节点名称
 	InstanceID string          `json:"instance_id" binding:"required"` // 实例 ID
 	Users      []PasswordReset `json:"users" binding:"required"`       // 用户密码重置列表
 	AutoStart  bool            `json:"auto_start,omitempty"`           // 重置后是否自动启动（如果之前是运行状态）
+	DryRun     bool            `json:"dry_run,omitempty"`              // 只校验实例存在，不修改密码
 }
 
 // PasswordReset 密码重置信息
@@ -0,0 +1,55 @@
+package entity
+
+// 实例导出格式
+const (
+	InstanceExportFormatQCOW2 = "qcow2" // 压缩的 qcow2 磁盘镜像
+	InstanceExportFormatOVA   = "ova"   // OVF 描述文件 + streamOptimized VMDK 打包的 tar，可导入 VMware/VirtualBox
+)
+
+// ExportInstanceRequest 导出实例系统盘请求
+type ExportInstanceRequest struct {
+	NodeName     string `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID   string `json:"instance_id" binding:"required"` // 实例 ID
+	PoolName     string `json:"pool_name" binding:"required"`   // 导出文件存放的存储池，位于存储池的 _exports_ 目录
+	Format       string `json:"format,omitempty"`               // 导出格式: qcow2, ova (默认: qcow2)
+	SnapshotName string `json:"snapshot_name,omitempty"`        // 从快照导出(可选)，实例可以保持运行；不指定时实例必须已停止
+	DryRun       bool   `json:"dry_run,omitempty"`              // 校验格式、存储池以及实例已停止或快照存在，不转换磁盘
+}
+
+// InstanceExport 实例导出文件
+type InstanceExport struct {
+	ExportID     string `json:"export_id"`
+	NodeName     string `json:"node_name"`
+	PoolName     string `json:"pool_name"`
+	InstanceID   string `json:"instance_id"`
+	Format       string `json:"format"`
+	SnapshotName string `json:"snapshot_name,omitempty"`
+	DiskTarget   string `json:"disk_target,omitempty"` // 快照导出的磁盘设备名
+	SizeB        uint64 `json:"size_b"`                // 导出文件大小(字节)，ova 为 VMDK 与 OVF 之和
+	DownloadURL  string `json:"download_url"`          // GET 该地址流式下载
+}
+
+// ExportInstanceResponse 导出实例系统盘响应
+type ExportInstanceResponse struct {
+	Export *InstanceExport `json:"export"`
+}
+
+// DownloadInstanceExportRequest 下载实例导出文件请求，参数来自 download_url 的路径
+type DownloadInstanceExportRequest struct {
+	NodeName string `uri:"node_name" binding:"required"`
+	PoolName string `uri:"pool_name" binding:"required"`
+	ExportID string `uri:"export_id" binding:"required"`
+}
+
+// DeleteInstanceExportRequest 删除实例导出文件请求
+type DeleteInstanceExportRequest struct {
+	NodeName string `json:"node_name" binding:"required"` // 节点名称
+	PoolName string `json:"pool_name" binding:"required"` // 存储池名称
+	ExportID string `json:"export_id" binding:"required"` // 导出 ID
+	DryRun   bool   `json:"dry_run,omitempty"`            // 只校验导出 ID 与存储池，不删除文件
+}
+
+// DeleteInstanceExportResponse 删除实例导出文件响应
+type DeleteInstanceExportResponse struct {
+	Message string `json:"message"`
+}
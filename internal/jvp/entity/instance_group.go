@@ -0,0 +1,46 @@
+package entity
+
+// 实例组操作
+const (
+	InstanceGroupActionStart     = "start"
+	InstanceGroupActionStop      = "stop"
+	InstanceGroupActionReboot    = "reboot"
+	InstanceGroupActionTerminate = "terminate"
+)
+
+// InstanceSelector 实例组选择条件，指定的条件需同时满足，至少指定一个条件
+type InstanceSelector struct {
+	Labels      map[string]string `json:"labels,omitempty"`       // 标签全部匹配，如 {"env": "staging"}
+	NamePrefix  string            `json:"name_prefix,omitempty"`  // 实例名称前缀
+	InstanceIDs []string          `json:"instance_ids,omitempty"` // 实例 ID 列表
+}
+
+// RunInstanceGroupActionRequest 对选中的一组实例执行启动、停止、重启或删除
+type RunInstanceGroupActionRequest struct {
+	NodeName      string           `json:"node_name,omitempty"`       // 节点名称（可选），为空时在所有在线节点上选择
+	Action        string           `json:"action" binding:"required"` // 操作：start, stop, reboot, terminate
+	Selector      InstanceSelector `json:"selector"`                  // 选择条件
+	Force         bool             `json:"force,omitempty"`           // stop 时强制停止
+	DeleteVolumes bool             `json:"delete_volumes,omitempty"`  // terminate 时同时删除磁盘
+	Permanent     bool             `json:"permanent,omitempty"`       // terminate 时跳过回收站直接删除
+	Concurrency   int              `json:"concurrency,omitempty"`     // 并发数（默认 4，最大 32）
+	DryRun        bool             `json:"dry_run,omitempty"`         // 仅解析选中的实例，不执行操作
+}
+
+// InstanceGroupActionResult 单个实例的执行结果
+type InstanceGroupActionResult struct {
+	NodeName      string `json:"node_name"`
+	InstanceID    string `json:"instance_id"`
+	PreviousState string `json:"previous_state,omitempty"`
+	CurrentState  string `json:"current_state,omitempty"`
+	Error         string `json:"error,omitempty"` // 执行失败时的错误信息，其他实例不受影响
+}
+
+// RunInstanceGroupActionResponse 实例组操作响应，按节点与实例 ID 排序
+type RunInstanceGroupActionResponse struct {
+	Action    string                      `json:"action"`
+	DryRun    bool                        `json:"dry_run,omitempty"`
+	Succeeded int                         `json:"succeeded"`
+	Failed    int                         `json:"failed"`
+	Results   []InstanceGroupActionResult `json:"results"`
+}
@@ -0,0 +1,79 @@
+package entity
+
+// 实例导入的磁盘镜像格式
+const (
+	InstanceImportFormatQCOW2 = "qcow2"
+	InstanceImportFormatRaw   = "raw"
+	InstanceImportFormatVMDK  = "vmdk"
+	InstanceImportFormatVHDX  = "vhdx"
+	InstanceImportFormatOVA   = "ova" // tar 包，取其中第一个 VMDK 作为系统盘
+)
+
+// CreateInstanceImportRequest 发起实例导入请求，之后按分片上传磁盘镜像
+type CreateInstanceImportRequest struct {
+	NodeName string `json:"node_name" binding:"required"` // 节点名称
+	PoolName string `json:"pool_name" binding:"required"` // 存储池名称，分片暂存在存储池的 _imports_ 目录，导入后的系统盘也位于该存储池
+	DryRun   bool   `json:"dry_run,omitempty"`            // 只校验节点与存储池，不创建暂存目录
+}
+
+// InstanceImport 一次实例导入
+type InstanceImport struct {
+	ImportID  string `json:"import_id"`
+	NodeName  string `json:"node_name"`
+	PoolName  string `json:"pool_name"`
+	UploadURL string `json:"upload_url"` // PUT {upload_url}/{part_number} 上传分片，请求体为分片内容
+}
+
+// CreateInstanceImportResponse 发起实例导入响应
+type CreateInstanceImportResponse struct {
+	Import *InstanceImport `json:"import"`
+}
+
+// UploadInstanceImportPartRequest 上传实例导入分片请求，参数来自 upload_url 的路径，请求体为分片内容
+type UploadInstanceImportPartRequest struct {
+	NodeName   string `uri:"node_name" binding:"required"`
+	PoolName   string `uri:"pool_name" binding:"required"`
+	ImportID   string `uri:"import_id" binding:"required"`
+	PartNumber int    `uri:"part_number" binding:"required,min=1,max=10000"` // 分片序号，从 1 开始，完成导入时按序号拼接
+	DryRun     bool   `form:"dry_run"`                                       // 查询参数，只校验导入是否存在，不读取请求体
+}
+
+// UploadInstanceImportPartResponse 上传实例导入分片响应
+type UploadInstanceImportPartResponse struct {
+	PartNumber int   `json:"part_number"`
+	SizeB      int64 `json:"size_b"` // 分片大小(字节)，重复上传同一序号会覆盖
+}
+
+// CompleteInstanceImportRequest 完成实例导入请求：拼接分片、校验并转换镜像，然后据此创建实例
+type CompleteInstanceImportRequest struct {
+	NodeName      string `json:"node_name" binding:"required"` // 节点名称
+	PoolName      string `json:"pool_name" binding:"required"` // 存储池名称
+	ImportID      string `json:"import_id" binding:"required"` // 导入 ID
+	Format        string `json:"format" binding:"required"`    // 上传镜像格式: qcow2, raw, vmdk, vhdx, ova
+	Name          string `json:"name,omitempty"`               // 实例名称(可选)，不指定时自动生成
+	VCPUs         uint16 `json:"vcpus,omitempty"`              // vCPU 数量(默认: 2)
+	MemoryMB      uint64 `json:"memory_mb,omitempty"`          // 内存大小 MB(默认: 2048)
+	DiskBus       string `json:"disk_bus,omitempty"`           // 系统盘总线: virtio, sata, ide (默认: virtio)；未安装 virtio 驱动的 guest 使用 sata
+	NetworkType   string `json:"network_type,omitempty"`       // 网络类型(默认: bridge)
+	NetworkSource string `json:"network_source,omitempty"`     // 网络源(默认: JVP_DEFAULT_NETWORK_SOURCE 或 br0，没有 br0 时回落到 default 网络)
+	Start         bool   `json:"start,omitempty"`              // 创建后是否启动
+	DryRun        bool   `json:"dry_run,omitempty"`            // 校验参数、分片序号连续与实例名称未占用，OVA 预检解包临时空间，不转换镜像
+}
+
+// CompleteInstanceImportResponse 完成实例导入响应
+type CompleteInstanceImportResponse struct {
+	Instance *Instance `json:"instance"`
+}
+
+// AbortInstanceImportRequest 取消实例导入请求，删除已上传的分片
+type AbortInstanceImportRequest struct {
+	NodeName string `json:"node_name" binding:"required"` // 节点名称
+	PoolName string `json:"pool_name" binding:"required"` // 存储池名称
+	ImportID string `json:"import_id" binding:"required"` // 导入 ID
+	DryRun   bool   `json:"dry_run,omitempty"`            // 只校验导入是否存在，不删除分片
+}
+
+// AbortInstanceImportResponse 取消实例导入响应
+type AbortInstanceImportResponse struct {
+	Message string `json:"message"`
+}
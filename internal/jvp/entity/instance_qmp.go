@@ -0,0 +1,19 @@
+package entity
+
+import "encoding/json"
+
+// DescribeInstanceQMPRequest 通过 libvirt monitor 通道在实例上执行一条只读 QMP 查询命令的请求
+// 用于查询 block job、迁移细节等 libvirt API 覆盖不到的信息，命令必须在白名单中
+type DescribeInstanceQMPRequest struct {
+	NodeName   string         `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID string         `json:"instance_id" binding:"required"` // 实例 ID
+	Command    string         `json:"command" binding:"required"`     // QMP 命令，如 query-block-jobs、query-migrate
+	Arguments  map[string]any `json:"arguments,omitempty"`            // 命令参数
+}
+
+// DescribeInstanceQMPResponse 执行 QMP 查询命令的响应
+type DescribeInstanceQMPResponse struct {
+	InstanceID string          `json:"instance_id"`
+	Command    string          `json:"command"`
+	Return     json.RawMessage `json:"return"` // QMP 响应中 return 字段的原始内容
+}
@@ -0,0 +1,25 @@
+package entity
+
+// DescribeInstanceSSHTargetRequest 查询实例 SSH 登录信息请求，供 jvpm ssh 一键登录
+type DescribeInstanceSSHTargetRequest struct {
+	NodeName   string `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID string `json:"instance_id" binding:"required"` // 实例 ID
+}
+
+// InstanceSSHTarget 实例 SSH 登录信息
+type InstanceSSHTarget struct {
+	InstanceID   string    `json:"instance_id"`
+	NodeName     string    `json:"node_name"`
+	Host         string    `json:"host"`                 // 登录地址，优先取第一个 IPv4 地址
+	IPs          []string  `json:"ips"`                  // 实例全部 IP
+	Port         int       `json:"port"`                 // SSH 端口，固定为 22
+	User         string    `json:"user,omitempty"`       // 登录用户：创建时注入密钥对的用户，未记录时取 user-data 中的第一个命名用户；注入发行版默认用户时为空
+	KeyPairs     []KeyPair `json:"keypairs,omitempty"`   // user-data 中注入的公钥对应的密钥对
+	JumpRequired bool      `json:"jump_required"`        // 登录地址位于 libvirt NAT 网络，只能从宿主机访问
+	ProxyJump    string    `json:"proxy_jump,omitempty"` // 远程节点的 SSH 跳板 user@host[:port]，取自节点 libvirt URI；本地节点为空
+}
+
+// DescribeInstanceSSHTargetResponse 查询实例 SSH 登录信息响应
+type DescribeInstanceSSHTargetResponse struct {
+	Target *InstanceSSHTarget `json:"target"`
+}
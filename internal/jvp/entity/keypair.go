@@ -17,6 +17,7 @@ type CreateKeyPairRequest struct {
 	Name      string `json:"name" binding:"required"` // 密钥对名称
 	Algorithm string `json:"algorithm"`               // 算法：rsa, ed25519（默认：ed25519）
 	KeySize   int    `json:"key_size,omitempty"`      // RSA 密钥长度（默认：2048，仅 RSA 使用）
+	DryRun    bool   `json:"dry_run,omitempty"`       // 只校验算法与密钥长度，不生成密钥对
 }
 
 // CreateKeyPairResponse 创建密钥对响应
@@ -29,6 +30,7 @@ type CreateKeyPairResponse struct {
 type ImportKeyPairRequest struct {
 	Name      string `json:"name" binding:"required"`       // 密钥对名称
 	PublicKey string `json:"public_key" binding:"required"` // 公钥内容
+	DryRun    bool   `json:"dry_run,omitempty"`             // 只校验公钥格式，不保存密钥对
 }
 
 // ImportKeyPairResponse 导入密钥对响应
@@ -39,6 +41,7 @@ type ImportKeyPairResponse struct {
 // DeleteKeyPairRequest 删除密钥对请求
 type DeleteKeyPairRequest struct {
 	KeyPairID string `json:"keypairID" binding:"required"`
+	DryRun    bool   `json:"dry_run,omitempty"`
 }
 
 // DeleteKeyPairResponse 删除密钥对响应
@@ -59,3 +62,11 @@ type DescribeKeyPairsResponse struct {
 	KeyPairs  []KeyPair `json:"keypairs"`
 	NextToken string    `json:"nextToken,omitempty"`
 }
+
+// PageInfo 实现 ginx.Paginated，没有下一页时本页即全部结果
+func (r *DescribeKeyPairsResponse) PageInfo() (string, int) {
+	if r.NextToken != "" {
+		return r.NextToken, -1
+	}
+	return "", len(r.KeyPairs)
+}
@@ -1,5 +1,7 @@
 package entity
 
+import "time"
+
 // Network libvirt 虚拟网络实体
 type Network struct {
 	Name       string `json:"name"`                 // 网络名称
@@ -15,6 +17,27 @@ type Network struct {
 	Netmask    string `json:"netmask,omitempty"`    // 子网掩码
 	DHCPStart  string `json:"dhcp_start,omitempty"` // DHCP 起始 IP
 	DHCPEnd    string `json:"dhcp_end,omitempty"`   // DHCP 结束 IP
+
+	IPv6Address string `json:"ipv6_address,omitempty"` // IPv6 网关，配置后 dnsmasq 发送 RA，实例通过 SLAAC 获取地址
+	IPv6Prefix  int    `json:"ipv6_prefix,omitempty"`  // IPv6 前缀长度
+
+	Segmentation *NetworkSegmentation `json:"segmentation,omitempty"` // 二层隔离配置，普通网络为空
+}
+
+// 网络二层隔离方式
+const (
+	NetworkSegmentationVLAN  = "vlan"
+	NetworkSegmentationVXLAN = "vxlan"
+)
+
+// NetworkSegmentation 网络的二层隔离配置：节点上的 VLAN 子接口或 VXLAN 设备接入网络的网桥
+// 同名网络在各节点上使用同一个 VLAN tag/VNI，跨节点二层互通
+type NetworkSegmentation struct {
+	Type       string `json:"type"`                  // 隔离方式：vlan, vxlan
+	ID         int    `json:"id,omitempty"`          // VLAN tag（1-4094）或 VXLAN VNI（1-16777215），创建时为空则自动分配
+	Uplink     string `json:"uplink,omitempty"`      // 节点上承载隔离流量的网卡：VLAN 子接口的父网卡或 VXLAN 的底层网卡
+	VXLANGroup string `json:"vxlan_group,omitempty"` // VXLAN 组播组（默认 239.1.1.1），各节点通过组播学习对端
+	Tenant     string `json:"tenant,omitempty"`      // 所属租户，其他租户的实例不能连接该网络；创建时取请求的租户
 }
 
 // HostBridge 宿主机网桥实体
@@ -34,6 +57,17 @@ type NetworkSources struct {
 	HostBridges     []HostBridge `json:"host_bridges"`     // 宿主机网桥
 }
 
+// DHCPLease libvirt 网络的 DHCP 租约
+type DHCPLease struct {
+	IP         string    `json:"ip"`
+	MAC        string    `json:"mac"`
+	Hostname   string    `json:"hostname,omitempty"`
+	ClientID   string    `json:"client_id,omitempty"`
+	ExpiryTime time.Time `json:"expiry_time,omitzero"` // 过期时间，永不过期的租约为空
+	Expired    bool      `json:"expired"`              // 是否已过期，过期租约不参与实例 IP 解析
+	Static     bool      `json:"static"`               // MAC 在网络的 DHCP 静态分配（dhcp host）中
+}
+
 // ============================================================================
 // Network API 请求和响应
 // ============================================================================
@@ -69,6 +103,12 @@ type CreateNetworkRequest struct {
 	DHCPStart string `json:"dhcp_start"`                   // DHCP 起始 IP
 	DHCPEnd   string `json:"dhcp_end"`                     // DHCP 结束 IP
 	Autostart bool   `json:"autostart"`                    // 是否自动启动
+	DryRun    bool   `json:"dry_run,omitempty"`            // 校验名称未占用、IPv6 与隔离参数，隔离网络只校验 VLAN tag/VNI 可用而不占用
+
+	IPv6Address string `json:"ipv6_address,omitempty"` // IPv6 网关（可选，如 fd00:100::1），配置后网络同时提供 IPv6，可用于 address_family 为 ipv6/dual 的实例
+	IPv6Prefix  int    `json:"ipv6_prefix,omitempty"`  // IPv6 前缀长度（默认 64，SLAAC 要求 64）
+
+	Segmentation *NetworkSegmentation `json:"segmentation,omitempty"` // 二层隔离（可选）：指定后网络为 isolated 模式，节点上的 VLAN 子接口或 VXLAN 设备接入网络的网桥
 }
 
 // CreateNetworkResponse 创建网络响应
@@ -80,6 +120,7 @@ type CreateNetworkResponse struct {
 type DeleteNetworkRequest struct {
 	NodeName    string `json:"node_name" binding:"required"`    // 节点名称
 	NetworkName string `json:"network_name" binding:"required"` // 网络名称
+	DryRun      bool   `json:"dry_run,omitempty"`               // 只校验网络存在，不删除网络
 }
 
 // DeleteNetworkResponse 删除网络响应
@@ -91,6 +132,7 @@ type DeleteNetworkResponse struct {
 type StartNetworkRequest struct {
 	NodeName    string `json:"node_name" binding:"required"`    // 节点名称
 	NetworkName string `json:"network_name" binding:"required"` // 网络名称
+	DryRun      bool   `json:"dry_run,omitempty"`               // 只校验网络存在，不启动网络
 }
 
 // StartNetworkResponse 启动网络响应
@@ -102,6 +144,7 @@ type StartNetworkResponse struct {
 type StopNetworkRequest struct {
 	NodeName    string `json:"node_name" binding:"required"`    // 节点名称
 	NetworkName string `json:"network_name" binding:"required"` // 网络名称
+	DryRun      bool   `json:"dry_run,omitempty"`               // 只校验网络存在，不停止网络
 }
 
 // StopNetworkResponse 停止网络响应
@@ -136,9 +179,10 @@ type ListBridgesResponse struct {
 // CreateBridgeRequest 创建网桥请求
 type CreateBridgeRequest struct {
 	NodeName   string   `json:"node_name" binding:"required"`   // 节点名称
-	BridgeName string   `json:"bridge_name" binding:"required"` // 网桥名称
+	BridgeName string   `json:"bridge_name" binding:"required"` // 网桥名称（最多 15 个字符，字母或数字开头，只含字母、数字、'.'、'_'、'-'）
 	STP        bool     `json:"stp"`                            // 是否启用 STP（默认 false）
 	Interfaces []string `json:"interfaces,omitempty"`           // 要绑定的网络接口（可选）
+	DryRun     bool     `json:"dry_run,omitempty"`              // 校验网桥名称未占用且接口可用，不创建网桥
 }
 
 // CreateBridgeResponse 创建网桥响应
@@ -150,6 +194,7 @@ type CreateBridgeResponse struct {
 type DeleteBridgeRequest struct {
 	NodeName   string `json:"node_name" binding:"required"`   // 节点名称
 	BridgeName string `json:"bridge_name" binding:"required"` // 网桥名称
+	DryRun     bool   `json:"dry_run,omitempty"`              // 只校验网桥存在，不删除网桥
 }
 
 // DeleteBridgeResponse 删除网桥响应
@@ -166,3 +211,51 @@ type ListAvailableInterfacesRequest struct {
 type ListAvailableInterfacesResponse struct {
 	Interfaces []NetworkInterface `json:"interfaces"`
 }
+
+// DescribeNetworkDHCPLeasesRequest 查询网络 DHCP 租约请求
+type DescribeNetworkDHCPLeasesRequest struct {
+	NodeName       string `json:"node_name" binding:"required"`    // 节点名称
+	NetworkName    string `json:"network_name" binding:"required"` // 网络名称
+	MAC            string `json:"mac,omitempty"`                   // 按 MAC 过滤（可选）
+	IncludeExpired bool   `json:"include_expired,omitempty"`       // 是否返回已过期但 dnsmasq 尚未清理的租约
+}
+
+// DescribeNetworkDHCPLeasesResponse 查询网络 DHCP 租约响应
+type DescribeNetworkDHCPLeasesResponse struct {
+	Leases []DHCPLease `json:"leases"`
+}
+
+// ReleaseNetworkDHCPLeaseRequest 释放网络 DHCP 租约请求
+type ReleaseNetworkDHCPLeaseRequest struct {
+	NodeName    string `json:"node_name" binding:"required"`    // 节点名称
+	NetworkName string `json:"network_name" binding:"required"` // 网络名称
+	MAC         string `json:"mac" binding:"required"`          // 租约的 MAC 地址
+	IP          string `json:"ip,omitempty"`                    // 租约 IP（可选），同一 MAC 有多个租约时只释放该 IP
+	DryRun      bool   `json:"dry_run,omitempty"`               // 仅校验租约存在，不执行释放
+}
+
+// ReleaseNetworkDHCPLeaseResponse 释放网络 DHCP 租约响应
+type ReleaseNetworkDHCPLeaseResponse struct {
+	Released []DHCPLease `json:"released"` // 已释放的租约
+}
+
+// OVSPort 节点上 Open vSwitch 的端口
+type OVSPort struct {
+	Name        string `json:"name"`                   // 端口名称（tap 设备名）
+	MAC         string `json:"mac,omitempty"`          // 实例网卡 MAC（external_ids:attached-mac）
+	InterfaceID string `json:"interface_id,omitempty"` // external_ids:iface-id
+	VMID        string `json:"vm_id,omitempty"`        // 所属域的 UUID（external_ids:vm-id）
+	Reason      string `json:"reason,omitempty"`       // 被判定为残留的原因
+}
+
+// CleanupOVSPortsRequest 清理 OVS 残留端口请求
+type CleanupOVSPortsRequest struct {
+	NodeName string `json:"node_name" binding:"required"` // 节点名称
+	DryRun   bool   `json:"dry_run,omitempty"`            // 仅返回将被清理的端口与流表，不执行删除
+}
+
+// CleanupOVSPortsResponse 清理 OVS 残留端口响应
+type CleanupOVSPortsResponse struct {
+	Ports []OVSPort `json:"ports"` // 已删除（DryRun 时为将删除）的端口
+	Flows int       `json:"flows"` // 已删除（DryRun 时为将删除）的防 MAC 欺骗流表规则组数
+}
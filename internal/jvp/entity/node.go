@@ -20,17 +20,57 @@ const (
 	NodeStateOnline      NodeState = "online"      // 在线
 	NodeStateOffline     NodeState = "offline"     // 离线
 	NodeStateMaintenance NodeState = "maintenance" // 维护模式
+	NodeStateDegraded    NodeState = "degraded"    // 降级：探测时无法连接或超时，容量信息不可用
 )
 
 // Node 节点信息
 type Node struct {
-	Name      string    `json:"name"`       // 节点名称
-	UUID      string    `json:"uuid"`       // 节点 UUID
-	URI       string    `json:"uri"`        // Libvirt 连接 URI
-	Type      NodeType  `json:"type"`       // 节点类型
-	State     NodeState `json:"state"`      // 节点状态
-	CreatedAt time.Time `json:"created_at"` // 创建时间
-	UpdatedAt time.Time `json:"updated_at"` // 更新时间
+	Name            string         `json:"name"`                        // 节点名称
+	UUID            string         `json:"uuid"`                        // 节点 UUID
+	URI             string         `json:"uri"`                         // Libvirt 连接 URI
+	Type            NodeType       `json:"type"`                        // 节点类型
+	State           NodeState      `json:"state"`                       // 节点状态
+	StateReason     string         `json:"state_reason,omitempty"`      // 降级原因：连接错误或探测超时
+	Warnings        []string       `json:"warnings,omitempty"`          // 告警，如节点时钟偏差超过阈值
+	Capacity        *NodeResources `json:"capacity,omitempty"`          // 节点总资源（节点不可达时为空）
+	Reserved        NodeResources  `json:"reserved"`                    // 预留给宿主机系统的资源
+	Allocatable     *NodeResources `json:"allocatable,omitempty"`       // 可分配给实例的资源：capacity - reserved
+	CreatedAt       time.Time      `json:"created_at"`                  // 创建时间
+	UpdatedAt       time.Time      `json:"updated_at"`                  // 更新时间
+	Ownership       NodeOwnership  `json:"ownership,omitzero"`          // 文件属主修复策略，未配置时使用全局默认
+	TLS             NodeTLS        `json:"tls,omitzero"`                // qemu+tls 连接使用的证书，未配置时使用 libvirt 默认位置
+	ClockSkewMs     *int64         `json:"clock_skew_ms,omitempty"`     // 节点时钟与控制面的偏差（毫秒），正数表示节点超前，尚未测量时为空
+	ClockMeasuredAt *time.Time     `json:"clock_measured_at,omitempty"` // 时钟偏差的测量时间，每分钟最多测量一次
+	NodeTopology
+}
+
+// NodeTopology 节点所在的物理拓扑，调度实例时按可用区过滤、按机架分散放置
+type NodeTopology struct {
+	Zone string `json:"zone,omitempty"` // 可用区（可选）
+	Rack string `json:"rack,omitempty"` // 机架（可选）
+}
+
+// NodeOwnership 节点上 JVP 代为创建的文件（VNC socket 目录、以 root 创建的卷）的属主修复策略
+// 配置后整体覆盖全局默认（JVP_QEMU_USER、JVP_QEMU_GROUP、JVP_DISABLE_OWNERSHIP_FIX）
+type NodeOwnership struct {
+	Disabled bool   `json:"disabled,omitempty"` // 关闭属主修复，由 ACL、libvirt dynamic_ownership 或存储池 permissions 处理
+	User     string `json:"user,omitempty"`     // QEMU 进程用户（名称或 UID），为空时依次尝试 libvirt-qemu、qemu
+	Group    string `json:"group,omitempty"`    // QEMU 进程组（名称或 GID），为空时依次尝试 kvm、qemu
+}
+
+// NodeTLS 节点 qemu+tls 连接使用的证书，路径为 JVP 所在主机上的文件，未配置的路径使用 libvirt 默认位置
+// 适用于禁止 SSH 直连 root 的环境：libvirt 连接走 TLS，证书轮换后调用 ModifyNodeTLS 重新建立连接
+type NodeTLS struct {
+	CACertPath         string `json:"ca_cert_path,omitempty"`         // CA 证书（默认 /etc/pki/CA/cacert.pem）
+	ClientCertPath     string `json:"client_cert_path,omitempty"`     // 客户端证书（默认 /etc/pki/libvirt/clientcert.pem）
+	ClientKeyPath      string `json:"client_key_path,omitempty"`      // 客户端私钥（默认 /etc/pki/libvirt/private/clientkey.pem）
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"` // 不校验 libvirtd 的服务端证书，仅用于测试环境
+}
+
+// NodeResources 节点 CPU 与内存资源
+type NodeResources struct {
+	CPUs     uint32 `json:"cpus"`      // 逻辑 CPU 数
+	MemoryMB uint64 `json:"memory_mb"` // 内存 (MB)
 }
 
 // NodeSummary 节点概要信息
@@ -40,6 +80,27 @@ type NodeSummary struct {
 	NUMA           NUMAInfo           `json:"numa"`
 	HugePages      HugePagesInfo      `json:"hugepages"`
 	Virtualization VirtualizationInfo `json:"virtualization"`
+	System         SystemInfo         `json:"system"` // 整机 SMBIOS 信息
+	Guests         []GuestCapability  `json:"guests"` // 节点支持的 guest 架构
+}
+
+// SystemInfo 整机 SMBIOS 信息（来自 libvirt sysinfo）
+type SystemInfo struct {
+	Manufacturer string `json:"manufacturer"`  // 整机制造商
+	Product      string `json:"product"`       // 整机型号
+	SerialNumber string `json:"serial_number"` // 整机序列号
+	UUID         string `json:"uuid"`          // SMBIOS UUID
+	BIOSVendor   string `json:"bios_vendor"`   // BIOS 厂商
+	BIOSVersion  string `json:"bios_version"`  // BIOS 版本
+}
+
+// GuestCapability 节点可运行的 guest 架构（来自 libvirt capabilities）
+type GuestCapability struct {
+	Arch        string   `json:"arch"`         // guest 架构：x86_64, aarch64 等
+	OSType      string   `json:"os_type"`      // hvm（完全虚拟化）等
+	Emulator    string   `json:"emulator"`     // QEMU 模拟器路径
+	DomainTypes []string `json:"domain_types"` // 支持的虚拟化类型：kvm, qemu
+	Machines    []string `json:"machines"`     // 支持的机器类型
 }
 
 // CPUInfo CPU 信息
@@ -78,6 +139,18 @@ type NUMANode struct {
 	Distance []int `json:"distance"` // 到其他节点的距离
 }
 
+// NUMACellResources NUMA cell 的资源与绑定情况，用于大内存实例选择 cell
+type NUMACellResources struct {
+	ID             uint32   `json:"id"`              // cell ID
+	CPUs           []uint32 `json:"cpus"`            // cell 内的逻辑 CPU
+	MemoryMB       uint64   `json:"memory_mb"`       // cell 内存总量（MB）
+	FreeMemoryMB   uint64   `json:"free_memory_mb"`  // cell 当前空闲内存（MB，来自宿主机）
+	BoundVCPUs     uint32   `json:"bound_vcpus"`     // 绑定到该 cell 的运行中实例的 vCPU 总数
+	BoundMemoryMB  uint64   `json:"bound_memory_mb"` // 绑定到该 cell 的运行中实例的内存总量（MB）
+	AvailableVCPUs uint32   `json:"available_vcpus"` // 剩余可绑定的 vCPU：cell CPU 数 - 已绑定 vCPU
+	Instances      []string `json:"instances"`       // 绑定到该 cell 的实例
+}
+
 // HugePagesInfo 大页内存信息
 type HugePagesInfo struct {
 	Enabled   bool           `json:"enabled"`    // 是否启用
@@ -94,10 +167,12 @@ type HugePageSize struct {
 
 // VirtualizationInfo 虚拟化特性
 type VirtualizationInfo struct {
-	VTx        bool `json:"vtx"`         // VT-x / AMD-V
-	EPT        bool `json:"ept"`         // EPT / NPT
-	IOMMU      bool `json:"iommu"`       // IOMMU (VT-d / AMD-Vi)
-	NestedVirt bool `json:"nested_virt"` // 嵌套虚拟化
+	Extension  string `json:"extension"`   // 硬件虚拟化扩展：vmx（Intel）、svm（AMD），不支持时为空
+	VTx        bool   `json:"vtx"`         // VT-x / AMD-V
+	EPT        bool   `json:"ept"`         // EPT / NPT
+	IOMMU      bool   `json:"iommu"`       // IOMMU (VT-d / AMD-Vi)
+	NestedVirt bool   `json:"nested_virt"` // 嵌套虚拟化
+	KVM        bool   `json:"kvm"`         // 是否可以使用 KVM 加速
 }
 
 // PCIDevice PCI 设备
@@ -178,6 +253,40 @@ type SRIOVInfo struct {
 	VFsInUse int    `json:"vfs_in_use"` // 已分配 VF 数量
 }
 
+// NodeNetwork 节点网络拓扑（创建实例时用于选择 network_source）
+type NodeNetwork struct {
+	Bridges       []HostInterface `json:"bridges"`        // 网桥，可作为 network_type=bridge 的网络源
+	Ethernets     []HostInterface `json:"ethernets"`      // 物理网卡，可作为 network_type=direct 的网络源
+	VLANs         []HostInterface `json:"vlans"`          // VLAN 接口
+	Bonds         []HostInterface `json:"bonds"`          // bond 接口
+	Networks      []Network       `json:"networks"`       // libvirt 虚拟网络，可作为 network_type=network 的网络源
+	DirectSources []DirectSource  `json:"direct_sources"` // 物理网卡（从 sysfs 探测，包括 libvirt 未管理的网卡），可作为 network_type=direct 的网络源
+}
+
+// DirectSource 可作为 macvtap（network_type=direct）网络源的物理网卡
+type DirectSource struct {
+	Name      string `json:"name"`                 // 网卡名称
+	MAC       string `json:"mac,omitempty"`        // MAC 地址
+	State     string `json:"state,omitempty"`      // 链路状态 (up/down)
+	SpeedMbps int    `json:"speed_mbps,omitempty"` // 协商速率（Mbps），链路断开时为空
+	Master    string `json:"master,omitempty"`     // 所属网桥或 bond，已加入网桥或 bond 的网卡不能作为 macvtap 网络源
+	Available bool   `json:"available"`            // 是否可以作为网络源
+}
+
+// HostInterface 宿主机网络接口
+type HostInterface struct {
+	Name       string   `json:"name"`                  // 接口名称
+	Type       string   `json:"type"`                  // 类型：ethernet/bridge/bond/vlan
+	MAC        string   `json:"mac,omitempty"`         // MAC 地址
+	State      string   `json:"state,omitempty"`       // 链路状态 (up/down)
+	MTU        int      `json:"mtu,omitempty"`         // MTU
+	Addresses  []string `json:"addresses,omitempty"`   // IP 段（CIDR）
+	Members    []string `json:"members,omitempty"`     // 网桥/bond 成员接口
+	BondMode   string   `json:"bond_mode,omitempty"`   // bond 模式
+	VLANTag    int      `json:"vlan_tag,omitempty"`    // VLAN ID
+	VLANParent string   `json:"vlan_parent,omitempty"` // VLAN 父接口
+}
+
 // Disk 物理磁盘
 type Disk struct {
 	Name       string      `json:"name"`       // 设备名称 (sda/nvme0n1)
@@ -211,3 +320,40 @@ type Partition struct {
 	MountPoint string `json:"mount_point"` // 挂载点
 	InUse      bool   `json:"in_use"`      // 是否被 Storage Pool 使用
 }
+
+// NodeOperationStatus 节点重 IO 操作的状态
+type NodeOperationStatus string
+
+const (
+	NodeOperationQueued  NodeOperationStatus = "queued"  // 超出节点并发上限，排队等待
+	NodeOperationRunning NodeOperationStatus = "running" // 执行中
+)
+
+// NodeOperation 节点上受并发上限约束的操作（创建实例、下载模板等）
+type NodeOperation struct {
+	ID            string              `json:"id"`                       // 操作 ID（实例 ID、下载任务 ID 等）
+	NodeName      string              `json:"node_name"`                // 节点名称
+	Type          string              `json:"type"`                     // 操作类型：RunInstance / DownloadTemplate
+	Resource      string              `json:"resource,omitempty"`       // 操作的资源（实例名、卷名等）
+	Status        NodeOperationStatus `json:"status"`                   // 状态：queued / running
+	QueuePosition int                 `json:"queue_position,omitempty"` // 排队位置（从 1 开始），执行中为 0
+	QueuedAt      time.Time           `json:"queued_at"`                // 提交时间
+	StartedAt     time.Time           `json:"started_at,omitzero"`      // 开始执行时间
+}
+
+// HostBlockDevice 宿主机块设备（整盘、分区、LVM LV、zvol），可通过 disk type='block' 直通给实例
+type HostBlockDevice struct {
+	Path             string `json:"path"`                        // 设备路径（如 /dev/sdb、/dev/mapper/vg-data、/dev/zd0）
+	KernelName       string `json:"kernel_name"`                 // 内核设备路径（如 /dev/dm-0），用于识别符号链接形式的路径
+	Type             string `json:"type"`                        // 类型：disk / part / lvm / raid1 / crypt 等（lsblk TYPE）
+	Size             uint64 `json:"size"`                        // 容量 (bytes)
+	Model            string `json:"model,omitempty"`             // 型号
+	Serial           string `json:"serial,omitempty"`            // 序列号
+	FSType           string `json:"fstype,omitempty"`            // 文件系统或签名类型（ext4、LVM2_member、zfs_member 等）
+	MountPoint       string `json:"mount_point,omitempty"`       // 挂载点
+	Parent           string `json:"parent,omitempty"`            // 父设备路径（分区所在磁盘、LV 所在 PV）
+	InUse            bool   `json:"in_use"`                      // 是否被宿主机或实例占用，占用时不能直通
+	InUseReason      string `json:"in_use_reason,omitempty"`     // 占用原因
+	AttachedInstance string `json:"attached_instance,omitempty"` // 已直通的实例 ID
+	AttachedDevice   string `json:"attached_device,omitempty"`   // 在实例中的设备名（如 vdb）
+}
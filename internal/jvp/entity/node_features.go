@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// NodeFeatures 节点上可选工具与宿主机能力的探测结果
+// 创建实例使用依赖这些能力的功能时，缺失会在 API 层提前拒绝并说明缺少的依赖
+type NodeFeatures struct {
+	CloudInitISO   bool      `json:"cloud_init_iso"`  // genisoimage 或 mkisofs：生成 cloud-init ISO（ISO 在 JVP 所在主机生成后上传，探测的是本机）
+	VirtCustomize  bool      `json:"virt_customize"`  // virt-customize：实例关机后离线重置密码
+	SWTPM          bool      `json:"swtpm"`           // swtpm：TPM 2.0 设备（devices.tpm）
+	VirtV2V        bool      `json:"virt_v2v"`        // virt-v2v：VMware 虚拟机迁移
+	UEFI           bool      `json:"uefi"`            // QEMU 固件描述文件：UEFI 启动（aarch64 guest 必需）
+	NestedVirt     bool      `json:"nested_virt"`     // kvm_intel/kvm_amd 开启 nested：嵌套虚拟化（nested_virtualization）
+	HugePagesTotal uint64    `json:"hugepages_total"` // 默认大小的大页总数，0 表示未配置大页
+	ProbedAt       time.Time `json:"probed_at"`       // 探测时间
+	Error          string    `json:"error,omitempty"` // 探测失败原因，失败时各项均为 false 且创建实例不做依赖检查
+}
@@ -0,0 +1,52 @@
+package entity
+
+// QuotaLimits 租户配额上限，0 表示不限制
+type QuotaLimits struct {
+	VCPUs     uint64 `json:"vcpus"`     // vCPU 总数
+	MemoryMB  uint64 `json:"memory_mb"` // 内存总量（MB）
+	DiskGB    uint64 `json:"disk_gb"`   // 实例磁盘总容量（GB）
+	Instances uint64 `json:"instances"` // 实例数
+}
+
+// QuotaResourceUsage 单项资源的使用量与上限
+type QuotaResourceUsage struct {
+	Used         uint64  `json:"used"`                    // 已用量
+	Limit        uint64  `json:"limit"`                   // 上限，0 表示不限制
+	UsagePercent float64 `json:"usage_percent,omitempty"` // 使用率（%），不限制时为空
+	Warning      bool    `json:"warning,omitempty"`       // 使用率达到预警阈值
+}
+
+// TenantQuotaUsage 租户的配额使用情况
+type TenantQuotaUsage struct {
+	Tenant    string             `json:"tenant"`    // 租户，为空表示未归属租户的实例（管理员创建或 JVP 之外创建）
+	VCPUs     QuotaResourceUsage `json:"vcpus"`     // vCPU
+	MemoryMB  QuotaResourceUsage `json:"memory_mb"` // 内存（MB）
+	DiskGB    QuotaResourceUsage `json:"disk_gb"`   // 实例磁盘容量（GB，向上取整）
+	Instances QuotaResourceUsage `json:"instances"` // 实例数
+	Warning   bool               `json:"warning"`   // 任意一项达到预警阈值
+}
+
+// DescribeQuotaUsageRequest 查询配额使用量请求
+type DescribeQuotaUsageRequest struct {
+	Tenant                  string  `json:"tenant,omitempty"`                    // 租户过滤（仅管理员可用），租户请求只返回自身
+	WarningThresholdPercent float64 `json:"warning_threshold_percent,omitempty"` // 预警阈值（%），默认 80
+}
+
+// DescribeQuotaUsageResponse 查询配额使用量响应
+type DescribeQuotaUsageResponse struct {
+	Tenants      []TenantQuotaUsage `json:"tenants"`                 // 按租户名排序
+	SkippedNodes []string           `json:"skipped_nodes,omitempty"` // 无法连接而未统计的节点
+}
+
+// ModifyTenantQuotaRequest 修改租户配额上限请求（仅管理员）
+type ModifyTenantQuotaRequest struct {
+	Tenant string      `json:"tenant" binding:"required"` // 租户
+	Limits QuotaLimits `json:"limits"`                    // 新的上限，全部为 0 表示取消限制
+	DryRun bool        `json:"dry_run,omitempty"`         // 只校验管理员权限与租户参数，不修改配额
+}
+
+// ModifyTenantQuotaResponse 修改租户配额上限响应
+type ModifyTenantQuotaResponse struct {
+	Tenant string      `json:"tenant"`
+	Limits QuotaLimits `json:"limits"`
+}
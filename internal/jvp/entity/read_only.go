@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// ReadOnlyMode 全局只读（维护）模式状态
+type ReadOnlyMode struct {
+	Enabled bool      `json:"enabled"`          // 是否处于只读模式，开启后所有写接口返回 ServiceUnavailable
+	Reason  string    `json:"reason,omitempty"` // 开启原因，随错误消息返回给调用方
+	Since   time.Time `json:"since,omitzero"`   // 开启时间
+}
+
+// DescribeReadOnlyModeRequest 查询只读模式请求
+type DescribeReadOnlyModeRequest struct{}
+
+// DescribeReadOnlyModeResponse 查询只读模式响应
+type DescribeReadOnlyModeResponse struct {
+	ReadOnlyMode ReadOnlyMode `json:"read_only_mode"`
+}
+
+// ModifyReadOnlyModeRequest 开启或关闭只读模式请求（仅管理员）
+type ModifyReadOnlyModeRequest struct {
+	Enabled bool   `json:"enabled"`           // 开启或关闭
+	Reason  string `json:"reason,omitempty"`  // 开启原因，如 "控制面升级"
+	DryRun  bool   `json:"dry_run,omitempty"` // 只校验管理员权限，不切换只读模式
+}
+
+// ModifyReadOnlyModeResponse 开启或关闭只读模式响应
+type ModifyReadOnlyModeResponse struct {
+	ReadOnlyMode ReadOnlyMode `json:"read_only_mode"`
+}
@@ -0,0 +1,60 @@
+package entity
+
+import "time"
+
+// 回收站资源类型
+const (
+	RecycleBinResourceInstance = "instance"
+	RecycleBinResourceVolume   = "volume"
+)
+
+// RecycleBinItem 回收站中的资源
+// 实例被关机并改名隔离，卷被移动到存储池的回收目录，文件都保留到过期后才物理删除
+type RecycleBinItem struct {
+	ResourceType  string    `json:"resource_type"`            // 资源类型：instance / volume
+	ResourceID    string    `json:"resource_id"`              // 实例 ID 或卷 ID
+	NodeName      string    `json:"node_name"`                // 节点名称
+	PoolName      string    `json:"pool_name,omitempty"`      // 卷所在存储池
+	VolumeName    string    `json:"volume_name,omitempty"`    // 卷文件名（含扩展名）
+	OriginalPath  string    `json:"original_path,omitempty"`  // 卷原始路径，恢复时移回此处
+	RecycledName  string    `json:"recycled_name"`            // 隔离后的 domain 名称或卷路径
+	DeleteVolumes bool      `json:"delete_volumes,omitempty"` // 实例清理时是否同时删除磁盘
+	DeletedAt     time.Time `json:"deleted_at"`               // 进入回收站的时间
+	ExpiresAt     time.Time `json:"expires_at"`               // 到期时间，之后由后台任务物理清理
+}
+
+// DescribeRecycleBinRequest 查询回收站请求
+type DescribeRecycleBinRequest struct {
+	NodeName     string `json:"node_name" binding:"required"` // 节点名称
+	ResourceType string `json:"resource_type,omitempty"`      // 资源类型过滤：instance / volume，为空时返回全部
+}
+
+// DescribeRecycleBinResponse 查询回收站响应（按进入回收站时间倒序）
+type DescribeRecycleBinResponse struct {
+	Items []RecycleBinItem `json:"items"`
+}
+
+// RestoreInstanceRequest 从回收站恢复实例请求
+type RestoreInstanceRequest struct {
+	NodeName   string `json:"node_name" binding:"required"`   // 节点名称
+	InstanceID string `json:"instance_id" binding:"required"` // 实例 ID
+	DryRun     bool   `json:"dry_run,omitempty"`              // 校验回收站记录与回收的域存在且原名称未占用，不恢复实例
+}
+
+// RestoreInstanceResponse 从回收站恢复实例响应（恢复后的实例处于关机状态）
+type RestoreInstanceResponse struct {
+	Instance *Instance `json:"instance"`
+}
+
+// RestoreVolumeRequest 从回收站恢复卷请求
+type RestoreVolumeRequest struct {
+	NodeName string `json:"node_name" binding:"required"` // 节点名称
+	PoolName string `json:"pool_name" binding:"required"` // 存储池名称
+	VolumeID string `json:"volume_id" binding:"required"` // 卷 ID
+	DryRun   bool   `json:"dry_run,omitempty"`            // 校验回收站记录存在且原卷名未占用，不恢复卷
+}
+
+// RestoreVolumeResponse 从回收站恢复卷响应
+type RestoreVolumeResponse struct {
+	Volume *Volume `json:"volume"`
+}
@@ -0,0 +1,63 @@
+package entity
+
+import "time"
+
+// 错过触发时间（进程未运行、非 leader 或上一次执行未结束）后的补偿策略
+const (
+	MisfirePolicySkip    = "skip"     // 跳过错过的触发，等待下一次
+	MisfirePolicyRunOnce = "run_once" // 无论错过多少次，尽快补偿执行一次
+)
+
+// 定时任务执行状态
+const (
+	ScheduledTaskRunSucceeded = "succeeded" // 执行成功
+	ScheduledTaskRunFailed    = "failed"    // 执行失败
+	ScheduledTaskRunSkipped   = "skipped"   // 上一次执行未结束或处于只读模式，本次触发被跳过
+)
+
+// ScheduledTask 定时任务
+type ScheduledTask struct {
+	Name          string    `json:"name"`                  // 任务名称
+	Description   string    `json:"description,omitempty"` // 任务说明
+	Schedule      string    `json:"schedule"`              // cron 表达式
+	MisfirePolicy string    `json:"misfire_policy"`        // 错过触发后的补偿策略：skip / run_once
+	Running       bool      `json:"running"`               // 是否正在执行
+	LastRunAt     time.Time `json:"last_run_at,omitzero"`  // 最近一次开始执行的时间
+	LastStatus    string    `json:"last_status,omitempty"` // 最近一次执行状态
+	NextRunAt     time.Time `json:"next_run_at,omitzero"`  // 下一次触发时间
+}
+
+// ScheduledTaskRun 定时任务的一次执行记录
+type ScheduledTaskRun struct {
+	TaskName    string    `json:"task_name"`            // 任务名称
+	ScheduledAt time.Time `json:"scheduled_at"`         // 计划触发时间
+	StartedAt   time.Time `json:"started_at"`           // 实际开始时间
+	FinishedAt  time.Time `json:"finished_at,omitzero"` // 结束时间
+	Status      string    `json:"status"`               // 执行状态：succeeded / failed / skipped
+	Error       string    `json:"error,omitempty"`      // 失败原因
+	Misfire     bool      `json:"misfire,omitempty"`    // 是否为错过触发后的补偿执行
+	Leader      string    `json:"leader,omitempty"`     // 执行任务的 JVP 进程（hostname/pid）
+}
+
+// DescribeScheduledTasksRequest 查询定时任务请求
+type DescribeScheduledTasksRequest struct {
+	Names []string `json:"names,omitempty"` // 任务名称过滤，为空表示全部
+}
+
+// DescribeScheduledTasksResponse 查询定时任务响应
+type DescribeScheduledTasksResponse struct {
+	Leader   bool            `json:"leader"`   // 当前进程是否为 leader，只有 leader 执行定时任务
+	Identity string          `json:"identity"` // 当前进程标识（hostname/pid）
+	Tasks    []ScheduledTask `json:"tasks"`    // 按名称排序的任务
+}
+
+// DescribeScheduledTaskHistoryRequest 查询定时任务执行历史请求
+type DescribeScheduledTaskHistoryRequest struct {
+	Name  string `json:"name" binding:"required"` // 任务名称
+	Limit int    `json:"limit,omitempty"`         // 返回的最大条数，默认全部保留的记录
+}
+
+// DescribeScheduledTaskHistoryResponse 查询定时任务执行历史响应（按开始时间倒序）
+type DescribeScheduledTaskHistoryResponse struct {
+	Runs []ScheduledTaskRun `json:"runs"`
+}
@@ -0,0 +1,84 @@
+package entity
+
+// 安全组规则协议
+const (
+	SecurityGroupProtocolTCP  = "tcp"
+	SecurityGroupProtocolUDP  = "udp"
+	SecurityGroupProtocolICMP = "icmp"
+	SecurityGroupProtocolAll  = "all"
+)
+
+// SecurityGroup 安全组：一组放行的入方向与出方向规则，未放行的流量全部丢弃
+// 安全组是有状态的，已放行连接的回包与关联流量（ESTABLISHED、RELATED）自动放行；DHCP 与 IPv6 邻居发现始终放行
+type SecurityGroup struct {
+	ID           string              `json:"id"`                    // 安全组 ID: sg-{递增 ID}
+	Name         string              `json:"name"`                  // 安全组名称，同一租户内唯一
+	Description  string              `json:"description,omitempty"` // 描述
+	Owner        string              `json:"owner,omitempty"`       // 所属租户，为空表示由管理员创建
+	IngressRules []SecurityGroupRule `json:"ingress_rules"`         // 入方向规则（发往实例的流量）
+	EgressRules  []SecurityGroupRule `json:"egress_rules"`          // 出方向规则（实例发出的流量），创建时默认放行所有
+	CreatedAt    string              `json:"created_at"`            // 创建时间
+}
+
+// SecurityGroupRule 安全组规则，protocol、from_port、to_port 与 cidr 相同即为同一条规则
+// tcp/udp 的端口范围为 from_port 到 to_port；icmp 的 from_port 为 ICMP 类型、to_port 为 ICMP 代码，-1 表示所有；all 不指定端口
+type SecurityGroupRule struct {
+	Protocol    string `json:"protocol" binding:"required"` // 协议：tcp, udp, icmp, all
+	FromPort    int    `json:"from_port,omitempty"`         // 起始端口（icmp 时为 ICMP 类型）
+	ToPort      int    `json:"to_port,omitempty"`           // 结束端口（icmp 时为 ICMP 代码）
+	CIDR        string `json:"cidr" binding:"required"`     // 对端地址段，如 0.0.0.0/0、10.0.0.0/8、::/0；入方向为来源，出方向为目的
+	Description string `json:"description,omitempty"`       // 规则描述
+}
+
+// CreateSecurityGroupRequest 创建安全组请求
+type CreateSecurityGroupRequest struct {
+	Name        string `json:"name" binding:"required"` // 安全组名称
+	Description string `json:"description,omitempty"`   // 描述
+	DryRun      bool   `json:"dry_run,omitempty"`       // 只校验名称合法且租户内未重名，不创建安全组
+}
+
+// CreateSecurityGroupResponse 创建安全组响应
+type CreateSecurityGroupResponse struct {
+	SecurityGroup *SecurityGroup `json:"security_group"`
+}
+
+// AuthorizeSecurityGroupRequest 添加入方向或出方向规则，已实例化的节点上立即生效
+type AuthorizeSecurityGroupRequest struct {
+	SecurityGroupID string              `json:"security_group_id" binding:"required"`
+	Rules           []SecurityGroupRule `json:"rules" binding:"required"`
+	DryRun          bool                `json:"dry_run,omitempty"`
+}
+
+// RevokeSecurityGroupRequest 删除入方向或出方向规则，按 protocol、from_port、to_port 与 cidr 匹配
+type RevokeSecurityGroupRequest struct {
+	SecurityGroupID string              `json:"security_group_id" binding:"required"`
+	Rules           []SecurityGroupRule `json:"rules" binding:"required"`
+	DryRun          bool                `json:"dry_run,omitempty"`
+}
+
+// SecurityGroupRulesResponse 修改规则后的安全组
+type SecurityGroupRulesResponse struct {
+	SecurityGroup *SecurityGroup `json:"security_group"`
+}
+
+// DescribeSecurityGroupsRequest 描述安全组请求
+type DescribeSecurityGroupsRequest struct {
+	SecurityGroupIDs []string `json:"security_group_ids,omitempty"` // 安全组 ID 列表，为空时返回所有可见的安全组
+	Names            []string `json:"names,omitempty"`              // 安全组名称列表
+}
+
+// DescribeSecurityGroupsResponse 描述安全组响应
+type DescribeSecurityGroupsResponse struct {
+	SecurityGroups []SecurityGroup `json:"security_groups"`
+}
+
+// DeleteSecurityGroupRequest 删除安全组请求，仍有实例使用时返回 SecurityGroupInUse
+type DeleteSecurityGroupRequest struct {
+	SecurityGroupID string `json:"security_group_id" binding:"required"`
+	DryRun          bool   `json:"dry_run,omitempty"`
+}
+
+// DeleteSecurityGroupResponse 删除安全组响应
+type DeleteSecurityGroupResponse struct {
+	Return bool `json:"return"`
+}
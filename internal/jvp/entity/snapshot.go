@@ -24,11 +24,14 @@ type SnapshotDisk struct {
 
 // CreateSnapshotRequest 创建快照请求
 type CreateSnapshotRequest struct {
-	NodeName     string `json:"node_name" binding:"required"`
-	VMName       string `json:"vm_name" binding:"required"`
-	SnapshotName string `json:"snapshot_name,omitempty"`
-	Description  string `json:"description,omitempty"`
-	WithMemory   bool   `json:"with_memory,omitempty"`
+	NodeName          string   `json:"node_name" binding:"required"`
+	VMName            string   `json:"vm_name" binding:"required"`
+	SnapshotName      string   `json:"snapshot_name,omitempty"`
+	Description       string   `json:"description,omitempty"`
+	WithMemory        bool     `json:"with_memory,omitempty"`
+	Quiesce           bool     `json:"quiesce,omitempty"`            // 通过 guest-agent 冻结文件系统后再做磁盘快照，要求实例运行且不带内存
+	FreezeMountpoints []string `json:"freeze_mountpoints,omitempty"` // 只冻结指定挂载点再做磁盘快照（与 quiesce 互斥），快照后立即解冻，异常时超时自动解冻
+	DryRun            bool     `json:"dry_run,omitempty"`
 }
 
 type CreateSnapshotResponse struct {
@@ -65,6 +68,8 @@ type DeleteSnapshotRequest struct {
 	MetadataOnly    bool   `json:"metadata_only,omitempty"`
 	DisksOnly       bool   `json:"disks_only,omitempty"`
 	UnsafeIgnoreAll bool   `json:"unsafe_ignore_all,omitempty"`
+	Merge           bool   `json:"merge,omitempty"` // 运行中实例通过 blockcommit 把快照 overlay 合并回 backing 并删除 overlay 文件
+	DryRun          bool   `json:"dry_run,omitempty"`
 }
 
 type DeleteSnapshotResponse struct {
@@ -78,6 +83,7 @@ type RevertSnapshotRequest struct {
 	SnapshotName     string `json:"snapshot_name" binding:"required"`
 	StartAfterRevert bool   `json:"start_after_revert,omitempty"`
 	Force            bool   `json:"force,omitempty"`
+	DryRun           bool   `json:"dry_run,omitempty"`
 }
 
 type RevertSnapshotResponse struct {
@@ -86,20 +92,36 @@ type RevertSnapshotResponse struct {
 
 // CloneFromSnapshotRequest 基于快照克隆创建新实例
 type CloneFromSnapshotRequest struct {
-	NodeName       string `json:"node_name" binding:"required"`        // 节点名称
-	SourceVMName   string `json:"source_vm_name" binding:"required"`   // 源虚拟机名称
-	SnapshotName   string `json:"snapshot_name" binding:"required"`    // 快照名称
-	PoolName       string `json:"pool_name" binding:"required"`        // 存储池名称（必须与源 VM 相同的存储池）
-	NewVMName      string `json:"new_vm_name,omitempty"`               // 新虚拟机名称，可选，自动生成
-	VCPUs          int    `json:"vcpus,omitempty"`                     // vCPU 数量，可选，默认继承源 VM
-	MemoryMB       int    `json:"memory_mb,omitempty"`                 // 内存大小（MB），可选，默认继承源 VM
-	NetworkType    string `json:"network_type,omitempty"`              // 网络类型（bridge/network），可选，默认继承源 VM
-	NetworkSource  string `json:"network_source,omitempty"`            // 网络源，可选，默认继承源 VM
-	Flatten        bool   `json:"flatten"`                             // 是否合并增量链（true=独立磁盘，false=保留增量链）
-	StartAfterClone bool  `json:"start_after_clone,omitempty"`         // 克隆后是否启动
+	NodeName        string `json:"node_name" binding:"required"`      // 节点名称
+	SourceVMName    string `json:"source_vm_name" binding:"required"` // 源虚拟机名称
+	SnapshotName    string `json:"snapshot_name" binding:"required"`  // 快照名称
+	PoolName        string `json:"pool_name" binding:"required"`      // 存储池名称（必须与源 VM 相同的存储池）
+	NewVMName       string `json:"new_vm_name,omitempty"`             // 新虚拟机名称，可选，自动生成
+	VCPUs           int    `json:"vcpus,omitempty"`                   // vCPU 数量，可选，默认继承源 VM
+	MemoryMB        int    `json:"memory_mb,omitempty"`               // 内存大小（MB），可选，默认继承源 VM
+	NetworkType     string `json:"network_type,omitempty"`            // 网络类型（bridge/network），可选，默认继承源 VM
+	NetworkSource   string `json:"network_source,omitempty"`          // 网络源，可选，默认继承源 VM
+	Flatten         bool   `json:"flatten"`                           // 是否合并增量链（true=独立磁盘，false=保留增量链）
+	StartAfterClone bool   `json:"start_after_clone,omitempty"`       // 克隆后是否启动
+	DryRun          bool   `json:"dry_run,omitempty"`                 // 校验快照、源实例、新实例名称、存储池与网络，不克隆磁盘
 }
 
 type CloneFromSnapshotResponse struct {
 	Instance *Instance `json:"instance"`
 	Message  string    `json:"message"`
 }
+
+// ExportSnapshotRequest 导出快照中一块磁盘在快照时刻的状态为压缩 qcow2，用于跨集群转移数据盘
+type ExportSnapshotRequest struct {
+	NodeName     string `json:"node_name" binding:"required"`     // 节点名称
+	VMName       string `json:"vm_name" binding:"required"`       // 虚拟机名称
+	SnapshotName string `json:"snapshot_name" binding:"required"` // 快照名称
+	DiskTarget   string `json:"disk_target,omitempty"`            // 磁盘设备名(如 vdb)，不指定时导出第一块磁盘
+	PoolName     string `json:"pool_name" binding:"required"`     // 导出文件存放的存储池，位于存储池的 _exports_ 目录
+	DryRun       bool   `json:"dry_run,omitempty"`                // 校验快照、存储池与磁盘存在，不转换磁盘
+}
+
+// ExportSnapshotResponse 导出快照响应，通过 download_url 下载，下载完成后用 delete-instance-export 删除
+type ExportSnapshotResponse struct {
+	Export *InstanceExport `json:"export"`
+}
@@ -15,16 +15,30 @@ type StoragePool struct {
 
 // Volume 存储卷信息
 type Volume struct {
-	ID          string `json:"volume_id"`    // Volume ID: vol-{uuid}
-	Name        string `json:"name"`         // Volume 名称(文件名)
-	NodeName    string `json:"node_name"`    // 所属节点
-	Pool        string `json:"pool"`         // 所属存储池名称
-	Path        string `json:"path"`         // 文件完整路径
-	CapacityB   uint64 `json:"capacity_b"`   // 容量(字节)
-	SizeGB      uint64 `json:"size_gb"`      // 容量(GB) - 前端展示用
-	AllocationB uint64 `json:"allocation_b"` // 已分配(字节)
-	Format      string `json:"format"`       // 格式: qcow2, raw, iso
-}
+	ID                 string `json:"volume_id"`           // Volume ID: vol-{uuid}
+	Name               string `json:"name"`                // Volume 名称(文件名)
+	NodeName           string `json:"node_name"`           // 所属节点
+	Pool               string `json:"pool"`                // 所属存储池名称
+	Path               string `json:"path"`                // 文件完整路径
+	CapacityB          uint64 `json:"capacity_b"`          // 容量(字节)
+	SizeGB             uint64 `json:"size_gb"`             // 容量(GB) - 前端展示用
+	AllocationB        uint64 `json:"allocation_b"`        // 已分配(字节)
+	Format             string `json:"format"`              // 格式: qcow2, raw, iso
+	Type               string `json:"type"`                // 类型: disk, iso, cidata
+	DeletionProtection bool   `json:"deletion_protection"` // 删除保护，开启后删除卷返回 OperationNotPermitted
+	MultiAttach        bool   `json:"multi_attach"`        // 是否允许同时挂载到多个实例（共享盘）
+	Revision           uint64 `json:"revision"`            // 最后一次变化的全局 revision，服务启动后未变化过时为 0
+
+	Labels map[string]string `json:"labels,omitempty"` // 标签，通过 CreateTags/DeleteTags 修改
+}
+
+// 卷类型
+const (
+	VolumeTypeDisk      = "disk"     // 普通磁盘卷
+	VolumeTypeISO       = "iso"      // ISO 镜像（如系统安装盘）
+	VolumeTypeCloudInit = "cidata"   // 实例的 cloud-init 数据卷，随实例删除
+	VolumeTypeIgnition  = "ignition" // 实例的 Ignition 配置（Fedora CoreOS/Flatcar），随实例删除
+)
 
 // CreateInternalVolumeRequest 创建内部 Volume 请求（用于 StorageService）
 type CreateInternalVolumeRequest struct {
@@ -79,6 +93,7 @@ type CreateStoragePoolRequest struct {
 	Name     string `json:"name" binding:"required"` // 存储池名称
 	Type     string `json:"type"`                    // 类型：dir, fs, netfs（默认：dir）
 	Path     string `json:"path" binding:"required"` // 存储池路径
+	DryRun   bool   `json:"dry_run,omitempty"`       // 只校验名称合法且未占用，不创建存储池
 }
 
 // CreateStoragePoolResponse 创建存储池响应
@@ -91,6 +106,7 @@ type DeleteStoragePoolRequest struct {
 	NodeName      string `json:"node_name"`                    // 节点名称（可选，为空表示本地节点）
 	PoolName      string `json:"pool_name" binding:"required"` // 存储池名称
 	DeleteVolumes bool   `json:"delete_volumes"`               // 是否删除存储池中的所有卷和目录（默认 false）
+	DryRun        bool   `json:"dry_run,omitempty"`            // 只校验存储池存在，不删除存储池
 }
 
 // DeleteStoragePoolResponse 删除存储池响应
@@ -102,6 +118,7 @@ type DeleteStoragePoolResponse struct {
 type StartStoragePoolRequest struct {
 	NodeName string `json:"node_name"`                    // 节点名称（可选，为空表示本地节点）
 	PoolName string `json:"pool_name" binding:"required"` // 存储池名称
+	DryRun   bool   `json:"dry_run,omitempty"`            // 只校验存储池存在，不启动存储池
 }
 
 // StartStoragePoolResponse 启动存储池响应
@@ -113,6 +130,7 @@ type StartStoragePoolResponse struct {
 type StopStoragePoolRequest struct {
 	NodeName string `json:"node_name"`                    // 节点名称（可选，为空表示本地节点）
 	PoolName string `json:"pool_name" binding:"required"` // 存储池名称
+	DryRun   bool   `json:"dry_run,omitempty"`            // 只校验存储池存在，不停止存储池
 }
 
 // StopStoragePoolResponse 停止存储池响应
@@ -124,6 +142,7 @@ type StopStoragePoolResponse struct {
 type RefreshStoragePoolRequest struct {
 	NodeName string `json:"node_name"`                    // 节点名称（可选，为空表示本地节点）
 	PoolName string `json:"pool_name" binding:"required"` // 存储池名称
+	DryRun   bool   `json:"dry_run,omitempty"`            // 只校验存储池存在，不刷新存储池
 }
 
 // RefreshStoragePoolResponse 刷新存储池响应
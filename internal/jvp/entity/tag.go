@@ -0,0 +1,53 @@
+package entity
+
+// CreateTagsRequest 为资源添加或覆盖标签请求，标签以键值形式保存在实例、卷与模板的 labels 中
+type CreateTagsRequest struct {
+	NodeName     string   `json:"node_name" binding:"required"`     // 节点名称
+	PoolName     string   `json:"pool_name,omitempty"`              // 存储池名称，卷与模板必填
+	ResourceType string   `json:"resource_type" binding:"required"` // 资源类型：instance / volume / template
+	ResourceIDs  []string `json:"resource_ids" binding:"required"`  // 资源 ID
+	Tags         []Tag    `json:"tags" binding:"required"`          // 要添加的标签，已存在的 key 覆盖 value
+	DryRun       bool     `json:"dry_run,omitempty"`
+}
+
+// CreateTagsResponse 添加标签响应
+type CreateTagsResponse struct {
+	Return bool `json:"return"`
+}
+
+// DeleteTagsRequest 删除资源标签请求
+type DeleteTagsRequest struct {
+	NodeName     string   `json:"node_name" binding:"required"`     // 节点名称
+	PoolName     string   `json:"pool_name,omitempty"`              // 存储池名称，卷与模板必填
+	ResourceType string   `json:"resource_type" binding:"required"` // 资源类型：instance / volume / template
+	ResourceIDs  []string `json:"resource_ids" binding:"required"`  // 资源 ID
+	Tags         []Tag    `json:"tags,omitempty"`                   // 要删除的标签，value 为空时不论值删除该 key，否则只在值相同时删除；为空删除全部标签
+	DryRun       bool     `json:"dry_run,omitempty"`
+}
+
+// DeleteTagsResponse 删除标签响应
+type DeleteTagsResponse struct {
+	Return bool `json:"return"`
+}
+
+// DescribeTagsRequest 查询资源标签请求
+type DescribeTagsRequest struct {
+	NodeName     string   `json:"node_name" binding:"required"`     // 节点名称
+	PoolName     string   `json:"pool_name,omitempty"`              // 存储池名称，卷与模板必填
+	ResourceType string   `json:"resource_type" binding:"required"` // 资源类型：instance / volume / template
+	ResourceIDs  []string `json:"resource_ids,omitempty"`           // 资源 ID，为空表示该类型的全部资源
+	Filters      []Filter `json:"filters,omitempty"`                // 支持 key、value
+}
+
+// TagDescription 资源上的一个标签
+type TagDescription struct {
+	ResourceType string `json:"resource_type"`
+	ResourceID   string `json:"resource_id"`
+	Key          string `json:"key"`
+	Value        string `json:"value"`
+}
+
+// DescribeTagsResponse 查询资源标签响应
+type DescribeTagsResponse struct {
+	Tags []TagDescription `json:"tags"`
+}
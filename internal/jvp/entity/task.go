@@ -0,0 +1,32 @@
+package entity
+
+// 可取消的长任务类型
+const (
+	TaskTypeDownload = "download" // 模板下载任务（task-N）
+	TaskTypeV2V      = "v2v"      // V2V 迁移任务（v2v-N）
+	TaskTypeDiskJob  = "disk_job" // 磁盘 block job：迁移存储、在线扁平化
+)
+
+// TaskStatusCancelled 任务已取消
+const TaskStatusCancelled = "cancelled"
+
+// CancelTaskRequest 取消长任务请求
+// 下载与 V2V 任务通过 task_id 指定；磁盘 block job 没有任务 ID，通过 node_name、instance_id 与 device 指定
+type CancelTaskRequest struct {
+	TaskID     string `json:"task_id,omitempty"`     // 下载任务（task-N）或 V2V 任务（v2v-N）ID
+	NodeName   string `json:"node_name,omitempty"`   // 磁盘 block job 所在节点
+	InstanceID string `json:"instance_id,omitempty"` // 磁盘 block job 所属实例
+	Device     string `json:"device,omitempty"`      // 磁盘设备名（如 vda）
+	DryRun     bool   `json:"dry_run,omitempty"`     // 仅校验任务是否存在且可取消，不执行变更
+}
+
+// CancelTaskResponse 取消长任务响应
+type CancelTaskResponse struct {
+	TaskID     string `json:"task_id,omitempty"`
+	Type       string `json:"type"`                  // download / v2v / disk_job
+	NodeName   string `json:"node_name,omitempty"`   // 任务所在节点
+	InstanceID string `json:"instance_id,omitempty"` // 磁盘 block job 所属实例
+	Device     string `json:"device,omitempty"`      // 磁盘设备名
+	JobType    string `json:"job_type,omitempty"`    // 磁盘 block job 类型: copy / pull
+	Status     string `json:"status"`                // cancelled
+}
@@ -19,10 +19,24 @@ type Template struct {
 	Features    TemplateFeatures `json:"features" yaml:"features"`
 	Usage       TemplateUsage    `json:"usage" yaml:"usage"`
 	Tags        []string         `json:"tags" yaml:"tags"`
+	Owner       string           `json:"owner,omitempty" yaml:"owner,omitempty"`             // 所属租户，注册时取自请求头 X-JVP-Tenant，为空表示由管理员注册
+	Visibility  string           `json:"visibility" yaml:"visibility"`                       // 可见性：private / shared / public
+	SharedWith  []string         `json:"shared_with,omitempty" yaml:"shared_with,omitempty"` // visibility=shared 时可以使用模板的租户
 	CreatedAt   time.Time        `json:"created_at" yaml:"created_at"`
 	UpdatedAt   time.Time        `json:"updated_at" yaml:"updated_at"`
+
+	Requirements TemplateRequirements `json:"requirements" yaml:"requirements"` // 资源需求：最小系统盘与内存、推荐规格
+
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"` // 键值标签，通过 CreateTags/DeleteTags 修改；Tags 为分类标签
 }
 
+// 模板可见性
+const (
+	TemplateVisibilityPrivate = "private" // 仅所属租户可见
+	TemplateVisibilityShared  = "shared"  // 所属租户及 SharedWith 中的租户可见
+	TemplateVisibilityPublic  = "public"  // 所有租户可见
+)
+
 // TemplateSource 描述模板的来源
 type TemplateSource struct {
 	Type       string `json:"type" yaml:"type"`                   // url | file | snapshot | volume
@@ -35,10 +49,19 @@ type TemplateSource struct {
 
 // TemplateOS 描述模板的操作系统信息
 type TemplateOS struct {
-	Name    string `json:"name" yaml:"name"`
-	Version string `json:"version" yaml:"version"`
-	Arch    string `json:"arch" yaml:"arch"`
-	Kernel  string `json:"kernel" yaml:"kernel"`
+	Name        string `json:"name" yaml:"name"`
+	Version     string `json:"version" yaml:"version"`
+	Arch        string `json:"arch" yaml:"arch"`
+	Kernel      string `json:"kernel" yaml:"kernel"`
+	DefaultUser string `json:"default_user,omitempty" yaml:"default_user,omitempty"` // 镜像的默认登录用户（如 ubuntu、debian、cloud-user），注入密钥对时使用，为空时注入发行版 cloud-init 配置的默认用户
+}
+
+// TemplateRequirements 描述模板的资源需求，0 表示不限制或没有推荐值
+type TemplateRequirements struct {
+	MinDiskGB           uint64 `json:"min_disk_gb,omitempty" yaml:"min_disk_gb,omitempty"`                     // 系统盘最小大小（GB），创建实例时 size_gb 不能更小
+	MinMemoryMB         uint64 `json:"min_memory_mb,omitempty" yaml:"min_memory_mb,omitempty"`                 // 最小内存（MB），创建实例时 memory_mb 不能更小
+	RecommendedMemoryMB uint64 `json:"recommended_memory_mb,omitempty" yaml:"recommended_memory_mb,omitempty"` // 推荐内存（MB），创建实例未指定 memory_mb 时使用
+	RecommendedVCPUs    uint16 `json:"recommended_vcpus,omitempty" yaml:"recommended_vcpus,omitempty"`         // 推荐 vCPU 数，创建实例未指定 vcpus 时使用
 }
 
 // TemplateFeatures 描述模板的特性
@@ -57,15 +80,20 @@ type TemplateUsage struct {
 
 // RegisterTemplateRequest 注册模板请求
 type RegisterTemplateRequest struct {
-	NodeName    string           `json:"node_name"`                      // 节点名称,可选,默认 local
-	PoolName    string           `json:"pool_name" binding:"required"`   // 存储池名称
-	VolumeName  string           `json:"volume_name" binding:"required"` // 存储卷名称(包含扩展名)
-	Name        string           `json:"name" binding:"required"`        // 模板名称
-	Description string           `json:"description"`                    // 模板描述
-	Tags        []string         `json:"tags"`                           // 标签
-	OS          TemplateOS       `json:"os"`                             // 操作系统信息
-	Features    TemplateFeatures `json:"features"`                       // 特性
-	Source      *TemplateSource  `json:"source"`                         // 模板来源
+	NodeName     string               `json:"node_name"`                      // 节点名称,可选,默认 local
+	PoolName     string               `json:"pool_name" binding:"required"`   // 存储池名称
+	VolumeName   string               `json:"volume_name" binding:"required"` // 存储卷名称(包含扩展名)
+	Name         string               `json:"name" binding:"required"`        // 模板名称
+	Description  string               `json:"description"`                    // 模板描述
+	Tags         []string             `json:"tags"`                           // 标签
+	OS           TemplateOS           `json:"os"`                             // 操作系统信息
+	Features     TemplateFeatures     `json:"features"`                       // 特性
+	Source       *TemplateSource      `json:"source"`                         // 模板来源
+	Visibility   string               `json:"visibility,omitempty"`           // 可见性：private（默认）/ shared / public
+	SharedWith   []string             `json:"shared_with,omitempty"`          // visibility=shared 时共享的租户
+	BandwidthMiB uint64               `json:"bandwidth_mib"`                  // 从 URL 下载时的限速（MiB/s），0 使用全局限速
+	Requirements TemplateRequirements `json:"requirements"`                   // 资源需求（可选）：最小系统盘与内存、推荐规格
+	DryRun       bool                 `json:"dry_run,omitempty"`              // 校验参数，并确认源卷存在（URL 来源确认存储池存在），不注册模板
 }
 
 // RegisterTemplateResponse 注册模板响应
@@ -76,12 +104,14 @@ type RegisterTemplateResponse struct {
 
 // DownloadTask 下载任务信息
 type DownloadTask struct {
-	ID         string `json:"id"`
-	NodeName   string `json:"node_name"`
-	PoolName   string `json:"pool_name"`
-	VolumeName string `json:"volume_name"`
-	Status     string `json:"status"` // pending, running, completed, failed
-	Error      string `json:"error,omitempty"`
+	ID            string `json:"id"`
+	NodeName      string `json:"node_name"`
+	PoolName      string `json:"pool_name"`
+	VolumeName    string `json:"volume_name"`
+	BandwidthMiB  uint64 `json:"bandwidth_mib,omitempty"`  // 下载限速（MiB/s），0 表示不限速
+	Status        string `json:"status"`                   // pending, running, completed, failed
+	QueuePosition int    `json:"queue_position,omitempty"` // pending 时在节点操作队列中的位置（从 1 开始）
+	Error         string `json:"error,omitempty"`
 }
 
 // GetDownloadTaskRequest 获取下载任务状态请求
@@ -104,8 +134,10 @@ type ListDownloadTasksResponse struct {
 
 // ListTemplatesRequest 列举模板请求
 type ListTemplatesRequest struct {
-	NodeName string `json:"node_name"` // 节点名称,可选
-	PoolName string `json:"pool_name"` // 存储池过滤,可选
+	NodeName   string   `json:"node_name"`            // 节点名称,可选
+	PoolName   string   `json:"pool_name"`            // 存储池过滤,可选
+	Visibility string   `json:"visibility,omitempty"` // 可见性过滤,可选
+	Filters    []Filter `json:"filters,omitempty"`    // 支持 tag:<key>、tag-key
 }
 
 // ListTemplatesResponse 列举模板响应
@@ -127,13 +159,17 @@ type DescribeTemplateResponse struct {
 
 // UpdateTemplateRequest 更新模板请求
 type UpdateTemplateRequest struct {
-	NodeName    string            `json:"node_name" binding:"required"`
-	PoolName    string            `json:"pool_name" binding:"required"`
-	TemplateID  string            `json:"template_id" binding:"required"`
-	Description *string           `json:"description"`
-	Tags        *[]string         `json:"tags"`
-	Features    *TemplateFeatures `json:"features"`
-	OS          *TemplateOS       `json:"os"`
+	NodeName     string                `json:"node_name" binding:"required"`
+	PoolName     string                `json:"pool_name" binding:"required"`
+	TemplateID   string                `json:"template_id" binding:"required"`
+	Description  *string               `json:"description"`
+	Tags         *[]string             `json:"tags"`
+	Features     *TemplateFeatures     `json:"features"`
+	OS           *TemplateOS           `json:"os"`
+	Requirements *TemplateRequirements `json:"requirements"`
+	Visibility   *string               `json:"visibility"`
+	SharedWith   *[]string             `json:"shared_with"`
+	DryRun       bool                  `json:"dry_run,omitempty"`
 }
 
 // UpdateTemplateResponse 更新模板响应
@@ -147,6 +183,8 @@ type DeleteTemplateRequest struct {
 	PoolName     string `json:"pool_name" binding:"required"`
 	TemplateID   string `json:"template_id" binding:"required"`
 	DeleteVolume bool   `json:"delete_volume"`
+	Force        bool   `json:"force"` // 模板文件仍被依赖时，先扁平化直接子卷再删除
+	DryRun       bool   `json:"dry_run,omitempty"`
 }
 
 // DeleteTemplateResponse 删除模板响应
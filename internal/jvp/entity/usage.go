@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// UsageRecord 实例在一个采集周期内的资源用量，供计费结算
+// CPU 时间与网络流量为周期内的增量，磁盘占用为周期结束时的采样值
+type UsageRecord struct {
+	PeriodStart  time.Time `json:"period_start"`     // 周期开始（上一次采集时间）
+	PeriodEnd    time.Time `json:"period_end"`       // 周期结束（本次采集时间）
+	NodeName     string    `json:"node_name"`        // 节点名称
+	InstanceID   string    `json:"instance_id"`      // 实例 ID
+	Tenant       string    `json:"tenant,omitempty"` // 所属租户，为空表示未归属租户的实例
+	Running      bool      `json:"running"`          // 周期结束时实例是否在运行
+	VCPUs        uint32    `json:"vcpus"`            // 周期结束时的 vCPU 数，未运行时为 0
+	MemoryMB     uint64    `json:"memory_mb"`        // 周期结束时的内存（MB），未运行时为 0
+	CPUSeconds   float64   `json:"cpu_seconds"`      // 周期内消耗的 CPU 时间（秒）
+	DiskBytes    uint64    `json:"disk_bytes"`       // 磁盘文件在宿主机上实际占用的字节数，不含 ISO 光盘
+	DiskGiBHours float64   `json:"disk_gib_hours"`   // 磁盘占用 × 周期时长（GiB·小时）
+	NetRxBytes   uint64    `json:"net_rx_bytes"`     // 周期内接收的字节数
+	NetTxBytes   uint64    `json:"net_tx_bytes"`     // 周期内发送的字节数
+}
+
+// DescribeUsageRecordsRequest 查询用量记录请求，按周期结束时间过滤，区间为 (start_time, end_time]
+// 同时用于 CSV 导出（GET 查询参数）
+type DescribeUsageRecordsRequest struct {
+	StartTime  time.Time `json:"start_time" form:"start_time"`             // 开始时间（RFC3339），默认 end_time 前 24 小时
+	EndTime    time.Time `json:"end_time" form:"end_time"`                 // 结束时间（RFC3339），默认当前时间
+	Tenant     string    `json:"tenant,omitempty" form:"tenant"`           // 租户过滤（仅管理员可用），租户请求只返回自身
+	NodeName   string    `json:"node_name,omitempty" form:"node_name"`     // 节点过滤
+	InstanceID string    `json:"instance_id,omitempty" form:"instance_id"` // 实例过滤
+}
+
+// DescribeUsageRecordsResponse 查询用量记录响应
+type DescribeUsageRecordsResponse struct {
+	Records []UsageRecord `json:"records"` // 按周期结束时间、节点、实例排序
+}
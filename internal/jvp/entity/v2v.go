@@ -0,0 +1,84 @@
+package entity
+
+import "time"
+
+// V2V 源虚拟机类型
+const (
+	V2VSourceTypeOVA     = "ova"     // VMware 导出的 OVA 文件
+	V2VSourceTypeVCenter = "vcenter" // 通过 vCenter/ESXi 直接读取虚拟机
+)
+
+// V2V 任务状态
+const (
+	V2VTaskStatusPending     = "pending"     // 等待节点并发名额
+	V2VTaskStatusRunning     = "running"     // virt-v2v 转换中
+	V2VTaskStatusCompleted   = "completed"   // 已创建实例
+	V2VTaskStatusFailed      = "failed"      // 转换或创建实例失败
+	V2VTaskStatusInterrupted = "interrupted" // 服务关停时未完成
+	V2VTaskStatusCancelled   = "cancelled"   // 被用户取消
+)
+
+// CreateV2VTaskRequest 创建 V2V 迁移任务请求：通过 virt-v2v 把 VMware 虚拟机转换为 jvp 实例
+type CreateV2VTaskRequest struct {
+	NodeName   string `json:"node_name" binding:"required"`   // 执行转换的节点，需安装 virt-v2v
+	PoolName   string `json:"pool_name" binding:"required"`   // 转换后系统盘所在的存储池
+	SourceType string `json:"source_type" binding:"required"` // 源类型: ova, vcenter
+
+	// source_type=ova 时二选一
+	ImportID string `json:"import_id,omitempty"` // 通过实例导入分片上传的 OVA（同一节点与存储池）
+	OVAPath  string `json:"ova_path,omitempty"`  // 节点上已有的 OVA 文件绝对路径
+
+	// source_type=vcenter 时必填
+	VCenterURL string `json:"vcenter_url,omitempty"` // libvirt vpx 连接地址，如 vpx://user@vcenter/Datacenter/esxi?no_verify=1
+	VMName     string `json:"vm_name,omitempty"`     // vCenter 中的虚拟机名称，源虚拟机必须已关机
+	Password   string `json:"password,omitempty"`    // vCenter 密码，只在转换期间以 0600 文件形式存放在节点上
+
+	Name          string `json:"name,omitempty"`           // 实例名称(可选)，不指定时自动生成
+	VCPUs         uint16 `json:"vcpus,omitempty"`          // vCPU 数量(可选，默认沿用源虚拟机)
+	MemoryMB      uint64 `json:"memory_mb,omitempty"`      // 内存大小 MB(可选，默认沿用源虚拟机)
+	NetworkType   string `json:"network_type,omitempty"`   // 网络类型(默认: bridge)
+	NetworkSource string `json:"network_source,omitempty"` // 网络源(默认: JVP_DEFAULT_NETWORK_SOURCE 或 br0，没有 br0 时回落到 default 网络)
+	Start         bool   `json:"start,omitempty"`          // 创建后是否启动
+	DryRun        bool   `json:"dry_run,omitempty"`        // 仅做参数与源校验，不创建任务
+}
+
+// V2VTask V2V 迁移任务
+type V2VTask struct {
+	ID         string    `json:"id"`
+	NodeName   string    `json:"node_name"`
+	PoolName   string    `json:"pool_name"`
+	SourceType string    `json:"source_type"`
+	Source     string    `json:"source"`               // OVA 路径或 vCenter 虚拟机名称
+	InstanceID string    `json:"instance_id"`          // 目标实例名称
+	Status     string    `json:"status"`               // pending, running, completed, failed, interrupted, cancelled
+	Stage      string    `json:"stage,omitempty"`      // virt-v2v 当前阶段（最近一行进度输出）
+	Error      string    `json:"error,omitempty"`      // 失败原因
+	CreatedAt  time.Time `json:"created_at"`           // 创建时间
+	UpdatedAt  time.Time `json:"updated_at"`           // 最近一次状态或阶段变化时间
+	FinishedAt time.Time `json:"finished_at,omitzero"` // 结束时间
+}
+
+// CreateV2VTaskResponse 创建 V2V 迁移任务响应
+type CreateV2VTaskResponse struct {
+	Task *V2VTask `json:"task"`
+}
+
+// GetV2VTaskRequest 查询 V2V 迁移任务请求
+type GetV2VTaskRequest struct {
+	TaskID string `json:"task_id" binding:"required"`
+}
+
+// GetV2VTaskResponse 查询 V2V 迁移任务响应
+type GetV2VTaskResponse struct {
+	Task *V2VTask `json:"task"`
+}
+
+// ListV2VTasksRequest 列出 V2V 迁移任务请求
+type ListV2VTasksRequest struct {
+	NodeName string `json:"node_name,omitempty"` // 节点过滤(可选)
+}
+
+// ListV2VTasksResponse 列出 V2V 迁移任务响应
+type ListV2VTasksResponse struct {
+	Tasks []*V2VTask `json:"tasks"`
+}
@@ -4,11 +4,13 @@ package entity
 
 // CreateVolumeRequest 创建卷请求
 type CreateVolumeRequest struct {
-	NodeName string `json:"node_name"`                    // 节点名称(可选,默认本地节点)
-	PoolName string `json:"pool_name" binding:"required"` // 存储池名称
-	Name     string `json:"name"`                         // 卷名称(可选,不提供则自动生成)
-	SizeGB   uint64 `json:"size_gb" binding:"required"`   // 大小(GB)
-	Format   string `json:"format"`                       // 格式: qcow2/raw (默认: qcow2)
+	NodeName    string `json:"node_name"`                    // 节点名称(可选,默认本地节点)
+	PoolName    string `json:"pool_name" binding:"required"` // 存储池名称
+	Name        string `json:"name"`                         // 卷名称(可选,不提供则自动生成)
+	SizeGB      uint64 `json:"size_gb" binding:"required"`   // 大小(GB)
+	Format      string `json:"format"`                       // 格式: qcow2/raw (默认: qcow2)
+	MultiAttach bool   `json:"multi_attach,omitempty"`       // 允许同时挂载到多个实例（共享盘），仅支持 raw 格式
+	DryRun      bool   `json:"dry_run,omitempty"`            // 校验名称与格式未冲突，并预检存储池空间，不创建卷
 }
 
 // CreateVolumeResponse 创建卷响应
@@ -18,8 +20,9 @@ type CreateVolumeResponse struct {
 
 // ListVolumesRequest 列举卷请求
 type ListVolumesRequest struct {
-	NodeName string `json:"node_name"`                    // 节点名称(可选,默认本地节点)
-	PoolName string `json:"pool_name" binding:"required"` // 存储池名称
+	NodeName string   `json:"node_name"`                    // 节点名称(可选,默认本地节点)
+	PoolName string   `json:"pool_name" binding:"required"` // 存储池名称
+	Filters  []Filter `json:"filters,omitempty"`            // 支持 tag:<key>、tag-key
 }
 
 // ListVolumesResponse 列举卷响应
@@ -45,6 +48,7 @@ type ResizeVolumeRequest struct {
 	PoolName  string `json:"pool_name" binding:"required"`   // 存储池名称
 	VolumeID  string `json:"volume_id" binding:"required"`   // 卷 ID
 	NewSizeGB uint64 `json:"new_size_gb" binding:"required"` // 新大小(GB)
+	DryRun    bool   `json:"dry_run,omitempty"`              // 校验新大小大于当前大小，并预检扩容部分的存储池空间
 }
 
 // ResizeVolumeResponse 扩容卷响应
@@ -54,9 +58,26 @@ type ResizeVolumeResponse struct {
 
 // DeleteVolumeRequest 删除卷请求
 type DeleteVolumeRequest struct {
-	NodeName string `json:"node_name"`                    // 节点名称(可选,默认本地节点)
-	PoolName string `json:"pool_name" binding:"required"` // 存储池名称
-	VolumeID string `json:"volume_id" binding:"required"` // 卷 ID
+	NodeName  string `json:"node_name"`                    // 节点名称(可选,默认本地节点)
+	PoolName  string `json:"pool_name" binding:"required"` // 存储池名称
+	VolumeID  string `json:"volume_id" binding:"required"` // 卷 ID
+	Permanent bool   `json:"permanent,omitempty"`          // 跳过回收站直接删除（回收站未启用时总是直接删除）
+	DryRun    bool   `json:"dry_run,omitempty"`            // 校验卷存在与删除保护，不删除卷
+}
+
+// ModifyVolumeAttributeRequest 修改卷属性请求
+type ModifyVolumeAttributeRequest struct {
+	NodeName           string `json:"node_name"`                     // 节点名称(可选,默认本地节点)
+	PoolName           string `json:"pool_name" binding:"required"`  // 存储池名称
+	VolumeID           string `json:"volume_id" binding:"required"`  // 卷 ID
+	DeletionProtection *bool  `json:"deletion_protection,omitempty"` // 删除保护，nil 表示不修改
+	MultiAttach        *bool  `json:"multi_attach,omitempty"`        // multi-attach（共享盘），nil 表示不修改，卷挂载中时不能修改
+	DryRun             bool   `json:"dry_run,omitempty"`             // 校验卷存在，修改 multi_attach 时校验格式且卷未挂载，不修改属性
+}
+
+// ModifyVolumeAttributeResponse 修改卷属性响应
+type ModifyVolumeAttributeResponse struct {
+	Volume *Volume `json:"volume"`
 }
 
 // DeleteVolumeResponse 删除卷响应
@@ -64,12 +85,30 @@ type DeleteVolumeResponse struct {
 	Message string `json:"message"`
 }
 
+// CloneVolumeRequest 克隆卷请求
+// zfs 存储池通过快照 + zfs clone 秒级完成，其他存储池完整复制
+type CloneVolumeRequest struct {
+	NodeName       string `json:"node_name"`                           // 节点名称(可选,默认本地节点)
+	PoolName       string `json:"pool_name" binding:"required"`        // 存储池名称，新卷与源卷位于同一存储池
+	SourceVolumeID string `json:"source_volume_id" binding:"required"` // 源卷 ID
+	Name           string `json:"name"`                                // 新卷名称(可选,默认使用生成的 volume ID)
+	SizeGB         uint64 `json:"size_gb,omitempty"`                   // 新卷容量(可选,大于源卷时扩容)
+	DryRun         bool   `json:"dry_run,omitempty"`                   // 校验源卷与新卷名称，并预检存储池空间（zfs 只计扩容部分），不克隆卷
+}
+
+// CloneVolumeResponse 克隆卷响应
+type CloneVolumeResponse struct {
+	Volume *Volume `json:"volume"`
+}
+
 // CreateVolumeFromURLRequest 从 URL 下载并创建卷请求
 type CreateVolumeFromURLRequest struct {
-	NodeName string `json:"node_name"`                    // 节点名称(可选,默认本地节点)
-	PoolName string `json:"pool_name" binding:"required"` // 存储池名称
-	Name     string `json:"name" binding:"required"`      // 卷名称(文件名)
-	URL      string `json:"url" binding:"required"`       // 下载 URL
+	NodeName     string `json:"node_name"`                    // 节点名称(可选,默认本地节点)
+	PoolName     string `json:"pool_name" binding:"required"` // 存储池名称
+	Name         string `json:"name" binding:"required"`      // 卷名称(文件名)
+	URL          string `json:"url" binding:"required"`       // 下载 URL
+	BandwidthMiB uint64 `json:"bandwidth_mib"`                // 下载限速（MiB/s），0 使用全局限速
+	DryRun       bool   `json:"dry_run,omitempty"`            // 只校验卷名称合法且未占用，不下载
 }
 
 // CreateVolumeFromURLResponse 从 URL 下载并创建卷响应
@@ -77,6 +116,128 @@ type CreateVolumeFromURLResponse struct {
 	Volume *Volume `json:"volume"`
 }
 
+// CompleteVolumeImportRequest 完成卷导入请求：分片通过 create-instance-import / upload-instance-import-part 上传，
+// 拼接、校验后转换为存储池中的新 qcow2 卷，用于导入其他集群导出的快照或数据盘
+type CompleteVolumeImportRequest struct {
+	NodeName string `json:"node_name"`                    // 节点名称(可选,默认本地节点)
+	PoolName string `json:"pool_name" binding:"required"` // 存储池名称，必须与发起导入时相同
+	ImportID string `json:"import_id" binding:"required"` // 导入 ID
+	Format   string `json:"format,omitempty"`             // 上传镜像格式: qcow2, raw, vmdk, vhdx (默认: qcow2)
+	Name     string `json:"name,omitempty"`               // 卷名称(可选)，不指定时自动生成
+	DryRun   bool   `json:"dry_run,omitempty"`            // 校验存储池类型、卷名称未占用与分片序号连续，不转换镜像
+}
+
+// CompleteVolumeImportResponse 完成卷导入响应
+type CompleteVolumeImportResponse struct {
+	Volume *Volume `json:"volume"`
+}
+
+// AttachVolumeRequest 附加卷到实例请求
+type AttachVolumeRequest struct {
+	NodeName           string              `json:"node_name"`                       // 节点名称(可选,默认本地节点)
+	PoolName           string              `json:"pool_name" binding:"required"`    // 存储池名称
+	VolumeID           string              `json:"volume_id" binding:"required"`    // 卷 ID
+	InstanceID         string              `json:"instance_id" binding:"required"`  // 实例 ID
+	Device             string              `json:"device"`                          // 目标设备名(可选,如 vdb/sdb/nvme0n2,默认按总线自动分配)
+	Bus                string              `json:"bus"`                             // 磁盘总线: virtio/scsi/nvme (默认: virtio)
+	Queues             int                 `json:"queues,omitempty"`                // virtio-scsi 控制器队列数(可选,仅首次挂载 scsi 盘新建控制器时生效)
+	IOThread           bool                `json:"iothread,omitempty"`              // 是否为 virtio-scsi 控制器分配独立 iothread(同上)
+	DiskDriver         *DiskDriverOptions  `json:"disk_driver,omitempty"`           // 磁盘 driver 参数(可选,未设置的字段使用卷格式的默认值)
+	AutoFormatAndMount *VolumeMountOptions `json:"auto_format_and_mount,omitempty"` // 附加后通过 guest-agent 在 guest 内格式化并挂载(可选,需要实例运行且 guest-agent 可用)
+	DryRun             bool                `json:"dry_run,omitempty"`               // 校验挂载冲突、multi-attach 缓存模式、自动挂载参数与实例存在，不附加卷
+}
+
+// DiskDriverOptions 磁盘 driver 的 cache、IO 模式与 discard 参数
+// 未设置的字段按卷格式取默认值：raw 为 cache=none,io=native，qcow2 为 cache=none,io=threads，均为 discard=unmap
+type DiskDriverOptions struct {
+	Cache   string `json:"cache,omitempty"`   // cache 模式：default, none, writethrough, writeback, directsync, unsafe
+	IO      string `json:"io,omitempty"`      // IO 模式：native（需要 cache 为 none 或 directsync）, threads, io_uring
+	Discard string `json:"discard,omitempty"` // discard 处理：unmap（guest trim 回收卷空间）, ignore
+}
+
+// VolumeMountOptions 附加卷后在 guest 内自动格式化与挂载的参数
+// 卷上已有文件系统时不会重新格式化，直接按已有文件系统挂载
+type VolumeMountOptions struct {
+	FSType     string `json:"fs_type,omitempty"`              // 文件系统类型: ext4, xfs (默认: ext4)
+	MountPoint string `json:"mount_point" binding:"required"` // 挂载点绝对路径，写入 /etc/fstab（带 nofail），重启后自动挂载
+}
+
+// AttachVolumeResponse 附加卷到实例响应
+type AttachVolumeResponse struct {
+	Attachment *VolumeAttachment `json:"attachment"`
+}
+
+// DetachVolumeRequest 从实例分离卷请求
+type DetachVolumeRequest struct {
+	NodeName   string `json:"node_name"`                      // 节点名称(可选,默认本地节点)
+	PoolName   string `json:"pool_name" binding:"required"`   // 存储池名称
+	VolumeID   string `json:"volume_id" binding:"required"`   // 卷 ID
+	InstanceID string `json:"instance_id" binding:"required"` // 实例 ID
+	DryRun     bool   `json:"dry_run,omitempty"`              // 校验卷已附加到实例且不是系统盘，不分离卷
+}
+
+// DetachVolumeResponse 从实例分离卷响应
+type DetachVolumeResponse struct {
+	Message string `json:"message"`
+}
+
+// VolumeAttachment 卷挂载信息
+type VolumeAttachment struct {
+	VolumeID   string `json:"volume_id"`
+	InstanceID string `json:"instance_id"`
+	Device     string `json:"device"`
+	Bus        string `json:"bus"`
+	Serial     string `json:"serial"`                // guest 内可通过 /dev/disk/by-id/*<serial> 定位
+	MountPoint string `json:"mount_point,omitempty"` // 自动格式化与挂载后的挂载点
+}
+
+// DescribeVolumeLineageRequest 查询卷 backing 链请求
+// volume_id 与 path 二选一，查询模板文件等不在存储池根目录的卷时使用 path
+type DescribeVolumeLineageRequest struct {
+	NodeName string `json:"node_name"`                    // 节点名称(可选,默认本地节点)
+	PoolName string `json:"pool_name" binding:"required"` // 存储池名称
+	VolumeID string `json:"volume_id"`                    // 卷 ID
+	Path     string `json:"path"`                         // 卷文件路径，需位于存储池目录下
+}
+
+// DescribeVolumeLineageResponse 查询卷 backing 链响应
+type DescribeVolumeLineageResponse struct {
+	Lineage *VolumeLineage `json:"lineage"`
+}
+
+// backing 链节点类型
+const (
+	VolumeLineageKindTemplate = "template" // 模板文件（_templates_ 目录）
+	VolumeLineageKindSnapshot = "snapshot" // 快照 overlay（_snapshots_ 目录）
+	VolumeLineageKindVolume   = "volume"   // 普通卷
+)
+
+// VolumeLineage 卷的 backing 链与依赖关系
+type VolumeLineage struct {
+	Volume     VolumeLineageNode        `json:"volume"`
+	Chain      []VolumeLineageNode      `json:"chain"`      // 从直接 backing 到最底层 base，不含自身
+	Dependents []VolumeLineageNode      `json:"dependents"` // 直接或间接以该卷为 backing 的卷（含快照 overlay）
+	References []VolumeLineageReference `json:"references"` // 引用该卷（含经由子卷引用）的 domain 磁盘
+}
+
+// VolumeLineageNode backing 链上的一个文件
+type VolumeLineageNode struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Pool        string `json:"pool,omitempty"`         // 所属存储池，不在存储池根目录的文件为空
+	Format      string `json:"format,omitempty"`       // 镜像格式
+	Kind        string `json:"kind"`                   // template, snapshot, volume
+	BackingPath string `json:"backing_path,omitempty"` // 直接 backing file
+}
+
+// VolumeLineageReference domain 对卷的引用
+type VolumeLineageReference struct {
+	DomainName string `json:"domain_name"`
+	Device     string `json:"device"` // 磁盘 target，如 vda
+	Source     string `json:"source"` // 磁盘当前使用的文件
+	Direct     bool   `json:"direct"` // 磁盘直接使用该卷，false 表示经由子卷间接依赖
+}
+
 // ==================== Storage Pool 相关 ====================
 // ==================== 通用类型 ====================
 
@@ -0,0 +1,45 @@
+package entity
+
+import "time"
+
+// 可监听变化的资源类型
+const (
+	ResourceTypeInstance    = "instance"
+	ResourceTypeVolume      = "volume"
+	ResourceTypeSnapshot    = "snapshot"
+	ResourceTypeTemplate    = "template"
+	ResourceTypeNetwork     = "network"
+	ResourceTypeStoragePool = "storage_pool"
+	ResourceTypeNode        = "node"
+)
+
+// 资源变化类型
+const (
+	ResourceChangeAdded    = "added"    // 资源创建（包括从回收站恢复）
+	ResourceChangeModified = "modified" // 资源配置或状态变化
+	ResourceChangeDeleted  = "deleted"  // 资源删除（包括移入回收站）
+)
+
+// ResourceChange 资源变化记录
+type ResourceChange struct {
+	Revision     uint64    `json:"revision"`            // 变化发生后的全局 revision，单调递增
+	ResourceType string    `json:"resource_type"`       // 资源类型：instance / volume / snapshot / template / network / storage_pool / node
+	ResourceID   string    `json:"resource_id"`         // 资源 ID
+	NodeName     string    `json:"node_name,omitempty"` // 所在节点，node 类型为空
+	Action       string    `json:"action"`              // 变化类型：added / modified / deleted
+	Timestamp    time.Time `json:"timestamp"`           // 变化时间
+}
+
+// WatchResourcesRequest 监听资源变化请求（long-poll）
+type WatchResourcesRequest struct {
+	SinceRevision  uint64   `json:"since_revision"`            // 返回 revision 大于该值的变化，为 0 时立即返回当前 revision
+	ResourceTypes  []string `json:"resource_types,omitempty"`  // 资源类型过滤，为空表示全部
+	NodeName       string   `json:"node_name,omitempty"`       // 节点过滤，为空表示全部
+	TimeoutSeconds uint64   `json:"timeout_seconds,omitempty"` // 没有变化时最长等待时间（秒），默认 30，最大 300
+}
+
+// WatchResourcesResponse 监听资源变化响应
+type WatchResourcesResponse struct {
+	Revision uint64           `json:"revision"` // 本次已检查到的全局 revision，下次请求作为 since_revision
+	Changes  []ResourceChange `json:"changes"`  // 按 revision 升序排列的变化
+}
@@ -3,48 +3,104 @@ package jvp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/jimmicro/grace"
 	"github.com/jimyag/jvp/internal/jvp/api"
 	"github.com/jimyag/jvp/internal/jvp/config"
 	"github.com/jimyag/jvp/internal/jvp/service"
+	"github.com/jimyag/jvp/pkg/idgen"
 	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/tracing"
 	"github.com/rs/zerolog"
 )
 
 type Server struct {
-	cfg *config.Config
-	api *api.API
+	cfg        *config.Config
+	api        *api.API
+	metadata   *api.MetadataServer // 未启用元数据服务时为 nil
+	scheduler  *service.Scheduler
+	operations *service.OperationLimiter
+	templates  *service.TemplateService
+	instances  *service.InstanceService
+	nodes      *service.NodeStorage
+	nodeInfo   *service.NodeService
+
+	shutdownTracing func(context.Context) error
 }
 
 func New(cfg *config.Config) (*Server, error) {
-	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
+	// 带节点名与实例 ID 的日志同时归档到实例的操作日志，供 GetInstanceOperationLogs 查询
+	instanceLogStore, err := service.NewInstanceLogStore(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("create instance log store: %w", err)
+	}
+	logger := zerolog.New(instanceLogStore.Writer(os.Stdout)).With().Timestamp().Logger()
 	zerolog.DefaultContextLogger = &logger
 
 	// 1. 验证 Libvirt 连接配置（可选，用于快速失败）
 	if cfg.LibvirtURI != "" {
 		logger.Info().Str("uri", cfg.LibvirtURI).Msg("Validating libvirt connection")
-		testClient, err := libvirt.NewWithURI(cfg.LibvirtURI)
+		testClient, err := libvirt.Connect(cfg.LibvirtURI)
 		if err != nil {
 			return nil, fmt.Errorf("failed to connect to libvirt at %s: %w", cfg.LibvirtURI, err)
 		}
 		// 验证连接后关闭，实际连接由 NodeService 管理
-		_ = testClient
+		if err := testClient.Close(); err != nil {
+			logger.Warn().Err(err).Msg("Failed to close libvirt validation connection")
+		}
 		logger.Info().Msg("Libvirt connection validated successfully")
 	}
 	logger.Info().Str("data_dir", cfg.DataDir).Msg("Using data directory")
 
+	// 初始化 OpenTelemetry 追踪，未配置导出地址时只解析请求头中的 trace context
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Endpoint:    cfg.OTLPEndpoint,
+		SampleRatio: cfg.TraceSampleRatio,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setup tracing: %w", err)
+	}
+	if cfg.OTLPEndpoint != "" {
+		logger.Info().Str("endpoint", cfg.OTLPEndpoint).Msg("OpenTelemetry tracing enabled")
+	}
+
+	// 初始化进程级共享的 ID 生成器，所有服务共用同一个生成器
+	var idOpts []idgen.Option
+	if cfg.IDNamespaces {
+		idOpts = append(idOpts, idgen.WithNamespaces())
+	}
+	idgen.SetDefaultGenerator(idgen.New(idOpts...))
+
+	// 解析实例 IP 时按节点缓存 DHCP 租约
+	libvirt.SetLeaseCacheTTL(cfg.DHCPLeaseCacheTTL())
+	// 节点未单独配置时 VNC socket 目录与卷的属主修复策略
+	libvirt.SetDefaultOwnershipPolicy(libvirt.OwnershipPolicy{
+		Disabled: cfg.DisableOwnershipFix,
+		User:     cfg.QEMUUser,
+		Group:    cfg.QEMUGroup,
+	})
+
 	// 2. 创建 Node Storage
 	nodeStorage, err := service.NewNodeStorage(cfg.DataDir)
 	if err != nil {
 		return nil, fmt.Errorf("create node storage: %w", err)
 	}
 
-	// 3. 创建 Node Service
-	nodeService, err := service.NewNodeService(nodeStorage)
+	// 3. 创建 Node Service（持有大文件传输的全局限速、每节点操作并发上限与资源变化流，各服务共享）
+	transferLimiter := service.NewTransferLimiter(cfg.TransferBandwidthMiB)
+	operationLimiter := service.NewOperationLimiter(cfg.NodeMaxConcurrentOperations)
+	changeFeed := service.NewChangeFeed()
+	networkSegmentStore, err := service.NewNetworkSegmentStore(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("create network segment store: %w", err)
+	}
+	nodeService, err := service.NewNodeService(nodeStorage, transferLimiter, operationLimiter, changeFeed, cfg.Region, service.NetworkDefaults{
+		Type:   cfg.DefaultNetworkType,
+		Source: cfg.DefaultNetworkSource,
+	}, networkSegmentStore, cfg.NodeClockSkewThreshold())
 	if err != nil {
 		return nil, err
 	}
@@ -55,73 +111,229 @@ func New(cfg *config.Config) (*Server, error) {
 		return nil, fmt.Errorf("create keypair service: %w", err)
 	}
 
+	// 安全组在节点上实现为 libvirt nwfilter，RunInstance 时绑定到实例网卡
+	securityGroupStore, err := service.NewSecurityGroupStore(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("create security group store: %w", err)
+	}
+	securityGroupService := service.NewSecurityGroupService(nodeService.GetNodeStorage, securityGroupStore)
+
+	// 元数据服务：guest 通过 169.254.169.254 拉取 cloud-init 配置，未配置监听地址时不启用
+	instanceMetadataStore, err := service.NewInstanceMetadataStore(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("create instance metadata store: %w", err)
+	}
+	metadataService := service.NewMetadataService(nodeService.GetNodeStorage, instanceMetadataStore, cfg.MetadataAddress != "")
+
 	// 5. 创建 Storage Pool Service
-	storagePoolService := service.NewStoragePoolService(nodeStorage)
+	storagePoolService := service.NewStoragePoolService(nodeStorage, changeFeed)
 
-	// 6. 创建 Volume Service
-	volumeService := service.NewVolumeService(nodeService, storagePoolService)
+	// 6. 创建 Volume Service（回收站和删除保护与 Instance Service 共享）
+	recycleBin, err := service.NewRecycleBin(cfg.DataDir, cfg.RecycleRetentionDays)
+	if err != nil {
+		return nil, fmt.Errorf("create recycle bin: %w", err)
+	}
+	deletionProtection, err := service.NewDeletionProtection(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("create deletion protection: %w", err)
+	}
+	multiAttachStore, err := service.NewMultiAttachStore(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("create multi-attach store: %w", err)
+	}
+	volumeLabelStore, err := service.NewVolumeLabelStore(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("create volume label store: %w", err)
+	}
+	volumeService := service.NewVolumeService(nodeService, storagePoolService, transferLimiter, recycleBin, deletionProtection, multiAttachStore, volumeLabelStore, changeFeed)
 
 	// 7. 创建 Template Service
 	templateStore := service.NewTemplateStore(nodeService.GetNodeStorage)
-	templateService := service.NewTemplateService(nodeService.GetNodeStorage, templateStore)
+	templateService := service.NewTemplateService(nodeService.GetNodeStorage, templateStore, transferLimiter, operationLimiter, changeFeed, cfg.TemplateCacheMaxGB)
 
-	// 8. 创建 Snapshot Service
-	snapshotService := service.NewSnapshotService(nodeService)
+	// 8. 创建 Snapshot Service（与 Instance Service 共享文件系统冻结状态）
+	fsFreezeManager := service.NewFSFreezeManager()
+	snapshotService := service.NewSnapshotService(nodeService, fsFreezeManager, changeFeed)
 
 	// 9. 创建 Bridge Service
 	bridgeService := service.NewBridgeService(nodeStorage)
 
-	// 10. 创建 Network Service
-	networkService := service.NewNetworkService(nodeStorage, bridgeService)
+	// 10. 创建 Network Service（与 Node Service 共享隔离网络的 VLAN/VXLAN 分配）
+	networkService := service.NewNetworkService(nodeStorage, bridgeService, changeFeed, networkSegmentStore, cfg.OVSPortCleanupInterval())
 
-	// 11. 创建 Instance Service
-	instanceService, err := service.NewInstanceService(nodeService, templateService, keyPairService)
+	// 11. 创建 Instance Service（事件历史、实例归属租户、标签与 guest OS 信息持久化在数据目录下）
+	instanceEventStore, err := service.NewInstanceEventStore(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("create instance event store: %w", err)
+	}
+	quotaStore, err := service.NewQuotaStore(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("create quota store: %w", err)
+	}
+	guestOSStore, err := service.NewGuestOSStore(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("create guest os store: %w", err)
+	}
+	instanceLabelStore, err := service.NewInstanceLabelStore(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("create instance label store: %w", err)
+	}
+	// 镜像转换（virt-v2v、OVA 解包）的临时空间：执行前预检剩余空间与配额，结束后强制清理
+	tempSpaceManager := service.NewTempSpaceManager(cfg.TempDir, cfg.TempQuotaGB)
+	instanceService, err := service.NewInstanceService(nodeService, templateService, keyPairService, securityGroupService, metadataService, service.GuestDefaults{
+		Timezone:   cfg.DefaultTimezone,
+		Locale:     cfg.DefaultLocale,
+		NTPServers: cfg.DefaultNTPServers,
+	}, fsFreezeManager, transferLimiter, operationLimiter, tempSpaceManager, instanceEventStore, instanceLogStore, recycleBin, deletionProtection, changeFeed, quotaStore, guestOSStore, instanceLabelStore, cfg.IPv6NDPProxy)
 	if err != nil {
 		return nil, err
 	}
 
-	// 12. 创建 API
+	// 12. 创建 Recycle Bin Service（负责查询回收站和定期清理过期资源）
+	recycleBinService := service.NewRecycleBinService(recycleBin, instanceService, volumeService)
+
+	// 13. 创建 Quota Service（统计各租户资源使用量）
+	quotaService := service.NewQuotaService(quotaStore, nodeService, instanceService)
+
+	// 14. 创建 Scheduler（统一执行定时任务，多进程共享数据目录时只有 leader 执行；只读模式下跳过触发）
+	readOnlyMode := service.NewReadOnlyMode(cfg.ReadOnly, cfg.ReadOnlyReason)
+	scheduler, err := service.NewScheduler(cfg.DataDir, readOnlyMode)
+	if err != nil {
+		return nil, fmt.Errorf("create scheduler: %w", err)
+	}
+	if err := recycleBinService.RegisterScheduledTasks(scheduler); err != nil {
+		return nil, fmt.Errorf("register recycle bin tasks: %w", err)
+	}
+	// 定期刷新存储池，发现在节点上手工放置或删除的卷
+	storageScanService := service.NewStorageScanService(nodeService, templateService, changeFeed, cfg.StorageScanInterval())
+	if err := storageScanService.RegisterScheduledTasks(scheduler); err != nil {
+		return nil, fmt.Errorf("register storage scan tasks: %w", err)
+	}
+	// 定期清理 libvirtd 异常退出或节点重启后留在 ovsdb 中的实例端口
+	if err := networkService.RegisterScheduledTasks(scheduler); err != nil {
+		return nil, fmt.Errorf("register network tasks: %w", err)
+	}
+
+	// 定期采集实例 CPU 时间、磁盘占用与网络流量，生成计费用量记录
+	usageStore, err := service.NewUsageStore(cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("create usage store: %w", err)
+	}
+	usageService := service.NewUsageService(usageStore, nodeService, quotaStore, cfg.UsageInterval(), cfg.UsageRetention())
+	if err := usageService.RegisterScheduledTasks(scheduler); err != nil {
+		return nil, fmt.Errorf("register usage tasks: %w", err)
+	}
+
+	// 取消模板下载、V2V 迁移与磁盘 block job
+	taskService := service.NewTaskService(templateService, instanceService)
+
+	// 实例、卷与模板的统一标签接口
+	tagService := service.NewTagService(instanceService, volumeService, templateService)
+
+	// 15. 创建 API
 	apiInstance, err := api.New(
 		nodeService,
 		instanceService,
 		volumeService,
 		keyPairService,
+		securityGroupService,
 		storagePoolService,
 		templateService,
 		snapshotService,
 		networkService,
 		bridgeService,
+		recycleBinService,
+		quotaService,
+		usageService,
+		scheduler,
+		taskService,
+		tagService,
+		readOnlyMode,
 		cfg,
 	)
 	if err != nil {
 		return nil, err
 	}
+	var metadataServer *api.MetadataServer
+	if cfg.MetadataAddress != "" {
+		metadataServer = api.NewMetadataServer(metadataService, cfg.MetadataAddress)
+	}
 
 	server := &Server{
-		cfg: cfg,
-		api: apiInstance,
+		cfg:        cfg,
+		api:        apiInstance,
+		metadata:   metadataServer,
+		scheduler:  scheduler,
+		operations: operationLimiter,
+		templates:  templateService,
+		instances:  instanceService,
+		nodes:      nodeStorage,
+		nodeInfo:   nodeService,
+
+		shutdownTracing: shutdownTracing,
 	}
 	return server, nil
 }
 
+// Run 启动服务并阻塞，收到 SIGTERM/SIGINT 等信号后按 Shutdown 的顺序优雅关停
 func (s *Server) Run(ctx context.Context) error {
-	// 使用 grace.Shepherd 管理服务生命周期
-	services := []grace.Grace{
-		s.api,
-	}
-
+	// 使用 grace.Shepherd 管理服务生命周期；各组件的关停有先后依赖，由 Server 统一编排
 	shepherd := grace.NewShepherd(
-		services,
-		grace.WithTimeout(30*time.Second),
+		[]grace.Grace{(*serverGrace)(s)},
+		grace.WithTimeout(s.cfg.ShutdownTimeout()),
 		grace.WithLogger(&zerologLogger{}),
 	)
-
-	shepherd.Start(ctx)
-	return nil
+	return shepherd.StartErr(ctx)
 }
 
+// Shutdown 优雅关停，ctx 到期后不再等待：
+//  1. 取消排队中的重 IO 操作（排队中的请求返回 ErrShuttingDown），API 与元数据服务停止接收新请求，
+//     等待进行中的请求（如创建实例）完成，避免留下半成品实例
+//  2. 终止 V2V 迁移任务（转换耗时通常远超关停超时）；等待执行中的操作（如模板下载）完成，
+//     仍未完成的下载任务标记为中断并删除部分文件
+//  3. 等待执行中的定时任务结束
+//  4. 关闭所有节点的 libvirt 连接
+//  5. 导出剩余的 trace span
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.api.Shutdown(ctx)
+	logger := zerolog.DefaultContextLogger
+	var errs []error
+
+	// 排队中的请求只有在取消后才会返回，必须先于等待 API 请求结束
+	s.operations.CancelQueued()
+	if err := s.api.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("shutdown api server: %w", err))
+	}
+	if s.metadata != nil {
+		if err := s.metadata.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown metadata server: %w", err))
+		}
+	}
+
+	s.instances.InterruptV2VTasks(logger.WithContext(context.WithoutCancel(ctx)))
+	if running := s.operations.Drain(ctx); len(running) > 0 {
+		for _, op := range running {
+			logger.Warn().
+				Str("node_name", op.NodeName).
+				Str("type", op.Type).
+				Str("resource", op.Resource).
+				Msg("Operation still running at shutdown deadline")
+		}
+		errs = append(errs, fmt.Errorf("%d operations still running at shutdown deadline", len(running)))
+	}
+	s.templates.InterruptDownloads(logger.WithContext(context.WithoutCancel(ctx)))
+
+	if err := s.scheduler.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("wait scheduled tasks: %w", err))
+	}
+
+	if err := s.nodes.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close libvirt connections: %w", err))
+	}
+
+	if err := s.shutdownTracing(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("flush trace spans: %w", err))
+	}
+	return errors.Join(errs...)
 }
 
 // Name 实现 grace.Grace 接口
@@ -129,6 +341,37 @@ func (s *Server) Name() string {
 	return "JVP Server"
 }
 
+// serverGrace 把 Server 适配为 grace.Grace：并发运行 API、调度器与元数据服务，任一退出出错即触发关停
+type serverGrace Server
+
+func (g *serverGrace) Run(ctx context.Context) error {
+	s := (*Server)(g)
+	// 后台探测各节点的工具与宿主机能力，创建实例时据此提前拒绝缺少依赖的功能
+	go s.nodeInfo.ProbeAllNodeFeatures(ctx)
+	runs := []func(context.Context) error{s.api.Run, s.scheduler.Run}
+	if s.metadata != nil {
+		runs = append(runs, s.metadata.Run)
+	}
+	errCh := make(chan error, len(runs))
+	for _, run := range runs {
+		go func() { errCh <- run(ctx) }()
+	}
+	for range runs {
+		if err := <-errCh; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *serverGrace) Shutdown(ctx context.Context) error {
+	return (*Server)(g).Shutdown(ctx)
+}
+
+func (g *serverGrace) Name() string {
+	return (*Server)(g).Name()
+}
+
 // zerologLogger 实现 grace.Logger 接口
 type zerologLogger struct{}
 
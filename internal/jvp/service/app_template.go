@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/cloudinit"
+)
+
+// DescribeAppTemplates 列出内置应用模板
+func (s *InstanceService) DescribeAppTemplates(ctx context.Context, req *entity.DescribeAppTemplatesRequest) (*entity.DescribeAppTemplatesResponse, error) {
+	templates := cloudinit.AppTemplates()
+	resp := &entity.DescribeAppTemplatesResponse{
+		AppTemplates: make([]entity.AppTemplate, 0, len(templates)),
+	}
+	for _, t := range templates {
+		resp.AppTemplates = append(resp.AppTemplates, entity.AppTemplate{
+			Name:        t.Name,
+			Description: t.Description,
+			Packages:    t.Packages,
+			Commands:    t.Commands,
+		})
+	}
+	return resp, nil
+}
+
+// lookupAppTemplate 查找请求指定的应用模板，未指定时返回 nil
+func lookupAppTemplate(name string) (*cloudinit.AppTemplate, error) {
+	if name == "" {
+		return nil, nil
+	}
+	appTemplate, ok := cloudinit.GetAppTemplate(name)
+	if !ok {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unknown app_template %q, must be %s, %s or %s", name,
+				cloudinit.AppTemplateDockerHost, cloudinit.AppTemplateK8sWorker, cloudinit.AppTemplateNFSServer),
+			http.StatusBadRequest,
+		)
+	}
+	return appTemplate, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+)
+
+// DescribeRegions 查询区域：整个 JVP 集群对应一个区域，用于兼容 AWS SDK 初始化时的探测调用
+func (s *NodeService) DescribeRegions(ctx context.Context, req *entity.DescribeRegionsRequest) (*entity.DescribeRegionsResponse, error) {
+	regions := []entity.Region{}
+	if len(req.RegionNames) == 0 || slices.Contains(req.RegionNames, s.region) {
+		regions = append(regions, entity.Region{
+			RegionName:  s.region,
+			OptInStatus: "opt-in-not-required",
+		})
+	}
+	return &entity.DescribeRegionsResponse{Regions: regions}, nil
+}
+
+// DescribeAvailabilityZones 查询可用区：zone 标签相同的节点组成一个可用区，未设置 zone 的节点不属于任何可用区
+func (s *NodeService) DescribeAvailabilityZones(ctx context.Context, req *entity.DescribeAvailabilityZonesRequest) (*entity.DescribeAvailabilityZonesResponse, error) {
+	nodes, err := s.ListNodes(ctx)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list nodes", err)
+	}
+
+	zones := map[string]*entity.AvailabilityZone{}
+	for _, node := range nodes {
+		if node.Zone == "" {
+			continue
+		}
+		zone, ok := zones[node.Zone]
+		if !ok {
+			zone = &entity.AvailabilityZone{
+				ZoneName:   node.Zone,
+				ZoneID:     s.region + "-" + node.Zone,
+				ZoneType:   "availability-zone",
+				State:      entity.AvailabilityZoneStateUnavailable,
+				RegionName: s.region,
+				GroupName:  s.region,
+			}
+			zones[node.Zone] = zone
+		}
+		zone.NodeNames = append(zone.NodeNames, node.Name)
+		if node.State == entity.NodeStateOnline {
+			zone.State = entity.AvailabilityZoneStateAvailable
+		}
+	}
+
+	result := make([]entity.AvailabilityZone, 0, len(zones))
+	for _, zone := range zones {
+		if matchAvailabilityZone(zone, req) {
+			sort.Strings(zone.NodeNames)
+			result = append(result, *zone)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ZoneName < result[j].ZoneName
+	})
+	return &entity.DescribeAvailabilityZonesResponse{AvailabilityZones: result}, nil
+}
+
+// matchAvailabilityZone 判断可用区是否满足请求中的名称、ID 与过滤条件
+func matchAvailabilityZone(zone *entity.AvailabilityZone, req *entity.DescribeAvailabilityZonesRequest) bool {
+	if len(req.ZoneNames) > 0 && !slices.Contains(req.ZoneNames, zone.ZoneName) {
+		return false
+	}
+	if len(req.ZoneIDs) > 0 && !slices.Contains(req.ZoneIDs, zone.ZoneID) {
+		return false
+	}
+	for _, filter := range req.Filters {
+		var value string
+		switch filter.Name {
+		case "zone-name":
+			value = zone.ZoneName
+		case "zone-id":
+			value = zone.ZoneID
+		case "state":
+			value = zone.State
+		case "region-name":
+			value = zone.RegionName
+		default:
+			continue
+		}
+		if !slices.Contains(filter.Values, value) {
+			return false
+		}
+	}
+	return true
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os/exec"
+	"slices"
 	"strings"
 
 	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/shellx"
 )
 
 // BridgeService 宿主机网桥服务
@@ -106,7 +110,7 @@ func (s *BridgeService) ListBridges(ctx context.Context, nodeName string) ([]ent
 	for _, link := range bridgeLinks {
 		// 获取桥接设备的 IP 地址
 		ips := make([]string, 0)
-		ipOutput, err := s.executeCommand(ctx, nodeName, fmt.Sprintf("ip -j addr show %s", link.IfName))
+		ipOutput, err := s.executeCommand(ctx, nodeName, "ip -j addr show "+shellx.Quote(link.IfName))
 		if err == nil {
 			var addrLinks []ipLinkOutput
 			if json.Unmarshal(ipOutput, &addrLinks) == nil && len(addrLinks) > 0 {
@@ -126,7 +130,7 @@ func (s *BridgeService) ListBridges(ctx context.Context, nodeName string) ([]ent
 
 		// 检查 STP 状态
 		stp := false
-		stpOutput, err := s.executeCommand(ctx, nodeName, fmt.Sprintf("cat /sys/class/net/%s/bridge/stp_state 2>/dev/null || echo 0", link.IfName))
+		stpOutput, err := s.executeCommand(ctx, nodeName, "cat "+shellx.Quote("/sys/class/net/"+link.IfName+"/bridge/stp_state")+" 2>/dev/null || echo 0")
 		if err == nil {
 			stp = strings.TrimSpace(string(stpOutput)) != "0"
 		}
@@ -152,8 +156,48 @@ func (s *BridgeService) ListBridges(ctx context.Context, nodeName string) ([]ent
 
 // CreateBridge 创建网桥
 func (s *BridgeService) CreateBridge(ctx context.Context, req *entity.CreateBridgeRequest) (*entity.HostBridge, error) {
+	// 名称拼接进节点上执行的命令，先按接口名规则校验
+	if err := validateInterfaceName("Bridge", req.BridgeName); err != nil {
+		return nil, err
+	}
+	for _, iface := range req.Interfaces {
+		if err := validateInterfaceName("Interface", iface); err != nil {
+			return nil, err
+		}
+	}
+
+	// 名称未占用、接口可用的校验在 DryRun 与实际创建时一致
+	bridges, err := s.ListBridges(ctx, req.NodeName)
+	if err != nil {
+		return nil, fmt.Errorf("list bridges: %w", err)
+	}
+	for _, br := range bridges {
+		if br.Name == req.BridgeName {
+			return nil, newResourceAlreadyExistsError("Bridge", req.BridgeName)
+		}
+	}
+	if len(req.Interfaces) > 0 {
+		available, err := s.ListAvailableInterfaces(ctx, req.NodeName)
+		if err != nil {
+			return nil, fmt.Errorf("list available interfaces: %w", err)
+		}
+		for _, iface := range req.Interfaces {
+			if !slices.ContainsFunc(available, func(ni entity.NetworkInterface) bool { return ni.Name == iface }) {
+				return nil, apierror.NewErrorWithStatus(
+					"InvalidParameterValue",
+					fmt.Sprintf("Interface %s is not available on node %s", iface, req.NodeName),
+					http.StatusBadRequest,
+				)
+			}
+		}
+	}
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CreateBridge")
+	}
+
 	// 创建网桥
-	cmd := fmt.Sprintf("ip link add %s type bridge", req.BridgeName)
+	bridge := shellx.Quote(req.BridgeName)
+	cmd := "ip link add " + bridge + " type bridge"
 	if _, err := s.executeCommand(ctx, req.NodeName, cmd); err != nil {
 		return nil, fmt.Errorf("create bridge: %w", err)
 	}
@@ -163,32 +207,32 @@ func (s *BridgeService) CreateBridge(ctx context.Context, req *entity.CreateBrid
 	if req.STP {
 		stpVal = "1"
 	}
-	cmd = fmt.Sprintf("echo %s > /sys/class/net/%s/bridge/stp_state", stpVal, req.BridgeName)
+	cmd = "echo " + stpVal + " > " + shellx.Quote("/sys/class/net/"+req.BridgeName+"/bridge/stp_state")
 	_, _ = s.executeCommand(ctx, req.NodeName, cmd)
 
 	// 启用网桥
-	cmd = fmt.Sprintf("ip link set %s up", req.BridgeName)
+	cmd = "ip link set " + bridge + " up"
 	if _, err := s.executeCommand(ctx, req.NodeName, cmd); err != nil {
 		// 启用失败，删除已创建的网桥
-		_, _ = s.executeCommand(ctx, req.NodeName, fmt.Sprintf("ip link del %s", req.BridgeName))
+		_, _ = s.executeCommand(ctx, req.NodeName, "ip link del "+bridge)
 		return nil, fmt.Errorf("enable bridge: %w", err)
 	}
 
 	// 绑定指定的网络接口
 	for _, iface := range req.Interfaces {
 		// 将接口添加到网桥
-		cmd = fmt.Sprintf("ip link set %s master %s", iface, req.BridgeName)
+		cmd = "ip link set " + shellx.Quote(iface) + " master " + bridge
 		if _, err := s.executeCommand(ctx, req.NodeName, cmd); err != nil {
 			// 绑定失败，继续处理其他接口，但记录错误
 			continue
 		}
 		// 确保接口是启用状态
-		cmd = fmt.Sprintf("ip link set %s up", iface)
+		cmd = "ip link set " + shellx.Quote(iface) + " up"
 		_, _ = s.executeCommand(ctx, req.NodeName, cmd)
 	}
 
 	// 获取创建后的网桥信息
-	bridges, err := s.ListBridges(ctx, req.NodeName)
+	bridges, err = s.ListBridges(ctx, req.NodeName)
 	if err != nil {
 		return nil, fmt.Errorf("get bridge info: %w", err)
 	}
@@ -269,12 +313,31 @@ func (s *BridgeService) ListAvailableInterfaces(ctx context.Context, nodeName st
 
 // DeleteBridge 删除网桥
 func (s *BridgeService) DeleteBridge(ctx context.Context, nodeName, bridgeName string) error {
+	if err := validateInterfaceName("Bridge", bridgeName); err != nil {
+		return err
+	}
+
+	bridges, err := s.ListBridges(ctx, nodeName)
+	if err != nil {
+		return fmt.Errorf("list bridges: %w", err)
+	}
+	if !slices.ContainsFunc(bridges, func(br entity.HostBridge) bool { return br.Name == bridgeName }) {
+		return apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Bridge %s not found on node %s", bridgeName, nodeName),
+			http.StatusNotFound,
+		)
+	}
+	if isDryRun(ctx) {
+		return dryRunOperation(ctx, "DeleteBridge")
+	}
+
 	// 先停用网桥
-	cmd := fmt.Sprintf("ip link set %s down", bridgeName)
+	cmd := "ip link set " + shellx.Quote(bridgeName) + " down"
 	_, _ = s.executeCommand(ctx, nodeName, cmd)
 
 	// 删除网桥
-	cmd = fmt.Sprintf("ip link del %s", bridgeName)
+	cmd = "ip link del " + shellx.Quote(bridgeName)
 	if _, err := s.executeCommand(ctx, nodeName, cmd); err != nil {
 		return fmt.Errorf("delete bridge %s: %w", bridgeName, err)
 	}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateInterfaceName(t *testing.T) {
+	for _, name := range []string{"br0", "br-lan", "vmbr0.100", "eth_0", "abcdefghijklmno"} {
+		assert.NoError(t, validateInterfaceName("Bridge", name), name)
+	}
+	for _, name := range []string{
+		"",
+		"abcdefghijklmnop", // 超过 IFNAMSIZ
+		"-br0",
+		".",
+		"br0;reboot",
+		"br0 type dummy",
+		"$(id)",
+		"br/0",
+		"br'0",
+	} {
+		assert.Error(t, validateInterfaceName("Bridge", name), name)
+	}
+}
+
+// TestBridgeRejectsInvalidNames 名称不合法时在执行任何节点命令之前返回错误
+func TestBridgeRejectsInvalidNames(t *testing.T) {
+	s := NewBridgeService(nil)
+	ctx := context.Background()
+
+	_, err := s.CreateBridge(ctx, &entity.CreateBridgeRequest{NodeName: "remote", BridgeName: "br0;reboot"})
+	assert.Error(t, err)
+	_, err = s.CreateBridge(ctx, &entity.CreateBridgeRequest{NodeName: "remote", BridgeName: "br0", Interfaces: []string{"eth0 && reboot"}})
+	assert.Error(t, err)
+	assert.Error(t, s.DeleteBridge(ctx, "remote", "$(reboot)"))
+}
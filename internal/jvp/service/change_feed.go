@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+)
+
+// maxResourceChanges 保留的最近变化条数，since_revision 早于保留窗口时需要重新列举
+const maxResourceChanges = 10000
+
+// watchableResourceTypes 可以监听变化的资源类型
+var watchableResourceTypes = []string{
+	entity.ResourceTypeInstance,
+	entity.ResourceTypeVolume,
+	entity.ResourceTypeSnapshot,
+	entity.ResourceTypeTemplate,
+	entity.ResourceTypeNetwork,
+	entity.ResourceTypeStoragePool,
+	entity.ResourceTypeNode,
+}
+
+// 监听请求的等待时间
+const (
+	defaultWatchTimeout = 30 * time.Second
+	maxWatchTimeout     = 300 * time.Second
+)
+
+// ChangeFeed 资源变化流，为所有资源变化分配单调递增的全局 revision
+// 只在进程内保留最近的变化，重启后 revision 从 0 重新开始，客户端需要重新列举
+type ChangeFeed struct {
+	mu       sync.Mutex
+	revision uint64                  // 当前全局 revision
+	changes  []entity.ResourceChange // 最近的变化，按 revision 升序
+	latest   map[string]uint64       // key: resourceType/nodeName/resourceID -> 资源最后一次变化的 revision
+	notify   chan struct{}           // 有新变化时关闭并替换，用于唤醒等待中的监听请求
+}
+
+// NewChangeFeed 创建资源变化流
+func NewChangeFeed() *ChangeFeed {
+	return &ChangeFeed{
+		latest: make(map[string]uint64),
+		notify: make(chan struct{}),
+	}
+}
+
+// resourceKey 生成资源唯一标识
+func resourceKey(resourceType, nodeName, resourceID string) string {
+	return resourceType + "/" + changeNodeName(resourceType, nodeName) + "/" + resourceID
+}
+
+// changeNodeName 变化记录中的节点名，节点自身的变化不带节点名，其余资源为空时视为本地节点
+func changeNodeName(resourceType, nodeName string) string {
+	if resourceType == entity.ResourceTypeNode {
+		return ""
+	}
+	return normalizeNodeName(nodeName)
+}
+
+// Record 记录资源变化并唤醒监听请求，返回分配的 revision
+func (f *ChangeFeed) Record(resourceType, nodeName, resourceID, action string) uint64 {
+	if f == nil {
+		return 0
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.revision++
+	f.changes = append(f.changes, entity.ResourceChange{
+		Revision:     f.revision,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		NodeName:     changeNodeName(resourceType, nodeName),
+		Action:       action,
+		Timestamp:    time.Now(),
+	})
+	if len(f.changes) > maxResourceChanges {
+		f.changes = slices.Clone(f.changes[len(f.changes)-maxResourceChanges:])
+	}
+
+	key := resourceKey(resourceType, nodeName, resourceID)
+	if action == entity.ResourceChangeDeleted {
+		delete(f.latest, key)
+	} else {
+		f.latest[key] = f.revision
+	}
+
+	close(f.notify)
+	f.notify = make(chan struct{})
+	return f.revision
+}
+
+// Revision 返回当前全局 revision
+func (f *ChangeFeed) Revision() uint64 {
+	if f == nil {
+		return 0
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.revision
+}
+
+// ResourceRevision 返回资源最后一次变化的 revision，进程启动后没有变化过的资源返回 0
+func (f *ChangeFeed) ResourceRevision(resourceType, nodeName, resourceID string) uint64 {
+	if f == nil {
+		return 0
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.latest[resourceKey(resourceType, nodeName, resourceID)]
+}
+
+// ChangedSince 资源在 revision 之后是否有变化记录；超出保留窗口的变化无法判断，视为没有变化
+func (f *ChangeFeed) ChangedSince(resourceType, nodeName, resourceID string, since uint64) bool {
+	if f == nil {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	nodeName = changeNodeName(resourceType, nodeName)
+	for i := len(f.changes) - 1; i >= 0 && f.changes[i].Revision > since; i-- {
+		change := f.changes[i]
+		if change.ResourceType == resourceType && change.NodeName == nodeName && change.ResourceID == resourceID {
+			return true
+		}
+	}
+	return false
+}
+
+// WatchResources 返回 revision 大于 since_revision 的资源变化
+// 没有符合过滤条件的变化时等待直到出现新变化或超时；超时返回空列表和当前 revision
+// since_revision 早于保留窗口或大于当前 revision（服务重启）时返回 410 ResourceExpired，客户端需重新列举
+func (f *ChangeFeed) WatchResources(ctx context.Context, req *entity.WatchResourcesRequest) (*entity.WatchResourcesResponse, error) {
+	for _, resourceType := range req.ResourceTypes {
+		if !slices.Contains(watchableResourceTypes, resourceType) {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("Unsupported resource type: %s", resourceType),
+				http.StatusBadRequest,
+			)
+		}
+	}
+	if req.SinceRevision == 0 {
+		return &entity.WatchResourcesResponse{Revision: f.Revision(), Changes: []entity.ResourceChange{}}, nil
+	}
+
+	timeout := defaultWatchTimeout
+	if req.TimeoutSeconds > 0 {
+		timeout = min(time.Duration(req.TimeoutSeconds)*time.Second, maxWatchTimeout)
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	since := req.SinceRevision
+	for {
+		f.mu.Lock()
+		if err := f.checkRevision(since); err != nil {
+			f.mu.Unlock()
+			return nil, err
+		}
+
+		changes := []entity.ResourceChange{}
+		for _, change := range f.changes {
+			if change.Revision > since && matchesWatch(req, change) {
+				changes = append(changes, change)
+			}
+		}
+		revision := f.revision
+		notify := f.notify
+		f.mu.Unlock()
+
+		if len(changes) > 0 {
+			return &entity.WatchResourcesResponse{Revision: revision, Changes: changes}, nil
+		}
+		// 期间的变化都不符合过滤条件，从当前 revision 继续等待
+		since = revision
+
+		select {
+		case <-notify:
+		case <-timer.C:
+			return &entity.WatchResourcesResponse{Revision: revision, Changes: changes}, nil
+		case <-ctx.Done():
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Watch canceled", ctx.Err())
+		}
+	}
+}
+
+// checkRevision 校验 since_revision 仍在保留窗口内，调用方需持有锁
+func (f *ChangeFeed) checkRevision(since uint64) error {
+	if since > f.revision {
+		return apierror.NewErrorWithStatus(
+			"ResourceExpired",
+			fmt.Sprintf("Revision %d is newer than current revision %d (server restarted), list resources again", since, f.revision),
+			http.StatusGone,
+		)
+	}
+	if len(f.changes) > 0 && since+1 < f.changes[0].Revision {
+		return apierror.NewErrorWithStatus(
+			"ResourceExpired",
+			fmt.Sprintf("Revision %d is too old (oldest retained revision is %d), list resources again", since, f.changes[0].Revision),
+			http.StatusGone,
+		)
+	}
+	return nil
+}
+
+// matchesWatch 判断变化是否符合监听请求的过滤条件
+func matchesWatch(req *entity.WatchResourcesRequest, change entity.ResourceChange) bool {
+	if len(req.ResourceTypes) > 0 && !slices.Contains(req.ResourceTypes, change.ResourceType) {
+		return false
+	}
+	if req.NodeName == "" {
+		return true
+	}
+	// 节点自身的变化以节点名作为资源 ID
+	if change.ResourceType == entity.ResourceTypeNode {
+		return change.ResourceID == req.NodeName
+	}
+	return change.NodeName == normalizeNodeName(req.NodeName)
+}
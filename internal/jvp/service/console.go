@@ -56,6 +56,7 @@ func (s *InstanceService) GetConsoleInfo(ctx context.Context, req *entity.GetCon
 
 	response := &entity.GetConsoleResponse{
 		InstanceID: req.InstanceID,
+		SerialLog:  consoleInfo.SerialLogPath,
 	}
 
 	// 6. 根据请求类型返回相应的控制台信息
@@ -75,6 +76,24 @@ func (s *InstanceService) GetConsoleInfo(ctx context.Context, req *entity.GetCon
 			Str("vnc_socket", consoleInfo.VNCSocket).
 			Msg("VNC console info retrieved")
 
+	case "spice":
+		if consoleInfo.SPICEPort == 0 && consoleInfo.SPICETLSPort == 0 {
+			return nil, apierror.NewErrorWithStatus(
+				"ConsoleNotAvailable",
+				"SPICE console is not configured for this instance or the instance is not running",
+				400,
+			)
+		}
+		response.SPICEListen = consoleInfo.SPICEListen
+		response.SPICEPort = consoleInfo.SPICEPort
+		response.SPICETLSPort = consoleInfo.SPICETLSPort
+		response.Type = "spice"
+		logger.Info().
+			Str("instance_id", req.InstanceID).
+			Int("spice_port", consoleInfo.SPICEPort).
+			Int("spice_tls_port", consoleInfo.SPICETLSPort).
+			Msg("SPICE console info retrieved")
+
 	case "serial":
 		if consoleInfo.SerialDevice == "" {
 			return nil, apierror.NewErrorWithStatus(
@@ -117,3 +136,53 @@ func (s *InstanceService) GetConsoleInfo(ctx context.Context, req *entity.GetCon
 
 	return response, nil
 }
+
+// defaultConsoleOutputBytes 默认返回的串口日志末尾字节数
+const defaultConsoleOutputBytes = 64 * 1024
+
+// GetConsoleOutput 获取实例串口输出日志，用于启动问题排查与回溯
+func (s *InstanceService) GetConsoleOutput(ctx context.Context, req *entity.GetConsoleOutputRequest) (*entity.GetConsoleOutputResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instance_id", req.InstanceID).
+		Int64("max_bytes", req.MaxBytes).
+		Msg("Getting console output for instance")
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	domain, err := client.GetDomainByName(req.InstanceID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found on node %s", req.InstanceID, req.NodeName),
+			404,
+		)
+	}
+
+	maxBytes := req.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = defaultConsoleOutputBytes
+	}
+
+	output, err := client.GetDomainConsoleOutput(domain, maxBytes)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to read console output", err)
+	}
+
+	logger.Info().
+		Str("instance_id", req.InstanceID).
+		Str("log_path", output.LogPath).
+		Int("bytes", len(output.Output)).
+		Msg("Console output retrieved")
+
+	return &entity.GetConsoleOutputResponse{
+		InstanceID: req.InstanceID,
+		LogPath:    output.LogPath,
+		Output:     output.Output,
+		Truncated:  output.Truncated,
+	}, nil
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+)
+
+// DeletionProtection 实例与卷的删除保护标志存储
+// 每个节点一个 JSON 文件：<dataDir>/deletion-protection/<node>.json；卷按文件路径记录，路径在节点内唯一
+type DeletionProtection struct {
+	storageDir string
+	mu         sync.Mutex
+}
+
+// protectionState 单个节点上开启了删除保护的资源
+type protectionState struct {
+	Instances map[string]bool `json:"instances,omitempty"` // key: 实例 ID
+	Volumes   map[string]bool `json:"volumes,omitempty"`   // key: 卷文件路径
+}
+
+// NewDeletionProtection 创建删除保护存储
+func NewDeletionProtection(dataDir string) (*DeletionProtection, error) {
+	storageDir := filepath.Join(dataDir, "deletion-protection")
+	if err := os.MkdirAll(storageDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create deletion protection directory: %w", err)
+	}
+	return &DeletionProtection{storageDir: storageDir}, nil
+}
+
+// getStatePath 获取节点的删除保护文件路径
+func (p *DeletionProtection) getStatePath(nodeName string) string {
+	return filepath.Join(p.storageDir, nodeName+".json")
+}
+
+func (p *DeletionProtection) loadUnlocked(nodeName string) (*protectionState, error) {
+	state := &protectionState{}
+	data, err := os.ReadFile(p.getStatePath(nodeName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read deletion protection: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal deletion protection: %w", err)
+	}
+	return state, nil
+}
+
+func (p *DeletionProtection) saveUnlocked(nodeName string, state *protectionState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal deletion protection: %w", err)
+	}
+	if err := os.WriteFile(p.getStatePath(nodeName), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write deletion protection: %w", err)
+	}
+	return nil
+}
+
+// ProtectedInstances 返回节点上开启了删除保护的实例
+func (p *DeletionProtection) ProtectedInstances(nodeName string) (map[string]bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, err := p.loadUnlocked(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return state.Instances, nil
+}
+
+// ProtectedVolumes 返回节点上开启了删除保护的卷路径
+func (p *DeletionProtection) ProtectedVolumes(nodeName string) (map[string]bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, err := p.loadUnlocked(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return state.Volumes, nil
+}
+
+// SetInstance 开启或关闭实例的删除保护
+func (p *DeletionProtection) SetInstance(nodeName, instanceID string, protected bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, err := p.loadUnlocked(nodeName)
+	if err != nil {
+		return err
+	}
+	state.Instances = setProtected(state.Instances, instanceID, protected)
+	return p.saveUnlocked(nodeName, state)
+}
+
+// SetVolume 开启或关闭卷的删除保护
+func (p *DeletionProtection) SetVolume(nodeName, volumePath string, protected bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, err := p.loadUnlocked(nodeName)
+	if err != nil {
+		return err
+	}
+	state.Volumes = setProtected(state.Volumes, volumePath, protected)
+	return p.saveUnlocked(nodeName, state)
+}
+
+// setProtected 更新标志集合，关闭保护时删除对应的 key
+func setProtected(set map[string]bool, key string, protected bool) map[string]bool {
+	if !protected {
+		delete(set, key)
+		return set
+	}
+	if set == nil {
+		set = make(map[string]bool)
+	}
+	set[key] = true
+	return set
+}
+
+// newOperationNotPermittedError 删除受保护资源时返回的错误
+func newOperationNotPermittedError(kind, name string) error {
+	return apierror.NewErrorWithStatus(
+		"OperationNotPermitted",
+		fmt.Sprintf("%s %s has deletion protection enabled, disable it before deleting", kind, name),
+		http.StatusForbidden,
+	)
+}
+
+// checkTerminationProtection 校验实例未开启删除保护；deleteVolumes 时同时校验随实例删除的磁盘
+func (s *InstanceService) checkTerminationProtection(client libvirt.DomainManager, nodeName string, instanceIDs []string, deleteVolumes bool) error {
+	instances, err := s.protection.ProtectedInstances(nodeName)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to load deletion protection", err)
+	}
+	var volumes map[string]bool
+	if deleteVolumes {
+		if volumes, err = s.protection.ProtectedVolumes(nodeName); err != nil {
+			return apierror.WrapError(apierror.ErrInternalError, "Failed to load deletion protection", err)
+		}
+	}
+
+	for _, instanceID := range instanceIDs {
+		if instances[instanceID] {
+			return newOperationNotPermittedError("Instance", instanceID)
+		}
+		if len(volumes) == 0 {
+			continue
+		}
+		// 实例不存在等错误由后续删除流程返回
+		disks, err := client.GetDomainDisks(instanceID)
+		if err != nil {
+			continue
+		}
+		for _, disk := range disks {
+			if disk.Device == "disk" && volumes[disk.Source.File] {
+				return newOperationNotPermittedError("Volume", disk.Source.File)
+			}
+		}
+	}
+	return nil
+}
+
+// checkDeletionProtection 校验卷未开启删除保护，卷不存在时交由删除流程返回错误
+func (s *VolumeService) checkDeletionProtection(client libvirt.StorageManager, nodeName, poolName, volumeID string) error {
+	volInfo, err := findPoolVolume(client, poolName, volumeID)
+	if err != nil {
+		return nil
+	}
+	volumes, err := s.protection.ProtectedVolumes(nodeName)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to load deletion protection", err)
+	}
+	if volumes[volInfo.Path] {
+		return newOperationNotPermittedError("Volume", volumeID)
+	}
+	return nil
+}
@@ -2,3 +2,6 @@ package service
 
 // SnapshotsDirName 快照存放的目录名（位于存储池根目录下）
 const SnapshotsDirName = "_snapshots_"
+
+// RecycleDirName 回收站目录名（位于存储池根目录下），也用作回收站中 domain 名称的前缀
+const RecycleDirName = "_recycle_"
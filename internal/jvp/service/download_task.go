@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
 	"github.com/rs/zerolog"
 )
 
@@ -16,37 +17,45 @@ import (
 type DownloadTaskStatus string
 
 const (
-	DownloadTaskStatusPending   DownloadTaskStatus = "pending"
-	DownloadTaskStatusRunning   DownloadTaskStatus = "running"
-	DownloadTaskStatusCompleted DownloadTaskStatus = "completed"
-	DownloadTaskStatusFailed    DownloadTaskStatus = "failed"
+	DownloadTaskStatusPending     DownloadTaskStatus = "pending"
+	DownloadTaskStatusRunning     DownloadTaskStatus = "running"
+	DownloadTaskStatusCompleted   DownloadTaskStatus = "completed"
+	DownloadTaskStatusFailed      DownloadTaskStatus = "failed"
+	DownloadTaskStatusInterrupted DownloadTaskStatus = "interrupted" // 服务关停时未完成，已下载的部分文件会被删除
+	DownloadTaskStatusCancelled   DownloadTaskStatus = "cancelled"   // 被 CancelTask 取消，下载进程被终止，已下载的部分文件会被删除
 )
 
 // DownloadTask 下载任务
 type DownloadTask struct {
-	ID         string             `json:"id"`
-	NodeName   string             `json:"node_name"`
-	PoolName   string             `json:"pool_name"`
-	VolumeName string             `json:"volume_name"`
-	URL        string             `json:"url"`
-	Status     DownloadTaskStatus `json:"status"`
-	Error      string             `json:"error,omitempty"`
-	CreatedAt  time.Time          `json:"created_at"`
-	UpdatedAt  time.Time          `json:"updated_at"`
+	ID            string             `json:"id"`
+	NodeName      string             `json:"node_name"`
+	PoolName      string             `json:"pool_name"`
+	VolumeName    string             `json:"volume_name"`
+	URL           string             `json:"url"`
+	BandwidthMiB  uint64             `json:"bandwidth_mib,omitempty"` // 下载限速（MiB/s），任务启动时确定，0 表示不限速
+	Status        DownloadTaskStatus `json:"status"`
+	QueuePosition int                `json:"queue_position,omitempty"` // pending 时在节点操作队列中的位置（从 1 开始）
+	Error         string             `json:"error,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
 }
 
 // DownloadTaskManager 下载任务管理器
 type DownloadTaskManager struct {
 	mu            sync.RWMutex
-	tasks         map[string]*DownloadTask // key: taskID
-	tasksByVolume map[string]string        // key: nodeName:poolName:volumeName -> taskID
+	tasks         map[string]*DownloadTask      // key: taskID
+	tasksByVolume map[string]string             // key: nodeName:poolName:volumeName -> taskID
+	cancels       map[string]context.CancelFunc // key: taskID，未结束任务的取消函数
+	operations    *OperationLimiter             // 节点并发上限，下载在获得名额前保持 pending
 }
 
 // NewDownloadTaskManager 创建下载任务管理器
-func NewDownloadTaskManager() *DownloadTaskManager {
+func NewDownloadTaskManager(operations *OperationLimiter) *DownloadTaskManager {
 	return &DownloadTaskManager{
 		tasks:         make(map[string]*DownloadTask),
 		tasksByVolume: make(map[string]string),
+		cancels:       make(map[string]context.CancelFunc),
+		operations:    operations,
 	}
 }
 
@@ -86,14 +95,21 @@ func (m *DownloadTaskManager) GetTask(taskID string) *DownloadTask {
 		return nil
 	}
 
-	// 返回副本
+	return m.snapshot(task)
+}
+
+// snapshot 返回任务副本，排队中的任务附带队列位置
+func (m *DownloadTaskManager) snapshot(task *DownloadTask) *DownloadTask {
 	taskCopy := *task
+	if taskCopy.Status == DownloadTaskStatusPending {
+		taskCopy.QueuePosition = m.operations.QueuePosition(task.NodeName, task.ID)
+	}
 	return &taskCopy
 }
 
 // CreateTask 创建下载任务
 // 如果已有相同 volume 的任务在运行中，返回现有任务
-func (m *DownloadTaskManager) CreateTask(taskID, nodeName, poolName, volumeName, url string) (*DownloadTask, bool) {
+func (m *DownloadTaskManager) CreateTask(taskID, nodeName, poolName, volumeName, url string, bandwidthMiB uint64) (*DownloadTask, bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -115,14 +131,15 @@ func (m *DownloadTaskManager) CreateTask(taskID, nodeName, poolName, volumeName,
 	// 创建新任务
 	now := time.Now()
 	task := &DownloadTask{
-		ID:         taskID,
-		NodeName:   nodeName,
-		PoolName:   poolName,
-		VolumeName: volumeName,
-		URL:        url,
-		Status:     DownloadTaskStatusPending,
-		CreatedAt:  now,
-		UpdatedAt:  now,
+		ID:           taskID,
+		NodeName:     nodeName,
+		PoolName:     poolName,
+		VolumeName:   volumeName,
+		URL:          url,
+		BandwidthMiB: bandwidthMiB,
+		Status:       DownloadTaskStatusPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
 	}
 
 	m.tasks[taskID] = task
@@ -132,13 +149,13 @@ func (m *DownloadTaskManager) CreateTask(taskID, nodeName, poolName, volumeName,
 	return &taskCopy, true // true 表示新创建的
 }
 
-// UpdateTaskStatus 更新任务状态
+// UpdateTaskStatus 更新任务状态，已取消的任务不再更新
 func (m *DownloadTaskManager) UpdateTaskStatus(taskID string, status DownloadTaskStatus, errMsg string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	task, exists := m.tasks[taskID]
-	if !exists {
+	if !exists || task.Status == DownloadTaskStatusCancelled {
 		return
 	}
 
@@ -170,7 +187,8 @@ func (m *DownloadTaskManager) CleanupOldTasks(maxAge time.Duration) {
 	now := time.Now()
 	for taskID, task := range m.tasks {
 		// 只清理已完成或失败的任务
-		if task.Status == DownloadTaskStatusCompleted || task.Status == DownloadTaskStatusFailed {
+		if task.Status == DownloadTaskStatusCompleted || task.Status == DownloadTaskStatusFailed ||
+			task.Status == DownloadTaskStatusInterrupted || task.Status == DownloadTaskStatusCancelled {
 			if now.Sub(task.UpdatedAt) > maxAge {
 				key := volumeKey(task.NodeName, task.PoolName, task.VolumeName)
 				delete(m.tasksByVolume, key)
@@ -188,6 +206,24 @@ func (m *DownloadTaskManager) ListActiveTasks() []*DownloadTask {
 	var result []*DownloadTask
 	for _, task := range m.tasks {
 		if task.Status == DownloadTaskStatusPending || task.Status == DownloadTaskStatusRunning {
+			result = append(result, m.snapshot(task))
+		}
+	}
+	return result
+}
+
+// InterruptActiveTasks 把所有 pending 或 running 的任务标记为中断并返回这些任务，用于服务关停
+func (m *DownloadTaskManager) InterruptActiveTasks(reason string) []*DownloadTask {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result []*DownloadTask
+	now := time.Now()
+	for _, task := range m.tasks {
+		if task.Status == DownloadTaskStatusPending || task.Status == DownloadTaskStatusRunning {
+			task.Status = DownloadTaskStatusInterrupted
+			task.Error = reason
+			task.UpdatedAt = now
 			taskCopy := *task
 			result = append(result, &taskCopy)
 		}
@@ -195,18 +231,75 @@ func (m *DownloadTaskManager) ListActiveTasks() []*DownloadTask {
 	return result
 }
 
+// Cancel 取消 pending 或 running 的任务：标记为 cancelled 并取消任务的 ctx，排队中的任务退出队列，
+// 下载进程由 ctx 终止。返回取消后的任务，任务不存在时返回 nil，已结束的任务保持原状态
+func (m *DownloadTaskManager) Cancel(taskID string) *DownloadTask {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, exists := m.tasks[taskID]
+	if !exists {
+		return nil
+	}
+	if task.Status == DownloadTaskStatusPending || task.Status == DownloadTaskStatusRunning {
+		task.Status = DownloadTaskStatusCancelled
+		task.Error = "cancelled by user"
+		task.UpdatedAt = time.Now()
+		if cancel, ok := m.cancels[taskID]; ok {
+			cancel()
+			delete(m.cancels, taskID)
+		}
+	}
+	taskCopy := *task
+	return &taskCopy
+}
+
+// setCancel 登记任务的取消函数，cancel 为 nil 时移除
+func (m *DownloadTaskManager) setCancel(taskID string, cancel context.CancelFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cancel == nil {
+		delete(m.cancels, taskID)
+		return
+	}
+	m.cancels[taskID] = cancel
+}
+
 // StartDownload 启动异步下载
 // 下载文件到存储池根目录（作为存储卷）
 // 模板元数据会在下载完成后单独保存到 _templates_ 目录
 func (m *DownloadTaskManager) StartDownload(
 	ctx context.Context,
 	task *DownloadTask,
-	client libvirt.LibvirtClient,
+	client poolFileClient,
 	onComplete func(task *DownloadTask, err error),
 ) {
+	// 请求结束后 ctx 会被取消，下载不能随之退出，因此使用不随请求取消的 ctx，只有 CancelTask 会取消它
+	taskCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	m.setCancel(task.ID, cancel)
+
 	go func() {
+		defer func() {
+			m.setCancel(task.ID, nil)
+			cancel()
+		}()
 		logger := zerolog.Ctx(ctx)
 
+		// 等待节点并发名额，排队期间任务保持 pending；服务关停或任务被取消时 Acquire 返回错误
+		release, err := m.operations.Acquire(taskCtx, task.NodeName, task.ID, OperationDownloadTemplate, task.VolumeName)
+		if err != nil {
+			logger.Warn().
+				Err(err).
+				Str("task_id", task.ID).
+				Msg("Download task interrupted before start")
+			m.UpdateTaskStatus(task.ID, DownloadTaskStatusInterrupted, err.Error())
+			if onComplete != nil {
+				onComplete(m.GetTask(task.ID), err)
+			}
+			return
+		}
+		defer release()
+
 		// 更新状态为运行中
 		m.UpdateTaskStatus(task.ID, DownloadTaskStatusRunning, "")
 
@@ -214,12 +307,17 @@ func (m *DownloadTaskManager) StartDownload(
 			Str("task_id", task.ID).
 			Str("url", task.URL).
 			Str("volume_name", task.VolumeName).
+			Uint64("bandwidth_mib", task.BandwidthMiB).
 			Msg("Starting download task")
 
 		// 执行下载到存储池的 _templates_ 目录
-		err := downloadToTemplatesDir(client, task.PoolName, task.VolumeName, task.URL)
+		err = downloadToTemplatesDir(taskCtx, client, task.PoolName, task.VolumeName, task.URL, task.BandwidthMiB)
 
-		if err != nil {
+		if err != nil && taskCtx.Err() != nil {
+			logger.Warn().
+				Str("task_id", task.ID).
+				Msg("Download task cancelled")
+		} else if err != nil {
 			logger.Error().
 				Err(err).
 				Str("task_id", task.ID).
@@ -240,19 +338,26 @@ func (m *DownloadTaskManager) StartDownload(
 	}()
 }
 
+// poolFileClient 操作存储池目录内文件所需的 libvirt 能力（远程节点上通过 SSH 执行）
+type poolFileClient interface {
+	libvirt.StorageManager
+	libvirt.RemoteManager
+}
+
 // downloadToPool 下载文件到存储池（普通卷，存储池根目录）
 // 通过 libvirt 的基础接口实现下载功能
-func downloadToPool(client libvirt.LibvirtClient, poolName, volumeName, downloadURL string) error {
-	return downloadToDir(client, poolName, "", volumeName, downloadURL)
+func downloadToPool(ctx context.Context, client poolFileClient, poolName, volumeName, downloadURL string, bandwidthMiB uint64) error {
+	return downloadToDir(ctx, client, poolName, "", volumeName, downloadURL, bandwidthMiB)
 }
 
 // downloadToTemplatesDir 下载模板文件到存储池的 _templates_ 目录
-func downloadToTemplatesDir(client libvirt.LibvirtClient, poolName, fileName, downloadURL string) error {
-	return downloadToDir(client, poolName, TemplatesDirName, fileName, downloadURL)
+func downloadToTemplatesDir(ctx context.Context, client poolFileClient, poolName, fileName, downloadURL string, bandwidthMiB uint64) error {
+	return downloadToDir(ctx, client, poolName, TemplatesDirName, fileName, downloadURL, bandwidthMiB)
 }
 
-// downloadToDir 下载文件到存储池的指定子目录
-func downloadToDir(client libvirt.LibvirtClient, poolName, subDir, fileName, downloadURL string) error {
+// downloadToDir 下载文件到存储池的指定子目录，bandwidthMiB 为 0 时不限速
+// ctx 取消时终止下载进程并删除已下载的部分文件
+func downloadToDir(ctx context.Context, client poolFileClient, poolName, subDir, fileName, downloadURL string, bandwidthMiB uint64) error {
 	// 获取存储池信息
 	poolInfo, err := client.GetStoragePool(poolName)
 	if err != nil {
@@ -275,19 +380,27 @@ func downloadToDir(client libvirt.LibvirtClient, poolName, subDir, fileName, dow
 
 	// 根据是否是远程连接选择下载方式
 	if client.IsRemoteConnection() {
-		// 远程连接：先创建目录，然后通过 SSH 执行下载命令
+		// 远程连接：先创建目录，然后通过 SSH 执行下载命令；路径与 URL 来自用户输入，由远程 shell 解释前逐个转义
 		if subDir != "" {
-			mkdirCmd := fmt.Sprintf("mkdir -p '%s'", targetDir)
+			mkdirCmd := "mkdir -p " + shellx.Quote(targetDir)
 			if err := client.ExecuteRemoteCommand(mkdirCmd); err != nil {
 				return fmt.Errorf("create directory via SSH: %w", err)
 			}
 		}
 
+		wgetRate, curlRate := downloadRateArgs(bandwidthMiB)
 		downloadCmd := fmt.Sprintf(
-			`command -v wget >/dev/null 2>&1 && wget -q -O '%s' '%s' || curl -sSL -o '%s' '%s'`,
-			targetPath, downloadURL, targetPath, downloadURL,
+			`command -v wget >/dev/null 2>&1 && wget -q %s -O %s %s || curl -sSL %s -o %s %s`,
+			shellx.Join(wgetRate...), shellx.Quote(targetPath), shellx.Quote(downloadURL),
+			shellx.Join(curlRate...), shellx.Quote(targetPath), shellx.Quote(downloadURL),
 		)
-		if err := client.ExecuteRemoteCommand(downloadCmd); err != nil {
+		if _, err := runNodeCommand(ctx, client, downloadCmd); err != nil {
+			if ctx.Err() != nil {
+				// 终止本地 ssh 不会结束远端的下载进程，按目标路径找到并终止它
+				killRemoteNodeProcesses(context.WithoutCancel(ctx), client, targetPath)
+				_, _ = runNodeCommand(context.WithoutCancel(ctx), client, "rm -f "+shellx.Quote(targetPath))
+				return fmt.Errorf("download cancelled: %w", ctx.Err())
+			}
 			return fmt.Errorf("download via SSH: %w", err)
 		}
 	} else {
@@ -298,7 +411,11 @@ func downloadToDir(client libvirt.LibvirtClient, poolName, subDir, fileName, dow
 			}
 		}
 
-		if err := downloadLocal(targetPath, downloadURL); err != nil {
+		if err := downloadLocal(ctx, targetPath, downloadURL, bandwidthMiB); err != nil {
+			if ctx.Err() != nil {
+				_ = os.Remove(targetPath)
+				return fmt.Errorf("download cancelled: %w", ctx.Err())
+			}
 			return fmt.Errorf("download locally: %w", err)
 		}
 	}
@@ -311,12 +428,15 @@ func downloadToDir(client libvirt.LibvirtClient, poolName, subDir, fileName, dow
 	return nil
 }
 
-// downloadLocal 在本地下载文件
-func downloadLocal(targetPath, downloadURL string) error {
+// downloadLocal 在本地下载文件，ctx 取消时终止 wget/curl 进程
+func downloadLocal(ctx context.Context, targetPath, downloadURL string, bandwidthMiB uint64) error {
+	wgetRate, curlRate := downloadRateArgs(bandwidthMiB)
+
 	// 优先使用 wget，如果不存在则使用 curl
 	wgetPath, err := exec.LookPath("wget")
 	if err == nil {
-		cmd := exec.Command(wgetPath, "-q", "-O", targetPath, downloadURL)
+		args := append([]string{"-q"}, wgetRate...)
+		cmd := exec.CommandContext(ctx, wgetPath, append(args, "-O", targetPath, downloadURL)...)
 		output, err := cmd.CombinedOutput()
 		if err != nil {
 			return fmt.Errorf("wget failed: %w, output: %s", err, string(output))
@@ -326,7 +446,8 @@ func downloadLocal(targetPath, downloadURL string) error {
 
 	curlPath, err := exec.LookPath("curl")
 	if err == nil {
-		cmd := exec.Command(curlPath, "-sSL", "-o", targetPath, downloadURL)
+		args := append([]string{"-sSL"}, curlRate...)
+		cmd := exec.CommandContext(ctx, curlPath, append(args, "-o", targetPath, downloadURL)...)
 		output, err := cmd.CombinedOutput()
 		if err != nil {
 			return fmt.Errorf("curl failed: %w, output: %s", err, string(output))
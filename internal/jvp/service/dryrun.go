@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+)
+
+type dryRunKey struct{}
+
+// WithDryRun 在 ctx 中标记本次写操作为 DryRun
+// 写接口在完成参数校验、存在性检查与容量预检后，若处于 DryRun 则返回 ErrDryRunOperation 而不执行变更
+func WithDryRun(ctx context.Context, dryRun bool) context.Context {
+	if !dryRun {
+		return ctx
+	}
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// isDryRun 判断 ctx 是否标记了 DryRun
+func isDryRun(ctx context.Context) bool {
+	v, _ := ctx.Value(dryRunKey{}).(bool)
+	return v
+}
+
+// dryRunOperation 记录 DryRun 校验通过，返回 DryRunOperation
+func dryRunOperation(ctx context.Context, action string) error {
+	zerolog.Ctx(ctx).Info().
+		Str("action", action).
+		Msg("Dry run checks passed, no changes made")
+	return apierror.ErrDryRunOperation
+}
+
+// checkPoolCapacity 预检存储池剩余空间是否能容纳 sizeGB 的新卷
+func checkPoolCapacity(client libvirt.StorageManager, poolName string, sizeGB uint64) error {
+	pool, err := client.GetStoragePool(poolName)
+	if err != nil {
+		return apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Storage pool %s not found", poolName),
+			http.StatusNotFound,
+		)
+	}
+	if required := sizeGB * 1024 * 1024 * 1024; required > pool.AvailableB {
+		return apierror.WrapError(
+			apierror.ErrInsufficientVolumeCapacity,
+			fmt.Sprintf("Storage pool %s has %d bytes available, %d bytes required", poolName, pool.AvailableB, required),
+			nil,
+		)
+	}
+	return nil
+}
+
+// checkHostCapacity 预检节点可分配的内存与 CPU（扣除宿主机预留）是否满足实例规格
+func checkHostCapacity(client libvirt.HostManager, reserved entity.NodeResources, memoryMB uint64, vcpus uint16) error {
+	info, err := client.GetNodeInfo()
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to get node info", err)
+	}
+	allocatable := allocatableResources(info, reserved)
+	if memoryMB > allocatable.MemoryMB {
+		return apierror.WrapError(
+			apierror.ErrInsufficientInstanceCapacity,
+			fmt.Sprintf("Node has %d MB allocatable memory (%d MB reserved), %d MB requested", allocatable.MemoryMB, reserved.MemoryMB, memoryMB),
+			nil,
+		)
+	}
+	if uint32(vcpus) > allocatable.CPUs {
+		return apierror.WrapError(
+			apierror.ErrInsufficientInstanceCapacity,
+			fmt.Sprintf("Node has %d allocatable CPUs (%d reserved), %d vCPUs requested", allocatable.CPUs, reserved.CPUs, vcpus),
+			nil,
+		)
+	}
+	return nil
+}
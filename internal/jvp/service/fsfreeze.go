@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// defaultFSFreezeTimeout 未指定超时时冻结的最长时间
+	defaultFSFreezeTimeout = 60 * time.Second
+	// maxFSFreezeTimeout 冻结允许的最长时间，冻结期间 guest 内的写操作全部阻塞
+	maxFSFreezeTimeout = 10 * time.Minute
+)
+
+// FSFreezeManager 跟踪通过 guest-agent 冻结了文件系统的实例
+// 每次冻结都会登记一个自动解冻定时器，调用方异常退出或忘记解冻时由定时器兜底，避免 guest 长时间无法写盘
+type FSFreezeManager struct {
+	mu      sync.Mutex
+	freezes map[string]*fsFreeze // key: nodeName:instanceID
+}
+
+// fsFreeze 一次冻结的自动解冻信息
+type fsFreeze struct {
+	timer    *time.Timer
+	deadline time.Time
+}
+
+// NewFSFreezeManager 创建文件系统冻结管理器
+func NewFSFreezeManager() *FSFreezeManager {
+	return &FSFreezeManager{
+		freezes: make(map[string]*fsFreeze),
+	}
+}
+
+// fsFreezeKey 生成实例唯一标识
+func fsFreezeKey(nodeName, instanceID string) string {
+	return fmt.Sprintf("%s:%s", nodeName, instanceID)
+}
+
+// freeze 冻结实例文件系统并登记超时自动解冻
+// 同一实例已处于冻结状态时返回 IncorrectInstanceState，需先解冻
+func (m *FSFreezeManager) freeze(
+	ctx context.Context,
+	client libvirt.GuestAgentManager,
+	nodeName, instanceID string,
+	mountpoints []string,
+	timeout time.Duration,
+) (int, time.Time, error) {
+	key := fsFreezeKey(nodeName, instanceID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.freezes[key]; ok {
+		return 0, time.Time{}, apierror.NewErrorWithStatus(
+			"IncorrectInstanceState",
+			fmt.Sprintf("File systems of instance %s are already frozen until %s", instanceID, existing.deadline.Format(time.RFC3339)),
+			http.StatusConflict,
+		)
+	}
+
+	count, err := client.FreezeDomainFS(instanceID, mountpoints)
+	if err != nil {
+		return 0, time.Time{}, apierror.WrapError(apierror.ErrInternalError, "Failed to freeze file systems", err)
+	}
+
+	entry := &fsFreeze{deadline: time.Now().Add(timeout).UTC()}
+	logger := zerolog.Ctx(ctx).With().
+		Str("node_name", nodeName).
+		Str("instanceID", instanceID).
+		Logger()
+	entry.timer = time.AfterFunc(timeout, func() {
+		m.mu.Lock()
+		if m.freezes[key] != entry {
+			// 已被手动解冻
+			m.mu.Unlock()
+			return
+		}
+		delete(m.freezes, key)
+		m.mu.Unlock()
+
+		thawed, err := client.ThawDomainFS(instanceID, nil)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to auto thaw file systems after timeout")
+			return
+		}
+		logger.Warn().
+			Int("filesystems", thawed).
+			Dur("timeout", timeout).
+			Msg("File systems auto thawed after timeout")
+	})
+	m.freezes[key] = entry
+
+	return count, entry.deadline, nil
+}
+
+// thaw 解冻实例文件系统，mountpoints 为空时解冻全部并取消自动解冻
+// 只解冻部分挂载点时保留定时器，剩余挂载点仍受超时保护
+func (m *FSFreezeManager) thaw(client libvirt.GuestAgentManager, nodeName, instanceID string, mountpoints []string) (int, error) {
+	key := fsFreezeKey(nodeName, instanceID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count, err := client.ThawDomainFS(instanceID, mountpoints)
+	if err != nil {
+		return 0, apierror.WrapError(apierror.ErrInternalError, "Failed to thaw file systems", err)
+	}
+
+	if entry, ok := m.freezes[key]; ok && len(mountpoints) == 0 {
+		entry.timer.Stop()
+		delete(m.freezes, key)
+	}
+	return count, nil
+}
+
+// fsFreezeTimeout 校验并换算冻结超时，0 使用默认值
+func fsFreezeTimeout(seconds int) (time.Duration, error) {
+	if seconds < 0 || time.Duration(seconds)*time.Second > maxFSFreezeTimeout {
+		return 0, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("timeout_seconds must be between 0 and %d", int(maxFSFreezeTimeout/time.Second)),
+			http.StatusBadRequest,
+		)
+	}
+	if seconds == 0 {
+		return defaultFSFreezeTimeout, nil
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// FreezeInstanceFS 通过 guest-agent 冻结实例文件系统，超时未调用 ThawInstanceFS 时自动解冻
+func (s *InstanceService) FreezeInstanceFS(ctx context.Context, req *entity.FreezeInstanceFSRequest) (*entity.FreezeInstanceFSResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Strs("mountpoints", req.Mountpoints).
+		Int("timeout_seconds", req.TimeoutSeconds).
+		Msg("Freezing instance file systems")
+
+	timeout, err := fsFreezeTimeout(req.TimeoutSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	domain, err := client.GetDomainByName(req.InstanceID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+	if err := ensureGuestAgentReady(client, domain); err != nil {
+		return nil, err
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "FreezeInstanceFS")
+	}
+
+	count, deadline, err := s.fsFreezes.freeze(ctx, client, req.NodeName, req.InstanceID, req.Mountpoints, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Int("filesystems", count).
+		Time("thaw_deadline", deadline).
+		Msg("Instance file systems frozen")
+
+	return &entity.FreezeInstanceFSResponse{
+		InstanceID:   req.InstanceID,
+		Filesystems:  count,
+		ThawDeadline: deadline.Format(time.RFC3339),
+	}, nil
+}
+
+// ThawInstanceFS 通过 guest-agent 解冻实例文件系统
+func (s *InstanceService) ThawInstanceFS(ctx context.Context, req *entity.ThawInstanceFSRequest) (*entity.ThawInstanceFSResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Strs("mountpoints", req.Mountpoints).
+		Msg("Thawing instance file systems")
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	domain, err := client.GetDomainByName(req.InstanceID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+	if err := ensureGuestAgentReady(client, domain); err != nil {
+		return nil, err
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ThawInstanceFS")
+	}
+
+	count, err := s.fsFreezes.thaw(client, req.NodeName, req.InstanceID, req.Mountpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Int("filesystems", count).
+		Msg("Instance file systems thawed")
+
+	return &entity.ThawInstanceFSResponse{
+		InstanceID:  req.InstanceID,
+		Filesystems: count,
+	}, nil
+}
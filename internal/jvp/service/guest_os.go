@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+)
+
+// guestOSProbeInterval 同一实例两次探测 guest OS 的最小间隔，避免 guest-agent 未就绪时每次查询都重复探测
+const guestOSProbeInterval = time.Minute
+
+// guestOSIDWindows guest-get-osinfo 对 Windows 返回的 id
+const guestOSIDWindows = "mswindows"
+
+// GuestOSStore 实例 guest 操作系统信息缓存
+// 每个节点一个 JSON 文件：<dataDir>/guest-os/<node>.json，key 为实例 ID；实例关机后仍保留最近一次上报的信息
+type GuestOSStore struct {
+	storageDir string
+	mu         sync.Mutex
+	probes     map[string]time.Time // 最近一次开始探测的时间，key: <node>/<instance>，只保存在内存中
+}
+
+// NewGuestOSStore 创建 guest OS 信息缓存
+func NewGuestOSStore(dataDir string) (*GuestOSStore, error) {
+	storageDir := filepath.Join(dataDir, "guest-os")
+	if err := os.MkdirAll(storageDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create guest os directory: %w", err)
+	}
+	return &GuestOSStore{
+		storageDir: storageDir,
+		probes:     make(map[string]time.Time),
+	}, nil
+}
+
+// getStatePath 获取节点的 guest OS 信息文件路径
+func (g *GuestOSStore) getStatePath(nodeName string) string {
+	return filepath.Join(g.storageDir, nodeName+".json")
+}
+
+func (g *GuestOSStore) loadUnlocked(nodeName string) (map[string]*entity.GuestOSInfo, error) {
+	state := make(map[string]*entity.GuestOSInfo)
+	data, err := os.ReadFile(g.getStatePath(nodeName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read guest os info: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal guest os info: %w", err)
+	}
+	return state, nil
+}
+
+// Instances 返回节点上已缓存的实例 guest OS 信息
+func (g *GuestOSStore) Instances(nodeName string) (map[string]*entity.GuestOSInfo, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.loadUnlocked(nodeName)
+}
+
+// Set 更新实例的 guest OS 信息，info 为 nil 时删除记录
+func (g *GuestOSStore) Set(nodeName, instanceID string, info *entity.GuestOSInfo) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	state, err := g.loadUnlocked(nodeName)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		if _, ok := state[instanceID]; !ok {
+			return nil
+		}
+		delete(state, instanceID)
+		delete(g.probes, nodeName+"/"+instanceID)
+	} else {
+		state[instanceID] = info
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal guest os info: %w", err)
+	}
+	if err := os.WriteFile(g.getStatePath(nodeName), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write guest os info: %w", err)
+	}
+	return nil
+}
+
+// beginProbe 判断是否可以开始探测实例的 guest OS，距上次探测不足 guestOSProbeInterval 时返回 false
+func (g *GuestOSStore) beginProbe(nodeName, instanceID string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := nodeName + "/" + instanceID
+	if last, ok := g.probes[key]; ok && time.Since(last) < guestOSProbeInterval {
+		return false
+	}
+	g.probes[key] = time.Now()
+	return true
+}
+
+// guestPlatform 根据 guest OS 信息返回平台：Windows 的 id 为 mswindows，其余均为 linux
+func guestPlatform(info *entity.GuestOSInfo) string {
+	if info == nil {
+		return ""
+	}
+	if info.ID == guestOSIDWindows {
+		return entity.PlatformWindows
+	}
+	return entity.PlatformLinux
+}
+
+// fillGuestOS 用缓存填充实例的 guest OS 信息与平台
+// 运行中的实例没有缓存或缓存早于本次启动（可能已重装或升级）时在后台通过 guest-agent 刷新，本次查询仍返回旧值
+func (s *InstanceService) fillGuestOS(ctx context.Context, client libvirt.LibvirtClient, nodeName string, instance *entity.Instance, cached *entity.GuestOSInfo, startTime *time.Time) {
+	instance.GuestOS = cached
+	instance.Platform = guestPlatform(cached)
+
+	if instance.State != "running" {
+		return
+	}
+	if cached != nil && (startTime == nil || !cached.UpdatedAt.Before(*startTime)) {
+		return
+	}
+	if !s.guestOS.beginProbe(nodeName, instance.ID) {
+		return
+	}
+
+	ctxCopy := context.WithoutCancel(ctx)
+	instanceID := instance.ID
+	s.asyncRun(func() {
+		s.refreshGuestOS(ctxCopy, client, nodeName, instanceID)
+	})
+}
+
+// refreshGuestOS 通过 guest-agent 获取 guest OS 信息并写入缓存，guest-agent 不可用时保留原有缓存
+func (s *InstanceService) refreshGuestOS(ctx context.Context, client libvirt.LibvirtClient, nodeName, instanceID string) {
+	logger := zerolog.Ctx(ctx)
+
+	osInfo, err := client.GetGuestOSInfo(instanceID)
+	if err != nil {
+		logger.Debug().
+			Err(err).
+			Str("instanceID", instanceID).
+			Msg("Guest OS info not available")
+		return
+	}
+
+	info := &entity.GuestOSInfo{
+		ID:            osInfo.ID,
+		Name:          osInfo.Name,
+		PrettyName:    osInfo.PrettyName,
+		VersionID:     osInfo.VersionID,
+		KernelRelease: osInfo.KernelRelease,
+		Machine:       osInfo.Machine,
+		UpdatedAt:     time.Now(),
+	}
+	if err := s.guestOS.Set(nodeName, instanceID, info); err != nil {
+		logger.Warn().
+			Err(err).
+			Str("instanceID", instanceID).
+			Msg("Failed to save guest OS info")
+		return
+	}
+	logger.Info().
+		Str("instanceID", instanceID).
+		Str("os", info.PrettyName).
+		Msg("Guest OS info updated")
+}
+
+// clearGuestOS 实例物理删除后清理 guest OS 缓存
+func (s *InstanceService) clearGuestOS(ctx context.Context, nodeName, instanceID string) {
+	if err := s.guestOS.Set(nodeName, instanceID, nil); err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("instanceID", instanceID).
+			Msg("Failed to clear guest OS info")
+	}
+}
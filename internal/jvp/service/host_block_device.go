@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/rs/zerolog"
+)
+
+// lsblkColumns 列举块设备时 lsblk 输出的列，-p 输出完整设备路径
+const lsblkColumns = "NAME,KNAME,TYPE,SIZE,MODEL,SERIAL,FSTYPE,MOUNTPOINT"
+
+// hostBlockDeviceHolderFSTypes 表示设备已被 LVM、ZFS、RAID、加密卷或 swap 占用的签名类型
+var hostBlockDeviceHolderFSTypes = map[string]bool{
+	"LVM2_member":       true,
+	"zfs_member":        true,
+	"linux_raid_member": true,
+	"crypto_LUKS":       true,
+	"swap":              true,
+	"bcache":            true,
+	"ceph_bluestore":    true,
+}
+
+// lsblkSize lsblk 的 SIZE 列，新版本输出数字，旧版本输出字符串
+type lsblkSize uint64
+
+func (s *lsblkSize) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		*s = 0
+		return nil
+	}
+	n, err := strconv.ParseUint(text, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid lsblk size %s: %w", data, err)
+	}
+	*s = lsblkSize(n)
+	return nil
+}
+
+// lsblkDevice lsblk -J 输出的设备
+type lsblkDevice struct {
+	Name       string        `json:"name"`
+	KName      string        `json:"kname"`
+	Type       string        `json:"type"`
+	Size       lsblkSize     `json:"size"`
+	Model      *string       `json:"model"`
+	Serial     *string       `json:"serial"`
+	FSType     *string       `json:"fstype"`
+	MountPoint *string       `json:"mountpoint"`
+	Children   []lsblkDevice `json:"children"`
+}
+
+// lsblkOutput lsblk -J 的输出
+type lsblkOutput struct {
+	BlockDevices []lsblkDevice `json:"blockdevices"`
+}
+
+// resolveHostDevicePaths 解析设备路径的符号链接（/dev/vg/lv、/dev/zvol/... 等），返回原路径到真实路径的映射
+func resolveHostDevicePaths(ctx context.Context, client libvirt.RemoteManager, paths []string) map[string]string {
+	resolved := make(map[string]string, len(paths))
+	if len(paths) == 0 {
+		return resolved
+	}
+	// 逐个输出，设备不存在时输出空行，保证与输入一一对应
+	command := fmt.Sprintf("for p in %s; do readlink -f \"$p\" 2>/dev/null || echo; done", shellx.Join(paths...))
+	output, err := runNodeCommand(ctx, client, command)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to resolve host device paths")
+		return resolved
+	}
+	lines := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	for i, p := range paths {
+		if i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+			resolved[p] = strings.TrimSpace(lines[i])
+		}
+	}
+	return resolved
+}
+
+// listHostBlockDevices 列举宿主机块设备并标记占用情况
+// 已挂载、带有 LVM/ZFS/RAID 等签名、存在分区或子设备、已被实例使用的设备视为占用
+func listHostBlockDevices(ctx context.Context, client libvirt.LibvirtClient) ([]entity.HostBlockDevice, error) {
+	output, err := runNodeCommand(ctx, client, "lsblk -J -b -p -o "+lsblkColumns)
+	if err != nil {
+		return nil, fmt.Errorf("list block devices: %w", err)
+	}
+	var parsed lsblkOutput
+	if err := json.Unmarshal(output, &parsed); err != nil {
+		return nil, fmt.Errorf("parse block devices: %w", err)
+	}
+
+	// 已被实例使用的块设备（type='block' 的磁盘）
+	type attachment struct {
+		instance, device string
+	}
+	attached := map[string]attachment{}
+	domains, err := client.GetVMSummaries()
+	if err != nil {
+		return nil, fmt.Errorf("list domains: %w", err)
+	}
+	var sources []string
+	for _, domain := range domains {
+		disks, err := client.GetDomainDisks(domain.Name)
+		if err != nil {
+			continue
+		}
+		for _, disk := range disks {
+			if disk.Type != "block" || disk.Source.Dev == "" {
+				continue
+			}
+			attached[disk.Source.Dev] = attachment{instance: domain.Name, device: disk.Target.Dev}
+			sources = append(sources, disk.Source.Dev)
+		}
+	}
+	for source, canonical := range resolveHostDevicePaths(ctx, client, sources) {
+		if _, ok := attached[canonical]; !ok {
+			attached[canonical] = attached[source]
+		}
+	}
+
+	devices := make([]entity.HostBlockDevice, 0)
+	seen := map[string]bool{}
+	var walk func(dev lsblkDevice, parent string)
+	walk = func(dev lsblkDevice, parent string) {
+		// 光驱不能作为磁盘直通；跨多个 PV 的 LV 会在每个 PV 下各出现一次，只保留第一次
+		if dev.Type == "rom" || seen[dev.Name] {
+			return
+		}
+		seen[dev.Name] = true
+		item := entity.HostBlockDevice{
+			Path:       dev.Name,
+			KernelName: dev.KName,
+			Type:       dev.Type,
+			Size:       uint64(dev.Size),
+			Model:      strings.TrimSpace(stringValue(dev.Model)),
+			Serial:     strings.TrimSpace(stringValue(dev.Serial)),
+			FSType:     stringValue(dev.FSType),
+			MountPoint: stringValue(dev.MountPoint),
+			Parent:     parent,
+		}
+		switch {
+		case item.MountPoint != "":
+			item.InUse, item.InUseReason = true, "mounted at "+item.MountPoint
+		case hostBlockDeviceHolderFSTypes[item.FSType]:
+			item.InUse, item.InUseReason = true, "in use as "+item.FSType
+		case len(dev.Children) > 0:
+			item.InUse, item.InUseReason = true, "has partitions or holder devices"
+		}
+		a, ok := attached[item.Path]
+		if !ok {
+			a, ok = attached[item.KernelName]
+		}
+		if ok {
+			item.InUse, item.InUseReason = true, "attached to instance "+a.instance
+			item.AttachedInstance, item.AttachedDevice = a.instance, a.device
+		}
+		devices = append(devices, item)
+
+		for _, child := range dev.Children {
+			walk(child, dev.Name)
+		}
+	}
+	for _, dev := range parsed.BlockDevices {
+		walk(dev, "")
+	}
+	return devices, nil
+}
+
+// stringValue 返回字符串指针的值，nil 时返回空字符串
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// findHostBlockDevice 按路径查找宿主机块设备，路径为符号链接时按真实路径匹配
+func findHostBlockDevice(ctx context.Context, client libvirt.LibvirtClient, devicePath string) (*entity.HostBlockDevice, error) {
+	devices, err := listHostBlockDevices(ctx, client)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list host block devices", err)
+	}
+	canonical := resolveHostDevicePaths(ctx, client, []string{devicePath})[devicePath]
+	for i := range devices {
+		d := &devices[i]
+		if d.Path == devicePath || (canonical != "" && (d.Path == canonical || d.KernelName == canonical)) {
+			return d, nil
+		}
+	}
+	return nil, apierror.NewErrorWithStatus(
+		"ResourceNotFound",
+		fmt.Sprintf("Block device %s not found on node", devicePath),
+		http.StatusNotFound,
+	)
+}
+
+// ListNodeBlockDevices 列举节点上可直通给实例的块设备（整盘、分区、LVM LV、zvol）
+// 通过 lsblk 获取，远程节点通过 SSH 执行；已挂载、被 LVM/ZFS 等占用或已直通给实例的设备标记为 in_use
+func (s *NodeService) ListNodeBlockDevices(ctx context.Context, nodeName string) ([]entity.HostBlockDevice, error) {
+	client, err := s.GetNodeStorage(ctx, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node connection: %w", err)
+	}
+	devices, err := listHostBlockDevices(ctx, client)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list host block devices", err)
+	}
+	return devices, nil
+}
+
+// AttachHostBlockDevice 把宿主机块设备以 disk type='block' 直通给实例，适合数据库等对 IO 延迟敏感的场景
+// 设备必须未被宿主机挂载、未被 LVM/ZFS 等占用且未直通给其他实例
+func (s *InstanceService) AttachHostBlockDevice(ctx context.Context, req *entity.AttachHostBlockDeviceRequest) (*entity.AttachHostBlockDeviceResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Str("device_path", req.DevicePath).
+		Str("device", req.Device).
+		Str("bus", req.Bus).
+		Msg("Attaching host block device")
+
+	if !strings.HasPrefix(req.DevicePath, "/dev/") {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("device_path %q must be a block device under /dev", req.DevicePath),
+			http.StatusBadRequest,
+		)
+	}
+	bus := req.Bus
+	if bus == "" {
+		bus = "virtio"
+	}
+	if bus != "virtio" && bus != "scsi" && bus != "nvme" {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported bus %q, must be virtio, scsi or nvme", bus),
+			http.StatusBadRequest,
+		)
+	}
+	if len(req.Serial) > 20 {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"serial must be at most 20 bytes",
+			http.StatusBadRequest,
+		)
+	}
+	driver := toLibvirtDiskDriver(req.DiskDriver)
+	if driver != nil {
+		if err := driver.Validate(); err != nil {
+			return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+		}
+	}
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+	if _, err := client.GetDomainByName(req.InstanceID); err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	device, err := findHostBlockDevice(ctx, client, req.DevicePath)
+	if err != nil {
+		return nil, err
+	}
+	if device.InUse {
+		return nil, apierror.NewErrorWithStatus(
+			"BlockDeviceInUse",
+			fmt.Sprintf("Block device %s is in use: %s", req.DevicePath, device.InUseReason),
+			http.StatusConflict,
+		)
+	}
+
+	unlock, err := s.lockInstanceVersion(ctx, req.NodeName, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "AttachHostBlockDevice")
+	}
+
+	target, err := client.AttachDiskToDomain(req.InstanceID, &libvirt.AttachDiskConfig{
+		VolumePath: req.DevicePath,
+		Block:      true,
+		Device:     req.Device,
+		Bus:        bus,
+		Serial:     req.Serial,
+		Driver:     driver,
+	})
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to attach host block device", err)
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device_path", req.DevicePath).
+		Str("device", target).
+		Msg("Host block device attached")
+
+	s.recordEvent(ctx, req.NodeName, req.InstanceID, entity.InstanceEventModified, "",
+		fmt.Sprintf("host block device %s attached as %s", req.DevicePath, target))
+
+	return &entity.AttachHostBlockDeviceResponse{
+		InstanceID: req.InstanceID,
+		DevicePath: req.DevicePath,
+		Device:     target,
+		Bus:        bus,
+		Serial:     req.Serial,
+	}, nil
+}
+
+// DetachHostBlockDevice 从实例分离直通的宿主机块设备
+func (s *InstanceService) DetachHostBlockDevice(ctx context.Context, req *entity.DetachHostBlockDeviceRequest) (*entity.DetachHostBlockDeviceResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Str("device_path", req.DevicePath).
+		Msg("Detaching host block device")
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+	disks, err := client.GetDomainDisks(req.InstanceID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	target := ""
+	for _, disk := range disks {
+		if disk.Type == "block" && disk.Source.Dev == req.DevicePath {
+			target = disk.Target.Dev
+			break
+		}
+	}
+	if target == "" {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Block device %s is not attached to instance %s", req.DevicePath, req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	unlock, err := s.lockInstanceVersion(ctx, req.NodeName, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "DetachHostBlockDevice")
+	}
+
+	if err := client.DetachDiskFromDomain(req.InstanceID, target); err != nil {
+		if errors.Is(err, libvirt.ErrDeviceBusy) {
+			return nil, apierror.NewErrorWithRawAndStatus(
+				"BlockDeviceInUse",
+				fmt.Sprintf("Block device %s is still in use by instance %s, unmount it in the guest and retry", req.DevicePath, req.InstanceID),
+				http.StatusConflict,
+				err,
+			)
+		}
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to detach host block device", err)
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device_path", req.DevicePath).
+		Str("device", target).
+		Msg("Host block device detached")
+
+	s.recordEvent(ctx, req.NodeName, req.InstanceID, entity.InstanceEventModified, "",
+		fmt.Sprintf("host block device %s detached from %s", req.DevicePath, target))
+
+	return &entity.DetachHostBlockDeviceResponse{
+		InstanceID: req.InstanceID,
+		DevicePath: req.DevicePath,
+		Device:     target,
+	}, nil
+}
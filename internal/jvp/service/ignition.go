@@ -0,0 +1,161 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+)
+
+// ignitionSpecVersion 自动生成的 Ignition 配置使用的规范版本，Fedora CoreOS 与 Flatcar（3185+）均支持
+const ignitionSpecVersion = "3.3.0"
+
+// ignitionDefaultUser Fedora CoreOS 与 Flatcar 的默认用户
+const ignitionDefaultUser = "core"
+
+// ignitionOSNames 使用 Ignition 而不是 cloud-init 的发行版（匹配模板名称或 OS 名称，忽略大小写）
+var ignitionOSNames = []string{"coreos", "flatcar", "rhcos"}
+
+// isIgnitionTemplate 判断模板镜像是否通过 Ignition 初始化
+func isIgnitionTemplate(template *entity.Template) bool {
+	if template == nil {
+		return false
+	}
+	candidates := []string{
+		strings.ToLower(template.OS.Name),
+		strings.ToLower(template.Name),
+	}
+	for _, candidate := range candidates {
+		for _, name := range ignitionOSNames {
+			if strings.Contains(candidate, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseIgnitionConfig 解析用户提供的 Ignition 配置，要求是包含 ignition.version 的 JSON 对象
+func parseIgnitionConfig(content string) (map[string]any, error) {
+	var config map[string]any
+	if err := json.Unmarshal([]byte(content), &config); err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("ignition_config is not a valid JSON object: %v", err),
+			http.StatusBadRequest,
+		)
+	}
+	ignition, _ := config["ignition"].(map[string]any)
+	if version, _ := ignition["version"].(string); version == "" {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"ignition_config must specify ignition.version",
+			http.StatusBadRequest,
+		)
+	}
+	return config, nil
+}
+
+// buildIgnitionConfig 生成实例的 Ignition 配置
+// 未提供配置时生成最小配置：设置主机名并为 core 用户注入 SSH 公钥；
+// 提供了配置时原样保留，只把 SSH 公钥追加到 core 用户
+func buildIgnitionConfig(content, hostname string, sshKeys []string) (string, error) {
+	var config map[string]any
+	if content != "" {
+		parsed, err := parseIgnitionConfig(content)
+		if err != nil {
+			return "", err
+		}
+		config = parsed
+	} else {
+		config = map[string]any{
+			"ignition": map[string]any{"version": ignitionSpecVersion},
+			"storage": map[string]any{
+				"files": []any{map[string]any{
+					"path":      "/etc/hostname",
+					"mode":      0o644,
+					"overwrite": true,
+					"contents":  map[string]any{"source": "data:," + url.PathEscape(hostname)},
+				}},
+			},
+		}
+	}
+
+	if len(sshKeys) > 0 {
+		passwd, _ := config["passwd"].(map[string]any)
+		if passwd == nil {
+			passwd = map[string]any{}
+			config["passwd"] = passwd
+		}
+		users, _ := passwd["users"].([]any)
+		var user map[string]any
+		for _, item := range users {
+			if u, ok := item.(map[string]any); ok && u["name"] == ignitionDefaultUser {
+				user = u
+				break
+			}
+		}
+		if user == nil {
+			user = map[string]any{"name": ignitionDefaultUser}
+			users = append(users, user)
+		}
+		keys, _ := user["sshAuthorizedKeys"].([]any)
+		for _, key := range sshKeys {
+			keys = append(keys, key)
+		}
+		user["sshAuthorizedKeys"] = keys
+		passwd["users"] = users
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("marshal ignition config: %w", err)
+	}
+	return string(data), nil
+}
+
+// createIgnitionVolume 把 Ignition 配置写入存储池，返回节点上的文件路径，随实例一起删除
+func createIgnitionVolume(client libvirt.LibvirtClient, poolName, instanceID, content string) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "ignition-")
+	if err != nil {
+		return "", fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	localPath := filepath.Join(tmpDir, libvirt.IgnitionVolumeName(instanceID))
+	if err := os.WriteFile(localPath, []byte(content), 0o644); err != nil {
+		return "", fmt.Errorf("write ignition config: %w", err)
+	}
+	volume, err := client.UploadFileToPool(poolName, libvirt.IgnitionVolumeName(instanceID), localPath)
+	if err != nil {
+		return "", fmt.Errorf("upload ignition config to pool %s: %w", poolName, err)
+	}
+	return volume.Path, nil
+}
+
+// findIgnitionVolume 查找实例的 Ignition 配置卷路径，配置卷与系统盘位于同一存储池，不存在时返回空字符串
+// Ignition 配置通过 fw_cfg 注入，不出现在 domain 的磁盘列表中
+func findIgnitionVolume(client libvirt.LibvirtClient, instanceID string, disks []libvirt.DomainDisk) string {
+	for _, disk := range disks {
+		if disk.Device != "disk" || disk.Source.File == "" {
+			continue
+		}
+		poolName, err := findPoolNameByPath(client, filepath.Dir(disk.Source.File))
+		if err != nil {
+			return ""
+		}
+		volume, err := client.GetVolume(poolName, libvirt.IgnitionVolumeName(instanceID))
+		if err != nil {
+			return ""
+		}
+		return volume.Path
+	}
+	return ""
+}
@@ -5,10 +5,15 @@ package service
 import (
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	libvirtlib "github.com/digitalocean/go-libvirt"
@@ -27,14 +32,79 @@ type InstanceService struct {
 	nodeProvider        NodeStorageProvider
 	templateService     *TemplateService
 	keyPairService      *KeyPairService
+	securityGroups      *SecurityGroupService
+	metadata            *MetadataService // 元数据服务，cloud_init_source=metadata 的实例从中拉取 cloud-init 配置
 	virtCustomizeClient virtcustomize.VirtCustomizeClient
 	idGen               *idgen.Generator
+	guestDefaults       GuestDefaults
+	fsFreezes           *FSFreezeManager
+	transfer            *TransferLimiter
+	operations          *OperationLimiter
+	tempSpace           *TempSpaceManager
+	events              *InstanceEventStore
+	logs                *InstanceLogStore // 实例操作日志归档
+	recycleBin          *RecycleBin
+	protection          *DeletionProtection
+	changes             *ChangeFeed
+	quotas              *QuotaStore
+	guestOS             *GuestOSStore // guest-agent 上报的操作系统信息缓存
+	labels              *LabelStore
+	ipv6NDPProxy        bool // 是否为 libvirt 网络中 ipv6/dual 实例在节点上游网卡添加 NDP 代理
+	v2vTasks            *V2VTaskManager
+	modifyLocks         sync.Map // key: nodeName/instanceID -> *sync.Mutex，串行化同一实例的修改
 	asyncRun            func(func())
 }
 
+// GuestDefaults 实例 guest 的全局默认环境，cloud-init 未指定时自动注入
+type GuestDefaults struct {
+	Timezone   string   // 默认时区（如：Asia/Shanghai）
+	Locale     string   // 默认语言环境（如：en_US.UTF-8）
+	NTPServers []string // 默认 NTP 服务器列表
+}
+
+// isEmpty 是否未配置任何默认值
+func (d GuestDefaults) isEmpty() bool {
+	return d.Timezone == "" && d.Locale == "" && len(d.NTPServers) == 0
+}
+
+// applyToConfig 将默认值填充到结构化 cloud-init 配置中未指定的字段
+func (d GuestDefaults) applyToConfig(config *cloudinit.Config) {
+	if config.Timezone == "" {
+		config.Timezone = d.Timezone
+	}
+	if config.Locale == "" {
+		config.Locale = d.Locale
+	}
+	if len(config.NTPServers) == 0 {
+		config.NTPServers = d.NTPServers
+	}
+}
+
+// applyToUserData 将默认值填充到原始 user-data 中未指定的字段
+func (d GuestDefaults) applyToUserData(userData *cloudinit.UserData) {
+	if userData.Timezone == "" {
+		userData.Timezone = d.Timezone
+	}
+	if userData.Locale == "" {
+		userData.Locale = d.Locale
+	}
+	if userData.NTP == nil && len(d.NTPServers) > 0 {
+		enabled := true
+		userData.NTP = &cloudinit.NTP{
+			Enabled: &enabled,
+			Servers: d.NTPServers,
+		}
+	}
+}
+
 // NodeStorageProvider 定义节点存储获取接口，便于测试替换
 type NodeStorageProvider interface {
 	GetNodeStorage(ctx context.Context, nodeName string) (libvirt.LibvirtClient, error)
+	ReservedResources(ctx context.Context, nodeName string) entity.NodeResources
+	NodeTopology(ctx context.Context, nodeName string) entity.NodeTopology
+	ListNodes(ctx context.Context) ([]*entity.Node, error)
+	NodeFeatures(ctx context.Context, nodeName string) *entity.NodeFeatures
+	ResolveInstanceNetwork(ctx context.Context, nodeName, networkType, networkSource string) (string, string, error)
 }
 
 // NewInstanceService 创建新的 Instance Service
@@ -42,6 +112,22 @@ func NewInstanceService(
 	nodeProvider NodeStorageProvider,
 	templateService *TemplateService,
 	keyPairService *KeyPairService,
+	securityGroups *SecurityGroupService,
+	metadata *MetadataService,
+	guestDefaults GuestDefaults,
+	fsFreezes *FSFreezeManager,
+	transfer *TransferLimiter,
+	operations *OperationLimiter,
+	tempSpace *TempSpaceManager,
+	events *InstanceEventStore,
+	logs *InstanceLogStore,
+	recycleBin *RecycleBin,
+	protection *DeletionProtection,
+	changes *ChangeFeed,
+	quotas *QuotaStore,
+	guestOS *GuestOSStore,
+	labels *LabelStore,
+	ipv6NDPProxy bool,
 ) (*InstanceService, error) {
 	// 创建 virt-customize 客户端（如果失败，返回 nil，后续使用时再处理）
 	virtCustomizeClient, _ := virtcustomize.NewClient()
@@ -50,8 +136,25 @@ func NewInstanceService(
 		nodeProvider:        nodeProvider,
 		templateService:     templateService,
 		keyPairService:      keyPairService,
+		securityGroups:      securityGroups,
+		metadata:            metadata,
 		virtCustomizeClient: virtCustomizeClient,
-		idGen:               idgen.New(),
+		idGen:               idgen.DefaultGenerator().Namespace(idgen.NamespaceInstance),
+		guestDefaults:       guestDefaults,
+		fsFreezes:           fsFreezes,
+		transfer:            transfer,
+		operations:          operations,
+		tempSpace:           tempSpace,
+		events:              events,
+		logs:                logs,
+		recycleBin:          recycleBin,
+		protection:          protection,
+		changes:             changes,
+		quotas:              quotas,
+		guestOS:             guestOS,
+		labels:              labels,
+		ipv6NDPProxy:        ipv6NDPProxy,
+		v2vTasks:            NewV2VTaskManager(),
 		asyncRun: func(f func()) {
 			go f()
 		},
@@ -68,10 +171,18 @@ func (s *InstanceService) RunInstance(ctx context.Context, req *entity.RunInstan
 	logger := zerolog.Ctx(ctx)
 	logger.Info().
 		Str("node_name", req.NodeName).
+		Str("zone", req.Zone).
 		Str("pool_name", req.PoolName).
 		Str("template_id", req.TemplateID).
 		Msg("Creating instance")
 
+	// 未指定节点时在可用区内选择节点，之后的流程与指定节点时相同
+	selectedNode, err := s.resolveInstanceNode(ctx, req.NodeName, req.Zone, req.PoolName)
+	if err != nil {
+		return nil, err
+	}
+	req.NodeName = selectedNode
+
 	// 获取节点的 libvirt 客户端
 	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
 	if err != nil {
@@ -86,7 +197,24 @@ func (s *InstanceService) RunInstance(ctx context.Context, req *entity.RunInstan
 			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to generate instance ID", err)
 		}
 		instanceName = fmt.Sprintf("i-%d", id)
+	} else {
+		if err := validateResourceName("instance", instanceName); err != nil {
+			return nil, err
+		}
+		// 实例名即 domain name，提前检测重名
+		if _, err := client.GetDomainByName(instanceName); err == nil {
+			return nil, newResourceAlreadyExistsError("Instance", instanceName)
+		}
+	}
+	if err := validateLabels(req.Labels); err != nil {
+		return nil, err
+	}
+	if err := validateLoginUsers(req); err != nil {
+		return nil, err
 	}
+	// 之后的日志同时归档到实例的操作日志
+	ctx = instanceLogContext(ctx, req.NodeName, instanceName)
+	logger = zerolog.Ctx(ctx)
 
 	// 设置默认值
 	memoryMB := req.MemoryMB
@@ -97,25 +225,173 @@ func (s *InstanceService) RunInstance(ctx context.Context, req *entity.RunInstan
 	if vcpus == 0 {
 		vcpus = 2 // 默认 2 核
 	}
+	if req.MaxMemoryMB != 0 && req.MaxMemoryMB < memoryMB {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("max_memory_mb (%d) must not be less than memory_mb (%d)", req.MaxMemoryMB, memoryMB),
+			http.StatusBadRequest,
+		)
+	}
+	if req.MaxVCPUs != 0 && req.MaxVCPUs < vcpus {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("max_vcpus (%d) must not be less than vcpus (%d)", req.MaxVCPUs, vcpus),
+			http.StatusBadRequest,
+		)
+	}
 	sizeGB := req.SizeGB
 	if sizeGB == 0 {
 		sizeGB = 20 // 默认 20GB
 	}
+	var deviceOptions libvirt.DeviceOptions
+	if req.Devices != nil {
+		deviceOptions = libvirt.DeviceOptions{
+			TPM:            req.Devices.TPM,
+			SoundModel:     req.Devices.SoundModel,
+			WatchdogModel:  req.Devices.WatchdogModel,
+			WatchdogAction: req.Devices.WatchdogAction,
+			DisableRNG:     req.Devices.DisableRNG,
+		}
+		if err := deviceOptions.Validate(); err != nil {
+			return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+		}
+	}
+	var spiceConfig *libvirt.SPICEConfig
+	if req.SPICE != nil {
+		spiceConfig = &libvirt.SPICEConfig{
+			Listen:       req.SPICE.Listen,
+			TLS:          req.SPICE.TLS,
+			USBRedirects: req.SPICE.USBRedirects,
+		}
+		if err := spiceConfig.Validate(); err != nil {
+			return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+		}
+	}
+	cpuTune := toLibvirtCPUTune(req.CPUTune)
+	if cpuTune != nil {
+		if err := cpuTune.Validate(); err != nil {
+			return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+		}
+	}
+	blkioTune := toLibvirtBlkioTune(req.BlkioTune)
+	if blkioTune != nil {
+		if err := blkioTune.Validate(); err != nil {
+			return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+		}
+	}
+	networkBandwidth := toLibvirtBandwidth(req.NetworkBandwidth)
+	if networkBandwidth != nil {
+		if err := networkBandwidth.Validate(); err != nil {
+			return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+		}
+	}
+	// unsafe 可以读取宿主机文件、直通宿主机设备，只允许管理员使用
+	if req.QEMUArgsUnsafe && tenantFromContext(ctx) != "" {
+		return nil, apierror.NewErrorWithStatus(
+			"OperationNotPermitted",
+			"only administrators can set qemu_args_unsafe",
+			http.StatusForbidden,
+		)
+	}
+	qemuArgWarnings, err := libvirt.ValidateQEMUArgs(req.QEMUArgs, req.QEMUArgsUnsafe)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+	}
+	for _, warning := range qemuArgWarnings {
+		logger.Warn().
+			Str("name", instanceName).
+			Strs("qemu_args", req.QEMUArgs).
+			Msg(warning)
+	}
+	if err := libvirt.ValidateDirectKernelBoot(req.Kernel, req.Initrd, req.KernelCmdline); err != nil {
+		return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+	}
+	switch req.DiskBus {
+	case "", "virtio", "scsi", "nvme":
+	default:
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported disk_bus %q, must be virtio, scsi or nvme", req.DiskBus),
+			http.StatusBadRequest,
+		)
+	}
+	if err := checkGuestArchitecture(client, req.Architecture, req.MachineType); err != nil {
+		return nil, err
+	}
+	diskDriver := toLibvirtDiskDriver(req.DiskDriver)
+	if diskDriver != nil {
+		if err := diskDriver.Validate(); err != nil {
+			return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+		}
+	}
+
+	appTemplate, err := lookupAppTemplate(req.AppTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	// installer 模式：从 ISO 安装到空白磁盘，不使用模板与 cloud-init
+	var installISOPath string
+	if req.InstallISO != "" {
+		if req.TemplateID != "" {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameter",
+				"template_id and install_iso cannot be specified together",
+				http.StatusBadRequest,
+			)
+		}
+		if req.UserData != nil || len(req.KeyPairIDs) > 0 || req.DefaultUser != "" || len(req.LoginUsers) > 0 || req.AppTemplate != "" || req.IgnitionConfig != "" {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameter",
+				"user_data, keypair_ids, default_user, login_users, app_template and ignition_config are not supported when installing from ISO",
+				http.StatusBadRequest,
+			)
+		}
+
+		isoName := req.InstallISO
+		if !strings.HasSuffix(isoName, ".iso") {
+			isoName += ".iso"
+		}
+		isoVolume, err := client.GetVolume(req.PoolName, isoName)
+		if err != nil {
+			return nil, apierror.NewErrorWithStatus(
+				"ResourceNotFound",
+				fmt.Sprintf("Install ISO %s not found in pool %s", req.InstallISO, req.PoolName),
+				http.StatusNotFound,
+			)
+		}
+		installISOPath = isoVolume.Path
+	}
 
 	var diskPath string
 	var templateID string
 
-	// 如果指定了模板，获取模板信息并创建增量磁盘
+	// 如果指定了模板，获取模板信息
+	// 模板注册在其他节点时（lazy clone），首次使用从模板的下载地址拉取到目标节点的缓存目录，之后命中本地缓存
+	var template *entity.Template
+	var lazyClone bool
 	if req.TemplateID != "" {
-		// 获取模板信息
-		template, err := s.templateService.DescribeTemplate(ctx, &entity.DescribeTemplateRequest{
-			NodeName:   req.NodeName,
-			PoolName:   req.PoolName,
+		templateNodeName, templatePoolName := req.NodeName, req.PoolName
+		if req.TemplateNodeName != "" {
+			templateNodeName = req.TemplateNodeName
+		}
+		if req.TemplatePoolName != "" {
+			templatePoolName = req.TemplatePoolName
+		}
+		template, err = s.templateService.DescribeTemplate(ctx, &entity.DescribeTemplateRequest{
+			NodeName:   templateNodeName,
+			PoolName:   templatePoolName,
 			TemplateID: req.TemplateID,
 		})
 		if err != nil {
 			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get template", err)
 		}
+		lazyClone = normalizeNodeName(templateNodeName) != normalizeNodeName(req.NodeName)
+		if lazyClone {
+			if err := checkTemplateReplicable(template); err != nil {
+				return nil, err
+			}
+		}
 
 		templateID = template.ID
 
@@ -126,15 +402,174 @@ func (s *InstanceService) RunInstance(ctx context.Context, req *entity.RunInstan
 		if sizeGB < uint64(template.SizeGB) {
 			sizeGB = uint64(template.SizeGB) // 不能比模板小
 		}
+		if err := applyTemplateRequirements(template, req, &memoryMB, &vcpus, &sizeGB); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fedora CoreOS/Flatcar 不读取 cloud-init，按镜像类型自动改用 Ignition（fw_cfg 注入）
+	useIgnition := req.IgnitionConfig != "" || isIgnitionTemplate(template)
+	if useIgnition {
+		if req.UserData != nil || req.AppTemplate != "" {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameter",
+				"user_data and app_template are not supported for Ignition based images (Fedora CoreOS/Flatcar), use ignition_config instead",
+				http.StatusBadRequest,
+			)
+		}
+		if req.DefaultUser != "" || len(req.LoginUsers) > 0 {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameter",
+				"default_user and login_users are not supported for Ignition based images (Fedora CoreOS/Flatcar), key pairs are injected into the core user",
+				http.StatusBadRequest,
+			)
+		}
+		if req.IgnitionConfig != "" {
+			if _, err := parseIgnitionConfig(req.IgnitionConfig); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 基于模板（cloud image）创建且配置了全局默认环境时，即使没有 user data 也生成 cloud-init
+	needGuestDefaults := req.TemplateID != "" && !s.guestDefaults.isEmpty()
+	// IPv6-only/双栈通过 cloud-init network-config 配置 guest 网卡，ipv4 与未指定等价
+	addressFamily := req.AddressFamily
+	if addressFamily == entity.AddressFamilyIPv4 {
+		addressFamily = ""
+	}
+	needNetworkConfig := addressFamily != ""
+	// cloud-init 配置由元数据服务下发时不生成 cidata ISO，guest 通过 SMBIOS 中的 NoCloud 地址拉取
+	useMetadata := req.CloudInitSource == entity.CloudInitSourceMetadata
+	if useMetadata && (useIgnition || req.InstallISO != "") {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameter",
+			"cloud_init_source metadata is not supported for Ignition based images or when installing from ISO",
+			http.StatusBadRequest,
+		)
+	}
+	needCloudInit := !useIgnition && (req.UserData != nil || len(req.KeyPairIDs) > 0 || len(req.LoginUsers) > 0 || appTemplate != nil || needGuestDefaults || needNetworkConfig || useMetadata)
+	if err := checkRunInstanceFeatures(s.nodeProvider.NodeFeatures(ctx, req.NodeName), req, needCloudInit && !useMetadata); err != nil {
+		return nil, err
+	}
+	networkType, networkSource, err := s.nodeProvider.ResolveInstanceNetwork(ctx, req.NodeName, req.NetworkType, req.NetworkSource)
+	if err != nil {
+		return nil, err
+	}
+	if err := libvirt.ValidateDirectMode(networkType, req.NetworkDirectMode); err != nil {
+		return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+	}
+	if err := libvirt.ValidateOVSOptions(networkType, req.NetworkVLAN, req.NetworkInterfaceID, req.NetworkMACSpoofCheck); err != nil {
+		return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+	}
+	if err := validateAddressFamily(client, req, networkType, networkSource, useIgnition); err != nil {
+		return nil, err
+	}
+	if err := s.metadata.validateCloudInitSource(req.CloudInitSource, networkType); err != nil {
+		return nil, err
+	}
+	// 直接引导的内核与 initrd 由节点上的 QEMU 读取，创建前确认文件存在
+	for _, path := range []string{req.Kernel, req.Initrd} {
+		if path == "" {
+			continue
+		}
+		if _, err := nodeFileSize(ctx, client, path); err != nil {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("%s not found on node %s", path, req.NodeName),
+				http.StatusBadRequest,
+			)
+		}
+	}
+	if len(req.SecurityGroupIDs) > 0 {
+		// nwfilter 由 libvirt 在 tap 设备所在的 Linux 网桥上下发，macvtap 与 OVS 端口不经过网桥的 netfilter
+		if networkType != "network" && networkType != "bridge" {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("security_group_ids require network type bridge or network, got %s", networkType),
+				http.StatusBadRequest,
+			)
+		}
+		if err := s.securityGroups.ValidateInstanceSecurityGroups(ctx, req.SecurityGroupIDs); err != nil {
+			return nil, err
+		}
+	}
 
+	// 绑定 NUMA 时按 cell 的剩余资源选择放置位置，dry-run 同样会校验
+	var numaTune *libvirt.NUMATune
+	if req.NUMATune != nil {
+		numaTune, err = selectNUMACell(client, req.NUMATune, vcpus, memoryMB)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info().
+			Str("name", instanceName).
+			Uint32("numa_cell", numaTune.Node).
+			Msg("Selected NUMA cell for instance")
+	}
+
+	if isDryRun(ctx) {
+		if err := checkPoolCapacity(client, req.PoolName, sizeGB); err != nil {
+			return nil, err
+		}
+		if err := checkHostCapacity(client, s.nodeProvider.ReservedResources(ctx, req.NodeName), memoryMB, vcpus); err != nil {
+			return nil, err
+		}
+		keyPairIDs := slices.Clone(req.KeyPairIDs)
+		for _, user := range req.LoginUsers {
+			keyPairIDs = append(keyPairIDs, user.KeyPairIDs...)
+		}
+		for _, keyPairID := range keyPairIDs {
+			if _, err := s.keyPairService.GetKeyPairByID(ctx, keyPairID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, dryRunOperation(ctx, "RunInstances")
+	}
+
+	// 在节点上定义安全组的过滤器，网卡引用它们的组合过滤器
+	var networkFilter string
+	if len(req.SecurityGroupIDs) > 0 {
+		networkFilter, err = s.securityGroups.EnsureInstanceFilter(ctx, client, req.NodeName, req.SecurityGroupIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 创建磁盘、注入 cloud-init 等 IO 密集步骤受节点并发上限约束，超出时排队等待
+	nodeName := normalizeNodeName(req.NodeName)
+	release, err := s.operations.Acquire(ctx, nodeName, instanceName, OperationRunInstance, instanceName)
+	if errors.Is(err, ErrShuttingDown) {
+		return nil, apierror.NewErrorWithRawAndStatus(
+			"ServiceUnavailable",
+			"Server is shutting down, retry later",
+			http.StatusServiceUnavailable,
+			err,
+		)
+	}
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Canceled while waiting in node operation queue", err)
+	}
+	defer release()
+
+	// 如果指定了模板，创建增量磁盘
+	if template != nil {
 		// 创建磁盘卷名称
 		diskVolumeName := instanceName + ".qcow2"
 
+		backingPath := template.Path
+		if lazyClone {
+			backingPath, err = s.templateService.EnsureTemplateCached(ctx, client, nodeName, req.PoolName, template)
+			if err != nil {
+				return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to pull template to node", err)
+			}
+		}
+
 		// 使用 backingStore 创建增量磁盘
 		logger.Info().
 			Str("pool_name", req.PoolName).
 			Str("volume_name", diskVolumeName).
-			Str("backing_path", template.Path).
+			Str("backing_path", backingPath).
 			Uint64("size_gb", sizeGB).
 			Msg("Creating disk with backing store")
 
@@ -143,7 +578,7 @@ func (s *InstanceService) RunInstance(ctx context.Context, req *entity.RunInstan
 			diskVolumeName,
 			sizeGB,
 			"qcow2",
-			template.Path,
+			backingPath,
 			template.Format,
 		)
 		if err != nil {
@@ -166,49 +601,43 @@ func (s *InstanceService) RunInstance(ctx context.Context, req *entity.RunInstan
 	}
 
 	// 处理 cloud-init 配置
-	var cloudInitISOPath string
-	if req.UserData != nil || len(req.KeyPairIDs) > 0 {
+	// loginUser 注入密钥对的登录用户，记录在域 metadata 中供 DescribeInstanceSSHTarget 返回
+	var cloudInitISOPath, metadataSeed, loginUser string
+	if needCloudInit {
 		cloudInitConfig, userData, err := s.convertUserDataToCloudInit(ctx, instanceName, req.UserData)
 		if err != nil {
 			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to convert user data", err)
 		}
+		if cloudInitConfig == nil && userData == nil {
+			cloudInitConfig = &cloudinit.Config{Hostname: instanceName}
+		}
 
-		// 添加 SSH 密钥
-		if len(req.KeyPairIDs) > 0 && cloudInitConfig != nil {
-			for _, keyPairID := range req.KeyPairIDs {
-				keyPair, err := s.keyPairService.GetKeyPairByID(ctx, keyPairID)
-				if err != nil {
-					logger.Warn().
-						Str("keypair_id", keyPairID).
-						Err(err).
-						Msg("Failed to get key pair, skipping")
-					continue
-				}
-				// 添加到默认用户的 SSH 密钥
-				if len(cloudInitConfig.Users) == 0 {
-					cloudInitConfig.Users = []cloudinit.User{{
-						Name:              "ubuntu",
-						Sudo:              "ALL=(ALL) NOPASSWD:ALL",
-						Shell:             "/bin/bash",
-						SSHAuthorizedKeys: []string{keyPair.PublicKey},
-					}}
-				} else {
-					cloudInitConfig.Users[0].SSHAuthorizedKeys = append(
-						cloudInitConfig.Users[0].SSHAuthorizedKeys,
-						keyPair.PublicKey,
-					)
-				}
-			}
+		// 注入全局默认时区 / locale / NTP（用户已指定的字段不覆盖）
+		if userData != nil {
+			s.guestDefaults.applyToUserData(userData)
+		} else {
+			s.guestDefaults.applyToConfig(cloudInitConfig)
 		}
 
-		// 生成 cloud-init ISO
-		if cloudInitConfig != nil || userData != nil {
-			// 获取存储池路径
-			poolInfo, err := client.GetStoragePool(req.PoolName)
-			if err != nil {
-				return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get storage pool", err)
+		// 合并应用模板的软件包、文件与启动命令
+		if appTemplate != nil {
+			if userData != nil {
+				appTemplate.ApplyToUserData(userData)
+			} else {
+				appTemplate.ApplyToConfig(cloudInitConfig)
 			}
+			logger.Info().
+				Str("app_template", appTemplate.Name).
+				Msg("App template applied to cloud-init config")
+		}
 
+		// 注入密钥对与登录用户
+		if cloudInitConfig != nil {
+			loginUser = s.injectLoginUsers(ctx, cloudInitConfig, req, template)
+		}
+
+		// 生成 cloud-init ISO 或保存到元数据服务
+		if cloudInitConfig != nil || userData != nil {
 			// 生成 cloud-init 配置文件内容
 			generator := cloudinit.NewGenerator()
 			metaData, err := generator.GenerateMetaData(cloudInitConfig.Hostname)
@@ -222,45 +651,120 @@ func (s *InstanceService) RunInstance(ctx context.Context, req *entity.RunInstan
 			} else {
 				userDataContent, err = generator.GenerateUserData(cloudInitConfig)
 			}
+			if errors.Is(err, cloudinit.ErrYescryptUnavailable) {
+				return nil, apierror.NewErrorWithStatus("InvalidParameterValue",
+					"yescrypt password hashing requires perl and a libxcrypt with yescrypt support on the jvp host, use sha512 or bcrypt instead",
+					http.StatusBadRequest)
+			}
 			if err != nil {
 				return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to generate user-data", err)
 			}
-
-			// 在远程节点上生成 cloud-init ISO
-			cloudInitISOPath, err = client.CreateCloudInitISO(
-				poolInfo.Path,
-				instanceName,
-				metaData,
-				userDataContent,
-			)
+			networkConfig, err := instanceNetworkConfig(req.AddressFamily)
 			if err != nil {
-				return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to generate cloud-init ISO on remote node", err)
+				return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to generate network-config", err)
 			}
 
-			logger.Info().
-				Str("cloud_init_iso", cloudInitISOPath).
-				Msg("Cloud-init ISO generated on remote node")
+			if useMetadata {
+				// 元数据在定义 domain 前保存，guest 启动后即可拉取；创建失败时删除
+				record := &instanceMetadataRecord{
+					MetaData:      metaData,
+					UserData:      userDataContent,
+					NetworkConfig: networkConfig,
+					PublicKeys:    s.metadataPublicKeys(ctx, req.KeyPairIDs),
+				}
+				if err := s.metadata.store.Set(req.NodeName, instanceName, record); err != nil {
+					return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to save instance metadata", err)
+				}
+				metadataSeed = metadataSeedURL
+
+				logger.Info().
+					Str("seed_url", metadataSeed).
+					Msg("Cloud-init config saved to metadata service")
+			} else {
+				// cidata ISO 作为存储池中的卷创建，随实例一起删除
+				cloudInitVolume, err := client.CreateCloudInitVolume(
+					req.PoolName,
+					instanceName,
+					metaData,
+					userDataContent,
+					networkConfig,
+				)
+				if err != nil {
+					return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to create cloud-init volume", err)
+				}
+				cloudInitISOPath = cloudInitVolume.Path
+
+				logger.Info().
+					Str("cloud_init_iso", cloudInitISOPath).
+					Msg("Cloud-init volume created")
+			}
 		}
 	}
 
-	// 设置网络配置
-	networkType := req.NetworkType
-	if networkType == "" {
-		networkType = "bridge"
-	}
-	networkSource := req.NetworkSource
-	if networkSource == "" {
-		networkSource = "br0"
+	// 处理 Ignition 配置：未提供时生成设置主机名的最小配置，密钥对注入 core 用户
+	var ignitionPath string
+	if useIgnition {
+		sshKeys := s.keyPairPublicKeys(ctx, req.KeyPairIDs)
+		ignitionConfig, err := buildIgnitionConfig(req.IgnitionConfig, instanceName, sshKeys)
+		if err != nil {
+			return nil, err
+		}
+		loginUser = ignitionDefaultUser
+		ignitionPath, err = createIgnitionVolume(client, req.PoolName, instanceName, ignitionConfig)
+		if err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to create ignition config", err)
+		}
+
+		logger.Info().
+			Str("ignition_config", ignitionPath).
+			Msg("Ignition config created")
 	}
 
-	// 创建 Domain
+	// 创建 Domain，创建时间记录在域 metadata 中，之后的查询返回同一时间
+	createdAt := time.Now().UTC()
 	vmConfig := &libvirt.CreateVMConfig{
-		Name:          instanceName,
-		Memory:        memoryMB * 1024, // 转换为 KB
-		VCPUs:         vcpus,
-		DiskPath:      diskPath,
-		NetworkType:   networkType,
-		NetworkSource: networkSource,
+		Name:                 instanceName,
+		Memory:               memoryMB * 1024, // 转换为 KB
+		MaxMemory:            req.MaxMemoryMB * 1024,
+		VCPUs:                vcpus,
+		MaxVCPUs:             req.MaxVCPUs,
+		CPUTune:              cpuTune,
+		BlkioTune:            blkioTune,
+		NUMATune:             numaTune,
+		NestedVirtualization: req.NestedVirtualization,
+		Architecture:         req.Architecture,
+		MachineType:          req.MachineType,
+		DiskPath:             diskPath,
+		DiskBus:              req.DiskBus,
+		DiskDriver:           diskDriver,
+		NetworkType:          networkType,
+		NetworkSource:        networkSource,
+		DirectMode:           req.NetworkDirectMode,
+		NetworkBandwidth:     networkBandwidth,
+		NetworkVLAN:          req.NetworkVLAN,
+		OVSInterfaceID:       req.NetworkInterfaceID,
+		MACSpoofCheck:        req.NetworkMACSpoofCheck,
+		NetworkFilter:        networkFilter,
+		AddressFamily:        req.AddressFamily,
+		CreatedAt:            createdAt,
+		LoginUser:            loginUser,
+		Labels:               req.Labels,
+		SerialType:           req.SerialType,
+		SerialTCPPort:        req.SerialTCPPort,
+		BootOrder:            req.BootOrder,
+		Devices:              deviceOptions,
+		SPICE:                spiceConfig,
+		QEMUArgs:             req.QEMUArgs,
+		QEMUArgsUnsafe:       req.QEMUArgsUnsafe,
+		IgnitionPath:         ignitionPath,
+		CloudInitSeedURL:     metadataSeed,
+		Kernel:               req.Kernel,
+		Initrd:               req.Initrd,
+		KernelCmdline:        req.KernelCmdline,
+		DiskController: libvirt.DiskControllerConfig{
+			Queues:   req.DiskQueues,
+			IOThread: req.DiskIOThread,
+		},
 	}
 
 	// 如果有 cloud-init ISO，添加到配置
@@ -268,6 +772,14 @@ func (s *InstanceService) RunInstance(ctx context.Context, req *entity.RunInstan
 		vmConfig.ISOPath = cloudInitISOPath
 	}
 
+	// installer 模式默认先从光驱引导，安装完成后通过 CompleteInstanceInstall 切回硬盘
+	if installISOPath != "" {
+		vmConfig.ISOPath = installISOPath
+		if len(vmConfig.BootOrder) == 0 {
+			vmConfig.BootOrder = []string{"cdrom", "hd"}
+		}
+	}
+
 	logger.Info().
 		Str("name", instanceName).
 		Uint64("memory_mb", memoryMB).
@@ -277,6 +789,9 @@ func (s *InstanceService) RunInstance(ctx context.Context, req *entity.RunInstan
 
 	domain, err := client.CreateDomain(vmConfig, true)
 	if err != nil {
+		if metadataSeed != "" {
+			s.clearInstanceMetadata(ctx, req.NodeName, instanceName)
+		}
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to create domain", err)
 	}
 
@@ -287,23 +802,79 @@ func (s *InstanceService) RunInstance(ctx context.Context, req *entity.RunInstan
 			Str("name", instanceName).
 			Msg("Failed to start domain, it might already be running")
 	}
+	if (needNetworkConfig && s.ipv6NDPProxy) || req.NetworkMACSpoofCheck {
+		if domainInfo, err := client.GetDomainInfo(domain.UUID); err == nil {
+			interfaces := convertInterfaceSpecs(domainInfo.NetworkInfo)
+			s.syncNDPProxy(ctx, client, instanceName, addressFamily, interfaces, true)
+			syncMACSpoofCheck(ctx, client, instanceName, req.NetworkMACSpoofCheck, interfaces, true)
+		}
+	}
 
 	logger.Info().
 		Str("name", instanceName).
 		Str("domain_uuid", formatDomainUUID(domain.UUID)).
 		Msg("Instance created successfully")
 
+	s.recordEvent(ctx, req.NodeName, instanceName, entity.InstanceEventCreated, "running",
+		fmt.Sprintf("created with %d vCPUs and %d MB memory", vcpus, memoryMB))
+
+	disableAPITermination := false
+	if req.DisableAPITermination {
+		if err := s.protection.SetInstance(req.NodeName, instanceName, true); err != nil {
+			logger.Error().
+				Err(err).
+				Str("name", instanceName).
+				Msg("Failed to enable deletion protection")
+		} else {
+			disableAPITermination = true
+		}
+	}
+
+	// 记录实例所属租户，用于配额使用量统计
+	owner := tenantFromContext(ctx)
+	if owner != "" {
+		if err := s.quotas.SetInstanceOwner(req.NodeName, instanceName, owner); err != nil {
+			logger.Error().
+				Err(err).
+				Str("name", instanceName).
+				Msg("Failed to record instance owner")
+		}
+	}
+	var labels map[string]string
+	if len(req.Labels) > 0 {
+		if err := s.labels.Set(req.NodeName, instanceName, req.Labels); err != nil {
+			logger.Error().
+				Err(err).
+				Str("name", instanceName).
+				Msg("Failed to record instance labels")
+		} else {
+			labels = req.Labels
+		}
+	}
+
 	return &entity.Instance{
-		ID:         instanceName,
-		Name:       instanceName,
-		State:      "running",
-		NodeName:   req.NodeName,
-		TemplateID: templateID,
-		MemoryMB:   memoryMB,
-		VCPUs:      vcpus,
-		CreatedAt:  time.Now().Format(time.RFC3339),
-		DomainUUID: formatDomainUUID(domain.UUID),
-		DomainName: instanceName,
+		ID:          instanceName,
+		Name:        instanceName,
+		State:       "running",
+		NodeName:    req.NodeName,
+		TemplateID:  templateID,
+		MemoryMB:    memoryMB,
+		MaxMemoryMB: max(req.MaxMemoryMB, memoryMB),
+		VCPUs:       vcpus,
+		MaxVCPUs:    max(req.MaxVCPUs, vcpus),
+		CPUTune:     req.CPUTune,
+		BlkioTune:   req.BlkioTune,
+		NUMATune:    fromLibvirtNUMATune(numaTune),
+		CreatedAt:   createdAt.Format(time.RFC3339),
+		DomainUUID:  formatDomainUUID(domain.UUID),
+		DomainName:  instanceName,
+		Owner:       owner,
+		Labels:      labels,
+
+		DisableAPITermination: disableAPITermination,
+
+		AddressFamily: addressFamily,
+		MACSpoofCheck: req.NetworkMACSpoofCheck,
 	}, nil
 }
 
@@ -435,8 +1006,25 @@ func (s *InstanceService) DescribeInstances(ctx context.Context, req *entity.Des
 	logger := zerolog.Ctx(ctx)
 	logger.Info().
 		Str("node_name", req.NodeName).
+		Strs("include", req.Include).
 		Msg("Describing instances from libvirt")
 
+	var includeInterfaces, includeDisks bool
+	for _, field := range req.Include {
+		switch field {
+		case entity.InstanceIncludeInterfaces:
+			includeInterfaces = true
+		case entity.InstanceIncludeDisks:
+			includeDisks = true
+		default:
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("unsupported include field %q, must be one of %s, %s", field, entity.InstanceIncludeInterfaces, entity.InstanceIncludeDisks),
+				http.StatusBadRequest,
+			)
+		}
+	}
+
 	// 获取节点的 libvirt 客户端
 	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
 	if err != nil {
@@ -456,9 +1044,44 @@ func (s *InstanceService) DescribeInstances(ctx context.Context, req *entity.Des
 		Int("total_domains", len(domains)).
 		Msg("Retrieved domains from libvirt")
 
+	protected, err := s.protection.ProtectedInstances(req.NodeName)
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("Failed to load deletion protection")
+	}
+	owners, err := s.quotas.InstanceOwners(req.NodeName)
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("Failed to load instance owners")
+	}
+	guestOS, err := s.guestOS.Instances(req.NodeName)
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("Failed to load guest OS info")
+	}
+	labels, err := s.labels.Resources(req.NodeName)
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("Failed to load instance labels")
+	}
+	topology := s.nodeProvider.NodeTopology(ctx, req.NodeName)
+
 	// 转换为 Instance 对象
 	instances := make([]entity.Instance, 0, len(domains))
 	for _, domain := range domains {
+		// 回收站中的实例不对外展示，通过 DescribeRecycleBin 查询
+		if isRecycledDomain(domain.Name) {
+			continue
+		}
+		// 按 ID 过滤提前到查询详情之前，避免为不需要的 domain 调用 libvirt
+		if len(req.InstanceIDs) > 0 && !slices.Contains(req.InstanceIDs, domain.Name) {
+			continue
+		}
+
 		// 获取详细信息
 		domainInfo, err := client.GetDomainInfo(domain.UUID)
 		if err != nil {
@@ -480,20 +1103,45 @@ func (s *InstanceService) DescribeInstances(ctx context.Context, req *entity.Des
 		}
 
 		instance := entity.Instance{
-			ID:         domain.Name, // 使用 domain name 作为 ID
-			Name:       domain.Name,
-			State:      convertDomainState(state),
-			NodeName:   req.NodeName,
-			DomainUUID: formatDomainUUID(domain.UUID),
-			DomainName: domain.Name,
-			VCPUs:      domainInfo.VCPUs,
-			MemoryMB:   domainInfo.Memory / 1024, // 转换为 MB
-			CreatedAt:  "",                       // libvirt 不提供创建时间
-			Autostart:  domainInfo.Autostart,
-			Interfaces: convertInterfaces(client, domainInfo.NetworkInfo),
-			StartedAt:  formatStartTime(domainInfo.StartTime),
-			Disks:      convertDisks(client, domain.Name),
+			ID:          domain.Name, // 使用 domain name 作为 ID
+			Name:        domain.Name,
+			State:       convertDomainState(state),
+			NodeName:    req.NodeName,
+			Zone:        topology.Zone,
+			Rack:        topology.Rack,
+			DomainUUID:  formatDomainUUID(domain.UUID),
+			DomainName:  domain.Name,
+			VCPUs:       domainInfo.VCPUs,
+			MaxVCPUs:    domainInfo.MaxVCPUs,
+			CPUTune:     fromLibvirtCPUTune(domainInfo.CPUTune),
+			BlkioTune:   fromLibvirtBlkioTune(domainInfo.BlkioTune),
+			NUMATune:    fromLibvirtNUMATune(domainInfo.NUMATune),
+			CPUModel:    domainInfo.CPUModel,
+			MemoryMB:    domainInfo.Memory / 1024,    // 转换为 MB
+			MaxMemoryMB: domainInfo.MaxMemory / 1024, // 转换为 MB
+			CreatedAt:   formatDomainTime(domainInfo.CreatedAt),
+			Autostart:   domainInfo.Autostart,
+			StartedAt:   formatDomainTime(domainInfo.StartTime),
+		}
+		instance.DisableAPITermination = protected[domain.Name]
+		instance.Owner = owners[domain.Name]
+		instance.Labels = instanceLabels(labels, domain.Name, domainInfo)
+		instance.AddressFamily = domainInfo.AddressFamily
+		instance.MACSpoofCheck = domainInfo.MACSpoofCheck
+		s.fillGuestOS(ctx, client, req.NodeName, &instance, guestOS[domain.Name], domainInfo.StartTime)
+		if includeInterfaces {
+			instance.Interfaces = convertInterfaces(client, domainInfo.NetworkInfo)
+		}
+		if includeDisks {
+			instance.Disks = convertDisks(client, domain.Name)
+			// 版本号包含网卡 MAC、来源与限速，这些字段来自 domain XML，无需解析 IP
+			versioned := instance
+			if !includeInterfaces {
+				versioned.Interfaces = convertInterfaceSpecs(domainInfo.NetworkInfo)
+			}
+			instance.Version = instanceVersion(&versioned)
 		}
+		instance.Revision = s.changes.ResourceRevision(entity.ResourceTypeInstance, req.NodeName, domain.Name)
 
 		// TODO: 如果需要 TemplateID，可以从 domain metadata 读取
 		instances = append(instances, instance)
@@ -530,7 +1178,18 @@ func (s *InstanceService) applyInstanceFilters(instances []entity.Instance, req
 				filtered = append(filtered, instance)
 			}
 		}
-		return filtered
+		instances = filtered
+	}
+
+	// label:<key>、tag:<key> 与 tag-key 过滤器
+	if len(req.Filters) > 0 {
+		filtered := make([]entity.Instance, 0, len(instances))
+		for _, instance := range instances {
+			if matchTagFilters(instance.Labels, req.Filters) {
+				filtered = append(filtered, instance)
+			}
+		}
+		instances = filtered
 	}
 
 	return instances
@@ -554,29 +1213,81 @@ func convertDomainState(state uint8) string {
 	}
 }
 
-func convertInterfaces(client libvirt.LibvirtClient, ifaces []libvirt.NetworkInterface) []entity.InstanceInterface {
+func convertInterfaces(client interface {
+	libvirt.NetworkManager
+	libvirt.RemoteManager
+}, ifaces []libvirt.NetworkInterface) []entity.InstanceInterface {
+	result := convertInterfaceSpecs(ifaces)
+	for i := range result {
+		result[i].IPs, _ = libvirt.ResolveIPsByMAC(client, result[i].MAC)
+	}
+	return result
+}
+
+// convertInterfaceSpecs 转换网卡配置，不解析 IP
+func convertInterfaceSpecs(ifaces []libvirt.NetworkInterface) []entity.InstanceInterface {
 	result := make([]entity.InstanceInterface, 0, len(ifaces))
 	for _, iface := range ifaces {
-		ips, _ := libvirt.ResolveIPsByMAC(client, iface.MAC)
 		result = append(result, entity.InstanceInterface{
-			Name:   iface.Name,
-			Type:   iface.Type,
-			Source: iface.Source,
-			MAC:    iface.MAC,
-			IPs:    ips,
+			Name:      iface.Name,
+			Type:      iface.Type,
+			Source:    iface.Source,
+			MAC:       iface.MAC,
+			Bandwidth: fromLibvirtBandwidth(iface.Bandwidth),
+
+			VirtualPort: iface.VirtualPort,
+			InterfaceID: iface.InterfaceID,
+			VLAN:        iface.VLAN,
+
+			SecurityGroupIDs: securityGroupIDsFromFilter(iface.Filter),
 		})
 	}
 	return result
 }
 
-func formatStartTime(t *time.Time) string {
+// checkGuestArchitecture 校验节点能运行该架构的 guest（KVM 或 TCG 模拟均可）并支持所选机器类型
+func checkGuestArchitecture(client libvirt.HostManager, arch, machine string) error {
+	if arch == "" {
+		arch = libvirt.ArchX86_64
+	}
+	if !slices.Contains(libvirt.SupportedArchitectures, arch) {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported architecture %q, must be one of %s", arch, strings.Join(libvirt.SupportedArchitectures, ", ")),
+			http.StatusBadRequest,
+		)
+	}
+
+	caps, err := client.GetCapabilities()
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to get node capabilities", err)
+	}
+	if !caps.SupportsGuest(arch, "kvm") && !caps.SupportsGuest(arch, "qemu") {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("node does not support %s guests (supported architectures: %s)", arch, strings.Join(caps.GuestArchs(), ", ")),
+			http.StatusBadRequest,
+		)
+	}
+	if machine != "" && !caps.SupportsMachine(arch, machine) {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("node does not support machine type %s for %s guests", machine, arch),
+			http.StatusBadRequest,
+		)
+	}
+	return nil
+}
+
+// formatDomainTime 把域的启动、创建时间格式化为 RFC3339（UTC），未知时为空
+func formatDomainTime(t *time.Time) string {
 	if t == nil {
 		return ""
 	}
 	return t.UTC().Format(time.RFC3339)
 }
 
-func convertDisks(client libvirt.LibvirtClient, domainName string) []entity.InstanceDisk {
+func convertDisks(client libvirt.DomainManager, domainName string) []entity.InstanceDisk {
 	disks, err := client.GetDomainDisks(domainName)
 	if err != nil {
 		return nil
@@ -585,8 +1296,13 @@ func convertDisks(client libvirt.LibvirtClient, domainName string) []entity.Inst
 	for _, d := range disks {
 		result = append(result, entity.InstanceDisk{
 			Target:      d.Target.Dev,
-			Path:        d.Source.File,
+			Path:        d.SourcePath(),
 			Format:      d.Driver.Type,
+			Bus:         d.Target.Bus,
+			Serial:      d.Serial,
+			Cache:       d.Driver.Cache,
+			IO:          d.Driver.IO,
+			Discard:     d.Driver.Discard,
 			CapacityB:   d.CapacityB,
 			AllocationB: d.AllocationB,
 		})
@@ -620,25 +1336,81 @@ func (s *InstanceService) GetInstance(ctx context.Context, nodeName, instanceID
 		state = 5 // Unknown
 	}
 
+	topology := s.nodeProvider.NodeTopology(ctx, nodeName)
 	instance := &entity.Instance{
-		ID:         domain.Name,
-		Name:       domain.Name,
-		State:      convertDomainState(state),
-		NodeName:   nodeName,
-		DomainUUID: formatDomainUUID(domain.UUID),
-		DomainName: domain.Name,
-		VCPUs:      domainInfo.VCPUs,
-		MemoryMB:   domainInfo.Memory / 1024,
-		CreatedAt:  time.Now().Format(time.RFC3339),
-		Autostart:  domainInfo.Autostart,
-		Interfaces: convertInterfaces(client, domainInfo.NetworkInfo),
-		StartedAt:  formatStartTime(domainInfo.StartTime),
-		Disks:      convertDisks(client, domain.Name),
+		ID:          domain.Name,
+		Name:        domain.Name,
+		State:       convertDomainState(state),
+		NodeName:    nodeName,
+		Zone:        topology.Zone,
+		Rack:        topology.Rack,
+		DomainUUID:  formatDomainUUID(domain.UUID),
+		DomainName:  domain.Name,
+		VCPUs:       domainInfo.VCPUs,
+		MaxVCPUs:    domainInfo.MaxVCPUs,
+		CPUTune:     fromLibvirtCPUTune(domainInfo.CPUTune),
+		BlkioTune:   fromLibvirtBlkioTune(domainInfo.BlkioTune),
+		NUMATune:    fromLibvirtNUMATune(domainInfo.NUMATune),
+		CPUModel:    domainInfo.CPUModel,
+		MemoryMB:    domainInfo.Memory / 1024,
+		MaxMemoryMB: domainInfo.MaxMemory / 1024,
+		CreatedAt:   formatDomainTime(domainInfo.CreatedAt),
+		Autostart:   domainInfo.Autostart,
+		Interfaces:  convertInterfaces(client, domainInfo.NetworkInfo),
+		StartedAt:   formatDomainTime(domainInfo.StartTime),
+		Disks:       convertDisks(client, domain.Name),
 	}
+	instance.AddressFamily = domainInfo.AddressFamily
+	instance.MACSpoofCheck = domainInfo.MACSpoofCheck
+
+	protected, err := s.protection.ProtectedInstances(nodeName)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("instanceID", instanceID).
+			Msg("Failed to load deletion protection")
+	}
+	instance.DisableAPITermination = protected[domain.Name]
+	owners, err := s.quotas.InstanceOwners(nodeName)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("instanceID", instanceID).
+			Msg("Failed to load instance owners")
+	}
+	instance.Owner = owners[domain.Name]
+	labels, err := s.labels.Resources(nodeName)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("instanceID", instanceID).
+			Msg("Failed to load instance labels")
+	}
+	instance.Labels = instanceLabels(labels, domain.Name, domainInfo)
+	guestOS, err := s.guestOS.Instances(nodeName)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("instanceID", instanceID).
+			Msg("Failed to load guest OS info")
+	}
+	s.fillGuestOS(ctx, client, nodeName, instance, guestOS[domain.Name], domainInfo.StartTime)
+	instance.Version = instanceVersion(instance)
+	instance.Revision = s.changes.ResourceRevision(entity.ResourceTypeInstance, nodeName, domain.Name)
 
 	return instance, nil
 }
 
+// checkInstancesExist 校验批量操作涉及的实例均存在（用于 DryRun）
+func (s *InstanceService) checkInstancesExist(ctx context.Context, nodeName string, instanceIDs []string) error {
+	for _, instanceID := range instanceIDs {
+		if _, err := s.GetInstance(ctx, nodeName, instanceID); err != nil {
+			return apierror.WrapError(apierror.ErrInternalError, "Instance not found", err)
+		}
+	}
+	return nil
+}
+
 // TerminateInstances 终止实例
 func (s *InstanceService) TerminateInstances(ctx context.Context, req *entity.TerminateInstancesRequest) ([]entity.InstanceStateChange, error) {
 	logger := zerolog.Ctx(ctx)
@@ -653,6 +1425,18 @@ func (s *InstanceService) TerminateInstances(ctx context.Context, req *entity.Te
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
 	}
 
+	// 删除保护在 DryRun 时同样校验，任一实例受保护则整个请求都不执行
+	if err := s.checkTerminationProtection(client, req.NodeName, req.InstanceIDs, req.DeleteVolumes); err != nil {
+		return nil, err
+	}
+
+	if isDryRun(ctx) {
+		if err := s.checkInstancesExist(ctx, req.NodeName, req.InstanceIDs); err != nil {
+			return nil, err
+		}
+		return nil, dryRunOperation(ctx, "TerminateInstances")
+	}
+
 	var changes []entity.InstanceStateChange
 	var lastError error
 
@@ -681,46 +1465,37 @@ func (s *InstanceService) TerminateInstances(ctx context.Context, req *entity.Te
 		}
 
 		// 记录磁盘路径，用于后续删除卷
-		disks, err := client.GetDomainDisks(instanceID)
-		if err != nil {
-			logger.Warn().
-				Str("instanceID", instanceID).
-				Err(err).
-				Msg("Failed to get domain disks before deletion")
-		}
-
-		// 删除 domain（会先停止运行中的实例）
-		// 使用 DomainUndefineSnapshotsMetadata 标志同时删除快照元数据
-		logger.Info().
-			Str("instanceID", instanceID).
-			Msg("Deleting domain from libvirt")
-		undefineFlags := libvirtlib.DomainUndefineSnapshotsMetadata | libvirtlib.DomainUndefineNvram
-		err = client.DeleteDomain(domain, libvirtlib.DomainUndefineFlagsValues(undefineFlags))
+		disks, err := client.GetDomainDisks(instanceID)
 		if err != nil {
-			logger.Error().
+			logger.Warn().
 				Str("instanceID", instanceID).
 				Err(err).
-				Msg("Failed to delete domain")
-			lastError = err
-			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to delete domain", err)
+				Msg("Failed to get domain disks before deletion")
 		}
 
-		logger.Info().
-			Str("instanceID", instanceID).
-			Msg("Domain deleted successfully")
-
-		// 删除关联的卷（可选）
-		if req.DeleteVolumes && len(disks) > 0 {
-			if err := s.deleteVolumesByDisks(ctx, client, disks); err != nil {
+		if s.recycleBin.Enabled() && !req.Permanent {
+			item, err := s.recycleInstance(ctx, client, domain, req.NodeName, instanceID, req.DeleteVolumes)
+			if err != nil {
 				logger.Error().
 					Str("instanceID", instanceID).
 					Err(err).
-					Msg("Failed to delete volumes for instance")
-				return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to delete instance volumes", err)
+					Msg("Failed to move instance to recycle bin")
+				return nil, err
 			}
-			logger.Info().
-				Str("instanceID", instanceID).
-				Msg("Associated volumes deleted")
+			s.syncNDPProxy(ctx, client, instanceID, instance.AddressFamily, instance.Interfaces, false)
+			syncMACSpoofCheck(ctx, client, instanceID, instance.MACSpoofCheck, instance.Interfaces, false)
+			changes = append(changes, entity.InstanceStateChange{
+				InstanceID:    instanceID,
+				CurrentState:  "terminated",
+				PreviousState: previousState,
+			})
+			s.recordEvent(ctx, req.NodeName, instanceID, entity.InstanceEventTerminated, "terminated",
+				fmt.Sprintf("moved to recycle bin, expires at %s", item.ExpiresAt.Format(time.RFC3339)))
+			continue
+		}
+
+		if err := s.deleteInstance(ctx, client, domain, instanceID, disks, req.DeleteVolumes); err != nil {
+			return nil, err
 		}
 
 		// Domain 已从 libvirt 删除，不需要额外操作
@@ -735,6 +1510,14 @@ func (s *InstanceService) TerminateInstances(ctx context.Context, req *entity.Te
 			Str("previousState", previousState).
 			Str("currentState", "terminated").
 			Msg("Instance terminated successfully")
+
+		s.recordEvent(ctx, req.NodeName, instanceID, entity.InstanceEventTerminated, "terminated", "")
+		s.clearInstanceOwner(ctx, req.NodeName, instanceID)
+		s.clearGuestOS(ctx, req.NodeName, instanceID)
+		s.clearInstanceLabels(ctx, req.NodeName, instanceID)
+		s.clearInstanceMetadata(ctx, req.NodeName, instanceID)
+		s.syncNDPProxy(ctx, client, instanceID, instance.AddressFamily, instance.Interfaces, false)
+		syncMACSpoofCheck(ctx, client, instanceID, instance.MACSpoofCheck, instance.Interfaces, false)
 	}
 
 	if lastError != nil {
@@ -752,7 +1535,7 @@ func (s *InstanceService) TerminateInstances(ctx context.Context, req *entity.Te
 }
 
 // deleteVolumesByDisks 根据磁盘列表删除对应的卷（优先按路径删除）
-func (s *InstanceService) deleteVolumesByDisks(ctx context.Context, client libvirt.LibvirtClient, disks []libvirt.DomainDisk) error {
+func (s *InstanceService) deleteVolumesByDisks(ctx context.Context, client poolFileClient, disks []libvirt.DomainDisk) error {
 	logger := zerolog.Ctx(ctx)
 
 	for _, disk := range disks {
@@ -818,6 +1601,13 @@ func (s *InstanceService) StopInstances(ctx context.Context, req *entity.StopIns
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
 	}
 
+	if isDryRun(ctx) {
+		if err := s.checkInstancesExist(ctx, req.NodeName, req.InstanceIDs); err != nil {
+			return nil, err
+		}
+		return nil, dryRunOperation(ctx, "StopInstances")
+	}
+
 	var changes []entity.InstanceStateChange
 	var lastError error
 
@@ -886,6 +1676,8 @@ func (s *InstanceService) StopInstances(ctx context.Context, req *entity.StopIns
 		logger.Info().
 			Str("instanceID", instanceID).
 			Msg("Domain stop command sent successfully")
+		// 端口随 tap 设备删除，流表中的端口号可能被之后启动的实例复用
+		syncMACSpoofCheck(ctx, client, instanceID, instance.MACSpoofCheck, instance.Interfaces, false)
 
 		// 状态已在 libvirt 中更新，不需要额外操作
 
@@ -900,6 +1692,8 @@ func (s *InstanceService) StopInstances(ctx context.Context, req *entity.StopIns
 			Str("previousState", previousState).
 			Str("currentState", "stopped").
 			Msg("Instance stopped successfully")
+
+		s.recordEvent(ctx, req.NodeName, instanceID, entity.InstanceEventStopped, "stopped", fmt.Sprintf("force=%t", req.Force))
 	}
 
 	if lastError != nil {
@@ -930,6 +1724,13 @@ func (s *InstanceService) StartInstances(ctx context.Context, req *entity.StartI
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
 	}
 
+	if isDryRun(ctx) {
+		if err := s.checkInstancesExist(ctx, req.NodeName, req.InstanceIDs); err != nil {
+			return nil, err
+		}
+		return nil, dryRunOperation(ctx, "StartInstances")
+	}
+
 	var changes []entity.InstanceStateChange
 	var lastError error
 
@@ -951,6 +1752,9 @@ func (s *InstanceService) StartInstances(ctx context.Context, req *entity.StartI
 			logger.Info().
 				Str("instanceID", instanceID).
 				Msg("Instance already running, skipping")
+			// 补齐 NDP 代理条目与防 MAC 欺骗流表（如节点重启后随 autostart 启动的实例）
+			s.syncNDPProxy(ctx, client, instanceID, instance.AddressFamily, instance.Interfaces, true)
+			syncMACSpoofCheck(ctx, client, instanceID, instance.MACSpoofCheck, instance.Interfaces, true)
 			changes = append(changes, entity.InstanceStateChange{
 				InstanceID:    instanceID,
 				CurrentState:  "running",
@@ -987,6 +1791,8 @@ func (s *InstanceService) StartInstances(ctx context.Context, req *entity.StartI
 		logger.Info().
 			Str("instanceID", instanceID).
 			Msg("Domain start command sent successfully")
+		s.syncNDPProxy(ctx, client, instanceID, instance.AddressFamily, instance.Interfaces, true)
+		syncMACSpoofCheck(ctx, client, instanceID, instance.MACSpoofCheck, instance.Interfaces, true)
 
 		// 状态已在 libvirt 中更新，不需要额外操作
 		changes = append(changes, entity.InstanceStateChange{
@@ -1000,6 +1806,8 @@ func (s *InstanceService) StartInstances(ctx context.Context, req *entity.StartI
 			Str("previousState", previousState).
 			Str("currentState", "running").
 			Msg("Instance started successfully")
+
+		s.recordEvent(ctx, req.NodeName, instanceID, entity.InstanceEventStarted, "running", "")
 	}
 
 	if lastError != nil {
@@ -1030,6 +1838,13 @@ func (s *InstanceService) RebootInstances(ctx context.Context, req *entity.Reboo
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
 	}
 
+	if isDryRun(ctx) {
+		if err := s.checkInstancesExist(ctx, req.NodeName, req.InstanceIDs); err != nil {
+			return nil, err
+		}
+		return nil, dryRunOperation(ctx, "RebootInstances")
+	}
+
 	var changes []entity.InstanceStateChange
 	var lastError error
 
@@ -1107,6 +1922,8 @@ func (s *InstanceService) RebootInstances(ctx context.Context, req *entity.Reboo
 			Str("previousState", previousState).
 			Str("currentState", "running").
 			Msg("Instance rebooted successfully")
+
+		s.recordEvent(ctx, req.NodeName, instanceID, entity.InstanceEventRebooted, "running", "")
 	}
 
 	if lastError != nil {
@@ -1124,7 +1941,8 @@ func (s *InstanceService) RebootInstances(ctx context.Context, req *entity.Reboo
 }
 
 // ModifyInstanceAttribute 修改实例属性
-func (s *InstanceService) ModifyInstanceAttribute(ctx context.Context, req *entity.ModifyInstanceAttributeRequest) (*entity.Instance, error) {
+// 内存、VCPU 的持久化配置更新后热修改失败不返回错误，在响应的 changes 中如实反映 live_applied 与 live_error
+func (s *InstanceService) ModifyInstanceAttribute(ctx context.Context, req *entity.ModifyInstanceAttributeRequest) (*entity.ModifyInstanceAttributeResponse, error) {
 	logger := zerolog.Ctx(ctx)
 	logger.Info().
 		Str("node_name", req.NodeName).
@@ -1138,6 +1956,12 @@ func (s *InstanceService) ModifyInstanceAttribute(ctx context.Context, req *enti
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
 	}
 
+	unlock, err := s.lockInstanceVersion(ctx, req.NodeName, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	// 获取当前实例信息
 	instance, err := s.GetInstance(ctx, req.NodeName, req.InstanceID)
 	if err != nil {
@@ -1150,30 +1974,78 @@ func (s *InstanceService) ModifyInstanceAttribute(ctx context.Context, req *enti
 		return nil, fmt.Errorf("get domain: %w", err)
 	}
 
+	if req.Live && instance.State == "running" {
+		if err := checkHotplugLimit(instance, req); err != nil {
+			return nil, err
+		}
+	}
+	if req.Labels != nil {
+		if err := validateLabels(*req.Labels); err != nil {
+			return nil, err
+		}
+	}
+
+	if isDryRun(ctx) {
+		memoryMB, vcpus := instance.MemoryMB, instance.VCPUs
+		if req.MemoryMB != nil {
+			memoryMB = *req.MemoryMB
+		}
+		if req.VCPUs != nil {
+			vcpus = *req.VCPUs
+		}
+		if err := checkHostCapacity(client, s.nodeProvider.ReservedResources(ctx, req.NodeName), memoryMB, vcpus); err != nil {
+			return nil, err
+		}
+		if req.UserData != nil {
+			if err := ensureStoppedForUserData(client, domain, req.InstanceID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, dryRunOperation(ctx, "ModifyInstanceAttribute")
+	}
+
+	var changes []entity.InstanceAttributeChange
+
 	// 修改内存
 	if req.MemoryMB != nil {
 		memoryKB := *req.MemoryMB * 1024
-		err = client.ModifyDomainMemory(domain, memoryKB, req.Live)
+		result, err := client.ModifyDomainMemory(domain, memoryKB, req.Live)
+		if errors.Is(err, libvirt.ErrHotplugLimitExceeded) {
+			return nil, newHotplugLimitError(req.InstanceID, "memory_mb", *req.MemoryMB, instance.MaxMemoryMB)
+		}
+		if errors.Is(err, libvirt.ErrDomainConfigConflict) {
+			return nil, newDomainConfigConflictError(req.InstanceID, err)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("modify memory: %w", err)
 		}
 		instance.MemoryMB = *req.MemoryMB
+		changes = append(changes, resizeChange(ctx, req.InstanceID, "memory_mb", result))
 		logger.Info().
 			Str("instanceID", req.InstanceID).
 			Uint64("memoryMB", *req.MemoryMB).
+			Bool("liveApplied", result.LiveApplied).
 			Msg("Instance memory modified")
 	}
 
 	// 修改 VCPU
 	if req.VCPUs != nil {
-		err = client.ModifyDomainVCPU(domain, *req.VCPUs, req.Live)
+		result, err := client.ModifyDomainVCPU(domain, *req.VCPUs, req.Live)
+		if errors.Is(err, libvirt.ErrHotplugLimitExceeded) {
+			return nil, newHotplugLimitError(req.InstanceID, "vcpus", uint64(*req.VCPUs), uint64(instance.MaxVCPUs))
+		}
+		if errors.Is(err, libvirt.ErrDomainConfigConflict) {
+			return nil, newDomainConfigConflictError(req.InstanceID, err)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("modify VCPU: %w", err)
 		}
 		instance.VCPUs = *req.VCPUs
+		changes = append(changes, resizeChange(ctx, req.InstanceID, "vcpus", result))
 		logger.Info().
 			Str("instanceID", req.InstanceID).
 			Uint16("vcpus", *req.VCPUs).
+			Bool("liveApplied", result.LiveApplied).
 			Msg("Instance VCPU modified")
 	}
 
@@ -1200,18 +2072,439 @@ func (s *InstanceService) ModifyInstanceAttribute(ctx context.Context, req *enti
 			Msg("Instance autostart modified")
 	}
 
+	// 修改 user-data：重建 cidata ISO，仅允许在实例关机时进行
+	if req.UserData != nil {
+		if err := ensureStoppedForUserData(client, domain, req.InstanceID); err != nil {
+			return nil, err
+		}
+		if err := s.rebuildCloudInitISO(ctx, client, req.NodeName, req.InstanceID, *req.UserData); err != nil {
+			return nil, err
+		}
+		logger.Info().
+			Str("instanceID", req.InstanceID).
+			Msg("Instance user data modified")
+	}
+
+	// 修改启动顺序
+	if len(req.BootOrder) > 0 {
+		err = client.SetDomainBootOrder(domain, req.BootOrder)
+		if errors.Is(err, libvirt.ErrDomainConfigConflict) {
+			return nil, newDomainConfigConflictError(req.InstanceID, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("modify boot order: %w", err)
+		}
+		logger.Info().
+			Str("instanceID", req.InstanceID).
+			Strs("bootOrder", req.BootOrder).
+			Msg("Instance boot order modified")
+	}
+
+	// 修改删除保护
+	if req.DisableAPITermination != nil {
+		if err := s.protection.SetInstance(req.NodeName, req.InstanceID, *req.DisableAPITermination); err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to modify deletion protection", err)
+		}
+		instance.DisableAPITermination = *req.DisableAPITermination
+		logger.Info().
+			Str("instanceID", req.InstanceID).
+			Bool("disableAPITermination", *req.DisableAPITermination).
+			Msg("Instance deletion protection modified")
+	}
+
+	// 修改标签
+	if req.Labels != nil {
+		if err := s.setInstanceLabels(client, req.NodeName, req.InstanceID, *req.Labels); err != nil {
+			return nil, err
+		}
+		instance.Labels = *req.Labels
+		logger.Info().
+			Str("instanceID", req.InstanceID).
+			Interface("labels", *req.Labels).
+			Msg("Instance labels modified")
+	}
+
+	s.recordEvent(ctx, req.NodeName, req.InstanceID, entity.InstanceEventModified, "", describeAttributeChanges(req))
+
 	// 属性已在 libvirt 中更新，重新获取实例信息以获取最新状态
 	updatedInstance, err := s.GetInstance(ctx, req.NodeName, req.InstanceID)
 	if err != nil {
 		// 如果获取失败，返回修改后的实例信息
-		return instance, nil
+		instance.Version = instanceVersion(instance)
+		return &entity.ModifyInstanceAttributeResponse{Instance: instance, Changes: changes}, nil
 	}
 
 	logger.Info().
 		Str("instanceID", req.InstanceID).
 		Msg("Instance attribute modified successfully")
 
-	return updatedInstance, nil
+	return &entity.ModifyInstanceAttributeResponse{Instance: updatedInstance, Changes: changes}, nil
+}
+
+// resizeChange 把内存或 VCPU 的修改结果转换为响应中的生效情况，热修改失败时记录警告
+func resizeChange(ctx context.Context, instanceID, attribute string, result *libvirt.ResizeResult) entity.InstanceAttributeChange {
+	change := entity.InstanceAttributeChange{
+		Attribute:   attribute,
+		Persisted:   result.Persisted,
+		LiveApplied: result.LiveApplied,
+	}
+	if result.LiveError != nil {
+		change.LiveError = result.LiveError.Error()
+		zerolog.Ctx(ctx).Warn().
+			Err(result.LiveError).
+			Str("instanceID", instanceID).
+			Str("attribute", attribute).
+			Msg("Live modification failed, the change takes effect after restart")
+	}
+	return change
+}
+
+// checkHotplugLimit 运行中热修改只能在 maxMemory / vcpu 上限内进行，超出时需关机修改后重启
+func checkHotplugLimit(instance *entity.Instance, req *entity.ModifyInstanceAttributeRequest) error {
+	if req.MemoryMB != nil && *req.MemoryMB > instance.MaxMemoryMB {
+		return newHotplugLimitError(req.InstanceID, "memory_mb", *req.MemoryMB, instance.MaxMemoryMB)
+	}
+	if req.VCPUs != nil && *req.VCPUs > instance.MaxVCPUs {
+		return newHotplugLimitError(req.InstanceID, "vcpus", uint64(*req.VCPUs), uint64(instance.MaxVCPUs))
+	}
+	return nil
+}
+
+// newHotplugLimitError 构造超出热插上限的错误，提示使用 live=false 修改后重启
+func newHotplugLimitError(instanceID, field string, value, limit uint64) error {
+	return apierror.NewErrorWithStatus(
+		"InvalidParameterValue",
+		fmt.Sprintf("%s %d exceeds the hot-plug limit %d of instance %s; set live=false and restart the instance to apply", field, value, limit, instanceID),
+		http.StatusBadRequest,
+	)
+}
+
+// newDomainConfigConflictError 实例配置在多次重试中始终被节点上的其他修改覆盖，返回 409 让调用方稍后重试
+func newDomainConfigConflictError(instanceID string, err error) error {
+	return apierror.NewErrorWithRawAndStatus(
+		"ConcurrentModification",
+		fmt.Sprintf("Configuration of instance %s is being modified concurrently on the node, retry later", instanceID),
+		http.StatusConflict,
+		err,
+	)
+}
+
+// ensureStoppedForUserData user-data 仅允许在实例关机时修改
+func ensureStoppedForUserData(client libvirt.DomainManager, domain libvirtlib.Domain, instanceID string) error {
+	state, _, err := client.GetDomainState(domain)
+	if err != nil {
+		return fmt.Errorf("get domain state: %w", err)
+	}
+	if libvirtlib.DomainState(state) != libvirtlib.DomainShutoff {
+		return apierror.NewErrorWithStatus(
+			"IncorrectInstanceState",
+			fmt.Sprintf("Instance %s must be stopped to modify user data", instanceID),
+			http.StatusConflict,
+		)
+	}
+	return nil
+}
+
+// describeAttributeChanges 生成属性修改的事件描述
+func describeAttributeChanges(req *entity.ModifyInstanceAttributeRequest) string {
+	var changes []string
+	if req.MemoryMB != nil {
+		changes = append(changes, fmt.Sprintf("memory_mb=%d", *req.MemoryMB))
+	}
+	if req.VCPUs != nil {
+		changes = append(changes, fmt.Sprintf("vcpus=%d", *req.VCPUs))
+	}
+	if req.Name != nil {
+		changes = append(changes, fmt.Sprintf("name=%s", *req.Name))
+	}
+	if req.Autostart != nil {
+		changes = append(changes, fmt.Sprintf("autostart=%t", *req.Autostart))
+	}
+	if req.UserData != nil {
+		changes = append(changes, "user_data")
+	}
+	if len(req.BootOrder) > 0 {
+		changes = append(changes, fmt.Sprintf("boot_order=%s", strings.Join(req.BootOrder, ",")))
+	}
+	if req.DisableAPITermination != nil {
+		changes = append(changes, fmt.Sprintf("disable_api_termination=%t", *req.DisableAPITermination))
+	}
+	if req.Labels != nil {
+		changes = append(changes, "labels")
+	}
+	if req.Live {
+		changes = append(changes, "live=true")
+	}
+	return strings.Join(changes, " ")
+}
+
+// DescribeInstanceAttribute 查询实例属性，目前支持 userData 与 disableApiTermination
+func (s *InstanceService) DescribeInstanceAttribute(ctx context.Context, req *entity.DescribeInstanceAttributeRequest) (*entity.DescribeInstanceAttributeResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Str("attribute", req.Attribute).
+		Msg("Describing instance attribute")
+
+	if req.Attribute != entity.InstanceAttributeUserData && req.Attribute != entity.InstanceAttributeDisableAPITermination {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("Unsupported instance attribute: %s", req.Attribute),
+			http.StatusBadRequest,
+		)
+	}
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	if _, err := client.GetDomainByName(req.InstanceID); err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	resp := &entity.DescribeInstanceAttributeResponse{InstanceID: req.InstanceID}
+
+	if req.Attribute == entity.InstanceAttributeDisableAPITermination {
+		protected, err := s.protection.ProtectedInstances(req.NodeName)
+		if err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to load deletion protection", err)
+		}
+		disableAPITermination := protected[req.InstanceID]
+		resp.DisableAPITermination = &disableAPITermination
+		return resp, nil
+	}
+
+	userData, ok, err := s.readInstanceUserData(client, req.NodeName, req.InstanceID)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to read user data", err)
+	}
+	if !ok {
+		// 实例未使用 cloud-init
+		return resp, nil
+	}
+	resp.UserData = &userData
+
+	return resp, nil
+}
+
+// readInstanceUserData 读取实例的 user-data：来自 cidata ISO 或元数据服务中的记录，实例未使用 cloud-init 时 ok 为 false
+func (s *InstanceService) readInstanceUserData(client libvirt.LibvirtClient, nodeName, instanceID string) (string, bool, error) {
+	isoPath, err := findCloudInitISO(client, instanceID)
+	if err != nil {
+		return "", false, err
+	}
+	if isoPath != "" {
+		userData, err := client.ReadCloudInitUserData(isoPath)
+		if err != nil {
+			return "", false, err
+		}
+		return userData, true, nil
+	}
+	record, err := s.metadata.store.Get(nodeName, instanceID)
+	if err != nil || record == nil {
+		return "", false, err
+	}
+	return record.UserData, true, nil
+}
+
+// findCloudInitISO 查找实例挂载的 cidata ISO 路径，未挂载时返回空字符串
+func findCloudInitISO(client libvirt.DomainManager, instanceID string) (string, error) {
+	disks, err := client.GetDomainDisks(instanceID)
+	if err != nil {
+		return "", err
+	}
+	for _, disk := range disks {
+		if disk.Device == "cdrom" && libvirt.IsCloudInitVolume(disk.Source.File) {
+			return disk.Source.File, nil
+		}
+	}
+	return "", nil
+}
+
+// findPoolNameByPath 根据目录路径查找对应的存储池名称
+func findPoolNameByPath(client libvirt.StorageManager, dir string) (string, error) {
+	pools, err := client.ListStoragePools()
+	if err != nil {
+		return "", err
+	}
+	for _, pool := range pools {
+		if filepath.Clean(pool.Path) == filepath.Clean(dir) {
+			return pool.Name, nil
+		}
+	}
+	return "", fmt.Errorf("no storage pool found for path %s", dir)
+}
+
+// rebuildCloudInitISO 使用新的 user-data 重建实例的 cidata ISO，由元数据服务下发的实例更新其记录
+// meta-data 会重新生成 instance-id，使 cloud-init 在下次启动时将其视为新实例并重新执行 user-data
+func (s *InstanceService) rebuildCloudInitISO(ctx context.Context, client libvirt.LibvirtClient, nodeName, instanceID, userData string) error {
+	logger := zerolog.Ctx(ctx)
+
+	if strings.HasPrefix(userData, "#cloud-config") {
+		var parsed map[string]any
+		if err := yaml.Unmarshal([]byte(userData), &parsed); err != nil {
+			return apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("Invalid cloud-config user data: %v", err),
+				http.StatusBadRequest,
+			)
+		}
+	}
+
+	isoPath, err := findCloudInitISO(client, instanceID)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to get instance disks", err)
+	}
+	if isoPath == "" {
+		record, err := s.metadata.store.Get(nodeName, instanceID)
+		if err != nil {
+			return apierror.WrapError(apierror.ErrInternalError, "Failed to load instance metadata", err)
+		}
+		if record != nil {
+			metaData, err := cloudinit.NewGenerator().GenerateMetaData(instanceID)
+			if err != nil {
+				return apierror.WrapError(apierror.ErrInternalError, "Failed to generate meta-data", err)
+			}
+			record.MetaData = metaData
+			record.UserData = userData
+			if err := s.metadata.store.Set(nodeName, instanceID, record); err != nil {
+				return apierror.WrapError(apierror.ErrInternalError, "Failed to save instance metadata", err)
+			}
+			logger.Info().
+				Str("instanceID", instanceID).
+				Msg("Instance metadata user data updated")
+			return nil
+		}
+		return apierror.NewErrorWithStatus(
+			"InvalidParameter",
+			fmt.Sprintf("Instance %s was not created with cloud-init", instanceID),
+			http.StatusBadRequest,
+		)
+	}
+
+	metaData, err := cloudinit.NewGenerator().GenerateMetaData(instanceID)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to generate meta-data", err)
+	}
+
+	// cidata 卷位于实例创建时所在的存储池，按路径找到存储池后覆盖重建
+	poolName, err := findPoolNameByPath(client, filepath.Dir(isoPath))
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to find storage pool of cloud-init volume", err)
+	}
+
+	// 保留实例地址族对应的 network-config
+	domain, err := client.GetDomainByName(instanceID)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to get domain", err)
+	}
+	domainInfo, err := client.GetDomainInfo(domain.UUID)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to get domain info", err)
+	}
+	networkConfig, err := instanceNetworkConfig(domainInfo.AddressFamily)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to generate network-config", err)
+	}
+	if _, err := client.CreateCloudInitVolume(poolName, instanceID, metaData, userData, networkConfig); err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to rebuild cloud-init volume", err)
+	}
+
+	logger.Info().
+		Str("instanceID", instanceID).
+		Str("cloud_init_iso", isoPath).
+		Msg("Cloud-init ISO rebuilt")
+
+	return nil
+}
+
+// CompleteInstanceInstall 完成 ISO 安装：弹出安装 ISO 并将启动顺序切换为仅从系统盘启动
+// cloud-init 的 cidata ISO 不受影响；新的启动顺序在下次启动时生效
+func (s *InstanceService) CompleteInstanceInstall(ctx context.Context, req *entity.CompleteInstanceInstallRequest) (*entity.Instance, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Msg("Completing instance install")
+
+	// 获取节点的 libvirt 客户端
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	domain, err := client.GetDomainByName(req.InstanceID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	disks, err := client.GetDomainDisks(req.InstanceID)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get instance disks", err)
+	}
+
+	systemDisk := ""
+	var installISOs []libvirt.DomainDisk
+	for _, disk := range disks {
+		switch disk.Device {
+		case "disk":
+			if systemDisk == "" {
+				systemDisk = disk.Target.Dev
+			}
+		case "cdrom":
+			if disk.Source.File == "" || libvirt.IsCloudInitVolume(disk.Source.File) {
+				continue
+			}
+			installISOs = append(installISOs, disk)
+		}
+	}
+
+	if systemDisk == "" {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameter",
+			fmt.Sprintf("Instance %s has no system disk", req.InstanceID),
+			http.StatusBadRequest,
+		)
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CompleteInstanceInstall")
+	}
+
+	for _, disk := range installISOs {
+		if err := client.EjectDomainCDROM(req.InstanceID, disk.Target.Dev); err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to eject install ISO", err)
+		}
+		logger.Info().
+			Str("instanceID", req.InstanceID).
+			Str("device", disk.Target.Dev).
+			Str("iso", disk.Source.File).
+			Msg("Install ISO ejected")
+	}
+
+	if err := client.SetDomainBootOrder(domain, []string{systemDisk}); err != nil {
+		if errors.Is(err, libvirt.ErrDomainConfigConflict) {
+			return nil, newDomainConfigConflictError(req.InstanceID, err)
+		}
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to switch boot order", err)
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("bootDevice", systemDisk).
+		Msg("Instance install completed")
+
+	return s.GetInstance(ctx, req.NodeName, req.InstanceID)
 }
 
 // ResetPassword 重置实例密码，异步执行：
@@ -1219,6 +2512,8 @@ func (s *InstanceService) ModifyInstanceAttribute(ctx context.Context, req *enti
 // 2. cloud-init（失败则回退）
 // 3. virt-customize（最后选择，远程节点通过 SSH 调用）
 func (s *InstanceService) ResetPassword(ctx context.Context, req *entity.ResetPasswordRequest) (*entity.ResetPasswordResponse, error) {
+	// 各重置策略的尝试过程归档到实例的操作日志
+	ctx = instanceLogContext(ctx, req.NodeName, req.InstanceID)
 	logger := zerolog.Ctx(ctx)
 	logger.Info().
 		Str("node_name", req.NodeName).
@@ -1250,6 +2545,10 @@ func (s *InstanceService) ResetPassword(ctx context.Context, req *entity.ResetPa
 		userList = append(userList, user.Username)
 	}
 
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ResetPassword")
+	}
+
 	// 3. 异步执行重置
 	wasRunning := instance.State == "running"
 	reqCopy := *req
@@ -1379,16 +2678,12 @@ func (s *InstanceService) ResetPassword(ctx context.Context, req *entity.ResetPa
 			diskPath := disks[0].Source.File
 
 			if isRemote {
-				passwordArgs := make([]string, 0, len(usersMap)*2)
-				for user, pwd := range usersMap {
-					passwordArgs = append(passwordArgs, "--password", fmt.Sprintf("%s:password:%s", user, pwd))
-				}
-				checkCmd := fmt.Sprintf("test -f '%s'", diskPath)
-				if err := client.ExecuteRemoteCommand(checkCmd); err != nil {
-					resetErr = fmt.Errorf("validate remote disk path: %w", err)
+				sshTarget, err := client.GetSSHTarget()
+				if err != nil {
+					resetErr = fmt.Errorf("get ssh target: %w", err)
 				} else {
-					cmd := fmt.Sprintf("virt-customize -a '%s' %s", diskPath, strings.Join(passwordArgs, " "))
-					resetErr = client.ExecuteRemoteCommand(cmd)
+					remoteStrategy := NewVirtCustomizeStrategy(virtcustomize.NewRemoteClient(sshTarget), client)
+					resetErr = remoteStrategy.ResetPassword(ctxCopy, diskPath, usersMap)
 				}
 				if resetErr == nil {
 					strategyUsed = "virt-customize-remote"
@@ -1517,7 +2812,7 @@ func (s *InstanceService) ListVMTemplates(ctx context.Context, nodeName string)
 				VCPUs:       domainInfo.VCPUs,
 				Memory:      domainInfo.Memory / 1024, // 转换为 MB
 				DiskSize:    20,                       // 默认磁盘大小，可以后续优化从实际磁盘获取
-				CreatedAt:   time.Now().Format(time.RFC3339),
+				CreatedAt:   formatDomainTime(domainInfo.CreatedAt),
 			}
 			templates = append(templates, template)
 
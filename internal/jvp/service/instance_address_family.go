@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/cloudinit"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/rs/zerolog"
+)
+
+// ndpProxyUplinkCommand 取节点 IPv6 默认路由的出口网卡，NDP 代理条目加在该网卡上
+const ndpProxyUplinkCommand = `dev=$(ip -6 route show default | awk '{for (i = 1; i < NF; i++) if ($i == "dev") {print $(i+1); exit}}'); ` +
+	`[ -n "$dev" ] || { echo "no IPv6 default route on node" >&2; exit 1; }; `
+
+// validateAddressFamily 校验实例网卡的地址族
+// ipv6/dual 通过 cloud-init network-config 配置 guest，只支持基于 cloud-init 模板创建的实例；
+// libvirt 网络需配置了 IPv6 子网（由 dnsmasq 发送 RA 与 DHCPv6），bridge/direct 依赖外部网络提供 RA
+func validateAddressFamily(client libvirt.NetworkManager, req *entity.RunInstanceRequest, networkType, networkSource string, useIgnition bool) error {
+	switch req.AddressFamily {
+	case "", entity.AddressFamilyIPv4:
+		return nil
+	case entity.AddressFamilyIPv6, entity.AddressFamilyDual:
+	default:
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported address_family %q, must be ipv4, ipv6 or dual", req.AddressFamily),
+			http.StatusBadRequest,
+		)
+	}
+	if useIgnition || req.TemplateID == "" {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("address_family %s requires an instance created from a cloud-init based template", req.AddressFamily),
+			http.StatusBadRequest,
+		)
+	}
+	if networkType != "network" {
+		return nil
+	}
+	network, err := client.GetNetwork(networkSource)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to get network", err)
+	}
+	if network.IPv6Address == "" {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("network %s has no IPv6 subnet, create the network with ipv6_address to use address_family %s", networkSource, req.AddressFamily),
+			http.StatusBadRequest,
+		)
+	}
+	return nil
+}
+
+// instanceNetworkConfig 生成地址族对应的 cloud-init network-config，ipv4 返回空（沿用 cloud-init 默认的 DHCPv4）
+// IPv6 地址使用 EUI-64 生成，地址由 MAC 决定，便于在节点上添加 NDP 代理
+func instanceNetworkConfig(addressFamily string) (string, error) {
+	if addressFamily == "" || addressFamily == entity.AddressFamilyIPv4 {
+		return "", nil
+	}
+	acceptRA := true
+	return cloudinit.NewGenerator().GenerateNetworkConfig(&cloudinit.Network{
+		Ethernets: map[string]cloudinit.Ethernet{
+			"primary": {
+				Match:                 &cloudinit.EthernetMatch{Name: "e*"},
+				DHCP4:                 addressFamily == entity.AddressFamilyDual,
+				DHCP6:                 true,
+				AcceptRA:              &acceptRA,
+				IPv6AddressGeneration: "eui64",
+			},
+		},
+	})
+}
+
+// eui64Address 按 SLAAC 规则由 /64 前缀与 MAC 生成接口地址（翻转 U/L 位并插入 ff:fe）
+func eui64Address(prefix, mac string) (string, error) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return "", fmt.Errorf("invalid MAC address %q", mac)
+	}
+	ip := net.ParseIP(prefix).To16()
+	if ip == nil || ip.To4() != nil {
+		return "", fmt.Errorf("invalid IPv6 prefix %q", prefix)
+	}
+	addr := make(net.IP, net.IPv6len)
+	copy(addr, ip[:8])
+	copy(addr[8:], []byte{hw[0] ^ 0x02, hw[1], hw[2], 0xff, 0xfe, hw[3], hw[4], hw[5]})
+	return addr.String(), nil
+}
+
+// ndpProxyAddresses 计算实例在 libvirt 网络中的 EUI-64 地址，只处理 IPv6 前缀为 /64 的网络
+func ndpProxyAddresses(client libvirt.NetworkManager, interfaces []entity.InstanceInterface) ([]string, error) {
+	var addresses []string
+	for _, iface := range interfaces {
+		if iface.Type != "network" || iface.MAC == "" {
+			continue
+		}
+		network, err := client.GetNetwork(iface.Source)
+		if err != nil {
+			return nil, fmt.Errorf("get network %s: %w", iface.Source, err)
+		}
+		if network.IPv6Address == "" || network.IPv6Prefix != 64 {
+			continue
+		}
+		addr, err := eui64Address(network.IPv6Address, iface.MAC)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, addr)
+	}
+	return addresses, nil
+}
+
+// syncNDPProxy 在节点 IPv6 上行网卡上添加（add=true）或删除实例地址的 NDP 代理条目，
+// 使上游路由器能解析到 libvirt NAT 网络内的 guest 地址；仅在开启 JVP_IPV6_NDP_PROXY 时生效，失败只记录告警
+func (s *InstanceService) syncNDPProxy(ctx context.Context, client libvirt.LibvirtClient, instanceID, addressFamily string, interfaces []entity.InstanceInterface, add bool) {
+	if !s.ipv6NDPProxy || addressFamily == "" || addressFamily == entity.AddressFamilyIPv4 {
+		return
+	}
+	logger := zerolog.Ctx(ctx).With().Str("instance_id", instanceID).Logger()
+
+	addresses, err := ndpProxyAddresses(client, interfaces)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to resolve instance IPv6 addresses for NDP proxy")
+		return
+	}
+	for _, addr := range addresses {
+		command := ndpProxyUplinkCommand +
+			`sysctl -qw "net.ipv6.conf.$dev.proxy_ndp=1" && ip -6 neigh replace proxy ` + shellx.Quote(addr) + ` dev "$dev"`
+		if !add {
+			command = ndpProxyUplinkCommand + `ip -6 neigh del proxy ` + shellx.Quote(addr) + ` dev "$dev" 2>/dev/null || true`
+		}
+		if _, err := runNodeCommand(ctx, client, command); err != nil {
+			logger.Warn().
+				Err(err).
+				Str("address", addr).
+				Bool("add", add).
+				Msg("Failed to update NDP proxy entry")
+			continue
+		}
+		logger.Info().
+			Str("address", addr).
+			Bool("add", add).
+			Msg("NDP proxy entry updated")
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+)
+
+// UpdateInstanceDevice 修改实例已有设备的配置：更换/弹出光驱介质、修改磁盘 driver 参数、修改网卡限速
+// 统一通过 DomainUpdateDeviceFlags 下发，运行中实例 live+config 双写；磁盘 driver 参数只能写入持久化配置，下次启动生效
+func (s *InstanceService) UpdateInstanceDevice(ctx context.Context, req *entity.UpdateInstanceDeviceRequest) (*entity.UpdateInstanceDeviceResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Str("device_type", req.DeviceType).
+		Str("device", req.Device).
+		Msg("Updating instance device")
+
+	update := libvirt.DeviceUpdate{Type: req.DeviceType, Target: req.Device}
+	switch req.DeviceType {
+	case libvirt.DeviceTypeCDROM, libvirt.DeviceTypeDisk:
+		if req.Device == "" {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("device is required for %s update", req.DeviceType),
+				http.StatusBadRequest,
+			)
+		}
+		if req.DeviceType == libvirt.DeviceTypeDisk {
+			update.Driver = libvirt.DiskDriverOptions{Cache: req.Cache, IO: req.IO, Discard: req.Discard}
+		}
+		if req.DeviceType == libvirt.DeviceTypeCDROM && req.Media != "" && req.PoolName == "" {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				"pool_name is required when media is specified",
+				http.StatusBadRequest,
+			)
+		}
+	case libvirt.DeviceTypeInterface:
+		if req.Inbound == nil && req.Outbound == nil {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				"At least one of inbound or outbound is required",
+				http.StatusBadRequest,
+			)
+		}
+		update.Bandwidth = toLibvirtBandwidth(&entity.InterfaceBandwidth{Inbound: req.Inbound, Outbound: req.Outbound})
+	}
+	if err := update.Validate(); err != nil {
+		return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+	}
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	if req.DeviceType == libvirt.DeviceTypeInterface {
+		iface, err := findInstanceInterface(client, req.InstanceID, req.Device)
+		if err != nil {
+			return nil, err
+		}
+		update.Target = iface.MAC
+	} else {
+		if err := checkInstanceDisk(client, req.InstanceID, req.Device, req.DeviceType); err != nil {
+			return nil, err
+		}
+		if req.Media != "" {
+			volume, err := findPoolVolume(client, req.PoolName, req.Media)
+			if err != nil {
+				return nil, apierror.NewErrorWithStatus(
+					"ResourceNotFound",
+					fmt.Sprintf("Media %s not found in pool %s", req.Media, req.PoolName),
+					http.StatusNotFound,
+				)
+			}
+			update.Media = volume.Path
+		}
+	}
+
+	unlock, err := s.lockInstanceVersion(ctx, req.NodeName, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "UpdateInstanceDevice")
+	}
+
+	live, err := client.UpdateDomainDevice(req.InstanceID, update)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to update instance device", err)
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device_type", req.DeviceType).
+		Str("device", update.Target).
+		Bool("live", live).
+		Msg("Instance device updated")
+
+	s.recordEvent(ctx, req.NodeName, req.InstanceID, entity.InstanceEventModified, "",
+		fmt.Sprintf("%s %s updated", req.DeviceType, update.Target))
+
+	return &entity.UpdateInstanceDeviceResponse{
+		InstanceID: req.InstanceID,
+		DeviceType: req.DeviceType,
+		Device:     update.Target,
+		Live:       live,
+	}, nil
+}
+
+// checkInstanceDisk 校验实例存在指定设备名且设备类型匹配（disk / cdrom）
+func checkInstanceDisk(client libvirt.DomainManager, instanceID, device, deviceType string) error {
+	if _, err := client.GetDomainByName(instanceID); err != nil {
+		return apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", instanceID),
+			http.StatusNotFound,
+		)
+	}
+	disks, err := client.GetDomainDisks(instanceID)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to get instance disks", err)
+	}
+	for _, disk := range disks {
+		if disk.Target.Dev != device {
+			continue
+		}
+		if disk.Device != deviceType {
+			return apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("Device %s of instance %s is a %s, not a %s", device, instanceID, disk.Device, deviceType),
+				http.StatusBadRequest,
+			)
+		}
+		return nil
+	}
+	return apierror.NewErrorWithStatus(
+		"ResourceNotFound",
+		fmt.Sprintf("Device %s not found in instance %s", device, instanceID),
+		http.StatusNotFound,
+	)
+}
+
+// toLibvirtDiskDriver 把 API 的磁盘 driver 参数转换为 libvirt 配置
+func toLibvirtDiskDriver(o *entity.DiskDriverOptions) *libvirt.DiskDriverOptions {
+	if o == nil {
+		return nil
+	}
+	return &libvirt.DiskDriverOptions{Cache: o.Cache, IO: o.IO, Discard: o.Discard}
+}
@@ -0,0 +1,571 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	libvirtlib "github.com/digitalocean/go-libvirt"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/rs/zerolog"
+)
+
+// FlattenInstanceDisk 把 backing 链数据合入实例磁盘，解除对模板等 backing file 的依赖
+// 运行中实例通过 blockpull 在线执行（异步，重复调用同一磁盘查询进度），关机实例通过 qemu-img rebase 离线执行
+func (s *InstanceService) FlattenInstanceDisk(ctx context.Context, req *entity.FlattenInstanceDiskRequest) (*entity.FlattenInstanceDiskResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Str("device", req.Device).
+		Str("mode", req.Mode).
+		Msg("Flattening instance disk")
+
+	if req.Mode != "" && req.Mode != entity.FlattenModeOnline && req.Mode != entity.FlattenModeOffline {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("mode must be %s or %s", entity.FlattenModeOnline, entity.FlattenModeOffline),
+			http.StatusBadRequest,
+		)
+	}
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	domain, err := client.GetDomainByName(req.InstanceID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	disk, err := findFlattenDisk(client, req.InstanceID, req.Device)
+	if err != nil {
+		return nil, err
+	}
+
+	state, _, err := client.GetDomainState(domain)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get instance state", err)
+	}
+	running := libvirtlib.DomainState(state) == libvirtlib.DomainRunning
+	shutoff := libvirtlib.DomainState(state) == libvirtlib.DomainShutoff
+
+	mode := req.Mode
+	if mode == "" {
+		mode = entity.FlattenModeOffline
+		if running {
+			mode = entity.FlattenModeOnline
+		}
+	}
+	if mode == entity.FlattenModeOnline && !running {
+		return nil, apierror.NewErrorWithStatus(
+			"IncorrectInstanceState",
+			fmt.Sprintf("Instance %s must be running to flatten disks online", req.InstanceID),
+			http.StatusConflict,
+		)
+	}
+	if mode == entity.FlattenModeOffline && !shutoff {
+		return nil, apierror.NewErrorWithStatus(
+			"IncorrectInstanceState",
+			fmt.Sprintf("Instance %s must be stopped to flatten disks offline", req.InstanceID),
+			http.StatusConflict,
+		)
+	}
+
+	qemuClient := newQemuImgClient(client)
+	backingPath, err := qemuClient.GetBackingFile(ctx, disk.Source.File)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to read disk backing file", err)
+	}
+
+	resp := &entity.FlattenInstanceDiskResponse{
+		InstanceID:  req.InstanceID,
+		Device:      disk.Target.Dev,
+		Path:        disk.Source.File,
+		Mode:        mode,
+		BackingPath: backingPath,
+	}
+
+	// 在线模式下已有 blockpull 在执行时直接返回进度
+	if mode == entity.FlattenModeOnline {
+		job, err := client.GetBlockJob(req.InstanceID, disk.Target.Dev)
+		if err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get block job", err)
+		}
+		if job != nil {
+			if job.Type != libvirt.BlockJobTypePull {
+				return nil, apierror.NewErrorWithStatus(
+					"IncorrectInstanceState",
+					fmt.Sprintf("Another %s block job is running on device %s of instance %s", job.Type, disk.Target.Dev, req.InstanceID),
+					http.StatusConflict,
+				)
+			}
+			resp.Status = entity.DiskJobStatusRunning
+			resp.Progress = job.Progress()
+			return resp, nil
+		}
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "FlattenInstanceDisk")
+	}
+
+	if backingPath == "" {
+		resp.Status = entity.DiskJobStatusCompleted
+		resp.Progress = 100
+		return resp, nil
+	}
+
+	if mode == entity.FlattenModeOffline {
+		if err := qemuClient.Rebase(ctx, disk.Source.File, "", ""); err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to flatten disk", err)
+		}
+		resp.Status = entity.DiskJobStatusCompleted
+		resp.BackingPath = ""
+		resp.Progress = 100
+
+		logger.Info().
+			Str("instanceID", req.InstanceID).
+			Str("device", disk.Target.Dev).
+			Str("backing_path", backingPath).
+			Msg("Instance disk flattened offline")
+		return resp, nil
+	}
+
+	if err := client.BlockPull(req.InstanceID, disk.Target.Dev, s.transfer.resolve(req.BandwidthMiB)); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to start block pull", err)
+	}
+
+	job, err := client.GetBlockJob(req.InstanceID, disk.Target.Dev)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get block job", err)
+	}
+	if job == nil {
+		// 小磁盘可能在查询前已完成
+		resp.Status = entity.DiskJobStatusCompleted
+		resp.BackingPath = ""
+		resp.Progress = 100
+	} else {
+		resp.Status = entity.DiskJobStatusRunning
+		resp.Progress = job.Progress()
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device", disk.Target.Dev).
+		Str("backing_path", backingPath).
+		Str("status", resp.Status).
+		Msg("Instance disk block pull started")
+
+	return resp, nil
+}
+
+// findFlattenDisk 查找要扁平化的磁盘，device 为空时使用系统盘（第一块 disk 设备）
+func findFlattenDisk(client libvirt.DomainManager, instanceID, device string) (*libvirt.DomainDisk, error) {
+	disks, err := client.GetDomainDisks(instanceID)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get instance disks", err)
+	}
+
+	for i := range disks {
+		if disks[i].Device != "disk" || disks[i].Source.File == "" {
+			continue
+		}
+		if device == "" || disks[i].Target.Dev == device {
+			return &disks[i], nil
+		}
+	}
+
+	if device == "" {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("Instance %s has no file-backed disk", instanceID),
+			http.StatusBadRequest,
+		)
+	}
+	return nil, apierror.NewErrorWithStatus(
+		"InvalidParameterValue",
+		fmt.Sprintf("Device %s of instance %s is not a file-backed disk", device, instanceID),
+		http.StatusBadRequest,
+	)
+}
+
+// MigrateInstanceStorage 通过 blockcopy 把运行中实例的磁盘在线迁移到同一节点的其他存储池
+// 复制在后台执行，数据同步完成后 pivot 到新文件并更新持久化配置，业务不中断；
+// 目标文件为完整副本，不再依赖源磁盘的 backing 链。重复调用同一请求返回各磁盘进度
+func (s *InstanceService) MigrateInstanceStorage(ctx context.Context, req *entity.MigrateInstanceStorageRequest) (*entity.MigrateInstanceStorageResponse, error) {
+	// 迁移过程（含后台的块复制任务）归档到实例的操作日志
+	ctx = instanceLogContext(ctx, req.NodeName, req.InstanceID)
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Str("target_pool", req.TargetPool).
+		Strs("devices", req.Devices).
+		Msg("Migrating instance storage")
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	domain, err := client.GetDomainByName(req.InstanceID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	state, _, err := client.GetDomainState(domain)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get instance state", err)
+	}
+	if libvirtlib.DomainState(state) != libvirtlib.DomainRunning {
+		return nil, apierror.NewErrorWithStatus(
+			"IncorrectInstanceState",
+			fmt.Sprintf("Instance %s must be running to migrate storage online", req.InstanceID),
+			http.StatusConflict,
+		)
+	}
+
+	targetPool, err := client.GetStoragePool(req.TargetPool)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Storage pool %s not found", req.TargetPool),
+			http.StatusNotFound,
+		)
+	}
+	targetDir := strings.TrimSuffix(targetPool.Path, "/") + "/"
+
+	disks, err := selectMigrationDisks(client, req.InstanceID, req.Devices)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &entity.MigrateInstanceStorageResponse{
+		InstanceID: req.InstanceID,
+		TargetPool: req.TargetPool,
+		Disks:      make([]entity.DiskMigration, 0, len(disks)),
+	}
+
+	// 逐块确认状态：已在目标池的视为完成，已有 copy 任务的返回进度，其余需要启动复制
+	var pending []entity.DiskMigration
+	var requiredB uint64
+	for _, disk := range disks {
+		migration := entity.DiskMigration{
+			Device:     disk.Target.Dev,
+			SourcePath: disk.Source.File,
+			TargetPath: targetDir + path.Base(disk.Source.File),
+		}
+
+		job, err := client.GetBlockJob(req.InstanceID, disk.Target.Dev)
+		if err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get block job", err)
+		}
+		switch {
+		case job != nil && job.Type == libvirt.BlockJobTypeCopy:
+			migration.Status = entity.DiskJobStatusRunning
+			migration.Progress = job.Progress()
+		case job != nil:
+			return nil, apierror.NewErrorWithStatus(
+				"IncorrectInstanceState",
+				fmt.Sprintf("Another %s block job is running on device %s of instance %s", job.Type, disk.Target.Dev, req.InstanceID),
+				http.StatusConflict,
+			)
+		case strings.HasPrefix(disk.Source.File, targetDir):
+			migration.TargetPath = disk.Source.File
+			migration.Status = entity.DiskJobStatusCompleted
+			migration.Progress = 100
+		default:
+			if _, err := client.GetVolume(req.TargetPool, path.Base(disk.Source.File)); err == nil {
+				return nil, newResourceAlreadyExistsError("Volume", migration.TargetPath)
+			}
+			migration.Status = entity.DiskJobStatusRunning
+			requiredB += disk.CapacityB
+		}
+		resp.Disks = append(resp.Disks, migration)
+		if migration.Status == entity.DiskJobStatusRunning && job == nil {
+			pending = append(pending, migration)
+		}
+	}
+
+	if len(pending) > 0 {
+		if err := checkPoolCapacity(client, req.TargetPool, (requiredB+(1<<30)-1)>>30); err != nil {
+			return nil, err
+		}
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "MigrateInstanceStorage")
+	}
+	if len(pending) == 0 {
+		return resp, nil
+	}
+
+	// 先全部启动复制，任一失败则取消已启动的任务，避免部分磁盘处于镜像状态
+	for i, migration := range pending {
+		err := client.BlockCopy(req.InstanceID, migration.Device, libvirt.BlockCopyConfig{
+			DestPath:     migration.TargetPath,
+			DestFormat:   "qcow2",
+			BandwidthMiB: s.transfer.resolve(req.BandwidthMiB),
+		})
+		if err == nil {
+			continue
+		}
+		for _, started := range pending[:i] {
+			if abortErr := client.AbortBlockJob(req.InstanceID, started.Device, false); abortErr != nil {
+				logger.Warn().
+					Err(abortErr).
+					Str("device", started.Device).
+					Msg("Failed to cancel block copy")
+			}
+		}
+		return nil, apierror.WrapError(apierror.ErrInternalError, fmt.Sprintf("Failed to start block copy for %s", migration.Device), err)
+	}
+
+	instanceID := req.InstanceID
+	targetPoolName := req.TargetPool
+	deleteSource := req.DeleteSource
+	ctxCopy := context.WithoutCancel(ctx)
+	s.asyncRun(func() {
+		for _, migration := range pending {
+			completeDiskMigration(ctxCopy, client, instanceID, migration, deleteSource)
+		}
+		if err := client.RefreshStoragePool(targetPoolName); err != nil {
+			zerolog.Ctx(ctxCopy).Warn().
+				Err(err).
+				Str("pool", targetPoolName).
+				Msg("Failed to refresh target storage pool")
+		}
+	})
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Int("disk_count", len(pending)).
+		Msg("Instance storage migration started")
+
+	devices := make([]string, 0, len(pending))
+	for _, migration := range pending {
+		devices = append(devices, migration.Device)
+	}
+	s.recordEvent(ctx, req.NodeName, req.InstanceID, entity.InstanceEventMigrated, "",
+		fmt.Sprintf("storage migration of %s to pool %s started", strings.Join(devices, ","), req.TargetPool))
+
+	return resp, nil
+}
+
+// completeDiskMigration 等待 blockcopy 同步完成后 pivot 到目标文件，并同步持久化配置
+func completeDiskMigration(ctx context.Context, client libvirt.LibvirtClient, instanceID string, migration entity.DiskMigration, deleteSource bool) {
+	logger := zerolog.Ctx(ctx).With().
+		Str("instanceID", instanceID).
+		Str("device", migration.Device).
+		Str("target_path", migration.TargetPath).
+		Logger()
+
+	job, err := client.WaitBlockJob(ctx, instanceID, migration.Device, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("Block copy failed")
+		if abortErr := client.AbortBlockJob(instanceID, migration.Device, false); abortErr != nil {
+			logger.Warn().Err(abortErr).Msg("Failed to cancel block copy")
+		}
+		return
+	}
+	if job == nil {
+		// 复制被 CancelTask 取消或异常结束，实例继续使用源文件，删除未完成的目标文件
+		logger.Error().Msg("Block copy ended before it was ready, disk left on source")
+		if _, err := runNodeCommand(ctx, client, "rm -f "+shellx.Quote(migration.TargetPath)); err != nil {
+			logger.Warn().Err(err).Msg("Failed to remove partial target disk")
+		}
+		return
+	}
+
+	if err := client.AbortBlockJob(instanceID, migration.Device, true); err != nil {
+		logger.Error().Err(err).Msg("Failed to pivot to target disk")
+		return
+	}
+	if err := client.SetDomainDiskSource(instanceID, migration.Device, migration.TargetPath, "qcow2"); err != nil {
+		// 运行时已经切换，持久化配置未更新时重启会回到源文件，源文件需保留
+		logger.Error().Err(err).Msg("Failed to update persistent disk source, keeping source file")
+		return
+	}
+
+	if deleteSource {
+		if err := client.DeleteVolumeByPath(migration.SourcePath); err != nil {
+			logger.Warn().
+				Err(err).
+				Str("source_path", migration.SourcePath).
+				Msg("Failed to delete source disk")
+		}
+	}
+
+	logger.Info().Msg("Disk migrated to target storage pool")
+}
+
+// selectMigrationDisks 选择要迁移的磁盘，devices 为空时返回所有基于文件的磁盘
+func selectMigrationDisks(client libvirt.DomainManager, instanceID string, devices []string) ([]libvirt.DomainDisk, error) {
+	disks, err := client.GetDomainDisks(instanceID)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get instance disks", err)
+	}
+
+	var result []libvirt.DomainDisk
+	for _, disk := range disks {
+		if disk.Device != "disk" || disk.Source.File == "" {
+			continue
+		}
+		if len(devices) == 0 || slices.Contains(devices, disk.Target.Dev) {
+			result = append(result, disk)
+		}
+	}
+
+	for _, device := range devices {
+		if !slices.ContainsFunc(result, func(d libvirt.DomainDisk) bool { return d.Target.Dev == device }) {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("Device %s of instance %s is not a file-backed disk", device, instanceID),
+				http.StatusBadRequest,
+			)
+		}
+	}
+	if len(result) == 0 {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("Instance %s has no file-backed disk", instanceID),
+			http.StatusBadRequest,
+		)
+	}
+	return result, nil
+}
+
+// ModifyDiskJobBandwidth 调整磁盘上正在执行的 block job（迁移存储、在线扁平化、快照合并）限速，立即生效
+func (s *InstanceService) ModifyDiskJobBandwidth(ctx context.Context, req *entity.ModifyDiskJobBandwidthRequest) (*entity.ModifyDiskJobBandwidthResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Str("device", req.Device).
+		Uint64("bandwidth_mib", req.BandwidthMiB).
+		Msg("Modifying disk job bandwidth")
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	if _, err := client.GetDomainByName(req.InstanceID); err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	job, err := client.GetBlockJob(req.InstanceID, req.Device)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get block job", err)
+	}
+	if job == nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("No block job is running on device %s of instance %s", req.Device, req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ModifyDiskJobBandwidth")
+	}
+
+	if err := client.SetBlockJobSpeed(req.InstanceID, req.Device, req.BandwidthMiB); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to set block job bandwidth", err)
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("device", req.Device).
+		Str("job_type", job.Type).
+		Uint64("bandwidth_mib", req.BandwidthMiB).
+		Msg("Disk job bandwidth modified")
+
+	return &entity.ModifyDiskJobBandwidthResponse{
+		InstanceID:   req.InstanceID,
+		Device:       req.Device,
+		JobType:      job.Type,
+		BandwidthMiB: req.BandwidthMiB,
+		Progress:     job.Progress(),
+	}, nil
+}
+
+// CancelDiskJob 取消磁盘上正在执行的 block job：迁移存储（blockcopy）与在线扁平化（blockpull）
+// blockcopy 取消后实例继续使用源文件，未完成的目标文件由迁移任务删除；blockpull 取消后已合入的数据保留，backing 链不变。
+// 快照合并（blockcommit）由快照删除同步等待，中途取消会破坏 backing 链，不支持取消
+func (s *InstanceService) CancelDiskJob(ctx context.Context, nodeName, instanceID, device string) (string, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", nodeName).
+		Str("instanceID", instanceID).
+		Str("device", device).
+		Msg("Cancelling disk job")
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, nodeName)
+	if err != nil {
+		return "", apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	if _, err := client.GetDomainByName(instanceID); err != nil {
+		return "", apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", instanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	job, err := client.GetBlockJob(instanceID, device)
+	if err != nil {
+		return "", apierror.WrapError(apierror.ErrInternalError, "Failed to get block job", err)
+	}
+	if job == nil {
+		return "", apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("No block job is running on device %s of instance %s", device, instanceID),
+			http.StatusNotFound,
+		)
+	}
+	if job.Type != libvirt.BlockJobTypeCopy && job.Type != libvirt.BlockJobTypePull {
+		return "", apierror.NewErrorWithStatus(
+			"IncorrectTaskState",
+			fmt.Sprintf("The %s block job on device %s of instance %s cannot be cancelled", job.Type, device, instanceID),
+			http.StatusConflict,
+		)
+	}
+
+	if isDryRun(ctx) {
+		return "", dryRunOperation(ctx, "CancelDiskJob")
+	}
+
+	if err := client.AbortBlockJob(instanceID, device, false); err != nil {
+		return "", apierror.WrapError(apierror.ErrInternalError, "Failed to cancel block job", err)
+	}
+
+	logger.Info().
+		Str("instanceID", instanceID).
+		Str("device", device).
+		Str("job_type", job.Type).
+		Int("progress", job.Progress()).
+		Msg("Disk job cancelled")
+	return job.Type, nil
+}
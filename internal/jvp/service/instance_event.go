@@ -0,0 +1,272 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/rs/zerolog"
+)
+
+// OperatorContextKey 请求操作者在上下文中的 key，由 API 中间件写入
+const OperatorContextKey = "jvp.operator"
+
+// maxInstanceEvents 每个实例保留的最大事件数，超出后丢弃最旧的事件
+const maxInstanceEvents = 500
+
+// InstanceEventStore 实例事件历史存储
+// 每个实例一个 JSON Lines 文件：<dataDir>/instance-events/<node>/<instance>.jsonl
+type InstanceEventStore struct {
+	storageDir string
+	mu         sync.Mutex
+}
+
+// NewInstanceEventStore 创建实例事件存储
+func NewInstanceEventStore(dataDir string) (*InstanceEventStore, error) {
+	storageDir := filepath.Join(dataDir, "instance-events")
+	if err := os.MkdirAll(storageDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create instance events directory: %w", err)
+	}
+	return &InstanceEventStore{storageDir: storageDir}, nil
+}
+
+// getEventsPath 获取实例事件文件路径
+func (s *InstanceEventStore) getEventsPath(nodeName, instanceID string) string {
+	return filepath.Join(s.storageDir, nodeName, instanceID+".jsonl")
+}
+
+// Append 追加一条事件，超出上限时重写文件只保留最近的事件
+func (s *InstanceEventStore) Append(event *entity.InstanceEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.getEventsPath(event.NodeName, event.InstanceID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create instance events directory: %w", err)
+	}
+
+	events, err := s.listUnlocked(path)
+	if err != nil {
+		return err
+	}
+	events = append(events, *event)
+
+	if len(events) <= maxInstanceEvents {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal instance event: %w", err)
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open instance events file: %w", err)
+		}
+		defer f.Close()
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("failed to write instance event: %w", err)
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, e := range events[len(events)-maxInstanceEvents:] {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to marshal instance event: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write instance events file: %w", err)
+	}
+	return nil
+}
+
+// List 按时间先后返回实例的全部事件，实例没有事件时返回空列表
+func (s *InstanceEventStore) List(nodeName, instanceID string) ([]entity.InstanceEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listUnlocked(s.getEventsPath(nodeName, instanceID))
+}
+
+func (s *InstanceEventStore) listUnlocked(path string) ([]entity.InstanceEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open instance events file: %w", err)
+	}
+	defer f.Close()
+
+	var events []entity.InstanceEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var event entity.InstanceEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			// 跳过损坏的行（如写入中途崩溃），不影响其余历史
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read instance events file: %w", err)
+	}
+	return events, nil
+}
+
+// operatorFromContext 获取请求操作者，未设置时视为系统操作
+func operatorFromContext(ctx context.Context) string {
+	if operator, ok := ctx.Value(OperatorContextKey).(string); ok && operator != "" {
+		return operator
+	}
+	return entity.InstanceEventOperatorSystem
+}
+
+// recordEvent 记录实例事件，同时写入资源变化流
+// 事件历史只用于审计和排障，写入失败只记录日志，不影响实例操作本身
+func (s *InstanceService) recordEvent(ctx context.Context, nodeName, instanceID, eventType, state, message string) {
+	s.changes.Record(entity.ResourceTypeInstance, nodeName, instanceID, instanceChangeAction(eventType))
+	if s.events == nil {
+		return
+	}
+	event := &entity.InstanceEvent{
+		InstanceID: instanceID,
+		NodeName:   nodeName,
+		Type:       eventType,
+		State:      state,
+		Operator:   operatorFromContext(ctx),
+		Message:    message,
+		Timestamp:  time.Now(),
+	}
+	if err := s.events.Append(event); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).
+			Str("instanceID", instanceID).
+			Str("event", eventType).
+			Msg("Failed to record instance event")
+	}
+}
+
+// instanceChangeAction 实例事件对应的资源变化类型
+func instanceChangeAction(eventType string) string {
+	switch eventType {
+	case entity.InstanceEventCreated, entity.InstanceEventRestored:
+		return entity.ResourceChangeAdded
+	case entity.InstanceEventTerminated:
+		return entity.ResourceChangeDeleted
+	default:
+		return entity.ResourceChangeModified
+	}
+}
+
+// DescribeInstanceEvents 查询实例事件历史（按时间倒序）
+// 查询前先对比 libvirt 当前状态与最后记录的状态，不一致时补记一条系统事件，
+// 用于捕获 guest 内关机、崩溃等绕过 API 的状态变化
+func (s *InstanceService) DescribeInstanceEvents(ctx context.Context, req *entity.DescribeInstanceEventsRequest) (*entity.DescribeInstanceEventsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Strs("event_types", req.EventTypes).
+		Msg("Describing instance events")
+
+	if s.events == nil {
+		return &entity.DescribeInstanceEventsResponse{Events: []entity.InstanceEvent{}}, nil
+	}
+
+	events, err := s.events.List(req.NodeName, req.InstanceID)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list instance events", err)
+	}
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	domain, err := client.GetDomainByName(req.InstanceID)
+	if err != nil {
+		// 已删除的实例仍可查询历史
+		if len(events) == 0 {
+			return nil, apierror.NewErrorWithStatus(
+				"ResourceNotFound",
+				fmt.Sprintf("Instance %s not found", req.InstanceID),
+				http.StatusNotFound,
+			)
+		}
+	} else if state, _, err := client.GetDomainState(domain); err == nil {
+		if event := reconcileInstanceState(req.NodeName, req.InstanceID, events, state); event != nil {
+			if err := s.events.Append(event); err != nil {
+				logger.Warn().Err(err).Str("instanceID", req.InstanceID).Msg("Failed to record instance event")
+			}
+			events = append(events, *event)
+		}
+	}
+
+	var typeSet map[string]bool
+	if len(req.EventTypes) > 0 {
+		typeSet = make(map[string]bool, len(req.EventTypes))
+		for _, t := range req.EventTypes {
+			typeSet[t] = true
+		}
+	}
+
+	result := make([]entity.InstanceEvent, 0, len(events))
+	for i := len(events) - 1; i >= 0; i-- {
+		if typeSet != nil && !typeSet[events[i].Type] {
+			continue
+		}
+		result = append(result, events[i])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.After(result[j].Timestamp)
+	})
+	if req.MaxResults > 0 && len(result) > req.MaxResults {
+		result = result[:req.MaxResults]
+	}
+
+	return &entity.DescribeInstanceEventsResponse{Events: result}, nil
+}
+
+// reconcileInstanceState 对比当前状态与最后记录的状态，不一致时返回需要补记的事件
+func reconcileInstanceState(nodeName, instanceID string, events []entity.InstanceEvent, domainState uint8) *entity.InstanceEvent {
+	current := convertDomainState(domainState)
+	var last string
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].State != "" {
+			last = events[i].State
+			break
+		}
+	}
+	if last == "" || last == current {
+		return nil
+	}
+
+	eventType := entity.InstanceEventStateChanged
+	if current == "failed" {
+		eventType = entity.InstanceEventCrashed
+	}
+	return &entity.InstanceEvent{
+		InstanceID: instanceID,
+		NodeName:   nodeName,
+		Type:       eventType,
+		State:      current,
+		Operator:   entity.InstanceEventOperatorSystem,
+		Message:    fmt.Sprintf("state changed from %s to %s", last, current),
+		Timestamp:  time.Now(),
+	}
+}
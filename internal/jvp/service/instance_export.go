@@ -0,0 +1,459 @@
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	libvirtlib "github.com/digitalocean/go-libvirt"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/rs/zerolog"
+)
+
+// ExportsDirName 实例导出文件存放的目录名（位于存储池根目录下）
+const ExportsDirName = "_exports_"
+
+// partialExportSuffix 转换中的导出文件后缀，转换完成后才重命名为正式文件，避免下载到不完整的镜像
+const partialExportSuffix = ".partial"
+
+// isExportVolume 判断卷是否为导出目录或其中的文件
+func isExportVolume(volInfo *libvirt.VolumeInfo) bool {
+	return volInfo.Name == ExportsDirName || strings.Contains(volInfo.Path, "/"+ExportsDirName+"/")
+}
+
+// InstanceExportDownload 一次导出文件的下载，ova 在下载时把 OVF 与 VMDK 打包为 tar
+type InstanceExportDownload struct {
+	FileName string // 下载文件名
+	SizeB    int64  // 响应体大小
+
+	stream func(w io.Writer) error
+}
+
+// Stream 把导出文件写入 w
+func (d *InstanceExportDownload) Stream(w io.Writer) error {
+	return d.stream(w)
+}
+
+// ExportInstance 导出实例系统盘：实例已停止时导出当前磁盘，指定快照时导出快照时刻的状态
+// 导出文件合并整条 backing 链，写入存储池的 _exports_ 目录，通过 download_url 流式下载
+func (s *InstanceService) ExportInstance(ctx context.Context, req *entity.ExportInstanceRequest) (*entity.InstanceExport, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Str("pool_name", req.PoolName).
+		Str("format", req.Format).
+		Str("snapshot_name", req.SnapshotName).
+		Msg("Exporting instance")
+
+	format := req.Format
+	if format == "" {
+		format = entity.InstanceExportFormatQCOW2
+	}
+	if format != entity.InstanceExportFormatQCOW2 && format != entity.InstanceExportFormatOVA {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported export format %q, must be qcow2 or ova", format),
+			http.StatusBadRequest,
+		)
+	}
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+	domain, err := client.GetDomainByName(req.InstanceID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+	pool, err := client.GetStoragePool(req.PoolName)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Storage pool %s not found", req.PoolName),
+			http.StatusNotFound,
+		)
+	}
+
+	sourcePath, sourceFormat, err := s.exportSource(ctx, client, domain, req.SnapshotName)
+	if err != nil {
+		return nil, err
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ExportInstance")
+	}
+
+	exportID := fmt.Sprintf("%s-%s", req.InstanceID, time.Now().UTC().Format("20060102150405"))
+	exportDir := filepath.Join(pool.Path, ExportsDirName)
+
+	// qcow2 开启压缩减小下载体积；OVA 使用 streamOptimized VMDK，这是 VMware/VirtualBox 导入 OVA 时要求的子格式
+	diskPath := filepath.Join(exportDir, exportID+".qcow2")
+	convertArgs := "-O qcow2 -c"
+	if format == entity.InstanceExportFormatOVA {
+		diskPath = filepath.Join(exportDir, exportID+".vmdk")
+		convertArgs = "-O vmdk -o subformat=streamOptimized"
+	}
+	if err := convertExportDisk(ctx, client, sourcePath, sourceFormat, convertArgs, diskPath); err != nil {
+		return nil, err
+	}
+
+	size, err := nodeFileSize(ctx, client, diskPath)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to stat export file", err)
+	}
+
+	if format == entity.InstanceExportFormatOVA {
+		ovfSize, err := s.writeExportOVF(ctx, client, domain, exportDir, exportID, diskPath, size)
+		if err != nil {
+			_, _ = runNodeCommand(context.WithoutCancel(ctx), client, "rm -f "+shellx.Quote(diskPath))
+			return nil, err
+		}
+		size += ovfSize
+	}
+
+	export := &entity.InstanceExport{
+		ExportID:     exportID,
+		NodeName:     req.NodeName,
+		PoolName:     req.PoolName,
+		InstanceID:   req.InstanceID,
+		Format:       format,
+		SnapshotName: req.SnapshotName,
+		SizeB:        uint64(size),
+		DownloadURL: fmt.Sprintf("/api/download-instance-export/%s/%s/%s",
+			url.PathEscape(req.NodeName), url.PathEscape(req.PoolName), url.PathEscape(exportID)),
+	}
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("export_id", exportID).
+		Int64("size_b", size).
+		Msg("Instance exported successfully")
+	return export, nil
+}
+
+// exportSource 确定导出的源磁盘与格式
+// 指定快照时使用快照 overlay 的 backing file（快照时刻的状态，只读，实例运行中也可读取）；否则要求实例已停止
+func (s *InstanceService) exportSource(ctx context.Context, client libvirt.LibvirtClient, domain libvirtlib.Domain, snapshotName string) (string, string, error) {
+	if snapshotName != "" {
+		snap, err := client.GetSnapshotXML(domain.Name, snapshotName)
+		if err != nil {
+			return "", "", apierror.NewErrorWithStatus(
+				"ResourceNotFound",
+				fmt.Sprintf("Snapshot %s of instance %s not found", snapshotName, domain.Name),
+				http.StatusNotFound,
+			)
+		}
+		return snapshotDiskSource(ctx, client, snap, "")
+	}
+
+	state, _, err := client.GetDomainState(domain)
+	if err != nil {
+		return "", "", apierror.WrapError(apierror.ErrInternalError, "Failed to get instance state", err)
+	}
+	if libvirtlib.DomainState(state) != libvirtlib.DomainShutoff {
+		return "", "", apierror.NewErrorWithStatus(
+			"IncorrectInstanceState",
+			fmt.Sprintf("Instance %s must be stopped to export its disk, or specify snapshot_name to export from a snapshot", domain.Name),
+			http.StatusConflict,
+		)
+	}
+	disks, err := client.GetDomainDisks(domain.Name)
+	if err != nil {
+		return "", "", apierror.WrapError(apierror.ErrInternalError, "Failed to get instance disks", err)
+	}
+	for _, disk := range disks {
+		if disk.Device == "disk" && disk.Source.File != "" {
+			format := disk.Driver.Type
+			if format == "" {
+				format = "qcow2"
+			}
+			return disk.Source.File, format, nil
+		}
+	}
+	return "", "", apierror.WrapError(apierror.ErrInternalError, "Instance has no file-backed system disk", nil)
+}
+
+// snapshotDiskSource 返回快照中磁盘在快照时刻的状态：快照 overlay 的 backing file，overlay 没有 backing file 时为其本身
+// target 为空时取第一块文件磁盘
+func snapshotDiskSource(ctx context.Context, client libvirt.RemoteManager, snap *libvirt.DomainSnapshotXML, target string) (string, string, error) {
+	for _, disk := range snap.Disks {
+		if disk.Source == nil || disk.Source.File == "" || (target != "" && disk.Name != target) {
+			continue
+		}
+		qemuClient := newQemuImgClient(client)
+		source, err := qemuClient.GetBackingFile(ctx, disk.Source.File)
+		if err != nil {
+			return "", "", apierror.WrapError(apierror.ErrInternalError, "Failed to get snapshot backing file", err)
+		}
+		if source == "" {
+			source = disk.Source.File
+		}
+		format, err := qemuClient.GetFormat(ctx, source)
+		if err != nil {
+			return "", "", apierror.WrapError(apierror.ErrInternalError, "Failed to get snapshot disk format", err)
+		}
+		return source, format, nil
+	}
+	if target != "" {
+		return "", "", apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Disk %s not found in snapshot %s", target, snap.Name),
+			http.StatusNotFound,
+		)
+	}
+	return "", "", apierror.WrapError(apierror.ErrInternalError, "No valid disk found in snapshot", nil)
+}
+
+// convertExportDisk 把源磁盘转换为导出文件，先写 .partial 再改名，下载方不会读到未完成的文件
+func convertExportDisk(ctx context.Context, client libvirt.RemoteManager, sourcePath, sourceFormat, convertArgs, diskPath string) error {
+	if _, err := runNodeCommand(ctx, client, "mkdir -p "+shellx.Quote(filepath.Dir(diskPath))); err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to create export directory", err)
+	}
+	partialPath := diskPath + partialExportSuffix
+	command := fmt.Sprintf("qemu-img convert -f %s %s %s %s && mv -f %s %s",
+		shellx.Quote(sourceFormat), convertArgs, shellx.Quote(sourcePath), shellx.Quote(partialPath),
+		shellx.Quote(partialPath), shellx.Quote(diskPath))
+	if _, err := runNodeCommand(ctx, client, command); err != nil {
+		_, _ = runNodeCommand(context.WithoutCancel(ctx), client, "rm -f "+shellx.Quote(partialPath))
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to convert disk for export", err)
+	}
+	return nil
+}
+
+// writeExportOVF 生成 OVA 的 OVF 描述文件，返回文件大小
+func (s *InstanceService) writeExportOVF(ctx context.Context, client libvirt.LibvirtClient, domain libvirtlib.Domain, exportDir, exportID, diskPath string, diskSize int64) (int64, error) {
+	info, err := client.GetDomainInfo(domain.UUID)
+	if err != nil {
+		return 0, apierror.WrapError(apierror.ErrInternalError, "Failed to get instance info", err)
+	}
+	output, err := runNodeCommand(ctx, client, "qemu-img info --output=json "+shellx.Quote(diskPath))
+	if err != nil {
+		return 0, apierror.WrapError(apierror.ErrInternalError, "Failed to get export disk info", err)
+	}
+	var diskInfo struct {
+		VirtualSize int64 `json:"virtual-size"`
+	}
+	if err := json.Unmarshal(output, &diskInfo); err != nil {
+		return 0, apierror.WrapError(apierror.ErrInternalError, "Failed to parse export disk info", err)
+	}
+
+	ovf := buildExportOVF(domain.Name, filepath.Base(diskPath), diskSize, diskInfo.VirtualSize, info.VCPUs, info.Memory/1024)
+	ovfPath := filepath.Join(exportDir, exportID+".ovf")
+	if _, err := runNodeCommand(ctx, client, fmt.Sprintf("printf '%%s' %s > %s", shellx.Quote(ovf), shellx.Quote(ovfPath))); err != nil {
+		return 0, apierror.WrapError(apierror.ErrInternalError, "Failed to write OVF descriptor", err)
+	}
+	return int64(len(ovf)), nil
+}
+
+// buildExportOVF 生成 OVF 1.0 描述文件：单个 IDE 控制器挂载 streamOptimized VMDK 系统盘
+func buildExportOVF(name, diskFile string, diskSize, capacity int64, vcpus uint16, memoryMB uint64) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1" xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData" xmlns:vssd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_VirtualSystemSettingData">
+  <References>
+    <File ovf:id="file1" ovf:href="%[2]s" ovf:size="%[3]d"/>
+  </References>
+  <DiskSection>
+    <Info>Virtual disk information</Info>
+    <Disk ovf:diskId="vmdisk1" ovf:fileRef="file1" ovf:capacity="%[4]d" ovf:format="http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized"/>
+  </DiskSection>
+  <VirtualSystem ovf:id="%[1]s">
+    <Info>Virtual machine exported from JVP</Info>
+    <Name>%[1]s</Name>
+    <OperatingSystemSection ovf:id="1">
+      <Info>The kind of installed guest operating system</Info>
+    </OperatingSystemSection>
+    <VirtualHardwareSection>
+      <Info>Virtual hardware requirements</Info>
+      <System>
+        <vssd:ElementName>Virtual Hardware Family</vssd:ElementName>
+        <vssd:InstanceID>0</vssd:InstanceID>
+        <vssd:VirtualSystemIdentifier>%[1]s</vssd:VirtualSystemIdentifier>
+        <vssd:VirtualSystemType>vmx-07</vssd:VirtualSystemType>
+      </System>
+      <Item>
+        <rasd:AllocationUnits>hertz * 10^6</rasd:AllocationUnits>
+        <rasd:ElementName>%[5]d virtual CPU(s)</rasd:ElementName>
+        <rasd:InstanceID>1</rasd:InstanceID>
+        <rasd:ResourceType>3</rasd:ResourceType>
+        <rasd:VirtualQuantity>%[5]d</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:AllocationUnits>byte * 2^20</rasd:AllocationUnits>
+        <rasd:ElementName>%[6]dMB of memory</rasd:ElementName>
+        <rasd:InstanceID>2</rasd:InstanceID>
+        <rasd:ResourceType>4</rasd:ResourceType>
+        <rasd:VirtualQuantity>%[6]d</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:Address>0</rasd:Address>
+        <rasd:ElementName>IDE Controller 0</rasd:ElementName>
+        <rasd:InstanceID>3</rasd:InstanceID>
+        <rasd:ResourceType>5</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:AddressOnParent>0</rasd:AddressOnParent>
+        <rasd:ElementName>Hard Disk 1</rasd:ElementName>
+        <rasd:HostResource>ovf:/disk/vmdisk1</rasd:HostResource>
+        <rasd:InstanceID>4</rasd:InstanceID>
+        <rasd:Parent>3</rasd:Parent>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>
+`, name, diskFile, diskSize, capacity, vcpus, memoryMB)
+}
+
+// OpenInstanceExport 打开导出文件用于下载，导出不存在时返回 404
+func (s *InstanceService) OpenInstanceExport(ctx context.Context, nodeName, poolName, exportID string) (*InstanceExportDownload, error) {
+	if err := validateResourceName("export", exportID); err != nil {
+		return nil, err
+	}
+	client, err := s.nodeProvider.GetNodeStorage(ctx, nodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+	pool, err := client.GetStoragePool(poolName)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Storage pool %s not found", poolName),
+			http.StatusNotFound,
+		)
+	}
+	base := filepath.Join(pool.Path, ExportsDirName, exportID)
+
+	if size, err := nodeFileSize(ctx, client, base+".qcow2"); err == nil {
+		return &InstanceExportDownload{
+			FileName: exportID + ".qcow2",
+			SizeB:    size,
+			stream: func(w io.Writer) error {
+				return streamNodeFile(ctx, client, base+".qcow2", w)
+			},
+		}, nil
+	}
+
+	ovfSize, ovfErr := nodeFileSize(ctx, client, base+".ovf")
+	vmdkSize, vmdkErr := nodeFileSize(ctx, client, base+".vmdk")
+	if ovfErr != nil || vmdkErr != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Export %s not found in pool %s", exportID, poolName),
+			http.StatusNotFound,
+		)
+	}
+	// OVA 是 OVF 在前的 tar 包：每个文件 512 字节头部加按 512 字节对齐的内容，末尾两个空块
+	return &InstanceExportDownload{
+		FileName: exportID + ".ova",
+		SizeB:    tarEntrySize(ovfSize) + tarEntrySize(vmdkSize) + 2*512,
+		stream: func(w io.Writer) error {
+			tw := tar.NewWriter(w)
+			for _, entry := range []struct {
+				ext  string
+				size int64
+			}{{".ovf", ovfSize}, {".vmdk", vmdkSize}} {
+				if err := tw.WriteHeader(&tar.Header{
+					Name:    exportID + entry.ext,
+					Mode:    0o644,
+					Size:    entry.size,
+					ModTime: time.Now(),
+				}); err != nil {
+					return err
+				}
+				if err := streamNodeFile(ctx, client, base+entry.ext, tw); err != nil {
+					return err
+				}
+			}
+			return tw.Close()
+		},
+	}, nil
+}
+
+// DeleteInstanceExport 删除导出文件
+func (s *InstanceService) DeleteInstanceExport(ctx context.Context, req *entity.DeleteInstanceExportRequest) error {
+	if err := validateResourceName("export", req.ExportID); err != nil {
+		return err
+	}
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+	pool, err := client.GetStoragePool(req.PoolName)
+	if err != nil {
+		return apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Storage pool %s not found", req.PoolName),
+			http.StatusNotFound,
+		)
+	}
+	if isDryRun(ctx) {
+		return dryRunOperation(ctx, "DeleteInstanceExport")
+	}
+	base := filepath.Join(pool.Path, ExportsDirName, req.ExportID)
+	if _, err := runNodeCommand(ctx, client, fmt.Sprintf("rm -f %s %s %s",
+		shellx.Quote(base+".qcow2"), shellx.Quote(base+".ovf"), shellx.Quote(base+".vmdk"))); err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to delete export", err)
+	}
+	zerolog.Ctx(ctx).Info().
+		Str("export_id", req.ExportID).
+		Msg("Instance export deleted")
+	return nil
+}
+
+// tarEntrySize tar 包中一个文件占用的字节数
+func tarEntrySize(size int64) int64 {
+	return 512 + (size+511)/512*512
+}
+
+// nodeFileSize 获取节点上文件的大小
+func nodeFileSize(ctx context.Context, client libvirt.RemoteManager, path string) (int64, error) {
+	output, err := runNodeCommand(ctx, client, "stat -c %s "+shellx.Quote(path))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+}
+
+// streamNodeFile 把节点上的文件写入 w，远程节点通过 SSH cat 读取，不在内存中缓存整个文件
+func streamNodeFile(ctx context.Context, client libvirt.RemoteManager, path string, w io.Writer) error {
+	if !client.IsRemoteConnection() {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(w, file)
+		return err
+	}
+
+	target, err := client.GetSSHTarget()
+	if err != nil {
+		return fmt.Errorf("get SSH target: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, "ssh", "-o", "StrictHostKeyChecking=no", "-o", "BatchMode=yes", target, "cat "+shellx.Quote(path))
+	cmd.Stdout = w
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
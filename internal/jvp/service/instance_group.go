@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// defaultInstanceGroupConcurrency 实例组操作的默认并发数
+	defaultInstanceGroupConcurrency = 4
+	// maxInstanceGroupConcurrency 实例组操作的最大并发数
+	maxInstanceGroupConcurrency = 32
+)
+
+// instanceGroupTarget 实例组操作选中的实例
+type instanceGroupTarget struct {
+	nodeName   string
+	instanceID string
+	state      string
+}
+
+// RunInstanceGroupAction 按标签、名称前缀或 ID 选择一组实例，并发执行启动、停止、重启或删除并返回逐个结果
+// 单个实例失败不影响其他实例；租户请求只会选中该租户的实例；DryRun 时只返回选中的实例
+func (s *InstanceService) RunInstanceGroupAction(ctx context.Context, req *entity.RunInstanceGroupActionRequest) (*entity.RunInstanceGroupActionResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("action", req.Action).
+		Interface("selector", req.Selector).
+		Msg("Running instance group action")
+
+	switch req.Action {
+	case entity.InstanceGroupActionStart, entity.InstanceGroupActionStop,
+		entity.InstanceGroupActionReboot, entity.InstanceGroupActionTerminate:
+	default:
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported action %q, must be start, stop, reboot or terminate", req.Action),
+			http.StatusBadRequest,
+		)
+	}
+	// 不允许空选择条件，避免误操作节点上的全部实例
+	if len(req.Selector.Labels) == 0 && req.Selector.NamePrefix == "" && len(req.Selector.InstanceIDs) == 0 {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"selector must specify at least one of labels, name_prefix or instance_ids",
+			http.StatusBadRequest,
+		)
+	}
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultInstanceGroupConcurrency
+	}
+	concurrency = min(concurrency, maxInstanceGroupConcurrency)
+
+	targets, err := s.selectInstanceGroup(ctx, req.NodeName, &req.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &entity.RunInstanceGroupActionResponse{
+		Action:  req.Action,
+		DryRun:  isDryRun(ctx),
+		Results: make([]entity.InstanceGroupActionResult, len(targets)),
+	}
+	for i, target := range targets {
+		resp.Results[i] = entity.InstanceGroupActionResult{
+			NodeName:      target.nodeName,
+			InstanceID:    target.instanceID,
+			PreviousState: target.state,
+		}
+	}
+	if resp.DryRun {
+		logger.Info().
+			Int("selected", len(targets)).
+			Msg("Dry run: instance group resolved, no action executed")
+		return resp, nil
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			currentState, err := s.runInstanceGroupAction(ctx, req, target)
+			if err != nil {
+				resp.Results[i].Error = err.Error()
+				return
+			}
+			resp.Results[i].CurrentState = currentState
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range resp.Results {
+		if result.Error != "" {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+	}
+	logger.Info().
+		Str("action", req.Action).
+		Int("succeeded", resp.Succeeded).
+		Int("failed", resp.Failed).
+		Msg("Instance group action completed")
+	return resp, nil
+}
+
+// selectInstanceGroup 解析选择条件对应的实例；未指定节点时遍历所有在线节点，单个节点查询失败时跳过该节点
+func (s *InstanceService) selectInstanceGroup(ctx context.Context, nodeName string, selector *entity.InstanceSelector) ([]instanceGroupTarget, error) {
+	logger := zerolog.Ctx(ctx)
+
+	nodeNames := []string{nodeName}
+	if nodeName == "" {
+		nodes, err := s.nodeProvider.ListNodes(ctx)
+		if err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list nodes", err)
+		}
+		nodeNames = nodeNames[:0]
+		for _, node := range nodes {
+			if node.State == entity.NodeStateOnline {
+				nodeNames = append(nodeNames, node.Name)
+			}
+		}
+	}
+
+	tenant := tenantFromContext(ctx)
+	var targets []instanceGroupTarget
+	for _, name := range nodeNames {
+		instances, err := s.DescribeInstances(ctx, &entity.DescribeInstancesRequest{
+			NodeName:    name,
+			InstanceIDs: selector.InstanceIDs,
+		})
+		if err != nil {
+			if nodeName != "" {
+				return nil, err
+			}
+			logger.Warn().
+				Err(err).
+				Str("node", name).
+				Msg("Failed to describe instances for group action, skipping node")
+			continue
+		}
+		for _, instance := range instances {
+			if !strings.HasPrefix(instance.Name, selector.NamePrefix) || !matchLabels(instance.Labels, selector.Labels) {
+				continue
+			}
+			if tenant != "" && instance.Owner != tenant {
+				continue
+			}
+			targets = append(targets, instanceGroupTarget{
+				nodeName:   name,
+				instanceID: instance.ID,
+				state:      instance.State,
+			})
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].nodeName != targets[j].nodeName {
+			return targets[i].nodeName < targets[j].nodeName
+		}
+		return targets[i].instanceID < targets[j].instanceID
+	})
+	return targets, nil
+}
+
+// runInstanceGroupAction 对单个实例执行操作，复用单实例批量接口的校验（如删除保护），返回操作后的状态
+func (s *InstanceService) runInstanceGroupAction(ctx context.Context, req *entity.RunInstanceGroupActionRequest, target instanceGroupTarget) (string, error) {
+	instanceIDs := []string{target.instanceID}
+	var (
+		changes []entity.InstanceStateChange
+		err     error
+	)
+	switch req.Action {
+	case entity.InstanceGroupActionStart:
+		changes, err = s.StartInstances(ctx, &entity.StartInstancesRequest{
+			NodeName:    target.nodeName,
+			InstanceIDs: instanceIDs,
+		})
+	case entity.InstanceGroupActionStop:
+		changes, err = s.StopInstances(ctx, &entity.StopInstancesRequest{
+			NodeName:    target.nodeName,
+			InstanceIDs: instanceIDs,
+			Force:       req.Force,
+		})
+	case entity.InstanceGroupActionReboot:
+		changes, err = s.RebootInstances(ctx, &entity.RebootInstancesRequest{
+			NodeName:    target.nodeName,
+			InstanceIDs: instanceIDs,
+		})
+	case entity.InstanceGroupActionTerminate:
+		changes, err = s.TerminateInstances(ctx, &entity.TerminateInstancesRequest{
+			NodeName:      target.nodeName,
+			InstanceIDs:   instanceIDs,
+			DeleteVolumes: req.DeleteVolumes,
+			Permanent:     req.Permanent,
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(changes) == 0 {
+		return target.state, nil
+	}
+	return changes[0].CurrentState, nil
+}
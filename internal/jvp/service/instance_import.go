@@ -0,0 +1,569 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/rs/zerolog"
+)
+
+// ImportsDirName 实例导入分片暂存的目录名（位于存储池根目录下），每次导入一个子目录
+const ImportsDirName = "_imports_"
+
+const (
+	// importPartPrefix 分片文件名前缀，后接 5 位序号
+	importPartPrefix = "part-"
+	// importUploadFile 分片拼接后的完整镜像文件名
+	importUploadFile = "upload"
+	// importOVADiskFile 从 OVA 中解出的 VMDK 文件名
+	importOVADiskFile = "disk.vmdk"
+)
+
+// isImportVolume 判断卷是否为导入目录或其中的文件
+func isImportVolume(volInfo *libvirt.VolumeInfo) bool {
+	return volInfo.Name == ImportsDirName || strings.Contains(volInfo.Path, "/"+ImportsDirName+"/")
+}
+
+// importImageInfo qemu-img info 中导入校验关心的字段
+type importImageInfo struct {
+	Format          string `json:"format"`
+	VirtualSize     int64  `json:"virtual-size"`
+	BackingFilename string `json:"backing-filename"`
+}
+
+// CreateInstanceImport 发起实例导入，在存储池的 _imports_ 目录下创建分片暂存目录
+func (s *InstanceService) CreateInstanceImport(ctx context.Context, req *entity.CreateInstanceImportRequest) (*entity.InstanceImport, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Msg("Creating instance import")
+
+	client, importsDir, err := s.importsDir(ctx, req.NodeName, req.PoolName)
+	if err != nil {
+		return nil, err
+	}
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CreateInstanceImport")
+	}
+	id, err := s.idGen.GenerateID()
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to generate import ID", err)
+	}
+	importID := fmt.Sprintf("import-%d", id)
+	if _, err := runNodeCommand(ctx, client, "mkdir -p "+shellx.Quote(filepath.Join(importsDir, importID))); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to create import directory", err)
+	}
+
+	logger.Info().
+		Str("import_id", importID).
+		Msg("Instance import created")
+	return &entity.InstanceImport{
+		ImportID: importID,
+		NodeName: req.NodeName,
+		PoolName: req.PoolName,
+		UploadURL: fmt.Sprintf("/api/upload-instance-import-part/%s/%s/%s",
+			url.PathEscape(req.NodeName), url.PathEscape(req.PoolName), url.PathEscape(importID)),
+	}, nil
+}
+
+// UploadInstanceImportPart 上传一个分片，先写入临时文件再重命名，中断的上传不会留下不完整的分片
+func (s *InstanceService) UploadInstanceImportPart(ctx context.Context, req *entity.UploadInstanceImportPartRequest, body io.Reader) (int64, error) {
+	client, importDir, err := s.openInstanceImport(ctx, req.NodeName, req.PoolName, req.ImportID)
+	if err != nil {
+		return 0, err
+	}
+	if isDryRun(ctx) {
+		return 0, dryRunOperation(ctx, "UploadInstanceImportPart")
+	}
+	partPath := filepath.Join(importDir, fmt.Sprintf("%s%05d", importPartPrefix, req.PartNumber))
+	partialPath := partPath + partialExportSuffix
+	if err := writeNodeFile(ctx, client, partialPath, body); err != nil {
+		_, _ = runNodeCommand(context.WithoutCancel(ctx), client, "rm -f "+shellx.Quote(partialPath))
+		return 0, apierror.WrapError(apierror.ErrInternalError, "Failed to write import part", err)
+	}
+	if _, err := runNodeCommand(ctx, client, fmt.Sprintf("mv -f %s %s", shellx.Quote(partialPath), shellx.Quote(partPath))); err != nil {
+		return 0, apierror.WrapError(apierror.ErrInternalError, "Failed to save import part", err)
+	}
+	size, err := nodeFileSize(ctx, client, partPath)
+	if err != nil {
+		return 0, apierror.WrapError(apierror.ErrInternalError, "Failed to stat import part", err)
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Str("import_id", req.ImportID).
+		Int("part_number", req.PartNumber).
+		Int64("size_b", size).
+		Msg("Instance import part uploaded")
+	return size, nil
+}
+
+// CompleteInstanceImport 完成实例导入：按序号拼接分片，校验镜像格式后转换为 qcow2 系统盘并创建实例
+// 导入的镜像按原样引导，不挂载 cloud-init；转换成功后删除暂存目录，失败时保留已拼接的镜像以便重试
+func (s *InstanceService) CompleteInstanceImport(ctx context.Context, req *entity.CompleteInstanceImportRequest) (*entity.Instance, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("import_id", req.ImportID).
+		Str("format", req.Format).
+		Msg("Completing instance import")
+
+	switch req.Format {
+	case entity.InstanceImportFormatQCOW2, entity.InstanceImportFormatRaw, entity.InstanceImportFormatVMDK,
+		entity.InstanceImportFormatVHDX, entity.InstanceImportFormatOVA:
+	default:
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported import format %q, must be qcow2, raw, vmdk, vhdx or ova", req.Format),
+			http.StatusBadRequest,
+		)
+	}
+	diskBus := req.DiskBus
+	switch diskBus {
+	case "":
+		diskBus = "virtio"
+	case "virtio", "sata", "ide":
+	default:
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported disk_bus %q, must be virtio, sata or ide", req.DiskBus),
+			http.StatusBadRequest,
+		)
+	}
+
+	client, importDir, err := s.openInstanceImport(ctx, req.NodeName, req.PoolName, req.ImportID)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := client.GetStoragePool(req.PoolName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get storage pool", err)
+	}
+
+	instanceName, diskPath, err := s.importedInstanceName(ctx, client, pool.Path, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	parts, err := pendingImportParts(ctx, client, importDir, req.ImportID)
+	if err != nil {
+		return nil, err
+	}
+
+	networkType, networkSource, err := s.nodeProvider.ResolveInstanceNetwork(ctx, req.NodeName, req.NetworkType, req.NetworkSource)
+	if err != nil {
+		return nil, err
+	}
+
+	// OVA 需先解出其中的 VMDK，临时文件与上传的镜像大小相当
+	var extractDir string
+	var extractSizeB uint64
+	if req.Format == entity.InstanceImportFormatOVA {
+		if extractSizeB, err = importUploadSize(ctx, client, importDir, parts); err != nil {
+			return nil, err
+		}
+		extractDir = s.tempSpace.WorkDir(filepath.Dir(importDir), req.ImportID+"-extract")
+		if err := s.tempSpace.Check(ctx, client, req.NodeName, extractDir, extractSizeB); err != nil {
+			return nil, err
+		}
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CompleteInstanceImport")
+	}
+
+	uploadPath, err := assembleImportUpload(ctx, client, importDir, parts)
+	if err != nil {
+		return nil, err
+	}
+
+	sourcePath, sourceFormat := uploadPath, req.Format
+	if req.Format == entity.InstanceImportFormatOVA {
+		releaseTemp, err := s.tempSpace.Acquire(ctx, client, req.NodeName, extractDir, extractSizeB)
+		if err != nil {
+			return nil, err
+		}
+		defer releaseTemp()
+		if sourcePath, err = extractOVADisk(ctx, client, uploadPath, filepath.Join(extractDir, importOVADiskFile)); err != nil {
+			return nil, err
+		}
+		sourceFormat = entity.InstanceImportFormatVMDK
+	}
+
+	info, err := inspectImportImage(ctx, client, sourcePath, sourceFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	// 显式指定源格式，避免 raw 镜像内容被当作其他格式探测
+	command := fmt.Sprintf("qemu-img convert -f %s -O qcow2 %s %s",
+		shellx.Quote(sourceFormat), shellx.Quote(sourcePath), shellx.Quote(diskPath))
+	if _, err := runNodeCommand(ctx, client, command); err != nil {
+		_, _ = runNodeCommand(context.WithoutCancel(ctx), client, "rm -f "+shellx.Quote(diskPath))
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to convert imported image", err)
+	}
+
+	memoryMB := req.MemoryMB
+	if memoryMB == 0 {
+		memoryMB = 2048
+	}
+	vcpus := req.VCPUs
+	if vcpus == 0 {
+		vcpus = 2
+	}
+
+	createdAt := time.Now().UTC()
+	domain, err := client.CreateDomain(&libvirt.CreateVMConfig{
+		Name:          instanceName,
+		Memory:        memoryMB * 1024,
+		VCPUs:         vcpus,
+		DiskPath:      diskPath,
+		DiskBus:       diskBus,
+		NetworkType:   networkType,
+		NetworkSource: networkSource,
+		OSType:        "hvm",
+		Architecture:  "x86_64",
+		VNCSocket:     fmt.Sprintf("/var/lib/jvp/qemu/%s.vnc", instanceName),
+		CreatedAt:     createdAt,
+	}, req.Start)
+	if err != nil {
+		_, _ = runNodeCommand(context.WithoutCancel(ctx), client, "rm -f "+shellx.Quote(diskPath))
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to create imported instance", err)
+	}
+
+	if _, err := runNodeCommand(ctx, client, "rm -rf "+shellx.Quote(importDir)); err != nil {
+		logger.Warn().
+			Err(err).
+			Str("import_id", req.ImportID).
+			Msg("Failed to remove import directory")
+	}
+
+	state := "stopped"
+	if req.Start {
+		state = "running"
+	}
+	s.recordEvent(ctx, req.NodeName, instanceName, entity.InstanceEventCreated, state,
+		fmt.Sprintf("imported from %s image %s (%d bytes virtual size)", req.Format, req.ImportID, info.VirtualSize))
+
+	owner := tenantFromContext(ctx)
+	if owner != "" {
+		if err := s.quotas.SetInstanceOwner(req.NodeName, instanceName, owner); err != nil {
+			logger.Error().
+				Err(err).
+				Str("name", instanceName).
+				Msg("Failed to record instance owner")
+		}
+	}
+
+	logger.Info().
+		Str("import_id", req.ImportID).
+		Str("name", instanceName).
+		Str("disk_path", diskPath).
+		Msg("Instance imported successfully")
+
+	return &entity.Instance{
+		ID:          instanceName,
+		Name:        instanceName,
+		State:       state,
+		NodeName:    req.NodeName,
+		MemoryMB:    memoryMB,
+		MaxMemoryMB: memoryMB,
+		VCPUs:       vcpus,
+		MaxVCPUs:    vcpus,
+		CreatedAt:   createdAt.Format(time.RFC3339),
+		DomainUUID:  formatDomainUUID(domain.UUID),
+		DomainName:  instanceName,
+		Owner:       owner,
+	}, nil
+}
+
+// AbortInstanceImport 取消实例导入，删除暂存目录及已上传的分片
+func (s *InstanceService) AbortInstanceImport(ctx context.Context, req *entity.AbortInstanceImportRequest) error {
+	client, importDir, err := s.openInstanceImport(ctx, req.NodeName, req.PoolName, req.ImportID)
+	if err != nil {
+		return err
+	}
+	if isDryRun(ctx) {
+		return dryRunOperation(ctx, "AbortInstanceImport")
+	}
+	if _, err := runNodeCommand(ctx, client, "rm -rf "+shellx.Quote(importDir)); err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to delete import", err)
+	}
+	zerolog.Ctx(ctx).Info().
+		Str("import_id", req.ImportID).
+		Msg("Instance import aborted")
+	return nil
+}
+
+// importedInstanceName 确定导入实例的名称与系统盘路径，未指定名称时自动生成，实例或系统盘已存在时返回 409
+func (s *InstanceService) importedInstanceName(ctx context.Context, client libvirt.LibvirtClient, poolPath, name string) (string, string, error) {
+	if name == "" {
+		id, err := s.idGen.GenerateID()
+		if err != nil {
+			return "", "", apierror.WrapError(apierror.ErrInternalError, "Failed to generate instance ID", err)
+		}
+		name = fmt.Sprintf("i-%d", id)
+	} else {
+		if err := validateResourceName("instance", name); err != nil {
+			return "", "", err
+		}
+		if _, err := client.GetDomainByName(name); err == nil {
+			return "", "", newResourceAlreadyExistsError("Instance", name)
+		}
+	}
+	diskPath := filepath.Join(poolPath, name+".qcow2")
+	if _, err := nodeFileSize(ctx, client, diskPath); err == nil {
+		return "", "", newResourceAlreadyExistsError("Volume", diskPath)
+	}
+	return name, diskPath, nil
+}
+
+// importsDir 返回节点连接与存储池的导入目录
+func (s *InstanceService) importsDir(ctx context.Context, nodeName, poolName string) (libvirt.LibvirtClient, string, error) {
+	client, err := s.nodeProvider.GetNodeStorage(ctx, nodeName)
+	if err != nil {
+		return nil, "", apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+	importsDir, err := poolImportsDir(client, poolName)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, importsDir, nil
+}
+
+// openInstanceImport 返回已存在的导入暂存目录，导入不存在时返回 404
+func (s *InstanceService) openInstanceImport(ctx context.Context, nodeName, poolName, importID string) (libvirt.LibvirtClient, string, error) {
+	client, err := s.nodeProvider.GetNodeStorage(ctx, nodeName)
+	if err != nil {
+		return nil, "", apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+	importDir, err := openImportDir(ctx, client, poolName, importID)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, importDir, nil
+}
+
+// poolImportsDir 返回存储池的导入目录，存储池不存在时返回 404
+func poolImportsDir(client libvirt.StorageManager, poolName string) (string, error) {
+	pool, err := client.GetStoragePool(poolName)
+	if err != nil {
+		return "", apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Storage pool %s not found", poolName),
+			http.StatusNotFound,
+		)
+	}
+	return filepath.Join(pool.Path, ImportsDirName), nil
+}
+
+// openImportDir 返回已存在的导入暂存目录，导入不存在时返回 404；实例导入与卷导入共用
+func openImportDir(ctx context.Context, client libvirt.LibvirtClient, poolName, importID string) (string, error) {
+	if err := validateResourceName("import", importID); err != nil {
+		return "", err
+	}
+	importsDir, err := poolImportsDir(client, poolName)
+	if err != nil {
+		return "", err
+	}
+	importDir := filepath.Join(importsDir, importID)
+	if _, err := runNodeCommand(ctx, client, "test -d "+shellx.Quote(importDir)); err != nil {
+		return "", apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Import %s not found in pool %s", importID, poolName),
+			http.StatusNotFound,
+		)
+	}
+	return importDir, nil
+}
+
+// listImportParts 按序号返回已上传的分片路径，序号必须从 1 开始连续
+func listImportParts(ctx context.Context, client libvirt.RemoteManager, importDir string) ([]string, error) {
+	output, err := runNodeCommand(ctx, client, "ls -1 "+shellx.Quote(importDir))
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list import parts", err)
+	}
+	var numbers []int
+	for _, name := range strings.Fields(string(output)) {
+		if !strings.HasPrefix(name, importPartPrefix) {
+			continue
+		}
+		number, err := strconv.Atoi(strings.TrimPrefix(name, importPartPrefix))
+		if err != nil {
+			// 上传中的 .partial 文件
+			continue
+		}
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+	parts := make([]string, 0, len(numbers))
+	for i, number := range numbers {
+		if number != i+1 {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("Import part %d is missing", i+1),
+				http.StatusBadRequest,
+			)
+		}
+		parts = append(parts, filepath.Join(importDir, fmt.Sprintf("%s%05d", importPartPrefix, number)))
+	}
+	return parts, nil
+}
+
+// pendingImportParts 返回待拼接的分片；没有分片时要求之前的完成请求已拼接过镜像（转换失败后重试）
+func pendingImportParts(ctx context.Context, client libvirt.RemoteManager, importDir, importID string) ([]string, error) {
+	parts, err := listImportParts(ctx, client, importDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		if _, err := nodeFileSize(ctx, client, filepath.Join(importDir, importUploadFile)); err != nil {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("Import %s has no uploaded parts", importID),
+				http.StatusBadRequest,
+			)
+		}
+	}
+	return parts, nil
+}
+
+// assembleImportUpload 按序号把分片拼接为完整镜像并删除分片，返回镜像路径
+func assembleImportUpload(ctx context.Context, client libvirt.RemoteManager, importDir string, parts []string) (string, error) {
+	uploadPath := filepath.Join(importDir, importUploadFile)
+	if len(parts) == 0 {
+		return uploadPath, nil
+	}
+	partList := shellx.Join(parts...)
+	if _, err := runNodeCommand(ctx, client, fmt.Sprintf("cat %s > %s && rm -f %s",
+		partList, shellx.Quote(uploadPath), partList)); err != nil {
+		return "", apierror.WrapError(apierror.ErrInternalError, "Failed to assemble import parts", err)
+	}
+	return uploadPath, nil
+}
+
+// importUploadSize 返回待拼接分片的总大小，已拼接过时返回完整镜像的大小
+func importUploadSize(ctx context.Context, client libvirt.RemoteManager, importDir string, parts []string) (uint64, error) {
+	paths := parts
+	if len(paths) == 0 {
+		paths = []string{filepath.Join(importDir, importUploadFile)}
+	}
+	var total uint64
+	for _, p := range paths {
+		size, err := nodeFileSize(ctx, client, p)
+		if err != nil {
+			return 0, apierror.WrapError(apierror.ErrInternalError, "Failed to stat uploaded image", err)
+		}
+		total += uint64(size)
+	}
+	return total, nil
+}
+
+// extractOVADisk 从 OVA（tar 包）中解出第一个 VMDK
+func extractOVADisk(ctx context.Context, client libvirt.RemoteManager, ovaPath, diskPath string) (string, error) {
+	output, err := runNodeCommand(ctx, client, "tar -tf "+shellx.Quote(ovaPath))
+	if err != nil {
+		return "", apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("Uploaded image is not a valid OVA archive: %v", err),
+			http.StatusBadRequest,
+		)
+	}
+	for _, entry := range strings.Split(string(output), "\n") {
+		entry = strings.TrimSpace(entry)
+		if !strings.HasSuffix(strings.ToLower(entry), ".vmdk") {
+			continue
+		}
+		// 只按名字读取单个成员并写到固定路径，成员名中的 .. 与绝对路径不会影响落盘位置，这里仍拒绝可疑名字
+		if path.IsAbs(entry) || strings.Contains(entry, "..") {
+			continue
+		}
+		if _, err := runNodeCommand(ctx, client, fmt.Sprintf("tar -xOf %s %s > %s",
+			shellx.Quote(ovaPath), shellx.Quote(entry), shellx.Quote(diskPath))); err != nil {
+			return "", apierror.WrapError(apierror.ErrInternalError, "Failed to extract disk from OVA", err)
+		}
+		return diskPath, nil
+	}
+	return "", apierror.NewErrorWithStatus(
+		"InvalidParameterValue",
+		"OVA archive contains no VMDK disk",
+		http.StatusBadRequest,
+	)
+}
+
+// inspectImportImage 校验上传的镜像：实际格式必须与声明一致，且不能引用 backing file（否则会读取宿主机上的任意文件）
+func inspectImportImage(ctx context.Context, client libvirt.RemoteManager, imagePath, format string) (*importImageInfo, error) {
+	output, err := runNodeCommand(ctx, client, "qemu-img info --output=json "+shellx.Quote(imagePath))
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("Uploaded image cannot be read by qemu-img: %v", err),
+			http.StatusBadRequest,
+		)
+	}
+	info := &importImageInfo{}
+	if err := json.Unmarshal(output, info); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to parse image info", err)
+	}
+	if info.Format != format {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("Uploaded image format is %s, expected %s", info.Format, format),
+			http.StatusBadRequest,
+		)
+	}
+	if info.BackingFilename != "" {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"Uploaded image must not reference a backing file",
+			http.StatusBadRequest,
+		)
+	}
+	return info, nil
+}
+
+// writeNodeFile 把 r 写入节点上的文件，远程节点通过 SSH cat 写入，不在内存中缓存整个文件
+func writeNodeFile(ctx context.Context, client libvirt.RemoteManager, path string, r io.Reader) error {
+	if !client.IsRemoteConnection() {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(file, r); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+
+	target, err := client.GetSSHTarget()
+	if err != nil {
+		return fmt.Errorf("get SSH target: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, "ssh", "-o", "StrictHostKeyChecking=no", "-o", "BatchMode=yes", target, "cat > "+shellx.Quote(path))
+	cmd.Stdin = r
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/rs/zerolog"
+)
+
+// 实例操作日志按这两个字段归档：同时带有节点名与实例 ID 的日志行会写入该实例的日志文件
+const (
+	instanceLogNodeField     = "node_name"
+	instanceLogInstanceField = "instanceID"
+)
+
+// maxInstanceLogBytes 单个实例日志文件的大小上限，超出后轮转为 .1 文件，只保留一份旧文件
+const maxInstanceLogBytes = 1 << 20
+
+// InstanceLogStore 实例操作日志归档
+// 每个实例一个 JSON Lines 文件：<dataDir>/instance-logs/<node>/<instance>.log，内容为服务日志的原始行
+type InstanceLogStore struct {
+	storageDir string
+	mu         sync.Mutex
+}
+
+// NewInstanceLogStore 创建实例操作日志归档
+func NewInstanceLogStore(dataDir string) (*InstanceLogStore, error) {
+	storageDir := filepath.Join(dataDir, "instance-logs")
+	if err := os.MkdirAll(storageDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create instance logs directory: %w", err)
+	}
+	return &InstanceLogStore{storageDir: storageDir}, nil
+}
+
+// getLogPath 获取实例日志文件路径
+func (s *InstanceLogStore) getLogPath(nodeName, instanceID string) string {
+	return filepath.Join(s.storageDir, nodeName, instanceID+".log")
+}
+
+// Writer 返回服务日志的输出：日志照常写入 next，带节点名与实例 ID 的行同时归档到对应实例的日志文件
+// 归档失败不影响服务日志本身
+func (s *InstanceLogStore) Writer(next io.Writer) io.Writer {
+	return &instanceLogWriter{next: next, store: s}
+}
+
+type instanceLogWriter struct {
+	next  io.Writer
+	store *InstanceLogStore
+}
+
+// Write zerolog 每次写入一条完整的 JSON 日志
+func (w *instanceLogWriter) Write(p []byte) (int, error) {
+	n, err := w.next.Write(p)
+	if bytes.Contains(p, []byte(`"`+instanceLogInstanceField+`":`)) {
+		_ = w.store.append(p)
+	}
+	return n, err
+}
+
+// validInstanceLogName 节点名与实例 ID 来自日志内容，拒绝可能逃逸出归档目录的值
+func validInstanceLogName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// append 把一条日志追加到对应实例的日志文件，文件超出上限时先轮转
+func (s *InstanceLogStore) append(line []byte) error {
+	var fields struct {
+		NodeName   string `json:"node_name"`
+		InstanceID string `json:"instanceID"`
+	}
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return err
+	}
+	if !validInstanceLogName(fields.NodeName) || !validInstanceLogName(fields.InstanceID) {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.getLogPath(fields.NodeName, fields.InstanceID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > maxInstanceLogBytes {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(line)
+	return err
+}
+
+// List 按时间先后返回实例的日志（包含轮转的旧文件），实例没有日志时返回空列表
+func (s *InstanceLogStore) List(nodeName, instanceID string) ([]entity.InstanceLogEntry, error) {
+	if !validInstanceLogName(nodeName) || !validInstanceLogName(instanceID) {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.getLogPath(nodeName, instanceID)
+	var entries []entity.InstanceLogEntry
+	for _, p := range []string{path + ".1", path} {
+		fileEntries, err := readInstanceLogFile(p)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileEntries...)
+	}
+	return entries, nil
+}
+
+func readInstanceLogFile(path string) ([]entity.InstanceLogEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open instance log file: %w", err)
+	}
+	defer f.Close()
+
+	var entries []entity.InstanceLogEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxInstanceLogBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(line, &fields); err != nil {
+			// 跳过损坏的行（如写入中途崩溃）
+			continue
+		}
+		entries = append(entries, parseInstanceLogEntry(fields))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read instance log file: %w", err)
+	}
+	return entries, nil
+}
+
+// parseInstanceLogEntry 把 zerolog 的 JSON 日志拆分为固定字段与其余上下文字段
+func parseInstanceLogEntry(fields map[string]any) entity.InstanceLogEntry {
+	var entry entity.InstanceLogEntry
+	if value, ok := fields[zerolog.TimestampFieldName].(string); ok {
+		entry.Time, _ = time.Parse(time.RFC3339, value)
+	}
+	entry.Level, _ = fields[zerolog.LevelFieldName].(string)
+	entry.Message, _ = fields[zerolog.MessageFieldName].(string)
+	entry.Error, _ = fields[zerolog.ErrorFieldName].(string)
+	entry.TraceID, _ = fields["trace_id"].(string)
+	for _, key := range []string{
+		zerolog.TimestampFieldName, zerolog.LevelFieldName, zerolog.MessageFieldName, zerolog.ErrorFieldName,
+		"trace_id", instanceLogNodeField, instanceLogInstanceField,
+	} {
+		delete(fields, key)
+	}
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+	return entry
+}
+
+// instanceLogContext 为 ctx 中的 logger 附加节点名与实例 ID，之后的日志会归档到该实例的操作日志
+func instanceLogContext(ctx context.Context, nodeName, instanceID string) context.Context {
+	logger := zerolog.Ctx(ctx).With().
+		Str(instanceLogNodeField, nodeName).
+		Str(instanceLogInstanceField, instanceID).
+		Logger()
+	return logger.WithContext(ctx)
+}
+
+// GetInstanceOperationLogs 查询实例的操作日志（创建、重置密码、存储迁移等过程的服务日志），按时间先后排列
+// 实例删除后日志仍保留，可继续查询
+func (s *InstanceService) GetInstanceOperationLogs(ctx context.Context, req *entity.GetInstanceOperationLogsRequest) (*entity.GetInstanceOperationLogsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	// 不带节点名字段，查询本身不会归档到实例的操作日志
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("filter_trace_id", req.TraceID).
+		Msg("Getting instance operation logs")
+
+	if s.logs == nil {
+		return &entity.GetInstanceOperationLogsResponse{Logs: []entity.InstanceLogEntry{}}, nil
+	}
+
+	entries, err := s.logs.List(req.NodeName, req.InstanceID)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list instance operation logs", err)
+	}
+
+	result := make([]entity.InstanceLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if req.TraceID != "" && entry.TraceID != req.TraceID {
+			continue
+		}
+		result = append(result, entry)
+	}
+	if req.MaxResults > 0 && len(result) > req.MaxResults {
+		result = result[len(result)-req.MaxResults:]
+	}
+	return &entity.GetInstanceOperationLogsResponse{Logs: result}, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/cloudinit"
+	"github.com/rs/zerolog"
+)
+
+// validateLoginUsers 校验 default_user 与 login_users：用户名合法、不重复，每个登录用户至少注入一个密钥对
+// 登录用户通过 cloud-init 创建，原始 user_data 中的用户需由调用方自行声明
+func validateLoginUsers(req *entity.RunInstanceRequest) error {
+	if req.DefaultUser == "" && len(req.LoginUsers) == 0 {
+		return nil
+	}
+	if req.UserData != nil && req.UserData.RawUserData != "" {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameter",
+			"default_user and login_users cannot be used with raw_user_data, declare the users in the raw user data instead",
+			http.StatusBadRequest,
+		)
+	}
+	if req.DefaultUser != "" {
+		if err := validateLoginUser("default_user", req.DefaultUser); err != nil {
+			return err
+		}
+	}
+
+	seen := make(map[string]bool, len(req.LoginUsers))
+	for i, user := range req.LoginUsers {
+		if err := validateLoginUser(fmt.Sprintf("login_users[%d].name", i), user.Name); err != nil {
+			return err
+		}
+		if seen[user.Name] {
+			return apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("duplicate login user %q", user.Name),
+				http.StatusBadRequest,
+			)
+		}
+		seen[user.Name] = true
+		if len(user.KeyPairIDs) == 0 {
+			return apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("login_users[%d].keypair_ids is required", i),
+				http.StatusBadRequest,
+			)
+		}
+		if user.Shell != "" && !strings.HasPrefix(user.Shell, "/") {
+			return apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("login_users[%d].shell must be an absolute path", i),
+				http.StatusBadRequest,
+			)
+		}
+	}
+	return nil
+}
+
+// injectLoginUsers 把密钥对注入 cloud-init 配置中的登录用户
+// keypair_ids 依次注入请求的 default_user、user_data 的第一个用户、模板的 os.default_user，都没有时注入发行版默认用户；
+// login_users 中的每个用户注入各自的密钥对。user_data 中已有同名用户时追加公钥，否则创建拥有免密 sudo 的用户
+// 返回 keypair_ids 注入的登录用户，注入发行版默认用户（名称取决于镜像）或没有注入密钥对时为空
+func (s *InstanceService) injectLoginUsers(ctx context.Context, config *cloudinit.Config, req *entity.RunInstanceRequest, template *entity.Template) string {
+	var loginUser string
+	if keys := s.keyPairPublicKeys(ctx, req.KeyPairIDs); len(keys) > 0 {
+		switch {
+		case req.DefaultUser != "":
+			loginUser = req.DefaultUser
+			addLoginUserKeys(config, loginUser, "", keys)
+		case len(config.Users) > 0:
+			loginUser = config.Users[0].Name
+			config.Users[0].SSHAuthorizedKeys = append(config.Users[0].SSHAuthorizedKeys, keys...)
+		case templateDefaultUser(template) != "":
+			loginUser = templateDefaultUser(template)
+			addLoginUserKeys(config, loginUser, "", keys)
+		default:
+			config.DefaultUserSSHKeys = append(config.DefaultUserSSHKeys, keys...)
+		}
+	}
+	for _, user := range req.LoginUsers {
+		if keys := s.keyPairPublicKeys(ctx, user.KeyPairIDs); len(keys) > 0 {
+			addLoginUserKeys(config, user.Name, user.Shell, keys)
+		}
+	}
+	return loginUser
+}
+
+// keyPairPublicKeys 获取密钥对的公钥，获取失败的密钥对记录告警后跳过
+func (s *InstanceService) keyPairPublicKeys(ctx context.Context, keyPairIDs []string) []string {
+	var keys []string
+	for _, keyPairID := range keyPairIDs {
+		keyPair, err := s.keyPairService.GetKeyPairByID(ctx, keyPairID)
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().
+				Str("keypair_id", keyPairID).
+				Err(err).
+				Msg("Failed to get key pair, skipping")
+			continue
+		}
+		keys = append(keys, keyPair.PublicKey)
+	}
+	return keys
+}
+
+// addLoginUserKeys 向同名用户追加公钥，用户不存在时创建拥有免密 sudo 的用户
+func addLoginUserKeys(config *cloudinit.Config, name, shell string, keys []string) {
+	for i := range config.Users {
+		if config.Users[i].Name == name {
+			config.Users[i].SSHAuthorizedKeys = append(config.Users[i].SSHAuthorizedKeys, keys...)
+			return
+		}
+	}
+	if shell == "" {
+		shell = "/bin/bash"
+	}
+	config.Users = append(config.Users, cloudinit.User{
+		Name:              name,
+		Sudo:              "ALL=(ALL) NOPASSWD:ALL",
+		Shell:             shell,
+		SSHAuthorizedKeys: keys,
+	})
+}
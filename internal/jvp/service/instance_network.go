@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+)
+
+// ModifyInstanceNetworkBandwidth 修改实例网卡限速（QoS）
+// 运行中实例通过 DomainSetInterfaceParameters 立即生效，同时写入持久化配置；关机实例只修改持久化配置
+func (s *InstanceService) ModifyInstanceNetworkBandwidth(ctx context.Context, req *entity.ModifyInstanceNetworkBandwidthRequest) (*entity.ModifyInstanceNetworkBandwidthResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Str("mac", req.MAC).
+		Msg("Modifying instance network bandwidth")
+
+	if req.Inbound == nil && req.Outbound == nil {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"At least one of inbound or outbound is required",
+			http.StatusBadRequest,
+		)
+	}
+	bandwidth := toLibvirtBandwidth(&entity.InterfaceBandwidth{Inbound: req.Inbound, Outbound: req.Outbound})
+	if err := bandwidth.Validate(); err != nil {
+		return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+	}
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	iface, err := findInstanceInterface(client, req.InstanceID, req.MAC)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := s.lockInstanceVersion(ctx, req.NodeName, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ModifyInstanceNetworkBandwidth")
+	}
+
+	if err := client.SetDomainInterfaceBandwidth(req.InstanceID, iface.MAC, *bandwidth); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to set interface bandwidth", err)
+	}
+
+	updated, err := findInstanceInterface(client, req.InstanceID, iface.MAC)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Str("mac", updated.MAC).
+		Msg("Instance network bandwidth modified")
+
+	s.recordEvent(ctx, req.NodeName, req.InstanceID, entity.InstanceEventModified, "",
+		fmt.Sprintf("network bandwidth of %s", updated.MAC))
+
+	return &entity.ModifyInstanceNetworkBandwidthResponse{
+		InstanceID: req.InstanceID,
+		MAC:        updated.MAC,
+		Bandwidth:  fromLibvirtBandwidth(updated.Bandwidth),
+	}, nil
+}
+
+// findInstanceInterface 按 MAC 查找实例网卡，mac 为空时返回第一块网卡
+func findInstanceInterface(client libvirt.DomainManager, instanceID, mac string) (*libvirt.NetworkInterface, error) {
+	domain, err := client.GetDomainByName(instanceID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", instanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	info, err := client.GetDomainInfo(domain.UUID)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get instance info", err)
+	}
+
+	for i := range info.NetworkInfo {
+		if mac == "" || strings.EqualFold(info.NetworkInfo[i].MAC, mac) {
+			return &info.NetworkInfo[i], nil
+		}
+	}
+
+	if mac == "" {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s has no network interface", instanceID),
+			http.StatusNotFound,
+		)
+	}
+	return nil, apierror.NewErrorWithStatus(
+		"ResourceNotFound",
+		fmt.Sprintf("Network interface %s not found in instance %s", mac, instanceID),
+		http.StatusNotFound,
+	)
+}
+
+// toLibvirtBandwidth 把 API 的网卡限速转换为 libvirt 配置
+func toLibvirtBandwidth(b *entity.InterfaceBandwidth) *libvirt.InterfaceBandwidth {
+	if b == nil {
+		return nil
+	}
+	result := &libvirt.InterfaceBandwidth{}
+	if b.Inbound != nil {
+		result.Inbound = &libvirt.BandwidthRate{Average: b.Inbound.Average, Peak: b.Inbound.Peak, Burst: b.Inbound.Burst}
+	}
+	if b.Outbound != nil {
+		result.Outbound = &libvirt.BandwidthRate{Average: b.Outbound.Average, Peak: b.Outbound.Peak, Burst: b.Outbound.Burst}
+	}
+	return result
+}
+
+// fromLibvirtBandwidth 把 libvirt 的网卡限速转换为 API 返回值
+func fromLibvirtBandwidth(b *libvirt.InterfaceBandwidth) *entity.InterfaceBandwidth {
+	if b == nil {
+		return nil
+	}
+	result := &entity.InterfaceBandwidth{}
+	if b.Inbound != nil {
+		result.Inbound = &entity.BandwidthRate{Average: b.Inbound.Average, Peak: b.Inbound.Peak, Burst: b.Inbound.Burst}
+	}
+	if b.Outbound != nil {
+		result.Outbound = &entity.BandwidthRate{Average: b.Outbound.Average, Peak: b.Outbound.Peak, Burst: b.Outbound.Burst}
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	libvirtlib "github.com/digitalocean/go-libvirt"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/qmp"
+)
+
+// DescribeInstanceQMP 通过 libvirt 的 QEMU monitor 通道执行一条白名单中的只读 QMP 命令
+// 实例必须处于运行或暂停状态；QEMU 返回的错误（如参数不正确）作为 400 返回
+func (s *InstanceService) DescribeInstanceQMP(ctx context.Context, req *entity.DescribeInstanceQMPRequest) (*entity.DescribeInstanceQMPResponse, error) {
+	if !qmp.IsAllowed(req.Command) {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("QMP command %s is not allowed, allowed commands: %s", req.Command, strings.Join(qmp.AllowedCommands(), ", ")),
+			http.StatusBadRequest,
+		)
+	}
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+	domain, err := client.GetDomainByName(req.InstanceID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+	state, _, err := client.GetDomainState(domain)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get instance state", err)
+	}
+	if st := libvirtlib.DomainState(state); st != libvirtlib.DomainRunning && st != libvirtlib.DomainPaused {
+		return nil, apierror.NewErrorWithStatus(
+			"IncorrectInstanceState",
+			fmt.Sprintf("Instance %s must be running to query QEMU monitor", req.InstanceID),
+			http.StatusConflict,
+		)
+	}
+
+	result, err := qmp.New(client, req.InstanceID).Execute(req.Command, req.Arguments)
+	if err != nil {
+		var qmpErr *qmp.Error
+		if errors.As(err, &qmpErr) {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("QMP command %s failed: %s", req.Command, qmpErr.Desc),
+				http.StatusBadRequest,
+			)
+		}
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to execute QMP command", err)
+	}
+
+	return &entity.DescribeInstanceQMPResponse{
+		InstanceID: req.InstanceID,
+		Command:    req.Command,
+		Return:     result,
+	}, nil
+}
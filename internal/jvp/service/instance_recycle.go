@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	libvirtlib "github.com/digitalocean/go-libvirt"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+)
+
+// deleteInstance 物理删除实例：undefine domain（会先停止运行中的实例），并删除关联的卷
+// cloud-init 数据卷与 Ignition 配置属于实例本身，总是删除；其余磁盘由 deleteVolumes 决定
+func (s *InstanceService) deleteInstance(ctx context.Context, client libvirt.LibvirtClient, domain libvirtlib.Domain, instanceID string, disks []libvirt.DomainDisk, deleteVolumes bool) error {
+	logger := zerolog.Ctx(ctx)
+
+	// 使用 DomainUndefineSnapshotsMetadata 标志同时删除快照元数据
+	logger.Info().
+		Str("instanceID", instanceID).
+		Msg("Deleting domain from libvirt")
+	undefineFlags := libvirtlib.DomainUndefineSnapshotsMetadata | libvirtlib.DomainUndefineNvram
+	if err := client.DeleteDomain(domain, libvirtlib.DomainUndefineFlagsValues(undefineFlags)); err != nil {
+		logger.Error().
+			Str("instanceID", instanceID).
+			Err(err).
+			Msg("Failed to delete domain")
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to delete domain", err)
+	}
+
+	logger.Info().
+		Str("instanceID", instanceID).
+		Msg("Domain deleted successfully")
+
+	volumeDisks := make([]libvirt.DomainDisk, 0, len(disks))
+	for _, disk := range disks {
+		switch {
+		case libvirt.IsCloudInitVolume(disk.Source.File):
+			volumeDisks = append(volumeDisks, disk)
+		case deleteVolumes && disk.Device == "disk":
+			// 光驱中的安装 ISO 等共享卷不随实例删除
+			volumeDisks = append(volumeDisks, disk)
+		}
+	}
+	if path := findIgnitionVolume(client, instanceID, disks); path != "" {
+		volumeDisks = append(volumeDisks, libvirt.DomainDisk{Source: libvirt.DomainDiskSource{File: path}})
+	}
+	if len(volumeDisks) > 0 {
+		if err := s.deleteVolumesByDisks(ctx, client, volumeDisks); err != nil {
+			logger.Error().
+				Str("instanceID", instanceID).
+				Err(err).
+				Msg("Failed to delete volumes for instance")
+			return apierror.WrapError(apierror.ErrInternalError, "Failed to delete instance volumes", err)
+		}
+		logger.Info().
+			Str("instanceID", instanceID).
+			Msg("Associated volumes deleted")
+	}
+	return nil
+}
+
+// recycleInstance 把实例移入回收站：强制关机、关闭自动启动并改名隔离，磁盘文件全部保留
+// libvirt 不允许重命名带快照的 domain，这种情况需要先删除快照或使用 permanent 直接删除
+func (s *InstanceService) recycleInstance(ctx context.Context, client libvirt.LibvirtClient, domain libvirtlib.Domain, nodeName, instanceID string, deleteVolumes bool) (*entity.RecycleBinItem, error) {
+	logger := zerolog.Ctx(ctx)
+
+	snapshots, err := client.ListSnapshots(instanceID)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list instance snapshots", err)
+	}
+	if len(snapshots) > 0 {
+		return nil, apierror.NewErrorWithStatus(
+			"IncorrectInstanceState",
+			fmt.Sprintf("Instance %s has %d snapshots and cannot be moved to recycle bin, delete the snapshots first or terminate with permanent", instanceID, len(snapshots)),
+			http.StatusConflict,
+		)
+	}
+
+	state, _, err := client.GetDomainState(domain)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get instance state", err)
+	}
+	switch libvirtlib.DomainState(state) {
+	case libvirtlib.DomainRunning, libvirtlib.DomainPaused, libvirtlib.DomainBlocked, libvirtlib.DomainPmsuspended:
+		if err := client.DestroyDomain(domain); err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to stop instance", err)
+		}
+	}
+
+	if err := client.SetDomainAutostart(domain, false); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to disable instance autostart", err)
+	}
+
+	item := s.recycleBin.newItem(entity.RecycleBinResourceInstance, instanceID, nodeName)
+	item.RecycledName = recycledDomainName(instanceID)
+	item.DeleteVolumes = deleteVolumes
+
+	if err := client.RenameDomain(domain, item.RecycledName); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to rename instance", err)
+	}
+	if err := s.recycleBin.Save(item); err != nil {
+		// 没有记录的实例不会被清理也无法恢复，改回原名保持可见
+		domain.Name = item.RecycledName
+		if renameErr := client.RenameDomain(domain, instanceID); renameErr != nil {
+			logger.Error().
+				Err(renameErr).
+				Str("instanceID", instanceID).
+				Msg("Failed to rename instance back after recycle bin failure")
+		}
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to save recycle bin item", err)
+	}
+
+	logger.Info().
+		Str("instanceID", instanceID).
+		Str("recycled_name", item.RecycledName).
+		Time("expires_at", item.ExpiresAt).
+		Msg("Instance moved to recycle bin")
+	return item, nil
+}
+
+// RestoreInstance 从回收站恢复实例，恢复后的实例处于关机状态
+func (s *InstanceService) RestoreInstance(ctx context.Context, req *entity.RestoreInstanceRequest) (*entity.RestoreInstanceResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Msg("Restoring instance from recycle bin")
+
+	item, err := s.recycleBin.Get(req.NodeName, entity.RecycleBinResourceInstance, "", req.InstanceID)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get recycle bin item", err)
+	}
+	if item == nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found in recycle bin", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	if _, err := client.GetDomainByName(req.InstanceID); err == nil {
+		return nil, newResourceAlreadyExistsError("Instance", req.InstanceID)
+	}
+	domain, err := client.GetDomainByName(item.RecycledName)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Recycled domain %s of instance %s not found", item.RecycledName, req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "RestoreInstance")
+	}
+
+	if err := client.RenameDomain(domain, req.InstanceID); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to rename instance", err)
+	}
+	if err := s.recycleBin.Delete(item); err != nil {
+		logger.Warn().
+			Err(err).
+			Str("instanceID", req.InstanceID).
+			Msg("Failed to delete recycle bin item")
+	}
+
+	s.recordEvent(ctx, req.NodeName, req.InstanceID, entity.InstanceEventRestored, "stopped", "restored from recycle bin")
+
+	instance, err := s.GetInstance(ctx, req.NodeName, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Msg("Instance restored from recycle bin")
+
+	return &entity.RestoreInstanceResponse{Instance: instance}, nil
+}
+
+// purgeRecycledInstance 物理删除回收站中的实例，domain 已不存在时只清理记录
+func (s *InstanceService) purgeRecycledInstance(ctx context.Context, item *entity.RecycleBinItem) error {
+	client, err := s.nodeProvider.GetNodeStorage(ctx, item.NodeName)
+	if err != nil {
+		return fmt.Errorf("get node connection: %w", err)
+	}
+
+	domain, err := client.GetDomainByName(item.RecycledName)
+	if err == nil {
+		disks, err := client.GetDomainDisks(item.RecycledName)
+		if err != nil {
+			return fmt.Errorf("get domain disks: %w", err)
+		}
+		if err := s.deleteInstance(ctx, client, domain, item.ResourceID, disks, item.DeleteVolumes); err != nil {
+			return err
+		}
+	}
+
+	if err := s.recycleBin.Delete(item); err != nil {
+		return err
+	}
+	s.recordEvent(ctx, item.NodeName, item.ResourceID, entity.InstanceEventTerminated, "terminated",
+		fmt.Sprintf("purged from recycle bin after %s", time.Since(item.DeletedAt).Round(time.Hour)))
+	s.clearInstanceOwner(ctx, item.NodeName, item.ResourceID)
+	s.clearGuestOS(ctx, item.NodeName, item.ResourceID)
+	s.clearInstanceLabels(ctx, item.NodeName, item.ResourceID)
+	s.clearInstanceMetadata(ctx, item.NodeName, item.ResourceID)
+	return nil
+}
+
+// clearInstanceOwner 实例物理删除后清理归属记录，移入回收站的实例保留归属以便恢复
+func (s *InstanceService) clearInstanceOwner(ctx context.Context, nodeName, instanceID string) {
+	if err := s.quotas.SetInstanceOwner(nodeName, instanceID, ""); err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("instanceID", instanceID).
+			Msg("Failed to clear instance owner")
+	}
+}
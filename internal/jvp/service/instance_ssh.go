@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+// sshUserData user-data 中与 SSH 登录相关的字段
+type sshUserData struct {
+	Users             []any    `yaml:"users"`
+	SSHAuthorizedKeys []string `yaml:"ssh_authorized_keys"`
+}
+
+// DescribeInstanceSSHTarget 查询实例的 SSH 登录信息：IP、user-data 中的登录用户与密钥对，以及是否需要经宿主机跳转
+func (s *InstanceService) DescribeInstanceSSHTarget(ctx context.Context, req *entity.DescribeInstanceSSHTargetRequest) (*entity.InstanceSSHTarget, error) {
+	logger := zerolog.Ctx(ctx)
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	domain, err := client.GetDomainByName(req.InstanceID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+	domainInfo, err := client.GetDomainInfo(domain.UUID)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get domain info", err)
+	}
+
+	target := &entity.InstanceSSHTarget{
+		InstanceID: req.InstanceID,
+		NodeName:   req.NodeName,
+		Port:       22,
+	}
+	for _, iface := range convertInterfaces(client, domainInfo.NetworkInfo) {
+		for _, ip := range iface.IPs {
+			target.IPs = append(target.IPs, ip)
+			// 优先使用 IPv4，没有 IPv4 时退回第一个地址
+			if target.Host == "" || (strings.Contains(target.Host, ":") && !strings.Contains(ip, ":")) {
+				target.Host = ip
+				target.JumpRequired = iface.Type == "network"
+			}
+		}
+	}
+	if target.Host == "" {
+		return nil, apierror.NewErrorWithStatus(
+			"IncorrectInstanceState",
+			fmt.Sprintf("Instance %s has no IP address yet, make sure it is running and has obtained a DHCP lease", req.InstanceID),
+			http.StatusConflict,
+		)
+	}
+
+	if client.IsRemoteConnection() {
+		target.ProxyJump = nodeProxyJump(client.GetConnectionURI())
+	}
+
+	// 登录用户优先取创建时记录在域 metadata 中的用户（含模板默认用户与 Ignition 的 core），
+	// 没有记录时（旧实例、原始 user-data）取 user-data 中的第一个命名用户；密钥对来自 cidata 或元数据服务中的 user-data，
+	// 读取失败不影响返回 IP
+	target.User = domainInfo.LoginUser
+	if user, keys, err := s.instanceSSHLogin(client, req.NodeName, req.InstanceID, target.User); err != nil {
+		logger.Warn().
+			Err(err).
+			Str("instanceID", req.InstanceID).
+			Msg("Failed to read SSH login from user data")
+	} else {
+		target.User = user
+		target.KeyPairs = s.matchKeyPairs(ctx, keys)
+	}
+
+	return target, nil
+}
+
+// instanceSSHLogin 从实例 user-data 中解析登录用户与注入的公钥，实例未使用 cloud-init 时原样返回 user
+// user 为空时登录用户取第一个命名用户；只有 default 用户时为空，名称取决于镜像
+func (s *InstanceService) instanceSSHLogin(client libvirt.LibvirtClient, nodeName, instanceID, user string) (string, []string, error) {
+	userData, ok, err := s.readInstanceUserData(client, nodeName, instanceID)
+	if err != nil || !ok {
+		return user, nil, err
+	}
+	return parseSSHLogin(userData, user)
+}
+
+// parseSSHLogin 解析 cloud-config 中登录用户的公钥，顶层 ssh_authorized_keys 同样计入
+// user 为空时取第一个命名用户
+func parseSSHLogin(userData, user string) (string, []string, error) {
+	if !strings.HasPrefix(userData, "#cloud-config") {
+		return user, nil, nil
+	}
+
+	var parsed sshUserData
+	if err := yaml.Unmarshal([]byte(userData), &parsed); err != nil {
+		return user, nil, fmt.Errorf("parse user data: %w", err)
+	}
+
+	keys := parsed.SSHAuthorizedKeys
+	for _, u := range parsed.Users {
+		m, ok := u.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := m["name"].(string)
+		if user == "" && name != "" {
+			user = name
+		}
+		if name != user {
+			continue
+		}
+		if userKeys, ok := m["ssh_authorized_keys"].([]any); ok {
+			for _, key := range userKeys {
+				if key, ok := key.(string); ok {
+					keys = append(keys, key)
+				}
+			}
+		}
+	}
+	return user, keys, nil
+}
+
+// matchKeyPairs 返回公钥与 keys 中任意一项相同的密钥对，比较时忽略公钥注释
+func (s *InstanceService) matchKeyPairs(ctx context.Context, keys []string) []entity.KeyPair {
+	if len(keys) == 0 {
+		return nil
+	}
+	authorized := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		authorized[publicKeyIdentity(key)] = true
+	}
+
+	keyPairs, err := s.keyPairService.DescribeKeyPairs(ctx, nil)
+	if err != nil {
+		return nil
+	}
+	var matched []entity.KeyPair
+	for _, keyPair := range keyPairs {
+		if authorized[publicKeyIdentity(keyPair.PublicKey)] {
+			matched = append(matched, keyPair)
+		}
+	}
+	return matched
+}
+
+// publicKeyIdentity 取 authorized_keys 格式公钥的类型与内容，去掉注释
+func publicKeyIdentity(key string) string {
+	fields := strings.Fields(key)
+	if len(fields) < 2 {
+		return key
+	}
+	return fields[0] + " " + fields[1]
+}
+
+// nodeProxyJump 从远程节点的 libvirt URI 生成 SSH 跳板地址 user@host[:port]，用户缺省为 root
+// 只有 ssh 传输的 URI 端口是 SSH 端口，tcp/tls 的端口属于 libvirtd，不带入跳板地址
+func nodeProxyJump(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Hostname() == "" {
+		return ""
+	}
+	user := parsed.User.Username()
+	if user == "" {
+		user = "root"
+	}
+	host := parsed.Hostname()
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := parsed.Port(); port != "" && strings.Contains(strings.ToLower(parsed.Scheme), "ssh") {
+		return user + "@" + net.JoinHostPort(parsed.Hostname(), port)
+	}
+	return user + "@" + host
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunInstanceRecordsLoginUser(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	keyPair, err := s.keyPairs.CreateKeyPair(ctx, &entity.CreateKeyPairRequest{Name: "login"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		defaultUser string
+		want        string
+	}{
+		{name: "vm-default-user", defaultUser: "alice", want: "alice"},
+		// 注入发行版默认用户时用户名取决于镜像，不记录
+		{name: "vm-distro-user", want: ""},
+	}
+	for _, tt := range tests {
+		instance, err := s.instances.RunInstance(ctx, &entity.RunInstanceRequest{
+			NodeName:    testNodeName,
+			PoolName:    testPoolName,
+			Name:        tt.name,
+			SizeGB:      10,
+			MemoryMB:    512,
+			VCPUs:       1,
+			NetworkType: "network",
+			KeyPairIDs:  []string{keyPair.KeyPair.ID},
+			DefaultUser: tt.defaultUser,
+		})
+		require.NoError(t, err, tt.name)
+
+		domain, err := s.fake.GetDomainByName(instance.ID)
+		require.NoError(t, err)
+		info, err := s.fake.GetDomainInfo(domain.UUID)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, info.LoginUser, tt.name)
+	}
+}
+
+func TestParseSSHLogin(t *testing.T) {
+	userData := `#cloud-config
+ssh_authorized_keys:
+  - ssh-ed25519 AAAAtop top
+users:
+  - default
+  - name: bob
+    ssh_authorized_keys:
+      - ssh-ed25519 AAAAbob bob
+  - name: alice
+    ssh_authorized_keys:
+      - ssh-ed25519 AAAAalice alice
+`
+	user, keys, err := parseSSHLogin(userData, "")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", user)
+	assert.Equal(t, []string{"ssh-ed25519 AAAAtop top", "ssh-ed25519 AAAAbob bob"}, keys)
+
+	// 创建时记录的登录用户优先于第一个命名用户
+	user, keys, err = parseSSHLogin(userData, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user)
+	assert.Equal(t, []string{"ssh-ed25519 AAAAtop top", "ssh-ed25519 AAAAalice alice"}, keys)
+
+	user, keys, err = parseSSHLogin("#!/bin/sh\necho hi\n", "core")
+	require.NoError(t, err)
+	assert.Equal(t, "core", user)
+	assert.Empty(t, keys)
+}
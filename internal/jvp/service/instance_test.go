@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceLifecycle(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	instance := s.runTestInstance(t, "vm-lifecycle")
+	assert.Equal(t, "vm-lifecycle", instance.ID)
+	assert.Equal(t, uint64(512), instance.MemoryMB)
+	assert.Equal(t, uint16(1), instance.VCPUs)
+
+	got, err := s.instances.GetInstance(ctx, testNodeName, instance.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "running", got.State)
+
+	changes, err := s.instances.StopInstances(ctx, &entity.StopInstancesRequest{
+		NodeName:    testNodeName,
+		InstanceIDs: []string{instance.ID},
+		Force:       true,
+	})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	got, err = s.instances.GetInstance(ctx, testNodeName, instance.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "stopped", got.State)
+
+	_, err = s.instances.StartInstances(ctx, &entity.StartInstancesRequest{
+		NodeName:    testNodeName,
+		InstanceIDs: []string{instance.ID},
+	})
+	require.NoError(t, err)
+	got, err = s.instances.GetInstance(ctx, testNodeName, instance.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "running", got.State)
+
+	_, err = s.instances.TerminateInstances(ctx, &entity.TerminateInstancesRequest{
+		NodeName:      testNodeName,
+		InstanceIDs:   []string{instance.ID},
+		DeleteVolumes: true,
+		Permanent:     true,
+	})
+	require.NoError(t, err)
+	_, err = s.instances.GetInstance(ctx, testNodeName, instance.ID)
+	assert.Error(t, err)
+}
+
+func TestRunInstanceDryRun(t *testing.T) {
+	s := newTestServices(t)
+	ctx := WithDryRun(context.Background(), true)
+
+	_, err := s.instances.RunInstance(ctx, &entity.RunInstanceRequest{
+		NodeName:    testNodeName,
+		PoolName:    testPoolName,
+		Name:        "vm-dry-run",
+		SizeGB:      10,
+		MemoryMB:    512,
+		VCPUs:       1,
+		NetworkType: "network",
+	})
+	require.ErrorIs(t, err, apierror.ErrDryRunOperation)
+
+	_, err = s.instances.GetInstance(context.Background(), testNodeName, "vm-dry-run")
+	assert.Error(t, err)
+}
+
+func TestTerminateInstanceProtected(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	instance := s.runTestInstance(t, "vm-protected")
+	protected := true
+	_, err := s.instances.ModifyInstanceAttribute(ctx, &entity.ModifyInstanceAttributeRequest{
+		NodeName:              testNodeName,
+		InstanceID:            instance.ID,
+		DisableAPITermination: &protected,
+	})
+	require.NoError(t, err)
+
+	_, err = s.instances.TerminateInstances(ctx, &entity.TerminateInstancesRequest{
+		NodeName:    testNodeName,
+		InstanceIDs: []string{instance.ID},
+		Permanent:   true,
+	})
+	require.Error(t, err)
+	_, err = s.instances.GetInstance(ctx, testNodeName, instance.ID)
+	assert.NoError(t, err)
+}
+
+func TestRunInstanceQEMUArgsUnsafeAdminOnly(t *testing.T) {
+	s := newTestServices(t)
+	req := &entity.RunInstanceRequest{
+		NodeName:       testNodeName,
+		PoolName:       testPoolName,
+		Name:           "vm-qemu-args",
+		SizeGB:         10,
+		MemoryMB:       512,
+		VCPUs:          1,
+		NetworkType:    "network",
+		QEMUArgs:       []string{"-fw_cfg", "name=opt/jvp,file=/etc/shadow"},
+		QEMUArgsUnsafe: true,
+	}
+
+	tenantCtx := context.WithValue(context.Background(), TenantContextKey, "team-a")
+	_, err := s.instances.RunInstance(tenantCtx, req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only administrators")
+
+	// 不设置 unsafe 时读取宿主机文件的子选项直接拒绝
+	req.QEMUArgsUnsafe = false
+	_, err = s.instances.RunInstance(tenantCtx, req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reads host files")
+}
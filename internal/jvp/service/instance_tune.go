@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+)
+
+// ModifyInstanceCPUTune 修改实例 CPU 权重与上限（cputune）
+// 运行中实例通过 DomainSetSchedulerParametersFlags 立即生效，同时写入持久化配置；关机实例只修改持久化配置
+func (s *InstanceService) ModifyInstanceCPUTune(ctx context.Context, req *entity.ModifyInstanceCPUTuneRequest) (*entity.ModifyInstanceCPUTuneResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Uint64("shares", req.Shares).
+		Uint64("period", req.Period).
+		Int64("quota", req.Quota).
+		Msg("Modifying instance CPU tune")
+
+	tune := libvirt.CPUTune{Shares: req.Shares, Period: req.Period, Quota: req.Quota}
+	if tune.Shares == 0 && tune.Period == 0 && tune.Quota == 0 {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"At least one of shares, period or quota is required",
+			http.StatusBadRequest,
+		)
+	}
+	if err := tune.Validate(); err != nil {
+		return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+	}
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	domain, err := client.GetDomainByName(req.InstanceID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	unlock, err := s.lockInstanceVersion(ctx, req.NodeName, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ModifyInstanceCPUTune")
+	}
+
+	if err := client.SetDomainCPUTune(req.InstanceID, tune); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to set CPU tune", err)
+	}
+
+	info, err := client.GetDomainInfo(domain.UUID)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get instance info", err)
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Msg("Instance CPU tune modified")
+
+	s.recordEvent(ctx, req.NodeName, req.InstanceID, entity.InstanceEventModified, "",
+		fmt.Sprintf("cputune shares=%d period=%d quota=%d", req.Shares, req.Period, req.Quota))
+
+	return &entity.ModifyInstanceCPUTuneResponse{
+		InstanceID: req.InstanceID,
+		CPUTune:    fromLibvirtCPUTune(info.CPUTune),
+	}, nil
+}
+
+// ModifyInstanceBlkioTune 修改实例磁盘 IO 权重（blkiotune weight）
+// 运行中实例通过 DomainSetBlkioParameters 立即生效，同时写入持久化配置；关机实例只修改持久化配置
+func (s *InstanceService) ModifyInstanceBlkioTune(ctx context.Context, req *entity.ModifyInstanceBlkioTuneRequest) (*entity.ModifyInstanceBlkioTuneResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("instanceID", req.InstanceID).
+		Uint("weight", req.Weight).
+		Msg("Modifying instance blkio tune")
+
+	tune := libvirt.BlkioTune{Weight: req.Weight}
+	if err := tune.Validate(); err != nil {
+		return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+	}
+
+	client, err := s.nodeProvider.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	domain, err := client.GetDomainByName(req.InstanceID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", req.InstanceID),
+			http.StatusNotFound,
+		)
+	}
+
+	unlock, err := s.lockInstanceVersion(ctx, req.NodeName, req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ModifyInstanceBlkioTune")
+	}
+
+	if err := client.SetDomainBlkioTune(req.InstanceID, tune); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to set blkio tune", err)
+	}
+
+	info, err := client.GetDomainInfo(domain.UUID)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get instance info", err)
+	}
+
+	logger.Info().
+		Str("instanceID", req.InstanceID).
+		Uint("weight", req.Weight).
+		Msg("Instance blkio tune modified")
+
+	s.recordEvent(ctx, req.NodeName, req.InstanceID, entity.InstanceEventModified, "",
+		fmt.Sprintf("blkiotune weight=%d", req.Weight))
+
+	return &entity.ModifyInstanceBlkioTuneResponse{
+		InstanceID: req.InstanceID,
+		BlkioTune:  fromLibvirtBlkioTune(info.BlkioTune),
+	}, nil
+}
+
+// toLibvirtCPUTune 把 API 的 CPU 调度参数转换为 libvirt 配置
+func toLibvirtCPUTune(t *entity.CPUTune) *libvirt.CPUTune {
+	if t == nil {
+		return nil
+	}
+	return &libvirt.CPUTune{Shares: t.Shares, Period: t.Period, Quota: t.Quota}
+}
+
+// fromLibvirtCPUTune 把 libvirt 的 CPU 调度参数转换为 API 返回值
+func fromLibvirtCPUTune(t *libvirt.CPUTune) *entity.CPUTune {
+	if t == nil {
+		return nil
+	}
+	return &entity.CPUTune{Shares: t.Shares, Period: t.Period, Quota: t.Quota}
+}
+
+// toLibvirtBlkioTune 把 API 的 IO 调优参数转换为 libvirt 配置
+func toLibvirtBlkioTune(t *entity.BlkioTune) *libvirt.BlkioTune {
+	if t == nil {
+		return nil
+	}
+	return &libvirt.BlkioTune{Weight: t.Weight}
+}
+
+// fromLibvirtBlkioTune 把 libvirt 的 IO 调优参数转换为 API 返回值
+func fromLibvirtBlkioTune(t *libvirt.BlkioTune) *entity.BlkioTune {
+	if t == nil {
+		return nil
+	}
+	return &entity.BlkioTune{Weight: t.Weight}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+)
+
+// IfMatchContextKey 请求期望的资源版本在上下文中的 key，由 API 中间件从 If-Match 请求头写入
+const IfMatchContextKey = "jvp.if_match"
+
+// instanceVersionFields 参与实例版本计算的配置字段
+// 只包含通过 API 修改的配置，运行状态、启动时间、IP、磁盘占用等随运行变化的字段不影响版本
+type instanceVersionFields struct {
+	MemoryMB              uint64                     `json:"memory_mb"`
+	MaxMemoryMB           uint64                     `json:"max_memory_mb"`
+	VCPUs                 uint16                     `json:"vcpus"`
+	MaxVCPUs              uint16                     `json:"max_vcpus"`
+	CPUTune               *entity.CPUTune            `json:"cputune"`
+	BlkioTune             *entity.BlkioTune          `json:"blkiotune"`
+	NUMATune              *entity.NUMATune           `json:"numatune"`
+	Autostart             bool                       `json:"autostart"`
+	DisableAPITermination bool                       `json:"disable_api_termination"`
+	Disks                 []instanceDiskVersion      `json:"disks"`
+	Interfaces            []instanceInterfaceVersion `json:"interfaces"`
+}
+
+// instanceDiskVersion 参与版本计算的磁盘配置
+type instanceDiskVersion struct {
+	Target  string `json:"target"`
+	Path    string `json:"path"`
+	Format  string `json:"format"`
+	Bus     string `json:"bus"`
+	Cache   string `json:"cache"`
+	IO      string `json:"io"`
+	Discard string `json:"discard"`
+}
+
+// instanceInterfaceVersion 参与版本计算的网卡配置
+type instanceInterfaceVersion struct {
+	MAC       string                     `json:"mac"`
+	Source    string                     `json:"source"`
+	Bandwidth *entity.InterfaceBandwidth `json:"bandwidth"`
+}
+
+// instanceVersion 根据实例配置计算版本号，配置不变时版本号不变
+func instanceVersion(instance *entity.Instance) string {
+	fields := instanceVersionFields{
+		MemoryMB:              instance.MemoryMB,
+		MaxMemoryMB:           instance.MaxMemoryMB,
+		VCPUs:                 instance.VCPUs,
+		MaxVCPUs:              instance.MaxVCPUs,
+		CPUTune:               instance.CPUTune,
+		BlkioTune:             instance.BlkioTune,
+		NUMATune:              instance.NUMATune,
+		Autostart:             instance.Autostart,
+		DisableAPITermination: instance.DisableAPITermination,
+	}
+	for _, disk := range instance.Disks {
+		fields.Disks = append(fields.Disks, instanceDiskVersion{
+			Target:  disk.Target,
+			Path:    disk.Path,
+			Format:  disk.Format,
+			Bus:     disk.Bus,
+			Cache:   disk.Cache,
+			IO:      disk.IO,
+			Discard: disk.Discard,
+		})
+	}
+	for _, iface := range instance.Interfaces {
+		fields.Interfaces = append(fields.Interfaces, instanceInterfaceVersion{
+			MAC:       iface.MAC,
+			Source:    iface.Source,
+			Bandwidth: iface.Bandwidth,
+		})
+	}
+
+	data, _ := json.Marshal(fields)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// lockInstanceVersion 串行化同一实例的修改请求，保证 If-Match 校验与修改之间版本不会被其他请求改变
+// 返回 unlock，调用方需在修改完成后调用；请求携带 If-Match 时校验实例当前版本，不一致返回 PreconditionFailed
+func (s *InstanceService) lockInstanceVersion(ctx context.Context, nodeName, instanceID string) (func(), error) {
+	value, _ := s.modifyLocks.LoadOrStore(nodeName+"/"+instanceID, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+
+	expected, _ := ctx.Value(IfMatchContextKey).(string)
+	if expected == "" {
+		return mu.Unlock, nil
+	}
+	instance, err := s.GetInstance(ctx, nodeName, instanceID)
+	if err != nil {
+		mu.Unlock()
+		return nil, apierror.WrapError(
+			apierror.ErrPreconditionFailed,
+			fmt.Sprintf("Instance %s not found", instanceID),
+			err,
+		)
+	}
+	if err := checkIfMatch(ctx, instance); err != nil {
+		mu.Unlock()
+		return nil, err
+	}
+	return mu.Unlock, nil
+}
+
+// checkIfMatch 校验请求的 If-Match 与实例版本一致，未携带 If-Match 或为 * 时不校验
+func checkIfMatch(ctx context.Context, instance *entity.Instance) error {
+	expected, _ := ctx.Value(IfMatchContextKey).(string)
+	if expected == "" || expected == "*" || expected == instance.Version {
+		return nil
+	}
+	return apierror.WrapError(
+		apierror.ErrPreconditionFailed,
+		fmt.Sprintf("Instance %s has been modified: current version is %s, If-Match is %s", instance.ID, instance.Version, expected),
+		nil,
+	)
+}
@@ -45,7 +45,7 @@ func NewKeyPairService() (*KeyPairService, error) {
 	}
 
 	return &KeyPairService{
-		idGen:      idgen.New(),
+		idGen:      idgen.DefaultGenerator().Namespace(idgen.NamespaceKeyPair),
 		storageDir: storageDir,
 	}, nil
 }
@@ -80,6 +80,10 @@ func (s *KeyPairService) CreateKeyPair(ctx context.Context, req *entity.CreateKe
 		)
 	}
 
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CreateKeyPair")
+	}
+
 	// 生成密钥对
 	var publicKeyStr string
 	var privateKeyStr string
@@ -160,6 +164,10 @@ func (s *KeyPairService) ImportKeyPair(ctx context.Context, req *entity.ImportKe
 	_ = comment
 	_ = options
 
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ImportKeyPair")
+	}
+
 	// 格式化公钥为 OpenSSH 格式
 	publicKeyStr := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey)))
 
@@ -218,6 +226,10 @@ func (s *KeyPairService) DeleteKeyPair(ctx context.Context, keyPairID string) er
 		)
 	}
 
+	if isDryRun(ctx) {
+		return dryRunOperation(ctx, "DeleteKeyPair")
+	}
+
 	// 删除文件
 	filePath := filepath.Join(s.storageDir, keyPairID+".json")
 	if err := os.Remove(filePath); err != nil {
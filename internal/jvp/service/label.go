@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// maxLabels 单个资源的标签数量上限
+	maxLabels = 50
+	// maxLabelKeyLength 标签 key 的最大长度
+	maxLabelKeyLength = 63
+	// maxLabelValueLength 标签 value 的最大长度
+	maxLabelValueLength = 255
+)
+
+// labelKeyPattern 标签 key 字符集：字母或数字开头，后续允许字母、数字、'.'、'_'、'-'、'/'
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+
+// LabelStore 资源标签存储，实例与卷各用一个目录
+// 每个节点一个 JSON 文件：<dataDir>/<dir>/<node>.json，实例以实例 ID 为 key，卷以文件路径为 key（路径在节点内唯一）
+type LabelStore struct {
+	storageDir string
+	mu         sync.Mutex
+}
+
+// NewInstanceLabelStore 创建实例标签存储
+func NewInstanceLabelStore(dataDir string) (*LabelStore, error) {
+	return newLabelStore(dataDir, "instance-labels")
+}
+
+// NewVolumeLabelStore 创建卷标签存储
+func NewVolumeLabelStore(dataDir string) (*LabelStore, error) {
+	return newLabelStore(dataDir, "volume-labels")
+}
+
+func newLabelStore(dataDir, dir string) (*LabelStore, error) {
+	storageDir := filepath.Join(dataDir, dir)
+	if err := os.MkdirAll(storageDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s directory: %w", dir, err)
+	}
+	return &LabelStore{storageDir: storageDir}, nil
+}
+
+// getStatePath 获取节点的标签文件路径
+func (l *LabelStore) getStatePath(nodeName string) string {
+	return filepath.Join(l.storageDir, nodeName+".json")
+}
+
+func (l *LabelStore) loadUnlocked(nodeName string) (map[string]map[string]string, error) {
+	state := make(map[string]map[string]string)
+	data, err := os.ReadFile(l.getStatePath(nodeName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read labels: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal labels: %w", err)
+	}
+	return state, nil
+}
+
+// Resources 返回节点上所有资源的标签
+func (l *LabelStore) Resources(nodeName string) (map[string]map[string]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.loadUnlocked(nodeName)
+}
+
+// Set 整体替换资源的标签，labels 为空时删除记录
+func (l *LabelStore) Set(nodeName, key string, labels map[string]string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, err := l.loadUnlocked(nodeName)
+	if err != nil {
+		return err
+	}
+	if len(labels) == 0 {
+		if _, ok := state[key]; !ok {
+			return nil
+		}
+		delete(state, key)
+	} else {
+		state[key] = labels
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal labels: %w", err)
+	}
+	if err := os.WriteFile(l.getStatePath(nodeName), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write labels: %w", err)
+	}
+	return nil
+}
+
+// validateLabels 校验标签数量、key 字符集与长度
+func validateLabels(labels map[string]string) error {
+	if len(labels) > maxLabels {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("too many labels (%d), at most %d are allowed", len(labels), maxLabels),
+			http.StatusBadRequest,
+		)
+	}
+	for key, value := range labels {
+		if len(key) > maxLabelKeyLength || !labelKeyPattern.MatchString(key) {
+			return apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("label key %q is invalid, it must start with a letter or digit, contain only letters, digits, '.', '_', '-' or '/', and be at most %d characters", key, maxLabelKeyLength),
+				http.StatusBadRequest,
+			)
+		}
+		if len(value) > maxLabelValueLength {
+			return apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("value of label %q is too long, at most %d characters are allowed", key, maxLabelValueLength),
+				http.StatusBadRequest,
+			)
+		}
+	}
+	return nil
+}
+
+// matchLabels 资源标签是否包含 selector 中的全部键值
+func matchLabels(labels, selector map[string]string) bool {
+	for key, value := range selector {
+		if actual, ok := labels[key]; !ok || actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+// clearInstanceLabels 实例物理删除后清理标签，移入回收站的实例保留标签以便恢复
+func (s *InstanceService) clearInstanceLabels(ctx context.Context, nodeName, instanceID string) {
+	if err := s.labels.Set(nodeName, instanceID, nil); err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("instanceID", instanceID).
+			Msg("Failed to clear instance labels")
+	}
+}
+
+// matchTagFilters 资源标签是否满足全部标签过滤器，其他名称的过滤器忽略
+//   - label:<key>、tag:<key>：标签 key 的值为 values 之一，values 为空时只要求存在该标签
+//   - tag-key：存在 values 中任一 key 的标签
+func matchTagFilters(labels map[string]string, filters []entity.Filter) bool {
+	for _, filter := range filters {
+		if filter.Name == "tag-key" {
+			if len(filter.Values) > 0 && !slices.ContainsFunc(filter.Values, func(key string) bool {
+				_, ok := labels[key]
+				return ok
+			}) {
+				return false
+			}
+			continue
+		}
+		key, ok := strings.CutPrefix(filter.Name, "tag:")
+		if !ok {
+			key, ok = strings.CutPrefix(filter.Name, "label:")
+		}
+		if !ok {
+			continue
+		}
+		value, exists := labels[key]
+		if !exists || (len(filter.Values) > 0 && !slices.Contains(filter.Values, value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// instanceLabels 实例标签以数据目录中的记录为准，记录丢失时使用域 metadata 中的副本
+func instanceLabels(stored map[string]map[string]string, instanceID string, domainInfo *libvirt.DomainInfo) map[string]string {
+	if labels, ok := stored[instanceID]; ok {
+		return labels
+	}
+	return domainInfo.Labels
+}
+
+// setInstanceLabels 整体替换实例标签，先写入域 metadata 再更新记录，数据目录丢失后仍可从域恢复
+func (s *InstanceService) setInstanceLabels(client libvirt.LibvirtClient, nodeName, instanceID string, labels map[string]string) error {
+	if err := client.SetDomainLabels(instanceID, labels); err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to write instance labels to domain metadata", err)
+	}
+	if err := s.labels.Set(nodeName, instanceID, labels); err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to modify instance labels", err)
+	}
+	return nil
+}
+
+// clearVolumeLabels 卷物理删除后清理标签，移入回收站的卷保留标签以便恢复
+func (s *VolumeService) clearVolumeLabels(ctx context.Context, nodeName, volumePath string) {
+	if volumePath == "" {
+		return
+	}
+	if err := s.labels.Set(nodeName, volumePath, nil); err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("path", volumePath).
+			Msg("Failed to clear volume labels")
+	}
+}
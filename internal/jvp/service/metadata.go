@@ -0,0 +1,424 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/cloudinit"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// metadataIP guest 访问元数据服务的链路本地地址
+	metadataIP = "169.254.169.254"
+	// metadataSeedURL cloud-init NoCloud 数据源地址，写入实例的 SMBIOS system serial
+	metadataSeedURL = "http://" + metadataIP + "/nocloud/"
+)
+
+// instanceMetadataRecord 元数据服务为实例提供的 cloud-init 配置，创建实例时生成
+type instanceMetadataRecord struct {
+	MetaData      string              `json:"meta_data"`                // NoCloud meta-data（含 cloud-init instance-id 与主机名）
+	UserData      string              `json:"user_data"`                // user-data
+	NetworkConfig string              `json:"network_config,omitempty"` // network-config，ipv4 实例为空
+	PublicKeys    []metadataPublicKey `json:"public_keys,omitempty"`    // keypair_ids 的公钥，EC2 元数据的 public-keys
+}
+
+// metadataPublicKey EC2 元数据中的公钥
+type metadataPublicKey struct {
+	Name       string `json:"name"`
+	OpenSSHKey string `json:"openssh_key"`
+}
+
+// InstanceMetadataStore 实例元数据存储
+// 每个节点一个 JSON 文件：<dataDir>/instance-metadata/<node>.json，key 为实例 ID
+type InstanceMetadataStore struct {
+	storageDir string
+	mu         sync.Mutex
+}
+
+// NewInstanceMetadataStore 创建实例元数据存储
+// user-data 中可能有密码哈希与私钥，目录与文件只允许 jvp 进程用户访问，已有目录同样收紧权限
+func NewInstanceMetadataStore(dataDir string) (*InstanceMetadataStore, error) {
+	storageDir := filepath.Join(dataDir, "instance-metadata")
+	if err := os.MkdirAll(storageDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create instance metadata directory: %w", err)
+	}
+	if err := os.Chmod(storageDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to restrict instance metadata directory: %w", err)
+	}
+	return &InstanceMetadataStore{storageDir: storageDir}, nil
+}
+
+// getStatePath 获取节点的实例元数据文件路径
+func (m *InstanceMetadataStore) getStatePath(nodeName string) string {
+	return filepath.Join(m.storageDir, nodeName+".json")
+}
+
+func (m *InstanceMetadataStore) loadUnlocked(nodeName string) (map[string]*instanceMetadataRecord, error) {
+	state := make(map[string]*instanceMetadataRecord)
+	data, err := os.ReadFile(m.getStatePath(nodeName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read instance metadata: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal instance metadata: %w", err)
+	}
+	return state, nil
+}
+
+// Nodes 返回存在元数据记录的节点
+func (m *InstanceMetadataStore) Nodes() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries, err := os.ReadDir(m.storageDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list instance metadata: %w", err)
+	}
+	var nodes []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".json"); ok && !entry.IsDir() {
+			nodes = append(nodes, name)
+		}
+	}
+	return nodes, nil
+}
+
+// Instances 返回节点上所有实例的元数据
+func (m *InstanceMetadataStore) Instances(nodeName string) (map[string]*instanceMetadataRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.loadUnlocked(nodeName)
+}
+
+// Get 返回实例的元数据，不存在时返回 nil
+func (m *InstanceMetadataStore) Get(nodeName, instanceID string) (*instanceMetadataRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, err := m.loadUnlocked(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return state[instanceID], nil
+}
+
+// Set 整体替换实例的元数据，record 为 nil 时删除记录
+func (m *InstanceMetadataStore) Set(nodeName, instanceID string, record *instanceMetadataRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, err := m.loadUnlocked(nodeName)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		if _, ok := state[instanceID]; !ok {
+			return nil
+		}
+		delete(state, instanceID)
+	} else {
+		state[instanceID] = record
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal instance metadata: %w", err)
+	}
+	// 先写 0600 的临时文件再重命名，之前以 0644 写入的文件也随之收紧权限
+	path := m.getStatePath(nodeName)
+	tmpPath := path + ".tmp"
+	_ = os.Remove(tmpPath)
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write instance metadata: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename instance metadata: %w", err)
+	}
+	return nil
+}
+
+// MetadataInstance 元数据请求对应的实例
+type MetadataInstance struct {
+	NodeName      string
+	InstanceID    string
+	IP            string // 请求的源 IP
+	MAC           string // 持有该 IP 的网卡 MAC
+	MetaData      string
+	UserData      string
+	NetworkConfig string
+	publicKeys    []metadataPublicKey
+	cloudInitID   string // meta-data 中的 instance-id，修改 user-data 后变化，cloud-init 据此重新执行
+	hostname      string
+}
+
+// MetadataService 元数据服务：guest 通过 169.254.169.254 获取 cloud-init 配置，替代每个实例的 cidata ISO
+// 请求按源 IP 在各节点 libvirt 网络的 DHCP 租约中找到网卡 MAC，再由 MAC 找到实例
+type MetadataService struct {
+	nodeStorageFn NodeStorageGetter
+	store         *InstanceMetadataStore
+	enabled       bool
+
+	mu   sync.Mutex
+	macs map[string]string // key: nodeName/网卡 MAC（小写） -> 实例 ID
+}
+
+// NewMetadataService 创建元数据服务，enabled 为 false 时创建实例不能选择 metadata 下发方式
+func NewMetadataService(nodeStorageFn NodeStorageGetter, store *InstanceMetadataStore, enabled bool) *MetadataService {
+	return &MetadataService{
+		nodeStorageFn: nodeStorageFn,
+		store:         store,
+		enabled:       enabled,
+		macs:          make(map[string]string),
+	}
+}
+
+// Enabled 是否启用了元数据服务
+func (s *MetadataService) Enabled() bool {
+	return s.enabled
+}
+
+// LookupInstance 按请求的源 IP 查找实例，未找到或多个节点上有相同 IP 的租约时返回错误
+func (s *MetadataService) LookupInstance(ctx context.Context, ip string) (*MetadataInstance, error) {
+	logger := zerolog.Ctx(ctx)
+
+	nodes, err := s.store.Nodes()
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list instance metadata", err)
+	}
+
+	var found []*MetadataInstance
+	for _, nodeName := range nodes {
+		client, err := s.nodeStorageFn(ctx, nodeName)
+		if err != nil {
+			logger.Warn().
+				Err(err).
+				Str("node_name", nodeName).
+				Msg("Failed to get node connection for metadata lookup")
+			continue
+		}
+		for _, mac := range libvirt.ResolveMACsByIP(client, ip) {
+			instanceID, ok := s.instanceByMAC(ctx, client, nodeName, mac)
+			if !ok {
+				continue
+			}
+			record, err := s.store.Get(nodeName, instanceID)
+			if err != nil {
+				return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to load instance metadata", err)
+			}
+			if record == nil {
+				continue
+			}
+			instance, err := newMetadataInstance(nodeName, instanceID, ip, mac, record)
+			if err != nil {
+				return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to parse instance meta-data", err)
+			}
+			found = append(found, instance)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("No instance with metadata holds a DHCP lease for %s", ip),
+			http.StatusNotFound,
+		)
+	case 1:
+		return found[0], nil
+	default:
+		// 各节点的 libvirt 网络网段重叠时无法区分，拒绝返回以免泄露其他实例的 user-data
+		return nil, apierror.NewErrorWithStatus(
+			"AmbiguousMetadataSource",
+			fmt.Sprintf("DHCP leases for %s found on multiple instances, libvirt network subnets must not overlap across nodes", ip),
+			http.StatusConflict,
+		)
+	}
+}
+
+// instanceByMAC 查找节点上网卡 MAC 对应的实例，缓存未命中时重新读取该节点上有元数据记录的实例网卡
+func (s *MetadataService) instanceByMAC(ctx context.Context, client libvirt.LibvirtClient, nodeName, mac string) (string, bool) {
+	key := nodeName + "/" + mac
+	s.mu.Lock()
+	instanceID, ok := s.macs[key]
+	s.mu.Unlock()
+	if ok {
+		return instanceID, true
+	}
+
+	records, err := s.store.Instances(nodeName)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("node_name", nodeName).
+			Msg("Failed to load instance metadata")
+		return "", false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id := range records {
+		domain, err := client.GetDomainByName(id)
+		if err != nil {
+			continue
+		}
+		info, err := client.GetDomainInfo(domain.UUID)
+		if err != nil {
+			continue
+		}
+		for _, iface := range info.NetworkInfo {
+			s.macs[nodeName+"/"+strings.ToLower(iface.MAC)] = id
+		}
+	}
+	instanceID, ok = s.macs[key]
+	return instanceID, ok
+}
+
+// forget 删除实例的 MAC 缓存，实例删除后其 MAC 可能被新实例复用
+func (s *MetadataService) forget(nodeName, instanceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, id := range s.macs {
+		if id == instanceID && strings.HasPrefix(key, nodeName+"/") {
+			delete(s.macs, key)
+		}
+	}
+}
+
+// newMetadataInstance 由元数据记录构造请求对应的实例
+func newMetadataInstance(nodeName, instanceID, ip, mac string, record *instanceMetadataRecord) (*MetadataInstance, error) {
+	var metaData cloudinit.MetaData
+	if err := yaml.Unmarshal([]byte(record.MetaData), &metaData); err != nil {
+		return nil, err
+	}
+	return &MetadataInstance{
+		NodeName:      nodeName,
+		InstanceID:    instanceID,
+		IP:            ip,
+		MAC:           mac,
+		MetaData:      record.MetaData,
+		UserData:      record.UserData,
+		NetworkConfig: record.NetworkConfig,
+		publicKeys:    record.PublicKeys,
+		cloudInitID:   metaData.InstanceID,
+		hostname:      metaData.LocalHostname,
+	}, nil
+}
+
+// EC2MetaData 返回 EC2 兼容元数据中 meta-data/ 之后路径的内容，路径为目录时按行列出子项（子目录以 / 结尾）
+// instance-id 使用 cloud-init 的 instance-id，与 NoCloud 数据源一致，修改 user-data 后 cloud-init 会重新执行
+func (i *MetadataInstance) EC2MetaData(path string) (string, bool) {
+	path = strings.Trim(path, "/")
+	switch path {
+	case "":
+		entries := []string{"hostname", "instance-id", "local-hostname", "local-ipv4", "mac"}
+		if len(i.publicKeys) > 0 {
+			entries = append(entries, "public-keys/")
+		}
+		return strings.Join(entries, "\n"), true
+	case "hostname", "local-hostname":
+		return i.hostname, true
+	case "instance-id":
+		return i.cloudInitID, true
+	case "local-ipv4":
+		return i.IP, true
+	case "mac":
+		return i.MAC, true
+	case "public-keys":
+		if len(i.publicKeys) == 0 {
+			return "", false
+		}
+		entries := make([]string, 0, len(i.publicKeys))
+		for index, key := range i.publicKeys {
+			entries = append(entries, fmt.Sprintf("%d=%s", index, key.Name))
+		}
+		return strings.Join(entries, "\n"), true
+	}
+
+	// public-keys/<index>/ 与 public-keys/<index>/openssh-key
+	rest, ok := strings.CutPrefix(path, "public-keys/")
+	if !ok {
+		return "", false
+	}
+	indexStr, name, _ := strings.Cut(rest, "/")
+	index, err := strconv.Atoi(indexStr)
+	if err != nil || index < 0 || index >= len(i.publicKeys) {
+		return "", false
+	}
+	switch name {
+	case "":
+		return "openssh-key", true
+	case "openssh-key":
+		return i.publicKeys[index].OpenSSHKey, true
+	}
+	return "", false
+}
+
+// validateCloudInitSource 校验 cloud-init 配置的下发方式，metadata 需要启用元数据服务且实例接入 libvirt 网络
+func (s *MetadataService) validateCloudInitSource(source, networkType string) error {
+	switch source {
+	case "", entity.CloudInitSourceISO:
+		return nil
+	case entity.CloudInitSourceMetadata:
+	default:
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported cloud_init_source %q, must be iso or metadata", source),
+			http.StatusBadRequest,
+		)
+	}
+	if !s.enabled {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"cloud_init_source metadata requires the metadata service, set JVP_METADATA_ADDRESS to enable it",
+			http.StatusBadRequest,
+		)
+	}
+	// 元数据服务按 DHCP 租约识别实例，只有 libvirt 网络的租约由节点上的 dnsmasq 分配
+	if networkType != "network" {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("cloud_init_source metadata requires network type network, got %s", networkType),
+			http.StatusBadRequest,
+		)
+	}
+	return nil
+}
+
+// metadataPublicKeys 获取密钥对的名称与公钥，获取失败的密钥对跳过（与注入 user-data 时一致）
+func (s *InstanceService) metadataPublicKeys(ctx context.Context, keyPairIDs []string) []metadataPublicKey {
+	var keys []metadataPublicKey
+	for _, keyPairID := range keyPairIDs {
+		keyPair, err := s.keyPairService.GetKeyPairByID(ctx, keyPairID)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, metadataPublicKey{Name: keyPair.Name, OpenSSHKey: keyPair.PublicKey})
+	}
+	return keys
+}
+
+// clearInstanceMetadata 实例物理删除后清理元数据，移入回收站的实例保留元数据以便恢复
+func (s *InstanceService) clearInstanceMetadata(ctx context.Context, nodeName, instanceID string) {
+	s.metadata.forget(nodeName, instanceID)
+	if err := s.metadata.store.Set(nodeName, instanceID, nil); err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("instanceID", instanceID).
+			Msg("Failed to clear instance metadata")
+	}
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceMetadataStorePermissions(t *testing.T) {
+	dataDir := t.TempDir()
+	storageDir := filepath.Join(dataDir, "instance-metadata")
+	// 模拟旧版本以 0755/0644 创建的目录与文件
+	require.NoError(t, os.MkdirAll(storageDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(storageDir, "node1.json"), []byte("{}"), 0o644))
+
+	store, err := NewInstanceMetadataStore(dataDir)
+	require.NoError(t, err)
+	require.NoError(t, store.Set("node1", "vm-1", &instanceMetadataRecord{
+		UserData: "#cloud-config\nchpasswd:\n  users:\n    - {name: root, password: $6$salt$hash}\n",
+	}))
+
+	info, err := os.Stat(storageDir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+	info, err = os.Stat(filepath.Join(storageDir, "node1.json"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	record, err := store.Get("node1", "vm-1")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Contains(t, record.UserData, "chpasswd")
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// MultiAttachStore 卷的 multi-attach（共享盘）标志存储
+// 每个节点一个 JSON 文件：<dataDir>/multi-attach/<node>.json；卷按文件路径记录，路径在节点内唯一
+type MultiAttachStore struct {
+	storageDir string
+	mu         sync.Mutex
+}
+
+// multiAttachState 单个节点上开启了 multi-attach 的卷
+type multiAttachState struct {
+	Volumes map[string]bool `json:"volumes,omitempty"` // key: 卷文件路径
+}
+
+// NewMultiAttachStore 创建 multi-attach 标志存储
+func NewMultiAttachStore(dataDir string) (*MultiAttachStore, error) {
+	storageDir := filepath.Join(dataDir, "multi-attach")
+	if err := os.MkdirAll(storageDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create multi-attach directory: %w", err)
+	}
+	return &MultiAttachStore{storageDir: storageDir}, nil
+}
+
+// getStatePath 获取节点的 multi-attach 文件路径
+func (m *MultiAttachStore) getStatePath(nodeName string) string {
+	return filepath.Join(m.storageDir, nodeName+".json")
+}
+
+func (m *MultiAttachStore) loadUnlocked(nodeName string) (*multiAttachState, error) {
+	state := &multiAttachState{}
+	data, err := os.ReadFile(m.getStatePath(nodeName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read multi-attach state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal multi-attach state: %w", err)
+	}
+	return state, nil
+}
+
+// Volumes 返回节点上开启了 multi-attach 的卷路径
+func (m *MultiAttachStore) Volumes(nodeName string) (map[string]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, err := m.loadUnlocked(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return state.Volumes, nil
+}
+
+// SetVolume 开启或关闭卷的 multi-attach
+func (m *MultiAttachStore) SetVolume(nodeName, volumePath string, enabled bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, err := m.loadUnlocked(nodeName)
+	if err != nil {
+		return err
+	}
+	state.Volumes = setProtected(state.Volumes, volumePath, enabled)
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal multi-attach state: %w", err)
+	}
+	if err := os.WriteFile(m.getStatePath(nodeName), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write multi-attach state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/jimyag/jvp/pkg/apierror"
+)
+
+// maxResourceNameLength 资源名称最大长度
+// libvirt 对 domain/pool/network 名称没有硬性限制，但名称会出现在文件名、网桥名等位置，统一限制为 64
+const maxResourceNameLength = 64
+
+// resourceNamePattern 资源名称字符集：字母或数字开头，后续允许字母、数字、'.'、'_'、'-'
+var resourceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// validateResourceName 校验用户指定的资源名称（实例、卷、快照、网络、存储池）
+// 名称会直接作为 libvirt 对象名或文件名使用，提前校验以避免 libvirt 返回难以理解的错误
+func validateResourceName(kind, name string) error {
+	if len(name) > maxResourceNameLength {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("%s name %q is too long, at most %d characters are allowed", kind, name, maxResourceNameLength),
+			http.StatusBadRequest,
+		)
+	}
+	if !resourceNamePattern.MatchString(name) {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("%s name %q is invalid, it must start with a letter or digit and contain only letters, digits, '.', '_' or '-'", kind, name),
+			http.StatusBadRequest,
+		)
+	}
+	return nil
+}
+
+// interfaceNamePattern 宿主机网络接口名称：受 IFNAMSIZ 限制最多 15 个字符，字母或数字开头，
+// 后续允许字母、数字、'.'、'_'、'-'，拼接进节点上执行的命令时不会出现 shell 特殊字符
+var interfaceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,14}$`)
+
+// validateInterfaceName 校验用户指定的宿主机网络接口名称（网桥、绑定到网桥的网卡）
+func validateInterfaceName(kind, name string) error {
+	if !interfaceNamePattern.MatchString(name) {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("%s name %q is invalid, it must be at most 15 characters, start with a letter or digit and contain only letters, digits, '.', '_' or '-'", kind, name),
+			http.StatusBadRequest,
+		)
+	}
+	return nil
+}
+
+// newResourceAlreadyExistsError 返回资源重名错误
+func newResourceAlreadyExistsError(kind, name string) error {
+	return apierror.NewErrorWithStatus(
+		"ResourceAlreadyExists",
+		fmt.Sprintf("%s %s already exists", kind, name),
+		http.StatusConflict,
+	)
+}
@@ -3,9 +3,14 @@ package service
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/http"
+	"time"
 
 	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
 	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
 )
 
 // NetworkService 网络服务
@@ -13,18 +18,26 @@ import (
 type NetworkService struct {
 	nodeStorage   *NodeStorage
 	bridgeService *BridgeService
+	changes       *ChangeFeed
+	segments      *NetworkSegmentStore
+
+	ovsCleanupInterval time.Duration // 定期清理 OVS 残留端口的间隔，0 表示不定期清理
 }
 
 // NewNetworkService 创建网络服务
-func NewNetworkService(nodeStorage *NodeStorage, bridgeService *BridgeService) *NetworkService {
+func NewNetworkService(nodeStorage *NodeStorage, bridgeService *BridgeService, changes *ChangeFeed, segments *NetworkSegmentStore, ovsCleanupInterval time.Duration) *NetworkService {
 	return &NetworkService{
 		nodeStorage:   nodeStorage,
 		bridgeService: bridgeService,
+		changes:       changes,
+		segments:      segments,
+
+		ovsCleanupInterval: ovsCleanupInterval,
 	}
 }
 
 // getLibvirtClient 获取 libvirt 客户端（本地或远程节点）
-func (s *NetworkService) getLibvirtClient(nodeName string) (*libvirt.Client, error) {
+func (s *NetworkService) getLibvirtClient(nodeName string) (libvirt.LibvirtClient, error) {
 	if nodeName == "" {
 		return libvirt.New()
 	}
@@ -43,50 +56,32 @@ func (s *NetworkService) ListNetworks(ctx context.Context, nodeName string) ([]e
 		return nil, fmt.Errorf("list networks: %w", err)
 	}
 
+	segments, err := s.segments.All()
+	if err != nil {
+		return nil, fmt.Errorf("load network segments: %w", err)
+	}
+
 	networks := make([]entity.Network, 0, len(networkInfos))
 	for _, info := range networkInfos {
-		state := "inactive"
-		if info.Active {
-			state = "active"
+		network := convertNetworkInfo(nodeName, info)
+		if segment, ok := segments[info.Name]; ok {
+			if _, ok := segment.Nodes[nodeName]; ok {
+				network.Segmentation = segment.toEntity(nodeName)
+			}
 		}
-		networks = append(networks, entity.Network{
-			Name:       info.Name,
-			UUID:       info.UUID,
-			NodeName:   nodeName,
-			Type:       "libvirt",
-			Mode:       info.Mode,
-			Bridge:     info.Bridge,
-			State:      state,
-			Autostart:  info.Autostart,
-			Persistent: info.Persistent,
-			IPAddress:  info.IPAddress,
-			Netmask:    info.Netmask,
-			DHCPStart:  info.DHCPStart,
-			DHCPEnd:    info.DHCPEnd,
-		})
+		networks = append(networks, network)
 	}
 
 	return networks, nil
 }
 
-// DescribeNetwork 查询网络详情
-func (s *NetworkService) DescribeNetwork(ctx context.Context, nodeName, networkName string) (*entity.Network, error) {
-	client, err := s.getLibvirtClient(nodeName)
-	if err != nil {
-		return nil, fmt.Errorf("get libvirt client: %w", err)
-	}
-
-	info, err := client.GetNetwork(networkName)
-	if err != nil {
-		return nil, fmt.Errorf("get network %s: %w", networkName, err)
-	}
-
+// convertNetworkInfo 将 libvirt 网络信息转换为网络实体
+func convertNetworkInfo(nodeName string, info libvirt.NetworkInfo) entity.Network {
 	state := "inactive"
 	if info.Active {
 		state = "active"
 	}
-
-	return &entity.Network{
+	return entity.Network{
 		Name:       info.Name,
 		UUID:       info.UUID,
 		NodeName:   nodeName,
@@ -100,18 +95,62 @@ func (s *NetworkService) DescribeNetwork(ctx context.Context, nodeName, networkN
 		Netmask:    info.Netmask,
 		DHCPStart:  info.DHCPStart,
 		DHCPEnd:    info.DHCPEnd,
-	}, nil
+
+		IPv6Address: info.IPv6Address,
+		IPv6Prefix:  info.IPv6Prefix,
+	}
+}
+
+// DescribeNetwork 查询网络详情
+func (s *NetworkService) DescribeNetwork(ctx context.Context, nodeName, networkName string) (*entity.Network, error) {
+	client, err := s.getLibvirtClient(nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("get libvirt client: %w", err)
+	}
+
+	info, err := client.GetNetwork(networkName)
+	if err != nil {
+		return nil, fmt.Errorf("get network %s: %w", networkName, err)
+	}
+
+	network := convertNetworkInfo(nodeName, *info)
+	segment, err := s.segments.Get(networkName, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("load network segments: %w", err)
+	}
+	if segment != nil {
+		network.Segmentation = segment.toEntity(nodeName)
+	}
+	return &network, nil
 }
 
 // CreateNetwork 创建网络
 func (s *NetworkService) CreateNetwork(ctx context.Context, req *entity.CreateNetworkRequest) (*entity.Network, error) {
+	if err := validateResourceName("network", req.Name); err != nil {
+		return nil, err
+	}
+
 	client, err := s.getLibvirtClient(req.NodeName)
 	if err != nil {
 		return nil, fmt.Errorf("get libvirt client: %w", err)
 	}
 
-	// 默认模式为 nat
+	if _, err := client.GetNetwork(req.Name); err == nil {
+		return nil, newResourceAlreadyExistsError("Network", req.Name)
+	}
+
+	if err := validateNetworkIPv6(req.IPv6Address, req.IPv6Prefix); err != nil {
+		return nil, err
+	}
+
+	// 默认模式为 nat，隔离网络固定为 isolated
 	mode := req.Mode
+	if req.Segmentation != nil {
+		if err := validateNetworkSegmentation(mode, req.Segmentation); err != nil {
+			return nil, err
+		}
+		mode = "isolated"
+	}
 	if mode == "" {
 		mode = "nat"
 	}
@@ -124,33 +163,83 @@ func (s *NetworkService) CreateNetwork(ctx context.Context, req *entity.CreateNe
 		DHCPStart: req.DHCPStart,
 		DHCPEnd:   req.DHCPEnd,
 		Autostart: req.Autostart,
+
+		IPv6Address: req.IPv6Address,
+		IPv6Prefix:  req.IPv6Prefix,
+	}
+
+	// 隔离网络先分配 VLAN tag/VNI（DryRun 只校验），网桥按 ID 命名
+	var segment *networkSegment
+	if req.Segmentation != nil {
+		segment, err = s.segments.Reserve(req.Name, req.NodeName, tenantFromContext(ctx), req.Segmentation, !isDryRun(ctx))
+		if err != nil {
+			return nil, err
+		}
+		config.Bridge = segment.bridgeName()
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CreateNetwork")
 	}
 
 	info, err := client.CreateNetwork(config)
 	if err != nil {
+		s.releaseSegment(ctx, req.Name, req.NodeName, segment)
 		return nil, fmt.Errorf("create network: %w", err)
 	}
+	if segment != nil {
+		if err := attachNetworkSegment(ctx, client, segment, req.NodeName); err != nil {
+			_ = client.DeleteNetwork(req.Name)
+			s.releaseSegment(ctx, req.Name, req.NodeName, segment)
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to attach network segment", err)
+		}
+	}
+	s.changes.Record(entity.ResourceTypeNetwork, req.NodeName, info.Name, entity.ResourceChangeAdded)
 
-	state := "inactive"
-	if info.Active {
-		state = "active"
+	network := convertNetworkInfo(req.NodeName, *info)
+	if segment != nil {
+		network.Segmentation = segment.toEntity(req.NodeName)
 	}
+	return &network, nil
+}
 
-	return &entity.Network{
-		Name:       info.Name,
-		UUID:       info.UUID,
-		NodeName:   req.NodeName,
-		Type:       "libvirt",
-		Mode:       info.Mode,
-		Bridge:     info.Bridge,
-		State:      state,
-		Autostart:  info.Autostart,
-		Persistent: info.Persistent,
-		IPAddress:  info.IPAddress,
-		Netmask:    info.Netmask,
-		DHCPStart:  info.DHCPStart,
-		DHCPEnd:    info.DHCPEnd,
-	}, nil
+// releaseSegment 网络创建失败或删除后释放节点的 VLAN tag/VNI 记录，失败只记录日志
+func (s *NetworkService) releaseSegment(ctx context.Context, networkName, nodeName string, segment *networkSegment) {
+	if segment == nil {
+		return
+	}
+	if err := s.segments.Release(networkName, nodeName); err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("network", networkName).
+			Msg("Failed to release network segment")
+	}
+}
+
+// validateNetworkIPv6 校验网络的 IPv6 网关地址与前缀长度，未配置 IPv6 时不校验
+func validateNetworkIPv6(address string, prefix int) error {
+	if address == "" {
+		if prefix != 0 {
+			return apierror.NewErrorWithStatus("InvalidParameterValue", "ipv6_prefix requires ipv6_address", http.StatusBadRequest)
+		}
+		return nil
+	}
+	ip := net.ParseIP(address)
+	if ip == nil || ip.To4() != nil {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("ipv6_address %q is not a valid IPv6 address", address),
+			http.StatusBadRequest,
+		)
+	}
+	if prefix < 0 || prefix > 128 {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("ipv6_prefix %d is out of range, must be between 1 and 128", prefix),
+			http.StatusBadRequest,
+		)
+	}
+	return nil
 }
 
 // DeleteNetwork 删除网络
@@ -160,9 +249,33 @@ func (s *NetworkService) DeleteNetwork(ctx context.Context, nodeName, networkNam
 		return fmt.Errorf("get libvirt client: %w", err)
 	}
 
+	if isDryRun(ctx) {
+		if _, err := client.GetNetwork(networkName); err != nil {
+			return fmt.Errorf("get network %s: %w", networkName, err)
+		}
+		return dryRunOperation(ctx, "DeleteNetwork")
+	}
+
 	if err := client.DeleteNetwork(networkName); err != nil {
 		return fmt.Errorf("delete network %s: %w", networkName, err)
 	}
+	s.changes.Record(entity.ResourceTypeNetwork, nodeName, networkName, entity.ResourceChangeDeleted)
+
+	// 网桥随网络删除，再删除节点上的 VLAN 子接口或 VXLAN 设备并释放分配
+	segment, err := s.segments.Get(networkName, nodeName)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("network", networkName).Msg("Failed to load network segment")
+	}
+	if segment != nil {
+		if _, err := runNodeCommand(ctx, client, segment.detachCommand()); err != nil {
+			zerolog.Ctx(ctx).Warn().
+				Err(err).
+				Str("network", networkName).
+				Str("link", segment.linkName()).
+				Msg("Failed to delete network segment link")
+		}
+		s.releaseSegment(ctx, networkName, nodeName, segment)
+	}
 
 	return nil
 }
@@ -174,10 +287,37 @@ func (s *NetworkService) StartNetwork(ctx context.Context, nodeName, networkName
 		return nil, fmt.Errorf("get libvirt client: %w", err)
 	}
 
-	if err := client.StartNetwork(networkName); err != nil {
-		return nil, fmt.Errorf("start network %s: %w", networkName, err)
+	if isDryRun(ctx) {
+		if _, err := client.GetNetwork(networkName); err != nil {
+			return nil, fmt.Errorf("get network %s: %w", networkName, err)
+		}
+		return nil, dryRunOperation(ctx, "StartNetwork")
 	}
 
+	// libvirt 启动网络时重新创建网桥，需要重新接入 VLAN 子接口或 VXLAN 设备；
+	// 节点重启后随 autostart 启动的隔离网络没有接入，对已启动的隔离网络调用启动只做接入
+	segment, err := s.segments.Get(networkName, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("load network segments: %w", err)
+	}
+	active := false
+	if segment != nil {
+		if info, err := client.GetNetwork(networkName); err == nil {
+			active = info.Active
+		}
+	}
+	if !active {
+		if err := client.StartNetwork(networkName); err != nil {
+			return nil, fmt.Errorf("start network %s: %w", networkName, err)
+		}
+	}
+	if segment != nil {
+		if err := attachNetworkSegment(ctx, client, segment, nodeName); err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to attach network segment", err)
+		}
+	}
+	s.changes.Record(entity.ResourceTypeNetwork, nodeName, networkName, entity.ResourceChangeModified)
+
 	return s.DescribeNetwork(ctx, nodeName, networkName)
 }
 
@@ -188,9 +328,17 @@ func (s *NetworkService) StopNetwork(ctx context.Context, nodeName, networkName
 		return nil, fmt.Errorf("get libvirt client: %w", err)
 	}
 
+	if isDryRun(ctx) {
+		if _, err := client.GetNetwork(networkName); err != nil {
+			return nil, fmt.Errorf("get network %s: %w", networkName, err)
+		}
+		return nil, dryRunOperation(ctx, "StopNetwork")
+	}
+
 	if err := client.StopNetwork(networkName); err != nil {
 		return nil, fmt.Errorf("stop network %s: %w", networkName, err)
 	}
+	s.changes.Record(entity.ResourceTypeNetwork, nodeName, networkName, entity.ResourceChangeModified)
 
 	return s.DescribeNetwork(ctx, nodeName, networkName)
 }
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/rs/zerolog"
+)
+
+// 内置的默认网络：桥接到网桥 br0，节点上没有 br0 时回落到 libvirt 自带的 default NAT 网络（virbr0）
+const (
+	defaultBridgeName      = "br0"
+	fallbackLibvirtNetwork = "default"
+)
+
+// NetworkDefaults 创建实例时未指定网络源的全局默认值
+// Source 为空时使用内置默认：节点上有网桥 br0 时桥接到 br0，否则回落到已启动的 libvirt default 网络
+type NetworkDefaults struct {
+	Type   string // 网络类型：bridge, network（默认：bridge）
+	Source string // 网桥名称或网络名称
+}
+
+// ResolveInstanceNetwork 计算实例网卡的网络类型与网络源，请求中指定的值优先
+// 配置了全局默认网络时直接使用，不做探测；使用内置默认时探测节点上的 br0，
+// br0 与 default 网络都不可用时返回说明如何处理的错误，而不是创建出无法启动的实例
+func (s *NodeService) ResolveInstanceNetwork(ctx context.Context, nodeName, networkType, networkSource string) (string, string, error) {
+	// macvtap 直接绑定宿主机网卡，没有可以回落的默认值
+	if networkType == "direct" {
+		if networkSource == "" || strings.Contains(networkSource, "/") {
+			return "", "", apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				"network_source must be a host interface when network_type is direct, see direct_sources of DescribeNodeNetwork",
+				http.StatusBadRequest,
+			)
+		}
+		client, err := s.GetNodeStorage(ctx, nodeName)
+		if err != nil {
+			return "", "", apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+		}
+		if err := checkDirectSource(ctx, client, nodeName, networkSource); err != nil {
+			return "", "", err
+		}
+		return networkType, networkSource, nil
+	}
+
+	// OVS 网桥由 ovs-vsctl 管理，同样没有默认值
+	if networkType == libvirt.NetworkTypeOVS {
+		client, err := s.GetNodeStorage(ctx, nodeName)
+		if err != nil {
+			return "", "", apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+		}
+		if err := checkOVSBridge(ctx, client, nodeName, networkSource); err != nil {
+			return "", "", err
+		}
+		return networkType, networkSource, nil
+	}
+
+	if networkSource != "" {
+		if networkType == "" {
+			networkType = "bridge"
+		}
+		if err := s.networkSegments.CheckTenant(ctx, networkType, networkSource); err != nil {
+			return "", "", err
+		}
+		return networkType, networkSource, nil
+	}
+
+	defaultType := s.networkDefaults.Type
+	if defaultType == "" {
+		defaultType = "bridge"
+	}
+	if s.networkDefaults.Source != "" && (networkType == "" || networkType == defaultType) {
+		return defaultType, s.networkDefaults.Source, nil
+	}
+
+	if networkType == "network" {
+		return networkType, fallbackLibvirtNetwork, nil
+	}
+
+	client, err := s.GetNodeStorage(ctx, nodeName)
+	if err != nil {
+		return "", "", apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+	output, err := runNodeCommand(ctx, client, fmt.Sprintf("if [ -d /sys/class/net/%s/bridge ]; then echo yes; else echo no; fi", defaultBridgeName))
+	if err != nil {
+		// 无法探测时保持原有行为，由 libvirt 在启动时报告网桥是否存在
+		zerolog.Ctx(ctx).Warn().Err(err).Str("node", nodeName).Msg("Failed to check default bridge, assuming it exists")
+		return "bridge", defaultBridgeName, nil
+	}
+	if strings.TrimSpace(string(output)) == "yes" {
+		return "bridge", defaultBridgeName, nil
+	}
+
+	// 显式指定 bridge 时不回落到 NAT 网络，避免实例网络模式与预期不一致
+	if networkType == "" {
+		if network, err := client.GetNetwork(fallbackLibvirtNetwork); err == nil && network.Active {
+			zerolog.Ctx(ctx).Warn().
+				Str("node", nodeName).
+				Msg("Bridge br0 does not exist, falling back to libvirt default network")
+			return "network", fallbackLibvirtNetwork, nil
+		}
+	}
+
+	return "", "", apierror.NewErrorWithStatus(
+		"InvalidParameter",
+		fmt.Sprintf("bridge %s does not exist on node %s and the libvirt %s network is not active: "+
+			"create the bridge (create-bridge), start the %s network (start-network), specify network_source, "+
+			"or set JVP_DEFAULT_NETWORK_SOURCE",
+			defaultBridgeName, normalizeNodeName(nodeName), fallbackLibvirtNetwork, fallbackLibvirtNetwork),
+		http.StatusBadRequest,
+	)
+}
+
+// directSourceProbeScript 列出带 device 链接的物理网卡：名称、MAC、链路状态、速率与所属网桥/bond
+const directSourceProbeScript = `for d in /sys/class/net/*; do
+  [ -e "$d/device" ] || continue
+  m=$(readlink "$d/master" 2>/dev/null)
+  echo "${d##*/} $(cat "$d/address") $(cat "$d/operstate") $(cat "$d/speed" 2>/dev/null || echo -1) ${m##*/}"
+done`
+
+// listDirectSources 从 sysfs 列出节点上可作为 macvtap 网络源的物理网卡
+func listDirectSources(ctx context.Context, client libvirt.RemoteManager) ([]entity.DirectSource, error) {
+	output, err := runNodeCommand(ctx, client, directSourceProbeScript)
+	if err != nil {
+		return nil, err
+	}
+	sources := []entity.DirectSource{}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		source := entity.DirectSource{
+			Name:  fields[0],
+			MAC:   fields[1],
+			State: fields[2],
+		}
+		if speed, err := strconv.Atoi(fields[3]); err == nil && speed > 0 {
+			source.SpeedMbps = speed
+		}
+		if len(fields) > 4 {
+			source.Master = fields[4]
+		}
+		source.Available = source.Master == ""
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// checkDirectSource 校验 macvtap 网络源存在且没有加入网桥或 bond，无法探测时交给 libvirt 在启动时报告
+func checkDirectSource(ctx context.Context, client libvirt.RemoteManager, nodeName, dev string) error {
+	output, err := runNodeCommand(ctx, client, fmt.Sprintf(
+		`d=/sys/class/net/%s; if [ ! -e "$d" ]; then echo missing; elif [ -e "$d/master" ]; then m=$(readlink "$d/master"); echo "master ${m##*/}"; else echo ok; fi`,
+		shellx.Quote(dev)))
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("node", nodeName).Str("dev", dev).Msg("Failed to check direct network source")
+		return nil
+	}
+	result := strings.Fields(strings.TrimSpace(string(output)))
+	switch {
+	case len(result) > 0 && result[0] == "missing":
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("interface %s does not exist on node %s, see direct_sources of DescribeNodeNetwork", dev, normalizeNodeName(nodeName)),
+			http.StatusBadRequest,
+		)
+	case len(result) > 1 && result[0] == "master":
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("interface %s on node %s is a member of %s and cannot be used as a macvtap source: "+
+				"use %s itself (network_type=bridge for a bridge, network_type=direct for a bond) or another interface",
+				dev, normalizeNodeName(nodeName), result[1], result[1]),
+			http.StatusBadRequest,
+		)
+	}
+	return nil
+}
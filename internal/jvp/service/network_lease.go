@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/rs/zerolog"
+)
+
+// dhcpReleaseTimeout 在节点上执行 dhcp_release 的超时时间
+const dhcpReleaseTimeout = 30 * time.Second
+
+// DescribeNetworkDHCPLeases 查询 libvirt 网络的 DHCP 租约，默认不返回已过期的租约
+// 直接读取 libvirt，不使用实例 IP 解析的租约缓存
+func (s *NetworkService) DescribeNetworkDHCPLeases(ctx context.Context, req *entity.DescribeNetworkDHCPLeasesRequest) ([]entity.DHCPLease, error) {
+	client, err := s.getLibvirtClient(req.NodeName)
+	if err != nil {
+		return nil, fmt.Errorf("get libvirt client: %w", err)
+	}
+	if _, err := client.GetNetwork(req.NetworkName); err != nil {
+		return nil, newNetworkNotFoundError(req.NetworkName)
+	}
+
+	leases, err := networkLeases(client, req.NetworkName)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]entity.DHCPLease, 0, len(leases))
+	for _, lease := range leases {
+		if lease.Expired && !req.IncludeExpired {
+			continue
+		}
+		if req.MAC != "" && !strings.EqualFold(lease.MAC, req.MAC) {
+			continue
+		}
+		result = append(result, lease)
+	}
+	return result, nil
+}
+
+// ReleaseNetworkDHCPLease 通过节点上的 dhcp_release（dnsmasq-utils）向 dnsmasq 发送 DHCPRELEASE，释放 MAC 的 IPv4 租约
+// 释放后立即使该节点的租约缓存失效；静态分配（dhcp host）的 MAC 下次请求时仍会获得同一地址
+func (s *NetworkService) ReleaseNetworkDHCPLease(ctx context.Context, req *entity.ReleaseNetworkDHCPLeaseRequest) ([]entity.DHCPLease, error) {
+	logger := zerolog.Ctx(ctx)
+
+	client, err := s.getLibvirtClient(req.NodeName)
+	if err != nil {
+		return nil, fmt.Errorf("get libvirt client: %w", err)
+	}
+	network, err := client.GetNetwork(req.NetworkName)
+	if err != nil {
+		return nil, newNetworkNotFoundError(req.NetworkName)
+	}
+
+	leases, err := networkLeases(client, req.NetworkName)
+	if err != nil {
+		return nil, err
+	}
+	var matched []entity.DHCPLease
+	for _, lease := range leases {
+		if strings.EqualFold(lease.MAC, req.MAC) && (req.IP == "" || lease.IP == req.IP) {
+			matched = append(matched, lease)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("No DHCP lease for MAC %s in network %s", req.MAC, req.NetworkName),
+			http.StatusNotFound,
+		)
+	}
+	for _, lease := range matched {
+		if strings.Contains(lease.IP, ":") {
+			return nil, apierror.NewErrorWithStatus(
+				"UnsupportedOperation",
+				fmt.Sprintf("Releasing IPv6 lease %s is not supported", lease.IP),
+				http.StatusBadRequest,
+			)
+		}
+	}
+	if network.Bridge == "" {
+		return nil, apierror.NewErrorWithStatus(
+			"UnsupportedOperation",
+			fmt.Sprintf("Network %s has no bridge, its leases are not managed by dnsmasq", req.NetworkName),
+			http.StatusBadRequest,
+		)
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ReleaseNetworkDHCPLease")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, dhcpReleaseTimeout)
+	defer cancel()
+	defer libvirt.InvalidateLeaseCache(client.GetConnectionURI())
+
+	released := make([]entity.DHCPLease, 0, len(matched))
+	for _, lease := range matched {
+		command := fmt.Sprintf("dhcp_release %s %s %s", shellx.Quote(network.Bridge), shellx.Quote(lease.IP), shellx.Quote(lease.MAC))
+		if _, err := runNodeCommand(ctx, client, command); err != nil {
+			return released, apierror.WrapError(apierror.ErrInternalError,
+				fmt.Sprintf("Failed to release DHCP lease %s, make sure dhcp_release (dnsmasq-utils) is installed on the node", lease.IP), err)
+		}
+		logger.Info().
+			Str("network", req.NetworkName).
+			Str("mac", lease.MAC).
+			Str("ip", lease.IP).
+			Msg("DHCP lease released")
+		released = append(released, lease)
+	}
+	return released, nil
+}
+
+// networkLeases 读取网络的全部租约，标记已过期与静态分配的租约
+func networkLeases(client libvirt.NetworkManager, networkName string) ([]entity.DHCPLease, error) {
+	raw, err := client.ListNetworkDHCPLeases(networkName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list DHCP leases", err)
+	}
+	static := staticDHCPHosts(client, networkName)
+
+	now := time.Now()
+	leases := make([]entity.DHCPLease, 0, len(raw))
+	for _, l := range raw {
+		for _, mac := range l.MACs {
+			lease := entity.DHCPLease{
+				IP:      l.IP,
+				MAC:     strings.ToLower(mac),
+				Expired: l.Expired(now),
+				Static:  static[strings.ToLower(mac)],
+			}
+			if len(l.Hostnames) > 0 {
+				lease.Hostname = l.Hostnames[0]
+			}
+			if len(l.ClientIDs) > 0 {
+				lease.ClientID = l.ClientIDs[0]
+			}
+			if l.Expiry != 0 {
+				lease.ExpiryTime = time.Unix(l.Expiry, 0)
+			}
+			leases = append(leases, lease)
+		}
+	}
+	return leases, nil
+}
+
+// staticDHCPHosts 返回网络 DHCP 静态分配（dhcp host）的 MAC 集合，读取失败时返回空集合
+func staticDHCPHosts(client libvirt.NetworkManager, networkName string) map[string]bool {
+	hosts := make(map[string]bool)
+	xmlDesc, err := client.GetNetworkXMLDesc(networkName)
+	if err != nil {
+		return hosts
+	}
+	var network libvirt.NetworkXML
+	if err := xml.Unmarshal([]byte(xmlDesc), &network); err != nil {
+		return hosts
+	}
+	ip := network.IPv4()
+	if ip == nil || ip.DHCP == nil {
+		return hosts
+	}
+	for _, host := range ip.DHCP.Host {
+		if host.MAC != "" {
+			hosts[strings.ToLower(host.MAC)] = true
+		}
+	}
+	return hosts
+}
+
+// newNetworkNotFoundError 网络不存在时返回的错误
+func newNetworkNotFoundError(networkName string) error {
+	return apierror.NewErrorWithStatus(
+		"ResourceNotFound",
+		fmt.Sprintf("Network %s not found", networkName),
+		http.StatusNotFound,
+	)
+}
@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// macSpoofCookiePrefix 防 MAC 欺骗流表 cookie 的高 16 位（"jv"），低 48 位为实例网卡 MAC，按 cookie 精确删除
+	macSpoofCookiePrefix = uint64(0x6a76) << 48
+	// macSpoofCookieMask 匹配所有防 MAC 欺骗流表的 cookie 掩码
+	macSpoofCookieMask = 0xffff000000000000
+	// macSpoofAllowPriority 放行源 MAC 为实例 MAC 的报文，交给 NORMAL 按普通交换机转发
+	macSpoofAllowPriority = 100
+	// macSpoofDropPriority 丢弃该端口其余的报文
+	macSpoofDropPriority = 99
+)
+
+// ovsInterfaceListCommand 列出 OVS 的所有 interface，节点未安装 OVS 时输出为空
+const ovsInterfaceListCommand = `command -v ovs-vsctl >/dev/null 2>&1 || exit 0; ` +
+	`ovs-vsctl --format=json --columns=name,ofport,external_ids list Interface`
+
+// macSpoofCookiePattern 从 ovs-ofctl dump-flows 的输出中提取 cookie
+var macSpoofCookiePattern = regexp.MustCompile(`cookie=(0x[0-9a-f]+)`)
+
+// checkOVSBridge 校验 OVS 网桥存在，无法探测时交给 libvirt 在启动时报告
+func checkOVSBridge(ctx context.Context, client libvirt.RemoteManager, nodeName, bridge string) error {
+	if !uplinkNamePattern.MatchString(bridge) {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"network_source must be the name of an Open vSwitch bridge when network_type is ovs",
+			http.StatusBadRequest,
+		)
+	}
+	// br-exists 在网桥不存在时退出码为 2，其他非 0 退出码表示无法连接 ovsdb
+	output, err := runNodeCommand(ctx, client, fmt.Sprintf(
+		`command -v ovs-vsctl >/dev/null 2>&1 || { echo not-installed; exit 0; }; rc=0; ovs-vsctl br-exists %s || rc=$?; echo $rc`,
+		shellx.Quote(bridge)))
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("node", nodeName).Str("bridge", bridge).Msg("Failed to check Open vSwitch bridge")
+		return nil
+	}
+	switch result := strings.TrimSpace(string(output)); result {
+	case "0":
+		return nil
+	case "not-installed":
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("Open vSwitch is not installed on node %s", normalizeNodeName(nodeName)),
+			http.StatusBadRequest,
+		)
+	case "2":
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("Open vSwitch bridge %s does not exist on node %s", bridge, normalizeNodeName(nodeName)),
+			http.StatusBadRequest,
+		)
+	default:
+		zerolog.Ctx(ctx).Warn().
+			Str("node", nodeName).
+			Str("bridge", bridge).
+			Str("result", result).
+			Msg("Failed to query Open vSwitch database, skipping bridge check")
+		return nil
+	}
+}
+
+// macSpoofCookie 实例网卡防 MAC 欺骗流表的 cookie
+func macSpoofCookie(mac string) (uint64, error) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return 0, fmt.Errorf("invalid MAC address %q", mac)
+	}
+	var cookie uint64
+	for _, b := range hw {
+		cookie = cookie<<8 | uint64(b)
+	}
+	return cookie | macSpoofCookiePrefix, nil
+}
+
+// syncMACSpoofCheck 在实例网卡所在的 OVS 网桥上下发（add=true）或删除防 MAC 欺骗流表：
+// 端口只放行源 MAC 为实例 MAC 的报文，其余丢弃。端口号按 external_ids:attached-mac 查找，
+// 实例每次启动端口号都可能变化，因此启动后重新下发、停止与删除后删除；失败只记录告警
+// 流表使用 NORMAL 转发，适用于没有 SDN 控制器的独立 OVS 网桥，由控制器（如 OVN）管理的网桥应使用控制器自身的端口安全
+func syncMACSpoofCheck(ctx context.Context, client libvirt.RemoteManager, instanceID string, enabled bool, interfaces []entity.InstanceInterface, add bool) {
+	if !enabled {
+		return
+	}
+	logger := zerolog.Ctx(ctx).With().Str("instance_id", instanceID).Logger()
+
+	for _, iface := range interfaces {
+		if iface.VirtualPort != libvirt.VirtualPortOpenvSwitch || iface.MAC == "" || iface.Source == "" {
+			continue
+		}
+		cookie, err := macSpoofCookie(iface.MAC)
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to compute MAC spoof check flow cookie")
+			continue
+		}
+		hw, _ := net.ParseMAC(iface.MAC)
+		bridge := shellx.Quote(iface.Source)
+		command := fmt.Sprintf("ovs-ofctl del-flows %s cookie=0x%x/-1", bridge, cookie)
+		if add {
+			command = fmt.Sprintf(
+				`ofport=$(ovs-vsctl --bare --columns=ofport find Interface %s | awk '$1 > 0 {print; exit}'); `+
+					`[ -n "$ofport" ] || { echo "no Open vSwitch port with MAC %s" >&2; exit 1; }; `+
+					`%s && ovs-ofctl add-flow %s "cookie=0x%x,priority=%d,in_port=$ofport,dl_src=%s,actions=NORMAL" && `+
+					`ovs-ofctl add-flow %s "cookie=0x%x,priority=%d,in_port=$ofport,actions=drop"`,
+				shellx.Quote(fmt.Sprintf("external_ids:attached-mac=%q", hw.String())), hw,
+				command, bridge, cookie, macSpoofAllowPriority, hw, bridge, cookie, macSpoofDropPriority)
+		}
+		if _, err := runNodeCommand(ctx, client, command); err != nil {
+			logger.Warn().
+				Err(err).
+				Str("mac", iface.MAC).
+				Str("bridge", iface.Source).
+				Bool("add", add).
+				Msg("Failed to update MAC spoof check flows")
+			continue
+		}
+		logger.Info().
+			Str("mac", iface.MAC).
+			Str("bridge", iface.Source).
+			Bool("add", add).
+			Msg("MAC spoof check flows updated")
+	}
+}
+
+// ovsInterface OVS Interface 表中的一行
+type ovsInterface struct {
+	Name        string
+	OFPort      int // 未分配时为 0，tap 设备不存在时为 -1
+	ExternalIDs map[string]string
+}
+
+// parseOVSInterfaces 解析 ovs-vsctl --format=json list Interface 的输出
+// 单值列为 JSON 值，空值为 ["set", []]，map 列为 ["map", [[key, value], ...]]
+func parseOVSInterfaces(output []byte) ([]ovsInterface, error) {
+	if len(strings.TrimSpace(string(output))) == 0 {
+		return nil, nil
+	}
+	var table struct {
+		Headings []string            `json:"headings"`
+		Data     [][]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(output, &table); err != nil {
+		return nil, fmt.Errorf("unmarshal ovs-vsctl output: %w", err)
+	}
+
+	interfaces := make([]ovsInterface, 0, len(table.Data))
+	for _, row := range table.Data {
+		iface := ovsInterface{ExternalIDs: make(map[string]string)}
+		for i, heading := range table.Headings {
+			if i >= len(row) {
+				break
+			}
+			switch heading {
+			case "name":
+				_ = json.Unmarshal(row[i], &iface.Name)
+			case "ofport":
+				_ = json.Unmarshal(row[i], &iface.OFPort)
+			case "external_ids":
+				var column []json.RawMessage
+				if err := json.Unmarshal(row[i], &column); err != nil || len(column) != 2 {
+					continue
+				}
+				var pairs [][2]string
+				if err := json.Unmarshal(column[1], &pairs); err != nil {
+					continue
+				}
+				for _, pair := range pairs {
+					iface.ExternalIDs[pair[0]] = pair[1]
+				}
+			}
+		}
+		interfaces = append(interfaces, iface)
+	}
+	return interfaces, nil
+}
+
+// CleanupOVSPorts 清理节点上 OVS 的残留端口与防 MAC 欺骗流表
+// 只处理 libvirt 创建的端口（external_ids 中有 vm-id）：tap 设备已不存在（ofport 为 -1），或所属域没有在运行，
+// 通常是 libvirtd 异常退出或节点重启后留在 ovsdb 中的记录；流表按 cookie 中的 MAC 判断，对应端口已不存在时删除
+// DryRun 时只返回将被清理的端口与流表
+func (s *NetworkService) CleanupOVSPorts(ctx context.Context, req *entity.CleanupOVSPortsRequest) (*entity.CleanupOVSPortsResponse, error) {
+	logger := zerolog.Ctx(ctx).With().Str("node", req.NodeName).Logger()
+
+	client, err := s.getLibvirtClient(req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+
+	output, err := runNodeCommand(ctx, client, ovsInterfaceListCommand)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list Open vSwitch interfaces", err)
+	}
+	interfaces, err := parseOVSInterfaces(output)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list Open vSwitch interfaces", err)
+	}
+	result := &entity.CleanupOVSPortsResponse{Ports: []entity.OVSPort{}}
+	if len(interfaces) == 0 {
+		return result, nil
+	}
+
+	running, err := runningDomainUUIDs(client)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list domains", err)
+	}
+
+	liveMACs := make(map[string]bool)
+	for _, iface := range interfaces {
+		vmID := iface.ExternalIDs["vm-id"]
+		if vmID == "" {
+			continue
+		}
+		reason := ""
+		switch {
+		case iface.OFPort < 0:
+			reason = "tap device no longer exists"
+		case !running[strings.ToLower(strings.ReplaceAll(vmID, "-", ""))]:
+			reason = "domain is not running"
+		default:
+			if hw, err := net.ParseMAC(iface.ExternalIDs["attached-mac"]); err == nil {
+				liveMACs[hw.String()] = true
+			}
+			continue
+		}
+
+		port := entity.OVSPort{
+			Name:        iface.Name,
+			MAC:         iface.ExternalIDs["attached-mac"],
+			InterfaceID: iface.ExternalIDs["iface-id"],
+			VMID:        vmID,
+			Reason:      reason,
+		}
+		if !isDryRun(ctx) {
+			if _, err := runNodeCommand(ctx, client, "ovs-vsctl --if-exists del-port "+shellx.Quote(iface.Name)); err != nil {
+				logger.Warn().Err(err).Str("port", iface.Name).Msg("Failed to delete stale Open vSwitch port")
+				continue
+			}
+			logger.Info().Str("port", iface.Name).Str("reason", reason).Msg("Stale Open vSwitch port deleted")
+		}
+		result.Ports = append(result.Ports, port)
+	}
+
+	result.Flows, err = s.cleanupMACSpoofFlows(ctx, client, liveMACs)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to clean up MAC spoof check flows", err)
+	}
+	return result, nil
+}
+
+// runningDomainUUIDs 返回节点上运行中（含暂停）的域 UUID（不带连字符的小写十六进制）
+func runningDomainUUIDs(client libvirt.LibvirtClient) (map[string]bool, error) {
+	domains, err := client.GetVMSummaries()
+	if err != nil {
+		return nil, err
+	}
+	running := make(map[string]bool, len(domains))
+	for _, domain := range domains {
+		state, _, err := client.GetDomainState(domain)
+		if err != nil {
+			// 无法确认状态时按运行中处理，避免误删端口
+			running[formatDomainUUID(domain.UUID)] = true
+			continue
+		}
+		if state == 1 || state == 3 {
+			running[formatDomainUUID(domain.UUID)] = true
+		}
+	}
+	return running, nil
+}
+
+// cleanupMACSpoofFlows 删除各 OVS 网桥上 MAC 已不属于任何端口的防 MAC 欺骗流表，返回删除的 cookie 数
+func (s *NetworkService) cleanupMACSpoofFlows(ctx context.Context, client libvirt.RemoteManager, liveMACs map[string]bool) (int, error) {
+	output, err := runNodeCommand(ctx, client, "ovs-vsctl list-br")
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, bridge := range strings.Fields(string(output)) {
+		flows, err := runNodeCommand(ctx, client, fmt.Sprintf("ovs-ofctl dump-flows %s cookie=0x%x/0x%x",
+			shellx.Quote(bridge), macSpoofCookiePrefix, uint64(macSpoofCookieMask)))
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("bridge", bridge).Msg("Failed to dump Open vSwitch flows")
+			continue
+		}
+		stale := make(map[uint64]bool)
+		for _, match := range macSpoofCookiePattern.FindAllStringSubmatch(string(flows), -1) {
+			cookie, err := strconv.ParseUint(match[1], 0, 64)
+			if err != nil || cookie&macSpoofCookieMask != macSpoofCookiePrefix {
+				continue
+			}
+			if !liveMACs[cookieMAC(cookie)] {
+				stale[cookie] = true
+			}
+		}
+		for cookie := range stale {
+			if !isDryRun(ctx) {
+				if _, err := runNodeCommand(ctx, client, fmt.Sprintf("ovs-ofctl del-flows %s cookie=0x%x/-1", shellx.Quote(bridge), cookie)); err != nil {
+					zerolog.Ctx(ctx).Warn().Err(err).Str("bridge", bridge).Msg("Failed to delete stale MAC spoof check flows")
+					continue
+				}
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// cookieMAC 从防 MAC 欺骗流表的 cookie 中取出 MAC
+func cookieMAC(cookie uint64) string {
+	hw := make(net.HardwareAddr, 6)
+	for i := range hw {
+		hw[i] = byte(cookie >> (8 * (5 - i)))
+	}
+	return hw.String()
+}
+
+// RegisterScheduledTasks 向调度器注册 OVS 残留端口清理任务，间隔为 0 时不注册
+func (s *NetworkService) RegisterScheduledTasks(scheduler *Scheduler) error {
+	if s.ovsCleanupInterval <= 0 {
+		return nil
+	}
+	return scheduler.Register(ScheduledTaskSpec{
+		Name:        "ovs-port-cleanup",
+		Description: "清理各节点 Open vSwitch 中已失效的实例端口与防 MAC 欺骗流表",
+		Schedule:    fmt.Sprintf("@every %s", s.ovsCleanupInterval),
+		Run:         s.cleanupAllOVSPorts,
+	})
+}
+
+// cleanupAllOVSPorts 清理所有在线节点，单个节点失败不影响其他节点
+func (s *NetworkService) cleanupAllOVSPorts(ctx context.Context, _ time.Time) error {
+	nodes, err := s.nodeStorage.List()
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, node := range nodes {
+		if node.State != entity.NodeStateOnline {
+			continue
+		}
+		if _, err := s.CleanupOVSPorts(ctx, &entity.CleanupOVSPortsRequest{NodeName: node.Name}); err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("node", node.Name).Msg("Failed to clean up Open vSwitch ports")
+			failed = append(failed, node.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("cleanup failed on %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
+)
+
+const (
+	// maxVLANID VLAN tag 上限（0 与 4095 保留）
+	maxVLANID = 4094
+	// maxVXLANID VXLAN VNI 上限（24 位）
+	maxVXLANID = 1<<24 - 1
+	// autoVLANIDStart 自动分配 VLAN tag 的起始值，低位 tag 通常已被物理网络使用
+	autoVLANIDStart = 100
+	// autoVXLANIDStart 自动分配 VNI 的起始值
+	autoVXLANIDStart = 10000
+	// defaultVXLANGroup VXLAN 默认组播组
+	defaultVXLANGroup = "239.1.1.1"
+	// vxlanPort VXLAN 的 IANA 标准 UDP 端口
+	vxlanPort = 4789
+)
+
+// uplinkNamePattern 节点网卡名称（IFNAMSIZ 限制 15 字符），同时避免拼接进 shell 命令时出现特殊字符
+var uplinkNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,15}$`)
+
+// networkSegment 隔离网络的分配记录
+type networkSegment struct {
+	Type   string            `json:"type"`
+	ID     int               `json:"id"`
+	Group  string            `json:"group,omitempty"`  // VXLAN 组播组
+	Tenant string            `json:"tenant,omitempty"` // 所属租户，为空表示管理员创建、不限制
+	Nodes  map[string]string `json:"nodes"`            // 已创建该网络的节点 -> 承载网卡
+}
+
+// bridgeName 网络的 libvirt 网桥名称，如 jvbr-v100、jvbr-x10000
+func (g *networkSegment) bridgeName() string {
+	return fmt.Sprintf("jvbr-%c%d", segmentPrefix(g.Type), g.ID)
+}
+
+// linkName 节点上的 VLAN 子接口或 VXLAN 设备名称，如 jvl100、jvx10000
+func (g *networkSegment) linkName() string {
+	if g.Type == entity.NetworkSegmentationVXLAN {
+		return fmt.Sprintf("jvx%d", g.ID)
+	}
+	return fmt.Sprintf("jvl%d", g.ID)
+}
+
+func segmentPrefix(segmentType string) byte {
+	if segmentType == entity.NetworkSegmentationVXLAN {
+		return 'x'
+	}
+	return 'v'
+}
+
+// attachCommand 创建（已存在时复用）VLAN 子接口或 VXLAN 设备并接入网络的网桥
+// libvirt 每次启动网络都会重新创建网桥，因此创建与启动网络后都需要执行
+func (g *networkSegment) attachCommand(uplink string) string {
+	link := g.linkName()
+	add := fmt.Sprintf("ip link add link %s name %s type vlan id %d", shellx.Quote(uplink), link, g.ID)
+	if g.Type == entity.NetworkSegmentationVXLAN {
+		add = fmt.Sprintf("ip link add %s type vxlan id %d dev %s group %s dstport %d", link, g.ID, shellx.Quote(uplink), g.Group, vxlanPort)
+	}
+	return fmt.Sprintf("{ ip link show %s >/dev/null 2>&1 || %s; } && ip link set %s up && ip link set %s master %s up",
+		link, add, shellx.Quote(uplink), link, g.bridgeName())
+}
+
+// detachCommand 删除节点上的 VLAN 子接口或 VXLAN 设备，不存在时忽略
+func (g *networkSegment) detachCommand() string {
+	return fmt.Sprintf("ip link del %s 2>/dev/null || true", g.linkName())
+}
+
+// toEntity 转换为节点上网络的隔离配置
+func (g *networkSegment) toEntity(nodeName string) *entity.NetworkSegmentation {
+	return &entity.NetworkSegmentation{
+		Type:       g.Type,
+		ID:         g.ID,
+		Uplink:     g.Nodes[nodeName],
+		VXLANGroup: g.Group,
+		Tenant:     g.Tenant,
+	}
+}
+
+// NetworkSegmentStore 隔离网络的 VLAN tag/VNI 分配
+// 所有节点共用一个 JSON 文件：<dataDir>/network-segments.json，key 为网络名称，
+// 同名网络在各节点上共用同一个 ID，节点全部删除该网络后 ID 释放
+type NetworkSegmentStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewNetworkSegmentStore 创建隔离网络分配存储
+func NewNetworkSegmentStore(dataDir string) (*NetworkSegmentStore, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return &NetworkSegmentStore{path: filepath.Join(dataDir, "network-segments.json")}, nil
+}
+
+func (s *NetworkSegmentStore) loadUnlocked() (map[string]*networkSegment, error) {
+	state := make(map[string]*networkSegment)
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read network segments: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal network segments: %w", err)
+	}
+	return state, nil
+}
+
+func (s *NetworkSegmentStore) saveUnlocked(state map[string]*networkSegment) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal network segments: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write network segments: %w", err)
+	}
+	return nil
+}
+
+// All 返回所有隔离网络的分配记录
+func (s *NetworkSegmentStore) All() (map[string]*networkSegment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.loadUnlocked()
+}
+
+// Get 返回网络在节点上的分配记录，网络不是隔离网络或该节点未创建时返回 nil
+func (s *NetworkSegmentStore) Get(networkName, nodeName string) (*networkSegment, error) {
+	state, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+	segment, ok := state[networkName]
+	if !ok {
+		return nil, nil
+	}
+	if _, ok := segment.Nodes[nodeName]; !ok {
+		return nil, nil
+	}
+	return segment, nil
+}
+
+// Reserve 为节点上的网络分配 VLAN tag/VNI：其他节点已创建同名网络时沿用其 ID，否则使用指定 ID 或分配最小的空闲 ID
+// commit 为 false 时只校验与计算（DryRun），不写入
+func (s *NetworkSegmentStore) Reserve(networkName, nodeName, tenant string, spec *entity.NetworkSegmentation, commit bool) (*networkSegment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.loadUnlocked()
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to load network segments", err)
+	}
+
+	segment, ok := state[networkName]
+	if ok {
+		if spec.Type != segment.Type || (spec.ID != 0 && spec.ID != segment.ID) {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("network %s already uses %s %d on other nodes, the same segmentation must be used on every node", networkName, segment.Type, segment.ID),
+				http.StatusBadRequest,
+			)
+		}
+		if tenant != "" && tenant != segment.Tenant {
+			return nil, apierror.NewErrorWithStatus(
+				"OperationNotPermitted",
+				fmt.Sprintf("network %s is owned by another tenant", networkName),
+				http.StatusForbidden,
+			)
+		}
+	} else {
+		id, err := allocateSegmentID(state, spec)
+		if err != nil {
+			return nil, err
+		}
+		segment = &networkSegment{
+			Type:   spec.Type,
+			ID:     id,
+			Tenant: tenant,
+			Nodes:  make(map[string]string),
+		}
+		if spec.Type == entity.NetworkSegmentationVXLAN {
+			segment.Group = spec.VXLANGroup
+			if segment.Group == "" {
+				segment.Group = defaultVXLANGroup
+			}
+		}
+	}
+
+	reserved := *segment
+	reserved.Nodes = make(map[string]string, len(segment.Nodes)+1)
+	for node, uplink := range segment.Nodes {
+		reserved.Nodes[node] = uplink
+	}
+	reserved.Nodes[nodeName] = spec.Uplink
+	if !commit {
+		return &reserved, nil
+	}
+
+	state[networkName] = &reserved
+	if err := s.saveUnlocked(state); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to save network segments", err)
+	}
+	return &reserved, nil
+}
+
+// allocateSegmentID 校验指定的 ID 未被其他网络使用，未指定时分配最小的空闲 ID
+func allocateSegmentID(state map[string]*networkSegment, spec *entity.NetworkSegmentation) (int, error) {
+	used := make(map[int]string)
+	for name, segment := range state {
+		if segment.Type == spec.Type {
+			used[segment.ID] = name
+		}
+	}
+
+	if spec.ID != 0 {
+		if name, ok := used[spec.ID]; ok {
+			return 0, apierror.NewErrorWithStatus(
+				"NetworkSegmentInUse",
+				fmt.Sprintf("%s %d is already used by network %s", spec.Type, spec.ID, name),
+				http.StatusConflict,
+			)
+		}
+		return spec.ID, nil
+	}
+
+	start, end := autoVLANIDStart, maxVLANID
+	if spec.Type == entity.NetworkSegmentationVXLAN {
+		start, end = autoVXLANIDStart, maxVXLANID
+	}
+	for id := start; id <= end; id++ {
+		if _, ok := used[id]; !ok {
+			return id, nil
+		}
+	}
+	return 0, apierror.WrapError(apierror.ErrInsufficientAddressCapacity,
+		fmt.Sprintf("No free %s ID left between %d and %d", spec.Type, start, end), nil)
+}
+
+// Release 节点删除网络后移除记录，所有节点都删除后释放 ID
+func (s *NetworkSegmentStore) Release(networkName, nodeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.loadUnlocked()
+	if err != nil {
+		return err
+	}
+	segment, ok := state[networkName]
+	if !ok {
+		return nil
+	}
+	delete(segment.Nodes, nodeName)
+	if len(segment.Nodes) == 0 {
+		delete(state, networkName)
+	}
+	return s.saveUnlocked(state)
+}
+
+// CheckTenant 租户只能把实例连接到自己的隔离网络，管理员请求与普通网络不受限制
+// networkType 为 bridge 时按网桥名称匹配，避免绕过网络名直接桥接到隔离网络的网桥
+func (s *NetworkSegmentStore) CheckTenant(ctx context.Context, networkType, networkSource string) error {
+	tenant := tenantFromContext(ctx)
+	if tenant == "" || (networkType != "network" && networkType != "bridge") {
+		return nil
+	}
+	state, err := s.All()
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to load network segments", err)
+	}
+	for name, segment := range state {
+		if segment.Tenant == "" || segment.Tenant == tenant {
+			continue
+		}
+		if (networkType == "network" && name == networkSource) || (networkType == "bridge" && segment.bridgeName() == networkSource) {
+			return apierror.NewErrorWithStatus(
+				"OperationNotPermitted",
+				fmt.Sprintf("network %s is owned by another tenant", name),
+				http.StatusForbidden,
+			)
+		}
+	}
+	return nil
+}
+
+// validateNetworkSegmentation 校验隔离网络的参数，隔离网络只能使用 isolated 模式
+func validateNetworkSegmentation(mode string, spec *entity.NetworkSegmentation) error {
+	if mode != "" && mode != "isolated" {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("mode %s cannot be used with segmentation, segmented networks are isolated", mode),
+			http.StatusBadRequest,
+		)
+	}
+	maxID := maxVLANID
+	switch spec.Type {
+	case entity.NetworkSegmentationVLAN:
+		if spec.VXLANGroup != "" {
+			return apierror.NewErrorWithStatus("InvalidParameterValue", "vxlan_group can only be used with vxlan segmentation", http.StatusBadRequest)
+		}
+	case entity.NetworkSegmentationVXLAN:
+		maxID = maxVXLANID
+		if spec.VXLANGroup != "" {
+			if ip := net.ParseIP(spec.VXLANGroup); ip == nil || !ip.IsMulticast() {
+				return apierror.NewErrorWithStatus(
+					"InvalidParameterValue",
+					fmt.Sprintf("vxlan_group %q is not a multicast address", spec.VXLANGroup),
+					http.StatusBadRequest,
+				)
+			}
+		}
+	default:
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported segmentation type %q, must be vlan or vxlan", spec.Type),
+			http.StatusBadRequest,
+		)
+	}
+	if spec.ID < 0 || spec.ID > maxID {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("%s id %d is out of range, must be between 1 and %d", spec.Type, spec.ID, maxID),
+			http.StatusBadRequest,
+		)
+	}
+	if !uplinkNamePattern.MatchString(spec.Uplink) {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("uplink %q must be the name of a host interface", spec.Uplink),
+			http.StatusBadRequest,
+		)
+	}
+	return nil
+}
+
+// attachNetworkSegment 在节点上把 VLAN 子接口或 VXLAN 设备接入网络的网桥
+func attachNetworkSegment(ctx context.Context, client libvirt.RemoteManager, segment *networkSegment, nodeName string) error {
+	if _, err := runNodeCommand(ctx, client, segment.attachCommand(segment.Nodes[nodeName])); err != nil {
+		return fmt.Errorf("attach %s %d to bridge %s: %w", segment.Type, segment.ID, segment.bridgeName(), err)
+	}
+	return nil
+}
@@ -3,42 +3,107 @@ package service
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
 	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
 )
 
 // NodeService 节点管理服务
 type NodeService struct {
-	storage *NodeStorage
+	storage    *NodeStorage
+	transfer   *TransferLimiter
+	operations *OperationLimiter
+	changes    *ChangeFeed
+	region     string // 集群在 EC2 兼容接口中的区域名称
+
+	networkDefaults NetworkDefaults      // 创建实例未指定网络源时的默认网络
+	networkSegments *NetworkSegmentStore // 隔离网络的归属租户，租户只能连接自己的隔离网络
+
+	featureCache nodeFeatureCache // 各节点的工具与能力探测结果
+	probes       nodeProbes       // 列举节点时正在执行的连接探测
+	clocks       nodeClocks       // 各节点与控制面的时钟偏差
+
+	clockSkewThreshold time.Duration // 时钟偏差告警阈值，0 表示不告警
+}
+
+// nodeProbeTimeout 列举节点时单个节点连接探测的超时时间
+const nodeProbeTimeout = 5 * time.Second
+
+// nodeProbeConcurrency 列举节点时同时探测的节点数上限
+const nodeProbeConcurrency = 8
+
+// nodeProbes 各节点正在执行的连接探测，key 为节点名称
+type nodeProbes struct {
+	mu       sync.Mutex
+	inflight map[string]*nodeProbeCall
+}
+
+// nodeProbeCall 一次连接探测，done 关闭后 info 与 err 可读
+type nodeProbeCall struct {
+	done chan struct{}
+	info *libvirt.NodeInfo
+	err  error
 }
 
 // NewNodeService 创建节点服务
-func NewNodeService(storage *NodeStorage) (*NodeService, error) {
+func NewNodeService(storage *NodeStorage, transfer *TransferLimiter, operations *OperationLimiter, changes *ChangeFeed, region string, networkDefaults NetworkDefaults, networkSegments *NetworkSegmentStore, clockSkewThreshold time.Duration) (*NodeService, error) {
 	if err := storage.EnsureDefaultLocal(); err != nil {
 		return nil, err
 	}
+	if err := storage.RegisterOwnershipPolicies(); err != nil {
+		return nil, err
+	}
+	if err := storage.RegisterTLSConfigs(); err != nil {
+		return nil, err
+	}
 	return &NodeService{
-		storage: storage,
+		storage:    storage,
+		transfer:   transfer,
+		operations: operations,
+		changes:    changes,
+		region:     region,
+
+		networkDefaults: networkDefaults,
+		networkSegments: networkSegments,
+
+		featureCache: nodeFeatureCache{features: make(map[string]*entity.NodeFeatures)},
+		probes:       nodeProbes{inflight: make(map[string]*nodeProbeCall)},
+		clocks:       nodeClocks{checks: make(map[string]*nodeClockCheck)},
+
+		clockSkewThreshold: clockSkewThreshold,
 	}, nil
 }
 
 // GetNodeStorage 获取节点的 libvirt 连接 (用于存储操作)
-// nodeName 为空时返回本地节点连接
+// nodeName 为空时返回本地节点连接；ctx 中有 span 时 libvirt 调用记录为其子 span
 func (s *NodeService) GetNodeStorage(ctx context.Context, nodeName string) (libvirt.LibvirtClient, error) {
 	// 如果 nodeName 为空或为 "local",返回本地连接
 	if nodeName == "" || nodeName == "local" {
 		// 本地连接使用默认的 qemu:///system
-		return libvirt.NewWithURI("qemu:///system")
+		client, err := libvirt.NewWithURI("qemu:///system")
+		if err != nil {
+			return nil, err
+		}
+		return libvirt.Traced(ctx, client), nil
 	}
 
 	// 获取远程节点连接
-	return s.storage.GetConnection(nodeName)
+	client, err := s.storage.GetConnection(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return libvirt.Traced(ctx, client), nil
 }
 
 // ListNodes 列举节点
+// 并行探测各节点（并发上限 nodeProbeConcurrency，单个节点超时 nodeProbeTimeout），无法连接或超时的节点标记为 degraded，不影响其他节点
+// 在线节点返回最近一次测量的时钟偏差，超过阈值时在 warnings 中告警
 func (s *NodeService) ListNodes(ctx context.Context) ([]*entity.Node, error) {
 	// 从存储获取所有节点配置
 	configs, err := s.storage.List()
@@ -47,43 +112,106 @@ func (s *NodeService) ListNodes(ctx context.Context) ([]*entity.Node, error) {
 	}
 
 	nodes := make([]*entity.Node, 0, len(configs))
+	sem := make(chan struct{}, nodeProbeConcurrency)
+	var wg sync.WaitGroup
 	for _, config := range configs {
+		node := &entity.Node{
+			Name:         config.Name,
+			UUID:         fmt.Sprintf("%s-node", config.Name),
+			URI:          config.URI,
+			Type:         config.Type,
+			Reserved:     config.Reserved,
+			CreatedAt:    config.CreatedAt,
+			UpdatedAt:    config.UpdatedAt,
+			Ownership:    config.Ownership,
+			TLS:          config.TLS,
+			NodeTopology: config.NodeTopology,
+		}
+		nodes = append(nodes, node)
 		// 如果节点被手动设置为维护模式，直接使用该状态
 		if config.State == entity.NodeStateMaintenance {
-			node := &entity.Node{
-				Name:      config.Name,
-				UUID:      fmt.Sprintf("%s-node", config.Name),
-				URI:       config.URI,
-				Type:      config.Type,
-				State:     entity.NodeStateMaintenance,
-				CreatedAt: config.CreatedAt,
-				UpdatedAt: config.UpdatedAt,
-			}
-			nodes = append(nodes, node)
+			node.State = entity.NodeStateMaintenance
 			continue
 		}
 
-		state := entity.NodeStateOffline
-		if conn, err := s.storage.GetConnection(config.Name); err == nil {
-			if _, err := conn.GetHostname(); err == nil {
-				state = entity.NodeStateOnline
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			info, err := s.probeNode(ctx, config.Name)
+			if err != nil {
+				node.State = entity.NodeStateDegraded
+				node.StateReason = err.Error()
+				zerolog.Ctx(ctx).Warn().
+					Err(err).
+					Str("node", config.Name).
+					Msg("Node probe failed")
+				return
 			}
-		}
-
-		nodes = append(nodes, &entity.Node{
-			Name:      config.Name,
-			UUID:      fmt.Sprintf("%s-node", config.Name),
-			URI:       config.URI,
-			Type:      config.Type,
-			State:     state,
-			CreatedAt: config.CreatedAt,
-			UpdatedAt: config.UpdatedAt,
-		})
+			node.State = entity.NodeStateOnline
+			s.checkNodeClock(ctx, config.Name)
+			s.applyNodeClock(node)
+			if info != nil {
+				capacity := nodeCapacity(info)
+				allocatable := allocatableResources(info, config.Reserved)
+				node.Capacity = &capacity
+				node.Allocatable = &allocatable
+			}
+		}()
 	}
+	wg.Wait()
 
 	return nodes, nil
 }
 
+// probeNode 探测节点是否可连接并获取资源信息，超时后返回错误
+// 超时的探测仍在后台继续，同一节点同时只有一个探测在执行，后续调用等待它的结果，避免不可达节点堆积连接
+func (s *NodeService) probeNode(ctx context.Context, nodeName string) (*libvirt.NodeInfo, error) {
+	s.probes.mu.Lock()
+	call, ok := s.probes.inflight[nodeName]
+	if !ok {
+		call = &nodeProbeCall{done: make(chan struct{})}
+		s.probes.inflight[nodeName] = call
+		go func() {
+			call.info, call.err = s.runNodeProbe(nodeName)
+			s.probes.mu.Lock()
+			delete(s.probes.inflight, nodeName)
+			s.probes.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	s.probes.mu.Unlock()
+
+	timer := time.NewTimer(nodeProbeTimeout)
+	defer timer.Stop()
+	select {
+	case <-call.done:
+		return call.info, call.err
+	case <-timer.C:
+		return nil, fmt.Errorf("node probe timed out after %s", nodeProbeTimeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// runNodeProbe 建立连接并获取主机名与资源信息；主机名可获取即视为在线，资源信息获取失败时返回 nil
+func (s *NodeService) runNodeProbe(nodeName string) (*libvirt.NodeInfo, error) {
+	conn, err := s.storage.GetConnection(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.GetHostname(); err != nil {
+		return nil, err
+	}
+	info, err := conn.GetNodeInfo()
+	if err != nil {
+		return nil, nil
+	}
+	return info, nil
+}
+
 // DescribeNode 查询节点详情
 func (s *NodeService) DescribeNode(ctx context.Context, nodeName string) (*entity.Node, error) {
 	nodes, err := s.ListNodes(ctx)
@@ -120,28 +248,16 @@ func (s *NodeService) DescribeNodeSummary(ctx context.Context, nodeName string)
 		}
 	}
 
-	// 获取 capabilities XML（包含详细的 CPU、内存、NUMA 信息）
-	capsXML, err := conn.GetCapabilities()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get capabilities: %w", err)
-	}
-
-	// 解析 capabilities XML
-	caps, err := libvirt.ParseCapabilities(capsXML)
+	// 获取 capabilities（包含详细的 CPU、内存、NUMA 信息以及支持的 guest 架构）
+	caps, err := conn.GetCapabilities()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse capabilities: %w", err)
-	}
-
-	// 获取 sysinfo XML（包含真实的 CPU 型号）
-	sysinfoXML, err := conn.GetSysinfo()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get sysinfo: %w", err)
+		return nil, err
 	}
 
-	// 解析 sysinfo XML
-	sysinfo, err := libvirt.ParseSysinfo(sysinfoXML)
+	// 获取 sysinfo（包含真实的 CPU 型号与整机序列号）
+	sysinfo, err := conn.GetSysinfo()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse sysinfo: %w", err)
+		return nil, err
 	}
 
 	// 提取 CPU 特性/flags
@@ -271,12 +387,15 @@ func (s *NodeService) DescribeNodeSummary(ctx context.Context, nodeName string)
 		}
 	}
 
-	// 构建虚拟化信息
+	// 构建虚拟化信息：vmx/svm 来自宿主机 CPU 特性，EPT/NPT 只有较新的 libvirt 会以 vmx-ept/npt 特性上报
+	extension := caps.VirtualizationExtension()
 	virtualizationInfo := entity.VirtualizationInfo{
-		VTx:        true,                             // 假设支持（因为能运行 libvirt）
-		EPT:        true,                             // 假设支持
+		Extension:  extension,
+		VTx:        extension != "",
+		EPT:        caps.HasCPUFeature("vmx-ept") || caps.HasCPUFeature("npt"),
 		IOMMU:      caps.Host.IOMMU.Support == "yes", // 从 capabilities 获取
-		NestedVirt: false,                            // 简化假设
+		NestedVirt: false,                            // capabilities 不提供宿主机 nested 参数
+		KVM:        caps.SupportsDomainType("kvm"),
 	}
 
 	summary := &entity.NodeSummary{
@@ -285,11 +404,42 @@ func (s *NodeService) DescribeNodeSummary(ctx context.Context, nodeName string)
 		NUMA:           numaInfo,
 		HugePages:      hugePagesInfo,
 		Virtualization: virtualizationInfo,
+		System: entity.SystemInfo{
+			Manufacturer: sysinfo.GetSystemManufacturer(),
+			Product:      sysinfo.GetSystemProduct(),
+			SerialNumber: sysinfo.GetSystemSerial(),
+			UUID:         sysinfo.GetSystemUUID(),
+			BIOSVendor:   sysinfo.GetBIOSVendor(),
+			BIOSVersion:  sysinfo.GetBIOSVersion(),
+		},
+		Guests: convertGuestCapabilities(caps),
 	}
 
 	return summary, nil
 }
 
+// convertGuestCapabilities 把 capabilities 中的 guest 列表转换为节点支持的架构
+func convertGuestCapabilities(caps *libvirt.CapabilitiesXML) []entity.GuestCapability {
+	guests := make([]entity.GuestCapability, 0, len(caps.Guests))
+	for _, guest := range caps.Guests {
+		gc := entity.GuestCapability{
+			Arch:        guest.Arch.Name,
+			OSType:      guest.OSType,
+			Emulator:    guest.Arch.Emulator,
+			DomainTypes: make([]string, 0, len(guest.Arch.Domains)),
+			Machines:    make([]string, 0, len(guest.Arch.Machines)),
+		}
+		for _, domain := range guest.Arch.Domains {
+			gc.DomainTypes = append(gc.DomainTypes, domain.Type)
+		}
+		for _, machine := range guest.Arch.Machines {
+			gc.Machines = append(gc.Machines, strings.TrimSpace(machine.Name))
+		}
+		guests = append(guests, gc)
+	}
+	return guests
+}
+
 // DescribeNodePCI 查询节点 PCI 设备
 func (s *NodeService) DescribeNodePCI(ctx context.Context, nodeName string) ([]entity.PCIDevice, error) {
 	// 获取节点的 libvirt 连接
@@ -424,6 +574,111 @@ func (s *NodeService) DescribeNodeNet(ctx context.Context, nodeName string) (*No
 	}, nil
 }
 
+// DescribeNodeNetwork 查询节点网络拓扑：网桥、物理网卡、VLAN、bond 及其 IP 段，以及 libvirt 虚拟网络
+func (s *NodeService) DescribeNodeNetwork(ctx context.Context, nodeName string) (*entity.NodeNetwork, error) {
+	logger := zerolog.Ctx(ctx)
+
+	conn, err := s.storage.GetConnection(nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node connection: %w", err)
+	}
+
+	ifaces, err := conn.ListInterfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interfaces: %w", err)
+	}
+
+	topology := &entity.NodeNetwork{
+		Bridges:   []entity.HostInterface{},
+		Ethernets: []entity.HostInterface{},
+		VLANs:     []entity.HostInterface{},
+		Bonds:     []entity.HostInterface{},
+		Networks:  []entity.Network{},
+	}
+
+	for _, iface := range ifaces {
+		xmlDesc, err := conn.GetInterfaceXMLDesc(iface)
+		if err != nil {
+			logger.Warn().Err(err).Str("interface", iface.Name).Msg("Failed to get interface XML")
+			continue
+		}
+		ifaceXML, err := libvirt.ParseInterfaceXML(xmlDesc)
+		if err != nil {
+			logger.Warn().Err(err).Str("interface", iface.Name).Msg("Failed to parse interface XML")
+			continue
+		}
+
+		hostIface := convertHostInterface(ifaceXML)
+		switch ifaceXML.Type {
+		case "bridge":
+			topology.Bridges = append(topology.Bridges, hostIface)
+		case "vlan":
+			topology.VLANs = append(topology.VLANs, hostIface)
+		case "bond":
+			topology.Bonds = append(topology.Bonds, hostIface)
+		case "ethernet":
+			// lo 不能作为实例网络源
+			if ifaceXML.Name != "lo" {
+				topology.Ethernets = append(topology.Ethernets, hostIface)
+			}
+		}
+	}
+
+	// libvirt 虚拟网络获取失败不影响宿主机接口结果
+	networkInfos, err := conn.ListNetworksInfo()
+	if err != nil {
+		logger.Warn().Err(err).Str("node", nodeName).Msg("Failed to list libvirt networks")
+	}
+	for _, info := range networkInfos {
+		topology.Networks = append(topology.Networks, convertNetworkInfo(nodeName, info))
+	}
+
+	// libvirt 的接口列表依赖 netcf/udev，可能缺少未配置的网卡，macvtap 网络源改从 sysfs 探测
+	topology.DirectSources, err = listDirectSources(ctx, conn)
+	if err != nil {
+		logger.Warn().Err(err).Str("node", nodeName).Msg("Failed to list direct network sources")
+		topology.DirectSources = []entity.DirectSource{}
+	}
+
+	return topology, nil
+}
+
+// convertHostInterface 将接口 XML 转换为宿主机网络接口实体
+func convertHostInterface(ifaceXML *libvirt.InterfaceXML) entity.HostInterface {
+	hostIface := entity.HostInterface{
+		Name:      ifaceXML.Name,
+		Type:      ifaceXML.Type,
+		Addresses: ifaceXML.CIDRs(),
+	}
+	if ifaceXML.MAC != nil {
+		hostIface.MAC = ifaceXML.MAC.Address
+	}
+	if ifaceXML.MTU != nil {
+		hostIface.MTU = ifaceXML.MTU.Size
+	}
+	if ifaceXML.Link != nil {
+		hostIface.State = ifaceXML.Link.State
+	}
+	if ifaceXML.Bridge != nil {
+		for _, member := range ifaceXML.Bridge.Interfaces {
+			hostIface.Members = append(hostIface.Members, member.Name)
+		}
+	}
+	if ifaceXML.Bond != nil {
+		hostIface.BondMode = ifaceXML.Bond.Mode
+		for _, member := range ifaceXML.Bond.Interfaces {
+			hostIface.Members = append(hostIface.Members, member.Name)
+		}
+	}
+	if ifaceXML.VLAN != nil {
+		hostIface.VLANTag = ifaceXML.VLAN.Tag
+		if ifaceXML.VLAN.Interface != nil {
+			hostIface.VLANParent = ifaceXML.VLAN.Interface.Name
+		}
+	}
+	return hostIface
+}
+
 // DescribeNodeDisks 查询节点物理磁盘
 func (s *NodeService) DescribeNodeDisks(ctx context.Context, nodeName string) ([]entity.Disk, error) {
 	// 获取节点的 libvirt 连接
@@ -487,18 +742,24 @@ func (s *NodeService) DescribeNodeDisks(ctx context.Context, nodeName string) ([
 	return disks, nil
 }
 
-// CreateNode 创建（添加）新节点
-func (s *NodeService) CreateNode(ctx context.Context, name, uri string, nodeType entity.NodeType) (*entity.Node, error) {
+// CreateNode 创建（添加）新节点，reserved 为预留给宿主机系统的资源，topology 为节点所在的可用区与机架
+// tlsConfig 为 qemu+tls 连接使用的证书，为空时使用 URI 中的 pkipath 或 libvirt 默认位置
+func (s *NodeService) CreateNode(ctx context.Context, name, uri string, nodeType entity.NodeType, reserved entity.NodeResources, topology entity.NodeTopology, tlsConfig entity.NodeTLS) (*entity.Node, error) {
 	// 检查节点是否已存在
 	if s.storage.Exists(name) {
 		return nil, fmt.Errorf("node %s already exists", name)
 	}
+	if err := validateNodeTLS(uri, tlsConfig); err != nil {
+		return nil, err
+	}
 
 	// 验证连接 - 尝试连接以确保 URI 有效
-	conn, err := libvirt.NewWithURI(uri)
+	conn, err := connectNode(uri, tlsConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to %s: %w", uri, err)
 	}
+	// 只用于校验，节点的长期连接之后由 NodeStorage 建立
+	defer func() { _ = conn.Close() }()
 
 	// 获取实际的 hostname
 	_, err = conn.GetHostname()
@@ -506,31 +767,56 @@ func (s *NodeService) CreateNode(ctx context.Context, name, uri string, nodeType
 		return nil, fmt.Errorf("failed to get hostname: %w", err)
 	}
 
+	info, err := conn.GetNodeInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node info: %w", err)
+	}
+	if err := validateReservedResources(info, reserved); err != nil {
+		return nil, err
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CreateNode")
+	}
+
 	// 创建节点配置
 	now := time.Now()
 	config := &NodeConfig{
-		Name:      name,
-		URI:       uri,
-		Type:      nodeType,
-		State:     entity.NodeStateOnline, // 新创建的节点默认为 online
-		CreatedAt: now,
-		UpdatedAt: now,
+		Name:         name,
+		URI:          uri,
+		Type:         nodeType,
+		State:        entity.NodeStateOnline, // 新创建的节点默认为 online
+		Reserved:     reserved,
+		TLS:          tlsConfig,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		NodeTopology: topology,
 	}
 
 	// 保存配置
 	if err := s.storage.Save(config); err != nil {
 		return nil, fmt.Errorf("failed to save node config: %w", err)
 	}
+	libvirt.SetTLSConfig(uri, nodeTLSConfig(tlsConfig))
+	s.changes.Record(entity.ResourceTypeNode, "", name, entity.ResourceChangeAdded)
+	s.probeNodeFeatures(ctx, name)
 
 	// 返回节点信息
+	capacity := nodeCapacity(info)
+	allocatable := allocatableResources(info, reserved)
 	node := &entity.Node{
-		Name:      name,
-		UUID:      fmt.Sprintf("%s-node", name),
-		URI:       uri,
-		Type:      nodeType,
-		State:     entity.NodeStateOnline,
-		CreatedAt: now,
-		UpdatedAt: now,
+		Name:         name,
+		UUID:         fmt.Sprintf("%s-node", name),
+		URI:          uri,
+		Type:         nodeType,
+		State:        entity.NodeStateOnline,
+		Capacity:     &capacity,
+		Reserved:     reserved,
+		Allocatable:  &allocatable,
+		TLS:          tlsConfig,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		NodeTopology: topology,
 	}
 
 	return node, nil
@@ -543,10 +829,21 @@ func (s *NodeService) DeleteNode(ctx context.Context, nodeName string) error {
 		return fmt.Errorf("node %s not found", nodeName)
 	}
 
+	if isDryRun(ctx) {
+		return dryRunOperation(ctx, "DeleteNode")
+	}
+
 	// 删除节点配置
 	if err := s.storage.Delete(nodeName); err != nil {
 		return fmt.Errorf("failed to delete node: %w", err)
 	}
+	s.featureCache.mu.Lock()
+	delete(s.featureCache.features, nodeName)
+	s.featureCache.mu.Unlock()
+	s.clocks.mu.Lock()
+	delete(s.clocks.checks, nodeName)
+	s.clocks.mu.Unlock()
+	s.changes.Record(entity.ResourceTypeNode, "", nodeName, entity.ResourceChangeDeleted)
 
 	return nil
 }
@@ -559,6 +856,10 @@ func (s *NodeService) EnableNode(ctx context.Context, nodeName string) error {
 		return fmt.Errorf("failed to get node config: %w", err)
 	}
 
+	if isDryRun(ctx) {
+		return dryRunOperation(ctx, "EnableNode")
+	}
+
 	// 更新状态为在线
 	config.State = entity.NodeStateOnline
 	config.UpdatedAt = time.Now()
@@ -567,6 +868,9 @@ func (s *NodeService) EnableNode(ctx context.Context, nodeName string) error {
 	if err := s.storage.Save(config); err != nil {
 		return fmt.Errorf("failed to save node config: %w", err)
 	}
+	s.changes.Record(entity.ResourceTypeNode, "", nodeName, entity.ResourceChangeModified)
+	// 维护期间可能安装或升级了工具，退出维护时重新探测
+	s.probeNodeFeatures(ctx, nodeName)
 
 	return nil
 }
@@ -579,6 +883,10 @@ func (s *NodeService) DisableNode(ctx context.Context, nodeName string) error {
 		return fmt.Errorf("failed to get node config: %w", err)
 	}
 
+	if isDryRun(ctx) {
+		return dryRunOperation(ctx, "DisableNode")
+	}
+
 	// 更新状态为维护模式
 	config.State = entity.NodeStateMaintenance
 	config.UpdatedAt = time.Now()
@@ -587,7 +895,249 @@ func (s *NodeService) DisableNode(ctx context.Context, nodeName string) error {
 	if err := s.storage.Save(config); err != nil {
 		return fmt.Errorf("failed to save node config: %w", err)
 	}
+	s.changes.Record(entity.ResourceTypeNode, "", nodeName, entity.ResourceChangeModified)
+
+	return nil
+}
+
+// ModifyNodeReservedResources 修改节点预留给宿主机系统的资源，之后的容量计算按新的预留值扣除
+func (s *NodeService) ModifyNodeReservedResources(ctx context.Context, nodeName string, reserved entity.NodeResources) (*entity.Node, error) {
+	config, err := s.storage.Get(nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node config: %w", err)
+	}
+
+	conn, err := s.storage.GetConnection(nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node connection: %w", err)
+	}
+	info, err := conn.GetNodeInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node info: %w", err)
+	}
+	if err := validateReservedResources(info, reserved); err != nil {
+		return nil, err
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ModifyNodeReservedResources")
+	}
+
+	config.Reserved = reserved
+	config.UpdatedAt = time.Now()
+	if err := s.storage.Save(config); err != nil {
+		return nil, fmt.Errorf("failed to save node config: %w", err)
+	}
+	s.changes.Record(entity.ResourceTypeNode, "", nodeName, entity.ResourceChangeModified)
+
+	zerolog.Ctx(ctx).Info().
+		Str("node_name", nodeName).
+		Uint32("reserved_cpus", reserved.CPUs).
+		Uint64("reserved_memory_mb", reserved.MemoryMB).
+		Msg("Node reserved resources modified")
+
+	return s.DescribeNode(ctx, nodeName)
+}
+
+// ReservedResources 获取节点预留给宿主机系统的资源，节点配置不存在时不预留
+func (s *NodeService) ReservedResources(ctx context.Context, nodeName string) entity.NodeResources {
+	if nodeName == "" {
+		nodeName = "local"
+	}
+	config, err := s.storage.Get(nodeName)
+	if err != nil {
+		return entity.NodeResources{}
+	}
+	return config.Reserved
+}
+
+// ModifyNodeTopology 修改节点的可用区与机架标签，只影响之后的实例调度，已有实例不会迁移
+func (s *NodeService) ModifyNodeTopology(ctx context.Context, nodeName string, topology entity.NodeTopology) (*entity.Node, error) {
+	config, err := s.storage.Get(nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node config: %w", err)
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ModifyNodeTopology")
+	}
+
+	config.NodeTopology = topology
+	config.UpdatedAt = time.Now()
+	if err := s.storage.Save(config); err != nil {
+		return nil, fmt.Errorf("failed to save node config: %w", err)
+	}
+	s.changes.Record(entity.ResourceTypeNode, "", nodeName, entity.ResourceChangeModified)
+
+	zerolog.Ctx(ctx).Info().
+		Str("node_name", nodeName).
+		Str("zone", topology.Zone).
+		Str("rack", topology.Rack).
+		Msg("Node topology modified")
+
+	return s.DescribeNode(ctx, nodeName)
+}
+
+// NodeTopology 获取节点的可用区与机架标签，节点配置不存在时为空
+func (s *NodeService) NodeTopology(ctx context.Context, nodeName string) entity.NodeTopology {
+	config, err := s.storage.Get(nodeName)
+	if err != nil {
+		return entity.NodeTopology{}
+	}
+	return config.NodeTopology
+}
+
+// ModifyNodeOwnership 修改节点的文件属主修复策略，立即对之后创建的 VNC socket 目录与卷生效，已有文件不做修改
+func (s *NodeService) ModifyNodeOwnership(ctx context.Context, nodeName string, ownership entity.NodeOwnership) (*entity.Node, error) {
+	config, err := s.storage.Get(nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node config: %w", err)
+	}
+	if ownership.Disabled && (ownership.User != "" || ownership.Group != "") {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"user and group cannot be set when ownership fix is disabled",
+			http.StatusBadRequest,
+		)
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ModifyNodeOwnership")
+	}
+
+	config.Ownership = ownership
+	config.UpdatedAt = time.Now()
+	if err := s.storage.Save(config); err != nil {
+		return nil, fmt.Errorf("failed to save node config: %w", err)
+	}
+	s.changes.Record(entity.ResourceTypeNode, "", nodeName, entity.ResourceChangeModified)
+
+	zerolog.Ctx(ctx).Info().
+		Str("node_name", nodeName).
+		Bool("disabled", ownership.Disabled).
+		Str("user", ownership.User).
+		Str("group", ownership.Group).
+		Msg("Node ownership policy modified")
+
+	return s.DescribeNode(ctx, nodeName)
+}
+
+// ModifyNodeTLS 修改节点 qemu+tls 连接使用的证书，用新证书建立连接验证通过后保存，并重建节点缓存的连接
+// 证书轮换时文件路径不变也可以调用，以便立即用新证书重连
+func (s *NodeService) ModifyNodeTLS(ctx context.Context, nodeName string, tlsConfig entity.NodeTLS) (*entity.Node, error) {
+	config, err := s.storage.Get(nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node config: %w", err)
+	}
+	if err := validateNodeTLS(config.URI, tlsConfig); err != nil {
+		return nil, err
+	}
+	if !libvirt.IsTLSURI(config.URI) {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("node %s connects with %s, TLS certificates only apply to qemu+tls URIs", nodeName, config.URI),
+			http.StatusBadRequest,
+		)
+	}
+
+	conn, err := connectNode(config.URI, tlsConfig)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("failed to connect to %s with the new certificates: %v", config.URI, err),
+			http.StatusBadRequest,
+		)
+	}
+	_ = conn.Close()
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ModifyNodeTLS")
+	}
+
+	config.TLS = tlsConfig
+	config.UpdatedAt = time.Now()
+	if err := s.storage.Save(config); err != nil {
+		return nil, fmt.Errorf("failed to save node config: %w", err)
+	}
+	libvirt.SetTLSConfig(config.URI, nodeTLSConfig(tlsConfig))
+	s.storage.ResetConnection(nodeName)
+	s.changes.Record(entity.ResourceTypeNode, "", nodeName, entity.ResourceChangeModified)
+
+	zerolog.Ctx(ctx).Info().
+		Str("node_name", nodeName).
+		Str("ca_cert_path", tlsConfig.CACertPath).
+		Str("client_cert_path", tlsConfig.ClientCertPath).
+		Str("client_key_path", tlsConfig.ClientKeyPath).
+		Bool("insecure_skip_verify", tlsConfig.InsecureSkipVerify).
+		Msg("Node TLS certificates modified")
+
+	return s.DescribeNode(ctx, nodeName)
+}
+
+// validateNodeTLS 证书配置只能用于 TLS 连接，配置的证书文件需在 JVP 所在主机上可读
+func validateNodeTLS(uri string, tlsConfig entity.NodeTLS) error {
+	if tlsConfig == (entity.NodeTLS{}) {
+		return nil
+	}
+	if !libvirt.IsTLSURI(uri) {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("TLS certificates only apply to qemu+tls URIs, got %s", uri),
+			http.StatusBadRequest,
+		)
+	}
+	if _, err := nodeTLSConfig(tlsConfig).Load(""); err != nil {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("invalid TLS certificates: %v", err),
+			http.StatusBadRequest,
+		)
+	}
+	return nil
+}
+
+// connectNode 按 URI 与证书配置建立节点连接，未配置证书时与 libvirt.Connect 相同
+func connectNode(uri string, tlsConfig entity.NodeTLS) (libvirt.LibvirtClient, error) {
+	if tlsConfig == (entity.NodeTLS{}) {
+		return libvirt.Connect(uri)
+	}
+	return libvirt.NewWithTLS(uri, nodeTLSConfig(tlsConfig))
+}
+
+// nodeCapacity 节点总资源
+func nodeCapacity(info *libvirt.NodeInfo) entity.NodeResources {
+	return entity.NodeResources{
+		CPUs:     info.CPUs,
+		MemoryMB: info.Memory / 1024,
+	}
+}
 
+// allocatableResources 节点可分配给实例的资源：总资源扣除预留，预留超过总量时为 0
+func allocatableResources(info *libvirt.NodeInfo, reserved entity.NodeResources) entity.NodeResources {
+	capacity := nodeCapacity(info)
+	return entity.NodeResources{
+		CPUs:     capacity.CPUs - min(reserved.CPUs, capacity.CPUs),
+		MemoryMB: capacity.MemoryMB - min(reserved.MemoryMB, capacity.MemoryMB),
+	}
+}
+
+// validateReservedResources 预留资源必须小于节点总资源，否则节点上无法再分配任何实例
+func validateReservedResources(info *libvirt.NodeInfo, reserved entity.NodeResources) error {
+	capacity := nodeCapacity(info)
+	if reserved.CPUs >= capacity.CPUs {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("reserved_cpus %d must be less than node CPUs %d", reserved.CPUs, capacity.CPUs),
+			http.StatusBadRequest,
+		)
+	}
+	if reserved.MemoryMB >= capacity.MemoryMB {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("reserved_memory_mb %d must be less than node memory %d MB", reserved.MemoryMB, capacity.MemoryMB),
+			http.StatusBadRequest,
+		)
+	}
 	return nil
 }
 
@@ -705,3 +1255,52 @@ func (s *NodeService) DescribeNodeVMs(ctx context.Context, nodeName string) ([]N
 
 	return vms, nil
 }
+
+// DescribeTransferBandwidth 查询大文件传输的全局限速（MiB/s），0 表示不限速
+func (s *NodeService) DescribeTransferBandwidth(ctx context.Context) uint64 {
+	return s.transfer.Global()
+}
+
+// ModifyTransferBandwidth 调整大文件传输的全局限速（MiB/s）
+// 对之后启动且未单独指定限速的传输生效，正在执行的 block job 需通过 ModifyDiskJobBandwidth 调整
+func (s *NodeService) ModifyTransferBandwidth(ctx context.Context, bandwidthMiB uint64) error {
+	if isDryRun(ctx) {
+		return dryRunOperation(ctx, "ModifyTransferBandwidth")
+	}
+
+	previous := s.transfer.Global()
+	s.transfer.SetGlobal(bandwidthMiB)
+
+	zerolog.Ctx(ctx).Info().
+		Uint64("previous_mib", previous).
+		Uint64("bandwidth_mib", bandwidthMiB).
+		Msg("Transfer bandwidth modified")
+	return nil
+}
+
+// DescribeNodeOperations 查询节点上执行中与排队中的重 IO 操作，以及当前每节点并发上限（0 表示不限制）
+func (s *NodeService) DescribeNodeOperations(ctx context.Context, nodeName string) ([]entity.NodeOperation, uint64) {
+	return s.operations.List(normalizeNodeName(nodeName)), s.operations.Limit()
+}
+
+// ModifyNodeOperationLimit 调整每节点重 IO 操作的并发上限，0 表示不限制
+// 调高后立即放行排队中的操作，调低不会中断正在执行的操作
+func (s *NodeService) ModifyNodeOperationLimit(ctx context.Context, limit uint64) error {
+	if isDryRun(ctx) {
+		return dryRunOperation(ctx, "ModifyNodeOperationLimit")
+	}
+
+	previous := s.operations.Limit()
+	s.operations.SetLimit(limit)
+
+	zerolog.Ctx(ctx).Info().
+		Uint64("previous_limit", previous).
+		Uint64("limit", limit).
+		Msg("Node operation limit modified")
+	return nil
+}
+
+// WatchResources 监听所有节点上的资源变化，since_revision 为 0 时立即返回当前 revision
+func (s *NodeService) WatchResources(ctx context.Context, req *entity.WatchResourcesRequest) (*entity.WatchResourcesResponse, error) {
+	return s.changes.WatchResources(ctx, req)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/rs/zerolog"
+)
+
+// nodeClockCheckInterval 同一节点两次时钟偏差测量的最小间隔，期间列举节点复用最近一次的结果
+const nodeClockCheckInterval = time.Minute
+
+// nodeClockCheckTimeout 单次时钟偏差测量的超时时间
+const nodeClockCheckTimeout = 10 * time.Second
+
+// nodeClocks 各节点最近一次的时钟偏差测量结果，key 为节点名称
+type nodeClocks struct {
+	mu     sync.Mutex
+	checks map[string]*nodeClockCheck
+}
+
+// nodeClockCheck 节点时钟偏差测量结果
+type nodeClockCheck struct {
+	skew       time.Duration // 节点时钟减去控制面时钟，正数表示节点超前
+	rtt        time.Duration // 读取节点时钟的命令往返耗时，偏差的测量误差不超过其一半
+	measuredAt time.Time     // 最近一次测量成功的时间，为零表示尚未测量成功
+	checkedAt  time.Time     // 最近一次开始测量的时间（无论成功与否）
+	inProgress bool
+}
+
+// checkNodeClock 节点探测成功后调用：距上次测量超过 nodeClockCheckInterval 时在后台重新测量，不阻塞节点探测
+func (s *NodeService) checkNodeClock(ctx context.Context, nodeName string) {
+	now := time.Now()
+	s.clocks.mu.Lock()
+	check, ok := s.clocks.checks[nodeName]
+	if !ok {
+		check = &nodeClockCheck{}
+		s.clocks.checks[nodeName] = check
+	}
+	if check.inProgress || now.Sub(check.checkedAt) < nodeClockCheckInterval {
+		s.clocks.mu.Unlock()
+		return
+	}
+	check.inProgress = true
+	check.checkedAt = now
+	s.clocks.mu.Unlock()
+
+	go func() {
+		logger := zerolog.Ctx(ctx).With().Str("node", nodeName).Logger()
+		checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), nodeClockCheckTimeout)
+		defer cancel()
+
+		skew, rtt, err := s.measureNodeClockSkew(checkCtx, nodeName)
+
+		s.clocks.mu.Lock()
+		check.inProgress = false
+		if err == nil {
+			check.skew, check.rtt, check.measuredAt = skew, rtt, time.Now()
+		}
+		s.clocks.mu.Unlock()
+
+		switch {
+		case err != nil:
+			logger.Warn().Err(err).Msg("Failed to measure node clock skew")
+		case s.clockSkewExceeded(skew):
+			logger.Warn().
+				Dur("skew", skew).
+				Dur("rtt", rtt).
+				Dur("threshold", s.clockSkewThreshold).
+				Msg("Node clock skew exceeds threshold, check time synchronization (chrony/ntpd) on the node")
+		default:
+			logger.Debug().
+				Dur("skew", skew).
+				Dur("rtt", rtt).
+				Msg("Node clock skew measured")
+		}
+	}()
+}
+
+// measureNodeClockSkew 读取节点时钟并与控制面时钟比较
+// 以命令往返的中点作为节点读取时钟的时刻，远程节点的 SSH 建连耗时计入往返
+func (s *NodeService) measureNodeClockSkew(ctx context.Context, nodeName string) (skew, rtt time.Duration, err error) {
+	client, err := s.storage.GetConnection(nodeName)
+	if err != nil {
+		return 0, 0, fmt.Errorf("get node connection: %w", err)
+	}
+
+	start := time.Now()
+	output, err := runNodeCommand(ctx, client, "date +%s%N")
+	if err != nil {
+		return 0, 0, fmt.Errorf("read node clock: %w", err)
+	}
+	rtt = time.Since(start)
+
+	nanos, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse node clock %q: %w", strings.TrimSpace(string(output)), err)
+	}
+	return time.Unix(0, nanos).Sub(start.Add(rtt / 2)), rtt, nil
+}
+
+// clockSkewExceeded 时钟偏差（绝对值）是否超过告警阈值，阈值为 0 时不告警
+func (s *NodeService) clockSkewExceeded(skew time.Duration) bool {
+	return s.clockSkewThreshold > 0 && skew.Abs() > s.clockSkewThreshold
+}
+
+// applyNodeClock 把最近一次的时钟偏差测量结果写入节点信息，超过阈值时添加告警
+func (s *NodeService) applyNodeClock(node *entity.Node) {
+	s.clocks.mu.Lock()
+	check, ok := s.clocks.checks[node.Name]
+	if !ok || check.measuredAt.IsZero() {
+		s.clocks.mu.Unlock()
+		return
+	}
+	skew := check.skew
+	measuredAt := check.measuredAt
+	s.clocks.mu.Unlock()
+
+	skewMs := skew.Milliseconds()
+	node.ClockSkewMs = &skewMs
+	node.ClockMeasuredAt = &measuredAt
+	if s.clockSkewExceeded(skew) {
+		node.Warnings = append(node.Warnings, fmt.Sprintf(
+			"clock skew %s exceeds threshold %s, cross-node snapshot and log ordering may be wrong; check time synchronization (chrony/ntpd) on the node",
+			skew.Round(time.Millisecond), s.clockSkewThreshold))
+	}
+}
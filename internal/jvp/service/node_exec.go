@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/jimyag/jvp/pkg/tracing"
+	"github.com/rs/zerolog"
+)
+
+// runNodeCommand 在 libvirt 连接所在的宿主机执行 shell 命令，远程节点通过 SSH 执行
+// command 由远程 shell 解释，其中来自用户输入的参数必须先用 shellx.Quote 转义
+func runNodeCommand(ctx context.Context, client libvirt.RemoteManager, command string) (output []byte, err error) {
+	target, err := nodeCommandTarget(client)
+	if err != nil {
+		return nil, err
+	}
+
+	// span 名称取命令的第一个词（如 "ssh lsblk"），完整命令记录在属性中
+	ctx, span := tracing.StartCommand(ctx, nodeCommandProgram(target), strings.Fields(command), target)
+	defer func() { tracing.End(span, err) }()
+
+	cmd := newNodeCommand(ctx, target, command)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err = cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+// runNodeCommandStream 在节点上执行命令并逐行回调标准输出，用于跟踪长时间运行命令的进度
+func runNodeCommandStream(ctx context.Context, client libvirt.RemoteManager, command string, onLine func(string)) (err error) {
+	target, err := nodeCommandTarget(client)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := tracing.StartCommand(ctx, nodeCommandProgram(target), strings.Fields(command), target)
+	defer func() { tracing.End(span, err) }()
+
+	cmd := newNodeCommand(ctx, target, command)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			onLine(line)
+		}
+	}
+	// 超长行会使扫描提前结束，继续读完输出避免命令阻塞在写管道上
+	_, _ = io.Copy(io.Discard, stdout)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// nodeCommandTarget 返回远程节点的 SSH 目标，本地连接返回空
+func nodeCommandTarget(client libvirt.RemoteManager) (string, error) {
+	if !client.IsRemoteConnection() {
+		return "", nil
+	}
+	target, err := client.GetSSHTarget()
+	if err != nil {
+		return "", fmt.Errorf("get SSH target: %w", err)
+	}
+	return target, nil
+}
+
+// nodeCommandProgram 执行命令的程序，用于 span 名称
+func nodeCommandProgram(target string) string {
+	if target != "" {
+		return "ssh"
+	}
+	return "sh"
+}
+
+// newNodeCommand 构造在节点上执行 command 的进程：远程节点通过 SSH，本地通过 sh -c
+func newNodeCommand(ctx context.Context, target, command string) *exec.Cmd {
+	if target != "" {
+		return exec.CommandContext(ctx, "ssh", "-o", "StrictHostKeyChecking=no", "-o", "BatchMode=yes", target, command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// killRemoteNodeProcesses 终止远程节点上命令行包含 match 的进程
+// 取消 ctx 只会结束本地的 ssh，远端进程不会随之退出，需要按命令行中唯一的参数（如目标路径）找到并终止
+func killRemoteNodeProcesses(ctx context.Context, client libvirt.RemoteManager, match string) {
+	if !client.IsRemoteConnection() || match == "" {
+		return
+	}
+	// 首字符放进字符类，正则仍匹配 match，但不匹配执行 pkill 的 shell 自身的命令行
+	pattern := "[" + regexp.QuoteMeta(match[:1]) + "]" + regexp.QuoteMeta(match[1:])
+	if _, err := runNodeCommand(ctx, client, "pkill -f -- "+shellx.Quote(pattern)); err != nil {
+		// pkill 没有匹配到进程时同样返回非 0
+		zerolog.Ctx(ctx).Debug().Err(err).Str("match", match).Msg("Failed to kill remote processes")
+	}
+}
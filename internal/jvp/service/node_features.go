@@ -0,0 +1,194 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+)
+
+// nodeFeatureProbeTimeout 单个节点探测的超时时间，避免不可达节点拖慢启动与创建实例
+const nodeFeatureProbeTimeout = 30 * time.Second
+
+// nodeFeatureProbeScript 在节点上一次性探测工具与宿主机能力，每行输出 key=value
+// UEFI 以 QEMU 固件描述文件为准：libvirt 的 firmware=efi 自动选择依赖这些文件
+const nodeFeatureProbeScript = `for tool in virt-customize swtpm virt-v2v; do
+  if command -v $tool >/dev/null 2>&1; then echo "$tool=1"; else echo "$tool=0"; fi
+done
+if ls /usr/share/qemu/firmware/*.json /etc/qemu/firmware/*.json >/dev/null 2>&1; then echo uefi=1; else echo uefi=0; fi
+nested=$(cat /sys/module/kvm_intel/parameters/nested /sys/module/kvm_amd/parameters/nested 2>/dev/null | head -n 1)
+case "$nested" in Y|y|1) echo nested=1 ;; *) echo nested=0 ;; esac
+echo "hugepages=$(awk '/^HugePages_Total:/ {print $2}' /proc/meminfo)"`
+
+// nodeFeatureCache 各节点的探测结果，节点上安装工具后通过 DescribeNodeFeatures(refresh) 或启用节点重新探测
+type nodeFeatureCache struct {
+	mu       sync.Mutex
+	features map[string]*entity.NodeFeatures
+}
+
+// ProbeAllNodeFeatures 并行探测所有节点，服务启动时在后台调用；维护中的节点同样探测
+func (s *NodeService) ProbeAllNodeFeatures(ctx context.Context) {
+	configs, err := s.storage.List()
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to list nodes for feature probe")
+		return
+	}
+	var wg sync.WaitGroup
+	for _, config := range configs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.probeNodeFeatures(ctx, config.Name)
+		}()
+	}
+	wg.Wait()
+}
+
+// NodeFeatures 返回节点的探测结果，尚未探测过时立即探测
+func (s *NodeService) NodeFeatures(ctx context.Context, nodeName string) *entity.NodeFeatures {
+	nodeName = normalizeNodeName(nodeName)
+	s.featureCache.mu.Lock()
+	features, ok := s.featureCache.features[nodeName]
+	s.featureCache.mu.Unlock()
+	if ok {
+		return features
+	}
+	return s.probeNodeFeatures(ctx, nodeName)
+}
+
+// DescribeNodeFeatures 查询节点的工具与能力探测结果，refresh 为 true 时重新探测
+func (s *NodeService) DescribeNodeFeatures(ctx context.Context, nodeName string, refresh bool) (*entity.NodeFeatures, error) {
+	if !s.storage.Exists(normalizeNodeName(nodeName)) {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("node %s not found", nodeName),
+			http.StatusNotFound,
+		)
+	}
+	if refresh {
+		return s.probeNodeFeatures(ctx, nodeName), nil
+	}
+	return s.NodeFeatures(ctx, nodeName), nil
+}
+
+// probeNodeFeatures 探测节点并更新缓存，探测失败时记录原因
+func (s *NodeService) probeNodeFeatures(ctx context.Context, nodeName string) *entity.NodeFeatures {
+	nodeName = normalizeNodeName(nodeName)
+	logger := zerolog.Ctx(ctx).With().Str("node", nodeName).Logger()
+
+	features, err := s.runNodeFeatureProbe(ctx, nodeName)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to probe node features")
+		features = &entity.NodeFeatures{Error: err.Error()}
+	} else {
+		logger.Info().
+			Bool("cloud_init_iso", features.CloudInitISO).
+			Bool("virt_customize", features.VirtCustomize).
+			Bool("swtpm", features.SWTPM).
+			Bool("virt_v2v", features.VirtV2V).
+			Bool("uefi", features.UEFI).
+			Bool("nested_virt", features.NestedVirt).
+			Uint64("hugepages_total", features.HugePagesTotal).
+			Msg("Node features probed")
+	}
+	features.ProbedAt = time.Now()
+
+	s.featureCache.mu.Lock()
+	s.featureCache.features[nodeName] = features
+	s.featureCache.mu.Unlock()
+	return features
+}
+
+// runNodeFeatureProbe 执行探测脚本并解析输出
+func (s *NodeService) runNodeFeatureProbe(ctx context.Context, nodeName string) (*entity.NodeFeatures, error) {
+	ctx, cancel := context.WithTimeout(ctx, nodeFeatureProbeTimeout)
+	defer cancel()
+
+	client, err := s.GetNodeStorage(ctx, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("get node connection: %w", err)
+	}
+	output, err := runNodeCommand(ctx, client, nodeFeatureProbeScript)
+	if err != nil {
+		return nil, err
+	}
+
+	features := &entity.NodeFeatures{CloudInitISO: hasCloudInitISOTool(client.GetConnectionURI())}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "virt-customize":
+			features.VirtCustomize = value == "1"
+		case "swtpm":
+			features.SWTPM = value == "1"
+		case "virt-v2v":
+			features.VirtV2V = value == "1"
+		case "uefi":
+			features.UEFI = value == "1"
+		case "nested":
+			features.NestedVirt = value == "1"
+		case "hugepages":
+			features.HugePagesTotal, _ = strconv.ParseUint(value, 10, 64)
+		}
+	}
+	return features, nil
+}
+
+// hasCloudInitISOTool JVP 所在主机是否有生成 cloud-init ISO 的工具，与 buildCloudInitISO 的查找顺序一致
+// fake node 在内存中生成 cidata 卷，不依赖这些工具
+func hasCloudInitISOTool(uri string) bool {
+	if strings.HasPrefix(uri, libvirt.FakeScheme+"://") {
+		return true
+	}
+	for _, tool := range []string{"genisoimage", "mkisofs"} {
+		if _, err := exec.LookPath(tool); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRunInstanceFeatures 检查创建实例用到的功能在节点上是否具备，缺失时返回说明缺少依赖的 UnsupportedOperation
+// 探测失败时无法判断，不做拦截，由后续步骤报错
+func checkRunInstanceFeatures(features *entity.NodeFeatures, req *entity.RunInstanceRequest, needCloudInit bool) error {
+	if features == nil || features.Error != "" {
+		return nil
+	}
+
+	var missing []string
+	if req.Devices != nil && req.Devices.TPM && !features.SWTPM {
+		missing = append(missing, "devices.tpm requires swtpm")
+	}
+	if req.Architecture == libvirt.ArchAArch64 && !features.UEFI {
+		missing = append(missing, "aarch64 guests require UEFI firmware (AAVMF/edk2 with QEMU firmware descriptors)")
+	}
+	if req.NestedVirtualization && !features.NestedVirt {
+		missing = append(missing, "nested_virtualization requires the nested parameter of kvm_intel or kvm_amd")
+	}
+	if needCloudInit && !features.CloudInitISO {
+		missing = append(missing, "cloud-init requires genisoimage or mkisofs on the JVP host")
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return apierror.NewErrorWithStatus(
+		"UnsupportedOperation",
+		fmt.Sprintf("node %s is missing dependencies for the requested features: %s", normalizeNodeName(req.NodeName), strings.Join(missing, "; ")),
+		http.StatusBadRequest,
+	)
+}
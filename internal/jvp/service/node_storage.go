@@ -2,6 +2,7 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,12 +15,16 @@ import (
 
 // NodeConfig 节点配置（用于持久化存储）
 type NodeConfig struct {
-	Name      string           `json:"name"`
-	URI       string           `json:"uri"`
-	Type      entity.NodeType  `json:"type"`
-	State     entity.NodeState `json:"state"` // 节点状态（用于手动禁用/启用）
-	CreatedAt time.Time        `json:"created_at"`
-	UpdatedAt time.Time        `json:"updated_at"`
+	Name      string               `json:"name"`
+	URI       string               `json:"uri"`
+	Type      entity.NodeType      `json:"type"`
+	State     entity.NodeState     `json:"state"`              // 节点状态（用于手动禁用/启用）
+	Reserved  entity.NodeResources `json:"reserved"`           // 预留给宿主机系统的资源，不参与实例分配
+	Ownership entity.NodeOwnership `json:"ownership,omitzero"` // 文件属主修复策略，未配置时使用全局默认
+	TLS       entity.NodeTLS       `json:"tls,omitzero"`       // qemu+tls 连接使用的证书
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+	entity.NodeTopology
 }
 
 // NodeStorage 节点存储
@@ -27,7 +32,7 @@ type NodeStorage struct {
 	storageDir string
 	mu         sync.RWMutex
 	// 连接池：为每个 node 缓存 libvirt 连接
-	connections map[string]*libvirt.Client
+	connections map[string]libvirt.LibvirtClient
 }
 
 // NewNodeStorage 创建节点存储
@@ -39,7 +44,7 @@ func NewNodeStorage(dataDir string) (*NodeStorage, error) {
 
 	return &NodeStorage{
 		storageDir:  storageDir,
-		connections: make(map[string]*libvirt.Client),
+		connections: make(map[string]libvirt.LibvirtClient),
 	}, nil
 }
 
@@ -62,6 +67,7 @@ func (s *NodeStorage) Save(config *NodeConfig) error {
 	if err := os.WriteFile(configPath, data, 0o644); err != nil {
 		return fmt.Errorf("failed to write node config: %w", err)
 	}
+	libvirt.SetOwnershipPolicy(config.URI, ownershipPolicy(config.Ownership))
 
 	return nil
 }
@@ -177,11 +183,11 @@ func (s *NodeStorage) listUnlocked() ([]*NodeConfig, error) {
 }
 
 // getConnectionUnlocked assumes caller holds lock
-func (s *NodeStorage) getConnectionUnlocked(cfg *NodeConfig) (*libvirt.Client, error) {
+func (s *NodeStorage) getConnectionUnlocked(cfg *NodeConfig) (libvirt.LibvirtClient, error) {
 	if conn, ok := s.connections[cfg.Name]; ok {
 		return conn, nil
 	}
-	conn, err := libvirt.NewWithURI(cfg.URI)
+	conn, err := libvirt.ConnectNode(cfg.URI)
 	if err != nil {
 		return nil, err
 	}
@@ -201,6 +207,7 @@ func (s *NodeStorage) Delete(nodeName string) error {
 		delete(s.connections, nodeName)
 	}
 
+	config, _ := s.get(nodeName)
 	configPath := s.getConfigPath(nodeName)
 	if err := os.Remove(configPath); err != nil {
 		if os.IsNotExist(err) {
@@ -208,17 +215,64 @@ func (s *NodeStorage) Delete(nodeName string) error {
 		}
 		return fmt.Errorf("failed to delete node config: %w", err)
 	}
+	if config != nil {
+		libvirt.SetOwnershipPolicy(config.URI, libvirt.OwnershipPolicy{})
+		libvirt.SetTLSConfig(config.URI, libvirt.TLSConfig{})
+	}
+
+	return nil
+}
+
+// RegisterOwnershipPolicies 把各节点配置的属主修复策略注册到 libvirt 包，需在建立节点连接前调用
+func (s *NodeStorage) RegisterOwnershipPolicies() error {
+	configs, err := s.List()
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		libvirt.SetOwnershipPolicy(config.URI, ownershipPolicy(config.Ownership))
+	}
+	return nil
+}
 
+// RegisterTLSConfigs 把各节点配置的 TLS 证书注册到 libvirt 包，需在建立节点连接前调用
+func (s *NodeStorage) RegisterTLSConfigs() error {
+	configs, err := s.List()
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		libvirt.SetTLSConfig(config.URI, nodeTLSConfig(config.TLS))
+	}
 	return nil
 }
 
+// nodeTLSConfig 节点 TLS 证书配置转换为 libvirt 包的配置
+func nodeTLSConfig(config entity.NodeTLS) libvirt.TLSConfig {
+	return libvirt.TLSConfig{
+		CACertPath:         config.CACertPath,
+		ClientCertPath:     config.ClientCertPath,
+		ClientKeyPath:      config.ClientKeyPath,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+}
+
+// ownershipPolicy 节点属主修复策略转换为 libvirt 包的策略
+func ownershipPolicy(ownership entity.NodeOwnership) libvirt.OwnershipPolicy {
+	return libvirt.OwnershipPolicy{
+		Disabled: ownership.Disabled,
+		User:     ownership.User,
+		Group:    ownership.Group,
+	}
+}
+
 // GetConnection 获取或创建节点的 libvirt 连接
-func (s *NodeStorage) GetConnection(nodeName string) (*libvirt.Client, error) {
+// 建立连接时不持有锁，一个不可达节点不会阻塞其他节点获取连接；并发创建同一节点的连接时保留先缓存的一个
+func (s *NodeStorage) GetConnection(nodeName string) (libvirt.LibvirtClient, error) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	// 检查缓存
 	if conn, ok := s.connections[nodeName]; ok {
+		s.mu.Unlock()
 		return conn, nil
 	}
 
@@ -228,24 +282,63 @@ func (s *NodeStorage) GetConnection(nodeName string) (*libvirt.Client, error) {
 		// 如果找不到指定节点且仅有一个节点配置，使用唯一的节点作为 fallback
 		configs, listErr := s.listUnlocked()
 		if listErr != nil || len(configs) != 1 {
+			s.mu.Unlock()
 			return nil, err
 		}
 		config = configs[0]
 		nodeName = config.Name
+		if conn, ok := s.connections[nodeName]; ok {
+			s.mu.Unlock()
+			return conn, nil
+		}
 	}
+	s.mu.Unlock()
 
 	// 创建新连接
-	conn, err := libvirt.NewWithURI(config.URI)
+	conn, err := libvirt.ConnectNode(config.URI)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to node %s: %w", nodeName, err)
 	}
 
 	// 缓存连接
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cached, ok := s.connections[nodeName]; ok {
+		_ = conn.Close()
+		return cached, nil
+	}
 	s.connections[nodeName] = conn
 
 	return conn, nil
 }
 
+// ResetConnection 关闭并移除节点缓存的 libvirt 连接，下次获取时按最新配置重新建立
+func (s *NodeStorage) ResetConnection(nodeName string) {
+	s.mu.Lock()
+	conn, ok := s.connections[nodeName]
+	delete(s.connections, nodeName)
+	s.mu.Unlock()
+
+	if ok {
+		_ = conn.Close()
+	}
+}
+
+// Close 关闭所有缓存的 libvirt 连接，服务关停时调用
+func (s *NodeStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for nodeName, conn := range s.connections {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close connection of node %s: %w", nodeName, err))
+		}
+		delete(s.connections, nodeName)
+	}
+	return errors.Join(errs...)
+}
+
 // Exists 检查节点是否存在
 func (s *NodeStorage) Exists(nodeName string) bool {
 	configPath := s.getConfigPath(nodeName)
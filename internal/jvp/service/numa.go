@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+)
+
+// DescribeNodeNUMA 查询节点每个 NUMA cell 的 CPU、内存以及已绑定实例占用的资源
+func (s *NodeService) DescribeNodeNUMA(ctx context.Context, nodeName string) ([]entity.NUMACellResources, error) {
+	conn, err := s.storage.GetConnection(nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node connection: %w", err)
+	}
+	return describeNUMACells(conn)
+}
+
+// describeNUMACells 汇总宿主机 NUMA cell 与绑定到各 cell 的运行中实例
+// 未绑定 cell 的实例由内核自动调度，不计入任何 cell
+func describeNUMACells(client libvirt.LibvirtClient) ([]entity.NUMACellResources, error) {
+	cells, err := client.GetNUMACells()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get numa cells: %w", err)
+	}
+
+	result := make([]entity.NUMACellResources, 0, len(cells))
+	index := make(map[uint32]int, len(cells))
+	for _, cell := range cells {
+		index[cell.ID] = len(result)
+		result = append(result, entity.NUMACellResources{
+			ID:           cell.ID,
+			CPUs:         cell.CPUs,
+			MemoryMB:     cell.MemoryKB / 1024,
+			FreeMemoryMB: cell.FreeMemoryKB / 1024,
+			Instances:    []string{},
+		})
+	}
+
+	domains, err := client.GetVMSummaries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VMs: %w", err)
+	}
+	for _, domain := range domains {
+		info, err := client.GetDomainInfo(domain.UUID)
+		if err != nil || info.NUMATune == nil {
+			continue
+		}
+		switch info.State {
+		case "Running", "Blocked", "Paused":
+		default:
+			continue
+		}
+		i, ok := index[info.NUMATune.Node]
+		if !ok {
+			continue
+		}
+		result[i].BoundVCPUs += uint32(info.VCPUs)
+		result[i].BoundMemoryMB += info.Memory / 1024
+		result[i].Instances = append(result[i].Instances, info.Name)
+	}
+
+	for i := range result {
+		if cpus := uint32(len(result[i].CPUs)); cpus > result[i].BoundVCPUs {
+			result[i].AvailableVCPUs = cpus - result[i].BoundVCPUs
+		}
+	}
+	return result, nil
+}
+
+// selectNUMACell 为新实例选择 NUMA cell
+// 指定了 cell 时只校验该 cell 放得下；未指定时在放得下的 cell 中选择剩余 vCPU 最多的，相同时选择空闲内存最多的
+func selectNUMACell(client libvirt.LibvirtClient, tune *entity.NUMATune, vcpus uint16, memoryMB uint64) (*libvirt.NUMATune, error) {
+	result := &libvirt.NUMATune{Mode: tune.Mode}
+	if err := result.Validate(); err != nil {
+		return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+	}
+	// preferred 模式下内存不足时可以回退到其他 cell，只需要校验 CPU
+	checkMemory := tune.Mode != libvirt.NUMAModePreferred
+
+	cells, err := describeNUMACells(client)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get NUMA topology", err)
+	}
+	if len(cells) == 0 {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"Node does not report NUMA topology, numatune is not supported",
+			http.StatusBadRequest,
+		)
+	}
+
+	fits := func(cell entity.NUMACellResources) error {
+		if int(vcpus) > len(cell.CPUs) {
+			return apierror.WrapError(
+				apierror.ErrInsufficientInstanceCapacity,
+				fmt.Sprintf("NUMA cell %d has %d CPUs, %d vCPUs requested", cell.ID, len(cell.CPUs), vcpus),
+				nil,
+			)
+		}
+		if checkMemory && memoryMB > cell.FreeMemoryMB {
+			return apierror.WrapError(
+				apierror.ErrInsufficientInstanceCapacity,
+				fmt.Sprintf("NUMA cell %d has %d MB free memory, %d MB requested", cell.ID, cell.FreeMemoryMB, memoryMB),
+				nil,
+			)
+		}
+		return nil
+	}
+
+	if tune.Node != nil {
+		for _, cell := range cells {
+			if cell.ID != *tune.Node {
+				continue
+			}
+			if err := fits(cell); err != nil {
+				return nil, err
+			}
+			result.Node = cell.ID
+			return result, nil
+		}
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("NUMA cell %d not found on node", *tune.Node),
+			http.StatusBadRequest,
+		)
+	}
+
+	var best *entity.NUMACellResources
+	for i := range cells {
+		cell := &cells[i]
+		if fits(*cell) != nil {
+			continue
+		}
+		if best == nil ||
+			cell.AvailableVCPUs > best.AvailableVCPUs ||
+			(cell.AvailableVCPUs == best.AvailableVCPUs && cell.FreeMemoryMB > best.FreeMemoryMB) {
+			best = cell
+		}
+	}
+	if best == nil {
+		return nil, apierror.WrapError(
+			apierror.ErrInsufficientInstanceCapacity,
+			fmt.Sprintf("No NUMA cell can hold %d vCPUs and %d MB memory", vcpus, memoryMB),
+			nil,
+		)
+	}
+	result.Node = best.ID
+	return result, nil
+}
+
+// fromLibvirtNUMATune 把 libvirt 的 NUMA 绑定转换为 API 返回值
+func fromLibvirtNUMATune(t *libvirt.NUMATune) *entity.NUMATune {
+	if t == nil {
+		return nil
+	}
+	node := t.Node
+	return &entity.NUMATune{Node: &node, Mode: t.Mode}
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 受节点并发上限约束的重 IO 操作类型
+const (
+	OperationRunInstance      = "RunInstance"      // 创建实例（创建磁盘、注入 cloud-init、定义并启动 domain）
+	OperationDownloadTemplate = "DownloadTemplate" // 下载模板镜像
+	OperationV2VImport        = "V2VImport"        // virt-v2v 转换 VMware 虚拟机
+)
+
+// ErrShuttingDown 服务正在关停，不再接受新的重 IO 操作
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// OperationLimiter 每节点重 IO 操作的并发上限，超出上限的操作按提交顺序排队
+// 上限可在运行时调整，调高后立即放行排队中的操作；调低不会中断正在执行的操作
+type OperationLimiter struct {
+	mu       sync.Mutex
+	limit    uint64                     // 每节点同时执行的操作数上限，0 表示不限制
+	nodes    map[string]*nodeOperations // key: nodeName
+	draining bool                       // 关停中：拒绝新操作，不再放行排队中的操作
+}
+
+// nodeOperations 单个节点上执行中与排队中的操作
+type nodeOperations struct {
+	running []*pendingOperation
+	queued  []*pendingOperation
+}
+
+// pendingOperation 已提交的操作，ready 在获得执行名额时关闭，canceled 在关停取消排队时关闭
+type pendingOperation struct {
+	op       entity.NodeOperation
+	ready    chan struct{}
+	canceled chan struct{}
+}
+
+// NewOperationLimiter 创建节点操作并发限制器
+func NewOperationLimiter(limit uint64) *OperationLimiter {
+	return &OperationLimiter{
+		limit: limit,
+		nodes: make(map[string]*nodeOperations),
+	}
+}
+
+// Limit 返回当前每节点并发上限，0 表示不限制
+func (l *OperationLimiter) Limit() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// SetLimit 调整每节点并发上限，0 表示不限制
+func (l *OperationLimiter) SetLimit(limit uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	for nodeName, node := range l.nodes {
+		l.dispatch(nodeName, node)
+	}
+}
+
+// Acquire 在节点上申请执行名额，没有空闲名额时排队等待
+// 返回的 release 必须在操作结束后调用；ctx 取消时退出队列并返回 ctx 的错误，服务关停时返回 ErrShuttingDown
+func (l *OperationLimiter) Acquire(ctx context.Context, nodeName, id, opType, resource string) (_ func(), err error) {
+	// 排队等待的耗时单独记录为 span，便于区分“慢在排队”还是“慢在执行”
+	_, span := tracing.Start(ctx, "OperationLimiter.Acquire",
+		attribute.String("jvp.node_name", nodeName),
+		attribute.String("jvp.operation_type", opType),
+		attribute.String("jvp.resource", resource),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	pending := &pendingOperation{
+		op: entity.NodeOperation{
+			ID:       id,
+			NodeName: nodeName,
+			Type:     opType,
+			Resource: resource,
+			Status:   entity.NodeOperationQueued,
+			QueuedAt: time.Now(),
+		},
+		ready:    make(chan struct{}),
+		canceled: make(chan struct{}),
+	}
+
+	l.mu.Lock()
+	if l.draining {
+		l.mu.Unlock()
+		return nil, ErrShuttingDown
+	}
+	node, ok := l.nodes[nodeName]
+	if !ok {
+		node = &nodeOperations{}
+		l.nodes[nodeName] = node
+	}
+	node.queued = append(node.queued, pending)
+	l.dispatch(nodeName, node)
+	l.mu.Unlock()
+
+	// 操作在 node 的队列或执行列表中时 node 不会从 l.nodes 删除，直接使用 node 而不是重新查找
+	release := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		node.running = slices.DeleteFunc(node.running, func(p *pendingOperation) bool { return p == pending })
+		l.dispatch(nodeName, node)
+	}
+
+	select {
+	case <-pending.ready:
+		return sync.OnceFunc(release), nil
+	case <-pending.canceled:
+		return nil, ErrShuttingDown
+	case <-ctx.Done():
+		// 取消的同时可能已获得名额，两处都移除后归还名额
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		node.queued = slices.DeleteFunc(node.queued, func(p *pendingOperation) bool { return p == pending })
+		node.running = slices.DeleteFunc(node.running, func(p *pendingOperation) bool { return p == pending })
+		l.dispatch(nodeName, node)
+		// 关停取消与 ctx 取消同时发生时 select 随机选择，统一返回 ErrShuttingDown
+		select {
+		case <-pending.canceled:
+			return nil, ErrShuttingDown
+		default:
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// dispatch 按排队顺序放行操作直到达到并发上限，节点上没有操作时删除记录，调用方需持有锁
+func (l *OperationLimiter) dispatch(nodeName string, node *nodeOperations) {
+	for !l.draining && len(node.queued) > 0 && (l.limit == 0 || uint64(len(node.running)) < l.limit) {
+		next := node.queued[0]
+		node.queued = node.queued[1:]
+		next.op.Status = entity.NodeOperationRunning
+		next.op.StartedAt = time.Now()
+		node.running = append(node.running, next)
+		close(next.ready)
+	}
+	if len(node.running) == 0 && len(node.queued) == 0 && l.nodes[nodeName] == node {
+		delete(l.nodes, nodeName)
+	}
+}
+
+// List 列出节点上执行中与排队中的操作，执行中的在前，排队中的按队列顺序排列
+func (l *OperationLimiter) List(nodeName string) []entity.NodeOperation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	result := []entity.NodeOperation{}
+	node, ok := l.nodes[nodeName]
+	if !ok {
+		return result
+	}
+	for _, p := range node.running {
+		result = append(result, p.op)
+	}
+	for i, p := range node.queued {
+		op := p.op
+		op.QueuePosition = i + 1
+		result = append(result, op)
+	}
+	return result
+}
+
+// QueuePosition 返回操作在节点队列中的位置（从 1 开始），未在排队时返回 0
+func (l *OperationLimiter) QueuePosition(nodeName, id string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	node, ok := l.nodes[nodeName]
+	if !ok {
+		return 0
+	}
+	for i, p := range node.queued {
+		if p.op.ID == id {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// drainPollInterval Drain 检查执行中操作是否结束的间隔
+const drainPollInterval = 100 * time.Millisecond
+
+// CancelQueued 服务关停时调用：拒绝新的操作并取消所有排队中的操作（Acquire 返回 ErrShuttingDown）
+// 需要在等待 API 请求结束之前调用，否则排队中的请求会一直等到关停超时
+func (l *OperationLimiter) CancelQueued() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.draining = true
+	for nodeName, node := range l.nodes {
+		for _, p := range node.queued {
+			close(p.canceled)
+		}
+		node.queued = nil
+		l.dispatch(nodeName, node)
+	}
+}
+
+// Drain 取消排队中的操作（同 CancelQueued）并等待执行中的操作结束；ctx 到期时返回仍在执行的操作
+func (l *OperationLimiter) Drain(ctx context.Context) []entity.NodeOperation {
+	l.CancelQueued()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		l.mu.Lock()
+		var running []entity.NodeOperation
+		for _, node := range l.nodes {
+			for _, p := range node.running {
+				running = append(running, p.op)
+			}
+		}
+		l.mu.Unlock()
+		if len(running) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return running
+		case <-ticker.C:
+		}
+	}
+}
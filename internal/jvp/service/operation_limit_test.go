@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// acquireAsync 在后台申请名额，结果写入返回的 channel
+func acquireAsync(ctx context.Context, l *OperationLimiter, nodeName, id string) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		release, err := l.Acquire(ctx, nodeName, id, OperationRunInstance, id)
+		if err == nil {
+			release()
+		}
+		done <- err
+	}()
+	return done
+}
+
+// waitQueued 等待操作进入节点队列
+func waitQueued(t *testing.T, l *OperationLimiter, nodeName, id string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return l.QueuePosition(nodeName, id) > 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestOperationLimiterQueuesInOrder(t *testing.T) {
+	l := NewOperationLimiter(1)
+	ctx := context.Background()
+
+	release, err := l.Acquire(ctx, "node1", "op-1", OperationRunInstance, "i-1")
+	require.NoError(t, err)
+
+	order := make(chan string, 2)
+	for _, id := range []string{"op-2", "op-3"} {
+		go func() {
+			release, err := l.Acquire(ctx, "node1", id, OperationRunInstance, id)
+			if err == nil {
+				order <- id
+				release()
+			}
+		}()
+		waitQueued(t, l, "node1", id)
+	}
+	assert.Equal(t, 2, l.QueuePosition("node1", "op-3"))
+
+	// 其他节点不受 node1 的上限影响
+	other, err := l.Acquire(ctx, "node2", "op-4", OperationRunInstance, "i-4")
+	require.NoError(t, err)
+	other()
+
+	release()
+	// release 只生效一次，重复调用不会多归还名额
+	release()
+	assert.Equal(t, "op-2", <-order)
+	assert.Equal(t, "op-3", <-order)
+	assert.Eventually(t, func() bool { return len(l.List("node1")) == 0 }, time.Second, time.Millisecond)
+}
+
+func TestOperationLimiterSetLimitDispatchesQueued(t *testing.T) {
+	l := NewOperationLimiter(1)
+	release, err := l.Acquire(context.Background(), "node1", "op-1", OperationRunInstance, "i-1")
+	require.NoError(t, err)
+	defer release()
+
+	done := acquireAsync(context.Background(), l, "node1", "op-2")
+	waitQueued(t, l, "node1", "op-2")
+
+	l.SetLimit(0)
+	require.NoError(t, <-done)
+}
+
+func TestOperationLimiterContextCanceled(t *testing.T) {
+	l := NewOperationLimiter(1)
+	release, err := l.Acquire(context.Background(), "node1", "op-1", OperationRunInstance, "i-1")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := acquireAsync(ctx, l, "node1", "op-2")
+	waitQueued(t, l, "node1", "op-2")
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Zero(t, l.QueuePosition("node1", "op-2"))
+
+	release()
+	assert.Empty(t, l.List("node1"))
+}
+
+func TestOperationLimiterDrain(t *testing.T) {
+	l := NewOperationLimiter(1)
+	release, err := l.Acquire(context.Background(), "node1", "op-1", OperationRunInstance, "i-1")
+	require.NoError(t, err)
+
+	done := acquireAsync(context.Background(), l, "node1", "op-2")
+	waitQueued(t, l, "node1", "op-2")
+
+	// 执行中的操作未结束时 Drain 到期返回仍在执行的操作，排队中的操作被取消
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	running := l.Drain(ctx)
+	require.Len(t, running, 1)
+	assert.Equal(t, "op-1", running[0].ID)
+	assert.ErrorIs(t, <-done, ErrShuttingDown)
+
+	_, err = l.Acquire(context.Background(), "node1", "op-3", OperationRunInstance, "i-3")
+	assert.ErrorIs(t, err, ErrShuttingDown)
+
+	release()
+	assert.Empty(t, l.Drain(context.Background()))
+}
+
+// TestOperationLimiterDrainRace 关停取消与 ctx 取消并发发生时不能 panic，排队中的操作只返回 ErrShuttingDown 或 ctx 的错误
+func TestOperationLimiterDrainRace(t *testing.T) {
+	for round := 0; round < 50; round++ {
+		l := NewOperationLimiter(1)
+		release, err := l.Acquire(context.Background(), "node1", "op-0", OperationRunInstance, "i-0")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 1; i <= 10; i++ {
+			id := fmt.Sprintf("op-%d", i)
+			done := acquireAsync(ctx, l, "node1", id)
+			waitQueued(t, l, "node1", id)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- <-done
+			}()
+		}
+
+		var start sync.WaitGroup
+		start.Add(1)
+		go func() {
+			start.Wait()
+			cancel()
+		}()
+		drained := make(chan struct{})
+		go func() {
+			start.Wait()
+			l.CancelQueued()
+			close(drained)
+		}()
+		start.Done()
+		<-drained
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if !errors.Is(err, ErrShuttingDown) && !errors.Is(err, context.Canceled) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		// 排队中的操作全部退出后，执行中操作的 release 仍能正常归还名额
+		release()
+		assert.Empty(t, l.Drain(context.Background()))
+		assert.Empty(t, l.List("node1"))
+	}
+}
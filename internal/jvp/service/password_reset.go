@@ -18,12 +18,18 @@ type PasswordResetStrategy interface {
 	Name() string
 }
 
+// guestAgentClient 通过 guest agent 操作 domain 所需的 libvirt 能力
+type guestAgentClient interface {
+	libvirt.DomainManager
+	libvirt.GuestAgentManager
+}
+
 // QemuGuestAgentStrategy qemu-guest-agent 密码重置策略
 type QemuGuestAgentStrategy struct {
-	libvirtClient libvirt.LibvirtClient
+	libvirtClient guestAgentClient
 }
 
-func NewQemuGuestAgentStrategy(libvirtClient libvirt.LibvirtClient) *QemuGuestAgentStrategy {
+func NewQemuGuestAgentStrategy(libvirtClient guestAgentClient) *QemuGuestAgentStrategy {
 	return &QemuGuestAgentStrategy{
 		libvirtClient: libvirtClient,
 	}
@@ -113,11 +119,11 @@ func (s *QemuGuestAgentStrategy) ResetPassword(ctx context.Context, instanceID s
 
 // CloudInitStrategy cloud-init 密码重置策略
 type CloudInitStrategy struct {
-	libvirtClient libvirt.LibvirtClient
+	libvirtClient libvirt.DomainManager
 	tempDir       string
 }
 
-func NewCloudInitStrategy(libvirtClient libvirt.LibvirtClient, tempDir string) *CloudInitStrategy {
+func NewCloudInitStrategy(libvirtClient libvirt.DomainManager, tempDir string) *CloudInitStrategy {
 	return &CloudInitStrategy{
 		libvirtClient: libvirtClient,
 		tempDir:       tempDir,
@@ -184,10 +190,10 @@ func (s *CloudInitStrategy) ResetPassword(ctx context.Context, instanceID string
 // VirtCustomizeStrategy virt-customize 密码重置策略
 type VirtCustomizeStrategy struct {
 	virtCustomizeClient virtcustomize.VirtCustomizeClient
-	libvirtClient       libvirt.LibvirtClient
+	libvirtClient       libvirt.DomainManager
 }
 
-func NewVirtCustomizeStrategy(virtCustomizeClient virtcustomize.VirtCustomizeClient, libvirtClient libvirt.LibvirtClient) *VirtCustomizeStrategy {
+func NewVirtCustomizeStrategy(virtCustomizeClient virtcustomize.VirtCustomizeClient, libvirtClient libvirt.DomainManager) *VirtCustomizeStrategy {
 	return &VirtCustomizeStrategy{
 		virtCustomizeClient: virtCustomizeClient,
 		libvirtClient:       libvirtClient,
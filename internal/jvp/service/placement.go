@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/rs/zerolog"
+)
+
+// placementCandidate 可以放置实例的节点
+type placementCandidate struct {
+	nodeName  string
+	rack      string
+	instances int // 节点上的实例数（不含回收站中的实例）
+}
+
+// resolveInstanceNode 确定创建实例的节点
+// 只指定 zone 时在可用区内选择节点；同时指定 node_name 与 zone 时校验节点属于该可用区
+func (s *InstanceService) resolveInstanceNode(ctx context.Context, nodeName, zone, poolName string) (string, error) {
+	if nodeName == "" && zone == "" {
+		return "", apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"either node_name or zone must be specified",
+			http.StatusBadRequest,
+		)
+	}
+	if nodeName == "" {
+		return s.placeInstance(ctx, zone, poolName)
+	}
+	if zone != "" {
+		if topology := s.nodeProvider.NodeTopology(ctx, nodeName); topology.Zone != zone {
+			return "", apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("node %s is not in zone %s", nodeName, zone),
+				http.StatusBadRequest,
+			)
+		}
+	}
+	return nodeName, nil
+}
+
+// placeInstance 在可用区内选择创建实例的节点：只考虑在线且有目标存储池的节点，
+// 先选实例数最少的机架，再选机架内实例数最少的节点，使同一可用区的实例分散在不同机架与节点上
+func (s *InstanceService) placeInstance(ctx context.Context, zone, poolName string) (string, error) {
+	logger := zerolog.Ctx(ctx)
+
+	nodes, err := s.nodeProvider.ListNodes(ctx)
+	if err != nil {
+		return "", apierror.WrapError(apierror.ErrInternalError, "Failed to list nodes", err)
+	}
+
+	var candidates []placementCandidate
+	rackInstances := map[string]int{}
+	for _, node := range nodes {
+		if node.Zone != zone || node.State != entity.NodeStateOnline {
+			continue
+		}
+		client, err := s.nodeProvider.GetNodeStorage(ctx, node.Name)
+		if err != nil {
+			continue
+		}
+		if _, err := client.GetStoragePool(poolName); err != nil {
+			continue
+		}
+		domains, err := client.GetVMSummaries()
+		if err != nil {
+			logger.Warn().Err(err).Str("node_name", node.Name).Msg("Failed to count instances, skipping node in placement")
+			continue
+		}
+		count := 0
+		for _, domain := range domains {
+			if !isRecycledDomain(domain.Name) {
+				count++
+			}
+		}
+		candidates = append(candidates, placementCandidate{nodeName: node.Name, rack: node.Rack, instances: count})
+		rackInstances[node.Rack] += count
+	}
+	if len(candidates) == 0 {
+		return "", apierror.WrapError(
+			apierror.ErrInsufficientInstanceCapacity,
+			fmt.Sprintf("No online node with storage pool %s in zone %s", poolName, zone),
+			nil,
+		)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if rackInstances[a.rack] != rackInstances[b.rack] {
+			return rackInstances[a.rack] < rackInstances[b.rack]
+		}
+		if a.instances != b.instances {
+			return a.instances < b.instances
+		}
+		return a.nodeName < b.nodeName
+	})
+	selected := candidates[0]
+	logger.Info().
+		Str("zone", zone).
+		Str("rack", selected.rack).
+		Str("node_name", selected.nodeName).
+		Int("candidates", len(candidates)).
+		Msg("Selected node for instance")
+	return selected.nodeName, nil
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/rs/zerolog"
+)
+
+// defaultQuotaWarningPercent 配额使用率的默认预警阈值（%）
+const defaultQuotaWarningPercent = 80
+
+// QuotaStore 租户配额上限与实例归属存储
+// 配额上限：<dataDir>/quotas/limits.json；实例归属每个节点一个文件：<dataDir>/quotas/owners/<node>.json
+type QuotaStore struct {
+	storageDir string
+	mu         sync.Mutex
+}
+
+// NewQuotaStore 创建配额存储
+func NewQuotaStore(dataDir string) (*QuotaStore, error) {
+	storageDir := filepath.Join(dataDir, "quotas")
+	if err := os.MkdirAll(filepath.Join(storageDir, "owners"), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create quotas directory: %w", err)
+	}
+	return &QuotaStore{storageDir: storageDir}, nil
+}
+
+// getLimitsPath 获取配额上限文件路径
+func (q *QuotaStore) getLimitsPath() string {
+	return filepath.Join(q.storageDir, "limits.json")
+}
+
+// getOwnersPath 获取节点的实例归属文件路径
+func (q *QuotaStore) getOwnersPath(nodeName string) string {
+	return filepath.Join(q.storageDir, "owners", nodeName+".json")
+}
+
+// readJSONUnlocked 读取 JSON 文件，不存在时保持 v 不变
+func (q *QuotaStore) readJSONUnlocked(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// writeJSONUnlocked 写入 JSON 文件
+func (q *QuotaStore) writeJSONUnlocked(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", filepath.Base(path), err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// Limits 返回所有租户的配额上限
+func (q *QuotaStore) Limits() (map[string]entity.QuotaLimits, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	limits := map[string]entity.QuotaLimits{}
+	if err := q.readJSONUnlocked(q.getLimitsPath(), &limits); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// SetLimits 设置租户的配额上限，全部为 0 时删除该租户的记录
+func (q *QuotaStore) SetLimits(tenant string, limits entity.QuotaLimits) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	all := map[string]entity.QuotaLimits{}
+	if err := q.readJSONUnlocked(q.getLimitsPath(), &all); err != nil {
+		return err
+	}
+	if limits == (entity.QuotaLimits{}) {
+		delete(all, tenant)
+	} else {
+		all[tenant] = limits
+	}
+	return q.writeJSONUnlocked(q.getLimitsPath(), all)
+}
+
+// InstanceOwners 返回节点上实例所属的租户，key 为实例 ID
+func (q *QuotaStore) InstanceOwners(nodeName string) (map[string]string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	owners := map[string]string{}
+	if err := q.readJSONUnlocked(q.getOwnersPath(nodeName), &owners); err != nil {
+		return nil, err
+	}
+	return owners, nil
+}
+
+// SetInstanceOwner 记录实例所属的租户，tenant 为空时删除记录
+func (q *QuotaStore) SetInstanceOwner(nodeName, instanceID, tenant string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	owners := map[string]string{}
+	if err := q.readJSONUnlocked(q.getOwnersPath(nodeName), &owners); err != nil {
+		return err
+	}
+	if tenant == "" {
+		if _, ok := owners[instanceID]; !ok {
+			return nil
+		}
+		delete(owners, instanceID)
+	} else {
+		owners[instanceID] = tenant
+	}
+	return q.writeJSONUnlocked(q.getOwnersPath(nodeName), owners)
+}
+
+// QuotaService 租户配额服务：统计各租户已用的 vCPU、内存、磁盘与实例数，并与配额上限对比
+type QuotaService struct {
+	store           *QuotaStore
+	nodeService     *NodeService
+	instanceService *InstanceService
+}
+
+// NewQuotaService 创建配额服务
+func NewQuotaService(store *QuotaStore, nodeService *NodeService, instanceService *InstanceService) *QuotaService {
+	return &QuotaService{
+		store:           store,
+		nodeService:     nodeService,
+		instanceService: instanceService,
+	}
+}
+
+// DescribeQuotaUsage 统计所有节点上各租户的资源使用量
+// 租户请求只返回自身；管理员返回所有有实例或配置了上限的租户，可按 tenant 过滤。回收站中的实例不计入
+func (s *QuotaService) DescribeQuotaUsage(ctx context.Context, req *entity.DescribeQuotaUsageRequest) (*entity.DescribeQuotaUsageResponse, error) {
+	logger := zerolog.Ctx(ctx)
+
+	filter, filtered := req.Tenant, req.Tenant != ""
+	if tenant := tenantFromContext(ctx); tenant != "" {
+		if filtered && filter != tenant {
+			return nil, apierror.NewErrorWithStatus(
+				"OperationNotPermitted",
+				fmt.Sprintf("quota usage of tenant %s is not visible to tenant %s", filter, tenant),
+				http.StatusForbidden,
+			)
+		}
+		filter, filtered = tenant, true
+	}
+	threshold := req.WarningThresholdPercent
+	if threshold <= 0 {
+		threshold = defaultQuotaWarningPercent
+	}
+
+	limits, err := s.store.Limits()
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to load quota limits", err)
+	}
+
+	nodes, err := s.nodeService.ListNodes(ctx)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list nodes", err)
+	}
+
+	type usage struct {
+		vcpus, memoryMB, diskBytes, instances uint64
+	}
+	used := map[string]*usage{}
+	var skipped []string
+	for _, node := range nodes {
+		instances, err := s.instanceService.DescribeInstances(ctx, &entity.DescribeInstancesRequest{
+			NodeName: node.Name,
+			Include:  []string{entity.InstanceIncludeDisks}, // 磁盘用量按实例磁盘容量统计
+		})
+		if err != nil {
+			logger.Warn().
+				Err(err).
+				Str("node_name", node.Name).
+				Msg("Failed to describe instances, skipping node in quota usage")
+			skipped = append(skipped, node.Name)
+			continue
+		}
+		for _, instance := range instances {
+			if filtered && instance.Owner != filter {
+				continue
+			}
+			u, ok := used[instance.Owner]
+			if !ok {
+				u = &usage{}
+				used[instance.Owner] = u
+			}
+			u.vcpus += uint64(instance.VCPUs)
+			u.memoryMB += instance.MemoryMB
+			u.instances++
+			for _, disk := range instance.Disks {
+				u.diskBytes += disk.CapacityB
+			}
+		}
+	}
+
+	tenants := map[string]bool{}
+	for tenant := range used {
+		tenants[tenant] = true
+	}
+	for tenant := range limits {
+		if !filtered || tenant == filter {
+			tenants[tenant] = true
+		}
+	}
+	if filtered {
+		tenants[filter] = true
+	}
+
+	result := make([]entity.TenantQuotaUsage, 0, len(tenants))
+	for tenant := range tenants {
+		u := used[tenant]
+		if u == nil {
+			u = &usage{}
+		}
+		limit := limits[tenant]
+		item := entity.TenantQuotaUsage{
+			Tenant:    tenant,
+			VCPUs:     quotaResourceUsage(u.vcpus, limit.VCPUs, threshold),
+			MemoryMB:  quotaResourceUsage(u.memoryMB, limit.MemoryMB, threshold),
+			DiskGB:    quotaResourceUsage(uint64(math.Ceil(float64(u.diskBytes)/(1024*1024*1024))), limit.DiskGB, threshold),
+			Instances: quotaResourceUsage(u.instances, limit.Instances, threshold),
+		}
+		item.Warning = item.VCPUs.Warning || item.MemoryMB.Warning || item.DiskGB.Warning || item.Instances.Warning
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Tenant < result[j].Tenant
+	})
+
+	return &entity.DescribeQuotaUsageResponse{Tenants: result, SkippedNodes: skipped}, nil
+}
+
+// quotaResourceUsage 计算单项资源的使用率，上限为 0（不限制）时不计算使用率也不预警
+func quotaResourceUsage(used, limit uint64, threshold float64) entity.QuotaResourceUsage {
+	result := entity.QuotaResourceUsage{Used: used, Limit: limit}
+	if limit == 0 {
+		return result
+	}
+	result.UsagePercent = math.Round(float64(used)*10000/float64(limit)) / 100
+	result.Warning = result.UsagePercent >= threshold
+	return result
+}
+
+// ModifyTenantQuota 修改租户的配额上限，只有管理员可以修改
+func (s *QuotaService) ModifyTenantQuota(ctx context.Context, req *entity.ModifyTenantQuotaRequest) (*entity.ModifyTenantQuotaResponse, error) {
+	if tenant := tenantFromContext(ctx); tenant != "" {
+		return nil, apierror.NewErrorWithStatus(
+			"OperationNotPermitted",
+			"only administrators can modify tenant quotas",
+			http.StatusForbidden,
+		)
+	}
+	if req.Tenant == "" {
+		return nil, invalidParameterError("tenant")
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ModifyTenantQuota")
+	}
+
+	if err := s.store.SetLimits(req.Tenant, req.Limits); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to save tenant quota", err)
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Str("tenant", req.Tenant).
+		Uint64("vcpus", req.Limits.VCPUs).
+		Uint64("memory_mb", req.Limits.MemoryMB).
+		Uint64("disk_gb", req.Limits.DiskGB).
+		Uint64("instances", req.Limits.Instances).
+		Msg("Tenant quota modified")
+
+	return &entity.ModifyTenantQuotaResponse{Tenant: req.Tenant, Limits: req.Limits}, nil
+}
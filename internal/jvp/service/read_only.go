@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/rs/zerolog"
+)
+
+// ReadOnlyMode 全局只读（维护）模式，用于控制面升级或事故处置时防止状态进一步变化
+// 开启后写接口由 API 层拦截，定时任务的触发记为 skipped；已经在执行的操作不受影响
+// 状态只保存在内存中，进程重启后回到配置中的初始值
+type ReadOnlyMode struct {
+	mu    sync.RWMutex
+	state entity.ReadOnlyMode
+}
+
+// NewReadOnlyMode 创建只读模式开关，enabled 为启动时的初始状态
+func NewReadOnlyMode(enabled bool, reason string) *ReadOnlyMode {
+	m := &ReadOnlyMode{}
+	if enabled {
+		m.state = entity.ReadOnlyMode{Enabled: true, Reason: reason, Since: time.Now()}
+	}
+	return m
+}
+
+// State 返回当前状态
+func (m *ReadOnlyMode) State() entity.ReadOnlyMode {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// Check 处于只读模式时返回带原因的 ServiceUnavailable
+func (m *ReadOnlyMode) Check() error {
+	state := m.State()
+	if !state.Enabled {
+		return nil
+	}
+	message := "The server is in read-only mode, write operations are rejected"
+	if state.Reason != "" {
+		message += ": " + state.Reason
+	}
+	return apierror.NewErrorWithStatus(apierror.ErrServiceUnavailable.Code, message, http.StatusServiceUnavailable)
+}
+
+// DescribeReadOnlyMode 查询只读模式状态
+func (m *ReadOnlyMode) DescribeReadOnlyMode(ctx context.Context, req *entity.DescribeReadOnlyModeRequest) (*entity.DescribeReadOnlyModeResponse, error) {
+	return &entity.DescribeReadOnlyModeResponse{ReadOnlyMode: m.State()}, nil
+}
+
+// ModifyReadOnlyMode 开启或关闭只读模式，只有管理员可以修改
+// 重复开启只更新原因，保留最初的开启时间
+func (m *ReadOnlyMode) ModifyReadOnlyMode(ctx context.Context, req *entity.ModifyReadOnlyModeRequest) (*entity.ModifyReadOnlyModeResponse, error) {
+	if tenant := tenantFromContext(ctx); tenant != "" {
+		return nil, apierror.NewErrorWithStatus(
+			"OperationNotPermitted",
+			"only administrators can modify read-only mode",
+			http.StatusForbidden,
+		)
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ModifyReadOnlyMode")
+	}
+
+	m.mu.Lock()
+	switch {
+	case !req.Enabled:
+		m.state = entity.ReadOnlyMode{}
+	case m.state.Enabled:
+		m.state.Reason = req.Reason
+	default:
+		m.state = entity.ReadOnlyMode{Enabled: true, Reason: req.Reason, Since: time.Now()}
+	}
+	state := m.state
+	m.mu.Unlock()
+
+	zerolog.Ctx(ctx).Warn().
+		Bool("enabled", state.Enabled).
+		Str("reason", state.Reason).
+		Str("operator", operatorFromContext(ctx)).
+		Msg("Read-only mode modified")
+
+	return &entity.ModifyReadOnlyModeResponse{ReadOnlyMode: state}, nil
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+)
+
+// RecycleBin 回收站记录存储
+// 每条记录一个 JSON 文件：<dataDir>/recycle-bin/<node>/<type>.<id>.json（卷为 volume.<pool>.<id>.json）
+type RecycleBin struct {
+	storageDir string
+	retention  time.Duration
+	mu         sync.Mutex
+}
+
+// NewRecycleBin 创建回收站，retentionDays 为 0 时不启用（删除立即生效）
+func NewRecycleBin(dataDir string, retentionDays uint64) (*RecycleBin, error) {
+	storageDir := filepath.Join(dataDir, "recycle-bin")
+	if err := os.MkdirAll(storageDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recycle bin directory: %w", err)
+	}
+	return &RecycleBin{
+		storageDir: storageDir,
+		retention:  time.Duration(retentionDays) * 24 * time.Hour,
+	}, nil
+}
+
+// Enabled 是否启用回收站
+func (b *RecycleBin) Enabled() bool {
+	return b != nil && b.retention > 0
+}
+
+// newItem 创建一条从当前时间开始计算保留期的记录
+func (b *RecycleBin) newItem(resourceType, resourceID, nodeName string) *entity.RecycleBinItem {
+	now := time.Now()
+	return &entity.RecycleBinItem{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		NodeName:     nodeName,
+		DeletedAt:    now,
+		ExpiresAt:    now.Add(b.retention),
+	}
+}
+
+// getItemPath 获取记录文件路径
+func (b *RecycleBin) getItemPath(nodeName, resourceType, poolName, resourceID string) string {
+	name := resourceType + "." + resourceID + ".json"
+	if resourceType == entity.RecycleBinResourceVolume {
+		name = resourceType + "." + poolName + "." + resourceID + ".json"
+	}
+	return filepath.Join(b.storageDir, nodeName, name)
+}
+
+// Save 保存记录
+func (b *RecycleBin) Save(item *entity.RecycleBinItem) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	itemPath := b.getItemPath(item.NodeName, item.ResourceType, item.PoolName, item.ResourceID)
+	if err := os.MkdirAll(filepath.Dir(itemPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create recycle bin directory: %w", err)
+	}
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recycle bin item: %w", err)
+	}
+	if err := os.WriteFile(itemPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write recycle bin item: %w", err)
+	}
+	return nil
+}
+
+// Get 获取记录，不存在时返回 nil
+func (b *RecycleBin) Get(nodeName, resourceType, poolName, resourceID string) (*entity.RecycleBinItem, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data, err := os.ReadFile(b.getItemPath(nodeName, resourceType, poolName, resourceID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read recycle bin item: %w", err)
+	}
+	var item entity.RecycleBinItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal recycle bin item: %w", err)
+	}
+	return &item, nil
+}
+
+// Delete 删除记录
+func (b *RecycleBin) Delete(item *entity.RecycleBinItem) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	err := os.Remove(b.getItemPath(item.NodeName, item.ResourceType, item.PoolName, item.ResourceID))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete recycle bin item: %w", err)
+	}
+	return nil
+}
+
+// List 列出节点上的记录，nodeName 为空时列出所有节点
+func (b *RecycleBin) List(nodeName string) ([]entity.RecycleBinItem, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pattern := filepath.Join(b.storageDir, "*", "*.json")
+	if nodeName != "" {
+		pattern = filepath.Join(b.storageDir, nodeName, "*.json")
+	}
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recycle bin items: %w", err)
+	}
+
+	items := make([]entity.RecycleBinItem, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var item entity.RecycleBinItem
+		if err := json.Unmarshal(data, &item); err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// recycledDomainName 实例进入回收站后的 domain 名称
+func recycledDomainName(instanceID string) string {
+	return RecycleDirName + instanceID
+}
+
+// isRecycledDomain 判断 domain 是否处于回收站中
+func isRecycledDomain(name string) bool {
+	return strings.HasPrefix(name, RecycleDirName)
+}
+
+// isRecycledVolume 判断卷是否为回收目录本身或其中的文件
+func isRecycledVolume(volInfo *libvirt.VolumeInfo) bool {
+	return volInfo.Name == RecycleDirName || strings.Contains(volInfo.Path, "/"+RecycleDirName+"/")
+}
+
+// movePoolFile 移动存储池中的文件，远程节点通过 SSH 执行
+func movePoolFile(client libvirt.RemoteManager, src, dst string) error {
+	if client.IsRemoteConnection() {
+		return client.ExecuteRemoteCommand(fmt.Sprintf("mkdir -p '%s' && mv -n '%s' '%s'", filepath.Dir(dst), src, dst))
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("create directory %s: %w", filepath.Dir(dst), err)
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("file %s already exists", dst)
+	}
+	return os.Rename(src, dst)
+}
+
+// removePoolFile 删除存储池中的文件，文件不存在时视为成功
+func removePoolFile(client libvirt.RemoteManager, filePath string) error {
+	if client.IsRemoteConnection() {
+		return client.ExecuteRemoteCommand(fmt.Sprintf("rm -f '%s'", filePath))
+	}
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// RecycleBinService 回收站服务：查询回收站，并通过调度器定期物理清理过期的实例和卷
+type RecycleBinService struct {
+	bin             *RecycleBin
+	instanceService *InstanceService
+	volumeService   *VolumeService
+}
+
+// NewRecycleBinService 创建回收站服务
+func NewRecycleBinService(bin *RecycleBin, instanceService *InstanceService, volumeService *VolumeService) *RecycleBinService {
+	return &RecycleBinService{
+		bin:             bin,
+		instanceService: instanceService,
+		volumeService:   volumeService,
+	}
+}
+
+// DescribeRecycleBin 查询回收站中的资源
+func (s *RecycleBinService) DescribeRecycleBin(ctx context.Context, req *entity.DescribeRecycleBinRequest) (*entity.DescribeRecycleBinResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("resource_type", req.ResourceType).
+		Msg("Describing recycle bin")
+
+	items, err := s.bin.List(req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list recycle bin", err)
+	}
+
+	result := make([]entity.RecycleBinItem, 0, len(items))
+	for _, item := range items {
+		if req.ResourceType != "" && item.ResourceType != req.ResourceType {
+			continue
+		}
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].DeletedAt.After(result[j].DeletedAt)
+	})
+
+	return &entity.DescribeRecycleBinResponse{Items: result}, nil
+}
+
+// PurgeExpired 物理清理所有节点上已过期的资源，单个资源失败不影响其余资源，下一轮重试
+func (s *RecycleBinService) PurgeExpired(ctx context.Context, now time.Time) error {
+	logger := zerolog.Ctx(ctx)
+
+	items, err := s.bin.List("")
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		item := &items[i]
+		if now.Before(item.ExpiresAt) {
+			continue
+		}
+
+		var err error
+		switch item.ResourceType {
+		case entity.RecycleBinResourceInstance:
+			err = s.instanceService.purgeRecycledInstance(ctx, item)
+		case entity.RecycleBinResourceVolume:
+			err = s.volumeService.purgeRecycledVolume(ctx, item)
+		default:
+			err = s.bin.Delete(item)
+		}
+		if err != nil {
+			logger.Warn().
+				Err(err).
+				Str("node_name", item.NodeName).
+				Str("resource_type", item.ResourceType).
+				Str("resource_id", item.ResourceID).
+				Msg("Failed to purge recycle bin item")
+			continue
+		}
+
+		logger.Info().
+			Str("node_name", item.NodeName).
+			Str("resource_type", item.ResourceType).
+			Str("resource_id", item.ResourceID).
+			Msg("Recycle bin item purged")
+	}
+	return nil
+}
+
+// RegisterScheduledTasks 向调度器注册过期资源清理任务，回收站未启用时不注册
+// 错过的清理（如进程停机期间）在启动后立即补偿执行一次
+func (s *RecycleBinService) RegisterScheduledTasks(scheduler *Scheduler) error {
+	if !s.bin.Enabled() {
+		return nil
+	}
+	return scheduler.Register(ScheduledTaskSpec{
+		Name:          "recycle-bin-purge",
+		Description:   "物理清理回收站中已过期的实例和卷",
+		Schedule:      "@hourly",
+		MisfirePolicy: entity.MisfirePolicyRunOnce,
+		Run:           s.PurgeExpired,
+	})
+}
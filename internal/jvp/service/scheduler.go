@@ -0,0 +1,471 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/cron"
+	"github.com/rs/zerolog"
+)
+
+// maxScheduledTaskRuns 每个定时任务保留的执行记录条数
+const maxScheduledTaskRuns = 100
+
+// schedulerIdleInterval 没有待触发任务时的最长等待时间，同时用于非 leader 重新尝试获取 leader 锁
+const schedulerIdleInterval = time.Minute
+
+// ScheduledTaskFunc 定时任务的执行函数，now 为计划触发时间
+type ScheduledTaskFunc func(ctx context.Context, now time.Time) error
+
+// ScheduledTaskSpec 注册定时任务的参数
+type ScheduledTaskSpec struct {
+	Name          string            // 任务名称，全局唯一
+	Description   string            // 任务说明
+	Schedule      string            // cron 表达式，支持 @hourly、@every 1h 等简写
+	MisfirePolicy string            // 错过触发后的补偿策略，默认 skip
+	Run           ScheduledTaskFunc // 执行函数
+}
+
+// Scheduler 统一的定时任务调度器
+// 多个 JVP 进程共享数据目录时，通过数据目录下的文件锁选出 leader，只有 leader 执行任务；
+// 每个任务最近一次触发时间与执行历史持久化在 <dataDir>/scheduler/<task>.json，用于重启或切换 leader 后的错过补偿
+type Scheduler struct {
+	storageDir string
+	identity   string
+	readOnly   *ReadOnlyMode // 只读模式下到期的触发记为 skipped
+
+	mu       sync.Mutex
+	tasks    map[string]*scheduledTask
+	lockFile *os.File      // 持有 leader 锁的文件，nil 表示不是 leader
+	wake     chan struct{} // 注册任务或任务结束时唤醒调度循环
+	wg       sync.WaitGroup
+}
+
+// scheduledTask 已注册的定时任务
+type scheduledTask struct {
+	spec     ScheduledTaskSpec
+	schedule cron.Schedule
+	state    scheduledTaskState
+	next     time.Time // 下一次触发时间，零值表示不再触发
+	misfire  bool      // 下一次触发是否为补偿执行
+	running  bool
+}
+
+// scheduledTaskState 持久化的任务状态
+type scheduledTaskState struct {
+	LastScheduledAt time.Time                 `json:"last_scheduled_at"` // 最近一次已处理的计划触发时间
+	Runs            []entity.ScheduledTaskRun `json:"runs"`              // 执行历史，按结束时间追加
+}
+
+// NewScheduler 创建定时任务调度器
+func NewScheduler(dataDir string, readOnly *ReadOnlyMode) (*Scheduler, error) {
+	storageDir := filepath.Join(dataDir, "scheduler")
+	if err := os.MkdirAll(storageDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create scheduler directory: %w", err)
+	}
+	hostname, _ := os.Hostname()
+	return &Scheduler{
+		storageDir: storageDir,
+		identity:   fmt.Sprintf("%s/%d", hostname, os.Getpid()),
+		readOnly:   readOnly,
+		tasks:      make(map[string]*scheduledTask),
+		wake:       make(chan struct{}, 1),
+	}, nil
+}
+
+// Register 注册定时任务，可以在 Run 之前或之后调用
+func (s *Scheduler) Register(spec ScheduledTaskSpec) error {
+	if spec.Name == "" || strings.ContainsAny(spec.Name, `/\`) {
+		return fmt.Errorf("invalid scheduled task name %q", spec.Name)
+	}
+	if spec.Run == nil {
+		return fmt.Errorf("scheduled task %s has no run function", spec.Name)
+	}
+	if spec.MisfirePolicy == "" {
+		spec.MisfirePolicy = entity.MisfirePolicySkip
+	}
+	if spec.MisfirePolicy != entity.MisfirePolicySkip && spec.MisfirePolicy != entity.MisfirePolicyRunOnce {
+		return fmt.Errorf("scheduled task %s has unsupported misfire policy %q", spec.Name, spec.MisfirePolicy)
+	}
+	schedule, err := cron.Parse(spec.Schedule)
+	if err != nil {
+		return fmt.Errorf("scheduled task %s: %w", spec.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tasks[spec.Name]; ok {
+		return fmt.Errorf("scheduled task %s already registered", spec.Name)
+	}
+
+	task := &scheduledTask{spec: spec, schedule: schedule}
+	state, err := s.loadState(spec.Name)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if state.LastScheduledAt.IsZero() {
+		// 新任务从注册时开始计算，不补偿注册前的触发
+		state.LastScheduledAt = now
+	}
+	task.state = state
+	s.tasks[spec.Name] = task
+	s.planNext(task, now)
+	s.notify()
+	return nil
+}
+
+// getStatePath 获取任务状态文件路径
+func (s *Scheduler) getStatePath(name string) string {
+	return filepath.Join(s.storageDir, name+".json")
+}
+
+// loadState 读取任务状态，不存在时返回零值
+func (s *Scheduler) loadState(name string) (scheduledTaskState, error) {
+	var state scheduledTaskState
+	data, err := os.ReadFile(s.getStatePath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, fmt.Errorf("failed to read scheduled task state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to unmarshal scheduled task state: %w", err)
+	}
+	return state, nil
+}
+
+// saveState 写入任务状态，先写临时文件再重命名，避免进程退出时留下不完整的文件
+func (s *Scheduler) saveState(name string, state scheduledTaskState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal scheduled task state: %w", err)
+	}
+	path := s.getStatePath(name)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write scheduled task state: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename scheduled task state: %w", err)
+	}
+	return nil
+}
+
+// planNext 根据最近一次已处理的触发时间计算下一次触发，调用方需持有锁
+// 下一次触发已经错过时，run_once 立即补偿执行一次，skip 跳到当前时间之后的下一次
+func (s *Scheduler) planNext(task *scheduledTask, now time.Time) {
+	next := task.schedule.Next(task.state.LastScheduledAt)
+	task.misfire = false
+	if !next.IsZero() && next.Before(now.Truncate(time.Second)) {
+		if task.spec.MisfirePolicy == entity.MisfirePolicyRunOnce {
+			task.next = now
+			task.misfire = true
+			return
+		}
+		next = task.schedule.Next(now)
+	}
+	task.next = next
+}
+
+// notify 唤醒调度循环重新计算等待时间
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// tryAcquireLeader 尝试获取 leader 锁，已持有时直接返回 true，调用方需持有锁
+// 刚成为 leader 时重新加载任务状态，接续上一任 leader 的触发记录并按补偿策略处理错过的触发
+func (s *Scheduler) tryAcquireLeader(ctx context.Context, now time.Time) bool {
+	if s.lockFile != nil {
+		return true
+	}
+	f, err := os.OpenFile(filepath.Join(s.storageDir, "leader.lock"), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to open scheduler leader lock")
+		return false
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		return false
+	}
+	_ = f.Truncate(0)
+	_, _ = f.WriteAt([]byte(s.identity+"\n"), 0)
+	s.lockFile = f
+
+	for name, task := range s.tasks {
+		state, err := s.loadState(name)
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("task", name).Msg("Failed to reload scheduled task state")
+			continue
+		}
+		if !state.LastScheduledAt.IsZero() {
+			task.state = state
+		}
+		if !task.running {
+			s.planNext(task, now)
+		}
+	}
+	zerolog.Ctx(ctx).Info().Str("identity", s.identity).Msg("Scheduler became leader")
+	return true
+}
+
+// Run 实现 grace.Grace 接口，按触发时间执行到期的任务，ctx 取消后等待执行中的任务结束
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		now := time.Now()
+		leader := s.tryAcquireLeader(ctx, now)
+		wait := schedulerIdleInterval
+		for _, task := range s.tasks {
+			if task.next.IsZero() {
+				continue
+			}
+			if !task.next.After(now) {
+				s.trigger(ctx, task, leader, now)
+			}
+			if !task.next.IsZero() {
+				wait = min(wait, task.next.Sub(now))
+			}
+		}
+		s.mu.Unlock()
+
+		timer := time.NewTimer(max(wait, 0))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			// 执行中的任务结束后再释放 leader 锁，避免新 leader 重复执行
+			s.wg.Wait()
+			s.releaseLeader()
+			return nil
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// trigger 处理到期的任务，调用方需持有锁
+// 非 leader 只推进触发时间；上一次执行未结束时记录 skipped，结束后按补偿策略处理本次错过的触发；
+// 只读模式下同样记录 skipped，关闭只读模式后等待下一次触发，不做补偿
+func (s *Scheduler) trigger(ctx context.Context, task *scheduledTask, leader bool, now time.Time) {
+	scheduledAt := task.next
+	if !leader {
+		task.next = task.schedule.Next(now)
+		return
+	}
+	if task.running {
+		s.appendRun(ctx, task, entity.ScheduledTaskRun{
+			TaskName:    task.spec.Name,
+			ScheduledAt: scheduledAt,
+			StartedAt:   now,
+			FinishedAt:  now,
+			Status:      entity.ScheduledTaskRunSkipped,
+			Error:       "previous run is still in progress",
+			Leader:      s.identity,
+		})
+		task.next = task.schedule.Next(now)
+		return
+	}
+	if err := s.readOnly.Check(); err != nil {
+		// 记录为已处理，避免关闭只读模式或切换 leader 后把这次触发当作错过而补偿执行
+		task.state.LastScheduledAt = scheduledAt
+		s.appendRun(ctx, task, entity.ScheduledTaskRun{
+			TaskName:    task.spec.Name,
+			ScheduledAt: scheduledAt,
+			StartedAt:   now,
+			FinishedAt:  now,
+			Status:      entity.ScheduledTaskRunSkipped,
+			Error:       err.Error(),
+			Leader:      s.identity,
+		})
+		task.next = task.schedule.Next(now)
+		return
+	}
+
+	run := entity.ScheduledTaskRun{
+		TaskName:    task.spec.Name,
+		ScheduledAt: scheduledAt,
+		StartedAt:   now,
+		Misfire:     task.misfire,
+		Leader:      s.identity,
+	}
+	task.running = true
+	task.state.LastScheduledAt = scheduledAt
+	task.next = task.schedule.Next(now)
+	task.misfire = false
+	if err := s.saveState(task.spec.Name, task.state); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("task", task.spec.Name).Msg("Failed to persist scheduled task state")
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.execute(ctx, task, run)
+	}()
+}
+
+// execute 执行任务并记录结果，任务 panic 时记为失败，不影响调度循环
+func (s *Scheduler) execute(ctx context.Context, task *scheduledTask, run entity.ScheduledTaskRun) {
+	logger := zerolog.Ctx(ctx).With().Str("task", task.spec.Name).Logger()
+	logger.Info().Bool("misfire", run.Misfire).Time("scheduled_at", run.ScheduledAt).Msg("Scheduled task started")
+
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return task.spec.Run(ctx, run.ScheduledAt)
+	}()
+
+	run.FinishedAt = time.Now()
+	run.Status = entity.ScheduledTaskRunSucceeded
+	if err != nil {
+		run.Status = entity.ScheduledTaskRunFailed
+		run.Error = err.Error()
+		logger.Warn().Err(err).Dur("duration", run.FinishedAt.Sub(run.StartedAt)).Msg("Scheduled task failed")
+	} else {
+		logger.Info().Dur("duration", run.FinishedAt.Sub(run.StartedAt)).Msg("Scheduled task finished")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task.running = false
+	s.appendRun(ctx, task, run)
+	// 执行期间错过的触发按补偿策略处理
+	s.planNext(task, time.Now())
+	s.notify()
+}
+
+// appendRun 追加执行记录并持久化，只保留最近的记录，调用方需持有锁
+func (s *Scheduler) appendRun(ctx context.Context, task *scheduledTask, run entity.ScheduledTaskRun) {
+	task.state.Runs = append(task.state.Runs, run)
+	if len(task.state.Runs) > maxScheduledTaskRuns {
+		task.state.Runs = slices.Clone(task.state.Runs[len(task.state.Runs)-maxScheduledTaskRuns:])
+	}
+	if err := s.saveState(task.spec.Name, task.state); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("task", task.spec.Name).Msg("Failed to persist scheduled task run")
+	}
+}
+
+// releaseLeader 释放 leader 锁，让其他进程接管
+func (s *Scheduler) releaseLeader() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lockFile == nil {
+		return
+	}
+	_ = syscall.Flock(int(s.lockFile.Fd()), syscall.LOCK_UN)
+	_ = s.lockFile.Close()
+	s.lockFile = nil
+}
+
+// Shutdown 实现 grace.Grace 接口，调度循环随 Run 的 ctx 取消而退出
+// 等待执行中的任务结束，ctx 到期时返回 ctx 的错误
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Name 实现 grace.Grace 接口
+func (s *Scheduler) Name() string {
+	return "Scheduler"
+}
+
+// DescribeScheduledTasks 查询已注册的定时任务
+func (s *Scheduler) DescribeScheduledTasks(ctx context.Context, req *entity.DescribeScheduledTasksRequest) (*entity.DescribeScheduledTasksResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := []entity.ScheduledTask{}
+	for name, task := range s.tasks {
+		if len(req.Names) > 0 && !slices.Contains(req.Names, name) {
+			continue
+		}
+		item := entity.ScheduledTask{
+			Name:          name,
+			Description:   task.spec.Description,
+			Schedule:      task.spec.Schedule,
+			MisfirePolicy: task.spec.MisfirePolicy,
+			Running:       task.running,
+			NextRunAt:     task.next,
+		}
+		for i := len(task.state.Runs) - 1; i >= 0; i-- {
+			if task.state.Runs[i].Status != entity.ScheduledTaskRunSkipped {
+				item.LastRunAt = task.state.Runs[i].StartedAt
+				item.LastStatus = task.state.Runs[i].Status
+				break
+			}
+		}
+		tasks = append(tasks, item)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Name < tasks[j].Name
+	})
+
+	return &entity.DescribeScheduledTasksResponse{
+		Leader:   s.lockFile != nil,
+		Identity: s.identity,
+		Tasks:    tasks,
+	}, nil
+}
+
+// DescribeScheduledTaskHistory 查询定时任务的执行历史，按开始时间倒序
+// 非 leader 进程从状态文件读取，能看到 leader 的执行记录
+func (s *Scheduler) DescribeScheduledTaskHistory(ctx context.Context, req *entity.DescribeScheduledTaskHistoryRequest) (*entity.DescribeScheduledTaskHistoryResponse, error) {
+	s.mu.Lock()
+	task, ok := s.tasks[req.Name]
+	var runs []entity.ScheduledTaskRun
+	if ok {
+		runs = slices.Clone(task.state.Runs)
+		if s.lockFile == nil {
+			if state, err := s.loadState(req.Name); err == nil {
+				runs = state.Runs
+			}
+		}
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Scheduled task %s not found", req.Name),
+			http.StatusNotFound,
+		)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+	if req.Limit > 0 && len(runs) > req.Limit {
+		runs = runs[:req.Limit]
+	}
+	if runs == nil {
+		runs = []entity.ScheduledTaskRun{}
+	}
+	return &entity.DescribeScheduledTaskHistoryResponse{Runs: runs}, nil
+}
@@ -0,0 +1,690 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/idgen"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// securityGroupFilterPrefix 安全组在节点上的 nwfilter 名称前缀，如 jvp-sg-123
+	securityGroupFilterPrefix = "jvp-"
+	// securityGroupSetFilterPrefix 实例网卡引用的组合 nwfilter 名称前缀，如 jvp-sgs_sg-1_sg-2
+	securityGroupSetFilterPrefix = "jvp-sgs_"
+	// maxInstanceSecurityGroups 每个实例最多关联的安全组数量
+	maxInstanceSecurityGroups = 5
+)
+
+// 组合过滤器中规则的优先级（越小越先匹配）：先放行已建立的连接与 DHCP、邻居发现，再匹配各安全组的规则，最后丢弃其余流量
+const (
+	securityGroupStatefulPriority = -900
+	securityGroupBaselinePriority = -800
+	securityGroupRulePriority     = 500
+	securityGroupDropPriority     = 1000
+)
+
+// securityGroupRecord 持久化的安全组，Nodes 记录已定义其过滤器的节点，修改规则时在这些节点上重新定义
+type securityGroupRecord struct {
+	entity.SecurityGroup
+	Nodes []string `json:"nodes,omitempty"`
+}
+
+// filterName 安全组在节点上的 nwfilter 名称
+func (r *securityGroupRecord) filterName() string {
+	return securityGroupFilterPrefix + r.ID
+}
+
+// SecurityGroupStore 安全组持久化
+// 所有节点共用一个 JSON 文件：<dataDir>/security-groups.json，key 为安全组 ID
+type SecurityGroupStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewSecurityGroupStore 创建安全组存储
+func NewSecurityGroupStore(dataDir string) (*SecurityGroupStore, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return &SecurityGroupStore{path: filepath.Join(dataDir, "security-groups.json")}, nil
+}
+
+func (s *SecurityGroupStore) loadUnlocked() (map[string]*securityGroupRecord, error) {
+	state := make(map[string]*securityGroupRecord)
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read security groups: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal security groups: %w", err)
+	}
+	return state, nil
+}
+
+func (s *SecurityGroupStore) saveUnlocked(state map[string]*securityGroupRecord) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal security groups: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write security groups: %w", err)
+	}
+	return nil
+}
+
+// All 返回所有安全组
+func (s *SecurityGroupStore) All() (map[string]*securityGroupRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.loadUnlocked()
+}
+
+// Put 保存安全组，已存在时覆盖
+func (s *SecurityGroupStore) Put(record *securityGroupRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.loadUnlocked()
+	if err != nil {
+		return err
+	}
+	state[record.ID] = record
+	return s.saveUnlocked(state)
+}
+
+// Delete 删除安全组，不存在时忽略
+func (s *SecurityGroupStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.loadUnlocked()
+	if err != nil {
+		return err
+	}
+	delete(state, id)
+	return s.saveUnlocked(state)
+}
+
+// SecurityGroupService 安全组服务
+// 每个安全组在节点上对应一个 nwfilter，实例网卡引用由其全部安全组组成的组合过滤器（有状态放行 + 各组规则 + 默认丢弃）。
+// 过滤器在实例首次使用安全组时定义到所在节点，修改规则时在已定义的节点上重新定义，libvirt 对运行中的网卡立即生效
+type SecurityGroupService struct {
+	nodeStorageFn NodeStorageGetter
+	store         *SecurityGroupStore
+	idGen         *idgen.Generator
+
+	mu sync.Mutex // 串行化安全组的修改与节点上过滤器的定义、删除
+}
+
+// NewSecurityGroupService 创建安全组服务
+func NewSecurityGroupService(nodeStorageFn NodeStorageGetter, store *SecurityGroupStore) *SecurityGroupService {
+	return &SecurityGroupService{
+		nodeStorageFn: nodeStorageFn,
+		store:         store,
+		idGen:         idgen.DefaultGenerator().Namespace(idgen.NamespaceSecurityGroup),
+	}
+}
+
+// CreateSecurityGroup 创建安全组，默认没有入方向规则、出方向放行所有流量
+func (s *SecurityGroupService) CreateSecurityGroup(ctx context.Context, req *entity.CreateSecurityGroupRequest) (*entity.SecurityGroup, error) {
+	logger := zerolog.Ctx(ctx)
+
+	if err := validateResourceName("security group", req.Name); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenant := tenantFromContext(ctx)
+	state, err := s.store.All()
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to load security groups", err)
+	}
+	for _, record := range state {
+		if record.Owner == tenant && record.Name == req.Name {
+			return nil, newResourceAlreadyExistsError("security group", req.Name)
+		}
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CreateSecurityGroup")
+	}
+
+	id, err := s.idGen.GenerateSecurityGroupID()
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to generate security group ID", err)
+	}
+	record := &securityGroupRecord{
+		SecurityGroup: entity.SecurityGroup{
+			ID:           id,
+			Name:         req.Name,
+			Description:  req.Description,
+			Owner:        tenant,
+			IngressRules: []entity.SecurityGroupRule{},
+			EgressRules: []entity.SecurityGroupRule{
+				{Protocol: entity.SecurityGroupProtocolAll, CIDR: "0.0.0.0/0"},
+				{Protocol: entity.SecurityGroupProtocolAll, CIDR: "::/0"},
+			},
+			CreatedAt: time.Now().Format(time.RFC3339),
+		},
+	}
+	if err := s.store.Put(record); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to save security group", err)
+	}
+
+	logger.Info().
+		Str("security_group_id", id).
+		Str("name", req.Name).
+		Msg("Security group created")
+	group := record.SecurityGroup
+	return &group, nil
+}
+
+// AuthorizeSecurityGroupIngress 添加入方向规则
+func (s *SecurityGroupService) AuthorizeSecurityGroupIngress(ctx context.Context, req *entity.AuthorizeSecurityGroupRequest) (*entity.SecurityGroup, error) {
+	return s.modifyRules(ctx, "AuthorizeSecurityGroupIngress", req.SecurityGroupID, req.Rules, true, true)
+}
+
+// AuthorizeSecurityGroupEgress 添加出方向规则
+func (s *SecurityGroupService) AuthorizeSecurityGroupEgress(ctx context.Context, req *entity.AuthorizeSecurityGroupRequest) (*entity.SecurityGroup, error) {
+	return s.modifyRules(ctx, "AuthorizeSecurityGroupEgress", req.SecurityGroupID, req.Rules, false, true)
+}
+
+// RevokeSecurityGroupIngress 删除入方向规则
+func (s *SecurityGroupService) RevokeSecurityGroupIngress(ctx context.Context, req *entity.RevokeSecurityGroupRequest) (*entity.SecurityGroup, error) {
+	return s.modifyRules(ctx, "RevokeSecurityGroupIngress", req.SecurityGroupID, req.Rules, true, false)
+}
+
+// RevokeSecurityGroupEgress 删除出方向规则
+func (s *SecurityGroupService) RevokeSecurityGroupEgress(ctx context.Context, req *entity.RevokeSecurityGroupRequest) (*entity.SecurityGroup, error) {
+	return s.modifyRules(ctx, "RevokeSecurityGroupEgress", req.SecurityGroupID, req.Rules, false, false)
+}
+
+// modifyRules 添加或删除一个方向的规则，并在已定义过滤器的节点上重新定义
+// 任一节点定义失败时把已更新的节点恢复为原规则，安全组保持不变
+func (s *SecurityGroupService) modifyRules(ctx context.Context, action, id string, rules []entity.SecurityGroupRule, ingress, authorize bool) (*entity.SecurityGroup, error) {
+	logger := zerolog.Ctx(ctx)
+
+	if len(rules) == 0 {
+		return nil, apierror.NewErrorWithStatus("InvalidParameterValue", "rules must not be empty", http.StatusBadRequest)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := s.getVisible(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	current := record.EgressRules
+	if ingress {
+		current = record.IngressRules
+	}
+	updated := slices.Clone(current)
+	for i, rule := range rules {
+		normalized, err := normalizeSecurityGroupRule(fmt.Sprintf("rules[%d]", i), rule)
+		if err != nil {
+			return nil, err
+		}
+		index := slices.IndexFunc(updated, func(r entity.SecurityGroupRule) bool { return sameSecurityGroupRule(r, normalized) })
+		switch {
+		case authorize && index >= 0:
+			return nil, apierror.NewErrorWithStatus(
+				"SecurityGroup.DuplicateRule",
+				fmt.Sprintf("rules[%d] (%s) already exists in security group %s", i, describeSecurityGroupRule(normalized), id),
+				http.StatusBadRequest,
+			)
+		case authorize:
+			updated = append(updated, normalized)
+		case index < 0:
+			return nil, apierror.NewErrorWithStatus(
+				"SecurityGroup.RuleNotFound",
+				fmt.Sprintf("rules[%d] (%s) does not exist in security group %s", i, describeSecurityGroupRule(normalized), id),
+				http.StatusNotFound,
+			)
+		default:
+			updated = slices.Delete(updated, index, index+1)
+		}
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, action)
+	}
+
+	previous := record.SecurityGroup
+	if ingress {
+		record.IngressRules = updated
+	} else {
+		record.EgressRules = updated
+	}
+	for i, nodeName := range record.Nodes {
+		if err := s.defineGroupFilter(ctx, nodeName, record); err != nil {
+			rollback := &securityGroupRecord{SecurityGroup: previous}
+			for _, applied := range record.Nodes[:i] {
+				if err := s.defineGroupFilter(ctx, applied, rollback); err != nil {
+					logger.Warn().
+						Err(err).
+						Str("security_group_id", id).
+						Str("node_name", applied).
+						Msg("Failed to restore security group filter")
+				}
+			}
+			return nil, apierror.WrapError(apierror.ErrInternalError,
+				fmt.Sprintf("Failed to apply security group rules on node %s", nodeName), err)
+		}
+	}
+	if err := s.store.Put(record); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to save security group", err)
+	}
+
+	logger.Info().
+		Str("security_group_id", id).
+		Str("action", action).
+		Int("rules", len(rules)).
+		Strs("nodes", record.Nodes).
+		Msg("Security group rules updated")
+	group := record.SecurityGroup
+	return &group, nil
+}
+
+// DescribeSecurityGroups 描述安全组，租户只能看到自己的安全组
+func (s *SecurityGroupService) DescribeSecurityGroups(ctx context.Context, req *entity.DescribeSecurityGroupsRequest) ([]entity.SecurityGroup, error) {
+	state, err := s.store.All()
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to load security groups", err)
+	}
+	tenant := tenantFromContext(ctx)
+	groups := make([]entity.SecurityGroup, 0, len(state))
+	for _, record := range state {
+		if tenant != "" && record.Owner != tenant {
+			continue
+		}
+		if len(req.SecurityGroupIDs) > 0 && !slices.Contains(req.SecurityGroupIDs, record.ID) {
+			continue
+		}
+		if len(req.Names) > 0 && !slices.Contains(req.Names, record.Name) {
+			continue
+		}
+		groups = append(groups, record.SecurityGroup)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	return groups, nil
+}
+
+// DeleteSecurityGroup 删除安全组及其在各节点上的过滤器，仍有实例（含已停止的实例）使用时返回 SecurityGroupInUse
+func (s *SecurityGroupService) DeleteSecurityGroup(ctx context.Context, id string) error {
+	logger := zerolog.Ctx(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := s.getVisible(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// 节点上引用该安全组的组合过滤器，先检查全部节点都没有实例使用，再开始删除
+	type nodeFilters struct {
+		nodeName string
+		client   libvirt.LibvirtClient
+		filters  []string // 需要删除的过滤器，组合过滤器在前
+	}
+	targets := make([]nodeFilters, 0, len(record.Nodes))
+	for _, nodeName := range record.Nodes {
+		client, err := s.nodeStorageFn(ctx, nodeName)
+		if err != nil {
+			return apierror.WrapError(apierror.ErrInternalError, fmt.Sprintf("Failed to connect to node %s", nodeName), err)
+		}
+		defined, err := client.ListNWFilters()
+		if err != nil {
+			return apierror.WrapError(apierror.ErrInternalError, fmt.Sprintf("Failed to list network filters on node %s", nodeName), err)
+		}
+		var filters []string
+		for _, name := range defined {
+			if slices.Contains(securityGroupIDsFromFilter(name), id) {
+				filters = append(filters, name)
+			}
+		}
+		if instance, err := nwfilterUser(client, filters); err != nil {
+			return apierror.WrapError(apierror.ErrInternalError, fmt.Sprintf("Failed to list instances on node %s", nodeName), err)
+		} else if instance != "" {
+			return securityGroupInUseError(id, instance, nodeName)
+		}
+		if slices.Contains(defined, record.filterName()) {
+			filters = append(filters, record.filterName())
+		}
+		targets = append(targets, nodeFilters{nodeName: nodeName, client: client, filters: filters})
+	}
+
+	if isDryRun(ctx) {
+		return dryRunOperation(ctx, "DeleteSecurityGroup")
+	}
+
+	for _, target := range targets {
+		for _, name := range target.filters {
+			if err := target.client.UndefineNWFilter(name); err != nil {
+				if errors.Is(err, libvirt.ErrNWFilterInUse) {
+					return securityGroupInUseError(id, name, target.nodeName)
+				}
+				return apierror.WrapError(apierror.ErrInternalError,
+					fmt.Sprintf("Failed to delete network filter %s on node %s", name, target.nodeName), err)
+			}
+		}
+	}
+	if err := s.store.Delete(id); err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to delete security group", err)
+	}
+
+	logger.Info().
+		Str("security_group_id", id).
+		Strs("nodes", record.Nodes).
+		Msg("Security group deleted")
+	return nil
+}
+
+// ValidateInstanceSecurityGroups 校验实例关联的安全组存在、对请求租户可见且不重复
+func (s *SecurityGroupService) ValidateInstanceSecurityGroups(ctx context.Context, ids []string) error {
+	if len(ids) > maxInstanceSecurityGroups {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("an instance can have at most %d security groups, got %d", maxInstanceSecurityGroups, len(ids)),
+			http.StatusBadRequest,
+		)
+	}
+	for i, id := range ids {
+		if slices.Contains(ids[:i], id) {
+			return apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("duplicate security group %s", id),
+				http.StatusBadRequest,
+			)
+		}
+		if _, err := s.getVisible(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureInstanceFilter 在节点上定义实例安全组的过滤器与组合过滤器，返回网卡应引用的组合过滤器名称
+// 安全组的过滤器每次都按当前规则重新定义，并记录该节点以便后续修改规则时同步
+func (s *SecurityGroupService) EnsureInstanceFilter(ctx context.Context, client libvirt.NWFilterManager, nodeName string, ids []string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sorted := slices.Clone(ids)
+	sort.Strings(sorted)
+	for _, id := range sorted {
+		record, err := s.getVisible(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		if err := client.DefineNWFilter(securityGroupFilter(record)); err != nil {
+			return "", apierror.WrapError(apierror.ErrInternalError,
+				fmt.Sprintf("Failed to define network filter for security group %s", id), err)
+		}
+		if !slices.Contains(record.Nodes, nodeName) {
+			record.Nodes = append(record.Nodes, nodeName)
+			if err := s.store.Put(record); err != nil {
+				return "", apierror.WrapError(apierror.ErrInternalError, "Failed to save security group", err)
+			}
+		}
+	}
+
+	filter := securityGroupSetFilter(sorted)
+	if err := client.DefineNWFilter(filter); err != nil {
+		return "", apierror.WrapError(apierror.ErrInternalError, "Failed to define network filter for security groups", err)
+	}
+	return filter.Name, nil
+}
+
+// getVisible 获取请求租户可见的安全组，不存在或属于其他租户时返回 ResourceNotFound
+func (s *SecurityGroupService) getVisible(ctx context.Context, id string) (*securityGroupRecord, error) {
+	state, err := s.store.All()
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to load security groups", err)
+	}
+	record, ok := state[id]
+	if tenant := tenantFromContext(ctx); ok && tenant != "" && record.Owner != tenant {
+		ok = false
+	}
+	if !ok {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("security group %s not found", id),
+			http.StatusNotFound,
+		)
+	}
+	return record, nil
+}
+
+// defineGroupFilter 在节点上按安全组的当前规则定义其过滤器
+func (s *SecurityGroupService) defineGroupFilter(ctx context.Context, nodeName string, record *securityGroupRecord) error {
+	client, err := s.nodeStorageFn(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+	return client.DefineNWFilter(securityGroupFilter(record))
+}
+
+// nwfilterUser 返回网卡引用了 filters 中任一过滤器的 domain，没有时返回空
+// libvirt 只拒绝删除运行中 domain 使用的过滤器，已停止的实例需要在这里检查，否则删除后无法启动
+func nwfilterUser(client libvirt.DomainManager, filters []string) (string, error) {
+	if len(filters) == 0 {
+		return "", nil
+	}
+	domains, err := client.GetVMSummaries()
+	if err != nil {
+		return "", err
+	}
+	for _, domain := range domains {
+		info, err := client.GetDomainInfo(domain.UUID)
+		if err != nil {
+			return "", err
+		}
+		for _, iface := range info.NetworkInfo {
+			if iface.Filter != "" && slices.Contains(filters, iface.Filter) {
+				return domain.Name, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func securityGroupInUseError(id, user, nodeName string) error {
+	return apierror.NewErrorWithStatus(
+		"SecurityGroupInUse",
+		fmt.Sprintf("security group %s is in use by %s on node %s", id, user, nodeName),
+		http.StatusConflict,
+	)
+}
+
+// securityGroupIDsFromFilter 从网卡引用的组合过滤器名称解析实例的安全组，不是安全组过滤器时返回 nil
+func securityGroupIDsFromFilter(filter string) []string {
+	ids, ok := strings.CutPrefix(filter, securityGroupSetFilterPrefix)
+	if !ok || ids == "" {
+		return nil
+	}
+	return strings.Split(ids, "_")
+}
+
+// normalizeSecurityGroupRule 校验规则并规范化：协议转为小写，CIDR 转为网络地址，all 不带端口
+func normalizeSecurityGroupRule(field string, rule entity.SecurityGroupRule) (entity.SecurityGroupRule, error) {
+	invalid := func(format string, args ...any) error {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			field+": "+fmt.Sprintf(format, args...),
+			http.StatusBadRequest,
+		)
+	}
+
+	rule.Protocol = strings.ToLower(rule.Protocol)
+	_, ipNet, err := net.ParseCIDR(rule.CIDR)
+	if err != nil {
+		return rule, invalid("invalid cidr %q", rule.CIDR)
+	}
+	rule.CIDR = ipNet.String()
+
+	switch rule.Protocol {
+	case entity.SecurityGroupProtocolTCP, entity.SecurityGroupProtocolUDP:
+		allPorts := rule.FromPort == 0 && rule.ToPort == 65535
+		if !allPorts && (rule.FromPort < 1 || rule.ToPort < rule.FromPort || rule.ToPort > 65535) {
+			return rule, invalid("invalid port range %d-%d, must be within 1-65535 (or 0-65535 for all ports)", rule.FromPort, rule.ToPort)
+		}
+	case entity.SecurityGroupProtocolICMP:
+		if rule.FromPort < -1 || rule.FromPort > 255 || rule.ToPort < -1 || rule.ToPort > 255 {
+			return rule, invalid("icmp type and code must be between 0 and 255, or -1 for all")
+		}
+		if rule.FromPort == -1 && rule.ToPort != -1 {
+			return rule, invalid("icmp code requires an icmp type")
+		}
+	case entity.SecurityGroupProtocolAll:
+		if (rule.FromPort != 0 && rule.FromPort != -1) || (rule.ToPort != 0 && rule.ToPort != -1) {
+			return rule, invalid("ports cannot be specified for protocol all")
+		}
+		rule.FromPort, rule.ToPort = 0, 0
+	default:
+		return rule, invalid("unsupported protocol %q, must be tcp, udp, icmp or all", rule.Protocol)
+	}
+	return rule, nil
+}
+
+// sameSecurityGroupRule 判断两条规则是否相同，描述不参与比较
+func sameSecurityGroupRule(a, b entity.SecurityGroupRule) bool {
+	return a.Protocol == b.Protocol && a.FromPort == b.FromPort && a.ToPort == b.ToPort && a.CIDR == b.CIDR
+}
+
+// describeSecurityGroupRule 规则的简短描述，用于错误信息
+func describeSecurityGroupRule(rule entity.SecurityGroupRule) string {
+	if rule.Protocol == entity.SecurityGroupProtocolAll {
+		return fmt.Sprintf("all %s", rule.CIDR)
+	}
+	return fmt.Sprintf("%s %d-%d %s", rule.Protocol, rule.FromPort, rule.ToPort, rule.CIDR)
+}
+
+// securityGroupFilter 安全组的过滤器：每条规则对应一条只放行新建连接的 accept 规则，回包由组合过滤器的有状态规则放行
+func securityGroupFilter(record *securityGroupRecord) libvirt.NWFilterXML {
+	filter := libvirt.NWFilterXML{Name: record.filterName()}
+	for _, rule := range record.IngressRules {
+		filter.Rules = append(filter.Rules, securityGroupNWFilterRule(rule, true))
+	}
+	for _, rule := range record.EgressRules {
+		filter.Rules = append(filter.Rules, securityGroupNWFilterRule(rule, false))
+	}
+	return filter
+}
+
+// securityGroupNWFilterRule 把安全组规则转换为 nwfilter 规则，按 CIDR 的地址族选择 IPv4 或 IPv6 协议
+// 入方向匹配报文的源地址，出方向匹配目的地址；端口与 ICMP 类型都是 guest 侧或对端服务的目的端口
+func securityGroupNWFilterRule(rule entity.SecurityGroupRule, ingress bool) libvirt.NWFilterRule {
+	ip, ipNet, _ := net.ParseCIDR(rule.CIDR)
+	ipv6 := ip.To4() == nil
+
+	protocol := rule.Protocol
+	if ipv6 {
+		switch rule.Protocol {
+		case entity.SecurityGroupProtocolICMP:
+			protocol = "icmpv6"
+		default:
+			protocol = rule.Protocol + "-ipv6"
+		}
+	}
+	direction := libvirt.NWFilterDirectionOut
+	if ingress {
+		direction = libvirt.NWFilterDirectionIn
+	}
+	nwRule := libvirt.NewNWFilterRule(libvirt.NWFilterActionAccept, direction, securityGroupRulePriority, protocol)
+	match := &nwRule.Match
+	match.State = "NEW"
+
+	if ones, _ := ipNet.Mask.Size(); ones > 0 {
+		if ingress {
+			match.SrcIPAddr, match.SrcIPMask = ipNet.IP.String(), strconv.Itoa(ones)
+		} else {
+			match.DstIPAddr, match.DstIPMask = ipNet.IP.String(), strconv.Itoa(ones)
+		}
+	}
+	switch rule.Protocol {
+	case entity.SecurityGroupProtocolTCP, entity.SecurityGroupProtocolUDP:
+		if rule.FromPort != 0 || rule.ToPort != 65535 {
+			match.DstPortStart = rule.FromPort
+			if rule.ToPort != rule.FromPort {
+				match.DstPortEnd = rule.ToPort
+			}
+		}
+	case entity.SecurityGroupProtocolICMP:
+		if rule.FromPort >= 0 {
+			icmpType := rule.FromPort
+			match.Type = &icmpType
+		}
+		if rule.ToPort >= 0 {
+			icmpCode := rule.ToPort
+			match.Code = &icmpCode
+		}
+	}
+	return nwRule
+}
+
+// securityGroupSetFilter 实例网卡引用的组合过滤器：放行已建立的连接、DHCP 与 IPv6 邻居发现，
+// 引用各安全组的过滤器，最后丢弃其余 IPv4/IPv6 流量（ARP 不受影响）
+func securityGroupSetFilter(ids []string) libvirt.NWFilterXML {
+	filter := libvirt.NWFilterXML{Name: securityGroupSetFilterPrefix + strings.Join(ids, "_")}
+	for _, protocol := range []string{"all", "all-ipv6"} {
+		rule := libvirt.NewNWFilterRule(libvirt.NWFilterActionAccept, libvirt.NWFilterDirectionInOut, securityGroupStatefulPriority, protocol)
+		rule.Match.State = "ESTABLISHED,RELATED"
+		filter.Rules = append(filter.Rules, rule)
+	}
+
+	// DHCP（客户端 68 → 服务端 67）与 DHCPv6（546 → 547）
+	for _, dhcp := range []struct {
+		protocol       string
+		client, server int
+	}{
+		{"udp", 68, 67},
+		{"udp-ipv6", 546, 547},
+	} {
+		request := libvirt.NewNWFilterRule(libvirt.NWFilterActionAccept, libvirt.NWFilterDirectionOut, securityGroupBaselinePriority, dhcp.protocol)
+		request.Match.SrcPortStart, request.Match.DstPortStart = dhcp.client, dhcp.server
+		reply := libvirt.NewNWFilterRule(libvirt.NWFilterActionAccept, libvirt.NWFilterDirectionIn, securityGroupBaselinePriority, dhcp.protocol)
+		reply.Match.SrcPortStart, reply.Match.DstPortStart = dhcp.server, dhcp.client
+		filter.Rules = append(filter.Rules, request, reply)
+	}
+	// 路由器请求/通告与邻居请求/通告，IPv6 地址配置（SLAAC）与地址解析依赖它们
+	for icmpType := 133; icmpType <= 136; icmpType++ {
+		rule := libvirt.NewNWFilterRule(libvirt.NWFilterActionAccept, libvirt.NWFilterDirectionInOut, securityGroupBaselinePriority, "icmpv6")
+		rule.Match.Type = &icmpType
+		filter.Rules = append(filter.Rules, rule)
+	}
+
+	for _, id := range ids {
+		filter.FilterRefs = append(filter.FilterRefs, libvirt.NWFilterReference{Filter: securityGroupFilterPrefix + id})
+	}
+	for _, protocol := range []string{"all", "all-ipv6"} {
+		filter.Rules = append(filter.Rules,
+			libvirt.NewNWFilterRule(libvirt.NWFilterActionDrop, libvirt.NWFilterDirectionInOut, securityGroupDropPriority, protocol))
+	}
+	return filter
+}
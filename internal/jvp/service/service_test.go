@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testNodeName = "test-node"
+	testPoolName = "test-pool"
+)
+
+// testServices 按 jvp.New 的方式组装的服务，节点为内存 FakeLibvirt
+type testServices struct {
+	fake      *libvirt.FakeLibvirt
+	poolPath  string
+	nodes     *NodeService
+	keyPairs  *KeyPairService
+	volumes   *VolumeService
+	snapshots *SnapshotService
+	instances *InstanceService
+}
+
+// newTestServices 在临时数据目录中组装服务，注册 fake node 并创建存储池
+// 每个测试使用独立的 fake URI，互不共享 domain 与卷
+func newTestServices(t *testing.T) *testServices {
+	t.Helper()
+	dataDir := t.TempDir()
+	// 密钥对固定存放在 ~/.jvp/keypairs
+	t.Setenv("HOME", t.TempDir())
+
+	nodeStorage, err := NewNodeStorage(dataDir)
+	require.NoError(t, err)
+	transfer := NewTransferLimiter(0)
+	operations := NewOperationLimiter(0)
+	changes := NewChangeFeed()
+	segments, err := NewNetworkSegmentStore(dataDir)
+	require.NoError(t, err)
+	nodes, err := NewNodeService(nodeStorage, transfer, operations, changes, "", NetworkDefaults{}, segments, 0)
+	require.NoError(t, err)
+
+	keyPairs, err := NewKeyPairService()
+	require.NoError(t, err)
+	securityGroupStore, err := NewSecurityGroupStore(dataDir)
+	require.NoError(t, err)
+	securityGroups := NewSecurityGroupService(nodes.GetNodeStorage, securityGroupStore)
+	metadataStore, err := NewInstanceMetadataStore(dataDir)
+	require.NoError(t, err)
+	metadata := NewMetadataService(nodes.GetNodeStorage, metadataStore, false)
+
+	pools := NewStoragePoolService(nodeStorage, changes)
+	recycleBin, err := NewRecycleBin(dataDir, 0)
+	require.NoError(t, err)
+	protection, err := NewDeletionProtection(dataDir)
+	require.NoError(t, err)
+	multiAttach, err := NewMultiAttachStore(dataDir)
+	require.NoError(t, err)
+	volumeLabels, err := NewVolumeLabelStore(dataDir)
+	require.NoError(t, err)
+	volumes := NewVolumeService(nodes, pools, transfer, recycleBin, protection, multiAttach, volumeLabels, changes)
+
+	templates := NewTemplateService(nodes.GetNodeStorage, NewTemplateStore(nodes.GetNodeStorage), transfer, operations, changes, 0)
+	fsFreezes := NewFSFreezeManager()
+	snapshots := NewSnapshotService(nodes, fsFreezes, changes)
+
+	logs, err := NewInstanceLogStore(dataDir)
+	require.NoError(t, err)
+	events, err := NewInstanceEventStore(dataDir)
+	require.NoError(t, err)
+	quotas, err := NewQuotaStore(dataDir)
+	require.NoError(t, err)
+	guestOS, err := NewGuestOSStore(dataDir)
+	require.NoError(t, err)
+	instanceLabels, err := NewInstanceLabelStore(dataDir)
+	require.NoError(t, err)
+	instances, err := NewInstanceService(nodes, templates, keyPairs, securityGroups, metadata, GuestDefaults{},
+		fsFreezes, transfer, operations, NewTempSpaceManager(t.TempDir(), 0), events, logs, recycleBin, protection,
+		changes, quotas, guestOS, instanceLabels, false)
+	require.NoError(t, err)
+
+	uri := libvirt.FakeScheme + ":///" + strings.ReplaceAll(t.Name(), "/", "-")
+	ctx := context.Background()
+	_, err = nodes.CreateNode(ctx, testNodeName, uri, entity.NodeTypeCompute, entity.NodeResources{}, entity.NodeTopology{}, entity.NodeTLS{})
+	require.NoError(t, err)
+	poolPath := t.TempDir()
+	_, err = pools.CreateStoragePool(ctx, testNodeName, testPoolName, "dir", poolPath)
+	require.NoError(t, err)
+
+	return &testServices{
+		fake:      libvirt.FakeNode(uri),
+		poolPath:  poolPath,
+		nodes:     nodes,
+		keyPairs:  keyPairs,
+		volumes:   volumes,
+		snapshots: snapshots,
+		instances: instances,
+	}
+}
+
+// runTestInstance 不使用模板创建一个运行中的实例
+func (s *testServices) runTestInstance(t *testing.T, name string) *entity.Instance {
+	t.Helper()
+	instance, err := s.instances.RunInstance(context.Background(), &entity.RunInstanceRequest{
+		NodeName:    testNodeName,
+		PoolName:    testPoolName,
+		Name:        name,
+		SizeGB:      10,
+		MemoryMB:    512,
+		VCPUs:       1,
+		NetworkType: "network",
+	})
+	require.NoError(t, err)
+	return instance
+}
@@ -3,9 +3,12 @@ package service
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -22,14 +25,18 @@ import (
 // SnapshotService 提供快照管理能力
 type SnapshotService struct {
 	nodeService *NodeService
+	fsFreezes   *FSFreezeManager
+	changes     *ChangeFeed
 	idGen       *idgen.Generator
 }
 
 // NewSnapshotService 创建快照服务
-func NewSnapshotService(nodeService *NodeService) *SnapshotService {
+func NewSnapshotService(nodeService *NodeService, fsFreezes *FSFreezeManager, changes *ChangeFeed) *SnapshotService {
 	return &SnapshotService{
 		nodeService: nodeService,
-		idGen:       idgen.New(),
+		fsFreezes:   fsFreezes,
+		changes:     changes,
+		idGen:       idgen.DefaultGenerator().Namespace(idgen.NamespaceSnapshot),
 	}
 }
 
@@ -41,8 +48,24 @@ func (s *SnapshotService) CreateSnapshot(ctx context.Context, req *entity.Create
 		Str("vm_name", req.VMName).
 		Str("snapshot_name", req.SnapshotName).
 		Bool("with_memory", req.WithMemory).
+		Bool("quiesce", req.Quiesce).
 		Msg("Creating snapshot")
 
+	if (req.Quiesce || len(req.FreezeMountpoints) > 0) && req.WithMemory {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"quiesce and freeze_mountpoints are only supported for disk-only snapshots",
+			http.StatusBadRequest,
+		)
+	}
+	if req.Quiesce && len(req.FreezeMountpoints) > 0 {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"quiesce and freeze_mountpoints are mutually exclusive",
+			http.StatusBadRequest,
+		)
+	}
+
 	client, err := s.nodeService.GetNodeStorage(ctx, req.NodeName)
 	if err != nil {
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
@@ -53,6 +76,12 @@ func (s *SnapshotService) CreateSnapshot(ctx context.Context, req *entity.Create
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to find domain", err)
 	}
 
+	if req.Quiesce || len(req.FreezeMountpoints) > 0 {
+		if err := ensureGuestAgentReady(client, domain); err != nil {
+			return nil, err
+		}
+	}
+
 	disks, err := client.GetDomainDisks(domain.Name)
 	if err != nil {
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get domain disks", err)
@@ -69,6 +98,18 @@ func (s *SnapshotService) CreateSnapshot(ctx context.Context, req *entity.Create
 	now := time.Now().UTC()
 	safeSnapshotName := sanitizeName(req.SnapshotName)
 
+	existing, err := client.ListSnapshots(domain.Name)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list snapshots", err)
+	}
+	if slices.Contains(existing, safeSnapshotName) {
+		return nil, newResourceAlreadyExistsError("Snapshot", safeSnapshotName)
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CreateSnapshot")
+	}
+
 	snapshotXML := libvirt.DomainSnapshotXML{
 		Name:        safeSnapshotName,
 		Description: req.Description,
@@ -117,11 +158,29 @@ func (s *SnapshotService) CreateSnapshot(ctx context.Context, req *entity.Create
 	if !req.WithMemory {
 		flags |= libvirtlib.DomainSnapshotCreateDiskOnly
 	}
+	if req.Quiesce {
+		// 由 libvirt 在快照前后调用 guest-fsfreeze-freeze/thaw
+		flags |= libvirtlib.DomainSnapshotCreateQuiesce
+	}
 
-	if err := client.CreateSnapshot(domain.Name, string(xmlBytes), flags); err != nil {
+	if len(req.FreezeMountpoints) > 0 {
+		if _, _, err := s.fsFreezes.freeze(ctx, client, req.NodeName, domain.Name, req.FreezeMountpoints, defaultFSFreezeTimeout); err != nil {
+			return nil, err
+		}
+	}
+	err = client.CreateSnapshot(domain.Name, string(xmlBytes), flags)
+	if len(req.FreezeMountpoints) > 0 {
+		// 快照完成后立即解冻，解冻失败时由超时定时器兜底
+		if _, thawErr := s.fsFreezes.thaw(client, req.NodeName, domain.Name, nil); thawErr != nil {
+			logger.Error().Err(thawErr).Msg("Failed to thaw file systems after snapshot, waiting for auto thaw")
+		}
+	}
+	if err != nil {
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to create snapshot", err)
 	}
 
+	s.changes.Record(entity.ResourceTypeSnapshot, req.NodeName, snapshotResourceID(domain.Name, safeSnapshotName), entity.ResourceChangeAdded)
+
 	// 读取最新的快照信息
 	created, err := client.GetSnapshotXML(domain.Name, safeSnapshotName)
 	if err != nil {
@@ -203,7 +262,8 @@ func (s *SnapshotService) DescribeSnapshot(ctx context.Context, req *entity.Desc
 
 // DeleteSnapshot 删除快照
 // 注意：对于外部快照（external snapshot），libvirt 无法自动合并磁盘链，
-// 因此默认只删除快照元数据。快照的磁盘文件需要手动清理或使用 blockcommit/blockpull 操作。
+// 因此默认只删除快照元数据，快照的磁盘文件保留在链中。
+// 设置 Merge 时对运行中实例执行 blockcommit，把快照 overlay 合并回 backing file 后删除 overlay 与元数据，保持链长度可控。
 func (s *SnapshotService) DeleteSnapshot(ctx context.Context, req *entity.DeleteSnapshotRequest) error {
 	logger := zerolog.Ctx(ctx)
 	logger.Info().
@@ -213,13 +273,26 @@ func (s *SnapshotService) DeleteSnapshot(ctx context.Context, req *entity.Delete
 		Bool("delete_children", req.DeleteChildren).
 		Bool("metadata_only", req.MetadataOnly).
 		Bool("disks_only", req.DisksOnly).
+		Bool("merge", req.Merge).
 		Msg("Deleting snapshot")
 
+	if req.Merge && (req.DeleteChildren || req.DisksOnly) {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"merge cannot be combined with delete_children or disks_only",
+			http.StatusBadRequest,
+		)
+	}
+
 	client, err := s.nodeService.GetNodeStorage(ctx, req.NodeName)
 	if err != nil {
 		return apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
 	}
 
+	if req.Merge {
+		return s.mergeSnapshot(ctx, client, req.VMName, req.SnapshotName)
+	}
+
 	var flags libvirtlib.DomainSnapshotDeleteFlags
 	if req.DeleteChildren {
 		flags |= libvirtlib.DomainSnapshotDeleteChildren
@@ -231,12 +304,216 @@ func (s *SnapshotService) DeleteSnapshot(ctx context.Context, req *entity.Delete
 		flags |= libvirtlib.DomainSnapshotDeleteMetadataOnly
 	}
 
+	if isDryRun(ctx) {
+		if err := checkSnapshotExists(client, req.VMName, req.SnapshotName); err != nil {
+			return err
+		}
+		return dryRunOperation(ctx, "DeleteSnapshot")
+	}
+
 	if err := client.DeleteSnapshot(req.VMName, req.SnapshotName, flags); err != nil {
 		return apierror.WrapError(apierror.ErrInternalError, "Failed to delete snapshot", err)
 	}
+	s.changes.Record(entity.ResourceTypeSnapshot, req.NodeName, snapshotResourceID(req.VMName, req.SnapshotName), entity.ResourceChangeDeleted)
 	return nil
 }
 
+// snapshotMerge 描述一块磁盘上待合并的快照 overlay
+type snapshotMerge struct {
+	device     string
+	overlay    string // 快照创建的 overlay 文件
+	base       string // overlay 的 backing file，合并目标
+	baseFormat string
+	active     bool // overlay 是否为磁盘当前写入的文件
+}
+
+// mergeSnapshot 通过 blockcommit 把快照创建的 overlay 合并回其 backing file，再删除 overlay 与快照元数据
+// overlay 为当前写入层时执行 active commit 并 pivot 回 backing file；
+// 其上还有后续快照的 overlay 时执行普通 commit，qemu 会把上层 overlay 的 backing 指向合并目标
+func (s *SnapshotService) mergeSnapshot(ctx context.Context, client libvirt.LibvirtClient, vmName, snapshotName string) error {
+	logger := zerolog.Ctx(ctx)
+
+	snap, err := client.GetSnapshotXML(vmName, snapshotName)
+	if err != nil {
+		return apierror.NewErrorWithRawAndStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Snapshot %s of instance %s not found", snapshotName, vmName),
+			http.StatusNotFound,
+			err,
+		)
+	}
+	if snap.Memory != nil && !strings.EqualFold(snap.Memory.Snapshot, "no") {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("Snapshot %s contains memory state and cannot be merged", snapshotName),
+			http.StatusBadRequest,
+		)
+	}
+
+	domain, err := client.GetDomainByName(vmName)
+	if err != nil {
+		return apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", vmName),
+			http.StatusNotFound,
+		)
+	}
+	state, _, err := client.GetDomainState(domain)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to get instance state", err)
+	}
+	if libvirtlib.DomainState(state) != libvirtlib.DomainRunning {
+		return apierror.NewErrorWithStatus(
+			"IncorrectInstanceState",
+			fmt.Sprintf("Instance %s must be running to merge snapshot disks", vmName),
+			http.StatusConflict,
+		)
+	}
+
+	merges, err := planSnapshotMerge(ctx, client, vmName, snap)
+	if err != nil {
+		return err
+	}
+
+	if isDryRun(ctx) {
+		return dryRunOperation(ctx, "DeleteSnapshot")
+	}
+
+	// 合并可能持续较久，不随请求取消中断，避免留下执行到一半的 block job
+	waitCtx := context.WithoutCancel(ctx)
+	for _, merge := range merges {
+		if err := commitSnapshotOverlay(waitCtx, client, vmName, merge); err != nil {
+			if errors.Is(err, libvirt.ErrDomainConfigConflict) {
+				return newDomainConfigConflictError(vmName, err)
+			}
+			return apierror.WrapError(apierror.ErrInternalError, fmt.Sprintf("Failed to merge snapshot disk %s", merge.device), err)
+		}
+		s.cleanupDisk(client, merge.overlay)
+
+		logger.Info().
+			Str("vm_name", vmName).
+			Str("device", merge.device).
+			Str("overlay", merge.overlay).
+			Str("base", merge.base).
+			Bool("active", merge.active).
+			Msg("Snapshot overlay merged")
+	}
+
+	if err := client.DeleteSnapshot(vmName, snapshotName, libvirtlib.DomainSnapshotDeleteMetadataOnly); err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to delete snapshot metadata", err)
+	}
+	return nil
+}
+
+// planSnapshotMerge 找出快照每块外部磁盘的 overlay 与合并目标，并确认磁盘上没有其他 block job
+func planSnapshotMerge(ctx context.Context, client libvirt.LibvirtClient, vmName string, snap *libvirt.DomainSnapshotXML) ([]snapshotMerge, error) {
+	disks, err := client.GetDomainDisks(vmName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get domain disks", err)
+	}
+	current := make(map[string]string, len(disks))
+	for _, disk := range disks {
+		current[disk.Target.Dev] = disk.Source.File
+	}
+
+	qemuClient := newQemuImgClient(client)
+	var merges []snapshotMerge
+	for _, disk := range snap.Disks {
+		if disk.Snapshot != "external" || disk.Source == nil || disk.Source.File == "" {
+			continue
+		}
+		if _, ok := current[disk.Name]; !ok {
+			return nil, apierror.NewErrorWithStatus(
+				"IncorrectInstanceState",
+				fmt.Sprintf("Device %s of snapshot %s is no longer attached to instance %s", disk.Name, snap.Name, vmName),
+				http.StatusConflict,
+			)
+		}
+
+		base, err := qemuClient.GetBackingFile(ctx, disk.Source.File)
+		if err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to read snapshot overlay backing file", err)
+		}
+		if base == "" {
+			return nil, apierror.NewErrorWithStatus(
+				"IncorrectInstanceState",
+				fmt.Sprintf("Snapshot overlay %s has no backing file to merge into", disk.Source.File),
+				http.StatusConflict,
+			)
+		}
+		baseFormat, err := qemuClient.GetFormat(ctx, base)
+		if err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to read snapshot base format", err)
+		}
+
+		job, err := client.GetBlockJob(vmName, disk.Name)
+		if err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get block job", err)
+		}
+		if job != nil {
+			return nil, apierror.NewErrorWithStatus(
+				"IncorrectInstanceState",
+				fmt.Sprintf("Another %s block job is running on device %s of instance %s", job.Type, disk.Name, vmName),
+				http.StatusConflict,
+			)
+		}
+
+		merges = append(merges, snapshotMerge{
+			device:     disk.Name,
+			overlay:    disk.Source.File,
+			base:       base,
+			baseFormat: baseFormat,
+			active:     current[disk.Name] == disk.Source.File,
+		})
+	}
+
+	if len(merges) == 0 {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("Snapshot %s has no external disks to merge", snap.Name),
+			http.StatusBadRequest,
+		)
+	}
+	return merges, nil
+}
+
+// commitSnapshotOverlay 执行单块磁盘的 blockcommit 并等待完成
+func commitSnapshotOverlay(ctx context.Context, client libvirt.LibvirtClient, vmName string, merge snapshotMerge) error {
+	config := libvirt.BlockCommitConfig{
+		Base:   merge.base,
+		Active: merge.active,
+	}
+	if !merge.active {
+		config.Top = merge.overlay
+	}
+	if err := client.BlockCommit(vmName, merge.device, config); err != nil {
+		return err
+	}
+
+	job, err := client.WaitBlockJob(ctx, vmName, merge.device, nil)
+	if err != nil {
+		if abortErr := client.AbortBlockJob(vmName, merge.device, false); abortErr != nil {
+			zerolog.Ctx(ctx).Warn().
+				Err(abortErr).
+				Str("device", merge.device).
+				Msg("Failed to cancel block commit")
+		}
+		return err
+	}
+	if !merge.active {
+		return nil
+	}
+	if job == nil {
+		return fmt.Errorf("active commit on %s ended before it was ready", merge.device)
+	}
+
+	if err := client.AbortBlockJob(vmName, merge.device, true); err != nil {
+		return fmt.Errorf("pivot to %s: %w", merge.base, err)
+	}
+	// pivot 只切换运行时磁盘，持久化配置同步指向合并目标，避免重启后回到已删除的 overlay
+	return client.SetDomainDiskSource(vmName, merge.device, merge.base, merge.baseFormat)
+}
+
 // RevertSnapshot 回滚到快照
 func (s *SnapshotService) RevertSnapshot(ctx context.Context, req *entity.RevertSnapshotRequest) error {
 	logger := zerolog.Ctx(ctx)
@@ -261,19 +538,72 @@ func (s *SnapshotService) RevertSnapshot(ctx context.Context, req *entity.Revert
 		flags |= libvirtlib.DomainSnapshotRevertForce
 	}
 
+	if isDryRun(ctx) {
+		if err := checkSnapshotExists(client, req.VMName, req.SnapshotName); err != nil {
+			return err
+		}
+		return dryRunOperation(ctx, "RevertSnapshot")
+	}
+
 	if err := client.RevertToSnapshot(req.VMName, req.SnapshotName, flags); err != nil {
 		return apierror.WrapError(apierror.ErrInternalError, "Failed to revert snapshot", err)
 	}
+	s.changes.Record(entity.ResourceTypeInstance, req.NodeName, req.VMName, entity.ResourceChangeModified)
+	return nil
+}
+
+// ensureGuestAgentReady 校验实例运行中且 guest-agent 可用，冻结文件系统依赖 guest-agent
+func ensureGuestAgentReady(client libvirt.LibvirtClient, domain libvirtlib.Domain) error {
+	state, _, err := client.GetDomainState(domain)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to get instance state", err)
+	}
+	if libvirtlib.DomainState(state) != libvirtlib.DomainRunning {
+		return apierror.NewErrorWithStatus(
+			"IncorrectInstanceState",
+			fmt.Sprintf("Instance %s must be running to quiesce file systems", domain.Name),
+			http.StatusConflict,
+		)
+	}
+	available, err := client.CheckGuestAgentAvailable(domain)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to check guest agent", err)
+	}
+	if !available {
+		return apierror.NewErrorWithStatus(
+			"IncorrectInstanceState",
+			fmt.Sprintf("Guest agent of instance %s is not available", domain.Name),
+			http.StatusConflict,
+		)
+	}
+	return nil
+}
+
+// checkSnapshotExists 校验快照存在
+func checkSnapshotExists(client libvirt.SnapshotManager, vmName, snapshotName string) error {
+	if _, err := client.GetSnapshotXML(vmName, snapshotName); err != nil {
+		return apierror.NewErrorWithRawAndStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Snapshot %s of instance %s not found", snapshotName, vmName),
+			http.StatusNotFound,
+			err,
+		)
+	}
 	return nil
 }
 
-func ensureDir(client libvirt.LibvirtClient, dir string) error {
+func ensureDir(client libvirt.RemoteManager, dir string) error {
 	if client.IsRemoteConnection() {
 		return client.ExecuteRemoteCommand(fmt.Sprintf("mkdir -p '%s'", dir))
 	}
 	return os.MkdirAll(dir, 0o755)
 }
 
+// snapshotResourceID 快照在资源变化流中的 ID，快照名只在所属实例内唯一
+func snapshotResourceID(vmName, snapshotName string) string {
+	return vmName + "/" + snapshotName
+}
+
 func convertSnapshot(nodeName, vmName string, snap *libvirt.DomainSnapshotXML) *entity.Snapshot {
 	result := &entity.Snapshot{
 		ID:       snap.Name,
@@ -364,6 +694,13 @@ func (s *SnapshotService) CloneFromSnapshot(ctx context.Context, req *entity.Clo
 			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to generate VM name", genErr)
 		}
 		newVMName = fmt.Sprintf("%s-clone-%d", req.SourceVMName, id)
+	} else {
+		if err := validateResourceName("instance", newVMName); err != nil {
+			return nil, err
+		}
+		if _, err := client.GetDomainByName(newVMName); err == nil {
+			return nil, newResourceAlreadyExistsError("Instance", newVMName)
+		}
 	}
 
 	// 4. 获取存储池路径
@@ -373,8 +710,17 @@ func (s *SnapshotService) CloneFromSnapshot(ctx context.Context, req *entity.Clo
 	}
 	poolPath := poolInfo.Path
 
+	networkType, networkSource, err := s.nodeService.ResolveInstanceNetwork(ctx, req.NodeName, req.NetworkType, req.NetworkSource)
+	if err != nil {
+		return nil, err
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CloneFromSnapshot")
+	}
+
 	// 5. 准备 qemu-img 客户端
-	qemuClient := s.createQemuImgClient(client)
+	qemuClient := newQemuImgClient(client)
 
 	// 6. 处理快照磁盘 - 为新 VM 创建磁盘
 	// 关键点：快照磁盘（snap-xxx.qcow2）是 VM 当前使用的增量文件，
@@ -454,16 +800,8 @@ func (s *SnapshotService) CloneFromSnapshot(ctx context.Context, req *entity.Clo
 		memoryKB = uint64(req.MemoryMB) * 1024
 	}
 
-	networkType := req.NetworkType
-	networkSource := req.NetworkSource
-	if networkType == "" {
-		networkType = "bridge"
-	}
-	if networkSource == "" {
-		networkSource = "br0"
-	}
-
 	// 8. 创建新 VM
+	createdAt := time.Now().UTC()
 	vmConfig := &libvirt.CreateVMConfig{
 		Name:          newVMName,
 		Memory:        memoryKB,
@@ -475,6 +813,7 @@ func (s *SnapshotService) CloneFromSnapshot(ctx context.Context, req *entity.Clo
 		OSType:        "hvm",
 		Architecture:  "x86_64",
 		VNCSocket:     fmt.Sprintf("/var/lib/jvp/qemu/%s.vnc", newVMName),
+		CreatedAt:     createdAt,
 	}
 
 	domain, err := client.CreateDomain(vmConfig, req.StartAfterClone)
@@ -483,6 +822,7 @@ func (s *SnapshotService) CloneFromSnapshot(ctx context.Context, req *entity.Clo
 		s.cleanupDisk(client, newDiskPath)
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to create cloned VM", err)
 	}
+	s.changes.Record(entity.ResourceTypeInstance, req.NodeName, newVMName, entity.ResourceChangeAdded)
 
 	// 9. 获取新 VM 状态
 	state := "stopped"
@@ -502,13 +842,14 @@ func (s *SnapshotService) CloneFromSnapshot(ctx context.Context, req *entity.Clo
 		NodeName:   req.NodeName,
 		MemoryMB:   memoryKB / 1024,
 		VCPUs:      vcpus,
+		CreatedAt:  createdAt.Format(time.RFC3339),
 		DomainUUID: fmt.Sprintf("%x", domain.UUID),
 		DomainName: domain.Name,
 	}, nil
 }
 
-// createQemuImgClient 创建 qemu-img 客户端，支持本地和远程
-func (s *SnapshotService) createQemuImgClient(client libvirt.LibvirtClient) *qemuimg.Client {
+// newQemuImgClient 创建 qemu-img 客户端，远程节点通过 SSH 执行
+func newQemuImgClient(client libvirt.RemoteManager) *qemuimg.Client {
 	qemuClient := qemuimg.New("")
 	if client.IsRemoteConnection() {
 		sshTarget, err := client.GetSSHTarget()
@@ -520,7 +861,7 @@ func (s *SnapshotService) createQemuImgClient(client libvirt.LibvirtClient) *qem
 }
 
 // cleanupDisk 清理磁盘文件
-func (s *SnapshotService) cleanupDisk(client libvirt.LibvirtClient, diskPath string) {
+func (s *SnapshotService) cleanupDisk(client libvirt.RemoteManager, diskPath string) {
 	if client.IsRemoteConnection() {
 		_ = client.ExecuteRemoteCommand(fmt.Sprintf("rm -f '%s'", diskPath))
 	} else {
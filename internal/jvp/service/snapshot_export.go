@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/rs/zerolog"
+)
+
+// ExportSnapshot 导出快照中一块磁盘在快照时刻的状态，合并整条 backing 链为独立的压缩 qcow2
+// 快照 overlay 的 backing file 是只读的，实例运行中也可以导出；导出文件与实例导出共用 _exports_ 目录与下载、删除接口
+func (s *SnapshotService) ExportSnapshot(ctx context.Context, req *entity.ExportSnapshotRequest) (*entity.InstanceExport, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("vm_name", req.VMName).
+		Str("snapshot_name", req.SnapshotName).
+		Str("disk_target", req.DiskTarget).
+		Str("pool_name", req.PoolName).
+		Msg("Exporting snapshot")
+
+	client, err := s.nodeService.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+	snap, err := client.GetSnapshotXML(req.VMName, req.SnapshotName)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Snapshot %s of instance %s not found", req.SnapshotName, req.VMName),
+			http.StatusNotFound,
+		)
+	}
+	pool, err := client.GetStoragePool(req.PoolName)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Storage pool %s not found", req.PoolName),
+			http.StatusNotFound,
+		)
+	}
+
+	target := req.DiskTarget
+	if target == "" {
+		for _, disk := range snap.Disks {
+			if disk.Source != nil && disk.Source.File != "" {
+				target = disk.Name
+				break
+			}
+		}
+	}
+	sourcePath, sourceFormat, err := snapshotDiskSource(ctx, client, snap, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ExportSnapshot")
+	}
+
+	exportID := fmt.Sprintf("%s-%s-%s", req.VMName, target, time.Now().UTC().Format("20060102150405"))
+	diskPath := filepath.Join(pool.Path, ExportsDirName, exportID+".qcow2")
+	if err := convertExportDisk(ctx, client, sourcePath, sourceFormat, "-O qcow2 -c", diskPath); err != nil {
+		return nil, err
+	}
+	size, err := nodeFileSize(ctx, client, diskPath)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to stat export file", err)
+	}
+
+	logger.Info().
+		Str("vm_name", req.VMName).
+		Str("snapshot_name", req.SnapshotName).
+		Str("export_id", exportID).
+		Int64("size_b", size).
+		Msg("Snapshot exported successfully")
+
+	return &entity.InstanceExport{
+		ExportID:     exportID,
+		NodeName:     req.NodeName,
+		PoolName:     req.PoolName,
+		InstanceID:   req.VMName,
+		Format:       entity.InstanceExportFormatQCOW2,
+		SnapshotName: req.SnapshotName,
+		DiskTarget:   target,
+		SizeB:        uint64(size),
+		DownloadURL: fmt.Sprintf("/api/download-instance-export/%s/%s/%s",
+			url.PathEscape(req.NodeName), url.PathEscape(req.PoolName), url.PathEscape(exportID)),
+	}, nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotLifecycle(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	instance := s.runTestInstance(t, "vm-snapshot")
+	_, err := s.instances.StopInstances(ctx, &entity.StopInstancesRequest{
+		NodeName:    testNodeName,
+		InstanceIDs: []string{instance.ID},
+		Force:       true,
+	})
+	require.NoError(t, err)
+
+	snapshot, err := s.snapshots.CreateSnapshot(ctx, &entity.CreateSnapshotRequest{
+		NodeName:     testNodeName,
+		VMName:       instance.ID,
+		SnapshotName: "snap-1",
+		Description:  "before upgrade",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "snap-1", snapshot.Name)
+	assert.Equal(t, instance.ID, snapshot.VMName)
+
+	// 同名快照不能重复创建
+	_, err = s.snapshots.CreateSnapshot(ctx, &entity.CreateSnapshotRequest{
+		NodeName:     testNodeName,
+		VMName:       instance.ID,
+		SnapshotName: "snap-1",
+	})
+	assert.Error(t, err)
+
+	_, err = s.snapshots.CreateSnapshot(ctx, &entity.CreateSnapshotRequest{
+		NodeName:     testNodeName,
+		VMName:       instance.ID,
+		SnapshotName: "snap-2",
+	})
+	require.NoError(t, err)
+
+	snapshots, err := s.snapshots.ListSnapshots(ctx, &entity.ListSnapshotsRequest{
+		NodeName: testNodeName,
+		VMName:   instance.ID,
+	})
+	require.NoError(t, err)
+	names := make([]string, 0, len(snapshots))
+	for _, snap := range snapshots {
+		names = append(names, snap.Name)
+	}
+	assert.ElementsMatch(t, []string{"snap-1", "snap-2"}, names)
+
+	require.NoError(t, s.snapshots.RevertSnapshot(ctx, &entity.RevertSnapshotRequest{
+		NodeName:     testNodeName,
+		VMName:       instance.ID,
+		SnapshotName: "snap-1",
+	}))
+
+	require.NoError(t, s.snapshots.DeleteSnapshot(ctx, &entity.DeleteSnapshotRequest{
+		NodeName:     testNodeName,
+		VMName:       instance.ID,
+		SnapshotName: "snap-2",
+	}))
+	_, err = s.snapshots.DescribeSnapshot(ctx, &entity.DescribeSnapshotRequest{
+		NodeName:     testNodeName,
+		VMName:       instance.ID,
+		SnapshotName: "snap-2",
+	})
+	assert.Error(t, err)
+}
+
+func TestCreateSnapshotInvalidOptions(t *testing.T) {
+	s := newTestServices(t)
+
+	instance := s.runTestInstance(t, "vm-snapshot-options")
+	_, err := s.snapshots.CreateSnapshot(context.Background(), &entity.CreateSnapshotRequest{
+		NodeName:     testNodeName,
+		VMName:       instance.ID,
+		SnapshotName: "snap-quiesce",
+		WithMemory:   true,
+		Quiesce:      true,
+	})
+	assert.Error(t, err)
+}
@@ -12,19 +12,26 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// storageClient StorageService 所需的 libvirt 能力（卷的挂载关系需要查询 domain 与快照）
+type storageClient interface {
+	libvirt.StorageManager
+	libvirt.DomainManager
+	libvirt.SnapshotManager
+}
+
 // StorageService 存储服务，管理 libvirt storage pool 和 volume
 type StorageService struct {
-	libvirtClient libvirt.LibvirtClient
+	libvirtClient storageClient
 	idGen         *idgen.Generator
 }
 
 // NewStorageService 创建新的 Storage Service
 func NewStorageService(
-	libvirtClient libvirt.LibvirtClient,
+	libvirtClient storageClient,
 ) *StorageService {
 	return &StorageService{
 		libvirtClient: libvirtClient,
-		idGen:         idgen.New(),
+		idGen:         idgen.DefaultGenerator().Namespace(idgen.NamespaceVolume),
 	}
 }
 
@@ -91,6 +98,7 @@ func (s *StorageService) CreateVolume(ctx context.Context, req *entity.CreateInt
 		CapacityB:   volInfo.CapacityB,
 		AllocationB: volInfo.AllocationB,
 		Format:      volInfo.Format,
+		Type:        volumeType(volInfo.Name),
 	}, nil
 }
 
@@ -117,6 +125,9 @@ func (s *StorageService) ListVolumes(ctx context.Context, poolName string) ([]*e
 		if volInfo.Name == SnapshotsDirName || strings.Contains(volInfo.Path, "/"+SnapshotsDirName+"/") {
 			continue
 		}
+		if isRecycledVolume(volInfo) || isExportVolume(volInfo) || isImportVolume(volInfo) {
+			continue
+		}
 
 		// 从文件名提取 volume ID（去掉 .qcow2 后缀）
 		volumeID := strings.TrimSuffix(volInfo.Name, ".qcow2")
@@ -129,6 +140,7 @@ func (s *StorageService) ListVolumes(ctx context.Context, poolName string) ([]*e
 			CapacityB:   volInfo.CapacityB,
 			AllocationB: volInfo.AllocationB,
 			Format:      volInfo.Format,
+			Type:        volumeType(volInfo.Name),
 		})
 	}
 
@@ -163,7 +175,7 @@ func (s *StorageService) ListStoragePools(ctx context.Context, includeVolumes bo
 				// 过滤掉 _templates_ 目录本身和目录中的文件
 				for _, volInfo := range volInfos {
 					if volInfo.Name != TemplatesDirName && !strings.Contains(volInfo.Path, "/"+TemplatesDirName+"/") &&
-						!isSnapshotVolume(volInfo, diskMaps.snapshotPaths) {
+						!isSnapshotVolume(volInfo, diskMaps.snapshotPaths) && !isRecycledVolume(volInfo) {
 						volumeCount++
 					}
 				}
@@ -213,7 +225,7 @@ func (s *StorageService) GetStoragePool(ctx context.Context, poolName string, in
 		// 过滤掉 _templates_ 目录本身和目录中的文件
 		for _, volInfo := range volInfos {
 			if volInfo.Name != TemplatesDirName && !strings.Contains(volInfo.Path, "/"+TemplatesDirName+"/") &&
-				!isSnapshotVolume(volInfo, diskMaps.snapshotPaths) {
+				!isSnapshotVolume(volInfo, diskMaps.snapshotPaths) && !isRecycledVolume(volInfo) {
 				volumeCount++
 			}
 		}
@@ -12,17 +12,19 @@ import (
 // 纯粹调用 libvirt API，不存储额外数据
 type StoragePoolService struct {
 	nodeStorage *NodeStorage
+	changes     *ChangeFeed
 }
 
 // NewStoragePoolService 创建存储池服务
-func NewStoragePoolService(nodeStorage *NodeStorage) *StoragePoolService {
+func NewStoragePoolService(nodeStorage *NodeStorage, changes *ChangeFeed) *StoragePoolService {
 	return &StoragePoolService{
 		nodeStorage: nodeStorage,
+		changes:     changes,
 	}
 }
 
 // getLibvirtClient 获取 libvirt 客户端（本地或远程节点）
-func (s *StoragePoolService) getLibvirtClient(nodeName string) (*libvirt.Client, error) {
+func (s *StoragePoolService) getLibvirtClient(nodeName string) (libvirt.LibvirtClient, error) {
 	if nodeName == "" {
 		// 本地节点
 		return libvirt.New()
@@ -52,7 +54,7 @@ func (s *StoragePoolService) ListStoragePools(ctx context.Context, nodeName stri
 			Name:       poolInfo.Name,
 			UUID:       "", // libvirt.StoragePoolInfo doesn't provide UUID
 			State:      poolInfo.State,
-			Type:       "", // libvirt.StoragePoolInfo doesn't provide Type
+			Type:       poolInfo.Type,
 			Capacity:   poolInfo.CapacityB,
 			Allocation: poolInfo.AllocationB,
 			Available:  poolInfo.AvailableB,
@@ -88,7 +90,7 @@ func (s *StoragePoolService) DescribeStoragePool(ctx context.Context, nodeName,
 		Name:        poolInfo.Name,
 		UUID:        "", // libvirt.StoragePoolInfo doesn't provide UUID
 		State:       poolInfo.State,
-		Type:        "", // libvirt.StoragePoolInfo doesn't provide Type
+		Type:        poolInfo.Type,
 		Capacity:    poolInfo.CapacityB,
 		Allocation:  poolInfo.AllocationB,
 		Available:   poolInfo.AvailableB,
@@ -99,21 +101,34 @@ func (s *StoragePoolService) DescribeStoragePool(ctx context.Context, nodeName,
 
 // CreateStoragePool 创建存储池
 func (s *StoragePoolService) CreateStoragePool(ctx context.Context, nodeName, name, poolType, path string) (*entity.StoragePool, error) {
+	if err := validateResourceName("storage pool", name); err != nil {
+		return nil, err
+	}
+
 	// 获取 libvirt 客户端
 	client, err := s.getLibvirtClient(nodeName)
 	if err != nil {
 		return nil, fmt.Errorf("get libvirt client: %w", err)
 	}
 
+	if _, err := client.GetStoragePool(name); err == nil {
+		return nil, newResourceAlreadyExistsError("Storage pool", name)
+	}
+
 	// 默认类型为 dir
 	if poolType == "" {
 		poolType = "dir"
 	}
 
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CreateStoragePool")
+	}
+
 	// 调用 libvirt API 创建存储池
 	if err := client.EnsureStoragePool(name, poolType, path); err != nil {
 		return nil, fmt.Errorf("create storage pool %s: %w", name, err)
 	}
+	s.changes.Record(entity.ResourceTypeStoragePool, nodeName, name, entity.ResourceChangeAdded)
 
 	// 获取创建后的存储池信息
 	return s.DescribeStoragePool(ctx, nodeName, name)
@@ -127,10 +142,18 @@ func (s *StoragePoolService) DeleteStoragePool(ctx context.Context, nodeName, po
 		return fmt.Errorf("get libvirt client: %w", err)
 	}
 
+	if isDryRun(ctx) {
+		if _, err := client.GetStoragePool(poolName); err != nil {
+			return fmt.Errorf("get storage pool %s: %w", poolName, err)
+		}
+		return dryRunOperation(ctx, "DeleteStoragePool")
+	}
+
 	// 调用 libvirt API 删除存储池
 	if err := client.DeleteStoragePool(poolName, deleteVolumes); err != nil {
 		return fmt.Errorf("delete storage pool %s: %w", poolName, err)
 	}
+	s.changes.Record(entity.ResourceTypeStoragePool, nodeName, poolName, entity.ResourceChangeDeleted)
 
 	return nil
 }
@@ -143,10 +166,18 @@ func (s *StoragePoolService) StartStoragePool(ctx context.Context, nodeName, poo
 		return nil, fmt.Errorf("get libvirt client: %w", err)
 	}
 
+	if isDryRun(ctx) {
+		if _, err := client.GetStoragePool(poolName); err != nil {
+			return nil, fmt.Errorf("get storage pool %s: %w", poolName, err)
+		}
+		return nil, dryRunOperation(ctx, "StartStoragePool")
+	}
+
 	// 调用 libvirt API 启动存储池
 	if err := client.StartStoragePool(poolName); err != nil {
 		return nil, fmt.Errorf("start storage pool %s: %w", poolName, err)
 	}
+	s.changes.Record(entity.ResourceTypeStoragePool, nodeName, poolName, entity.ResourceChangeModified)
 
 	// 返回更新后的存储池信息
 	return s.DescribeStoragePool(ctx, nodeName, poolName)
@@ -160,10 +191,18 @@ func (s *StoragePoolService) StopStoragePool(ctx context.Context, nodeName, pool
 		return nil, fmt.Errorf("get libvirt client: %w", err)
 	}
 
+	if isDryRun(ctx) {
+		if _, err := client.GetStoragePool(poolName); err != nil {
+			return nil, fmt.Errorf("get storage pool %s: %w", poolName, err)
+		}
+		return nil, dryRunOperation(ctx, "StopStoragePool")
+	}
+
 	// 调用 libvirt API 停止存储池
 	if err := client.StopStoragePool(poolName); err != nil {
 		return nil, fmt.Errorf("stop storage pool %s: %w", poolName, err)
 	}
+	s.changes.Record(entity.ResourceTypeStoragePool, nodeName, poolName, entity.ResourceChangeModified)
 
 	// 返回更新后的存储池信息
 	return s.DescribeStoragePool(ctx, nodeName, poolName)
@@ -177,10 +216,18 @@ func (s *StoragePoolService) RefreshStoragePool(ctx context.Context, nodeName, p
 		return nil, fmt.Errorf("get libvirt client: %w", err)
 	}
 
+	if isDryRun(ctx) {
+		if _, err := client.GetStoragePool(poolName); err != nil {
+			return nil, fmt.Errorf("get storage pool %s: %w", poolName, err)
+		}
+		return nil, dryRunOperation(ctx, "RefreshStoragePool")
+	}
+
 	// 调用 libvirt API 刷新存储池
 	if err := client.RefreshStoragePool(poolName); err != nil {
 		return nil, fmt.Errorf("refresh storage pool %s: %w", poolName, err)
 	}
+	s.changes.Record(entity.ResourceTypeStoragePool, nodeName, poolName, entity.ResourceChangeModified)
 
 	// 返回更新后的存储池信息
 	return s.DescribeStoragePool(ctx, nodeName, poolName)
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/rs/zerolog"
+)
+
+// StorageScanService 定期刷新各节点的存储池并与上一次的卷列表比较，发现在节点上手工放置或删除的文件
+// libvirt 不会感知存储池目录中的文件变化，也没有卷级别的事件，只能通过刷新存储池后比较卷列表实现
+// 发现的变化记录到资源变化流；手工放入 _templates_ 目录的镜像文件在大小稳定后自动注册为 public 模板
+type StorageScanService struct {
+	nodeService *NodeService
+	templates   *TemplateService
+	changes     *ChangeFeed
+	interval    time.Duration
+
+	mu    sync.Mutex
+	pools map[string]*poolScan // key: <node>/<pool>，进程内保存，重启后第一次扫描重新建立基线
+}
+
+// poolScan 存储池最近一次扫描的结果
+type poolScan struct {
+	revision      uint64            // 扫描结束时资源变化流的 revision，之后由 JVP 自身记录的变化不重复上报
+	volumes       map[string]string // 卷路径 -> 卷名称
+	templateFiles map[string]int64  // _templates_ 目录中尚未注册为模板的镜像文件 -> 文件大小
+}
+
+// NewStorageScanService 创建存储池扫描服务，interval 为 0 时不注册定时任务
+func NewStorageScanService(nodeService *NodeService, templates *TemplateService, changes *ChangeFeed, interval time.Duration) *StorageScanService {
+	return &StorageScanService{
+		nodeService: nodeService,
+		templates:   templates,
+		changes:     changes,
+		interval:    interval,
+		pools:       make(map[string]*poolScan),
+	}
+}
+
+// RegisterScheduledTasks 向调度器注册存储池扫描任务，错过的触发直接跳过
+func (s *StorageScanService) RegisterScheduledTasks(scheduler *Scheduler) error {
+	if s.interval <= 0 {
+		return nil
+	}
+	return scheduler.Register(ScheduledTaskSpec{
+		Name:        "storage-pool-scan",
+		Description: "刷新各节点的存储池，发现手工放置或删除的卷，并把放入 _templates_ 目录的镜像注册为模板",
+		Schedule:    fmt.Sprintf("@every %s", s.interval),
+		Run:         s.ScanStoragePools,
+	})
+}
+
+// ScanStoragePools 扫描所有在线节点上运行中的存储池，单个节点或存储池失败不影响其他存储池
+func (s *StorageScanService) ScanStoragePools(ctx context.Context, now time.Time) error {
+	logger := zerolog.Ctx(ctx)
+
+	nodes, err := s.nodeService.ListNodes(ctx)
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, node := range nodes {
+		if node.State != entity.NodeStateOnline {
+			continue
+		}
+		client, err := s.nodeService.storage.GetConnection(node.Name)
+		if err != nil {
+			failed = append(failed, node.Name)
+			continue
+		}
+		pools, err := client.ListStoragePools()
+		if err != nil {
+			logger.Warn().Err(err).Str("node", node.Name).Msg("Failed to list storage pools for scan")
+			failed = append(failed, node.Name)
+			continue
+		}
+		for _, pool := range pools {
+			if pool.State != "Active" {
+				continue
+			}
+			if err := s.scanPool(ctx, client, node.Name, pool.Name); err != nil {
+				logger.Warn().
+					Err(err).
+					Str("node", node.Name).
+					Str("pool", pool.Name).
+					Msg("Failed to scan storage pool")
+				failed = append(failed, node.Name+"/"+pool.Name)
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("scan failed on %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// scanPool 刷新存储池并与上一次扫描结果比较，第一次扫描只建立基线
+func (s *StorageScanService) scanPool(ctx context.Context, client libvirt.LibvirtClient, nodeName, poolName string) error {
+	logger := zerolog.Ctx(ctx)
+
+	if err := client.RefreshStoragePool(poolName); err != nil {
+		return fmt.Errorf("refresh storage pool: %w", err)
+	}
+	volInfos, err := client.ListVolumes(poolName)
+	if err != nil {
+		return fmt.Errorf("list volumes: %w", err)
+	}
+	volumes := make(map[string]string, len(volInfos))
+	for _, volInfo := range volInfos {
+		if scannableVolume(volInfo) {
+			volumes[volInfo.Path] = volInfo.Name
+		}
+	}
+
+	key := nodeName + "/" + poolName
+	s.mu.Lock()
+	previous := s.pools[key]
+	s.mu.Unlock()
+
+	templateFiles := s.scanTemplateFiles(ctx, client, nodeName, poolName, previous)
+	if previous == nil {
+		s.savePoolScan(key, volumes, templateFiles)
+		return nil
+	}
+
+	var added, deleted []string
+	for path, name := range volumes {
+		if _, ok := previous.volumes[path]; ok {
+			continue
+		}
+		volumeID := volumeIDFromName(name)
+		if s.changes.ChangedSince(entity.ResourceTypeVolume, nodeName, volumeID, previous.revision) {
+			continue
+		}
+		s.changes.Record(entity.ResourceTypeVolume, nodeName, volumeID, entity.ResourceChangeAdded)
+		added = append(added, name)
+	}
+	for path, name := range previous.volumes {
+		if _, ok := volumes[path]; ok {
+			continue
+		}
+		volumeID := volumeIDFromName(name)
+		if s.changes.ChangedSince(entity.ResourceTypeVolume, nodeName, volumeID, previous.revision) {
+			continue
+		}
+		s.changes.Record(entity.ResourceTypeVolume, nodeName, volumeID, entity.ResourceChangeDeleted)
+		deleted = append(deleted, name)
+	}
+	if len(added) > 0 || len(deleted) > 0 {
+		s.changes.Record(entity.ResourceTypeStoragePool, nodeName, poolName, entity.ResourceChangeModified)
+		logger.Info().
+			Str("node", nodeName).
+			Str("pool", poolName).
+			Strs("added", added).
+			Strs("deleted", deleted).
+			Msg("Storage pool volumes changed outside JVP")
+	}
+	s.savePoolScan(key, volumes, templateFiles)
+	return nil
+}
+
+// savePoolScan 保存扫描结果，revision 取保存时的值，包含本次扫描自身记录的变化
+func (s *StorageScanService) savePoolScan(key string, volumes map[string]string, templateFiles map[string]int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pools[key] = &poolScan{
+		revision:      s.changes.Revision(),
+		volumes:       volumes,
+		templateFiles: templateFiles,
+	}
+}
+
+// scanTemplateFiles 列出 _templates_ 目录中尚未注册的镜像文件，与上一次扫描大小相同（复制已完成）的文件注册为模板
+// 返回本次仍未注册的文件，供下一次扫描比较；列举失败时返回上一次的结果
+func (s *StorageScanService) scanTemplateFiles(ctx context.Context, client libvirt.LibvirtClient, nodeName, poolName string, previous *poolScan) map[string]int64 {
+	logger := zerolog.Ctx(ctx).With().
+		Str("node", nodeName).
+		Str("pool", poolName).
+		Logger()
+
+	var last map[string]int64
+	if previous != nil {
+		last = previous.templateFiles
+	}
+	pool, err := client.GetStoragePool(poolName)
+	if err != nil || pool.Path == "" {
+		return last
+	}
+	templatesDir := filepath.Join(pool.Path, TemplatesDirName)
+	output, err := runNodeCommand(ctx, client, fmt.Sprintf("test -d %s || exit 0; find %s -maxdepth 1 -type f -printf '%%s %%f\\n'", shellx.Quote(templatesDir), shellx.Quote(templatesDir)))
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to list template directory")
+		return last
+	}
+
+	registered := make(map[string]bool)
+	templates, err := s.templates.store.List(ctx, nodeName, poolName)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to list templates")
+		return last
+	}
+	for _, template := range templates {
+		registered[template.Path] = true
+	}
+
+	files := make(map[string]int64)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		sizeStr, name, ok := strings.Cut(line, " ")
+		if !ok || !isTemplateImageFile(name) || registered[filepath.Join(templatesDir, name)] {
+			continue
+		}
+		size, err := strconv.ParseInt(sizeStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		if lastSize, ok := last[name]; !ok || lastSize != size {
+			// 新出现或仍在复制中，等下一次扫描确认大小稳定
+			files[name] = size
+			continue
+		}
+
+		template, err := s.templates.registerTemplateFromVolume(ctx, &entity.RegisterTemplateRequest{
+			NodeName:    nodeName,
+			PoolName:    poolName,
+			VolumeName:  name,
+			Name:        volumeIDFromName(name),
+			Description: "Discovered by storage pool scan",
+			Visibility:  entity.TemplateVisibilityPublic,
+		}, client, nodeName, "")
+		if err != nil {
+			logger.Warn().Err(err).Str("file", name).Msg("Failed to register discovered image as template")
+			files[name] = size
+			continue
+		}
+		logger.Info().
+			Str("file", name).
+			Str("template_id", template.ID).
+			Msg("Discovered image registered as template")
+	}
+	return files
+}
+
+// isTemplateImageFile 是否为可以注册为模板的磁盘镜像文件
+func isTemplateImageFile(name string) bool {
+	switch filepath.Ext(name) {
+	case ".qcow2", ".raw", ".img":
+		return true
+	default:
+		return false
+	}
+}
+
+// scannableVolume 是否参与扫描比较：跳过 JVP 内部目录（模板、快照、回收站、导入导出、模板缓存）以及 cloud-init、Ignition 卷
+func scannableVolume(volInfo *libvirt.VolumeInfo) bool {
+	for _, dir := range []string{TemplatesDirName, SnapshotsDirName, RecycleDirName, ExportsDirName, ImportsDirName, TemplateCacheDirName} {
+		if volInfo.Name == dir || strings.Contains(volInfo.Path, "/"+dir+"/") {
+			return false
+		}
+	}
+	return !libvirt.IsCloudInitVolume(volInfo.Name) && !libvirt.IsIgnitionVolume(volInfo.Name)
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+)
+
+// TagService 实例、卷与模板的统一标签接口，标签即资源的 labels
+// 实例标签同时写入 libvirt 域 metadata，数据目录丢失后仍可恢复；卷标签按文件路径记录在数据目录；模板标签保存在存储池的模板元数据中
+type TagService struct {
+	instanceService *InstanceService
+	volumeService   *VolumeService
+	templateService *TemplateService
+}
+
+// NewTagService 创建标签服务
+func NewTagService(instanceService *InstanceService, volumeService *VolumeService, templateService *TemplateService) *TagService {
+	return &TagService{
+		instanceService: instanceService,
+		volumeService:   volumeService,
+		templateService: templateService,
+	}
+}
+
+// taggedResource 已加载当前标签的资源，save 整体替换其标签
+type taggedResource struct {
+	id     string
+	labels map[string]string
+	save   func(labels map[string]string) error
+}
+
+// CreateTags 为资源添加标签，已存在的 key 覆盖 value；先校验全部资源再写入，任一资源不存在时不做修改
+func (s *TagService) CreateTags(ctx context.Context, req *entity.CreateTagsRequest) (*entity.CreateTagsResponse, error) {
+	if len(req.ResourceIDs) == 0 {
+		return nil, invalidParameterError("resource_ids")
+	}
+	if len(req.Tags) == 0 {
+		return nil, invalidParameterError("tags")
+	}
+	resources, err := s.loadResources(ctx, req.NodeName, req.PoolName, req.ResourceType, req.ResourceIDs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make([]map[string]string, len(resources))
+	for i, resource := range resources {
+		labels := maps.Clone(resource.labels)
+		if labels == nil {
+			labels = make(map[string]string, len(req.Tags))
+		}
+		for _, tag := range req.Tags {
+			labels[tag.Key] = tag.Value
+		}
+		if err := validateLabels(labels); err != nil {
+			return nil, err
+		}
+		updated[i] = labels
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CreateTags")
+	}
+	if err := saveTaggedResources(resources, updated); err != nil {
+		return nil, err
+	}
+	return &entity.CreateTagsResponse{Return: true}, nil
+}
+
+// DeleteTags 删除资源标签：tag 的 value 为空时不论值删除该 key，否则只在值相同时删除；未指定 tags 时删除全部标签
+func (s *TagService) DeleteTags(ctx context.Context, req *entity.DeleteTagsRequest) (*entity.DeleteTagsResponse, error) {
+	if len(req.ResourceIDs) == 0 {
+		return nil, invalidParameterError("resource_ids")
+	}
+	resources, err := s.loadResources(ctx, req.NodeName, req.PoolName, req.ResourceType, req.ResourceIDs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make([]map[string]string, len(resources))
+	for i, resource := range resources {
+		labels := maps.Clone(resource.labels)
+		if len(req.Tags) == 0 {
+			labels = nil
+		}
+		for _, tag := range req.Tags {
+			if value, ok := labels[tag.Key]; ok && (tag.Value == "" || tag.Value == value) {
+				delete(labels, tag.Key)
+			}
+		}
+		updated[i] = labels
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "DeleteTags")
+	}
+	if err := saveTaggedResources(resources, updated); err != nil {
+		return nil, err
+	}
+	return &entity.DeleteTagsResponse{Return: true}, nil
+}
+
+// DescribeTags 查询资源标签，按资源 ID、key 排序返回
+func (s *TagService) DescribeTags(ctx context.Context, req *entity.DescribeTagsRequest) (*entity.DescribeTagsResponse, error) {
+	var resources []taggedResource
+	var err error
+	if len(req.ResourceIDs) > 0 {
+		resources, err = s.loadResources(ctx, req.NodeName, req.PoolName, req.ResourceType, req.ResourceIDs, false)
+	} else {
+		resources, err = s.listResources(ctx, req.NodeName, req.PoolName, req.ResourceType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]entity.TagDescription, 0)
+	for _, resource := range resources {
+		for _, key := range slices.Sorted(maps.Keys(resource.labels)) {
+			tag := entity.TagDescription{
+				ResourceType: req.ResourceType,
+				ResourceID:   resource.id,
+				Key:          key,
+				Value:        resource.labels[key],
+			}
+			if matchTagDescription(tag, req.Filters) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	slices.SortStableFunc(tags, func(a, b entity.TagDescription) int {
+		return strings.Compare(a.ResourceID, b.ResourceID)
+	})
+	return &entity.DescribeTagsResponse{Tags: tags}, nil
+}
+
+// matchTagDescription 标签是否满足 key、value 过滤器
+func matchTagDescription(tag entity.TagDescription, filters []entity.Filter) bool {
+	for _, filter := range filters {
+		switch filter.Name {
+		case "key":
+			if !slices.Contains(filter.Values, tag.Key) {
+				return false
+			}
+		case "value":
+			if !slices.Contains(filter.Values, tag.Value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// saveTaggedResources 逐个写回资源标签
+func saveTaggedResources(resources []taggedResource, updated []map[string]string) error {
+	for i, resource := range resources {
+		if maps.Equal(resource.labels, updated[i]) {
+			continue
+		}
+		if err := resource.save(updated[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTagTarget 校验资源类型，卷与模板需要指定存储池
+func validateTagTarget(nodeName, poolName, resourceType string) error {
+	if nodeName == "" {
+		return invalidParameterError("node_name")
+	}
+	switch resourceType {
+	case entity.ResourceTypeInstance:
+		return nil
+	case entity.ResourceTypeVolume, entity.ResourceTypeTemplate:
+		if poolName == "" {
+			return invalidParameterError("pool_name")
+		}
+		return nil
+	default:
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported resource_type %q, must be %s, %s or %s", resourceType,
+				entity.ResourceTypeInstance, entity.ResourceTypeVolume, entity.ResourceTypeTemplate),
+			http.StatusBadRequest,
+		)
+	}
+}
+
+// loadResources 按 ID 加载资源及其当前标签，任一资源不存在时返回错误；forWrite 时同时校验修改权限
+func (s *TagService) loadResources(ctx context.Context, nodeName, poolName, resourceType string, ids []string, forWrite bool) ([]taggedResource, error) {
+	if err := validateTagTarget(nodeName, poolName, resourceType); err != nil {
+		return nil, err
+	}
+	resources := make([]taggedResource, 0, len(ids))
+	for _, id := range slices.Compact(slices.Sorted(slices.Values(ids))) {
+		var resource *taggedResource
+		var err error
+		switch resourceType {
+		case entity.ResourceTypeInstance:
+			resource, err = s.instanceResource(ctx, nodeName, id)
+		case entity.ResourceTypeVolume:
+			resource, err = s.volumeResource(ctx, nodeName, poolName, id)
+		case entity.ResourceTypeTemplate:
+			resource, err = s.templateResource(ctx, nodeName, poolName, id, forWrite)
+		}
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, *resource)
+	}
+	return resources, nil
+}
+
+// listResources 列出节点（卷与模板为存储池）上某类资源的标签，只用于查询
+func (s *TagService) listResources(ctx context.Context, nodeName, poolName, resourceType string) ([]taggedResource, error) {
+	if err := validateTagTarget(nodeName, poolName, resourceType); err != nil {
+		return nil, err
+	}
+	var resources []taggedResource
+	switch resourceType {
+	case entity.ResourceTypeInstance:
+		instances, err := s.instanceService.DescribeInstances(ctx, &entity.DescribeInstancesRequest{NodeName: nodeName})
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range instances {
+			resources = append(resources, taggedResource{id: instance.ID, labels: instance.Labels})
+		}
+	case entity.ResourceTypeVolume:
+		volumes, err := s.volumeService.ListVolumes(ctx, &entity.ListVolumesRequest{NodeName: nodeName, PoolName: poolName})
+		if err != nil {
+			return nil, err
+		}
+		for _, volume := range volumes {
+			resources = append(resources, taggedResource{id: volume.ID, labels: volume.Labels})
+		}
+	case entity.ResourceTypeTemplate:
+		templates, err := s.templateService.ListTemplates(ctx, &entity.ListTemplatesRequest{NodeName: nodeName, PoolName: poolName})
+		if err != nil {
+			return nil, err
+		}
+		for _, template := range templates {
+			resources = append(resources, taggedResource{id: template.ID, labels: template.Labels})
+		}
+	}
+	return resources, nil
+}
+
+// instanceResource 加载实例标签，写入时同步到域 metadata 并记录实例事件
+func (s *TagService) instanceResource(ctx context.Context, nodeName, instanceID string) (*taggedResource, error) {
+	client, err := s.instanceService.nodeProvider.GetNodeStorage(ctx, nodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node connection", err)
+	}
+	// 回收站中的实例不对外展示，与不存在一样处理
+	if _, err := client.GetDomainByName(instanceID); err != nil || isRecycledDomain(instanceID) {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Instance %s not found", instanceID),
+			http.StatusNotFound,
+		)
+	}
+	instance, err := s.instanceService.GetInstance(ctx, nodeName, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	return &taggedResource{
+		id:     instanceID,
+		labels: instance.Labels,
+		save: func(labels map[string]string) error {
+			if err := s.instanceService.setInstanceLabels(client, nodeName, instanceID, labels); err != nil {
+				return err
+			}
+			s.instanceService.recordEvent(ctx, nodeName, instanceID, entity.InstanceEventModified, "", "tags")
+			return nil
+		},
+	}, nil
+}
+
+// volumeResource 加载卷标签，卷标签按文件路径记录
+func (s *TagService) volumeResource(ctx context.Context, nodeName, poolName, volumeID string) (*taggedResource, error) {
+	client, err := s.volumeService.nodeService.GetNodeStorage(ctx, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("get node storage: %w", err)
+	}
+	volInfo, err := findPoolVolume(client, poolName, volumeID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Volume %s not found in pool %s", volumeID, poolName),
+			http.StatusNotFound,
+		)
+	}
+	labels, err := s.volumeService.labels.Resources(nodeName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to load volume labels", err)
+	}
+	return &taggedResource{
+		id:     volumeID,
+		labels: labels[volInfo.Path],
+		save: func(labels map[string]string) error {
+			if err := s.volumeService.labels.Set(nodeName, volInfo.Path, labels); err != nil {
+				return apierror.WrapError(apierror.ErrInternalError, "Failed to modify volume labels", err)
+			}
+			s.volumeService.changes.Record(entity.ResourceTypeVolume, nodeName, volumeIDFromName(volInfo.Name), entity.ResourceChangeModified)
+			return nil
+		},
+	}, nil
+}
+
+// templateResource 加载模板标签，只有模板所属租户或管理员可以修改
+func (s *TagService) templateResource(ctx context.Context, nodeName, poolName, templateID string, forWrite bool) (*taggedResource, error) {
+	nodeName = normalizeNodeName(nodeName)
+	template, err := s.templateService.getVisibleTemplate(ctx, nodeName, poolName, templateID)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, apierror.NewErrorWithStatus(
+				"Template.NotFound",
+				fmt.Sprintf("template %s not found on node %s pool %s", templateID, nodeName, poolName),
+				http.StatusNotFound,
+			)
+		}
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to load template metadata", err)
+	}
+	if forWrite {
+		if err := checkTemplateOwner(ctx, template); err != nil {
+			return nil, err
+		}
+	}
+	return &taggedResource{
+		id:     templateID,
+		labels: template.Labels,
+		save: func(labels map[string]string) error {
+			template.Labels = labels
+			template.UpdatedAt = time.Now().UTC()
+			if err := s.templateService.store.Save(ctx, template); err != nil {
+				return apierror.WrapError(apierror.ErrInternalError, "Failed to update template metadata", err)
+			}
+			s.templateService.changes.Record(entity.ResourceTypeTemplate, nodeName, template.ID, entity.ResourceChangeModified)
+			return nil
+		},
+	}, nil
+}
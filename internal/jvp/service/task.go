@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+)
+
+// TaskService 长任务的统一取消入口：模板下载、V2V 迁移与磁盘 block job
+// 取消会传播到底层：下载与 V2V 终止节点上的进程并删除未完成的文件，磁盘 block job 执行 blockjob abort
+type TaskService struct {
+	templateService *TemplateService
+	instanceService *InstanceService
+}
+
+// NewTaskService 创建任务服务
+func NewTaskService(templateService *TemplateService, instanceService *InstanceService) *TaskService {
+	return &TaskService{
+		templateService: templateService,
+		instanceService: instanceService,
+	}
+}
+
+// CancelTask 取消 pending 或 running 的长任务，按 task_id 前缀区分下载（task-）与 V2V（v2v-）任务，
+// 未指定 task_id 时取消 node_name、instance_id 与 device 指定的磁盘 block job
+func (s *TaskService) CancelTask(ctx context.Context, req *entity.CancelTaskRequest) (*entity.CancelTaskResponse, error) {
+	switch {
+	case strings.HasPrefix(req.TaskID, "task-"):
+		task, err := s.templateService.CancelDownloadTask(ctx, req.TaskID)
+		if err != nil {
+			return nil, err
+		}
+		return &entity.CancelTaskResponse{
+			TaskID:   task.ID,
+			Type:     entity.TaskTypeDownload,
+			NodeName: task.NodeName,
+			Status:   string(task.Status),
+		}, nil
+	case strings.HasPrefix(req.TaskID, "v2v-"):
+		task, err := s.instanceService.CancelV2VTask(ctx, req.TaskID)
+		if err != nil {
+			return nil, err
+		}
+		return &entity.CancelTaskResponse{
+			TaskID:     task.ID,
+			Type:       entity.TaskTypeV2V,
+			NodeName:   task.NodeName,
+			InstanceID: task.InstanceID,
+			Status:     task.Status,
+		}, nil
+	case req.TaskID != "":
+		return nil, apierror.NewErrorWithStatus("ResourceNotFound", fmt.Sprintf("Task %s not found", req.TaskID), http.StatusNotFound)
+	case req.NodeName == "" || req.InstanceID == "" || req.Device == "":
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameter",
+			"task_id, or node_name, instance_id and device of a disk job, is required",
+			http.StatusBadRequest,
+		)
+	}
+
+	jobType, err := s.instanceService.CancelDiskJob(ctx, req.NodeName, req.InstanceID, req.Device)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.CancelTaskResponse{
+		Type:       entity.TaskTypeDiskJob,
+		NodeName:   req.NodeName,
+		InstanceID: req.InstanceID,
+		Device:     req.Device,
+		JobType:    jobType,
+		Status:     entity.TaskStatusCancelled,
+	}, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/rs/zerolog"
+)
+
+// TempSpaceManager 节点上镜像转换临时空间的统一管理
+// virt-v2v 转换、OVA 解包等操作会产生与镜像等大的临时文件，执行前在节点上预检剩余空间并按配额登记占用，
+// 结束时（无论成功、失败还是被取消）强制删除临时目录
+type TempSpaceManager struct {
+	mu     sync.Mutex
+	dir    string                       // 节点上的临时目录，为空时使用操作所在存储池的 _imports_ 目录
+	quotaB uint64                       // 每节点同时占用的临时空间上限（字节），0 表示不限制
+	used   map[string]map[string]uint64 // key: nodeName -> 临时目录 -> 登记的字节数
+}
+
+// NewTempSpaceManager 创建临时空间管理器，dir 为空时使用存储池的 _imports_ 目录，quotaGB 为 0 时不限制
+func NewTempSpaceManager(dir string, quotaGB uint64) *TempSpaceManager {
+	return &TempSpaceManager{
+		dir:    dir,
+		quotaB: quotaGB << 30,
+		used:   make(map[string]map[string]uint64),
+	}
+}
+
+// WorkDir 返回操作的临时目录：配置了临时目录时为 <dir>/<id>，否则为 <poolDir>/<id>
+func (m *TempSpaceManager) WorkDir(poolDir, id string) string {
+	if m.dir != "" {
+		return filepath.Join(m.dir, id)
+	}
+	return filepath.Join(poolDir, id)
+}
+
+// Check 预检节点上是否能为操作提供 sizeB 字节的临时空间，不登记占用
+func (m *TempSpaceManager) Check(ctx context.Context, client libvirt.RemoteManager, nodeName, workDir string, sizeB uint64) error {
+	if err := m.checkQuota(nodeName, workDir, sizeB); err != nil {
+		return err
+	}
+	return checkTempSpaceAvailable(ctx, client, nodeName, workDir, sizeB)
+}
+
+// Acquire 创建临时目录并登记 sizeB 字节的占用，超出配额或节点剩余空间不足时返回 InsufficientCapacity
+// 返回的 release 必须在操作结束后调用，删除临时目录并归还配额
+func (m *TempSpaceManager) Acquire(ctx context.Context, client libvirt.RemoteManager, nodeName, workDir string, sizeB uint64) (func(), error) {
+	m.mu.Lock()
+	if err := m.checkQuotaLocked(nodeName, workDir, sizeB); err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	if m.used[nodeName] == nil {
+		m.used[nodeName] = make(map[string]uint64)
+	}
+	m.used[nodeName][workDir] = sizeB
+	m.mu.Unlock()
+
+	release := sync.OnceFunc(func() {
+		// 操作可能因 ctx 取消而结束，清理不能随之取消
+		if _, err := runNodeCommand(context.WithoutCancel(ctx), client, "rm -rf "+shellx.Quote(workDir)); err != nil {
+			zerolog.Ctx(ctx).Warn().
+				Err(err).
+				Str("node_name", nodeName).
+				Str("work_dir", workDir).
+				Msg("Failed to remove temporary directory")
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.used[nodeName], workDir)
+	})
+
+	if _, err := runNodeCommand(ctx, client, "mkdir -p "+shellx.Quote(workDir)); err != nil {
+		release()
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to create temporary directory", err)
+	}
+	if err := checkTempSpaceAvailable(ctx, client, nodeName, workDir, sizeB); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// checkQuota 检查登记 sizeB 字节后是否超出节点配额
+func (m *TempSpaceManager) checkQuota(nodeName, workDir string, sizeB uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.checkQuotaLocked(nodeName, workDir, sizeB)
+}
+
+// checkQuotaLocked 同 checkQuota，调用方需持有锁
+func (m *TempSpaceManager) checkQuotaLocked(nodeName, workDir string, sizeB uint64) error {
+	if _, exists := m.used[nodeName][workDir]; exists {
+		return apierror.NewErrorWithStatus(
+			"ConcurrentModification",
+			fmt.Sprintf("Temporary directory %s on node %s is in use by another operation", workDir, nodeName),
+			http.StatusConflict,
+		)
+	}
+	if m.quotaB == 0 {
+		return nil
+	}
+	if sizeB > m.quotaB {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("The conversion requires %d bytes of temporary space, more than the %d bytes quota per node (JVP_TEMP_QUOTA_GB)", sizeB, m.quotaB),
+			http.StatusBadRequest,
+		)
+	}
+	var usedB uint64
+	for _, size := range m.used[nodeName] {
+		usedB += size
+	}
+	if usedB+sizeB > m.quotaB {
+		return apierror.WrapError(
+			apierror.ErrInsufficientCapacity,
+			fmt.Sprintf("Temporary space quota on node %s exceeded: %d of %d bytes in use by running conversions, %d bytes required, retry after they finish",
+				nodeName, usedB, m.quotaB, sizeB),
+			nil,
+		)
+	}
+	return nil
+}
+
+// checkTempSpaceAvailable 检查节点上 dir 所在文件系统的剩余空间，dir 不存在时检查其最近的已存在上级目录
+func checkTempSpaceAvailable(ctx context.Context, client libvirt.RemoteManager, nodeName, dir string, sizeB uint64) error {
+	if sizeB == 0 {
+		return nil
+	}
+	// df 要求路径存在，临时目录尚未创建时向上查找
+	command := fmt.Sprintf(`d=%s; while [ ! -e "$d" ]; do d=$(dirname "$d"); done; df -B1 --output=avail "$d" | tail -n 1`, shellx.Quote(dir))
+	output, err := runNodeCommand(ctx, client, command)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to check temporary space", err)
+	}
+	availableB, err := strconv.ParseUint(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to parse df output", err)
+	}
+	if availableB < sizeB {
+		return apierror.WrapError(
+			apierror.ErrInsufficientCapacity,
+			fmt.Sprintf("Not enough temporary space on node %s: %s has %d bytes available, %d bytes required for the conversion",
+				nodeName, dir, availableB, sizeB),
+			nil,
+		)
+	}
+	return nil
+}
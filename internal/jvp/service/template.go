@@ -7,9 +7,11 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 	"time"
 
+	libvirtlib "github.com/digitalocean/go-libvirt"
 	"github.com/jimyag/jvp/internal/jvp/entity"
 	"github.com/jimyag/jvp/pkg/apierror"
 	"github.com/jimyag/jvp/pkg/idgen"
@@ -26,15 +28,22 @@ type TemplateService struct {
 	store           *TemplateStore
 	idGen           *idgen.Generator
 	downloadManager *DownloadTaskManager
+	transfer        *TransferLimiter
+	changes         *ChangeFeed
+	cache           templateCache
 }
 
 // NewTemplateService 创建新的 TemplateService
-func NewTemplateService(nodeStorageFn NodeStorageGetter, store *TemplateStore) *TemplateService {
+// cacheMaxGB 为每个存储池模板缓存目录的容量上限（GB），0 表示不限制
+func NewTemplateService(nodeStorageFn NodeStorageGetter, store *TemplateStore, transfer *TransferLimiter, operations *OperationLimiter, changes *ChangeFeed, cacheMaxGB uint64) *TemplateService {
 	return &TemplateService{
 		nodeStorageFn:   nodeStorageFn,
 		store:           store,
-		idGen:           idgen.New(),
-		downloadManager: NewDownloadTaskManager(),
+		transfer:        transfer,
+		changes:         changes,
+		cache:           templateCache{maxBytes: cacheMaxGB * 1024 * 1024 * 1024},
+		idGen:           idgen.DefaultGenerator().Namespace(idgen.NamespaceTemplate),
+		downloadManager: NewDownloadTaskManager(operations),
 	}
 }
 
@@ -62,6 +71,14 @@ func (s *TemplateService) RegisterTemplate(ctx context.Context, req *entity.Regi
 	if req.Name == "" {
 		return nil, invalidParameterError("name")
 	}
+	if err := validateTemplateVisibility(req.Visibility, req.SharedWith); err != nil {
+		return nil, err
+	}
+	if err := validateTemplateMetadata(req.OS, req.Requirements); err != nil {
+		return nil, err
+	}
+	// 异步下载完成时请求已结束，所属租户需要提前从请求中取出
+	owner := tenantFromContext(ctx)
 
 	nodeName := normalizeNodeName(req.NodeName)
 	client, err := s.getNodeClient(ctx, nodeName)
@@ -69,6 +86,17 @@ func (s *TemplateService) RegisterTemplate(ctx context.Context, req *entity.Regi
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get node storage", err)
 	}
 
+	if isDryRun(ctx) {
+		if req.Source != nil && req.Source.Type == "url" && req.Source.URL != "" {
+			if _, err := client.GetStoragePool(req.PoolName); err != nil {
+				return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get storage pool info", err)
+			}
+		} else if _, err := s.lookupVolume(client, req.PoolName, req.VolumeName); err != nil {
+			return nil, err
+		}
+		return nil, dryRunOperation(ctx, "RegisterTemplate")
+	}
+
 	// 如果 Source.Type 为 "url"，启动异步下载
 	if req.Source != nil && req.Source.Type == "url" && req.Source.URL != "" {
 		// 检查是否已有下载任务
@@ -93,7 +121,7 @@ func (s *TemplateService) RegisterTemplate(ctx context.Context, req *entity.Regi
 		taskID := fmt.Sprintf("task-%d", taskIDNum)
 
 		// 创建下载任务
-		task, isNew := s.downloadManager.CreateTask(taskID, nodeName, req.PoolName, req.VolumeName, req.Source.URL)
+		task, isNew := s.downloadManager.CreateTask(taskID, nodeName, req.PoolName, req.VolumeName, req.Source.URL, s.transfer.resolve(req.BandwidthMiB))
 
 		if !isNew {
 			// 任务已存在
@@ -117,6 +145,12 @@ func (s *TemplateService) RegisterTemplate(ctx context.Context, req *entity.Regi
 		// 保存请求信息以便下载完成后注册模板
 		reqCopy := *req
 		s.downloadManager.StartDownload(ctx, task, client, func(completedTask *DownloadTask, downloadErr error) {
+			if completedTask.Status == DownloadTaskStatusCancelled {
+				logger.Info().
+					Str("task_id", completedTask.ID).
+					Msg("Download cancelled, template not registered")
+				return
+			}
 			if downloadErr != nil {
 				logger.Error().
 					Err(downloadErr).
@@ -130,7 +164,7 @@ func (s *TemplateService) RegisterTemplate(ctx context.Context, req *entity.Regi
 				Str("task_id", completedTask.ID).
 				Msg("Download completed, registering template")
 
-			template, err := s.registerTemplateFromVolume(ctx, &reqCopy, client, nodeName)
+			template, err := s.registerTemplateFromVolume(ctx, &reqCopy, client, nodeName, owner)
 			if err != nil {
 				logger.Error().
 					Err(err).
@@ -152,7 +186,7 @@ func (s *TemplateService) RegisterTemplate(ctx context.Context, req *entity.Regi
 	}
 
 	// 同步注册（卷已存在）
-	template, err := s.registerTemplateFromVolume(ctx, req, client, nodeName)
+	template, err := s.registerTemplateFromVolume(ctx, req, client, nodeName, owner)
 	if err != nil {
 		return nil, err
 	}
@@ -164,7 +198,7 @@ func (s *TemplateService) RegisterTemplate(ctx context.Context, req *entity.Regi
 }
 
 // registerTemplateFromVolume 从已存在的卷注册模板
-func (s *TemplateService) registerTemplateFromVolume(ctx context.Context, req *entity.RegisterTemplateRequest, client libvirt.LibvirtClient, nodeName string) (*entity.Template, error) {
+func (s *TemplateService) registerTemplateFromVolume(ctx context.Context, req *entity.RegisterTemplateRequest, client poolFileClient, nodeName, owner string) (*entity.Template, error) {
 	logger := zerolog.Ctx(ctx)
 
 	volumeInfo, err := s.lookupVolume(client, req.PoolName, req.VolumeName)
@@ -177,6 +211,11 @@ func (s *TemplateService) registerTemplateFromVolume(ctx context.Context, req *e
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to generate template ID", err)
 	}
 
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = entity.TemplateVisibilityPrivate
+	}
+
 	now := time.Now().UTC()
 	template := &entity.Template{
 		ID:          templateID,
@@ -194,13 +233,19 @@ func (s *TemplateService) registerTemplateFromVolume(ctx context.Context, req *e
 		Features:    req.Features,
 		Usage:       entity.TemplateUsage{},
 		Tags:        cloneTags(req.Tags),
+		Owner:       owner,
+		Visibility:  visibility,
+		SharedWith:  cloneTags(req.SharedWith),
 		CreatedAt:   now,
 		UpdatedAt:   now,
+
+		Requirements: req.Requirements,
 	}
 
 	if err := s.store.Save(ctx, template); err != nil {
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to persist template metadata", err)
 	}
+	s.changes.Record(entity.ResourceTypeTemplate, template.NodeName, template.ID, entity.ResourceChangeAdded)
 
 	logger.Info().
 		Str("template_id", template.ID).
@@ -215,22 +260,89 @@ func (s *TemplateService) registerTemplateFromVolume(ctx context.Context, req *e
 func (s *TemplateService) GetDownloadTask(ctx context.Context, taskID string) (*DownloadTask, error) {
 	task := s.downloadManager.GetTask(taskID)
 	if task == nil {
-		return nil, apierror.NewErrorWithStatus("NotFound", "Download task not found", http.StatusNotFound)
+		return nil, apierror.NewErrorWithStatus("ResourceNotFound", "Download task not found", http.StatusNotFound)
 	}
 	return task, nil
 }
 
+// CancelDownloadTask 取消 pending 或 running 的下载任务：终止下载进程并删除未完成的文件，已取消的任务重复取消直接返回
+func (s *TemplateService) CancelDownloadTask(ctx context.Context, taskID string) (*DownloadTask, error) {
+	task := s.downloadManager.GetTask(taskID)
+	if task == nil {
+		return nil, apierror.NewErrorWithStatus("ResourceNotFound", "Download task not found", http.StatusNotFound)
+	}
+	if task.Status == DownloadTaskStatusCancelled {
+		return task, nil
+	}
+	if task.Status == DownloadTaskStatusCompleted || task.Status == DownloadTaskStatusFailed {
+		return nil, apierror.NewErrorWithStatus(
+			"IncorrectTaskState",
+			fmt.Sprintf("Download task %s has already finished with status %s", taskID, task.Status),
+			http.StatusConflict,
+		)
+	}
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CancelDownloadTask")
+	}
+
+	task = s.downloadManager.Cancel(taskID)
+	if task == nil || task.Status != DownloadTaskStatusCancelled {
+		return nil, apierror.NewErrorWithStatus(
+			"IncorrectTaskState",
+			fmt.Sprintf("Download task %s has already finished", taskID),
+			http.StatusConflict,
+		)
+	}
+	zerolog.Ctx(ctx).Info().
+		Str("task_id", taskID).
+		Str("volume_name", task.VolumeName).
+		Msg("Download task cancelled")
+	return task, nil
+}
+
 // ListDownloadTasks 列出所有活跃的下载任务
 func (s *TemplateService) ListDownloadTasks(ctx context.Context) []*DownloadTask {
 	return s.downloadManager.ListActiveTasks()
 }
 
+// InterruptDownloads 服务关停时把未完成的下载任务标记为中断，并删除已下载的部分文件，避免之后被当作完整镜像注册
+func (s *TemplateService) InterruptDownloads(ctx context.Context) {
+	logger := zerolog.Ctx(ctx)
+
+	for _, task := range s.downloadManager.InterruptActiveTasks(ErrShuttingDown.Error()) {
+		logger.Warn().
+			Str("task_id", task.ID).
+			Str("node_name", task.NodeName).
+			Str("volume_name", task.VolumeName).
+			Msg("Download task interrupted by shutdown")
+
+		client, err := s.getNodeClient(ctx, task.NodeName)
+		if err != nil {
+			logger.Error().Err(err).Str("task_id", task.ID).Msg("Failed to get node client, partial download is kept")
+			continue
+		}
+		pool, err := client.GetStoragePool(task.PoolName)
+		if err != nil {
+			logger.Error().Err(err).Str("task_id", task.ID).Msg("Failed to get storage pool, partial download is kept")
+			continue
+		}
+		if err := removePoolFile(client, pool.Path+"/"+TemplatesDirName+"/"+task.VolumeName); err != nil {
+			logger.Error().Err(err).Str("task_id", task.ID).Msg("Failed to remove partial download")
+		}
+	}
+}
+
 // ListTemplates 列举模板
 func (s *TemplateService) ListTemplates(ctx context.Context, req *entity.ListTemplatesRequest) ([]entity.Template, error) {
 	if req == nil {
 		req = &entity.ListTemplatesRequest{}
 	}
 	nodeName := normalizeNodeName(req.NodeName)
+	if req.Visibility != "" {
+		if err := validateTemplateVisibility(req.Visibility, nil); err != nil {
+			return nil, err
+		}
+	}
 
 	// 如果指定了存储池，直接查询
 	if req.PoolName != "" {
@@ -238,7 +350,23 @@ func (s *TemplateService) ListTemplates(ctx context.Context, req *entity.ListTem
 		if err != nil {
 			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to list templates", err)
 		}
-		return templates, nil
+
+		// 只返回当前租户可见的模板
+		tenant := tenantFromContext(ctx)
+		visible := make([]entity.Template, 0, len(templates))
+		for i := range templates {
+			if !templateVisibleTo(&templates[i], tenant) {
+				continue
+			}
+			if req.Visibility != "" && templateVisibility(&templates[i]) != req.Visibility {
+				continue
+			}
+			if !matchTagFilters(templates[i].Labels, req.Filters) {
+				continue
+			}
+			visible = append(visible, templates[i])
+		}
+		return visible, nil
 	}
 
 	// 否则需要遍历存储池
@@ -263,7 +391,7 @@ func (s *TemplateService) DescribeTemplate(ctx context.Context, req *entity.Desc
 	}
 
 	nodeName := normalizeNodeName(req.NodeName)
-	template, err := s.store.Get(ctx, nodeName, req.PoolName, req.TemplateID)
+	template, err := s.getVisibleTemplate(ctx, nodeName, req.PoolName, req.TemplateID)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return nil, apierror.NewErrorWithStatus(
@@ -293,7 +421,7 @@ func (s *TemplateService) UpdateTemplate(ctx context.Context, req *entity.Update
 	}
 
 	nodeName := normalizeNodeName(req.NodeName)
-	template, err := s.store.Get(ctx, nodeName, req.PoolName, req.TemplateID)
+	template, err := s.getVisibleTemplate(ctx, nodeName, req.PoolName, req.TemplateID)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return nil, apierror.NewErrorWithStatus(
@@ -305,6 +433,41 @@ func (s *TemplateService) UpdateTemplate(ctx context.Context, req *entity.Update
 		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to load template metadata", err)
 	}
 
+	if err := checkTemplateOwner(ctx, template); err != nil {
+		return nil, err
+	}
+	if req.Visibility != nil || req.SharedWith != nil {
+		visibility, sharedWith := templateVisibility(template), template.SharedWith
+		if req.Visibility != nil {
+			visibility = *req.Visibility
+			if visibility != entity.TemplateVisibilityShared {
+				sharedWith = nil
+			}
+		}
+		if req.SharedWith != nil {
+			sharedWith = *req.SharedWith
+		}
+		if err := validateTemplateVisibility(visibility, sharedWith); err != nil {
+			return nil, err
+		}
+	}
+	if req.OS != nil || req.Requirements != nil {
+		templateOS, requirements := template.OS, template.Requirements
+		if req.OS != nil {
+			templateOS = *req.OS
+		}
+		if req.Requirements != nil {
+			requirements = *req.Requirements
+		}
+		if err := validateTemplateMetadata(templateOS, requirements); err != nil {
+			return nil, err
+		}
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "UpdateTemplate")
+	}
+
 	modified := false
 	if req.Description != nil && template.Description != *req.Description {
 		template.Description = *req.Description
@@ -322,17 +485,45 @@ func (s *TemplateService) UpdateTemplate(ctx context.Context, req *entity.Update
 		template.OS = *req.OS
 		modified = true
 	}
+	if req.Requirements != nil {
+		template.Requirements = *req.Requirements
+		modified = true
+	}
+	if req.Visibility != nil {
+		template.Visibility = *req.Visibility
+		if template.Visibility != entity.TemplateVisibilityShared {
+			template.SharedWith = nil
+		}
+		modified = true
+	}
+	if req.SharedWith != nil {
+		template.SharedWith = cloneTags(*req.SharedWith)
+		modified = true
+	}
 
 	if modified {
 		template.UpdatedAt = time.Now().UTC()
 		if err := s.store.Save(ctx, template); err != nil {
 			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to update template metadata", err)
 		}
+		s.changes.Record(entity.ResourceTypeTemplate, nodeName, template.ID, entity.ResourceChangeModified)
 	}
 
 	return template, nil
 }
 
+// getVisibleTemplate 加载当前租户可见的模板，不可见时与不存在一样返回 os.ErrNotExist，避免暴露其他租户的模板
+func (s *TemplateService) getVisibleTemplate(ctx context.Context, nodeName, poolName, templateID string) (*entity.Template, error) {
+	template, err := s.store.Get(ctx, nodeName, poolName, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if !templateVisibleTo(template, tenantFromContext(ctx)) {
+		return nil, fmt.Errorf("template %s: %w", templateID, os.ErrNotExist)
+	}
+	return template, nil
+}
+
 // DeleteTemplate 删除模板
 func (s *TemplateService) DeleteTemplate(ctx context.Context, req *entity.DeleteTemplateRequest) error {
 	if req == nil {
@@ -349,7 +540,7 @@ func (s *TemplateService) DeleteTemplate(ctx context.Context, req *entity.Delete
 	}
 
 	nodeName := normalizeNodeName(req.NodeName)
-	template, err := s.store.Get(ctx, nodeName, req.PoolName, req.TemplateID)
+	template, err := s.getVisibleTemplate(ctx, nodeName, req.PoolName, req.TemplateID)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return apierror.NewErrorWithStatus(
@@ -360,9 +551,15 @@ func (s *TemplateService) DeleteTemplate(ctx context.Context, req *entity.Delete
 		}
 		return apierror.WrapError(apierror.ErrInternalError, "Failed to load template metadata", err)
 	}
+	if err := checkTemplateOwner(ctx, template); err != nil {
+		return err
+	}
 
+	var client libvirt.LibvirtClient
+	var volumePath string
+	var lineage *entity.VolumeLineage
 	if req.DeleteVolume {
-		client, err := s.getNodeClient(ctx, nodeName)
+		client, err = s.getNodeClient(ctx, nodeName)
 		if err != nil {
 			return apierror.WrapError(apierror.ErrInternalError, "Failed to get node storage", err)
 		}
@@ -374,7 +571,31 @@ func (s *TemplateService) DeleteTemplate(ctx context.Context, req *entity.Delete
 		}
 
 		// 模板卷通常在 _templates_ 目录下
-		volumePath := poolInfo.Path + "/" + TemplatesDirName + "/" + template.VolumeName
+		volumePath = poolInfo.Path + "/" + TemplatesDirName + "/" + template.VolumeName
+
+		// 仍有卷以模板为 backing 时删除模板文件会损坏这些卷
+		lineage, err = describeLineage(ctx, client, volumePath)
+		if err != nil {
+			return apierror.WrapError(apierror.ErrInternalError, "Failed to check template dependents", err)
+		}
+		if len(lineage.Dependents) > 0 || len(lineage.References) > 0 {
+			if !req.Force {
+				return newTemplateInUseError(req.TemplateID, lineage)
+			}
+			if err := ensureReferencesStopped(client, req.TemplateID, lineage); err != nil {
+				return err
+			}
+		}
+	}
+
+	if isDryRun(ctx) {
+		return dryRunOperation(ctx, "DeleteTemplate")
+	}
+
+	if req.DeleteVolume {
+		if err := flattenDirectDependents(ctx, client, volumePath, lineage); err != nil {
+			return apierror.WrapError(apierror.ErrInternalError, "Failed to flatten template dependents", err)
+		}
 
 		// 优先使用路径删除
 		if err := client.DeleteVolumeByPath(volumePath); err != nil {
@@ -392,6 +613,7 @@ func (s *TemplateService) DeleteTemplate(ctx context.Context, req *entity.Delete
 	if err := s.store.Delete(ctx, nodeName, req.PoolName, req.TemplateID); err != nil {
 		return apierror.WrapError(apierror.ErrInternalError, "Failed to delete template metadata", err)
 	}
+	s.changes.Record(entity.ResourceTypeTemplate, nodeName, req.TemplateID, entity.ResourceChangeDeleted)
 
 	zerolog.Ctx(ctx).Info().
 		Str("template_id", req.TemplateID).
@@ -401,7 +623,66 @@ func (s *TemplateService) DeleteTemplate(ctx context.Context, req *entity.Delete
 	return nil
 }
 
-func (s *TemplateService) lookupVolume(client libvirt.LibvirtClient, poolName, volumeName string) (*libvirt.VolumeInfo, error) {
+// newTemplateInUseError 返回模板文件仍被依赖的错误，列出依赖的卷与实例
+func newTemplateInUseError(templateID string, lineage *entity.VolumeLineage) error {
+	users := make([]string, 0, len(lineage.Dependents)+len(lineage.References))
+	for _, dep := range lineage.Dependents {
+		users = append(users, dep.Name)
+	}
+	for _, ref := range lineage.References {
+		if !slices.Contains(users, ref.DomainName) {
+			users = append(users, ref.DomainName)
+		}
+	}
+	return apierror.NewErrorWithStatus(
+		"TemplateInUse",
+		fmt.Sprintf("template %s is still used as backing file by: %s; use force to flatten them first", templateID, strings.Join(users, ", ")),
+		http.StatusConflict,
+	)
+}
+
+// ensureReferencesStopped 扁平化会改写磁盘文件，要求所有依赖模板的实例处于关机状态
+func ensureReferencesStopped(client libvirt.DomainManager, templateID string, lineage *entity.VolumeLineage) error {
+	for _, ref := range lineage.References {
+		domain, err := client.GetDomainByName(ref.DomainName)
+		if err != nil {
+			return fmt.Errorf("get domain %s: %w", ref.DomainName, err)
+		}
+		state, _, err := client.GetDomainState(domain)
+		if err != nil {
+			return fmt.Errorf("get domain %s state: %w", ref.DomainName, err)
+		}
+		if libvirtlib.DomainState(state) != libvirtlib.DomainShutoff {
+			return apierror.NewErrorWithStatus(
+				"IncorrectInstanceState",
+				fmt.Sprintf("instance %s depends on template %s; stop it or flatten its disk online with FlattenInstanceDisk first", ref.DomainName, templateID),
+				http.StatusConflict,
+			)
+		}
+	}
+	return nil
+}
+
+// flattenDirectDependents 把 backing file 数据合入直接子卷，解除对该文件的依赖
+// 更上层的卷以直接子卷为 backing，无需处理
+func flattenDirectDependents(ctx context.Context, client libvirt.RemoteManager, backingPath string, lineage *entity.VolumeLineage) error {
+	qemuClient := newQemuImgClient(client)
+	for _, dep := range lineage.Dependents {
+		if dep.BackingPath != backingPath {
+			continue
+		}
+		zerolog.Ctx(ctx).Info().
+			Str("path", dep.Path).
+			Str("backing_path", backingPath).
+			Msg("Flattening dependent volume")
+		if err := qemuClient.Rebase(ctx, dep.Path, "", ""); err != nil {
+			return fmt.Errorf("flatten %s: %w", dep.Path, err)
+		}
+	}
+	return nil
+}
+
+func (s *TemplateService) lookupVolume(client poolFileClient, poolName, volumeName string) (*libvirt.VolumeInfo, error) {
 	// 获取存储池信息
 	poolInfo, err := client.GetStoragePool(poolName)
 	if err != nil {
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/rs/zerolog"
+)
+
+// TemplateCacheDirName 模板缓存目录名（位于存储池根目录下）
+// 在其他节点注册的模板首次在本节点使用时，从模板的下载地址拉取到该目录，作为实例磁盘的 backing file
+const TemplateCacheDirName = "_template_cache_"
+
+// templateCachePartialSuffix 下载中的缓存文件后缀，下载完成后重命名，避免半成品被当作缓存命中
+const templateCachePartialSuffix = ".part"
+
+// templateCacheEntry 缓存目录中的一个镜像文件
+type templateCacheEntry struct {
+	path   string
+	size   uint64
+	usedAt float64 // 最近使用时间（mtime，Unix 秒），命中缓存时更新
+}
+
+// templateCache 节点模板缓存：按需拉取、命中时刷新使用时间、超出上限时按 LRU 清理
+type templateCache struct {
+	maxBytes uint64   // 每个存储池缓存目录的容量上限，0 表示不限制
+	locks    sync.Map // key: node/pool/templateID，串行化同一模板的拉取
+}
+
+// templateCacheFileName 返回模板在缓存目录中的文件名
+func templateCacheFileName(template *entity.Template) string {
+	format := template.Format
+	if format == "" {
+		format = "img"
+	}
+	return template.ID + "." + format
+}
+
+// checkTemplateReplicable 检查模板能否拉取到其他节点：只有从 URL 注册的模板记录了镜像地址
+func checkTemplateReplicable(template *entity.Template) error {
+	if template.Source == nil || template.Source.Type != "url" || template.Source.URL == "" {
+		return apierror.NewErrorWithStatus(
+			"Template.NotReplicable",
+			fmt.Sprintf("template %s was not registered from a URL and only exists on node %s, it cannot be used on other nodes", template.ID, template.NodeName),
+			http.StatusBadRequest,
+		)
+	}
+	return nil
+}
+
+// EnsureTemplateCached 返回模板在节点存储池缓存目录中的镜像路径
+// 缓存命中时刷新使用时间；未命中时从模板的下载地址拉取，完成后按 LRU 清理超出容量上限的旧镜像
+// 调用方应已持有节点操作名额（拉取属于重 IO 操作）
+func (s *TemplateService) EnsureTemplateCached(ctx context.Context, client libvirt.LibvirtClient, nodeName, poolName string, template *entity.Template) (string, error) {
+	logger := zerolog.Ctx(ctx)
+	if err := checkTemplateReplicable(template); err != nil {
+		return "", err
+	}
+
+	pool, err := client.GetStoragePool(poolName)
+	if err != nil {
+		return "", fmt.Errorf("get storage pool: %w", err)
+	}
+	if pool.Path == "" {
+		return "", fmt.Errorf("storage pool %s has no target path", poolName)
+	}
+	cacheDir := pool.Path + "/" + TemplateCacheDirName
+	fileName := templateCacheFileName(template)
+	cachePath := cacheDir + "/" + fileName
+
+	value, _ := s.cache.locks.LoadOrStore(nodeName+"/"+poolName+"/"+template.ID, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	defer mu.Unlock()
+
+	// 命中时 touch 刷新 mtime，作为 LRU 的使用时间
+	output, err := runNodeCommand(ctx, client, fmt.Sprintf("if [ -f %s ]; then touch -c %s && echo hit; fi", shellx.Quote(cachePath), shellx.Quote(cachePath)))
+	if err != nil {
+		return "", fmt.Errorf("check template cache: %w", err)
+	}
+	if strings.TrimSpace(string(output)) == "hit" {
+		logger.Info().
+			Str("template_id", template.ID).
+			Str("path", cachePath).
+			Msg("Template cache hit")
+		return cachePath, nil
+	}
+
+	logger.Info().
+		Str("template_id", template.ID).
+		Str("source_node", template.NodeName).
+		Str("url", template.Source.URL).
+		Str("path", cachePath).
+		Msg("Template not cached on node, pulling from source URL")
+
+	partialName := fileName + templateCachePartialSuffix
+	if err := downloadToDir(ctx, client, poolName, TemplateCacheDirName, partialName, template.Source.URL, s.transfer.resolve(0)); err != nil {
+		if removeErr := removePoolFile(client, cacheDir+"/"+partialName); removeErr != nil {
+			logger.Warn().Err(removeErr).Str("path", cacheDir+"/"+partialName).Msg("Failed to remove partial template cache")
+		}
+		return "", fmt.Errorf("pull template %s: %w", template.ID, err)
+	}
+	if _, err := runNodeCommand(ctx, client, fmt.Sprintf("mv -f %s %s", shellx.Quote(cacheDir+"/"+partialName), shellx.Quote(cachePath))); err != nil {
+		return "", fmt.Errorf("rename template cache: %w", err)
+	}
+
+	logger.Info().
+		Str("template_id", template.ID).
+		Str("path", cachePath).
+		Msg("Template cached on node")
+
+	s.evictTemplateCache(ctx, client, cacheDir, cachePath)
+	return cachePath, nil
+}
+
+// listTemplateCache 列举缓存目录中的镜像文件（不含下载中的文件），目录不存在时返回空
+func listTemplateCache(ctx context.Context, client libvirt.RemoteManager, cacheDir string) ([]templateCacheEntry, error) {
+	command := fmt.Sprintf("[ -d %s ] && find %s -maxdepth 1 -type f ! -name '*%s' -printf '%%T@ %%s %%p\\n' || true",
+		shellx.Quote(cacheDir), shellx.Quote(cacheDir), templateCachePartialSuffix)
+	output, err := runNodeCommand(ctx, client, command)
+	if err != nil {
+		return nil, err
+	}
+	var entries []templateCacheEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		usedAt, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		size, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, templateCacheEntry{path: fields[2], size: size, usedAt: usedAt})
+	}
+	return entries, nil
+}
+
+// evictTemplateCache 缓存目录超出容量上限时，按最近使用时间从旧到新删除镜像
+// 仍被卷或实例作为 backing file 引用的镜像不会删除，因此实际占用可能暂时超过上限；keep 为刚拉取的镜像，不参与清理
+func (s *TemplateService) evictTemplateCache(ctx context.Context, client libvirt.LibvirtClient, cacheDir, keep string) {
+	logger := zerolog.Ctx(ctx)
+	if s.cache.maxBytes == 0 {
+		return
+	}
+
+	entries, err := listTemplateCache(ctx, client, cacheDir)
+	if err != nil {
+		logger.Warn().Err(err).Str("dir", cacheDir).Msg("Failed to list template cache")
+		return
+	}
+	var total uint64
+	for _, entry := range entries {
+		total += entry.size
+	}
+	if total <= s.cache.maxBytes {
+		return
+	}
+
+	graph, err := buildVolumeGraph(client, newQemuImgClient(client), logger)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to build volume graph, skipping template cache eviction")
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].usedAt < entries[j].usedAt
+	})
+	for _, entry := range entries {
+		if total <= s.cache.maxBytes {
+			break
+		}
+		if entry.path == keep || len(graph.descendants(entry.path)) > 0 {
+			continue
+		}
+		refs, _, err := graph.references(ctx, client, entry.path)
+		if err != nil || len(refs) > 0 {
+			continue
+		}
+		if err := removePoolFile(client, entry.path); err != nil {
+			logger.Warn().Err(err).Str("path", entry.path).Msg("Failed to evict template cache")
+			continue
+		}
+		total -= entry.size
+		logger.Info().
+			Str("path", entry.path).
+			Uint64("size_bytes", entry.size).
+			Msg("Evicted template cache")
+	}
+	if total > s.cache.maxBytes {
+		logger.Warn().
+			Str("dir", cacheDir).
+			Uint64("size_bytes", total).
+			Uint64("limit_bytes", s.cache.maxBytes).
+			Msg("Template cache exceeds limit, remaining images are still in use")
+	}
+}
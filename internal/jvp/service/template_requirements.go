@@ -0,0 +1,89 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+)
+
+// loginUserPattern 登录用户名，与 useradd 默认的 NAME_REGEX 一致
+var loginUserPattern = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// validateLoginUser 校验登录用户名，field 为出错时提示的参数名
+func validateLoginUser(field, name string) error {
+	if !loginUserPattern.MatchString(name) {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("invalid %s %q, must start with a lowercase letter or underscore and contain only lowercase letters, digits, underscores and hyphens (at most 32 characters)", field, name),
+			http.StatusBadRequest,
+		)
+	}
+	return nil
+}
+
+// validateTemplateMetadata 校验模板的默认登录用户与资源需求，推荐内存不能低于最小内存
+func validateTemplateMetadata(templateOS entity.TemplateOS, requirements entity.TemplateRequirements) error {
+	if templateOS.DefaultUser != "" {
+		if err := validateLoginUser("os.default_user", templateOS.DefaultUser); err != nil {
+			return err
+		}
+	}
+	if requirements.RecommendedMemoryMB != 0 && requirements.RecommendedMemoryMB < requirements.MinMemoryMB {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("recommended_memory_mb (%d) must not be less than min_memory_mb (%d)",
+				requirements.RecommendedMemoryMB, requirements.MinMemoryMB),
+			http.StatusBadRequest,
+		)
+	}
+	return nil
+}
+
+// applyTemplateRequirements 按模板的资源需求补齐并校验实例规格
+// 请求未指定内存与 vCPU 时使用模板推荐值（不超过 max_memory_mb / max_vcpus），
+// 内存与系统盘不能低于模板的最小需求；未指定 size_gb 时系统盘按最小需求扩大
+func applyTemplateRequirements(template *entity.Template, req *entity.RunInstanceRequest, memoryMB *uint64, vcpus *uint16, sizeGB *uint64) error {
+	requirements := template.Requirements
+	if req.MemoryMB == 0 && requirements.RecommendedMemoryMB > 0 {
+		*memoryMB = requirements.RecommendedMemoryMB
+		if req.MaxMemoryMB != 0 {
+			*memoryMB = min(*memoryMB, req.MaxMemoryMB)
+		}
+	}
+	if req.VCPUs == 0 && requirements.RecommendedVCPUs > 0 {
+		*vcpus = requirements.RecommendedVCPUs
+		if req.MaxVCPUs != 0 {
+			*vcpus = min(*vcpus, req.MaxVCPUs)
+		}
+	}
+
+	if *memoryMB < requirements.MinMemoryMB {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("memory_mb (%d) is less than the minimum %d MB required by template %s",
+				*memoryMB, requirements.MinMemoryMB, template.ID),
+			http.StatusBadRequest,
+		)
+	}
+	if req.SizeGB != 0 && req.SizeGB < requirements.MinDiskGB {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("size_gb (%d) is less than the minimum %d GB required by template %s",
+				req.SizeGB, requirements.MinDiskGB, template.ID),
+			http.StatusBadRequest,
+		)
+	}
+	*sizeGB = max(*sizeGB, requirements.MinDiskGB)
+	return nil
+}
+
+// templateDefaultUser 模板记录的默认登录用户，未使用模板或未记录时为空
+func templateDefaultUser(template *entity.Template) string {
+	if template == nil {
+		return ""
+	}
+	return template.OS.DefaultUser
+}
@@ -80,7 +80,7 @@ func (s *TemplateStore) ensureTemplatesDir(ctx context.Context, nodeName, poolPa
 }
 
 // ensureTemplatesDirRemote 通过 SSH 在远程创建目录
-func (s *TemplateStore) ensureTemplatesDirRemote(client libvirt.LibvirtClient, templatesDir string) error {
+func (s *TemplateStore) ensureTemplatesDirRemote(client libvirt.RemoteManager, templatesDir string) error {
 	return client.ExecuteRemoteCommand(fmt.Sprintf("mkdir -p '%s'", templatesDir))
 }
 
@@ -135,7 +135,7 @@ func (s *TemplateStore) Save(ctx context.Context, template *entity.Template) err
 }
 
 // writeFileRemote 通过 SSH 写入文件
-func (s *TemplateStore) writeFileRemote(client libvirt.LibvirtClient, path string, data []byte) error {
+func (s *TemplateStore) writeFileRemote(client libvirt.RemoteManager, path string, data []byte) error {
 	// 使用 cat 和 heredoc 写入文件
 	content := string(data)
 	// 转义单引号
@@ -213,7 +213,7 @@ func (s *TemplateStore) listPoolTemplatesLocal(templatesDir string) ([]entity.Te
 }
 
 // listPoolTemplatesRemote 远程列举模板
-func (s *TemplateStore) listPoolTemplatesRemote(client libvirt.LibvirtClient, templatesDir string) ([]entity.Template, error) {
+func (s *TemplateStore) listPoolTemplatesRemote(client libvirt.RemoteManager, templatesDir string) ([]entity.Template, error) {
 	// 获取目录中的所有 yaml 文件
 	files, err := client.ListRemoteFiles(templatesDir, "*.yaml")
 	if err != nil {
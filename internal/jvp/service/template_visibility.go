@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+)
+
+// TenantContextKey 请求租户在上下文中的 key，由 API 中间件写入
+// 上下文中没有租户的请求是管理员（见 API 的 tenantMiddleware），可以看到并管理所有模板
+const TenantContextKey = "jvp.tenant"
+
+// tenantFromContext 获取请求的租户，管理员请求返回空字符串
+func tenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(TenantContextKey).(string)
+	return tenant
+}
+
+// templateVisibility 返回模板的可见性，引入可见性之前注册的模板视为 public
+func templateVisibility(template *entity.Template) string {
+	if template.Visibility == "" {
+		return entity.TemplateVisibilityPublic
+	}
+	return template.Visibility
+}
+
+// templateVisibleTo 判断租户是否可以看到并使用模板
+func templateVisibleTo(template *entity.Template, tenant string) bool {
+	if tenant == "" || template.Owner == tenant {
+		return true
+	}
+	switch templateVisibility(template) {
+	case entity.TemplateVisibilityPublic:
+		return true
+	case entity.TemplateVisibilityShared:
+		return slices.Contains(template.SharedWith, tenant)
+	default:
+		return false
+	}
+}
+
+// validateTemplateVisibility 校验可见性参数，空值表示使用默认的 private
+func validateTemplateVisibility(visibility string, sharedWith []string) error {
+	switch visibility {
+	case "", entity.TemplateVisibilityPrivate, entity.TemplateVisibilityPublic:
+		if len(sharedWith) > 0 {
+			return apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				"shared_with is only allowed when visibility is shared",
+				http.StatusBadRequest,
+			)
+		}
+		return nil
+	case entity.TemplateVisibilityShared:
+		if len(sharedWith) == 0 {
+			return apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				"shared_with is required when visibility is shared",
+				http.StatusBadRequest,
+			)
+		}
+		return nil
+	default:
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("invalid visibility %q, must be one of private, shared, public", visibility),
+			http.StatusBadRequest,
+		)
+	}
+}
+
+// checkTemplateOwner 只有模板所属租户和管理员可以修改或删除模板
+func checkTemplateOwner(ctx context.Context, template *entity.Template) error {
+	tenant := tenantFromContext(ctx)
+	if tenant == "" || template.Owner == tenant {
+		return nil
+	}
+	return apierror.NewErrorWithStatus(
+		"OperationNotPermitted",
+		fmt.Sprintf("template %s is owned by another tenant", template.ID),
+		http.StatusForbidden,
+	)
+}
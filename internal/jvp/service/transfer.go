@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+)
+
+// TransferLimiter 大文件传输（镜像下载、存储迁移、磁盘扁平化）的带宽限制
+// 全局限速可在运行时调整，对之后启动的传输生效；任务可单独指定限速覆盖全局值
+type TransferLimiter struct {
+	mu        sync.RWMutex
+	globalMiB uint64 // 全局限速（MiB/s），0 表示不限速
+}
+
+// NewTransferLimiter 创建传输限速器
+func NewTransferLimiter(globalMiB uint64) *TransferLimiter {
+	return &TransferLimiter{globalMiB: globalMiB}
+}
+
+// Global 返回当前全局限速（MiB/s）
+func (l *TransferLimiter) Global() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.globalMiB
+}
+
+// SetGlobal 调整全局限速（MiB/s），0 表示不限速
+func (l *TransferLimiter) SetGlobal(bandwidthMiB uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.globalMiB = bandwidthMiB
+}
+
+// resolve 计算任务实际使用的限速，任务未指定时使用全局限速
+func (l *TransferLimiter) resolve(taskMiB uint64) uint64 {
+	if taskMiB > 0 {
+		return taskMiB
+	}
+	return l.Global()
+}
+
+// downloadRateArgs 生成 wget 与 curl 的限速参数，不限速时返回空
+func downloadRateArgs(bandwidthMiB uint64) (wgetArgs, curlArgs []string) {
+	if bandwidthMiB == 0 {
+		return nil, nil
+	}
+	return []string{fmt.Sprintf("--limit-rate=%dm", bandwidthMiB)},
+		[]string{"--limit-rate", fmt.Sprintf("%dM", bandwidthMiB)}
+}
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+)
+
+// usageRecordDateLayout 用量记录文件按周期结束时间（UTC）的日期分文件
+const usageRecordDateLayout = "2006-01-02"
+
+// defaultUsageQueryRange 未指定开始时间时查询的时间范围
+const defaultUsageQueryRange = 24 * time.Hour
+
+// usageCounters 实例上一次采集时的累计计数
+type usageCounters struct {
+	SampledAt  time.Time `json:"sampled_at"`
+	CPUTime    uint64    `json:"cpu_time"` // 纳秒
+	NetRxBytes uint64    `json:"net_rx_bytes"`
+	NetTxBytes uint64    `json:"net_tx_bytes"`
+}
+
+// UsageStore 用量记录存储
+// 记录按周期结束日期每天一个 JSON Lines 文件：<dataDir>/usage/records/<YYYY-MM-DD>.jsonl；
+// 各实例上一次采集的累计计数：<dataDir>/usage/counters.json，重启后继续按增量计算
+type UsageStore struct {
+	storageDir string
+	mu         sync.Mutex
+}
+
+// NewUsageStore 创建用量记录存储
+func NewUsageStore(dataDir string) (*UsageStore, error) {
+	storageDir := filepath.Join(dataDir, "usage")
+	if err := os.MkdirAll(filepath.Join(storageDir, "records"), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create usage directory: %w", err)
+	}
+	return &UsageStore{storageDir: storageDir}, nil
+}
+
+// getRecordsPath 获取某天的用量记录文件路径
+func (s *UsageStore) getRecordsPath(date string) string {
+	return filepath.Join(s.storageDir, "records", date+".jsonl")
+}
+
+// getCountersPath 获取累计计数文件路径
+func (s *UsageStore) getCountersPath() string {
+	return filepath.Join(s.storageDir, "counters.json")
+}
+
+// Counters 返回节点上各实例上一次采集的累计计数，key 为实例 ID
+func (s *UsageStore) Counters(nodeName string) (map[string]usageCounters, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.countersUnlocked()
+	if err != nil {
+		return nil, err
+	}
+	counters := all[nodeName]
+	if counters == nil {
+		counters = map[string]usageCounters{}
+	}
+	return counters, nil
+}
+
+// SetCounters 整体替换节点上各实例的累计计数，节点上已不存在的实例随之删除
+func (s *UsageStore) SetCounters(nodeName string, counters map[string]usageCounters) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.countersUnlocked()
+	if err != nil {
+		return err
+	}
+	all[nodeName] = counters
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage counters: %w", err)
+	}
+	if err := os.WriteFile(s.getCountersPath(), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write usage counters: %w", err)
+	}
+	return nil
+}
+
+func (s *UsageStore) countersUnlocked() (map[string]map[string]usageCounters, error) {
+	all := map[string]map[string]usageCounters{}
+	data, err := os.ReadFile(s.getCountersPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return all, nil
+		}
+		return nil, fmt.Errorf("failed to read usage counters: %w", err)
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal usage counters: %w", err)
+	}
+	return all, nil
+}
+
+// Append 追加用量记录，按周期结束日期写入对应文件
+func (s *UsageStore) Append(records []entity.UsageRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byDate := make(map[string]*bytes.Buffer)
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal usage record: %w", err)
+		}
+		date := record.PeriodEnd.UTC().Format(usageRecordDateLayout)
+		if byDate[date] == nil {
+			byDate[date] = &bytes.Buffer{}
+		}
+		byDate[date].Write(data)
+		byDate[date].WriteByte('\n')
+	}
+	for date, buf := range byDate {
+		f, err := os.OpenFile(s.getRecordsPath(date), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open usage records file: %w", err)
+		}
+		_, err = f.Write(buf.Bytes())
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write usage records: %w", err)
+		}
+	}
+	return nil
+}
+
+// List 返回周期结束时间在 (start, end] 内的用量记录，按文件日期先后排列
+func (s *UsageStore) List(start, end time.Time) ([]entity.UsageRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []entity.UsageRecord
+	first := start.UTC().Format(usageRecordDateLayout)
+	last := end.UTC().Format(usageRecordDateLayout)
+	for _, date := range s.datesUnlocked() {
+		if date < first || date > last {
+			continue
+		}
+		dayRecords, err := s.readRecordsUnlocked(s.getRecordsPath(date))
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range dayRecords {
+			if record.PeriodEnd.After(start) && !record.PeriodEnd.After(end) {
+				records = append(records, record)
+			}
+		}
+	}
+	return records, nil
+}
+
+// Prune 删除周期结束日期早于 before 所在日期的记录文件
+func (s *UsageStore) Prune(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := before.UTC().Format(usageRecordDateLayout)
+	removed := 0
+	for _, date := range s.datesUnlocked() {
+		if date >= cutoff {
+			continue
+		}
+		if err := os.Remove(s.getRecordsPath(date)); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove usage records file: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// datesUnlocked 返回已有记录文件的日期，按先后排序
+func (s *UsageStore) datesUnlocked() []string {
+	entries, err := os.ReadDir(filepath.Join(s.storageDir, "records"))
+	if err != nil {
+		return nil
+	}
+	var dates []string
+	for _, e := range entries {
+		date, ok := strings.CutSuffix(e.Name(), ".jsonl")
+		if !ok || e.IsDir() {
+			continue
+		}
+		if _, err := time.Parse(usageRecordDateLayout, date); err != nil {
+			continue
+		}
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return dates
+}
+
+func (s *UsageStore) readRecordsUnlocked(path string) ([]entity.UsageRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open usage records file: %w", err)
+	}
+	defer f.Close()
+
+	var records []entity.UsageRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record entity.UsageRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			// 跳过损坏的行（如写入中途崩溃），不影响其余记录
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage records file: %w", err)
+	}
+	return records, nil
+}
+
+// UsageService 周期性采集各实例的 CPU 时间、磁盘占用与网络流量，生成计费用的用量记录
+// 每次采集与上一次的累计计数比较得到周期内的增量；实例第一次出现时只记录基线
+type UsageService struct {
+	store       *UsageStore
+	nodeService *NodeService
+	quotaStore  *QuotaStore
+	interval    time.Duration
+	retention   time.Duration
+}
+
+// NewUsageService 创建用量采集服务，interval 为 0 时不注册采集任务，retention 为 0 时永久保留记录
+func NewUsageService(store *UsageStore, nodeService *NodeService, quotaStore *QuotaStore, interval, retention time.Duration) *UsageService {
+	return &UsageService{
+		store:       store,
+		nodeService: nodeService,
+		quotaStore:  quotaStore,
+		interval:    interval,
+		retention:   retention,
+	}
+}
+
+// RegisterScheduledTasks 向调度器注册用量采集任务，错过的触发直接跳过，增量由下一次采集补齐
+func (s *UsageService) RegisterScheduledTasks(scheduler *Scheduler) error {
+	if s.interval <= 0 {
+		return nil
+	}
+	return scheduler.Register(ScheduledTaskSpec{
+		Name:        "usage-collect",
+		Description: "采集各实例的 CPU 时间、磁盘占用与网络流量，生成计费用量记录并清理过期记录",
+		Schedule:    fmt.Sprintf("@every %s", s.interval),
+		Run:         s.CollectUsage,
+	})
+}
+
+// CollectUsage 采集所有在线节点上的实例用量，单个节点失败不影响其他节点
+func (s *UsageService) CollectUsage(ctx context.Context, now time.Time) error {
+	logger := zerolog.Ctx(ctx)
+
+	nodes, err := s.nodeService.ListNodes(ctx)
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, node := range nodes {
+		if node.State != entity.NodeStateOnline {
+			continue
+		}
+		count, err := s.collectNode(ctx, node.Name, now)
+		if err != nil {
+			logger.Warn().Err(err).Str("node", node.Name).Msg("Failed to collect instance usage")
+			failed = append(failed, node.Name)
+			continue
+		}
+		logger.Debug().Str("node", node.Name).Int("records", count).Msg("Instance usage collected")
+	}
+
+	if s.retention > 0 {
+		if removed, err := s.store.Prune(now.Add(-s.retention)); err != nil {
+			logger.Warn().Err(err).Msg("Failed to prune usage records")
+		} else if removed > 0 {
+			logger.Info().Int("files", removed).Msg("Expired usage records pruned")
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("collect usage failed on %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// collectNode 采集一个节点上全部实例的用量，返回生成的记录数
+func (s *UsageService) collectNode(ctx context.Context, nodeName string, now time.Time) (int, error) {
+	client, err := s.nodeService.storage.GetConnection(nodeName)
+	if err != nil {
+		return 0, err
+	}
+	stats, err := client.GetAllDomainStats()
+	if err != nil {
+		return 0, err
+	}
+	previous, err := s.store.Counters(nodeName)
+	if err != nil {
+		return 0, err
+	}
+	owners, err := s.quotaStore.InstanceOwners(nodeName)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("node", nodeName).Msg("Failed to load instance owners for usage")
+		owners = map[string]string{}
+	}
+
+	counters := make(map[string]usageCounters, len(stats))
+	var records []entity.UsageRecord
+	for _, domainStats := range stats {
+		current := usageCounters{
+			SampledAt:  now,
+			CPUTime:    domainStats.CPUTime,
+			NetRxBytes: domainStats.NetRxBytes,
+			NetTxBytes: domainStats.NetTxBytes,
+		}
+		counters[domainStats.Name] = current
+		last, ok := previous[domainStats.Name]
+		if !ok || !now.After(last.SampledAt) {
+			continue
+		}
+		records = append(records, usageRecord(nodeName, owners[domainStats.Name], domainStats, last, current))
+	}
+
+	if err := s.store.Append(records); err != nil {
+		return 0, err
+	}
+	if err := s.store.SetCounters(nodeName, counters); err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
+
+// usageRecord 根据两次采集的累计计数生成周期内的用量记录
+func usageRecord(nodeName, tenant string, stats libvirt.DomainStats, last, current usageCounters) entity.UsageRecord {
+	period := current.SampledAt.Sub(last.SampledAt)
+	return entity.UsageRecord{
+		PeriodStart:  last.SampledAt,
+		PeriodEnd:    current.SampledAt,
+		NodeName:     nodeName,
+		InstanceID:   stats.Name,
+		Tenant:       tenant,
+		Running:      stats.Active,
+		VCPUs:        stats.VCPUs,
+		MemoryMB:     stats.MemoryKB / 1024,
+		CPUSeconds:   float64(counterDelta(current.CPUTime, last.CPUTime)) / float64(time.Second),
+		DiskBytes:    stats.DiskPhysicalBytes,
+		DiskGiBHours: float64(stats.DiskPhysicalBytes) / (1024 * 1024 * 1024) * period.Hours(),
+		NetRxBytes:   counterDelta(current.NetRxBytes, last.NetRxBytes),
+		NetTxBytes:   counterDelta(current.NetTxBytes, last.NetTxBytes),
+	}
+}
+
+// counterDelta 计算累计计数的增量；计数变小说明 QEMU 进程重启后从 0 开始累计，增量取当前值
+func counterDelta(current, previous uint64) uint64 {
+	if current < previous {
+		return current
+	}
+	return current - previous
+}
+
+// DescribeUsageRecords 查询用量记录，租户请求只返回自身的记录
+func (s *UsageService) DescribeUsageRecords(ctx context.Context, req *entity.DescribeUsageRecordsRequest) (*entity.DescribeUsageRecordsResponse, error) {
+	filter := *req
+	if tenant := tenantFromContext(ctx); tenant != "" {
+		if filter.Tenant != "" && filter.Tenant != tenant {
+			return nil, apierror.NewErrorWithStatus(
+				"OperationNotPermitted",
+				fmt.Sprintf("usage records of tenant %s are not visible to tenant %s", filter.Tenant, tenant),
+				http.StatusForbidden,
+			)
+		}
+		filter.Tenant = tenant
+	}
+	if filter.EndTime.IsZero() {
+		filter.EndTime = time.Now()
+	}
+	if filter.StartTime.IsZero() {
+		filter.StartTime = filter.EndTime.Add(-defaultUsageQueryRange)
+	}
+	if !filter.EndTime.After(filter.StartTime) {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"end_time must be after start_time",
+			http.StatusBadRequest,
+		)
+	}
+
+	all, err := s.store.List(filter.StartTime, filter.EndTime)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to load usage records", err)
+	}
+	records := make([]entity.UsageRecord, 0, len(all))
+	for _, record := range all {
+		if (filter.Tenant != "" && record.Tenant != filter.Tenant) ||
+			(filter.NodeName != "" && record.NodeName != filter.NodeName) ||
+			(filter.InstanceID != "" && record.InstanceID != filter.InstanceID) {
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if !records[i].PeriodEnd.Equal(records[j].PeriodEnd) {
+			return records[i].PeriodEnd.Before(records[j].PeriodEnd)
+		}
+		if records[i].NodeName != records[j].NodeName {
+			return records[i].NodeName < records[j].NodeName
+		}
+		return records[i].InstanceID < records[j].InstanceID
+	})
+	return &entity.DescribeUsageRecordsResponse{Records: records}, nil
+}
+
+// usageCSVHeader CSV 导出的表头，与 UsageRecord 的 JSON 字段一致
+var usageCSVHeader = []string{
+	"period_start", "period_end", "node_name", "instance_id", "tenant", "running", "vcpus", "memory_mb",
+	"cpu_seconds", "disk_bytes", "disk_gib_hours", "net_rx_bytes", "net_tx_bytes",
+}
+
+// WriteUsageRecordsCSV 把用量记录写为 CSV，时间为 RFC3339（UTC）
+func WriteUsageRecordsCSV(w io.Writer, records []entity.UsageRecord) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(usageCSVHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := writer.Write([]string{
+			r.PeriodStart.UTC().Format(time.RFC3339),
+			r.PeriodEnd.UTC().Format(time.RFC3339),
+			r.NodeName,
+			r.InstanceID,
+			r.Tenant,
+			strconv.FormatBool(r.Running),
+			strconv.FormatUint(uint64(r.VCPUs), 10),
+			strconv.FormatUint(r.MemoryMB, 10),
+			strconv.FormatFloat(r.CPUSeconds, 'f', 3, 64),
+			strconv.FormatUint(r.DiskBytes, 10),
+			strconv.FormatFloat(r.DiskGiBHours, 'f', 6, 64),
+			strconv.FormatUint(r.NetRxBytes, 10),
+			strconv.FormatUint(r.NetTxBytes, 10),
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,599 @@
+package service
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/rs/zerolog"
+)
+
+// V2VTaskManager V2V 迁移任务管理器，任务只保存在内存中，服务重启后丢失
+type V2VTaskManager struct {
+	mu      sync.RWMutex
+	tasks   map[string]*entity.V2VTask    // key: taskID
+	cancels map[string]context.CancelFunc // key: taskID，未结束任务的取消函数，关停时终止 virt-v2v
+}
+
+// NewV2VTaskManager 创建 V2V 迁移任务管理器
+func NewV2VTaskManager() *V2VTaskManager {
+	return &V2VTaskManager{
+		tasks:   make(map[string]*entity.V2VTask),
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// add 登记新任务
+func (m *V2VTaskManager) add(task *entity.V2VTask, cancel context.CancelFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tasks[task.ID] = task
+	m.cancels[task.ID] = cancel
+}
+
+// Get 根据任务 ID 获取任务副本
+func (m *V2VTaskManager) Get(taskID string) *entity.V2VTask {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	task, exists := m.tasks[taskID]
+	if !exists {
+		return nil
+	}
+	taskCopy := *task
+	return &taskCopy
+}
+
+// List 按创建时间列出任务，nodeName 为空时列出所有节点
+func (m *V2VTaskManager) List(nodeName string) []*entity.V2VTask {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*entity.V2VTask, 0, len(m.tasks))
+	for _, task := range m.tasks {
+		if nodeName != "" && task.NodeName != nodeName {
+			continue
+		}
+		taskCopy := *task
+		result = append(result, &taskCopy)
+	}
+	slices.SortFunc(result, func(a, b *entity.V2VTask) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return result
+}
+
+// setStage 更新任务状态与当前阶段，已结束的任务不再更新
+func (m *V2VTaskManager) setStage(taskID, status, stage string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, exists := m.tasks[taskID]
+	if !exists || isV2VTaskFinished(task.Status) {
+		return
+	}
+	task.Status = status
+	task.Stage = stage
+	task.UpdatedAt = time.Now()
+}
+
+// finish 结束任务，已被关停中断或被取消的任务保持原状态
+func (m *V2VTaskManager) finish(taskID, status, errMsg string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cancel, ok := m.cancels[taskID]; ok {
+		cancel()
+		delete(m.cancels, taskID)
+	}
+	task, exists := m.tasks[taskID]
+	if !exists || isV2VTaskFinished(task.Status) {
+		return
+	}
+	now := time.Now()
+	task.Status = status
+	task.Error = errMsg
+	task.UpdatedAt = now
+	task.FinishedAt = now
+}
+
+// Cancel 取消未结束的任务并终止其 virt-v2v 进程，返回任务副本；任务不存在时返回 nil，已结束的任务保持原状态
+func (m *V2VTaskManager) Cancel(taskID string) *entity.V2VTask {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, exists := m.tasks[taskID]
+	if !exists {
+		return nil
+	}
+	if !isV2VTaskFinished(task.Status) {
+		now := time.Now()
+		task.Status = entity.V2VTaskStatusCancelled
+		task.Error = "cancelled by user"
+		task.UpdatedAt = now
+		task.FinishedAt = now
+		if cancel, ok := m.cancels[taskID]; ok {
+			cancel()
+			delete(m.cancels, taskID)
+		}
+	}
+	taskCopy := *task
+	return &taskCopy
+}
+
+// InterruptActiveTasks 把所有未结束的任务标记为中断并终止其 virt-v2v 进程，返回这些任务，用于服务关停
+func (m *V2VTaskManager) InterruptActiveTasks(reason string) []*entity.V2VTask {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result []*entity.V2VTask
+	now := time.Now()
+	for taskID, task := range m.tasks {
+		if isV2VTaskFinished(task.Status) {
+			continue
+		}
+		task.Status = entity.V2VTaskStatusInterrupted
+		task.Error = reason
+		task.UpdatedAt = now
+		task.FinishedAt = now
+		if cancel, ok := m.cancels[taskID]; ok {
+			cancel()
+			delete(m.cancels, taskID)
+		}
+		taskCopy := *task
+		result = append(result, &taskCopy)
+	}
+	return result
+}
+
+// isV2VTaskFinished 任务是否已结束
+func isV2VTaskFinished(status string) bool {
+	return status == entity.V2VTaskStatusCompleted || status == entity.V2VTaskStatusFailed ||
+		status == entity.V2VTaskStatusInterrupted || status == entity.V2VTaskStatusCancelled
+}
+
+// v2vPlan 创建任务时确定的转换参数
+type v2vPlan struct {
+	client        libvirt.LibvirtClient
+	input         string   // virt-v2v 的输入参数（已转义）
+	importDir     string   // source 来自实例导入时的暂存目录，需要先拼接分片
+	importParts   []string // 待拼接的分片
+	workDir       string   // virt-v2v -o local 的输出目录，由临时空间管理器创建与清理
+	tempSizeB     uint64   // 预估的临时空间（OVA 大小），vCenter 源无法预知时为 0
+	poolPath      string
+	diskPath      string
+	password      string // vCenter 密码，写入 workDir 下的密码文件
+	vcpus         uint16
+	memoryMB      uint64
+	networkType   string
+	networkSource string
+	start         bool
+}
+
+// v2vPasswordFile virt-v2v -ip 读取的密码文件名
+const v2vPasswordFile = "password"
+
+// v2vDomainXML virt-v2v -o local 生成的 domain XML 中使用的字段
+type v2vDomainXML struct {
+	Memory libvirt.DomainMemory `xml:"memory"`
+	VCPU   uint16               `xml:"vcpu"`
+	OS     struct {
+		Firmware string    `xml:"firmware,attr"`
+		Loader   *struct{} `xml:"loader"`
+	} `xml:"os"`
+}
+
+// CreateV2VTask 创建 V2V 迁移任务：在节点上通过 virt-v2v 把 VMware 虚拟机（OVA 或 vCenter）转换为 qcow2，
+// 注入 virtio 驱动后据此创建实例。转换在后台执行，进度通过 GetV2VTask 查询
+func (s *InstanceService) CreateV2VTask(ctx context.Context, req *entity.CreateV2VTaskRequest) (*entity.V2VTask, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("source_type", req.SourceType).
+		Str("vm_name", req.VMName).
+		Msg("Creating V2V task")
+
+	client, importsDir, err := s.importsDir(ctx, req.NodeName, req.PoolName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := runNodeCommand(ctx, client, "command -v virt-v2v"); err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"UnsupportedOperation",
+			fmt.Sprintf("virt-v2v is not installed on node %s", req.NodeName),
+			http.StatusBadRequest,
+		)
+	}
+
+	plan := &v2vPlan{client: client}
+	var source string
+	switch req.SourceType {
+	case entity.V2VSourceTypeOVA:
+		if (req.ImportID == "") == (req.OVAPath == "") {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				"exactly one of import_id and ova_path must be specified for source_type ova",
+				http.StatusBadRequest,
+			)
+		}
+		ovaPath := req.OVAPath
+		if req.ImportID != "" {
+			_, importDir, err := s.openInstanceImport(ctx, req.NodeName, req.PoolName, req.ImportID)
+			if err != nil {
+				return nil, err
+			}
+			if plan.importParts, err = pendingImportParts(ctx, client, importDir, req.ImportID); err != nil {
+				return nil, err
+			}
+			plan.importDir = importDir
+			ovaPath = filepath.Join(importDir, importUploadFile)
+			source = req.ImportID
+			if plan.tempSizeB, err = importUploadSize(ctx, client, importDir, plan.importParts); err != nil {
+				return nil, err
+			}
+		} else {
+			if !path.IsAbs(ovaPath) || path.Clean(ovaPath) != ovaPath {
+				return nil, apierror.NewErrorWithStatus(
+					"InvalidParameterValue",
+					fmt.Sprintf("invalid ova_path %q, must be a clean absolute path", ovaPath),
+					http.StatusBadRequest,
+				)
+			}
+			size, err := nodeFileSize(ctx, client, ovaPath)
+			if err != nil {
+				return nil, apierror.NewErrorWithStatus(
+					"ResourceNotFound",
+					fmt.Sprintf("OVA file %s not found on node %s", ovaPath, req.NodeName),
+					http.StatusNotFound,
+				)
+			}
+			plan.tempSizeB = uint64(size)
+			source = ovaPath
+		}
+		plan.input = "-i ova " + shellx.Quote(ovaPath)
+	case entity.V2VSourceTypeVCenter:
+		if !strings.HasPrefix(req.VCenterURL, "vpx://") && !strings.HasPrefix(req.VCenterURL, "esx://") {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("invalid vcenter_url %q, must start with vpx:// or esx://", req.VCenterURL),
+				http.StatusBadRequest,
+			)
+		}
+		if req.VMName == "" || req.Password == "" {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				"vm_name and password are required for source_type vcenter",
+				http.StatusBadRequest,
+			)
+		}
+		plan.password = req.Password
+		source = req.VMName
+	default:
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported source_type %q, must be ova or vcenter", req.SourceType),
+			http.StatusBadRequest,
+		)
+	}
+
+	pool, err := client.GetStoragePool(req.PoolName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get storage pool", err)
+	}
+	instanceName, diskPath, err := s.importedInstanceName(ctx, client, pool.Path, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	networkType, networkSource, err := s.nodeProvider.ResolveInstanceNetwork(ctx, req.NodeName, req.NetworkType, req.NetworkSource)
+	if err != nil {
+		return nil, err
+	}
+
+	// 转换输出与源镜像大小相当，创建任务前预检临时空间，执行前再按任务登记占用
+	if err := s.tempSpace.Check(ctx, client, req.NodeName, s.tempSpace.WorkDir(importsDir, ""), plan.tempSizeB); err != nil {
+		return nil, err
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CreateV2VTask")
+	}
+
+	id, err := s.idGen.GenerateID()
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to generate task ID", err)
+	}
+	taskID := fmt.Sprintf("v2v-%d", id)
+	plan.workDir = s.tempSpace.WorkDir(importsDir, taskID)
+	plan.poolPath = pool.Path
+	plan.diskPath = diskPath
+	plan.vcpus = req.VCPUs
+	plan.memoryMB = req.MemoryMB
+	plan.networkType = networkType
+	plan.networkSource = networkSource
+	plan.start = req.Start
+	if req.SourceType == entity.V2VSourceTypeVCenter {
+		plan.input = fmt.Sprintf("-ic %s -ip %s %s", shellx.Quote(req.VCenterURL),
+			shellx.Quote(filepath.Join(plan.workDir, v2vPasswordFile)), shellx.Quote(req.VMName))
+	}
+
+	now := time.Now()
+	task := &entity.V2VTask{
+		ID:         taskID,
+		NodeName:   req.NodeName,
+		PoolName:   req.PoolName,
+		SourceType: req.SourceType,
+		Source:     source,
+		InstanceID: instanceName,
+		Status:     entity.V2VTaskStatusPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	// 转换耗时远超请求生命周期，使用不随请求取消的 ctx，只在服务关停时取消
+	taskCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	s.v2vTasks.add(task, cancel)
+	s.asyncRun(func() {
+		s.runV2VTask(taskCtx, task.ID, req.NodeName, instanceName, plan)
+	})
+
+	logger.Info().
+		Str("task_id", taskID).
+		Str("instanceID", instanceName).
+		Msg("V2V task created")
+	return s.v2vTasks.Get(taskID), nil
+}
+
+// GetV2VTask 查询 V2V 迁移任务
+func (s *InstanceService) GetV2VTask(ctx context.Context, taskID string) (*entity.V2VTask, error) {
+	task := s.v2vTasks.Get(taskID)
+	if task == nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("V2V task %s not found", taskID),
+			http.StatusNotFound,
+		)
+	}
+	return task, nil
+}
+
+// ListV2VTasks 列出 V2V 迁移任务
+func (s *InstanceService) ListV2VTasks(ctx context.Context, req *entity.ListV2VTasksRequest) []*entity.V2VTask {
+	return s.v2vTasks.List(req.NodeName)
+}
+
+// CancelV2VTask 取消 pending 或 running 的 V2V 迁移任务：终止节点上的 virt-v2v 进程，任务随后自行清理转换产生的临时文件与系统盘
+// 已取消的任务重复取消直接返回
+func (s *InstanceService) CancelV2VTask(ctx context.Context, taskID string) (*entity.V2VTask, error) {
+	task := s.v2vTasks.Get(taskID)
+	if task == nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("V2V task %s not found", taskID),
+			http.StatusNotFound,
+		)
+	}
+	if task.Status == entity.V2VTaskStatusCancelled {
+		return task, nil
+	}
+	if isV2VTaskFinished(task.Status) {
+		return nil, apierror.NewErrorWithStatus(
+			"IncorrectTaskState",
+			fmt.Sprintf("V2V task %s has already finished with status %s", taskID, task.Status),
+			http.StatusConflict,
+		)
+	}
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CancelV2VTask")
+	}
+
+	task = s.v2vTasks.Cancel(taskID)
+	zerolog.Ctx(ctx).Info().
+		Str("task_id", taskID).
+		Str("node_name", task.NodeName).
+		Str("instanceID", task.InstanceID).
+		Msg("V2V task cancelled")
+	return task, nil
+}
+
+// InterruptV2VTasks 服务关停时终止未完成的 V2V 任务，被终止的任务随后自行清理转换产生的临时文件
+func (s *InstanceService) InterruptV2VTasks(ctx context.Context) {
+	logger := zerolog.Ctx(ctx)
+	for _, task := range s.v2vTasks.InterruptActiveTasks(ErrShuttingDown.Error()) {
+		logger.Warn().
+			Str("task_id", task.ID).
+			Str("node_name", task.NodeName).
+			Str("instanceID", task.InstanceID).
+			Msg("V2V task interrupted by shutdown")
+	}
+}
+
+// runV2VTask 在后台执行 V2V 转换并创建实例
+func (s *InstanceService) runV2VTask(ctx context.Context, taskID, nodeName, instanceName string, plan *v2vPlan) {
+	logger := zerolog.Ctx(ctx).With().
+		Str("task_id", taskID).
+		Str("instanceID", instanceName).
+		Logger()
+	ctx = logger.WithContext(ctx)
+
+	// 转换是重 IO 操作，受节点并发上限约束，排队期间任务保持 pending
+	release, err := s.operations.Acquire(ctx, nodeName, taskID, OperationV2VImport, instanceName)
+	if err != nil {
+		logger.Warn().Err(err).Msg("V2V task interrupted before start")
+		s.v2vTasks.finish(taskID, entity.V2VTaskStatusInterrupted, err.Error())
+		return
+	}
+	defer release()
+
+	// 临时目录在任务结束时（成功、失败、取消或关停中断）删除
+	releaseTemp, err := s.tempSpace.Acquire(ctx, plan.client, nodeName, plan.workDir, plan.tempSizeB)
+	if err != nil {
+		logger.Error().Err(err).Msg("V2V task failed to acquire temporary space")
+		s.v2vTasks.finish(taskID, entity.V2VTaskStatusFailed, err.Error())
+		return
+	}
+	defer releaseTemp()
+
+	if err := s.convertV2V(ctx, taskID, nodeName, instanceName, plan); err != nil {
+		logger.Error().Err(err).Msg("V2V task failed")
+		_, _ = runNodeCommand(context.WithoutCancel(ctx), plan.client, "rm -f "+shellx.Quote(plan.diskPath))
+		s.v2vTasks.finish(taskID, entity.V2VTaskStatusFailed, err.Error())
+		return
+	}
+
+	logger.Info().Msg("V2V task completed")
+	s.v2vTasks.finish(taskID, entity.V2VTaskStatusCompleted, "")
+}
+
+// convertV2V 执行 virt-v2v，把输出的系统盘移动到存储池并创建实例
+func (s *InstanceService) convertV2V(ctx context.Context, taskID, nodeName, instanceName string, plan *v2vPlan) error {
+	client := plan.client
+
+	if plan.importDir != "" && len(plan.importParts) > 0 {
+		s.v2vTasks.setStage(taskID, entity.V2VTaskStatusRunning, "assembling uploaded parts")
+		if _, err := assembleImportUpload(ctx, client, plan.importDir, plan.importParts); err != nil {
+			return err
+		}
+	}
+
+	if plan.password != "" {
+		passwordPath := filepath.Join(plan.workDir, v2vPasswordFile)
+		if _, err := runNodeCommand(ctx, client, "install -m 600 /dev/null "+shellx.Quote(passwordPath)); err != nil {
+			return fmt.Errorf("create password file: %w", err)
+		}
+		if err := writeNodeFile(ctx, client, passwordPath, strings.NewReader(plan.password)); err != nil {
+			return fmt.Errorf("write password file: %w", err)
+		}
+	}
+
+	// virt-v2v 以 root 运行时使用 direct 后端，不依赖 libvirt 会话；输出 <workDir>/<name>-sda 等磁盘与 <name>.xml
+	s.v2vTasks.setStage(taskID, entity.V2VTaskStatusRunning, "starting virt-v2v")
+	command := fmt.Sprintf("LIBGUESTFS_BACKEND=direct virt-v2v %s -o local -os %s -of qcow2 -on %s",
+		plan.input, shellx.Quote(plan.workDir), shellx.Quote(instanceName))
+	err := runNodeCommandStream(ctx, client, command, func(line string) {
+		// 进度行形如 "[  12.3] Copying disk 1/1"
+		if strings.HasPrefix(line, "[") {
+			s.v2vTasks.setStage(taskID, entity.V2VTaskStatusRunning, line)
+		}
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			// 取消或关停只会结束本地的 ssh，远端的 virt-v2v 按输出目录找到并终止，之后才能安全删除临时目录
+			killRemoteNodeProcesses(context.WithoutCancel(ctx), client, plan.workDir)
+		}
+		return fmt.Errorf("virt-v2v: %w", err)
+	}
+
+	s.v2vTasks.setStage(taskID, entity.V2VTaskStatusRunning, "creating instance")
+	output, err := runNodeCommand(ctx, client, "cat "+shellx.Quote(filepath.Join(plan.workDir, instanceName+".xml")))
+	if err != nil {
+		return fmt.Errorf("read virt-v2v domain XML: %w", err)
+	}
+	var domainXML v2vDomainXML
+	if err := xml.Unmarshal(output, &domainXML); err != nil {
+		return fmt.Errorf("parse virt-v2v domain XML: %w", err)
+	}
+	// jvp 创建的 x86_64 实例使用 BIOS 引导
+	if domainXML.OS.Firmware == "efi" || domainXML.OS.Loader != nil {
+		return fmt.Errorf("source VM boots with UEFI, which is not supported")
+	}
+
+	// 第一块磁盘作为系统盘，其余磁盘作为普通卷保留在存储池中，可通过 AttachVolume 附加
+	output, err = runNodeCommand(ctx, client, "ls -1 "+shellx.Quote(plan.workDir))
+	if err != nil {
+		return fmt.Errorf("list virt-v2v output: %w", err)
+	}
+	var disks []string
+	for _, name := range strings.Fields(string(output)) {
+		if strings.HasPrefix(name, instanceName+"-sd") {
+			disks = append(disks, name)
+		}
+	}
+	slices.Sort(disks)
+	if len(disks) == 0 {
+		return fmt.Errorf("virt-v2v produced no disk")
+	}
+	for i, name := range disks {
+		target := plan.diskPath
+		if i > 0 {
+			target = filepath.Join(plan.poolPath, name+".qcow2")
+		}
+		if _, err := runNodeCommand(ctx, client, fmt.Sprintf("[ ! -e %[2]s ] && mv %[1]s %[2]s",
+			shellx.Quote(filepath.Join(plan.workDir, name)), shellx.Quote(target))); err != nil {
+			return fmt.Errorf("move disk %s to %s: %w", name, target, err)
+		}
+	}
+
+	vcpus := plan.vcpus
+	if vcpus == 0 {
+		vcpus = max(domainXML.VCPU, 1)
+	}
+	memoryMB := plan.memoryMB
+	if memoryMB == 0 {
+		memoryMB = max(v2vMemoryMB(domainXML.Memory), 512)
+	}
+	domain, err := client.CreateDomain(&libvirt.CreateVMConfig{
+		Name:          instanceName,
+		Memory:        memoryMB * 1024,
+		VCPUs:         vcpus,
+		DiskPath:      plan.diskPath,
+		DiskBus:       "virtio",
+		NetworkType:   plan.networkType,
+		NetworkSource: plan.networkSource,
+		OSType:        "hvm",
+		Architecture:  "x86_64",
+		VNCSocket:     fmt.Sprintf("/var/lib/jvp/qemu/%s.vnc", instanceName),
+	}, plan.start)
+	if err != nil {
+		return fmt.Errorf("create instance: %w", err)
+	}
+
+	state := "stopped"
+	if plan.start {
+		state = "running"
+	}
+	s.recordEvent(ctx, nodeName, instanceName, entity.InstanceEventCreated, state,
+		fmt.Sprintf("migrated from VMware by V2V task %s", taskID))
+	if owner := tenantFromContext(ctx); owner != "" {
+		if err := s.quotas.SetInstanceOwner(nodeName, instanceName, owner); err != nil {
+			zerolog.Ctx(ctx).Error().
+				Err(err).
+				Msg("Failed to record instance owner")
+		}
+	}
+	zerolog.Ctx(ctx).Info().
+		Str("domain_uuid", formatDomainUUID(domain.UUID)).
+		Msg("V2V instance created")
+
+	if plan.importDir != "" {
+		if _, err := runNodeCommand(ctx, client, "rm -rf "+shellx.Quote(plan.importDir)); err != nil {
+			zerolog.Ctx(ctx).Warn().
+				Err(err).
+				Msg("Failed to remove import directory")
+		}
+	}
+	return nil
+}
+
+// v2vMemoryMB 把 domain XML 的内存换算为 MB，virt-v2v 默认使用 KiB
+func v2vMemoryMB(memory libvirt.DomainMemory) uint64 {
+	switch memory.Unit {
+	case "MiB", "M":
+		return memory.Value
+	case "GiB", "G":
+		return memory.Value * 1024
+	default:
+		return memory.Value / 1024
+	}
+}
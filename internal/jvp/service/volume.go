@@ -2,13 +2,19 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"path"
+	"slices"
 	"strings"
 
 	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
 	"github.com/jimyag/jvp/pkg/idgen"
 	"github.com/jimyag/jvp/pkg/libvirt"
 	"github.com/jimyag/jvp/pkg/qemuimg"
+	"github.com/jimyag/jvp/pkg/zfs"
 	"github.com/rs/zerolog"
 )
 
@@ -24,11 +30,36 @@ func isSnapshotVolume(volInfo *libvirt.VolumeInfo, snapshotPaths map[string]stru
 	return false
 }
 
+// volumeIDFromName 从卷文件名提取 volume ID（去掉扩展名）
+func volumeIDFromName(name string) string {
+	volumeID := strings.TrimSuffix(name, ".qcow2")
+	volumeID = strings.TrimSuffix(volumeID, ".raw")
+	volumeID = strings.TrimSuffix(volumeID, ".iso")
+	return strings.TrimSuffix(volumeID, ".img")
+}
+
+// volumeType 根据卷名称判断卷类型
+func volumeType(name string) string {
+	switch {
+	case libvirt.IsCloudInitVolume(name):
+		return entity.VolumeTypeCloudInit
+	case libvirt.IsIgnitionVolume(name):
+		return entity.VolumeTypeIgnition
+	case strings.HasSuffix(name, ".iso"):
+		return entity.VolumeTypeISO
+	default:
+		return entity.VolumeTypeDisk
+	}
+}
+
 type diskMaps struct {
 	snapshotPaths map[string]struct{}
 }
 
-func buildDiskMaps(client libvirt.LibvirtClient, logger *zerolog.Logger) diskMaps {
+func buildDiskMaps(client interface {
+	libvirt.DomainManager
+	libvirt.SnapshotManager
+}, logger *zerolog.Logger) diskMaps {
 	snapshotPaths := make(map[string]struct{})
 
 	domains, err := client.GetVMSummaries()
@@ -64,18 +95,36 @@ type VolumeService struct {
 	storagePoolService *StoragePoolService
 	qemuImgClient      qemuimg.QemuImgClient
 	idGen              *idgen.Generator
+	transfer           *TransferLimiter
+	recycleBin         *RecycleBin
+	protection         *DeletionProtection
+	multiAttach        *MultiAttachStore
+	labels             *LabelStore
+	changes            *ChangeFeed
 }
 
 // NewVolumeService 创建新的 Volume Service
 func NewVolumeService(
 	nodeService *NodeService,
 	storagePoolService *StoragePoolService,
+	transfer *TransferLimiter,
+	recycleBin *RecycleBin,
+	protection *DeletionProtection,
+	multiAttach *MultiAttachStore,
+	labels *LabelStore,
+	changes *ChangeFeed,
 ) *VolumeService {
 	return &VolumeService{
 		nodeService:        nodeService,
 		storagePoolService: storagePoolService,
+		transfer:           transfer,
+		recycleBin:         recycleBin,
+		protection:         protection,
+		multiAttach:        multiAttach,
+		labels:             labels,
+		changes:            changes,
 		qemuImgClient:      qemuimg.New(""),
-		idGen:              idgen.New(),
+		idGen:              idgen.DefaultGenerator().Namespace(idgen.NamespaceVolume),
 	}
 }
 
@@ -100,6 +149,8 @@ func (s *VolumeService) CreateVolume(ctx context.Context, req *entity.CreateVolu
 	if volumeName == "" {
 		// 如果没有提供名称，使用 volumeID
 		volumeName = volumeID
+	} else if err := validateResourceName("volume", volumeName); err != nil {
+		return nil, err
 	}
 
 	logger.Info().
@@ -107,30 +158,49 @@ func (s *VolumeService) CreateVolume(ctx context.Context, req *entity.CreateVolu
 		Str("volume_name", volumeName).
 		Msg("Generated volume ID and name")
 
-	// 确定格式
-	format := req.Format
-	if format == "" {
-		format = "qcow2"
-	}
-
-	// 根据格式添加扩展名
-	fileExtension := ".qcow2"
-	if format == "raw" {
-		fileExtension = ".raw"
-	}
-	fileName := volumeName + fileExtension
-
 	// 获取节点的存储服务
 	nodeStorage, err := s.nodeService.GetNodeStorage(ctx, req.NodeName)
 	if err != nil {
 		return nil, fmt.Errorf("get node storage: %w", err)
 	}
 
+	// 按存储池类型选择卷后端，由后端确定卷名称（文件卷带扩展名）与格式
+	backend, err := newVolumeBackend(nodeStorage, req.PoolName)
+	if err != nil {
+		return nil, err
+	}
+	fileName, format, err := backend.VolumeName(volumeName, req.Format)
+	if err != nil {
+		return nil, err
+	}
+	if req.MultiAttach {
+		if err := validateMultiAttachFormat(format); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := nodeStorage.GetVolume(req.PoolName, fileName); err == nil {
+		return nil, newResourceAlreadyExistsError("Volume", fileName)
+	}
+
+	if isDryRun(ctx) {
+		if err := checkPoolCapacity(nodeStorage, req.PoolName, req.SizeGB); err != nil {
+			return nil, err
+		}
+		return nil, dryRunOperation(ctx, "CreateVolume")
+	}
+
 	// 创建存储卷
-	volInfo, err := nodeStorage.CreateVolume(req.PoolName, fileName, req.SizeGB, format)
+	volInfo, err := backend.CreateVolume(ctx, fileName, req.SizeGB, format)
 	if err != nil {
 		return nil, fmt.Errorf("create volume: %w", err)
 	}
+	if req.MultiAttach {
+		if err := s.multiAttach.SetVolume(req.NodeName, volInfo.Path, true); err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to enable multi-attach", err)
+		}
+	}
+	revision := s.changes.Record(entity.ResourceTypeVolume, req.NodeName, volumeIDFromName(volInfo.Name), entity.ResourceChangeAdded)
 
 	// 构建返回的 Volume 对象
 	volume := &entity.Volume{
@@ -143,6 +213,9 @@ func (s *VolumeService) CreateVolume(ctx context.Context, req *entity.CreateVolu
 		SizeGB:      req.SizeGB,
 		AllocationB: volInfo.AllocationB,
 		Format:      volInfo.Format,
+		Type:        volumeType(volInfo.Name),
+		MultiAttach: req.MultiAttach,
+		Revision:    revision,
 	}
 
 	logger.Info().
@@ -153,6 +226,109 @@ func (s *VolumeService) CreateVolume(ctx context.Context, req *entity.CreateVolu
 	return volume, nil
 }
 
+// CloneVolume 在同一存储池内克隆卷
+// zfs 存储池通过源卷快照 + zfs clone 秒级完成且不占用额外空间，其他存储池通过 qemu-img 完整复制
+func (s *VolumeService) CloneVolume(ctx context.Context, req *entity.CloneVolumeRequest) (*entity.Volume, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("source_volume_id", req.SourceVolumeID).
+		Str("requested_name", req.Name).
+		Uint64("size_gb", req.SizeGB).
+		Msg("Cloning volume")
+
+	nodeStorage, err := s.nodeService.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, fmt.Errorf("get node storage: %w", err)
+	}
+
+	source, err := findPoolVolume(nodeStorage, req.PoolName, req.SourceVolumeID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Volume %s not found in pool %s", req.SourceVolumeID, req.PoolName),
+			http.StatusNotFound,
+		)
+	}
+	if volumeType(source.Name) != entity.VolumeTypeDisk {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("volume %s is a %s volume and cannot be cloned", req.SourceVolumeID, volumeType(source.Name)),
+			http.StatusBadRequest,
+		)
+	}
+
+	volumeID, err := s.idGen.GenerateVolumeID()
+	if err != nil {
+		return nil, fmt.Errorf("generate volume ID: %w", err)
+	}
+	volumeName := req.Name
+	if volumeName == "" {
+		volumeName = volumeID
+	} else if err := validateResourceName("volume", volumeName); err != nil {
+		return nil, err
+	}
+
+	backend, err := newVolumeBackend(nodeStorage, req.PoolName)
+	if err != nil {
+		return nil, err
+	}
+	// 克隆卷保持源卷格式，zvol 固定为 raw
+	format := source.Format
+	if backend.Name() == VolumeBackendZFS {
+		format = "raw"
+	}
+	fileName, _, err := backend.VolumeName(volumeName, format)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := nodeStorage.GetVolume(req.PoolName, fileName); err == nil {
+		return nil, newResourceAlreadyExistsError("Volume", fileName)
+	}
+
+	if isDryRun(ctx) {
+		// zfs 克隆与源卷共享数据块，只有扩容部分需要空间
+		requiredGB := source.CapacityB / (1024 * 1024 * 1024)
+		if backend.Name() == VolumeBackendZFS {
+			requiredGB = 0
+		}
+		if req.SizeGB > source.CapacityB/(1024*1024*1024) {
+			requiredGB = req.SizeGB
+		}
+		if err := checkPoolCapacity(nodeStorage, req.PoolName, requiredGB); err != nil {
+			return nil, err
+		}
+		return nil, dryRunOperation(ctx, "CloneVolume")
+	}
+
+	volInfo, err := backend.CloneVolume(ctx, source, fileName, req.SizeGB)
+	if err != nil {
+		return nil, fmt.Errorf("clone volume: %w", err)
+	}
+	revision := s.changes.Record(entity.ResourceTypeVolume, req.NodeName, volumeIDFromName(volInfo.Name), entity.ResourceChangeAdded)
+
+	logger.Info().
+		Str("volume_id", volumeID).
+		Str("backend", backend.Name()).
+		Str("path", volInfo.Path).
+		Msg("Volume cloned successfully")
+
+	return &entity.Volume{
+		ID:          volumeIDFromName(volInfo.Name),
+		Name:        volInfo.Name,
+		NodeName:    req.NodeName,
+		Pool:        req.PoolName,
+		Path:        volInfo.Path,
+		CapacityB:   volInfo.CapacityB,
+		SizeGB:      volInfo.CapacityB / (1024 * 1024 * 1024),
+		AllocationB: volInfo.AllocationB,
+		Format:      volInfo.Format,
+		Type:        volumeType(volInfo.Name),
+		Revision:    revision,
+	}, nil
+}
+
 // ListVolumes 列举存储池中的所有卷
 func (s *VolumeService) ListVolumes(ctx context.Context, req *entity.ListVolumesRequest) ([]entity.Volume, error) {
 	logger := zerolog.Ctx(ctx)
@@ -169,6 +345,25 @@ func (s *VolumeService) ListVolumes(ctx context.Context, req *entity.ListVolumes
 
 	diskMaps := buildDiskMaps(nodeStorage, logger)
 
+	protected, err := s.protection.ProtectedVolumes(req.NodeName)
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("Failed to load deletion protection")
+	}
+	shared, err := s.multiAttach.Volumes(req.NodeName)
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("Failed to load multi-attach state")
+	}
+	labels, err := s.labels.Resources(req.NodeName)
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("Failed to load volume labels")
+	}
+
 	// 列举卷
 	volInfos, err := nodeStorage.ListVolumes(req.PoolName)
 	if err != nil {
@@ -177,20 +372,18 @@ func (s *VolumeService) ListVolumes(ctx context.Context, req *entity.ListVolumes
 
 	volumes := make([]entity.Volume, 0, len(volInfos))
 	for _, volInfo := range volInfos {
-		// 跳过模板目录和快照卷（含非规范命名）
+		// 跳过模板目录、回收目录和快照卷（含非规范命名）
 		if volInfo.Name == TemplatesDirName || strings.Contains(volInfo.Path, "/"+TemplatesDirName+"/") {
 			continue
 		}
+		if isRecycledVolume(volInfo) || isExportVolume(volInfo) || isImportVolume(volInfo) {
+			continue
+		}
 		if isSnapshotVolume(volInfo, diskMaps.snapshotPaths) {
 			continue
 		}
 
-		// 从文件名提取 volume ID (去掉扩展名)
-		volumeID := strings.TrimSuffix(volInfo.Name, ".qcow2")
-		volumeID = strings.TrimSuffix(volumeID, ".raw")
-		volumeID = strings.TrimSuffix(volumeID, ".iso")
-		volumeID = strings.TrimSuffix(volumeID, ".img")
-
+		volumeID := volumeIDFromName(volInfo.Name)
 		volume := entity.Volume{
 			ID:          volumeID,
 			Name:        volInfo.Name,
@@ -201,6 +394,16 @@ func (s *VolumeService) ListVolumes(ctx context.Context, req *entity.ListVolumes
 			SizeGB:      volInfo.CapacityB / (1024 * 1024 * 1024),
 			AllocationB: volInfo.AllocationB,
 			Format:      volInfo.Format,
+			Type:        volumeType(volInfo.Name),
+
+			DeletionProtection: protected[volInfo.Path],
+			MultiAttach:        shared[volInfo.Path],
+			Revision:           s.changes.ResourceRevision(entity.ResourceTypeVolume, req.NodeName, volumeID),
+
+			Labels: labels[volInfo.Path],
+		}
+		if !matchTagFilters(volume.Labels, req.Filters) {
+			continue
 		}
 		volumes = append(volumes, volume)
 	}
@@ -227,8 +430,8 @@ func (s *VolumeService) DescribeVolume(ctx context.Context, req *entity.Describe
 		return nil, fmt.Errorf("get node storage: %w", err)
 	}
 
-	// 查询卷信息，尝试多种扩展名
-	extensions := []string{".qcow2", ".raw", ".img", ".iso"}
+	// 查询卷信息，尝试多种扩展名，zfs 存储池的卷没有扩展名
+	extensions := []string{".qcow2", ".raw", ".img", ".iso", ""}
 	var volInfo *libvirt.VolumeInfo
 	var volumeName string
 	var lastErr error
@@ -254,8 +457,32 @@ func (s *VolumeService) DescribeVolume(ctx context.Context, req *entity.Describe
 		SizeGB:      volInfo.CapacityB / (1024 * 1024 * 1024),
 		AllocationB: volInfo.AllocationB,
 		Format:      volInfo.Format,
+		Type:        volumeType(volInfo.Name),
 	}
 
+	protected, err := s.protection.ProtectedVolumes(req.NodeName)
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("Failed to load deletion protection")
+	}
+	volume.DeletionProtection = protected[volInfo.Path]
+	shared, err := s.multiAttach.Volumes(req.NodeName)
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("Failed to load multi-attach state")
+	}
+	volume.MultiAttach = shared[volInfo.Path]
+	labels, err := s.labels.Resources(req.NodeName)
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("Failed to load volume labels")
+	}
+	volume.Labels = labels[volInfo.Path]
+	volume.Revision = s.changes.ResourceRevision(entity.ResourceTypeVolume, req.NodeName, volumeIDFromName(volInfo.Name))
+
 	logger.Info().
 		Str("volume_id", req.VolumeID).
 		Msg("Volume described successfully")
@@ -263,6 +490,70 @@ func (s *VolumeService) DescribeVolume(ctx context.Context, req *entity.Describe
 	return volume, nil
 }
 
+// DescribeVolumeLineage 查询卷的 backing 链、以其为 backing 的子卷以及引用它的 domain
+func (s *VolumeService) DescribeVolumeLineage(ctx context.Context, req *entity.DescribeVolumeLineageRequest) (*entity.VolumeLineage, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("volume_id", req.VolumeID).
+		Str("path", req.Path).
+		Msg("Describing volume lineage")
+
+	if (req.VolumeID == "") == (req.Path == "") {
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"exactly one of volume_id and path must be specified",
+			http.StatusBadRequest,
+		)
+	}
+
+	nodeStorage, err := s.nodeService.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, fmt.Errorf("get node storage: %w", err)
+	}
+
+	filePath := req.Path
+	if filePath == "" {
+		volume, err := s.DescribeVolume(ctx, &entity.DescribeVolumeRequest{
+			NodeName: req.NodeName,
+			PoolName: req.PoolName,
+			VolumeID: req.VolumeID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		filePath = volume.Path
+	} else {
+		poolInfo, err := nodeStorage.GetStoragePool(req.PoolName)
+		if err != nil {
+			return nil, fmt.Errorf("get storage pool: %w", err)
+		}
+		filePath = path.Clean(filePath)
+		if !strings.HasPrefix(filePath, strings.TrimSuffix(poolInfo.Path, "/")+"/") {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("path %s is not inside storage pool %s", req.Path, req.PoolName),
+				http.StatusBadRequest,
+			)
+		}
+	}
+
+	lineage, err := describeLineage(ctx, nodeStorage, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("describe volume lineage: %w", err)
+	}
+
+	logger.Info().
+		Str("path", filePath).
+		Int("chain_depth", len(lineage.Chain)).
+		Int("dependents", len(lineage.Dependents)).
+		Int("references", len(lineage.References)).
+		Msg("Volume lineage described successfully")
+
+	return lineage, nil
+}
+
 // ResizeVolume 扩容卷
 func (s *VolumeService) ResizeVolume(ctx context.Context, req *entity.ResizeVolumeRequest) (*entity.Volume, error) {
 	logger := zerolog.Ctx(ctx)
@@ -296,11 +587,23 @@ func (s *VolumeService) ResizeVolume(ctx context.Context, req *entity.ResizeVolu
 		return nil, fmt.Errorf("get node storage: %w", err)
 	}
 
-	// 使用 libvirt API 调整大小
-	err = nodeStorage.ResizeVolume(req.PoolName, volume.Name, req.NewSizeGB)
+	backend, err := newVolumeBackend(nodeStorage, req.PoolName)
+	if err != nil {
+		return nil, err
+	}
+
+	if isDryRun(ctx) {
+		if err := checkPoolCapacity(nodeStorage, req.PoolName, req.NewSizeGB-currentSizeGB); err != nil {
+			return nil, err
+		}
+		return nil, dryRunOperation(ctx, "ResizeVolume")
+	}
+
+	err = backend.ResizeVolume(ctx, volume.Name, req.NewSizeGB)
 	if err != nil {
 		return nil, fmt.Errorf("resize volume: %w", err)
 	}
+	s.changes.Record(entity.ResourceTypeVolume, req.NodeName, volumeIDFromName(req.VolumeID), entity.ResourceChangeModified)
 
 	// 重新查询卷信息
 	updatedVolume, err := s.DescribeVolume(ctx, describeReq)
@@ -331,26 +634,71 @@ func (s *VolumeService) DeleteVolume(ctx context.Context, req *entity.DeleteVolu
 		return fmt.Errorf("get node storage: %w", err)
 	}
 
+	// 删除保护在 DryRun 时同样校验
+	if err := s.checkDeletionProtection(nodeStorage, req.NodeName, req.PoolName, req.VolumeID); err != nil {
+		return err
+	}
+
+	if isDryRun(ctx) {
+		if _, err := s.DescribeVolume(ctx, &entity.DescribeVolumeRequest{
+			NodeName: req.NodeName,
+			PoolName: req.PoolName,
+			VolumeID: req.VolumeID,
+		}); err != nil {
+			return fmt.Errorf("get volume: %w", err)
+		}
+		return dryRunOperation(ctx, "DeleteVolume")
+	}
+
+	backend, err := newVolumeBackend(nodeStorage, req.PoolName)
+	if err != nil {
+		return err
+	}
+
+	// 回收站通过移动文件实现，zvol 不支持，直接删除
+	if s.recycleBin.Enabled() && !req.Permanent && backend.Name() == VolumeBackendDir {
+		if err := s.recycleVolume(ctx, nodeStorage, req); err != nil {
+			return err
+		}
+		s.changes.Record(entity.ResourceTypeVolume, req.NodeName, volumeIDFromName(req.VolumeID), entity.ResourceChangeDeleted)
+		return nil
+	}
+
+	// 卷标签按文件路径记录，删除前取得路径以便删除后清理
+	var volumePath string
+	if volInfo, err := findPoolVolume(nodeStorage, req.PoolName, req.VolumeID); err == nil {
+		volumePath = volInfo.Path
+	}
+
 	// 首先尝试直接使用原始名称删除（可能已经包含扩展名）
-	err = nodeStorage.DeleteVolume(req.PoolName, req.VolumeID)
+	err = backend.DeleteVolume(ctx, req.VolumeID)
 	if err == nil {
 		logger.Info().
 			Str("volume_id", req.VolumeID).
 			Msg("Volume deleted successfully")
+		s.clearVolumeLabels(ctx, req.NodeName, volumePath)
+		s.changes.Record(entity.ResourceTypeVolume, req.NodeName, volumeIDFromName(req.VolumeID), entity.ResourceChangeDeleted)
 		return nil
 	}
 
+	// 卷存在但不能删除（zvol 仍有克隆）时直接返回
+	if errors.Is(err, zfs.ErrHasDependents) {
+		return err
+	}
+
 	// 如果原始名称失败，尝试添加各种扩展名
 	extensions := []string{".qcow2", ".raw", ".img", ".iso"}
 	var lastErr error = err
 	for _, ext := range extensions {
 		volumeName := req.VolumeID + ext
-		err = nodeStorage.DeleteVolume(req.PoolName, volumeName)
+		err = backend.DeleteVolume(ctx, volumeName)
 		if err == nil {
 			logger.Info().
 				Str("volume_id", req.VolumeID).
 				Str("volume_name", volumeName).
 				Msg("Volume deleted successfully")
+			s.clearVolumeLabels(ctx, req.NodeName, volumePath)
+			s.changes.Record(entity.ResourceTypeVolume, req.NodeName, volumeIDFromName(req.VolumeID), entity.ResourceChangeDeleted)
 			return nil
 		}
 		lastErr = err
@@ -359,6 +707,81 @@ func (s *VolumeService) DeleteVolume(ctx context.Context, req *entity.DeleteVolu
 	return fmt.Errorf("delete volume: %w", lastErr)
 }
 
+// ModifyVolumeAttribute 修改卷属性，目前支持删除保护与 multi-attach
+func (s *VolumeService) ModifyVolumeAttribute(ctx context.Context, req *entity.ModifyVolumeAttributeRequest) (*entity.Volume, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("volume_id", req.VolumeID).
+		Msg("Modifying volume attribute")
+
+	nodeStorage, err := s.nodeService.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, fmt.Errorf("get node storage: %w", err)
+	}
+
+	volInfo, err := findPoolVolume(nodeStorage, req.PoolName, req.VolumeID)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Volume %s not found in pool %s", req.VolumeID, req.PoolName),
+			http.StatusNotFound,
+		)
+	}
+
+	// multi-attach 决定挂载时 disk XML 是否带 shareable，卷挂载中修改会导致已有挂载与标志不一致
+	if req.MultiAttach != nil {
+		if *req.MultiAttach {
+			if err := validateMultiAttachFormat(volInfo.Format); err != nil {
+				return nil, err
+			}
+		}
+		attachments, err := findVolumeAttachments(nodeStorage, volInfo.Path)
+		if err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to check volume attachment", err)
+		}
+		if len(attachments) > 0 {
+			return nil, apierror.NewErrorWithStatus(
+				"VolumeInUse",
+				fmt.Sprintf("Volume %s is attached to instance %s, detach it before modifying multi-attach", req.VolumeID, attachments[0].instanceID),
+				http.StatusConflict,
+			)
+		}
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "ModifyVolumeAttribute")
+	}
+
+	if req.DeletionProtection != nil {
+		if err := s.protection.SetVolume(req.NodeName, volInfo.Path, *req.DeletionProtection); err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to modify deletion protection", err)
+		}
+		logger.Info().
+			Str("volume_id", req.VolumeID).
+			Bool("deletion_protection", *req.DeletionProtection).
+			Msg("Volume deletion protection modified")
+		s.changes.Record(entity.ResourceTypeVolume, req.NodeName, volumeIDFromName(volInfo.Name), entity.ResourceChangeModified)
+	}
+	if req.MultiAttach != nil {
+		if err := s.multiAttach.SetVolume(req.NodeName, volInfo.Path, *req.MultiAttach); err != nil {
+			return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to modify multi-attach", err)
+		}
+		logger.Info().
+			Str("volume_id", req.VolumeID).
+			Bool("multi_attach", *req.MultiAttach).
+			Msg("Volume multi-attach modified")
+		s.changes.Record(entity.ResourceTypeVolume, req.NodeName, volumeIDFromName(volInfo.Name), entity.ResourceChangeModified)
+	}
+
+	return s.DescribeVolume(ctx, &entity.DescribeVolumeRequest{
+		NodeName: req.NodeName,
+		PoolName: req.PoolName,
+		VolumeID: req.VolumeID,
+	})
+}
+
 // CreateVolumeFromURL 从 URL 下载并创建存储卷
 func (s *VolumeService) CreateVolumeFromURL(ctx context.Context, req *entity.CreateVolumeFromURLRequest) (*entity.Volume, error) {
 	logger := zerolog.Ctx(ctx)
@@ -367,8 +790,13 @@ func (s *VolumeService) CreateVolumeFromURL(ctx context.Context, req *entity.Cre
 		Str("pool_name", req.PoolName).
 		Str("name", req.Name).
 		Str("url", req.URL).
+		Uint64("bandwidth_mib", req.BandwidthMiB).
 		Msg("Creating volume from URL")
 
+	if err := validateResourceName("volume", req.Name); err != nil {
+		return nil, err
+	}
+
 	// 生成 Volume ID
 	volumeID, err := s.idGen.GenerateVolumeID()
 	if err != nil {
@@ -381,8 +809,16 @@ func (s *VolumeService) CreateVolumeFromURL(ctx context.Context, req *entity.Cre
 		return nil, fmt.Errorf("get node storage: %w", err)
 	}
 
+	if _, err := nodeStorage.GetVolume(req.PoolName, req.Name); err == nil {
+		return nil, newResourceAlreadyExistsError("Volume", req.Name)
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CreateVolumeFromURL")
+	}
+
 	// 下载文件到存储池
-	if err := downloadToPool(nodeStorage, req.PoolName, req.Name, req.URL); err != nil {
+	if err := downloadToPool(ctx, nodeStorage, req.PoolName, req.Name, req.URL, s.transfer.resolve(req.BandwidthMiB)); err != nil {
 		return nil, fmt.Errorf("download volume from URL: %w", err)
 	}
 
@@ -391,6 +827,7 @@ func (s *VolumeService) CreateVolumeFromURL(ctx context.Context, req *entity.Cre
 	if err != nil {
 		return nil, fmt.Errorf("get volume info: %w", err)
 	}
+	revision := s.changes.Record(entity.ResourceTypeVolume, req.NodeName, volumeIDFromName(volInfo.Name), entity.ResourceChangeAdded)
 
 	// 构建返回的 Volume 对象
 	volume := &entity.Volume{
@@ -403,6 +840,8 @@ func (s *VolumeService) CreateVolumeFromURL(ctx context.Context, req *entity.Cre
 		SizeGB:      volInfo.CapacityB / (1024 * 1024 * 1024),
 		AllocationB: volInfo.AllocationB,
 		Format:      volInfo.Format,
+		Type:        volumeType(volInfo.Name),
+		Revision:    revision,
 	}
 
 	logger.Info().
@@ -412,3 +851,467 @@ func (s *VolumeService) CreateVolumeFromURL(ctx context.Context, req *entity.Cre
 
 	return volume, nil
 }
+
+// volumeAttachmentRef 卷在某个实例上的挂载
+type volumeAttachmentRef struct {
+	instanceID string
+	disk       libvirt.DomainDisk
+}
+
+// findVolumeAttachments 查找卷当前挂载的所有实例与设备，未挂载时返回空切片
+func findVolumeAttachments(client libvirt.DomainManager, volumePath string) ([]volumeAttachmentRef, error) {
+	domains, err := client.GetVMSummaries()
+	if err != nil {
+		return nil, fmt.Errorf("list domains: %w", err)
+	}
+	var attachments []volumeAttachmentRef
+	for _, domain := range domains {
+		disks, err := client.GetDomainDisks(domain.Name)
+		if err != nil {
+			continue
+		}
+		for _, disk := range disks {
+			if disk.SourcePath() == volumePath {
+				attachments = append(attachments, volumeAttachmentRef{instanceID: domain.Name, disk: disk})
+			}
+		}
+	}
+	return attachments, nil
+}
+
+// validateMultiAttachFormat 校验卷格式支持 multi-attach
+// qcow2 的元数据由 QEMU 进程独占维护，多个实例同时写入会损坏镜像，共享盘只能是 raw
+func validateMultiAttachFormat(format string) error {
+	if format != "raw" {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("multi-attach requires a raw volume, got %q", format),
+			http.StatusBadRequest,
+		)
+	}
+	return nil
+}
+
+// maxBackingChainDepth backing 链遍历的最大深度，防止异常的循环引用
+const maxBackingChainDepth = 32
+
+// volumeGraphEntry 存储池中的一个卷
+type volumeGraphEntry struct {
+	pool string
+	info *libvirt.VolumeInfo
+}
+
+// volumeGraph 节点上所有存储池卷的 backing 关系
+// 不在存储池根目录的文件（模板、快照 overlay）不在 libvirt 卷列表中，按需通过 qemu-img 读取
+type volumeGraph struct {
+	volumes  map[string]volumeGraphEntry
+	children map[string][]string
+	qemuImg  qemuimg.QemuImgClient
+}
+
+// buildVolumeGraph 扫描节点上所有存储池的卷，建立 backing file 到子卷的反向索引
+func buildVolumeGraph(client libvirt.StorageManager, qemuImg qemuimg.QemuImgClient, logger *zerolog.Logger) (*volumeGraph, error) {
+	pools, err := client.ListStoragePools()
+	if err != nil {
+		return nil, fmt.Errorf("list storage pools: %w", err)
+	}
+
+	g := &volumeGraph{
+		volumes:  make(map[string]volumeGraphEntry),
+		children: make(map[string][]string),
+		qemuImg:  qemuImg,
+	}
+	for _, pool := range pools {
+		volInfos, err := client.ListVolumes(pool.Name)
+		if err != nil {
+			logger.Debug().
+				Str("pool", pool.Name).
+				Err(err).
+				Msg("Skip volume listing for pool")
+			continue
+		}
+		for _, volInfo := range volInfos {
+			g.volumes[volInfo.Path] = volumeGraphEntry{pool: pool.Name, info: volInfo}
+			if volInfo.BackingPath != "" {
+				g.children[volInfo.BackingPath] = append(g.children[volInfo.BackingPath], volInfo.Path)
+			}
+		}
+	}
+	return g, nil
+}
+
+// backingOf 返回文件的直接 backing file，相对路径按文件所在目录解析
+func (g *volumeGraph) backingOf(ctx context.Context, filePath string) string {
+	var backing string
+	if entry, ok := g.volumes[filePath]; ok {
+		backing = entry.info.BackingPath
+	} else if g.qemuImg != nil {
+		b, err := g.qemuImg.GetBackingFile(ctx, filePath)
+		if err != nil {
+			zerolog.Ctx(ctx).Debug().
+				Str("path", filePath).
+				Err(err).
+				Msg("Failed to read backing file")
+			return ""
+		}
+		backing = b
+	}
+	if backing != "" && !path.IsAbs(backing) {
+		backing = path.Join(path.Dir(filePath), backing)
+	}
+	return backing
+}
+
+// chain 返回文件的 backing 链，从直接 backing 到最底层 base，不含自身
+func (g *volumeGraph) chain(ctx context.Context, filePath string) []string {
+	var result []string
+	visited := map[string]struct{}{filePath: {}}
+	current := filePath
+	for range maxBackingChainDepth {
+		backing := g.backingOf(ctx, current)
+		if backing == "" {
+			break
+		}
+		if _, ok := visited[backing]; ok {
+			break
+		}
+		visited[backing] = struct{}{}
+		result = append(result, backing)
+		current = backing
+	}
+	return result
+}
+
+// descendants 返回直接或间接以该文件为 backing 的存储池卷
+func (g *volumeGraph) descendants(filePath string) []string {
+	var result []string
+	visited := map[string]struct{}{filePath: {}}
+	queue := []string{filePath}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range g.children[current] {
+			if _, ok := visited[child]; ok {
+				continue
+			}
+			visited[child] = struct{}{}
+			result = append(result, child)
+			queue = append(queue, child)
+		}
+	}
+	return result
+}
+
+// node 构建 backing 链节点信息
+func (g *volumeGraph) node(ctx context.Context, filePath string) entity.VolumeLineageNode {
+	node := entity.VolumeLineageNode{
+		Name: path.Base(filePath),
+		Path: filePath,
+		Kind: lineageKind(filePath),
+	}
+	if entry, ok := g.volumes[filePath]; ok {
+		node.Name = entry.info.Name
+		node.Pool = entry.pool
+		node.Format = entry.info.Format
+	}
+	node.BackingPath = g.backingOf(ctx, filePath)
+	return node
+}
+
+// references 返回依赖该文件的 domain 磁盘：磁盘文件本身或其 backing 链中包含该文件
+// 同时返回磁盘链上位于该文件之上的文件，其中包含不在存储池卷列表中的快照 overlay
+func (g *volumeGraph) references(ctx context.Context, client libvirt.DomainManager, filePath string) ([]entity.VolumeLineageReference, []string, error) {
+	domains, err := client.GetVMSummaries()
+	if err != nil {
+		return nil, nil, fmt.Errorf("list domains: %w", err)
+	}
+
+	var refs []entity.VolumeLineageReference
+	var upper []string
+	for _, domain := range domains {
+		disks, err := client.GetDomainDisks(domain.Name)
+		if err != nil {
+			continue
+		}
+		for _, disk := range disks {
+			source := disk.Source.File
+			if disk.Device != "disk" || source == "" {
+				continue
+			}
+			files := append([]string{source}, g.chain(ctx, source)...)
+			idx := slices.Index(files, filePath)
+			if idx < 0 {
+				continue
+			}
+			refs = append(refs, entity.VolumeLineageReference{
+				DomainName: domain.Name,
+				Device:     disk.Target.Dev,
+				Source:     source,
+				Direct:     idx == 0,
+			})
+			upper = append(upper, files[:idx]...)
+		}
+	}
+	return refs, upper, nil
+}
+
+// lineageKind 根据文件所在目录判断 backing 链节点类型
+func lineageKind(filePath string) string {
+	switch {
+	case strings.Contains(filePath, "/"+TemplatesDirName+"/"), strings.Contains(filePath, "/"+TemplateCacheDirName+"/"):
+		return entity.VolumeLineageKindTemplate
+	case strings.Contains(filePath, "/"+SnapshotsDirName+"/"):
+		return entity.VolumeLineageKindSnapshot
+	default:
+		return entity.VolumeLineageKindVolume
+	}
+}
+
+// describeLineage 汇总文件的 backing 链、子卷与 domain 引用
+func describeLineage(ctx context.Context, client libvirt.LibvirtClient, filePath string) (*entity.VolumeLineage, error) {
+	g, err := buildVolumeGraph(client, newQemuImgClient(client), zerolog.Ctx(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	lineage := &entity.VolumeLineage{
+		Volume:     g.node(ctx, filePath),
+		Chain:      []entity.VolumeLineageNode{},
+		Dependents: []entity.VolumeLineageNode{},
+	}
+	for _, p := range g.chain(ctx, filePath) {
+		lineage.Chain = append(lineage.Chain, g.node(ctx, p))
+	}
+	refs, upper, err := g.references(ctx, client, filePath)
+	if err != nil {
+		return nil, err
+	}
+	lineage.References = refs
+
+	seen := make(map[string]struct{})
+	for _, p := range append(g.descendants(filePath), upper...) {
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		lineage.Dependents = append(lineage.Dependents, g.node(ctx, p))
+	}
+	if lineage.References == nil {
+		lineage.References = []entity.VolumeLineageReference{}
+	}
+	return lineage, nil
+}
+
+// AttachVolume 附加卷到实例
+// 实例运行时热插拔，卷的 serial 固定为 volume ID，guest 内可通过 /dev/disk/by-id 稳定定位
+func (s *VolumeService) AttachVolume(ctx context.Context, req *entity.AttachVolumeRequest) (*entity.VolumeAttachment, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("volume_id", req.VolumeID).
+		Str("instance_id", req.InstanceID).
+		Str("device", req.Device).
+		Str("bus", req.Bus).
+		Msg("Attaching volume")
+
+	bus := req.Bus
+	if bus == "" {
+		bus = "virtio"
+	}
+	if bus != "virtio" && bus != "scsi" && bus != "nvme" {
+		return nil, fmt.Errorf("unsupported bus %q, must be virtio, scsi or nvme", bus)
+	}
+	if driver := toLibvirtDiskDriver(req.DiskDriver); driver != nil {
+		if err := driver.Validate(); err != nil {
+			return nil, apierror.NewErrorWithStatus("InvalidParameterValue", err.Error(), http.StatusBadRequest)
+		}
+	}
+
+	volume, err := s.DescribeVolume(ctx, &entity.DescribeVolumeRequest{
+		NodeName: req.NodeName,
+		PoolName: req.PoolName,
+		VolumeID: req.VolumeID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get volume: %w", err)
+	}
+	if volume.Type != entity.VolumeTypeDisk {
+		return nil, fmt.Errorf("volume %s is a %s volume and cannot be attached as a disk", req.VolumeID, volume.Type)
+	}
+
+	nodeStorage, err := s.nodeService.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, fmt.Errorf("get node storage: %w", err)
+	}
+
+	// 只有开启 multi-attach 的卷可以同时附加到多个实例
+	attachments, err := findVolumeAttachments(nodeStorage, volume.Path)
+	if err != nil {
+		return nil, fmt.Errorf("check volume attachment: %w", err)
+	}
+	for _, attachment := range attachments {
+		if !volume.MultiAttach || attachment.instanceID == req.InstanceID {
+			return nil, fmt.Errorf("volume %s is already attached to instance %s as %s", req.VolumeID, attachment.instanceID, attachment.disk.Target.Dev)
+		}
+	}
+	if req.AutoFormatAndMount != nil {
+		if err := validateVolumeMountOptions(req.AutoFormatAndMount, volume.MultiAttach); err != nil {
+			return nil, err
+		}
+		if err := ensureInstanceGuestAgent(nodeStorage, req.InstanceID); err != nil {
+			return nil, err
+		}
+	}
+	if volume.MultiAttach {
+		// 多个 guest 共用同一个卷时不能经过宿主机页缓存，否则各实例看到的数据不一致
+		if driver := toLibvirtDiskDriver(req.DiskDriver); driver != nil && driver.Cache != "" && driver.Cache != "none" && driver.Cache != "directsync" {
+			return nil, apierror.NewErrorWithStatus(
+				"InvalidParameterValue",
+				fmt.Sprintf("multi-attach volume %s requires cache mode none or directsync, got %s", req.VolumeID, driver.Cache),
+				http.StatusBadRequest,
+			)
+		}
+	}
+
+	if isDryRun(ctx) {
+		if _, err := nodeStorage.GetDomainByName(req.InstanceID); err != nil {
+			return nil, fmt.Errorf("get instance %s: %w", req.InstanceID, err)
+		}
+		return nil, dryRunOperation(ctx, "AttachVolume")
+	}
+
+	format := volume.Format
+	if format == "" {
+		format = "qcow2"
+	}
+
+	// virtio-blk 的 serial 最长 20 字节，超出部分会被 guest 截断
+	serial := req.VolumeID
+	device, err := nodeStorage.AttachDiskToDomain(req.InstanceID, &libvirt.AttachDiskConfig{
+		VolumePath: volume.Path,
+		Device:     req.Device,
+		Bus:        bus,
+		Format:     format,
+		Serial:     serial,
+		Controller: libvirt.DiskControllerConfig{
+			Queues:   req.Queues,
+			IOThread: req.IOThread,
+		},
+		Driver:    toLibvirtDiskDriver(req.DiskDriver),
+		Shareable: volume.MultiAttach,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("attach disk to domain: %w", err)
+	}
+	s.changes.Record(entity.ResourceTypeVolume, req.NodeName, volumeIDFromName(req.VolumeID), entity.ResourceChangeModified)
+	s.changes.Record(entity.ResourceTypeInstance, req.NodeName, req.InstanceID, entity.ResourceChangeModified)
+
+	logger.Info().
+		Str("volume_id", req.VolumeID).
+		Str("instance_id", req.InstanceID).
+		Str("device", device).
+		Msg("Volume attached successfully")
+
+	attachment := &entity.VolumeAttachment{
+		VolumeID:   req.VolumeID,
+		InstanceID: req.InstanceID,
+		Device:     device,
+		Bus:        bus,
+		Serial:     serial,
+	}
+	if req.AutoFormatAndMount != nil {
+		// 卷已附加，guest 内操作失败时保留附加状态，由用户排查后手动挂载或分离
+		if err := formatAndMountVolume(nodeStorage, req.InstanceID, bus, serial, req.AutoFormatAndMount); err != nil {
+			return nil, apierror.WrapError(
+				apierror.ErrInternalError,
+				fmt.Sprintf("Volume %s attached to instance %s as %s, but auto format and mount failed", req.VolumeID, req.InstanceID, device),
+				err,
+			)
+		}
+		attachment.MountPoint = req.AutoFormatAndMount.MountPoint
+		logger.Info().
+			Str("volume_id", req.VolumeID).
+			Str("fs_type", req.AutoFormatAndMount.FSType).
+			Str("mount_point", req.AutoFormatAndMount.MountPoint).
+			Msg("Volume formatted and mounted in guest")
+	}
+	return attachment, nil
+}
+
+// DetachVolume 从实例分离卷
+func (s *VolumeService) DetachVolume(ctx context.Context, req *entity.DetachVolumeRequest) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("volume_id", req.VolumeID).
+		Str("instance_id", req.InstanceID).
+		Msg("Detaching volume")
+
+	volume, err := s.DescribeVolume(ctx, &entity.DescribeVolumeRequest{
+		NodeName: req.NodeName,
+		PoolName: req.PoolName,
+		VolumeID: req.VolumeID,
+	})
+	if err != nil {
+		return fmt.Errorf("get volume: %w", err)
+	}
+
+	nodeStorage, err := s.nodeService.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return fmt.Errorf("get node storage: %w", err)
+	}
+
+	disks, err := nodeStorage.GetDomainDisks(req.InstanceID)
+	if err != nil {
+		return fmt.Errorf("get domain disks: %w", err)
+	}
+
+	// 系统盘为第一块 disk 设备，设备名随总线不同（vda、sda、nvme0n1）
+	device, systemDevice := "", ""
+	for _, disk := range disks {
+		if systemDevice == "" && disk.Device == "disk" {
+			systemDevice = disk.Target.Dev
+		}
+		if disk.Source.File == volume.Path {
+			device = disk.Target.Dev
+		}
+	}
+	if device == "" {
+		return fmt.Errorf("volume %s is not attached to instance %s", req.VolumeID, req.InstanceID)
+	}
+	// 系统盘不能分离
+	if device == systemDevice {
+		return fmt.Errorf("volume %s is the system disk of instance %s and cannot be detached", req.VolumeID, req.InstanceID)
+	}
+
+	if isDryRun(ctx) {
+		return dryRunOperation(ctx, "DetachVolume")
+	}
+
+	if err := nodeStorage.DetachDiskFromDomain(req.InstanceID, device); err != nil {
+		if errors.Is(err, libvirt.ErrDeviceBusy) {
+			return apierror.NewErrorWithRawAndStatus(
+				"VolumeInUse",
+				fmt.Sprintf("Volume %s is still in use by instance %s, unmount it in the guest and retry", req.VolumeID, req.InstanceID),
+				http.StatusConflict,
+				err,
+			)
+		}
+		if errors.Is(err, libvirt.ErrDomainConfigConflict) {
+			return newDomainConfigConflictError(req.InstanceID, err)
+		}
+		return fmt.Errorf("detach disk from domain: %w", err)
+	}
+	s.changes.Record(entity.ResourceTypeVolume, req.NodeName, volumeIDFromName(req.VolumeID), entity.ResourceChangeModified)
+	s.changes.Record(entity.ResourceTypeInstance, req.NodeName, req.InstanceID, entity.ResourceChangeModified)
+
+	logger.Info().
+		Str("volume_id", req.VolumeID).
+		Str("instance_id", req.InstanceID).
+		Str("device", device).
+		Msg("Volume detached successfully")
+
+	return nil
+}
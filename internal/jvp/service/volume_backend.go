@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/jimyag/jvp/pkg/qemuimg"
+	"github.com/jimyag/jvp/pkg/zfs"
+	"github.com/rs/zerolog"
+)
+
+// 卷后端名称
+const (
+	VolumeBackendDir = "dir" // 文件卷：libvirt 存储卷 + qemu-img
+	VolumeBackendZFS = "zfs" // zvol：直接执行 zfs 命令，克隆基于快照，秒级完成
+)
+
+// zfsCloneSnapshotPrefix 克隆卷时在源卷上创建的快照名前缀，克隆卷删除后延迟回收
+const zfsCloneSnapshotPrefix = "jvp-clone-"
+
+// VolumeBackend 卷后端，屏蔽不同类型存储池创建、克隆、扩容、删除卷的差异
+// 每个后端对象绑定一个节点上的一个存储池，由 newVolumeBackend 按存储池类型创建
+type VolumeBackend interface {
+	// Name 后端名称：dir / zfs
+	Name() string
+	// VolumeName 根据卷名称与请求的格式返回卷在存储池中的名称与实际格式，格式不支持时返回错误
+	VolumeName(name, format string) (string, string, error)
+	// CreateVolume 创建空卷，volumeName 为 VolumeName 返回的名称
+	CreateVolume(ctx context.Context, volumeName string, sizeGB uint64, format string) (*libvirt.VolumeInfo, error)
+	// CloneVolume 以 source 为源创建新卷，sizeGB 大于源卷容量时同时扩容
+	CloneVolume(ctx context.Context, source *libvirt.VolumeInfo, volumeName string, sizeGB uint64) (*libvirt.VolumeInfo, error)
+	// ResizeVolume 扩容卷
+	ResizeVolume(ctx context.Context, volumeName string, sizeGB uint64) error
+	// DeleteVolume 删除卷
+	DeleteVolume(ctx context.Context, volumeName string) error
+}
+
+// newVolumeBackend 按存储池类型选择卷后端：zfs 存储池使用 zfs 后端，其余使用文件卷后端
+func newVolumeBackend(client libvirt.LibvirtClient, poolName string) (VolumeBackend, error) {
+	pool, err := client.GetStoragePool(poolName)
+	if err != nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Storage pool %s not found", poolName),
+			http.StatusNotFound,
+		)
+	}
+	if pool.Type == VolumeBackendZFS {
+		if pool.Source == "" {
+			return nil, fmt.Errorf("zfs storage pool %s has no source dataset", poolName)
+		}
+		zfsClient := zfs.New("")
+		if client.IsRemoteConnection() {
+			target, err := client.GetSSHTarget()
+			if err != nil {
+				return nil, fmt.Errorf("get SSH target: %w", err)
+			}
+			zfsClient = zfsClient.WithSSHTarget(target)
+		}
+		return &zfsVolumeBackend{client: client, pool: pool, zfs: zfsClient}, nil
+	}
+	return &dirVolumeBackend{client: client, pool: pool, qemuImg: newQemuImgClient(client)}, nil
+}
+
+// dirVolumeBackend 文件卷后端，卷为存储池目录下的 qcow2/raw 文件
+type dirVolumeBackend struct {
+	client  libvirt.LibvirtClient
+	pool    *libvirt.StoragePoolInfo
+	qemuImg qemuimg.QemuImgClient
+}
+
+func (b *dirVolumeBackend) Name() string {
+	return VolumeBackendDir
+}
+
+func (b *dirVolumeBackend) VolumeName(name, format string) (string, string, error) {
+	switch format {
+	case "", "qcow2":
+		return name + ".qcow2", "qcow2", nil
+	case "raw":
+		return name + ".raw", "raw", nil
+	default:
+		return "", "", apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported volume format %q, must be qcow2 or raw", format),
+			http.StatusBadRequest,
+		)
+	}
+}
+
+func (b *dirVolumeBackend) CreateVolume(ctx context.Context, volumeName string, sizeGB uint64, format string) (*libvirt.VolumeInfo, error) {
+	return b.client.CreateVolume(b.pool.Name, volumeName, sizeGB, format)
+}
+
+// CloneVolume 通过 qemu-img convert 完整复制源卷，新卷不依赖源卷
+func (b *dirVolumeBackend) CloneVolume(ctx context.Context, source *libvirt.VolumeInfo, volumeName string, sizeGB uint64) (*libvirt.VolumeInfo, error) {
+	format := source.Format
+	if format != "raw" {
+		format = "qcow2"
+	}
+	target := path.Join(b.pool.Path, volumeName)
+	if err := b.qemuImg.Convert(ctx, source.Format, format, source.Path, target); err != nil {
+		return nil, fmt.Errorf("copy volume: %w", err)
+	}
+	if err := b.client.RefreshStoragePool(b.pool.Name); err != nil {
+		return nil, fmt.Errorf("refresh storage pool: %w", err)
+	}
+	if sizeGB*1024*1024*1024 > source.CapacityB {
+		if err := b.client.ResizeVolume(b.pool.Name, volumeName, sizeGB); err != nil {
+			return nil, fmt.Errorf("resize cloned volume: %w", err)
+		}
+	}
+	return b.client.GetVolume(b.pool.Name, volumeName)
+}
+
+func (b *dirVolumeBackend) ResizeVolume(ctx context.Context, volumeName string, sizeGB uint64) error {
+	return b.client.ResizeVolume(b.pool.Name, volumeName, sizeGB)
+}
+
+func (b *dirVolumeBackend) DeleteVolume(ctx context.Context, volumeName string) error {
+	return b.client.DeleteVolume(b.pool.Name, volumeName)
+}
+
+// zfsVolumeBackend zfs 卷后端，卷为存储池 dataset 下的 zvol（/dev/zvol/<dataset>/<name>）
+// 新卷为稀疏 zvol，克隆通过源卷快照 + zfs clone 完成，与数据量无关
+type zfsVolumeBackend struct {
+	client libvirt.LibvirtClient
+	pool   *libvirt.StoragePoolInfo
+	zfs    zfs.ZFSClient
+}
+
+func (b *zfsVolumeBackend) Name() string {
+	return VolumeBackendZFS
+}
+
+// dataset 返回卷对应的 zvol dataset 名称
+func (b *zfsVolumeBackend) dataset(volumeName string) string {
+	return b.pool.Source + "/" + volumeName
+}
+
+// VolumeName zvol 只支持 raw 格式，卷名称不带扩展名
+func (b *zfsVolumeBackend) VolumeName(name, format string) (string, string, error) {
+	if format != "" && format != "raw" {
+		return "", "", apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("zfs storage pool %s only supports raw volumes", b.pool.Name),
+			http.StatusBadRequest,
+		)
+	}
+	return name, "raw", nil
+}
+
+// refreshAndGet 刷新存储池后查询卷，zfs 命令创建的 zvol 需要刷新后才出现在 libvirt 卷列表中
+func (b *zfsVolumeBackend) refreshAndGet(volumeName string) (*libvirt.VolumeInfo, error) {
+	if err := b.client.RefreshStoragePool(b.pool.Name); err != nil {
+		return nil, fmt.Errorf("refresh storage pool: %w", err)
+	}
+	info, err := b.client.GetVolume(b.pool.Name, volumeName)
+	if err != nil {
+		return nil, err
+	}
+	// libvirt 的 zfs 存储池不记录卷格式，zvol 总是 raw
+	if info.Format == "" || info.Format == "unknown" {
+		info.Format = "raw"
+	}
+	return info, nil
+}
+
+func (b *zfsVolumeBackend) CreateVolume(ctx context.Context, volumeName string, sizeGB uint64, format string) (*libvirt.VolumeInfo, error) {
+	if err := b.zfs.CreateVolume(ctx, b.dataset(volumeName), sizeGB*1024*1024*1024, true); err != nil {
+		return nil, err
+	}
+	return b.refreshAndGet(volumeName)
+}
+
+// CloneVolume 对源卷打快照并 zfs clone，新卷与源卷共享数据块
+// 源卷有克隆时不能直接删除，克隆卷删除后对应的快照延迟回收
+func (b *zfsVolumeBackend) CloneVolume(ctx context.Context, source *libvirt.VolumeInfo, volumeName string, sizeGB uint64) (*libvirt.VolumeInfo, error) {
+	snapshot := b.dataset(source.Name) + "@" + zfsCloneSnapshotPrefix + volumeName
+	if err := b.zfs.Snapshot(ctx, snapshot); err != nil {
+		return nil, err
+	}
+	if err := b.zfs.Clone(ctx, snapshot, b.dataset(volumeName)); err != nil {
+		if destroyErr := b.zfs.Destroy(ctx, snapshot, false); destroyErr != nil {
+			zerolog.Ctx(ctx).Warn().
+				Err(destroyErr).
+				Str("snapshot", snapshot).
+				Msg("Failed to destroy clone snapshot")
+		}
+		return nil, err
+	}
+	if sizeGB*1024*1024*1024 > source.CapacityB {
+		if err := b.zfs.SetVolSize(ctx, b.dataset(volumeName), sizeGB*1024*1024*1024); err != nil {
+			return nil, err
+		}
+	}
+	return b.refreshAndGet(volumeName)
+}
+
+func (b *zfsVolumeBackend) ResizeVolume(ctx context.Context, volumeName string, sizeGB uint64) error {
+	if err := b.zfs.SetVolSize(ctx, b.dataset(volumeName), sizeGB*1024*1024*1024); err != nil {
+		return err
+	}
+	return b.client.RefreshStoragePool(b.pool.Name)
+}
+
+// DeleteVolume 删除 zvol，克隆卷删除后延迟回收其来源快照
+func (b *zfsVolumeBackend) DeleteVolume(ctx context.Context, volumeName string) error {
+	dataset, err := b.zfs.Get(ctx, b.dataset(volumeName))
+	if err != nil {
+		return err
+	}
+	if dataset.Type != "volume" {
+		return fmt.Errorf("%s is a zfs %s, not a volume", dataset.Name, dataset.Type)
+	}
+	if err := b.zfs.Destroy(ctx, dataset.Name, false); err != nil {
+		if errors.Is(err, zfs.ErrHasDependents) {
+			return apierror.NewErrorWithRawAndStatus(
+				"VolumeInUse",
+				fmt.Sprintf("Volume %s has snapshots or cloned volumes, delete them first", volumeName),
+				http.StatusConflict,
+				err,
+			)
+		}
+		return err
+	}
+	if _, name, ok := strings.Cut(dataset.Origin, "@"); ok && strings.HasPrefix(name, zfsCloneSnapshotPrefix) {
+		if err := b.zfs.Destroy(ctx, dataset.Origin, true); err != nil {
+			zerolog.Ctx(ctx).Warn().
+				Err(err).
+				Str("snapshot", dataset.Origin).
+				Msg("Failed to destroy clone snapshot")
+		}
+	}
+	return b.client.RefreshStoragePool(b.pool.Name)
+}
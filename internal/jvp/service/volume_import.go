@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/rs/zerolog"
+)
+
+// CompleteVolumeImport 完成卷导入：拼接通过实例导入接口上传的分片，校验镜像后转换为存储池中的新 qcow2 卷
+// 用于导入 export-snapshot 导出的磁盘；只支持文件卷存储池，转换成功后删除暂存目录，失败时保留已拼接的镜像以便重试
+func (s *VolumeService) CompleteVolumeImport(ctx context.Context, req *entity.CompleteVolumeImportRequest) (*entity.Volume, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("import_id", req.ImportID).
+		Str("format", req.Format).
+		Str("requested_name", req.Name).
+		Msg("Completing volume import")
+
+	format := req.Format
+	switch format {
+	case "":
+		format = entity.InstanceImportFormatQCOW2
+	case entity.InstanceImportFormatQCOW2, entity.InstanceImportFormatRaw, entity.InstanceImportFormatVMDK, entity.InstanceImportFormatVHDX:
+	default:
+		return nil, apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported import format %q, must be qcow2, raw, vmdk or vhdx", req.Format),
+			http.StatusBadRequest,
+		)
+	}
+
+	volumeID, err := s.idGen.GenerateVolumeID()
+	if err != nil {
+		return nil, fmt.Errorf("generate volume ID: %w", err)
+	}
+	volumeName := req.Name
+	if volumeName == "" {
+		volumeName = volumeID
+	} else if err := validateResourceName("volume", volumeName); err != nil {
+		return nil, err
+	}
+
+	nodeStorage, err := s.nodeService.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, fmt.Errorf("get node storage: %w", err)
+	}
+	backend, err := newVolumeBackend(nodeStorage, req.PoolName)
+	if err != nil {
+		return nil, err
+	}
+	if backend.Name() != VolumeBackendDir {
+		return nil, apierror.NewErrorWithStatus(
+			"UnsupportedOperation",
+			fmt.Sprintf("Storage pool %s is a %s pool, volume import only supports file-backed pools", req.PoolName, backend.Name()),
+			http.StatusBadRequest,
+		)
+	}
+	fileName, _, err := backend.VolumeName(volumeName, "qcow2")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := nodeStorage.GetVolume(req.PoolName, fileName); err == nil {
+		return nil, newResourceAlreadyExistsError("Volume", fileName)
+	}
+
+	importDir, err := openImportDir(ctx, nodeStorage, req.PoolName, req.ImportID)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := pendingImportParts(ctx, nodeStorage, importDir, req.ImportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "CompleteVolumeImport")
+	}
+
+	uploadPath, err := assembleImportUpload(ctx, nodeStorage, importDir, parts)
+	if err != nil {
+		return nil, err
+	}
+	info, err := inspectImportImage(ctx, nodeStorage, uploadPath, format)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := nodeStorage.GetStoragePool(req.PoolName)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get storage pool", err)
+	}
+	volumePath := path.Join(pool.Path, fileName)
+	// 显式指定源格式，避免 raw 镜像内容被当作其他格式探测
+	command := fmt.Sprintf("qemu-img convert -f %s -O qcow2 %s %s",
+		shellx.Quote(format), shellx.Quote(uploadPath), shellx.Quote(volumePath))
+	if _, err := runNodeCommand(ctx, nodeStorage, command); err != nil {
+		_, _ = runNodeCommand(context.WithoutCancel(ctx), nodeStorage, "rm -f "+shellx.Quote(volumePath))
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to convert imported image", err)
+	}
+	if err := nodeStorage.RefreshStoragePool(req.PoolName); err != nil {
+		return nil, fmt.Errorf("refresh storage pool: %w", err)
+	}
+	volInfo, err := nodeStorage.GetVolume(req.PoolName, fileName)
+	if err != nil {
+		return nil, fmt.Errorf("get volume info: %w", err)
+	}
+
+	if _, err := runNodeCommand(ctx, nodeStorage, "rm -rf "+shellx.Quote(importDir)); err != nil {
+		logger.Warn().
+			Err(err).
+			Str("import_id", req.ImportID).
+			Msg("Failed to remove import directory")
+	}
+	revision := s.changes.Record(entity.ResourceTypeVolume, req.NodeName, volumeIDFromName(volInfo.Name), entity.ResourceChangeAdded)
+
+	logger.Info().
+		Str("import_id", req.ImportID).
+		Str("path", volInfo.Path).
+		Int64("virtual_size", info.VirtualSize).
+		Msg("Volume imported successfully")
+
+	return &entity.Volume{
+		ID:          volumeIDFromName(volInfo.Name),
+		Name:        volInfo.Name,
+		NodeName:    req.NodeName,
+		Pool:        req.PoolName,
+		Path:        volInfo.Path,
+		CapacityB:   volInfo.CapacityB,
+		SizeGB:      volInfo.CapacityB / (1024 * 1024 * 1024),
+		AllocationB: volInfo.AllocationB,
+		Format:      volInfo.Format,
+		Type:        volumeType(volInfo.Name),
+		Revision:    revision,
+	}, nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	libvirtlib "github.com/digitalocean/go-libvirt"
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+)
+
+const (
+	// defaultMountFSType 自动格式化未指定文件系统时使用的类型
+	defaultMountFSType = "ext4"
+	// volumeMountTimeout guest 内格式化与挂载的最长等待时间
+	volumeMountTimeout = 2 * time.Minute
+	// virtioSerialMaxLen virtio-blk 暴露给 guest 的 serial 最大长度，超出部分被截断
+	virtioSerialMaxLen = 20
+)
+
+// mountPointPattern 允许的挂载点路径，/etc/fstab 以空白分隔字段，挂载点不能包含空格等特殊字符
+var mountPointPattern = regexp.MustCompile(`^/[A-Za-z0-9._/-]+$`)
+
+// volumeMountScript 在 guest 内按 serial 找到新附加的磁盘，没有文件系统时格式化，写入 fstab 并挂载
+// 参数：$1 serial，$2 文件系统类型，$3 挂载点
+const volumeMountScript = `set -e
+serial="$1"; fstype="$2"; target="$3"
+dev=""
+for i in $(seq 1 30); do
+	for link in /dev/disk/by-id/*"$serial"; do
+		if [ -e "$link" ]; then dev=$(readlink -f "$link"); break; fi
+	done
+	[ -n "$dev" ] && break
+	sleep 1
+done
+if [ -z "$dev" ]; then echo "disk with serial $serial not found" >&2; exit 1; fi
+if [ -z "$(blkid -o value -s TYPE "$dev" 2>/dev/null)" ] && [ -z "$(blkid -o value -s PTTYPE "$dev" 2>/dev/null)" ]; then
+	mkfs -t "$fstype" "$dev"
+fi
+uuid=$(blkid -o value -s UUID "$dev")
+fstype=$(blkid -o value -s TYPE "$dev")
+if [ -z "$uuid" ]; then echo "$dev has a partition table or no file system, refusing to mount" >&2; exit 1; fi
+mkdir -p "$target"
+grep -q "^UUID=$uuid " /etc/fstab || echo "UUID=$uuid $target $fstype defaults,nofail 0 2" >> /etc/fstab
+mountpoint -q "$target" || mount "$target"
+`
+
+// validateVolumeMountOptions 校验自动格式化与挂载参数并填充默认值
+func validateVolumeMountOptions(opts *entity.VolumeMountOptions, multiAttach bool) error {
+	if multiAttach {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			"auto_format_and_mount is not supported for multi-attach volumes, ext4/xfs cannot be mounted by multiple instances",
+			http.StatusBadRequest,
+		)
+	}
+	if opts.FSType == "" {
+		opts.FSType = defaultMountFSType
+	}
+	if opts.FSType != "ext4" && opts.FSType != "xfs" {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("unsupported fs_type %q, must be ext4 or xfs", opts.FSType),
+			http.StatusBadRequest,
+		)
+	}
+	if !mountPointPattern.MatchString(opts.MountPoint) || path.Clean(opts.MountPoint) != opts.MountPoint || opts.MountPoint == "/" {
+		return apierror.NewErrorWithStatus(
+			"InvalidParameterValue",
+			fmt.Sprintf("invalid mount_point %q, must be a clean absolute path other than /", opts.MountPoint),
+			http.StatusBadRequest,
+		)
+	}
+	return nil
+}
+
+// ensureInstanceGuestAgent 校验实例运行中且 guest-agent 可用，自动挂载在附加卷之前校验，避免附加成功后才失败
+func ensureInstanceGuestAgent(client libvirt.LibvirtClient, instanceID string) error {
+	domain, err := client.GetDomainByName(instanceID)
+	if err != nil {
+		return fmt.Errorf("get instance %s: %w", instanceID, err)
+	}
+	state, _, err := client.GetDomainState(domain)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to get instance state", err)
+	}
+	available := false
+	if libvirtlib.DomainState(state) == libvirtlib.DomainRunning {
+		if available, err = client.CheckGuestAgentAvailable(domain); err != nil {
+			return apierror.WrapError(apierror.ErrInternalError, "Failed to check guest agent", err)
+		}
+	}
+	if !available {
+		return apierror.NewErrorWithStatus(
+			"IncorrectInstanceState",
+			fmt.Sprintf("auto_format_and_mount requires instance %s to be running with qemu-guest-agent available", instanceID),
+			http.StatusConflict,
+		)
+	}
+	return nil
+}
+
+// formatAndMountVolume 通过 guest-agent 在 guest 内格式化并挂载刚附加的卷
+func formatAndMountVolume(client libvirt.GuestAgentManager, instanceID, bus, serial string, opts *entity.VolumeMountOptions) error {
+	// virtio-blk 的 /dev/disk/by-id 链接名使用截断后的 serial
+	if bus == "virtio" && len(serial) > virtioSerialMaxLen {
+		serial = serial[:virtioSerialMaxLen]
+	}
+	result, err := client.GuestExec(instanceID, "/bin/sh", []string{"-c", volumeMountScript, "jvp-mount", serial, opts.FSType, opts.MountPoint}, volumeMountTimeout)
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("mount script exited with code %d: %s", result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+	return nil
+}
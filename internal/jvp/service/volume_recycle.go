@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/rs/zerolog"
+)
+
+// findPoolVolume 按卷 ID 查找卷，先按原始名称（可能已包含扩展名），再依次尝试常见扩展名
+func findPoolVolume(client libvirt.StorageManager, poolName, volumeID string) (*libvirt.VolumeInfo, error) {
+	volInfo, err := client.GetVolume(poolName, volumeID)
+	if err == nil {
+		return volInfo, nil
+	}
+	for _, ext := range []string{".qcow2", ".raw", ".img", ".iso"} {
+		if volInfo, err = client.GetVolume(poolName, volumeID+ext); err == nil {
+			return volInfo, nil
+		}
+	}
+	return nil, err
+}
+
+// recycleVolume 把卷移入存储池的回收目录，文件保留到过期后才物理删除
+func (s *VolumeService) recycleVolume(ctx context.Context, client libvirt.LibvirtClient, req *entity.DeleteVolumeRequest) error {
+	logger := zerolog.Ctx(ctx)
+
+	volInfo, err := findPoolVolume(client, req.PoolName, req.VolumeID)
+	if err != nil {
+		return apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Volume %s not found in pool %s", req.VolumeID, req.PoolName),
+			http.StatusNotFound,
+		)
+	}
+
+	existing, err := s.recycleBin.Get(req.NodeName, entity.RecycleBinResourceVolume, req.PoolName, req.VolumeID)
+	if err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to get recycle bin item", err)
+	}
+	if existing != nil {
+		return apierror.NewErrorWithStatus(
+			"ResourceAlreadyExists",
+			fmt.Sprintf("Volume %s is already in recycle bin of pool %s, delete it with permanent", req.VolumeID, req.PoolName),
+			http.StatusConflict,
+		)
+	}
+
+	item := s.recycleBin.newItem(entity.RecycleBinResourceVolume, req.VolumeID, req.NodeName)
+	item.PoolName = req.PoolName
+	item.VolumeName = volInfo.Name
+	item.OriginalPath = volInfo.Path
+	item.RecycledName = filepath.Join(filepath.Dir(volInfo.Path), RecycleDirName, volInfo.Name)
+
+	if err := movePoolFile(client, item.OriginalPath, item.RecycledName); err != nil {
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to move volume to recycle bin", err)
+	}
+	if err := s.recycleBin.Save(item); err != nil {
+		// 没有记录的卷不会被清理也无法恢复，移回原位置保持可见
+		if moveErr := movePoolFile(client, item.RecycledName, item.OriginalPath); moveErr != nil {
+			logger.Error().
+				Err(moveErr).
+				Str("volume_id", req.VolumeID).
+				Msg("Failed to move volume back after recycle bin failure")
+		}
+		return apierror.WrapError(apierror.ErrInternalError, "Failed to save recycle bin item", err)
+	}
+	if err := client.RefreshStoragePool(req.PoolName); err != nil {
+		logger.Warn().
+			Err(err).
+			Str("pool", req.PoolName).
+			Msg("Failed to refresh storage pool")
+	}
+
+	logger.Info().
+		Str("volume_id", req.VolumeID).
+		Str("recycled_path", item.RecycledName).
+		Time("expires_at", item.ExpiresAt).
+		Msg("Volume moved to recycle bin")
+	return nil
+}
+
+// RestoreVolume 从回收站恢复卷到原始位置
+func (s *VolumeService) RestoreVolume(ctx context.Context, req *entity.RestoreVolumeRequest) (*entity.Volume, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("node_name", req.NodeName).
+		Str("pool_name", req.PoolName).
+		Str("volume_id", req.VolumeID).
+		Msg("Restoring volume from recycle bin")
+
+	item, err := s.recycleBin.Get(req.NodeName, entity.RecycleBinResourceVolume, req.PoolName, req.VolumeID)
+	if err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to get recycle bin item", err)
+	}
+	if item == nil {
+		return nil, apierror.NewErrorWithStatus(
+			"ResourceNotFound",
+			fmt.Sprintf("Volume %s not found in recycle bin of pool %s", req.VolumeID, req.PoolName),
+			http.StatusNotFound,
+		)
+	}
+
+	client, err := s.nodeService.GetNodeStorage(ctx, req.NodeName)
+	if err != nil {
+		return nil, fmt.Errorf("get node storage: %w", err)
+	}
+
+	if _, err := client.GetVolume(req.PoolName, item.VolumeName); err == nil {
+		return nil, newResourceAlreadyExistsError("Volume", item.VolumeName)
+	}
+
+	if isDryRun(ctx) {
+		return nil, dryRunOperation(ctx, "RestoreVolume")
+	}
+
+	if err := movePoolFile(client, item.RecycledName, item.OriginalPath); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to move volume out of recycle bin", err)
+	}
+	if err := s.recycleBin.Delete(item); err != nil {
+		logger.Warn().
+			Err(err).
+			Str("volume_id", req.VolumeID).
+			Msg("Failed to delete recycle bin item")
+	}
+	if err := client.RefreshStoragePool(req.PoolName); err != nil {
+		return nil, apierror.WrapError(apierror.ErrInternalError, "Failed to refresh storage pool", err)
+	}
+
+	volInfo, err := client.GetVolume(req.PoolName, item.VolumeName)
+	if err != nil {
+		return nil, fmt.Errorf("get volume: %w", err)
+	}
+
+	logger.Info().
+		Str("volume_id", req.VolumeID).
+		Str("path", volInfo.Path).
+		Msg("Volume restored from recycle bin")
+
+	revision := s.changes.Record(entity.ResourceTypeVolume, req.NodeName, volumeIDFromName(volInfo.Name), entity.ResourceChangeAdded)
+	return &entity.Volume{
+		ID:          req.VolumeID,
+		Name:        volInfo.Name,
+		NodeName:    req.NodeName,
+		Pool:        req.PoolName,
+		Path:        volInfo.Path,
+		CapacityB:   volInfo.CapacityB,
+		SizeGB:      volInfo.CapacityB / (1024 * 1024 * 1024),
+		AllocationB: volInfo.AllocationB,
+		Format:      volInfo.Format,
+		Type:        volumeType(volInfo.Name),
+		Revision:    revision,
+	}, nil
+}
+
+// purgeRecycledVolume 物理删除回收站中的卷文件
+func (s *VolumeService) purgeRecycledVolume(ctx context.Context, item *entity.RecycleBinItem) error {
+	client, err := s.nodeService.GetNodeStorage(ctx, item.NodeName)
+	if err != nil {
+		return fmt.Errorf("get node storage: %w", err)
+	}
+	if err := removePoolFile(client, item.RecycledName); err != nil {
+		return fmt.Errorf("remove volume file %s: %w", item.RecycledName, err)
+	}
+	s.clearVolumeLabels(ctx, item.NodeName, item.OriginalPath)
+	return s.recycleBin.Delete(item)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jimyag/jvp/internal/jvp/entity"
+	"github.com/jimyag/jvp/pkg/apierror"
+	"github.com/jimyag/jvp/pkg/libvirt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVolumeAttachDetach(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	instance := s.runTestInstance(t, "vm-volume")
+	volume, err := s.volumes.CreateVolume(ctx, &entity.CreateVolumeRequest{
+		NodeName: testNodeName,
+		PoolName: testPoolName,
+		SizeGB:   5,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5<<30), volume.CapacityB)
+
+	attachment, err := s.volumes.AttachVolume(ctx, &entity.AttachVolumeRequest{
+		NodeName:   testNodeName,
+		PoolName:   testPoolName,
+		VolumeID:   volume.ID,
+		InstanceID: instance.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "vdb", attachment.Device)
+
+	disks, err := s.fake.GetDomainDisks(instance.ID)
+	require.NoError(t, err)
+	assert.True(t, hasDiskSource(disks, volume.Path))
+
+	// 未开启 multi-attach 的卷不能重复附加
+	_, err = s.volumes.AttachVolume(ctx, &entity.AttachVolumeRequest{
+		NodeName:   testNodeName,
+		PoolName:   testPoolName,
+		VolumeID:   volume.ID,
+		InstanceID: instance.ID,
+	})
+	assert.Error(t, err)
+
+	// DryRun 只校验，不分离卷
+	err = s.volumes.DetachVolume(WithDryRun(ctx, true), &entity.DetachVolumeRequest{
+		NodeName:   testNodeName,
+		PoolName:   testPoolName,
+		VolumeID:   volume.ID,
+		InstanceID: instance.ID,
+	})
+	require.ErrorIs(t, err, apierror.ErrDryRunOperation)
+	disks, err = s.fake.GetDomainDisks(instance.ID)
+	require.NoError(t, err)
+	assert.True(t, hasDiskSource(disks, volume.Path))
+
+	require.NoError(t, s.volumes.DetachVolume(ctx, &entity.DetachVolumeRequest{
+		NodeName:   testNodeName,
+		PoolName:   testPoolName,
+		VolumeID:   volume.ID,
+		InstanceID: instance.ID,
+	}))
+	disks, err = s.fake.GetDomainDisks(instance.ID)
+	require.NoError(t, err)
+	assert.False(t, hasDiskSource(disks, volume.Path))
+
+	require.NoError(t, s.volumes.DeleteVolume(ctx, &entity.DeleteVolumeRequest{
+		NodeName:  testNodeName,
+		PoolName:  testPoolName,
+		VolumeID:  volume.ID,
+		Permanent: true,
+	}))
+	_, err = s.volumes.DescribeVolume(ctx, &entity.DescribeVolumeRequest{
+		NodeName: testNodeName,
+		PoolName: testPoolName,
+		VolumeID: volume.ID,
+	})
+	assert.Error(t, err)
+}
+
+func TestDetachSystemDisk(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	instance := s.runTestInstance(t, "vm-system-disk")
+	disks, err := s.fake.GetDomainDisks(instance.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, disks)
+	volumes, err := s.volumes.ListVolumes(ctx, &entity.ListVolumesRequest{
+		NodeName: testNodeName,
+		PoolName: testPoolName,
+	})
+	require.NoError(t, err)
+	var systemVolumeID string
+	for _, volume := range volumes {
+		if volume.Path == disks[0].Source.File {
+			systemVolumeID = volume.ID
+		}
+	}
+	require.NotEmpty(t, systemVolumeID)
+
+	err = s.volumes.DetachVolume(ctx, &entity.DetachVolumeRequest{
+		NodeName:   testNodeName,
+		PoolName:   testPoolName,
+		VolumeID:   systemVolumeID,
+		InstanceID: instance.ID,
+	})
+	assert.Error(t, err)
+}
+
+// hasDiskSource 判断实例磁盘中是否有指定路径的卷
+func hasDiskSource(disks []libvirt.DomainDisk, path string) bool {
+	for _, disk := range disks {
+		if disk.Source.File == path {
+			return true
+		}
+	}
+	return false
+}
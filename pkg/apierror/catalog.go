@@ -0,0 +1,79 @@
+package apierror
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ErrorCode 错误目录中的一项，供客户端 SDK 自动生成错误处理逻辑
+type ErrorCode struct {
+	Code       string `xml:"Code"       json:"code"`
+	Message    string `xml:"Message"    json:"message"`     // 默认消息，实际响应中的消息通常带有资源名等上下文
+	HTTPStatus int    `xml:"HTTPStatus" json:"http_status"` // 返回该错误时的 HTTP 状态码
+	Retryable  bool   `xml:"Retryable"  json:"retryable"`   // 不修改请求、稍后原样重试可能成功（建议指数退避）
+}
+
+// catalogEntry 由预定义错误生成目录项
+func catalogEntry(err *Error, retryable bool) ErrorCode {
+	return ErrorCode{
+		Code:       err.Code,
+		Message:    err.Message,
+		HTTPStatus: err.HTTPStatus,
+		Retryable:  retryable,
+	}
+}
+
+// catalog jvp 会返回的全部错误代码：预定义错误与服务层直接按代码构造的错误
+// 服务层新增错误代码时需要同步登记到这里
+var catalog = []ErrorCode{
+	catalogEntry(ErrDryRunOperation, false),
+	catalogEntry(ErrPreconditionFailed, false),
+	catalogEntry(ErrBandwidthLimitExceeded, true),
+	catalogEntry(ErrInsufficientAddressCapacity, true),
+	catalogEntry(ErrInsufficientCapacity, true),
+	catalogEntry(ErrInsufficientInstanceCapacity, true),
+	catalogEntry(ErrInsufficientHostCapacity, true),
+	catalogEntry(ErrInsufficientReservedInstanceCapacity, true),
+	catalogEntry(ErrInsufficientVolumeCapacity, true),
+	catalogEntry(ErrServerInternal, true),
+	catalogEntry(ErrInternalFailure, true),
+	catalogEntry(ErrRequestLimitExceeded, true),
+	catalogEntry(ErrServiceUnavailable, true),
+	catalogEntry(ErrInternalError, true),
+	catalogEntry(ErrUnavailable, true),
+
+	{Code: "InvalidParameter", Message: "A parameter specified in the request is not valid, is unsupported, or cannot be used together with another parameter.", HTTPStatus: http.StatusBadRequest},
+	{Code: "InvalidParameterValue", Message: "A value specified in a parameter is not valid.", HTTPStatus: http.StatusBadRequest},
+	{Code: "UnsupportedOperation", Message: "The requested operation is not supported by the node or the resource.", HTTPStatus: http.StatusBadRequest},
+	{Code: "ConsoleNotAvailable", Message: "The requested console type is not configured for the instance.", HTTPStatus: http.StatusBadRequest},
+	{Code: "Template.InvalidPool", Message: "The storage pool cannot hold templates.", HTTPStatus: http.StatusBadRequest},
+	{Code: "Template.NotReplicable", Message: "The template cannot be replicated to another node.", HTTPStatus: http.StatusBadRequest},
+	{Code: "Template.VolumeNotFound", Message: "The volume backing the template does not exist.", HTTPStatus: http.StatusBadRequest},
+	{Code: "SecurityGroup.DuplicateRule", Message: "The security group already contains the specified rule.", HTTPStatus: http.StatusBadRequest},
+	{Code: "AuthFailure", Message: "The request does not declare a tenant or carries an invalid admin token.", HTTPStatus: http.StatusUnauthorized},
+	{Code: "OperationNotPermitted", Message: "The operation is not permitted, for example by deletion protection, quota or template visibility.", HTTPStatus: http.StatusForbidden},
+	{Code: "ResourceNotFound", Message: "The specified resource does not exist.", HTTPStatus: http.StatusNotFound},
+	{Code: "Template.NotFound", Message: "The specified template does not exist.", HTTPStatus: http.StatusNotFound},
+	{Code: "SecurityGroup.RuleNotFound", Message: "The security group does not contain the specified rule.", HTTPStatus: http.StatusNotFound},
+	{Code: "ResourceAlreadyExists", Message: "A resource with the specified name already exists.", HTTPStatus: http.StatusConflict},
+	{Code: "IncorrectInstanceState", Message: "The instance is in a state that does not allow the operation.", HTTPStatus: http.StatusConflict},
+	{Code: "IncorrectTaskState", Message: "The task has already finished and cannot be cancelled.", HTTPStatus: http.StatusConflict},
+	{Code: "VolumeInUse", Message: "The volume is attached to an instance.", HTTPStatus: http.StatusConflict},
+	{Code: "BlockDeviceInUse", Message: "The host block device is in use.", HTTPStatus: http.StatusConflict},
+	{Code: "TemplateInUse", Message: "The template is used by existing instances.", HTTPStatus: http.StatusConflict},
+	{Code: "NetworkSegmentInUse", Message: "The VLAN ID or VXLAN VNI is already used by another network.", HTTPStatus: http.StatusConflict},
+	{Code: "SecurityGroupInUse", Message: "The security group is used by existing instances.", HTTPStatus: http.StatusConflict},
+	{Code: "AmbiguousMetadataSource", Message: "The source IP of the metadata request is leased to instances on multiple nodes.", HTTPStatus: http.StatusConflict},
+	{Code: "ConcurrentModification", Message: "The resource configuration was modified concurrently, retry the request.", HTTPStatus: http.StatusConflict},
+	{Code: "ResourceExpired", Message: "The requested revision is no longer retained, list the resources again.", HTTPStatus: http.StatusGone},
+}
+
+// ListErrorCodes 返回错误目录，按代码排序
+func ListErrorCodes() []ErrorCode {
+	codes := slices.Clone(catalog)
+	slices.SortFunc(codes, func(a, b ErrorCode) int {
+		return strings.Compare(a.Code, b.Code)
+	})
+	return codes
+}
@@ -0,0 +1,23 @@
+package apierror
+
+import "net/http"
+
+// AWS EC2 客户端错误
+// https://docs.aws.amazon.com/zh_cn/AWSEC2/latest/APIReference/errors-overview.html#CommonErrors
+var (
+	// ErrDryRunOperation 请求设置了 DryRun，若未设置则会执行成功
+	// 该错误表示参数、权限与容量预检均已通过，但未执行任何实际变更
+	ErrDryRunOperation = &Error{
+		Code:       "DryRunOperation",
+		Message:    "Request would have succeeded, but DryRun flag is set.",
+		HTTPStatus: http.StatusPreconditionFailed, // 412
+	}
+
+	// ErrPreconditionFailed 请求的 If-Match 版本与资源当前版本不一致
+	// 该错误表示资源已被其他请求修改，调用方应重新查询后再决定是否重试
+	ErrPreconditionFailed = &Error{
+		Code:       "PreconditionFailed",
+		Message:    "The resource has been modified since the specified version.",
+		HTTPStatus: http.StatusPreconditionFailed, // 412
+	}
+)
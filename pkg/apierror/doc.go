@@ -53,6 +53,10 @@
 //   - ErrInternalError: 内部错误
 //   - ErrUnavailable: 服务器过载
 //
+// AWS EC2 客户端错误变量：
+//
+//   - ErrDryRunOperation: 设置了 DryRun，请求校验通过但未执行
+//
 // 使用示例：
 //
 //	// 直接使用预定义的错误
@@ -60,4 +64,9 @@
 //
 //	// 或创建自定义错误
 //	err := apierror.NewError("CustomError", "Custom error message")
+//
+// 错误目录：
+//
+// ListErrorCodes 返回 jvp 会返回的全部错误代码及默认消息、HTTP 状态码与是否可重试，
+// 通过 GET /api/errors 对外提供，供客户端 SDK 自动生成错误处理逻辑。新增错误代码时需要同步登记到 catalog。
 package apierror
@@ -0,0 +1,134 @@
+package cloudinit
+
+import "slices"
+
+// 内置应用模板名称
+const (
+	AppTemplateDockerHost = "docker-host"
+	AppTemplateK8sWorker  = "k8s-worker"
+	AppTemplateNFSServer  = "nfs-server"
+)
+
+// k8sWorkerVersion k8s-worker 模板安装的 Kubernetes 版本（pkgs.k8s.io 按 minor 版本划分仓库）
+const k8sWorkerVersion = "v1.31"
+
+// AppTemplate 应用模板：一组常见用途的软件包、文件与启动命令，合并到实例的 cloud-init 配置中
+// 内置模板面向 Debian/Ubuntu 系的 cloud image（使用 apt 安装软件包）
+type AppTemplate struct {
+	Name        string   // 模板名称
+	Description string   // 模板描述
+	Packages    []string // 要安装的软件包
+	WriteFiles  []File   // 要写入的文件
+	Commands    []string // 启动后执行的命令，先于用户的命令执行
+}
+
+var appTemplates = []AppTemplate{
+	{
+		Name:        AppTemplateDockerHost,
+		Description: "Docker 主机：安装发行版仓库中的 Docker Engine 并设置开机启动",
+		Packages:    []string{"docker.io"},
+		Commands: []string{
+			"systemctl enable --now docker",
+		},
+	},
+	{
+		Name:        AppTemplateK8sWorker,
+		Description: "Kubernetes 工作节点：配置内核模块与 sysctl，安装 containerd 与 kubelet/kubeadm/kubectl " + k8sWorkerVersion + "，启动后需手动执行 kubeadm join 加入集群",
+		Packages:    []string{"apt-transport-https", "ca-certificates", "curl", "gpg", "containerd"},
+		WriteFiles: []File{
+			{
+				Path:    "/etc/modules-load.d/k8s.conf",
+				Content: "overlay\nbr_netfilter\n",
+			},
+			{
+				Path:    "/etc/sysctl.d/k8s.conf",
+				Content: "net.bridge.bridge-nf-call-iptables = 1\nnet.bridge.bridge-nf-call-ip6tables = 1\nnet.ipv4.ip_forward = 1\n",
+			},
+		},
+		Commands: []string{
+			"modprobe overlay",
+			"modprobe br_netfilter",
+			"sysctl --system",
+			"swapoff -a",
+			"sed -i '/\\sswap\\s/ s/^/#/' /etc/fstab",
+			"mkdir -p /etc/containerd",
+			"containerd config default > /etc/containerd/config.toml",
+			"sed -i 's/SystemdCgroup = false/SystemdCgroup = true/' /etc/containerd/config.toml",
+			"systemctl restart containerd",
+			"mkdir -p -m 755 /etc/apt/keyrings",
+			"curl -fsSL https://pkgs.k8s.io/core:/stable:/" + k8sWorkerVersion + "/deb/Release.key | gpg --dearmor -o /etc/apt/keyrings/kubernetes-apt-keyring.gpg",
+			"echo 'deb [signed-by=/etc/apt/keyrings/kubernetes-apt-keyring.gpg] https://pkgs.k8s.io/core:/stable:/" + k8sWorkerVersion + "/deb/ /' > /etc/apt/sources.list.d/kubernetes.list",
+			"apt-get update",
+			"DEBIAN_FRONTEND=noninteractive apt-get install -y kubelet kubeadm kubectl",
+			"apt-mark hold kubelet kubeadm kubectl",
+			"systemctl enable --now kubelet",
+		},
+	},
+	{
+		Name:        AppTemplateNFSServer,
+		Description: "NFS 服务器：安装 nfs-kernel-server，导出 /srv/nfs（读写，允许所有客户端，生产环境请修改 /etc/exports.d/jvp.exports 限制网段）",
+		Packages:    []string{"nfs-kernel-server"},
+		WriteFiles: []File{
+			{
+				Path:    "/etc/exports.d/jvp.exports",
+				Content: "/srv/nfs *(rw,sync,no_subtree_check,no_root_squash)\n",
+			},
+		},
+		Commands: []string{
+			"mkdir -p /srv/nfs",
+			"chown nobody:nogroup /srv/nfs",
+			"systemctl enable --now nfs-server",
+			"exportfs -ra",
+		},
+	},
+}
+
+// AppTemplates 返回所有内置应用模板
+func AppTemplates() []AppTemplate {
+	return slices.Clone(appTemplates)
+}
+
+// GetAppTemplate 按名称查找内置应用模板
+func GetAppTemplate(name string) (*AppTemplate, bool) {
+	for i := range appTemplates {
+		if appTemplates[i].Name == name {
+			t := appTemplates[i]
+			return &t, true
+		}
+	}
+	return nil, false
+}
+
+// ApplyToConfig 将模板合并到 Config：软件包去重追加，文件与命令放在用户配置之前，同路径的文件以用户配置为准
+func (t *AppTemplate) ApplyToConfig(config *Config) {
+	config.Packages = mergePackages(t.Packages, config.Packages)
+	config.WriteFiles = append(slices.Clone(t.WriteFiles), config.WriteFiles...)
+	config.Commands = append(slices.Clone(t.Commands), config.Commands...)
+}
+
+// ApplyToUserData 将模板合并到原始 user-data，规则与 ApplyToConfig 相同
+func (t *AppTemplate) ApplyToUserData(userData *UserData) {
+	userData.Packages = mergePackages(t.Packages, userData.Packages)
+	writeFiles := make([]WriteFile, 0, len(t.WriteFiles)+len(userData.WriteFiles))
+	for _, file := range t.WriteFiles {
+		writeFiles = append(writeFiles, WriteFile{
+			Path:        file.Path,
+			Content:     file.Content,
+			Owner:       "root:root",
+			Permissions: "0644",
+		})
+	}
+	userData.WriteFiles = append(writeFiles, userData.WriteFiles...)
+	userData.RunCmd = append(slices.Clone(t.Commands), userData.RunCmd...)
+}
+
+// mergePackages 合并软件包列表并去重，保持先模板后用户的顺序
+func mergePackages(base, extra []string) []string {
+	packages := make([]string, 0, len(base)+len(extra))
+	for _, pkg := range append(slices.Clone(base), extra...) {
+		if !slices.Contains(packages, pkg) {
+			packages = append(packages, pkg)
+		}
+	}
+	return packages
+}
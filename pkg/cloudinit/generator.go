@@ -3,8 +3,8 @@ package cloudinit
 import (
 	"crypto/rand"
 	"fmt"
+	"strconv"
 
-	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/yaml.v3"
 )
 
@@ -90,6 +90,21 @@ func (g *Generator) GenerateNetworkConfigFromStruct(networkData *NetworkData) (s
 	return string(yamlData), nil
 }
 
+// MarshalYAML 将 version 输出为数字，cloud-init 只接受 version: 2，不接受字符串 "2"
+func (n NetworkData) MarshalYAML() (any, error) {
+	version, err := strconv.Atoi(n.Version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network-config version %q: %w", n.Version, err)
+	}
+	return struct {
+		Version   int                 `yaml:"version"`
+		Ethernets map[string]Ethernet `yaml:"ethernets,omitempty"`
+		Bonds     map[string]Bond     `yaml:"bonds,omitempty"`
+		Bridges   map[string]Bridge   `yaml:"bridges,omitempty"`
+		VLANs     map[string]VLAN     `yaml:"vlans,omitempty"`
+	}{version, n.Ethernets, n.Bonds, n.Bridges, n.VLANs}, nil
+}
+
 // GenerateUserData 生成 user-data 文件内容
 // 从高级的 Config 结构生成，自动处理密码哈希等
 func (g *Generator) GenerateUserData(config *Config) (string, error) {
@@ -122,9 +137,9 @@ func (g *Generator) GenerateUserData(config *Config) (string, error) {
 		for _, user := range config.Users {
 			// 处理密码哈希（如果提供了明文密码）
 			if user.PlainTextPasswd != "" && user.Passwd == "" && user.HashedPasswd == "" {
-				hashedPassword, err := HashPassword(user.PlainTextPasswd)
+				hashedPassword, err := HashPasswordWithOptions(user.PlainTextPasswd, config.PasswordHash)
 				if err != nil {
-					return "", fmt.Errorf("failed to hash password for user %s: %v", user.Name, err)
+					return "", fmt.Errorf("failed to hash password for user %s: %w", user.Name, err)
 				}
 				user.Passwd = hashedPassword
 				hasPassword = true
@@ -163,9 +178,9 @@ func (g *Generator) GenerateUserData(config *Config) (string, error) {
 
 		// 设置密码（如果提供）
 		if config.Password != "" {
-			hashedPassword, err := HashPassword(config.Password)
+			hashedPassword, err := HashPasswordWithOptions(config.Password, config.PasswordHash)
 			if err != nil {
-				return "", fmt.Errorf("failed to hash password: %v", err)
+				return "", fmt.Errorf("failed to hash password: %w", err)
 			}
 			userConfig.Passwd = hashedPassword
 
@@ -186,12 +201,23 @@ func (g *Generator) GenerateUserData(config *Config) (string, error) {
 	}
 
 	userData.Users = users
+	userData.SSHAuthorizedKeys = config.DefaultUserSSHKeys
 
 	// 禁用 root 登录
 	userData.DisableRoot = config.DisableRoot
 
-	// 设置时区
+	// 设置时区与语言环境
 	userData.Timezone = config.Timezone
+	userData.Locale = config.Locale
+
+	// 设置 NTP 服务器
+	if len(config.NTPServers) > 0 {
+		enabled := true
+		userData.NTP = &NTP{
+			Enabled: &enabled,
+			Servers: config.NTPServers,
+		}
+	}
 
 	// 要安装的软件包
 	userData.Packages = config.Packages
@@ -255,15 +281,6 @@ func (g *Generator) GenerateNetworkConfig(network *Network) (string, error) {
 	return string(yamlData), nil
 }
 
-// HashPassword 使用 bcrypt 加密密码
-func HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(hash), nil
-}
-
 // generateInstanceID 生成随机的 instance-id
 func generateInstanceID() (string, error) {
 	b := make([]byte, 16)
@@ -0,0 +1,355 @@
+package cloudinit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashAlgorithm 密码 hash 算法
+type HashAlgorithm string
+
+const (
+	HashSHA512   HashAlgorithm = "sha512"   // sha512-crypt（$6$，默认，所有主流发行版均支持）
+	HashBcrypt   HashAlgorithm = "bcrypt"   // bcrypt（$2b$，部分发行版的 PAM 不支持）
+	HashYescrypt HashAlgorithm = "yescrypt" // yescrypt（$y$，依赖宿主机 perl 与 libxcrypt）
+)
+
+const (
+	sha512DefaultRounds = 5000
+	sha512MinRounds     = 1000
+	sha512MaxRounds     = 999999999
+	sha512SaltLen       = 16
+
+	yescryptDefaultCost = 5
+	yescryptMinCost     = 1
+	yescryptMaxCost     = 11
+	yescryptSaltLen     = 16
+)
+
+// ErrYescryptUnavailable 宿主机无法计算 yescrypt：缺少 perl，或 libxcrypt 不支持 yescrypt
+var ErrYescryptUnavailable = errors.New("yescrypt is not available on this host")
+
+// crypt 系列算法使用的 base64 字母表
+const cryptAlphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// HashOptions 密码 hash 参数
+type HashOptions struct {
+	Algorithm HashAlgorithm // hash 算法（默认：sha512）
+	// Rounds 计算强度：
+	//   sha512: 迭代次数（1000-999999999，默认 5000）
+	//   bcrypt: cost（4-31，默认 10）
+	//   yescrypt: cost（1-11，默认 5）
+	Rounds int
+	Salt   string // 盐（可选，仅 sha512/yescrypt 支持，为空时随机生成）
+}
+
+// HashPassword 使用默认算法（sha512-crypt）加密密码
+func HashPassword(password string) (string, error) {
+	return HashPasswordWithOptions(password, nil)
+}
+
+// HashPasswordWithOptions 按指定算法、强度和盐加密密码
+func HashPasswordWithOptions(password string, opts *HashOptions) (string, error) {
+	if opts == nil {
+		opts = &HashOptions{}
+	}
+
+	switch opts.Algorithm {
+	case "", HashSHA512:
+		return hashSHA512(password, opts.Rounds, opts.Salt)
+	case HashBcrypt:
+		if opts.Salt != "" {
+			return "", fmt.Errorf("bcrypt does not support custom salt")
+		}
+		cost := opts.Rounds
+		if cost == 0 {
+			cost = bcrypt.DefaultCost
+		}
+		if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return "", fmt.Errorf("invalid bcrypt cost %d, must be between %d and %d", cost, bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	case HashYescrypt:
+		return hashYescrypt(password, opts.Rounds, opts.Salt)
+	default:
+		return "", fmt.Errorf("unsupported hash algorithm: %s", opts.Algorithm)
+	}
+}
+
+// VerifyPassword 校验明文密码与 hash 是否匹配，根据 hash 前缀自动识别算法
+func VerifyPassword(password, hash string) (bool, error) {
+	switch {
+	case strings.HasPrefix(hash, "$6$"):
+		rounds, salt, err := parseSHA512Setting(hash)
+		if err != nil {
+			return false, err
+		}
+		expected, err := hashSHA512(password, rounds, salt)
+		if err != nil {
+			return false, err
+		}
+		return subtle.ConstantTimeCompare([]byte(expected), []byte(hash)) == 1, nil
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	case strings.HasPrefix(hash, "$y$"):
+		idx := strings.LastIndex(hash, "$")
+		expected, err := systemCrypt(password, hash[:idx])
+		if err != nil {
+			return false, err
+		}
+		return subtle.ConstantTimeCompare([]byte(expected), []byte(hash)) == 1, nil
+	default:
+		return false, fmt.Errorf("unrecognized password hash format")
+	}
+}
+
+// randomCryptSalt 生成指定长度的随机盐（crypt base64 字母表）
+func randomCryptSalt(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = cryptAlphabet[int(b[i])%len(cryptAlphabet)]
+	}
+	return string(b), nil
+}
+
+// validateCryptSalt 检查盐是否只包含 crypt base64 字符
+func validateCryptSalt(salt string) error {
+	for i := 0; i < len(salt); i++ {
+		if strings.IndexByte(cryptAlphabet, salt[i]) < 0 {
+			return fmt.Errorf("invalid salt character %q", salt[i])
+		}
+	}
+	return nil
+}
+
+// parseSHA512Setting 从 $6$[rounds=N$]salt$hash 中解析 rounds 和盐
+func parseSHA512Setting(hash string) (int, string, error) {
+	rest := strings.TrimPrefix(hash, "$6$")
+	rounds := 0
+	if strings.HasPrefix(rest, "rounds=") {
+		end := strings.IndexByte(rest, '$')
+		if end < 0 {
+			return 0, "", fmt.Errorf("invalid sha512-crypt hash")
+		}
+		n, err := strconv.Atoi(rest[len("rounds="):end])
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid sha512-crypt rounds: %w", err)
+		}
+		rounds = n
+		rest = rest[end+1:]
+	}
+	end := strings.IndexByte(rest, '$')
+	if end < 0 {
+		return 0, "", fmt.Errorf("invalid sha512-crypt hash")
+	}
+	return rounds, rest[:end], nil
+}
+
+// hashSHA512 实现 sha512-crypt（Ulrich Drepper 规范，与 glibc crypt(3) 兼容）
+func hashSHA512(password string, rounds int, salt string) (string, error) {
+	customRounds := rounds != 0
+	if !customRounds {
+		rounds = sha512DefaultRounds
+	}
+	if rounds < sha512MinRounds {
+		rounds = sha512MinRounds
+	}
+	if rounds > sha512MaxRounds {
+		rounds = sha512MaxRounds
+	}
+
+	if salt == "" {
+		var err error
+		if salt, err = randomCryptSalt(sha512SaltLen); err != nil {
+			return "", fmt.Errorf("failed to generate salt: %w", err)
+		}
+	}
+	if len(salt) > sha512SaltLen {
+		salt = salt[:sha512SaltLen]
+	}
+	if err := validateCryptSalt(salt); err != nil {
+		return "", err
+	}
+
+	pw := []byte(password)
+	s := []byte(salt)
+
+	// Digest B
+	b := sha512.New()
+	b.Write(pw)
+	b.Write(s)
+	b.Write(pw)
+	sumB := b.Sum(nil)
+
+	// Digest A
+	a := sha512.New()
+	a.Write(pw)
+	a.Write(s)
+	for n := len(pw); n > 0; n -= sha512.Size {
+		if n > sha512.Size {
+			a.Write(sumB)
+		} else {
+			a.Write(sumB[:n])
+		}
+	}
+	for n := len(pw); n > 0; n >>= 1 {
+		if n&1 != 0 {
+			a.Write(sumB)
+		} else {
+			a.Write(pw)
+		}
+	}
+	sumA := a.Sum(nil)
+
+	// Digest DP -> P
+	dp := sha512.New()
+	for i := 0; i < len(pw); i++ {
+		dp.Write(pw)
+	}
+	p := repeatDigest(dp.Sum(nil), len(pw))
+
+	// Digest DS -> S
+	ds := sha512.New()
+	for i := 0; i < 16+int(sumA[0]); i++ {
+		ds.Write(s)
+	}
+	sBytes := repeatDigest(ds.Sum(nil), len(s))
+
+	// 迭代
+	c := sumA
+	for i := 0; i < rounds; i++ {
+		h := sha512.New()
+		if i&1 != 0 {
+			h.Write(p)
+		} else {
+			h.Write(c)
+		}
+		if i%3 != 0 {
+			h.Write(sBytes)
+		}
+		if i%7 != 0 {
+			h.Write(p)
+		}
+		if i&1 != 0 {
+			h.Write(c)
+		} else {
+			h.Write(p)
+		}
+		c = h.Sum(nil)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("$6$")
+	if customRounds {
+		fmt.Fprintf(&out, "rounds=%d$", rounds)
+	}
+	out.WriteString(salt)
+	out.WriteByte('$')
+	out.WriteString(encodeSHA512Digest(c))
+	return out.String(), nil
+}
+
+// repeatDigest 将 digest 重复拼接到指定长度
+func repeatDigest(sum []byte, length int) []byte {
+	out := make([]byte, 0, length)
+	for len(out)+len(sum) <= length {
+		out = append(out, sum...)
+	}
+	return append(out, sum[:length-len(out)]...)
+}
+
+// sha512Permutation sha512-crypt 输出编码的字节重排顺序
+var sha512Permutation = [21][3]int{
+	{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4},
+	{47, 5, 26}, {6, 27, 48}, {28, 49, 7}, {50, 8, 29}, {9, 30, 51},
+	{31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13}, {56, 14, 35},
+	{15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19},
+	{62, 20, 41},
+}
+
+// encodeSHA512Digest 按 crypt base64 编码最终 digest
+func encodeSHA512Digest(sum []byte) string {
+	var out strings.Builder
+	encode := func(v uint32, n int) {
+		for i := 0; i < n; i++ {
+			out.WriteByte(cryptAlphabet[v&0x3f])
+			v >>= 6
+		}
+	}
+	for _, t := range sha512Permutation {
+		encode(uint32(sum[t[0]])<<16|uint32(sum[t[1]])<<8|uint32(sum[t[2]]), 4)
+	}
+	encode(uint32(sum[63]), 2)
+	return out.String()
+}
+
+// hashYescrypt 通过宿主机 libxcrypt 计算 yescrypt hash
+func hashYescrypt(password string, cost int, salt string) (string, error) {
+	if cost == 0 {
+		cost = yescryptDefaultCost
+	}
+	if cost < yescryptMinCost || cost > yescryptMaxCost {
+		return "", fmt.Errorf("invalid yescrypt cost %d, must be between %d and %d", cost, yescryptMinCost, yescryptMaxCost)
+	}
+
+	if salt == "" {
+		var err error
+		if salt, err = randomCryptSalt(yescryptSaltLen); err != nil {
+			return "", fmt.Errorf("failed to generate salt: %w", err)
+		}
+	}
+	if err := validateCryptSalt(salt); err != nil {
+		return "", err
+	}
+
+	// 参数格式与 libxcrypt crypt_gensalt 一致：j（默认 flags）+ N（log2(N)-1）+ T（r=32）
+	setting := fmt.Sprintf("$y$j%cT$%s", cryptAlphabet[cost+6], salt)
+	return systemCrypt(password, setting)
+}
+
+// systemCrypt 调用宿主机 crypt(3) 计算 hash，密码通过 stdin 传入避免出现在进程参数中
+//
+// 运行时依赖：jvp 所在主机需安装 perl，且 crypt(3) 由支持 yescrypt 的 libxcrypt 提供
+// （Debian 11+、Ubuntu 22.04+、Fedora 35+ 等默认满足）；不满足时返回 ErrYescryptUnavailable
+func systemCrypt(password, setting string) (string, error) {
+	perl, err := exec.LookPath("perl")
+	if err != nil {
+		return "", fmt.Errorf("%w: perl not found: %v", ErrYescryptUnavailable, err)
+	}
+
+	cmd := exec.Command(perl, "-e", `local $/; my $p = <STDIN>; print crypt($p, $ARGV[0])`, setting)
+	cmd.Stdin = strings.NewReader(password)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to call system crypt: %w", err)
+	}
+
+	hash := string(output)
+	if !strings.HasPrefix(hash, setting[:3]) {
+		return "", fmt.Errorf("%w: system crypt does not support setting %q", ErrYescryptUnavailable, setting[:3])
+	}
+	return hash, nil
+}
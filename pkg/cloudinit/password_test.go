@@ -0,0 +1,136 @@
+package cloudinit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sha512CryptVectors 来自 Ulrich Drepper 的 sha512-crypt 规范
+var sha512CryptVectors = []struct {
+	password string
+	rounds   int
+	salt     string
+	want     string
+}{
+	{
+		password: "Hello world!",
+		salt:     "saltstring",
+		want:     "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1",
+	},
+	{
+		password: "Hello world!",
+		rounds:   10000,
+		salt:     "saltstringsaltstring",
+		want:     "$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v.",
+	},
+	{
+		password: "This is just a test",
+		rounds:   5000,
+		salt:     "toolongsaltstring",
+		want:     "$6$rounds=5000$toolongsaltstrin$lQ8jolhgVRVhY4b5pZKaysCLi0QBxGoNeKQzQ3glMhwllF7oGDZxUhx1yxdYcz/e1JSbq3y6JMxxl8audkUEm0",
+	},
+	{
+		password: "a very much longer text to encrypt.  This one even stretches over morethan one line.",
+		rounds:   1400,
+		salt:     "anotherlongsaltstring",
+		want:     "$6$rounds=1400$anotherlongsalts$POfYwTEok97VWcjxIiSOjiykti.o/pQs.wPvMxQ6Fm7I6IoYN3CmLs66x9t0oSwbtEW7o7UmJEiDwGqd8p4ur1",
+	},
+	{
+		password: "we have a short salt string but not a short password",
+		rounds:   77777,
+		salt:     "short",
+		want:     "$6$rounds=77777$short$WuQyW2YR.hBNpjjRhpYD/ifIw05xdfeEyQoMxIXbkvr0gge1a1x3yRULJ5CCaUeOxFmtlcGZelFl5CxtgfiAc0",
+	},
+	{
+		password: "a short string",
+		rounds:   123456,
+		salt:     "asaltof16chars..",
+		want:     "$6$rounds=123456$asaltof16chars..$BtCwjqMJGx5hrJhZywWvt0RLE8uZ4oPwcelCjmw2kSYu.Ec6ycULevoBK25fs2xXgMNrCzIMVcgEJAstJeonj1",
+	},
+	{
+		password: "the minimum number is still observed",
+		rounds:   10,
+		salt:     "roundstoolow",
+		want:     "$6$rounds=1000$roundstoolow$kUMsbe306n21p9R.FRkW3IGn.S9NPN0x50YhH1xhLsPuWGsUSklZt58jaTfF4ZEQpyUNGc0dqbpBYYBaHHrsX.",
+	},
+}
+
+func TestHashSHA512Vectors(t *testing.T) {
+	for _, v := range sha512CryptVectors {
+		got, err := HashPasswordWithOptions(v.password, &HashOptions{Rounds: v.rounds, Salt: v.salt})
+		require.NoError(t, err)
+		assert.Equal(t, v.want, got)
+	}
+}
+
+func TestVerifyPasswordSHA512(t *testing.T) {
+	for _, v := range sha512CryptVectors {
+		ok, err := VerifyPassword(v.password, v.want)
+		require.NoError(t, err)
+		assert.True(t, ok, v.want)
+
+		ok, err = VerifyPassword(v.password+"x", v.want)
+		require.NoError(t, err)
+		assert.False(t, ok, v.want)
+	}
+}
+
+func TestHashPasswordRandomSalt(t *testing.T) {
+	first, err := HashPassword("secret")
+	require.NoError(t, err)
+	second, err := HashPassword("secret")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, "$6$"))
+	assert.NotEqual(t, first, second)
+
+	_, salt, err := parseSHA512Setting(first)
+	require.NoError(t, err)
+	assert.Len(t, salt, sha512SaltLen)
+
+	ok, err := VerifyPassword("secret", first)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestHashPasswordInvalidOptions(t *testing.T) {
+	_, err := HashPasswordWithOptions("secret", &HashOptions{Salt: "bad$salt"})
+	assert.Error(t, err)
+
+	_, err = HashPasswordWithOptions("secret", &HashOptions{Algorithm: HashBcrypt, Salt: "saltstring"})
+	assert.Error(t, err)
+
+	_, err = HashPasswordWithOptions("secret", &HashOptions{Algorithm: "md5"})
+	assert.Error(t, err)
+}
+
+func TestVerifyPasswordBcrypt(t *testing.T) {
+	hash, err := HashPasswordWithOptions("secret", &HashOptions{Algorithm: HashBcrypt, Rounds: 4})
+	require.NoError(t, err)
+
+	ok, err := VerifyPassword("secret", hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = VerifyPassword("other", hash)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestVerifyPasswordUnknownFormat(t *testing.T) {
+	_, err := VerifyPassword("secret", "$1$salt$hash")
+	assert.Error(t, err)
+}
+
+func TestHashYescryptWithoutPerl(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	_, err := HashPasswordWithOptions("secret", &HashOptions{Algorithm: HashYescrypt})
+	assert.ErrorIs(t, err, ErrYescryptUnavailable)
+
+	_, err = VerifyPassword("secret", "$y$j9T$saltsaltsaltsalt$hash")
+	assert.ErrorIs(t, err, ErrYescryptUnavailable)
+}
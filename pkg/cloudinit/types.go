@@ -2,16 +2,21 @@ package cloudinit
 
 // Config cloud-init 配置
 type Config struct {
-	Hostname       string   // 主机名
-	Users          []User   // 用户列表（如果为空，会创建默认用户）
-	Groups         []Group  // 组列表
-	DisableRoot    bool     // 禁用 root 登录（默认：true）
-	Network        *Network // 网络配置（可选）
-	Commands       []string // 启动后执行的命令
-	Packages       []string // 要安装的软件包
-	WriteFiles     []File   // 要写入的文件
-	Timezone       string   // 时区（如：Asia/Shanghai）
-	CustomUserData string   // 自定义 user-data YAML 内容（会覆盖其他配置）
+	Hostname       string       // 主机名
+	Users          []User       // 用户列表（如果为空，会创建默认用户）
+	Groups         []Group      // 组列表
+	DisableRoot    bool         // 禁用 root 登录（默认：true）
+	Network        *Network     // 网络配置（可选）
+	Commands       []string     // 启动后执行的命令
+	Packages       []string     // 要安装的软件包
+	WriteFiles     []File       // 要写入的文件
+	Timezone       string       // 时区（如：Asia/Shanghai）
+	Locale         string       // 系统语言环境（如：en_US.UTF-8）
+	NTPServers     []string     // NTP 服务器列表
+	CustomUserData string       // 自定义 user-data YAML 内容（会覆盖其他配置）
+	PasswordHash   *HashOptions // 明文密码的 hash 参数（可选，默认 sha512-crypt）
+
+	DefaultUserSSHKeys []string // 注入发行版默认用户（cloud.cfg 中的 default_user，如 ubuntu、debian、cloud-user）的 SSH 公钥
 
 	// 已废弃：为了向后兼容保留，建议使用 Users 字段
 	Username string   // 用户名（默认：ubuntu）- 已废弃，请使用 Users
@@ -28,7 +33,7 @@ type User struct {
 	Groups            string      `yaml:"groups,omitempty"`              // 附加组，逗号分隔（如："users,admin"）
 	SELinuxUser       string      `yaml:"selinux_user,omitempty"`        // SELinux 用户
 	LockPasswd        *bool       `yaml:"lock_passwd,omitempty"`         // 锁定密码登录
-	Passwd            string      `yaml:"passwd,omitempty"`              // 密码哈希（使用 hashPassword 生成）
+	Passwd            string      `yaml:"passwd,omitempty"`              // 密码哈希（使用 HashPassword 生成）
 	PlainTextPasswd   string      `yaml:"plain_text_passwd,omitempty"`   // 明文密码（不推荐）
 	HashedPasswd      string      `yaml:"hashed_passwd,omitempty"`       // 密码哈希（同 Passwd）
 	Sudo              interface{} `yaml:"sudo,omitempty"`                // sudo 规则：string, []string 或 false
@@ -59,16 +64,25 @@ type Network struct {
 
 // Ethernet 以太网接口配置
 type Ethernet struct {
-	DHCP4       bool     `yaml:"dhcp4,omitempty"`     // 启用 DHCP4
-	DHCP6       bool     `yaml:"dhcp6,omitempty"`     // 启用 DHCP6
-	Addresses   []string `yaml:"addresses,omitempty"` // 静态 IP 地址（CIDR 格式，如：192.168.1.100/24）
-	Gateway4    string   `yaml:"gateway4,omitempty"`  // IPv4 网关
-	Gateway6    string   `yaml:"gateway6,omitempty"`  // IPv6 网关
-	Nameservers struct {
+	Match                 *EthernetMatch `yaml:"match,omitempty"`                   // 按网卡名称或 MAC 匹配（可选，key 不是实际网卡名时使用）
+	DHCP4                 bool           `yaml:"dhcp4,omitempty"`                   // 启用 DHCP4
+	DHCP6                 bool           `yaml:"dhcp6,omitempty"`                   // 启用 DHCP6
+	AcceptRA              *bool          `yaml:"accept-ra,omitempty"`               // 接受路由通告（RA），用于 SLAAC 与 IPv6 默认路由
+	IPv6AddressGeneration string         `yaml:"ipv6-address-generation,omitempty"` // SLAAC 地址生成方式：eui64（由 MAC 推导）, stable-privacy
+	Addresses             []string       `yaml:"addresses,omitempty"`               // 静态 IP 地址（CIDR 格式，如：192.168.1.100/24）
+	Gateway4              string         `yaml:"gateway4,omitempty"`                // IPv4 网关
+	Gateway6              string         `yaml:"gateway6,omitempty"`                // IPv6 网关
+	Nameservers           struct {
 		Addresses []string `yaml:"addresses,omitempty"` // DNS 服务器地址列表
 	} `yaml:"nameservers,omitempty"` // DNS 配置
 }
 
+// EthernetMatch 网卡匹配条件，name 支持通配符（如 en*）
+type EthernetMatch struct {
+	Name       string `yaml:"name,omitempty"`       // 网卡名称
+	MACAddress string `yaml:"macaddress,omitempty"` // MAC 地址
+}
+
 // File 要写入的文件
 type File struct {
 	Path        string // 文件路径
@@ -112,6 +126,8 @@ type UserData struct {
 	RunCmd      []string            `yaml:"runcmd,omitempty"`       // 启动后执行的命令
 	WriteFiles  []WriteFile         `yaml:"write_files,omitempty"`  // 要写入的文件列表
 
+	SSHAuthorizedKeys []string `yaml:"ssh_authorized_keys,omitempty"` // 注入发行版默认用户的 SSH 公钥
+
 	// 可以添加更多 cloud-init 支持的字段
 	SSHPwauth      *bool                 `yaml:"ssh_pwauth,omitempty"`    // 启用 SSH 密码认证
 	ChPasswd       *ChPasswd             `yaml:"chpasswd,omitempty"`      // 修改用户密码
@@ -123,6 +139,14 @@ type UserData struct {
 	APTSources     map[string]*APTSource `yaml:"apt_sources,omitempty"`   // APT 软件源配置
 	Mounts         [][]string            `yaml:"mounts,omitempty"`        // 挂载点配置
 	SSHKeys        *SSHKeys              `yaml:"ssh_keys,omitempty"`      // SSH 主机密钥
+	NTP            *NTP                  `yaml:"ntp,omitempty"`           // NTP 配置
+}
+
+// NTP 时间同步配置
+type NTP struct {
+	Enabled *bool    `yaml:"enabled,omitempty"` // 启用 NTP
+	Servers []string `yaml:"servers,omitempty"` // NTP 服务器列表
+	Pools   []string `yaml:"pools,omitempty"`   // NTP 服务器池列表
 }
 
 // ChPasswd 密码修改配置
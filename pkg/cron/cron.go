@@ -0,0 +1,232 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 触发计划
+type Schedule interface {
+	// Next 返回严格晚于 t 的下一次触发时间，不存在时（如 2 月 30 日）返回零值
+	Next(t time.Time) time.Time
+}
+
+// field 单个字段的取值范围与名称
+type field struct {
+	name  string
+	min   uint
+	max   uint
+	names map[string]uint
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 周允许 7 表示周日，解析后归一到 0
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// descriptors 简写与对应的标准表达式
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// maxSearchYears 查找下一次触发时间的最大跨度
+const maxSearchYears = 5
+
+// Parse 解析 cron 表达式
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("empty cron expression")
+	}
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every duration %q: %w", rest, err)
+		}
+		if interval < time.Second {
+			return nil, fmt.Errorf("@every duration must be at least 1s, got %s", interval)
+		}
+		return everySchedule{interval: interval}, nil
+	}
+	if strings.HasPrefix(spec, "@") {
+		expanded, ok := descriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("unknown cron descriptor %q", spec)
+		}
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour day-of-month month day-of-week), got %d", spec, len(fields))
+	}
+
+	s := &specSchedule{}
+	var err error
+	if s.minute, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[2], domField); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domAny = unrestricted(fields[2])
+	s.dowAny = unrestricted(fields[4])
+	return s, nil
+}
+
+// unrestricted 日或周字段以 * 或 ? 开头（含 */2 这类带步长的写法）时视为不限制，与 crontab(5) 一致
+func unrestricted(expr string) bool {
+	return strings.HasPrefix(expr, "*") || strings.HasPrefix(expr, "?")
+}
+
+// parseField 解析单个字段，返回取值的位图
+func parseField(expr string, f field) (uint64, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(expr, ",") {
+		if part == "" {
+			return 0, fmt.Errorf("invalid %s %q: empty list item", f.name, expr)
+		}
+
+		rangeExpr, stepExpr, hasStep := strings.Cut(part, "/")
+		step := uint(1)
+		if hasStep {
+			n, err := strconv.ParseUint(stepExpr, 10, 8)
+			if err != nil || n == 0 {
+				return 0, fmt.Errorf("invalid %s step %q", f.name, stepExpr)
+			}
+			step = uint(n)
+		}
+
+		var start, end uint
+		switch {
+		case rangeExpr == "*" || rangeExpr == "?":
+			start, end = f.min, f.max
+		case strings.Contains(rangeExpr, "-"):
+			lo, hi, _ := strings.Cut(rangeExpr, "-")
+			var err error
+			if start, err = parseValue(lo, f); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(hi, f); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid %s range %q: start is greater than end", f.name, rangeExpr)
+			}
+		default:
+			value, err := parseValue(rangeExpr, f)
+			if err != nil {
+				return 0, err
+			}
+			start, end = value, value
+			// 单值带步长（如 5/15）表示从该值开始到最大值
+			if hasStep {
+				end = f.max
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// parseValue 解析单个数值或名称
+func parseValue(expr string, f field) (uint, error) {
+	if value, ok := f.names[strings.ToLower(expr)]; ok {
+		return value, nil
+	}
+	n, err := strconv.ParseUint(expr, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value %q", f.name, expr)
+	}
+	if uint(n) < f.min || uint(n) > f.max {
+		return 0, fmt.Errorf("%s value %d out of range [%d, %d]", f.name, n, f.min, f.max)
+	}
+	return uint(n), nil
+}
+
+// specSchedule 标准 5 段表达式，各字段为取值位图
+type specSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// Next 按月、日、时、分逐级跳过不匹配的取值，时间使用 t 的时区
+func (s *specSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+	loc := t.Location()
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 日和周都受限制时满足其一即可，有一个不受限制时两者都要满足
+func (s *specSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// everySchedule 固定间隔，从当前时间起按间隔触发，按秒对齐
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Next 返回 t 之后一个间隔的时间
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval).Truncate(time.Second)
+}
@@ -0,0 +1,133 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"1,,2 * * * *",
+		"* * * foo *",
+		"@often",
+		"@every 500ms",
+		"@every soon",
+	} {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestNext(t *testing.T) {
+	from := date(2026, time.January, 15, 10, 7) // 周四
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", date(2026, time.January, 15, 10, 8)},
+		{"*/15 * * * *", date(2026, time.January, 15, 10, 15)},
+		{"5/20 * * * *", date(2026, time.January, 15, 10, 25)},
+		{"0-30/10 * * * *", date(2026, time.January, 15, 10, 10)},
+		{"0 2-4 * * *", date(2026, time.January, 16, 2, 0)},
+		{"0 9,18 * * *", date(2026, time.January, 15, 18, 0)},
+		{"30 3 1 * *", date(2026, time.February, 1, 3, 30)},
+		{"0 0 1 jan *", date(2027, time.January, 1, 0, 0)},
+		{"0 12 * * MON-FRI", date(2026, time.January, 15, 12, 0)},
+		{"0 12 * * sat,sun", date(2026, time.January, 17, 12, 0)},
+		{"0 0 * * 7", date(2026, time.January, 18, 0, 0)},
+		{"0 0 29 2 *", date(2028, time.February, 29, 0, 0)},
+		{"@hourly", date(2026, time.January, 15, 11, 0)},
+		{"@daily", date(2026, time.January, 16, 0, 0)},
+		{"@weekly", date(2026, time.January, 18, 0, 0)},
+		{"@monthly", date(2026, time.February, 1, 0, 0)},
+		{"@yearly", date(2027, time.January, 1, 0, 0)},
+	}
+	for _, tt := range tests {
+		schedule, err := Parse(tt.spec)
+		require.NoError(t, err, tt.spec)
+		assert.Equal(t, tt.want, schedule.Next(from), tt.spec)
+	}
+}
+
+func TestNextDayOfMonthOrDayOfWeek(t *testing.T) {
+	from := date(2026, time.January, 15, 10, 7) // 周四
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		// 日和周都指定时满足其一即可
+		{"0 0 20 * 5", date(2026, time.January, 16, 0, 0)},
+		{"0 0 16 * 1", date(2026, time.January, 16, 0, 0)},
+		// 只指定其中一个时按指定的匹配
+		{"0 0 20 * *", date(2026, time.January, 20, 0, 0)},
+		{"0 0 * * 1", date(2026, time.January, 19, 0, 0)},
+		{"0 0 ? * 1", date(2026, time.January, 19, 0, 0)},
+	}
+	for _, tt := range tests {
+		schedule, err := Parse(tt.spec)
+		require.NoError(t, err, tt.spec)
+		assert.Equal(t, tt.want, schedule.Next(from), tt.spec)
+	}
+}
+
+func TestNextImpossibleDate(t *testing.T) {
+	schedule, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(date(2026, time.January, 1, 0, 0)).IsZero())
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	schedule, err := Parse("0 3 * * *")
+	require.NoError(t, err)
+
+	next := schedule.Next(time.Date(2026, time.January, 15, 10, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2026, time.January, 16, 3, 0, 0, 0, loc), next)
+}
+
+func TestEvery(t *testing.T) {
+	schedule, err := Parse("@every 90m")
+	require.NoError(t, err)
+
+	from := time.Date(2026, time.January, 15, 10, 7, 30, 500, time.UTC)
+	assert.Equal(t, time.Date(2026, time.January, 15, 11, 37, 30, 0, time.UTC), schedule.Next(from))
+}
+
+// TestNextStarStepDayField 以 * 开头的带步长字段视为不受限制，日与周需同时满足（crontab(5) 语义）
+func TestNextStarStepDayField(t *testing.T) {
+	from := date(2026, time.January, 15, 10, 7) // 周四
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		// 奇数日且为周一：按满足其一会在 1 月 17 日（周六）触发
+		{"0 0 */2 * 1", date(2026, time.January, 19, 0, 0)},
+		// 21 日且为周日、二、四、六：1 月 21 日是周三，2 月 21 日是周六
+		{"0 0 21 * */2", date(2026, time.February, 21, 0, 0)},
+		// 范围不以 * 开头，仍按满足其一处理
+		{"0 0 1-31/2 * 1", date(2026, time.January, 17, 0, 0)},
+	}
+	for _, tt := range tests {
+		schedule, err := Parse(tt.spec)
+		require.NoError(t, err, tt.spec)
+		assert.Equal(t, tt.want, schedule.Next(from), tt.spec)
+	}
+}
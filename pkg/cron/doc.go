@@ -0,0 +1,30 @@
+// Package cron 解析 cron 表达式并计算下一次触发时间
+//
+// 支持标准的 5 段表达式：分 时 日 月 周
+//   - 分：0-59
+//   - 时：0-23
+//   - 日：1-31
+//   - 月：1-12 或 JAN-DEC
+//   - 周：0-6（0 为周日，7 也表示周日）或 SUN-SAT
+//
+// 每段支持 *、列表（1,15）、范围（1-5）、步长（*/10、0-30/5）。
+// 日和周都受限制（都不以 * 开头）时，满足其一即触发；任一字段以 * 开头时两者都要满足。
+// 与 crontab(5) 一致，*/2 这类带步长的写法也以 * 开头，视为不受限制：
+// "0 0 */2 * 1" 只在日期为奇数的周一触发，而不是每个奇数日加上每个周一。
+//
+// 另外支持以下简写：
+//   - @yearly / @annually：0 0 1 1 *
+//   - @monthly：0 0 1 * *
+//   - @weekly：0 0 * * 0
+//   - @daily / @midnight：0 0 * * *
+//   - @hourly：0 * * * *
+//   - @every <duration>：固定间隔，如 @every 90m
+//
+// 示例：
+//
+//	schedule, err := cron.Parse("*/15 2-4 * * MON-FRI")
+//	if err != nil {
+//		return err
+//	}
+//	next := schedule.Next(time.Now())
+package cron
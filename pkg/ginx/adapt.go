@@ -111,6 +111,75 @@ func Adapt5[TArgs any, TResp any](fn func(*gin.Context, *TArgs) (TResp, error))
 	}
 }
 
+// AdaptStream 适配自行写入响应体的 handler（如文件下载）
+// 开始写入响应前返回的错误按普通错误渲染；开始写入后状态码已发出，错误只记录到 gin 上下文
+func AdaptStream[T any](fn func(*gin.Context, *T) error) gin.HandlerFunc {
+	var argsType T
+	argsTypeValue := reflect.TypeOf(argsType)
+
+	return func(ctx *gin.Context) {
+		// 绑定参数
+		argsValue := reflect.New(argsTypeValue)
+		args := argsValue.Interface()
+
+		if err := bindArgs(ctx, args); err != nil {
+			renderError(ctx, http.StatusBadRequest, err)
+			return
+		}
+
+		// 验证参数（如果实现了 IsValid 方法）
+		if validator, ok := args.(interface{ IsValid() error }); ok {
+			if err := validator.IsValid(); err != nil {
+				renderError(ctx, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		// 调用 handler
+		if err := fn(ctx, args.(*T)); err != nil {
+			if ctx.Writer.Written() {
+				_ = ctx.Error(err)
+				ctx.Abort()
+				return
+			}
+			renderError(ctx, http.StatusInternalServerError, err)
+		}
+	}
+}
+
+// AdaptUpload 适配自行读取请求体的 handler（如分片上传），参数只从 URI 与 Query 绑定，请求体原样留给 handler
+func AdaptUpload[TArgs any, TResp any](fn func(*gin.Context, *TArgs) (TResp, error)) gin.HandlerFunc {
+	var argsType TArgs
+	argsTypeValue := reflect.TypeOf(argsType)
+
+	return func(ctx *gin.Context) {
+		// 绑定参数
+		argsValue := reflect.New(argsTypeValue)
+		args := argsValue.Interface()
+
+		setResponseFormat(ctx, "json")
+		if err := ctx.ShouldBindUri(args); err != nil {
+			renderError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		// 请求体是上传内容，其余参数只能来自 Query
+		if err := ctx.ShouldBindQuery(args); err != nil {
+			renderError(ctx, http.StatusBadRequest, err)
+			return
+		}
+
+		// 调用 handler
+		result, err := fn(ctx, args.(*TArgs))
+		if err != nil {
+			renderError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+
+		// 处理响应
+		renderResponse(ctx, result)
+	}
+}
+
 // Adapt6 适配有参数、只有返回值的 handler
 func Adapt6[TArgs any, TResp any](fn func(*gin.Context, *TArgs) TResp) gin.HandlerFunc {
 	var argsType TArgs
@@ -5,10 +5,13 @@ import (
 )
 
 // contextKey 用于在 gin.Context 中存储值的类型安全 key
-type contextKey struct{}
+// 空结构体的值彼此相等，用 name 区分不同的 key
+type contextKey struct {
+	name string
+}
 
 // responseFormatKey 用于存储响应格式（"json" 或 "xml"）
-var responseFormatKey = contextKey{}
+var responseFormatKey = contextKey{name: "response_format"}
 
 // setResponseFormat 设置响应格式
 func setResponseFormat(ctx *gin.Context, format string) {
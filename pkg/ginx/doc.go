@@ -6,6 +6,14 @@
 //   - 如果使用 XML 解析请求，响应也会使用 XML 格式
 //   - 错误响应也会根据请求格式自动选择 JSON 或 XML
 //
+// 响应 envelope：
+//   - 经过 ResponseMiddleware 的请求都会分配请求 ID（沿用请求头 X-Request-Id 或自动生成），
+//     写入响应头 X-Request-Id 与错误响应的 requestID
+//   - 开启 envelope 后成功响应包裹为 {"request_id": ..., "metadata": {...}, "data": ...}，
+//     响应实现 Paginated 时 metadata 带上 next_token 与 total_count
+//   - StableBody 返回去掉请求 ID 的 envelope，计算 ETag 时使用，避免每次请求 ETag 都不同
+//   - 请求头 X-JVP-Envelope: true/false 可以按请求覆盖默认开关
+//
 // 支持多种 handler 函数签名：
 //
 //	// 1. 有参数，有返回值，有 error
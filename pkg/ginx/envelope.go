@@ -0,0 +1,124 @@
+package ginx
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDHeader 请求 ID 请求头与响应头，调用方传入时沿用，否则由服务端生成
+	RequestIDHeader = "X-Request-Id"
+	// EnvelopeHeader 按请求覆盖 envelope 开关的请求头，取值 true/false
+	EnvelopeHeader = "X-JVP-Envelope"
+)
+
+// maxRequestIDLength 沿用调用方请求 ID 的最大长度，超出时重新生成
+const maxRequestIDLength = 128
+
+var (
+	// requestIDKey 用于存储请求 ID
+	requestIDKey = contextKey{name: "request_id"}
+	// envelopeKey 用于存储是否包裹成功响应
+	envelopeKey = contextKey{name: "envelope"}
+	// wrappedKey 用于存储已渲染的 Envelope，供计算 ETag 时排除请求 ID
+	wrappedKey = contextKey{name: "wrapped"}
+)
+
+// Envelope 成功响应的统一包裹
+type Envelope struct {
+	XMLName   xml.Name  `xml:"Response"            json:"-"`
+	RequestID string    `xml:"RequestID"           json:"request_id"`
+	Metadata  *Metadata `xml:"Metadata,omitempty"  json:"metadata,omitempty"`
+	Data      any       `xml:"Data"                json:"data"`
+}
+
+// Metadata 分页元数据，只有实现 Paginated 的响应才返回
+type Metadata struct {
+	NextToken  string `xml:"NextToken,omitempty"  json:"next_token,omitempty"`
+	TotalCount *int   `xml:"TotalCount,omitempty" json:"total_count,omitempty"`
+}
+
+// Paginated 分页响应实现该接口后，envelope 的 metadata 会带上分页信息
+// total_count 小于 0 表示总数未知，不返回该字段
+type Paginated interface {
+	PageInfo() (nextToken string, totalCount int)
+}
+
+// ResponseMiddleware 为每个请求分配请求 ID 并写入响应头，错误响应的 requestID 与之一致
+// envelope 为 true 时成功响应包裹为 Envelope；请求头 X-JVP-Envelope 可以按请求覆盖该默认值，
+// 便于在开启后仍让旧客户端拿到裸对象，或在关闭时让新客户端提前切换
+func ResponseMiddleware(envelope bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := strings.TrimSpace(c.GetHeader(RequestIDHeader))
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = rand.Text()
+		}
+		c.Set(requestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		enabled := envelope
+		if value, err := strconv.ParseBool(c.GetHeader(EnvelopeHeader)); err == nil {
+			enabled = value
+		}
+		c.Set(envelopeKey, enabled)
+		c.Next()
+	}
+}
+
+// RequestID 返回当前请求的请求 ID，未经过 ResponseMiddleware 时返回空字符串
+func RequestID(ctx *gin.Context) string {
+	return ctx.GetString(requestIDKey)
+}
+
+// useEnvelope 当前请求的成功响应是否需要包裹
+func useEnvelope(ctx *gin.Context) bool {
+	return ctx.GetBool(envelopeKey)
+}
+
+// StableBody 返回计算 ETag 使用的响应内容
+// 成功响应包裹为 Envelope 时返回去掉请求 ID 后的序列化结果，保证内容不变时 ETag 不随请求变化；
+// 未包裹时返回 nil，直接使用响应体
+func StableBody(ctx *gin.Context) []byte {
+	value, ok := ctx.Get(wrappedKey)
+	if !ok {
+		return nil
+	}
+	envelope := *value.(*Envelope)
+	envelope.RequestID = ""
+	var (
+		body []byte
+		err  error
+	)
+	if isXMLResponse(ctx) {
+		body, err = xml.Marshal(&envelope)
+	} else {
+		body, err = json.Marshal(&envelope)
+	}
+	if err != nil {
+		return nil
+	}
+	return body
+}
+
+// wrapResponse 把成功响应包裹为 Envelope
+func wrapResponse(ctx *gin.Context, response any) *Envelope {
+	envelope := &Envelope{
+		RequestID: RequestID(ctx),
+		Data:      response,
+	}
+	if paginated, ok := response.(Paginated); ok {
+		nextToken, totalCount := paginated.PageInfo()
+		metadata := &Metadata{NextToken: nextToken}
+		if totalCount >= 0 {
+			metadata.TotalCount = &totalCount
+		}
+		envelope.Metadata = metadata
+	}
+	ctx.Set(wrappedKey, envelope)
+	return envelope
+}
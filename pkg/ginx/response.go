@@ -31,6 +31,16 @@ func renderResponse(ctx *gin.Context, response any) {
 
 	useXML := isXMLResponse(ctx)
 
+	// 开启 envelope 时所有成功响应（包括基本类型）统一包裹，客户端按同一结构处理
+	if useEnvelope(ctx) {
+		if useXML {
+			ctx.XML(http.StatusOK, wrapResponse(ctx, response))
+		} else {
+			ctx.JSON(http.StatusOK, wrapResponse(ctx, response))
+		}
+		return
+	}
+
 	// 基本类型特殊处理
 	switch v := response.(type) {
 	case string:
@@ -74,6 +84,12 @@ func renderResponse(ctx *gin.Context, response any) {
 	}
 }
 
+// AbortWithError 在中间件中拒绝请求：按与 handler 返回错误相同的格式渲染错误响应，并中止后续 handler
+func AbortWithError(ctx *gin.Context, err error) {
+	renderError(ctx, http.StatusInternalServerError, err)
+	ctx.Abort()
+}
+
 // renderError 渲染错误响应
 // 如果 err 是 *apierror.Error 或 *apierror.ErrorResponse，直接序列化错误对象
 // 否则使用默认的错误格式
@@ -87,7 +103,7 @@ func renderError(ctx *gin.Context, statusCode int, err error) {
 			statusCode = apiErr.HTTPStatus
 		}
 		// 创建 ErrorResponse 用于序列化
-		errorResp := apierror.NewErrorResponse("", apiErr)
+		errorResp := apierror.NewErrorResponse(RequestID(ctx), apiErr)
 		if useXML {
 			ctx.XML(statusCode, errorResp)
 		} else {
@@ -102,6 +118,9 @@ func renderError(ctx *gin.Context, statusCode int, err error) {
 		if len(errorResp.Errors) > 0 && errorResp.Errors[0].HTTPStatus > 0 {
 			statusCode = errorResp.Errors[0].HTTPStatus
 		}
+		if errorResp.RequestID == "" {
+			errorResp.RequestID = RequestID(ctx)
+		}
 		if useXML {
 			ctx.XML(statusCode, errorResp)
 		} else {
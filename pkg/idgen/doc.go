@@ -33,8 +33,18 @@
 //	gen := idgen.DefaultGenerator()
 //	imageID, err := gen.GenerateImageID()
 //
-// 方式三：创建自定义生成器
+// 方式三：创建自定义生成器并注入为进程级默认生成器
 //
-//	gen := idgen.New()
-//	imageID, err := gen.GenerateImageID()
+//	idgen.SetDefaultGenerator(idgen.New(idgen.WithNamespaces()))
+//
+// 同一进程内应共享一个生成器：多个 machine ID 相同的 Sonyflake 并发生成时可能产生重复 ID。
+//
+// 命名空间隔离：
+//
+// 使用 WithNamespaces 创建的生成器可以通过 Namespace 获取按资源类型隔离的子生成器，
+// 每个子生成器拥有独立的 machine ID 与序列计数，可通过 idgen.MachineID 从 ID 中识别来源。
+// 未启用时 Namespace 返回生成器本身。
+//
+//	gen := idgen.DefaultGenerator().Namespace(idgen.NamespaceVolume)
+//	volumeID, err := gen.GenerateVolumeID()
 package idgen
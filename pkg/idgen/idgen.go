@@ -2,44 +2,148 @@ package idgen
 
 import (
 	"fmt"
+	"hash/fnv"
 	"sync"
 	"time"
 
 	"github.com/sony/sonyflake"
 )
 
+// 资源类型命名空间
+const (
+	NamespaceImage         = "image"
+	NamespaceVolume        = "volume"
+	NamespaceInstance      = "instance"
+	NamespaceSnapshot      = "snapshot"
+	NamespaceTemplate      = "template"
+	NamespaceKeyPair       = "keypair"
+	NamespaceSecurityGroup = "security-group"
+)
+
+// defaultMachineID 根生成器使用的 machine ID
+const defaultMachineID uint16 = 1
+
 // Generator 递增 ID 生成器
-// 使用 Sonyflake 算法生成全局唯一且递增的 ID
+// 使用 Sonyflake 算法生成全局唯一且递增的 ID，可安全地被多个 goroutine 并发使用
 type Generator struct {
-	sf *sonyflake.Sonyflake
+	sf        *sonyflake.Sonyflake
+	machineID uint16
+	startTime time.Time
+
+	// 命名空间隔离（仅根生成器使用）
+	namespaced bool
+	mu         sync.Mutex
+	children   map[string]*Generator
+	usedIDs    map[uint16]struct{}
+}
+
+// Option 生成器选项
+type Option func(*Generator)
+
+// WithNamespaces 启用按资源类型的命名空间隔离
+// 启用后 Namespace 为每种资源类型分配独立的 machine ID，各自计数，
+// 可通过 MachineID 从 ID 中识别其来源
+func WithNamespaces() Option {
+	return func(g *Generator) {
+		g.namespaced = true
+	}
+}
+
+// WithMachineID 指定根生成器的 machine ID（默认：1）
+func WithMachineID(id uint16) Option {
+	return func(g *Generator) {
+		g.machineID = id
+	}
 }
 
 var (
-	defaultGenerator     *Generator
-	defaultGeneratorOnce sync.Once
+	defaultGenerator   *Generator
+	defaultGeneratorMu sync.Mutex
 )
 
-// initDefaultGenerator 初始化默认生成器
-func initDefaultGenerator() {
-	defaultGenerator = New()
-}
-
-// DefaultGenerator 返回默认的 ID 生成器
+// DefaultGenerator 返回进程级共享的默认 ID 生成器
+// 同一进程内的所有服务应共享该生成器，避免多个使用相同 machine ID 的 Sonyflake 生成重复 ID
 func DefaultGenerator() *Generator {
-	defaultGeneratorOnce.Do(initDefaultGenerator)
+	defaultGeneratorMu.Lock()
+	defer defaultGeneratorMu.Unlock()
+	if defaultGenerator == nil {
+		defaultGenerator = New()
+	}
 	return defaultGenerator
 }
 
+// SetDefaultGenerator 替换进程级默认生成器
+// 应在创建各个服务之前调用，之后通过 DefaultGenerator 获取到的都是该生成器
+func SetDefaultGenerator(g *Generator) {
+	defaultGeneratorMu.Lock()
+	defer defaultGeneratorMu.Unlock()
+	defaultGenerator = g
+}
+
 // New 创建新的 ID 生成器
-func New() *Generator {
-	sf := newSonyflake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+// 注意：同一进程内多个生成器使用相同的 machine ID 时可能生成重复 ID，一般应使用 DefaultGenerator
+func New(opts ...Option) *Generator {
+	g := &Generator{
+		machineID: defaultMachineID,
+		startTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	sf := newSonyflake(g.startTime, g.machineID)
 	if sf == nil {
-		sf = newSonyflake(time.Now())
+		g.startTime = time.Now()
+		sf = newSonyflake(g.startTime, g.machineID)
+	}
+	g.sf = sf
+
+	if g.namespaced {
+		g.children = make(map[string]*Generator)
+		g.usedIDs = map[uint16]struct{}{g.machineID: {}}
 	}
 
-	return &Generator{
-		sf: sf,
+	return g
+}
+
+// Namespace 返回指定资源类型的子生成器，相同名称总是返回同一个子生成器
+// 未启用命名空间隔离时直接返回自身
+func (g *Generator) Namespace(name string) *Generator {
+	if !g.namespaced {
+		return g
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if child, ok := g.children[name]; ok {
+		return child
+	}
+
+	// 按名称哈希分配 machine ID，使同一资源类型在重启后保持相同的 machine ID；冲突时顺延
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	machineID := uint16(h.Sum32())
+	for {
+		if _, used := g.usedIDs[machineID]; !used {
+			break
+		}
+		machineID++
+	}
+	g.usedIDs[machineID] = struct{}{}
+
+	child := &Generator{
+		sf:        newSonyflake(g.startTime, machineID),
+		machineID: machineID,
+		startTime: g.startTime,
 	}
+	g.children[name] = child
+	return child
+}
+
+// MachineID 返回生成器的 machine ID
+func (g *Generator) MachineID() uint16 {
+	return g.machineID
 }
 
 // generateIDWithPrefix 生成带前缀的 ID
@@ -51,16 +155,21 @@ func (g *Generator) generateIDWithPrefix(prefix, errorMsg string) (string, error
 	return fmt.Sprintf("%s-%d", prefix, id), nil
 }
 
-func newSonyflake(start time.Time) *sonyflake.Sonyflake {
+func newSonyflake(start time.Time, machineID uint16) *sonyflake.Sonyflake {
 	settings := sonyflake.Settings{
 		StartTime: start,
 		MachineID: func() (uint16, error) {
-			return 1, nil
+			return machineID, nil
 		},
 	}
 	return sonyflake.NewSonyflake(settings)
 }
 
+// MachineID 从 ID 中解析出生成它的 machine ID，用于识别 ID 来源的命名空间
+func MachineID(id uint64) uint16 {
+	return uint16(sonyflake.MachineID(id))
+}
+
 // GenerateImageID 生成镜像 ID（格式：ami-{递增 ID}）
 func (g *Generator) GenerateImageID() (string, error) {
 	return g.generateIDWithPrefix("ami", "generate image ID")
@@ -91,6 +200,11 @@ func (g *Generator) GenerateKeyPairID() (string, error) {
 	return g.generateIDWithPrefix("kp", "generate keypair ID")
 }
 
+// GenerateSecurityGroupID 生成安全组 ID（格式：sg-{递增 ID}）
+func (g *Generator) GenerateSecurityGroupID() (string, error) {
+	return g.generateIDWithPrefix("sg", "generate security group ID")
+}
+
 // GenerateID 生成通用递增 ID
 func (g *Generator) GenerateID() (uint64, error) {
 	return g.sf.NextID()
@@ -100,32 +214,37 @@ func (g *Generator) GenerateID() (uint64, error) {
 
 // GenerateImageID 使用默认生成器生成镜像 ID
 func GenerateImageID() (string, error) {
-	return DefaultGenerator().GenerateImageID()
+	return DefaultGenerator().Namespace(NamespaceImage).GenerateImageID()
 }
 
 // GenerateVolumeID 使用默认生成器生成 Volume ID
 func GenerateVolumeID() (string, error) {
-	return DefaultGenerator().GenerateVolumeID()
+	return DefaultGenerator().Namespace(NamespaceVolume).GenerateVolumeID()
 }
 
 // GenerateInstanceID 使用默认生成器生成 Instance ID
 func GenerateInstanceID() (string, error) {
-	return DefaultGenerator().GenerateInstanceID()
+	return DefaultGenerator().Namespace(NamespaceInstance).GenerateInstanceID()
 }
 
 // GenerateSnapshotID 使用默认生成器生成 Snapshot ID
 func GenerateSnapshotID() (string, error) {
-	return DefaultGenerator().GenerateSnapshotID()
+	return DefaultGenerator().Namespace(NamespaceSnapshot).GenerateSnapshotID()
 }
 
 // GenerateTemplateID 使用默认生成器生成 Template ID
 func GenerateTemplateID() (string, error) {
-	return DefaultGenerator().GenerateTemplateID()
+	return DefaultGenerator().Namespace(NamespaceTemplate).GenerateTemplateID()
 }
 
 // GenerateKeyPairID 使用默认生成器生成密钥对 ID
 func GenerateKeyPairID() (string, error) {
-	return DefaultGenerator().GenerateKeyPairID()
+	return DefaultGenerator().Namespace(NamespaceKeyPair).GenerateKeyPairID()
+}
+
+// GenerateSecurityGroupID 使用默认生成器生成安全组 ID
+func GenerateSecurityGroupID() (string, error) {
+	return DefaultGenerator().Namespace(NamespaceSecurityGroup).GenerateSecurityGroupID()
 }
 
 // GenerateID 使用默认生成器生成通用递增 ID
@@ -0,0 +1,141 @@
+package libvirt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 支持的 guest 架构
+const (
+	ArchX86_64  = "x86_64"
+	ArchI686    = "i686"
+	ArchAArch64 = "aarch64"
+)
+
+// SupportedArchitectures 可以生成 domain 默认配置的 guest 架构
+var SupportedArchitectures = []string{ArchX86_64, ArchI686, ArchAArch64}
+
+// aarch64TCGCPUModel aarch64 TCG 模拟使用的 CPU 型号，libvirt 未指定时默认为 32 位的 cortex-a15
+const aarch64TCGCPUModel = "cortex-a57"
+
+// validateArchitecture 校验 guest 架构
+func validateArchitecture(arch string) error {
+	switch arch {
+	case "", ArchX86_64, ArchI686, ArchAArch64:
+		return nil
+	default:
+		return fmt.Errorf("unsupported architecture: %s (must be %s)", arch, strings.Join(SupportedArchitectures, ", "))
+	}
+}
+
+// checkGuestArch 根据节点 capabilities 校验节点能以所选虚拟化类型运行该架构的 guest，并校验机器类型
+func (c *Client) checkGuestArch(config *CreateVMConfig) error {
+	caps, err := c.GetCapabilities()
+	if err != nil {
+		return err
+	}
+	if !caps.SupportsGuest(config.Architecture, config.DomainType) {
+		return fmt.Errorf("node does not support %s guests with domain type %s (supported architectures: %s)",
+			config.Architecture, config.DomainType, strings.Join(caps.GuestArchs(), ", "))
+	}
+	if config.MachineType != "" && !caps.SupportsMachine(config.Architecture, config.MachineType) {
+		return fmt.Errorf("node does not support machine type %s for %s guests", config.MachineType, config.Architecture)
+	}
+	return nil
+}
+
+// applyArchDefaults 按 guest 架构调整 domain 中与架构相关的配置
+// x86 沿用 buildDomainXML 的默认值（pc/q35、ACPI + APIC、isa-serial、PS/2 输入）；
+// aarch64 使用 virt 机器类型、UEFI 固件（firmware=efi 由 libvirt 自动选择 AAVMF）、GIC 中断控制器、
+// pl011 串口、xHCI 与 USB 输入设备，光驱改用 virtio-scsi（virt 机器没有 IDE 控制器）
+func applyArchDefaults(domain *DomainXML, config *CreateVMConfig) error {
+	if config.Architecture != ArchAArch64 {
+		return nil
+	}
+
+	if domain.OS.Type.Machine == "" {
+		domain.OS.Type.Machine = "virt"
+	}
+	domain.OS.Firmware = "efi"
+
+	// kvm 下 GIC 版本必须与宿主机一致，TCG 模拟 GICv3
+	gicVersion := "3"
+	if config.DomainType == "kvm" {
+		gicVersion = "host"
+	}
+	domain.Features = &DomainFeatures{
+		ACPI: &DomainFeatureEnabled{},
+		GIC:  &DomainFeatureGIC{Version: gicVersion},
+	}
+
+	// aarch64 kvm 只支持 host-passthrough；嵌套虚拟化已在之前校验为 x86 专有
+	if domain.CPU == nil {
+		if config.DomainType == "kvm" {
+			domain.CPU = &DomainCPU{Mode: "host-passthrough", Check: "none"}
+		} else {
+			domain.CPU = &DomainCPU{
+				Mode:  "custom",
+				Match: "exact",
+				Model: &DomainCPUModel{Fallback: "forbid", Value: aarch64TCGCPUModel},
+			}
+		}
+	}
+
+	domain.Devices.Serial.Target = DomainSerialTarget{
+		Type:  "system-serial",
+		Port:  0,
+		Model: DomainSerialTargetModel{Name: "pl011"},
+	}
+
+	// virt 机器没有 PS/2，pci-root 由 libvirt 按 pcie-root 自动添加
+	controllers := domain.Devices.Controllers[:0]
+	for _, ctrl := range domain.Devices.Controllers {
+		switch {
+		case ctrl.Type == "pci" && ctrl.Model == "pci-root":
+		case ctrl.Type == "usb":
+			controllers = append(controllers, DomainController{Type: "usb", Index: ctrl.Index, Model: "qemu-xhci"})
+		default:
+			controllers = append(controllers, ctrl)
+		}
+	}
+	domain.Devices.Controllers = controllers
+
+	inputs := domain.Devices.Inputs[:0]
+	for _, input := range domain.Devices.Inputs {
+		if input.Bus == "ps2" {
+			if input.Type == "keyboard" {
+				inputs = append(inputs, DomainInput{Type: "keyboard", Bus: "usb"})
+			}
+			continue
+		}
+		inputs = append(inputs, input)
+	}
+	domain.Devices.Inputs = inputs
+
+	// 光驱挂到 virtio-scsi 上，设备名顺延已使用的 sd* 名称
+	used := make(map[string]bool, len(domain.Devices.Disks))
+	for _, disk := range domain.Devices.Disks {
+		if disk.Device != "cdrom" {
+			used[disk.Target.Dev] = true
+		}
+	}
+	hasCDROM := false
+	for i := range domain.Devices.Disks {
+		disk := &domain.Devices.Disks[i]
+		if disk.Device != "cdrom" {
+			continue
+		}
+		dev, err := nextDiskDevice("sd", used)
+		if err != nil {
+			return err
+		}
+		used[dev] = true
+		disk.Target = DomainDiskTarget{Dev: dev, Bus: "scsi"}
+		hasCDROM = true
+	}
+	if hasCDROM && !hasController(domain.Devices.Controllers, "scsi") {
+		domain.Devices.Controllers = append(domain.Devices.Controllers, *newDiskController("scsi", DiskControllerConfig{}, 0))
+	}
+
+	return nil
+}
@@ -0,0 +1,284 @@
+package libvirt
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+)
+
+// blockJobPollInterval WaitBlockJob 轮询任务进度的间隔
+const blockJobPollInterval = time.Second
+
+// block job 类型
+const (
+	BlockJobTypePull         = "pull"
+	BlockJobTypeCopy         = "copy"
+	BlockJobTypeCommit       = "commit"
+	BlockJobTypeActiveCommit = "active-commit"
+	BlockJobTypeBackup       = "backup"
+)
+
+// BlockJobInfo 磁盘 block job 进度
+type BlockJobInfo struct {
+	Type      string // pull, copy, commit, active-commit, backup
+	Cur       uint64 // 已处理字节数
+	End       uint64 // 总字节数
+	Bandwidth uint64 // 限速（MiB/s），0 表示不限速
+}
+
+// Ready copy 与 active-commit 任务数据同步完成后进入 ready 状态，
+// 此时任务不会自行结束，需要 AbortBlockJob(pivot=true) 切换到新文件或放弃
+func (j *BlockJobInfo) Ready() bool {
+	return (j.Type == BlockJobTypeCopy || j.Type == BlockJobTypeActiveCommit) && j.End > 0 && j.Cur == j.End
+}
+
+// Progress 完成百分比
+func (j *BlockJobInfo) Progress() int {
+	if j.End == 0 {
+		return 0
+	}
+	return int(j.Cur * 100 / j.End)
+}
+
+// BlockCommitConfig blockcommit 配置
+type BlockCommitConfig struct {
+	// Top 要合并的上层文件，为空表示磁盘当前使用的文件（active commit）
+	Top string
+	// Base 合并目标，为空表示 backing 链最底层的文件
+	Base string
+	// Active Top 为当前使用的文件时必须设置，任务 ready 后需 pivot
+	Active bool
+	// Delete 合并完成后删除被合并的文件
+	Delete bool
+	// BandwidthMiB 限速（MiB/s），0 表示不限速
+	BandwidthMiB uint64
+}
+
+// BlockCopyConfig blockcopy 配置
+type BlockCopyConfig struct {
+	// DestPath 目标文件路径
+	DestPath string
+	// DestFormat 目标文件格式，默认 qcow2
+	DestFormat string
+	// Shallow 只复制当前文件，目标沿用原 backing 链
+	Shallow bool
+	// ReuseExternal 目标文件已预先创建（如通过存储池创建），不由 libvirt 创建
+	ReuseExternal bool
+	// BandwidthMiB 限速（MiB/s），0 表示不限速
+	BandwidthMiB uint64
+}
+
+// blockCopyDest blockcopy 目标磁盘 XML，libvirt 只使用其中的 source 与 driver type
+type blockCopyDest struct {
+	XMLName xml.Name `xml:"disk"`
+	Type    string   `xml:"type,attr"`
+	Source  struct {
+		File string `xml:"file,attr"`
+	} `xml:"source"`
+	Driver struct {
+		Type string `xml:"type,attr"`
+	} `xml:"driver"`
+}
+
+// BlockPull 在运行中的 domain 上启动 blockpull，把 backing 链数据合入磁盘当前文件
+// 任务异步执行，通过 GetBlockJob 查询进度，完成后 libvirt 自动移除 backing 链
+func (c *Client) BlockPull(domainName, device string, bandwidthMiB uint64) error {
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return fmt.Errorf("lookup domain: %w", err)
+	}
+
+	if err := c.conn.DomainBlockPull(domain, device, bandwidthMiB, 0); err != nil {
+		return fmt.Errorf("block pull %s: %w", device, err)
+	}
+	return nil
+}
+
+// BlockCommit 启动 blockcommit，把 Top 到 Base 之间的数据合并到 Base
+// 非 active commit 完成后任务自动结束；active commit 进入 ready 后需 AbortBlockJob(pivot=true)
+func (c *Client) BlockCommit(domainName, device string, config BlockCommitConfig) error {
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return fmt.Errorf("lookup domain: %w", err)
+	}
+
+	var flags libvirt.DomainBlockCommitFlags
+	if config.Active {
+		flags |= libvirt.DomainBlockCommitActive
+	}
+	if config.Delete {
+		flags |= libvirt.DomainBlockCommitDelete
+	}
+
+	if err := c.conn.DomainBlockCommit(domain, device, optString(config.Base), optString(config.Top), config.BandwidthMiB, flags); err != nil {
+		return fmt.Errorf("block commit %s: %w", device, err)
+	}
+	return nil
+}
+
+// BlockCopy 启动 blockcopy，把磁盘镜像到 DestPath
+// 任务 ready 后源与目标保持同步写入，AbortBlockJob(pivot=true) 切换到目标文件，pivot=false 放弃复制
+// 使用 transient job，持久化 domain 也可执行，切换后由调用方负责更新持久化配置
+func (c *Client) BlockCopy(domainName, device string, config BlockCopyConfig) error {
+	if config.DestPath == "" {
+		return fmt.Errorf("destination path is required")
+	}
+	format := config.DestFormat
+	if format == "" {
+		format = "qcow2"
+	}
+
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return fmt.Errorf("lookup domain: %w", err)
+	}
+
+	dest := blockCopyDest{Type: "file"}
+	dest.Source.File = config.DestPath
+	dest.Driver.Type = format
+	destXML, err := xml.Marshal(dest)
+	if err != nil {
+		return fmt.Errorf("marshal destination XML: %w", err)
+	}
+
+	flags := libvirt.DomainBlockCopyTransientJob
+	if config.Shallow {
+		flags |= libvirt.DomainBlockCopyShallow
+	}
+	if config.ReuseExternal {
+		flags |= libvirt.DomainBlockCopyReuseExt
+	}
+
+	var params []libvirt.TypedParam
+	if config.BandwidthMiB > 0 {
+		// blockcopy 参数中的带宽单位为字节/秒
+		params = append(params, libvirt.TypedParam{
+			Field: libvirt.DomainBlockCopyBandwidth,
+			Value: *libvirt.NewTypedParamValueUllong(config.BandwidthMiB * 1024 * 1024),
+		})
+	}
+
+	if err := c.conn.DomainBlockCopy(domain, device, string(destXML), params, flags); err != nil {
+		return fmt.Errorf("block copy %s: %w", device, err)
+	}
+	return nil
+}
+
+// GetBlockJob 查询磁盘上正在执行的 block job，没有任务时返回 nil
+func (c *Client) GetBlockJob(domainName, device string) (*BlockJobInfo, error) {
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return nil, fmt.Errorf("lookup domain: %w", err)
+	}
+
+	found, jobType, bandwidth, cur, end, err := c.conn.DomainGetBlockJobInfo(domain, device, 0)
+	if err != nil {
+		return nil, fmt.Errorf("get block job info %s: %w", device, err)
+	}
+	if found == 0 {
+		return nil, nil
+	}
+	return &BlockJobInfo{
+		Type:      formatBlockJobType(jobType),
+		Cur:       cur,
+		End:       end,
+		Bandwidth: bandwidth,
+	}, nil
+}
+
+// AbortBlockJob 结束磁盘上的 block job
+// pivot 为 true 时把 ready 状态的 copy/active-commit 任务切换到新文件，否则取消任务
+func (c *Client) AbortBlockJob(domainName, device string, pivot bool) error {
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return fmt.Errorf("lookup domain: %w", err)
+	}
+
+	var flags libvirt.DomainBlockJobAbortFlags
+	if pivot {
+		flags |= libvirt.DomainBlockJobAbortPivot
+	}
+	if err := c.conn.DomainBlockJobAbort(domain, device, flags); err != nil {
+		return fmt.Errorf("abort block job %s: %w", device, err)
+	}
+	return nil
+}
+
+// SetBlockJobSpeed 调整正在执行的 block job 限速（MiB/s），0 表示不限速
+func (c *Client) SetBlockJobSpeed(domainName, device string, bandwidthMiB uint64) error {
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return fmt.Errorf("lookup domain: %w", err)
+	}
+
+	if err := c.conn.DomainBlockJobSetSpeed(domain, device, bandwidthMiB, 0); err != nil {
+		return fmt.Errorf("set block job speed %s: %w", device, err)
+	}
+	return nil
+}
+
+// WaitBlockJob 等待 block job 完成或进入 ready 状态
+// 任务自行结束时返回 nil；copy/active-commit 进入 ready 时返回当前进度，由调用方决定 pivot 或取消
+// onProgress 可为空，每次轮询时回调当前进度
+func (c *Client) WaitBlockJob(ctx context.Context, domainName, device string, onProgress func(*BlockJobInfo)) (*BlockJobInfo, error) {
+	return waitBlockJob(ctx, c, domainName, device, onProgress)
+}
+
+// waitBlockJob WaitBlockJob 的通用实现，Client 与 FakeLibvirt 共用
+func waitBlockJob(ctx context.Context, m interface {
+	GetBlockJob(domainName, device string) (*BlockJobInfo, error)
+}, domainName, device string, onProgress func(*BlockJobInfo)) (*BlockJobInfo, error) {
+	ticker := time.NewTicker(blockJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := m.GetBlockJob(domainName, device)
+		if err != nil {
+			return nil, err
+		}
+		if job == nil {
+			return nil, nil
+		}
+		if onProgress != nil {
+			onProgress(job)
+		}
+		if job.Ready() {
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return job, fmt.Errorf("wait block job %s: %w", device, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// formatBlockJobType 将 block job 类型转换为可读字符串
+func formatBlockJobType(jobType int32) string {
+	switch libvirt.DomainBlockJobType(jobType) {
+	case libvirt.DomainBlockJobTypePull:
+		return BlockJobTypePull
+	case libvirt.DomainBlockJobTypeCopy:
+		return BlockJobTypeCopy
+	case libvirt.DomainBlockJobTypeCommit:
+		return BlockJobTypeCommit
+	case libvirt.DomainBlockJobTypeActiveCommit:
+		return BlockJobTypeActiveCommit
+	case libvirt.DomainBlockJobTypeBackup:
+		return BlockJobTypeBackup
+	default:
+		return "unknown"
+	}
+}
+
+// optString 空字符串对应 libvirt 的 NULL 参数
+func optString(s string) libvirt.OptString {
+	if s == "" {
+		return nil
+	}
+	return libvirt.OptString{s}
+}
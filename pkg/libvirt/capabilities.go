@@ -2,12 +2,14 @@ package libvirt
 
 import (
 	"encoding/xml"
+	"slices"
 )
 
 // CapabilitiesXML 是 libvirt capabilities 的 XML 结构
 type CapabilitiesXML struct {
-	XMLName xml.Name         `xml:"capabilities"`
-	Host    CapabilitiesHost `xml:"host"`
+	XMLName xml.Name            `xml:"capabilities"`
+	Host    CapabilitiesHost    `xml:"host"`
+	Guests  []CapabilitiesGuest `xml:"guest"`
 }
 
 // CapabilitiesHost 主机信息
@@ -149,6 +151,112 @@ type CapabilitiesCacheBank struct {
 	CPUs  string `xml:"cpus,attr"`
 }
 
+// CapabilitiesGuest 节点可运行的 guest 类型（一个 os_type + arch 组合）
+type CapabilitiesGuest struct {
+	OSType string                `xml:"os_type"`
+	Arch   CapabilitiesGuestArch `xml:"arch"`
+}
+
+// CapabilitiesGuestArch guest 架构及其模拟器、机器类型与虚拟化类型
+type CapabilitiesGuestArch struct {
+	Name     string                    `xml:"name,attr"`
+	WordSize string                    `xml:"wordsize"`
+	Emulator string                    `xml:"emulator"`
+	Machines []CapabilitiesMachine     `xml:"machine"`
+	Domains  []CapabilitiesGuestDomain `xml:"domain"`
+}
+
+// CapabilitiesMachine 机器类型，别名（如 pc）通过 canonical 指向具体版本
+type CapabilitiesMachine struct {
+	Canonical string `xml:"canonical,attr"`
+	MaxCPUs   string `xml:"maxCpus,attr"`
+	Name      string `xml:",chardata"`
+}
+
+// CapabilitiesGuestDomain 支持的虚拟化类型（kvm、qemu 等）
+type CapabilitiesGuestDomain struct {
+	Type     string                `xml:"type,attr"`
+	Emulator string                `xml:"emulator"`
+	Machines []CapabilitiesMachine `xml:"machine"`
+}
+
+// HasCPUFeature 判断宿主机 CPU 是否具有指定特性
+func (c *CapabilitiesXML) HasCPUFeature(name string) bool {
+	return slices.ContainsFunc(c.Host.CPU.Features, func(f CapabilitiesFeature) bool {
+		return f.Name == name
+	})
+}
+
+// VirtualizationExtension 返回宿主机 CPU 的硬件虚拟化扩展：vmx（Intel VT-x）、svm（AMD-V），不支持时为空
+func (c *CapabilitiesXML) VirtualizationExtension() string {
+	for _, ext := range []string{"vmx", "svm"} {
+		if c.HasCPUFeature(ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// SupportsDomainType 判断节点是否能运行指定虚拟化类型（kvm、qemu）的 guest
+func (c *CapabilitiesXML) SupportsDomainType(domainType string) bool {
+	for _, guest := range c.Guests {
+		for _, domain := range guest.Arch.Domains {
+			if domain.Type == domainType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SupportsGuest 判断节点是否能以指定虚拟化类型运行该架构的 hvm guest
+func (c *CapabilitiesXML) SupportsGuest(arch, domainType string) bool {
+	for _, guest := range c.Guests {
+		if guest.OSType != "hvm" || guest.Arch.Name != arch {
+			continue
+		}
+		for _, domain := range guest.Arch.Domains {
+			if domain.Type == domainType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GuestArchs 返回节点可运行 hvm guest 的架构
+func (c *CapabilitiesXML) GuestArchs() []string {
+	var archs []string
+	for _, guest := range c.Guests {
+		if guest.OSType == "hvm" && !slices.Contains(archs, guest.Arch.Name) {
+			archs = append(archs, guest.Arch.Name)
+		}
+	}
+	return archs
+}
+
+// SupportsMachine 判断架构是否支持指定机器类型，别名（如 virt、q35）与具体版本都可以匹配
+// 虚拟化类型下单独列出的机器类型（如 kvm 专有的）也计算在内
+func (c *CapabilitiesXML) SupportsMachine(arch, machine string) bool {
+	match := func(m CapabilitiesMachine) bool {
+		return m.Name == machine || m.Canonical == machine
+	}
+	for _, guest := range c.Guests {
+		if guest.OSType != "hvm" || guest.Arch.Name != arch {
+			continue
+		}
+		if slices.ContainsFunc(guest.Arch.Machines, match) {
+			return true
+		}
+		for _, domain := range guest.Arch.Domains {
+			if slices.ContainsFunc(domain.Machines, match) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // ParseCapabilities 解析 capabilities XML
 func ParseCapabilities(xmlData string) (*CapabilitiesXML, error) {
 	var caps CapabilitiesXML
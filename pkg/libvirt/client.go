@@ -1,15 +1,18 @@
 package libvirt
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"net/url"
-	"os"
 	"os/exec"
-	"os/user"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/digitalocean/go-libvirt"
@@ -18,8 +21,9 @@ import (
 )
 
 type Client struct {
-	conn *libvirt.Libvirt
-	uri  string // 保存原始连接 URI
+	conn      *libvirt.Libvirt
+	uri       string   // 保存原始连接 URI
+	emulators sync.Map // 按 架构/虚拟化类型 缓存从 capabilities 探测到的 emulator 路径
 }
 
 // DomainInfo 包含域的详细信息
@@ -30,43 +34,105 @@ type DomainInfo struct {
 	MaxMemory   uint64             `json:"max_memory"` // KB
 	Memory      uint64             `json:"memory"`     // KB
 	VCPUs       uint16             `json:"vcpus"`
-	CPUTime     uint64             `json:"cpu_time"` // nanoseconds
+	MaxVCPUs    uint16             `json:"max_vcpus"` // vCPU 热插上限
+	CPUTime     uint64             `json:"cpu_time"`  // nanoseconds
 	OSType      string             `json:"os_type"`
+	CPUModel    string             `json:"cpu_model,omitempty"` // CPU 型号，host-passthrough/host-model 时为模式名
 	Autostart   bool               `json:"autostart"`
 	Persistent  bool               `json:"persistent"`
 	NetworkInfo []NetworkInterface `json:"network_interfaces"`
+	CPUTune     *CPUTune           `json:"cputune,omitempty"`   // CPU 权重与上限，未配置时为空
+	BlkioTune   *BlkioTune         `json:"blkiotune,omitempty"` // 磁盘 IO 权重，未配置时为空
+	NUMATune    *NUMATune          `json:"numatune,omitempty"`  // NUMA 绑定，未绑定单个 cell 时为空
 	StartTime   *time.Time         `json:"start_time,omitempty"`
-	OSVersion   string             `json:"os_version,omitempty"`
+	// AddressFamily 创建时指定的网卡地址族（ipv4, ipv6, dual），记录在域 metadata 中，未记录时为空（即 ipv4）
+	AddressFamily string `json:"address_family,omitempty"`
+	// MACSpoofCheck 创建时是否开启 OVS 端口防 MAC 欺骗，记录在域 metadata 中
+	MACSpoofCheck bool `json:"mac_spoof_check,omitempty"`
+	// CreatedAt 创建时间，记录在域 metadata 中，不是由 JVP 创建或 JVP 记录创建时间之前的域为空
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	// LoginUser 创建时注入密钥对的登录用户，记录在域 metadata 中，注入发行版默认用户（名称未知）时为空
+	LoginUser string `json:"login_user,omitempty"`
+	// Labels 实例标签，记录在域 metadata 中，用于 JVP 数据目录丢失后恢复
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // NetworkInterface 网络接口信息
 type NetworkInterface struct {
-	Name   string `json:"name"`
-	Type   string `json:"type"`
-	Source string `json:"source"`
-	MAC    string `json:"mac"`
-	Model  string `json:"model"`
+	Name      string              `json:"name"`
+	Type      string              `json:"type"`
+	Source    string              `json:"source"`
+	MAC       string              `json:"mac"`
+	Model     string              `json:"model"`
+	Bandwidth *InterfaceBandwidth `json:"bandwidth,omitempty"` // 网卡限速（QoS），未限速时为空
+
+	VirtualPort string `json:"virtual_port,omitempty"` // 虚拟交换机端口类型，接入 OVS 网桥时为 openvswitch
+	InterfaceID string `json:"interface_id,omitempty"` // OVS 端口的 iface-id，供 SDN 控制器关联端口
+	VLAN        int    `json:"vlan,omitempty"`         // OVS access 端口的 VLAN tag，未设置时为 0
+	Filter      string `json:"filter,omitempty"`       // 网卡引用的 nwfilter，未设置时为空
 }
 
 // CreateVMConfig 创建虚拟机配置参数
 type CreateVMConfig struct {
-	Name              string              // 虚拟机名称（必填）
-	Memory            uint64              // 内存大小（KB）（必填）
-	VCPUs             uint16              // 虚拟 CPU 数量（必填）
-	DiskPath          string              // 磁盘路径（必填）
-	DiskSize          uint64              // 磁盘大小（GB）（可选，用于创建新磁盘）
-	DiskBus           string              // 磁盘总线类型：virtio, sata, scsi, ide（默认：virtio）
-	NetworkType       string              // 网络类型：network, bridge, direct（默认：bridge）
-	NetworkSource     string              // 网络源：网络名称或网桥名称（默认：br0）
-	OSType            string              // 操作系统类型：hvm, linux, exe（默认：hvm）
-	Architecture      string              // CPU 架构：x86_64, aarch64, i686 等（默认：x86_64）
-	MachineType       string              // 机器类型（可选，如：pc-q35-6.2）
-	ISOPath           string              // ISO 路径（可选，用于操作系统安装）
-	VNCSocket         string              // VNC Unix socket 路径（可选，默认：/var/lib/jvp/qemu/{name}.vnc）
-	Autostart         bool                // 是否开机自动启动（默认：false）
-	CloudInit         *cloudinit.Config   // cloud-init 配置（可选）
-	CloudInitUserData *cloudinit.UserData // cloud-init 用户数据（可选）
-	cloudInitISOPath  string              // cloud-init ISO 路径（内部使用）
+	Name                 string               // 虚拟机名称（必填）
+	Memory               uint64               // 内存大小（KB）（必填）
+	VCPUs                uint16               // 虚拟 CPU 数量（必填）
+	MaxMemory            uint64               // 内存热插上限（KB）（可选，默认等于 Memory，即运行时不能扩容）
+	MaxVCPUs             uint16               // vCPU 热插上限（可选，默认等于 VCPUs，即运行时不能扩容）
+	CPUTune              *CPUTune             // CPU 权重与上限（可选，写入 cputune 元素）
+	BlkioTune            *BlkioTune           // 磁盘 IO 权重（可选，写入 blkiotune 元素）
+	NUMATune             *NUMATune            // NUMA 绑定（可选，写入 numatune 元素并把 vCPU 限定在该 cell 的 CPU 上）
+	DiskPath             string               // 磁盘路径（必填）
+	DiskSize             uint64               // 磁盘大小（GB）（可选，用于创建新磁盘）
+	DiskBus              string               // 磁盘总线类型：virtio, sata, scsi, nvme, ide（默认：virtio）
+	DiskController       DiskControllerConfig // virtio-scsi 控制器参数（可选，DiskBus=scsi 时生效）
+	DiskDriver           *DiskDriverOptions   // 系统盘 driver 参数（可选，未设置的字段使用 qcow2 的默认值）
+	NetworkType          string               // 网络类型：network, bridge, direct, ovs（默认：bridge）
+	NetworkSource        string               // 网络源：网络名称、网桥名称（含 OVS 网桥）或 direct 时的宿主机网卡（默认：br0）
+	DirectMode           string               // NetworkType=direct 时的 macvtap 模式：vepa, bridge, private, passthrough（默认：bridge）
+	NetworkBandwidth     *InterfaceBandwidth  // 网卡限速（可选，写入 interface 的 bandwidth 元素）
+	NetworkVLAN          int                  // NetworkType=ovs 时端口的 VLAN tag（可选，1-4094，设置后为 access 端口）
+	OVSInterfaceID       string               // NetworkType=ovs 时端口的 interfaceid（可选，UUID，默认由 libvirt 生成）
+	MACSpoofCheck        bool                 // NetworkType=ovs 时是否开启防 MAC 欺骗（记录在域 metadata 中，流表由调用方下发）
+	NetworkFilter        string               // 网卡引用的 nwfilter 名称（可选，仅 network 与 bridge 类型，过滤器需已在节点上定义）
+	AddressFamily        string               // 网卡地址族：ipv4, ipv6, dual（可选，非 ipv4 时记录在域 metadata 中，guest 内的地址配置由 cloud-init network-config 完成）
+	CreatedAt            time.Time            // 创建时间（记录在域 metadata 中，零值时使用当前时间）
+	LoginUser            string               // 注入密钥对的登录用户（可选，记录在域 metadata 中，供查询 SSH 登录信息）
+	Labels               map[string]string    // 实例标签（可选，记录在域 metadata 中，之后通过 SetDomainLabels 修改）
+	OSType               string               // 操作系统类型：hvm, linux, exe（默认：hvm）
+	Architecture         string               // CPU 架构：x86_64, aarch64, i686 等（默认：x86_64）
+	MachineType          string               // 机器类型（可选，如：pc-q35-6.2）
+	ISOPath              string               // ISO 路径（可选，用于操作系统安装）
+	IgnitionPath         string               // Ignition 配置文件路径（可选，通过 fw_cfg 的 opt/com.coreos/config 传给 Fedora CoreOS/Flatcar）
+	CloudInitSeedURL     string               // cloud-init NoCloud 数据源地址（可选，写入 SMBIOS system serial，guest 从该地址拉取 meta-data 与 user-data，无需 cidata ISO）
+	Kernel               string               // 直接引导的内核路径（可选，节点上的绝对路径，设置后跳过磁盘上的 bootloader）
+	Initrd               string               // 直接引导的 initrd 路径（可选，需要 Kernel）
+	KernelCmdline        string               // 直接引导的内核命令行（可选，需要 Kernel，如 console=ttyS0 root=/dev/vda1）
+	VNCSocket            string               // VNC Unix socket 路径（可选，默认：/var/lib/jvp/qemu/{name}.vnc）
+	Autostart            bool                 // 是否开机自动启动（默认：false）
+	SerialType           string               // 串口类型：pty, file, tcp（默认：pty）
+	SerialLogPath        string               // 串口输出日志文件（可选，默认：/var/lib/jvp/qemu/{name}.serial.log）
+	SerialTCPHost        string               // type=tcp 时监听地址（可选，默认：127.0.0.1）
+	SerialTCPPort        int                  // type=tcp 时监听端口（type=tcp 时必填）
+	BootOrder            []string             // 启动设备顺序（可选）：hd, cdrom, network；设置后使用 per-device boot order
+	DomainType           string               // 虚拟化类型：kvm, qemu（可选，默认：节点支持 KVM 时为 kvm，否则为 qemu 即 TCG 软件模拟）
+	NestedVirtualization bool                 // 是否开启嵌套虚拟化：CPU 使用 host-passthrough 并要求 vmx/svm，用于在实例内运行 KVM（需要 kvm 类型且宿主机开启 nested）
+	Devices              DeviceOptions        // 可选设备开关（TPM、声卡、看门狗、RNG）
+	SPICE                *SPICEConfig         // SPICE 图形协议（可选，在 VNC 之外额外提供，含 vdagent 通道与 USB 重定向）
+	QEMUArgs             []string             // 透传给 QEMU 的附加命令行参数（可选，写入 qemu:commandline，按 ValidateQEMUArgs 校验）
+	QEMUArgsUnsafe       bool                 // 是否允许白名单以外的 QEMU 选项（默认：false）
+	CloudInit            *cloudinit.Config    // cloud-init 配置（可选）
+	CloudInitUserData    *cloudinit.UserData  // cloud-init 用户数据（可选）
+	cloudInitISOPath     string               // cloud-init ISO 路径（内部使用）
+}
+
+// DeviceOptions 可选设备开关，零值即默认配置：无 TPM、无声卡、无看门狗、启用 virtio-rng
+type DeviceOptions struct {
+	TPM            bool   // 是否启用 TPM 2.0（swtpm emulator，Windows 11 必需）
+	SoundModel     string // 声卡型号：ich9, ich6, ac97, es1370, sb16, usb（为空不添加声卡）
+	WatchdogModel  string // 看门狗型号：i6300esb, ib700, diag288（为空不添加看门狗）
+	WatchdogAction string // 看门狗触发动作：reset, shutdown, poweroff, pause, none, dump, inject-nmi（默认：reset）
+	DisableRNG     bool   // 是否禁用 virtio-rng（默认启用，熵源为宿主机 /dev/urandom）
 }
 
 func New() (*Client, error) {
@@ -74,8 +140,14 @@ func New() (*Client, error) {
 }
 
 // NewWithURI 使用指定的 URI 创建 libvirt 客户端
-// 如果 uri 为空，则使用默认的 qemu:///system
+// 如果 uri 为空，则使用默认的 qemu:///system；TLS 连接使用 SetTLSConfig 为该 URI 配置的证书
 func NewWithURI(uri string) (*Client, error) {
+	return NewWithTLS(uri, tlsConfigFor(uri))
+}
+
+// NewWithTLS 使用指定的 URI 与证书配置创建 libvirt 客户端，证书配置只对 TLS 连接生效
+// 证书配置为空时 TLS 连接使用 URI 中的 pkipath 或 libvirt 默认位置
+func NewWithTLS(uri string, tlsConfig TLSConfig) (*Client, error) {
 	if uri == "" {
 		uri = string(libvirt.QEMUSystem)
 	}
@@ -85,7 +157,13 @@ func NewWithURI(uri string) (*Client, error) {
 		return nil, fmt.Errorf("failed to parse URI %s: %v", uri, err)
 	}
 
-	l, err := libvirt.ConnectToURI(parsedURI)
+	var l *libvirt.Libvirt
+	if !tlsConfig.IsZero() && isTLSTransport(parsedURI) {
+		l = libvirt.NewWithDialer(newTLSDialer(parsedURI, tlsConfig))
+		err = l.ConnectToURI(libvirt.RemoteURI(parsedURI))
+	} else {
+		l, err = libvirt.ConnectToURI(parsedURI)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to libvirt: %v", err)
 	}
@@ -93,6 +171,34 @@ func NewWithURI(uri string) (*Client, error) {
 	return &Client{conn: l, uri: uri}, nil
 }
 
+// Connect 按 URI 创建 libvirt 客户端
+// fake:// 开头的 URI 返回同名的内存 FakeLibvirt（用于 e2e 测试的 fake node），其余 URI 建立真实连接
+func Connect(uri string) (LibvirtClient, error) {
+	if strings.HasPrefix(uri, FakeScheme+"://") {
+		return FakeNode(uri), nil
+	}
+	return NewWithURI(uri)
+}
+
+// ConnectNode 按 URI 建立长期使用的节点连接，与 Connect 相同，真实连接额外准备 VNC socket 目录，
+// 并订阅 domain 生命周期事件为之后新定义的 domain 准备目录，连接关闭时退出订阅
+// 只用于按节点缓存的连接；校验用的临时连接使用 Connect，避免遍历所有 domain 并启动事件订阅
+func ConnectNode(uri string) (LibvirtClient, error) {
+	conn, err := Connect(uri)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := conn.(*Client); ok {
+		if err := c.prepareVNCSocketDirs(); err != nil {
+			log.Warn().Err(err).Str("uri", uri).Msg("Failed to prepare VNC socket directories")
+		}
+		if err := c.watchVNCSocketDirs(); err != nil {
+			log.Warn().Err(err).Str("uri", uri).Msg("Failed to watch domain lifecycle events")
+		}
+	}
+	return conn, nil
+}
+
 // formatLibvirtVersion converts libvirt version number to human readable format
 // libvirt version is encoded as: major * 1000000 + minor * 1000 + micro
 // For example: 8003000 = 8.3.0
@@ -112,6 +218,14 @@ func (c *Client) GetHostname() (string, error) {
 	return hostname, nil
 }
 
+// Close 断开 libvirt 连接，之后该客户端不可再使用
+func (c *Client) Close() error {
+	if err := c.conn.Disconnect(); err != nil {
+		return fmt.Errorf("failed to disconnect from libvirt: %w", err)
+	}
+	return nil
+}
+
 // GetLibvirtVersion 获取 libvirt 版本
 func (c *Client) GetLibvirtVersion() (string, error) {
 	v, err := c.conn.ConnectGetLibVersion()
@@ -150,20 +264,28 @@ func (c *Client) GetNodeInfo() (*NodeInfo, error) {
 	}, nil
 }
 
-// GetCapabilities 获取 libvirt 主机能力（XML 格式）
-func (c *Client) GetCapabilities() (string, error) {
-	caps, err := c.conn.ConnectGetCapabilities()
+// GetCapabilities 获取并解析 libvirt 主机能力：宿主机 CPU、NUMA 拓扑以及支持的 guest 架构
+func (c *Client) GetCapabilities() (*CapabilitiesXML, error) {
+	capsXML, err := c.conn.ConnectGetCapabilities()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get capabilities: %w", err)
+	}
+	caps, err := ParseCapabilities(capsXML)
 	if err != nil {
-		return "", fmt.Errorf("failed to get capabilities: %w", err)
+		return nil, fmt.Errorf("failed to parse capabilities: %w", err)
 	}
 	return caps, nil
 }
 
-// GetSysinfo 获取主机系统信息（SMBIOS，包含真实的 CPU 型号）
-func (c *Client) GetSysinfo() (string, error) {
-	sysinfo, err := c.conn.ConnectGetSysinfo(0)
+// GetSysinfo 获取并解析主机系统信息（SMBIOS，包含真实的 CPU 型号与整机序列号）
+func (c *Client) GetSysinfo() (*SysinfoXML, error) {
+	sysinfoXML, err := c.conn.ConnectGetSysinfo(0)
 	if err != nil {
-		return "", fmt.Errorf("failed to get sysinfo: %w", err)
+		return nil, fmt.Errorf("failed to get sysinfo: %w", err)
+	}
+	sysinfo, err := ParseSysinfo(sysinfoXML)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sysinfo: %w", err)
 	}
 	return sysinfo, nil
 }
@@ -227,12 +349,18 @@ func (c *Client) GetDomainInfo(domainUUID libvirt.UUID) (*DomainInfo, error) {
 		return nil, fmt.Errorf("failed to get domain info: %v", err)
 	}
 
-	info.State = c.formatDomainState(state)
+	info.State = formatDomainState(state)
 	info.MaxMemory = maxMem
 	info.Memory = memory
 	info.VCPUs = vcpus
+	info.MaxVCPUs = vcpus
 	info.CPUTime = cpuTime
 
+	// 获取 vCPU 上限（运行中取 live 配置，否则取持久化配置）
+	if maxVcpus, err := c.conn.DomainGetVcpusFlags(domain, uint32(libvirt.DomainVCPUMaximum)); err == nil && maxVcpus > 0 {
+		info.MaxVCPUs = uint16(maxVcpus)
+	}
+
 	// 获取 OS 类型
 	osType, err := c.conn.DomainGetOsType(domain)
 	if err == nil {
@@ -254,6 +382,9 @@ func (c *Client) GetDomainInfo(domainUUID libvirt.UUID) (*DomainInfo, error) {
 		info.NetworkInfo = networkInfo
 	}
 
+	// 获取 CPU 型号、CPU 调度参数与 IO 权重，读取失败时保持为空
+	_ = c.getDomainConfig(domain, info)
+
 	// 尝试获取启动时间（仅对运行中的域有效）
 	if state == uint8(libvirt.DomainRunning) {
 		startTime := c.getDomainStartTime(domain)
@@ -266,7 +397,7 @@ func (c *Client) GetDomainInfo(domainUUID libvirt.UUID) (*DomainInfo, error) {
 }
 
 // formatDomainState 将域状态数字转换为可读字符串
-func (c *Client) formatDomainState(state uint8) string {
+func formatDomainState(state uint8) string {
 	switch libvirt.DomainState(state) {
 	case libvirt.DomainNostate:
 		return "NoState"
@@ -314,10 +445,15 @@ func (c *Client) getDomainNetworkInfo(domain libvirt.Domain) ([]NetworkInterface
 	var interfaces []NetworkInterface
 	for _, iface := range domainXML.Devices.Interfaces {
 		netIface := NetworkInterface{
-			Name:  iface.Target.Dev,
-			Type:  iface.Type,
-			MAC:   iface.MAC.Address,
-			Model: iface.Model.Type,
+			Name:      iface.Target.Dev,
+			Type:      iface.Type,
+			MAC:       iface.MAC.Address,
+			Model:     iface.Model.Type,
+			Bandwidth: bandwidthFromXML(iface.Bandwidth),
+		}
+		netIface.applyVirtualPort(&iface)
+		if iface.FilterRef != nil {
+			netIface.Filter = iface.FilterRef.Filter
 		}
 
 		// 设置网络源
@@ -442,24 +578,8 @@ func (c *Client) DefineDomain(config *CreateVMConfig) (libvirt.Domain, error) {
 
 	// 确保 VNC socket 目录存在，并设置正确的权限
 	if config.VNCSocket != "" {
-		vncDir := filepath.Dir(config.VNCSocket)
-		if c.IsRemoteConnection() {
-			// 远程连接：通过 SSH 创建目录
-			if err := c.ExecuteRemoteCommand(fmt.Sprintf("mkdir -p '%s' && chmod 755 '%s'", vncDir, vncDir)); err != nil {
-				return libvirt.Domain{}, fmt.Errorf("create VNC socket directory on remote: %w", err)
-			}
-			// 尝试设置目录所有者（可能失败，取决于远程系统配置）
-			_ = c.ExecuteRemoteCommand(fmt.Sprintf("chown libvirt-qemu:kvm '%s' 2>/dev/null || chown qemu:qemu '%s' 2>/dev/null || true", vncDir, vncDir))
-		} else {
-			// 本地连接：直接创建目录
-			if err := os.MkdirAll(vncDir, 0o755); err != nil {
-				return libvirt.Domain{}, fmt.Errorf("create VNC socket directory: %w", err)
-			}
-			// 设置目录所有者为 libvirt-qemu:kvm，以便 QEMU 进程可以创建 socket
-			if err := c.fixVNCDirOwnership(vncDir); err != nil {
-				// 非致命错误，只记录警告
-				log.Warn().Err(err).Str("dir", vncDir).Msg("Failed to fix VNC directory ownership")
-			}
+		if err := c.prepareVNCSocketDir(filepath.Dir(config.VNCSocket)); err != nil {
+			return libvirt.Domain{}, err
 		}
 	}
 
@@ -505,12 +625,8 @@ func (c *Client) DefineDomain(config *CreateVMConfig) (libvirt.Domain, error) {
 }
 
 // StartDomain 启动已定义的域
+// VNC socket 目录在连接节点时和 domain 定义时准备，见 vnc.go
 func (c *Client) StartDomain(domain libvirt.Domain) error {
-	// 启动前确保 VNC socket 目录存在
-	if err := c.ensureVNCSocketDir(domain); err != nil {
-		log.Warn().Err(err).Str("domain", domain.Name).Msg("Failed to ensure VNC socket directory")
-	}
-
 	err := c.conn.DomainCreate(domain)
 	if err != nil {
 		return fmt.Errorf("failed to start domain %s: %v", domain.Name, err)
@@ -518,52 +634,6 @@ func (c *Client) StartDomain(domain libvirt.Domain) error {
 	return nil
 }
 
-// ensureVNCSocketDir 确保 VNC socket 目录存在
-func (c *Client) ensureVNCSocketDir(domain libvirt.Domain) error {
-	// 获取 domain XML
-	xmlDesc, err := c.conn.DomainGetXMLDesc(domain, 0)
-	if err != nil {
-		return fmt.Errorf("get domain XML: %w", err)
-	}
-
-	// 解析 VNC socket 路径
-	// 查找 <graphics type='vnc' ... socket='/path/to/socket' />
-	// 使用简单的字符串查找
-	socketStart := strings.Index(xmlDesc, "socket='/var/lib/jvp/qemu/")
-	if socketStart == -1 {
-		// 没有使用 Unix socket 的 VNC
-		return nil
-	}
-
-	// 提取 socket 路径
-	socketStart += len("socket='")
-	socketEnd := strings.Index(xmlDesc[socketStart:], "'")
-	if socketEnd == -1 {
-		return nil
-	}
-	socketPath := xmlDesc[socketStart : socketStart+socketEnd]
-
-	// 获取目录
-	vncDir := filepath.Dir(socketPath)
-
-	// 创建目录
-	if c.IsRemoteConnection() {
-		if err := c.ExecuteRemoteCommand(fmt.Sprintf("mkdir -p '%s' && chmod 755 '%s'", vncDir, vncDir)); err != nil {
-			return fmt.Errorf("create VNC socket directory on remote: %w", err)
-		}
-		_ = c.ExecuteRemoteCommand(fmt.Sprintf("chown libvirt-qemu:kvm '%s' 2>/dev/null || chown qemu:qemu '%s' 2>/dev/null || true", vncDir, vncDir))
-	} else {
-		if err := os.MkdirAll(vncDir, 0o755); err != nil {
-			return fmt.Errorf("create VNC socket directory: %w", err)
-		}
-		if err := c.fixVNCDirOwnership(vncDir); err != nil {
-			log.Warn().Err(err).Str("dir", vncDir).Msg("Failed to fix VNC directory ownership")
-		}
-	}
-
-	return nil
-}
-
 // StopDomain 停止运行中的域（优雅关闭）
 func (c *Client) StopDomain(domain libvirt.Domain) error {
 	err := c.conn.DomainShutdown(domain)
@@ -609,91 +679,115 @@ func (c *Client) DestroyDomain(domain libvirt.Domain) error {
 	return nil
 }
 
+// ErrHotplugLimitExceeded 热修改的目标值超过域当前的 maxMemory / vcpu 上限，需要关机修改配置后重启生效
+var ErrHotplugLimitExceeded = errors.New("exceeds hot-plug limit, restart required")
+
+// ResizeResult 修改域内存或 vCPU 的结果，持久化配置与运行中的域分别生效
+type ResizeResult struct {
+	Persisted   bool  // 持久化配置已更新，下次启动生效
+	LiveApplied bool  // 已在运行中的域上立即生效
+	LiveError   error // 持久化配置已更新但热修改失败的原因（如 guest 未加载 balloon 驱动或拒绝热拔 vCPU）
+}
+
 // ModifyDomainMemory 修改域的内存大小
 // memoryKB: 新的内存大小（KB）
 // live: true=热修改（如果域正在运行），false=仅修改配置（需要重启生效）
-func (c *Client) ModifyDomainMemory(domain libvirt.Domain, memoryKB uint64, live bool) error {
-	// 获取持久化配置 XML（使用 DomainXMLInactive 标志）
-	xmlDesc, err := c.conn.DomainGetXMLDesc(domain, libvirt.DomainXMLInactive)
-	if err != nil {
-		return fmt.Errorf("get domain XML: %w", err)
-	}
-
-	// 解析 XML
-	var domainXML DomainXML
-	if err := xml.Unmarshal([]byte(xmlDesc), &domainXML); err != nil {
-		return fmt.Errorf("unmarshal domain XML: %w", err)
-	}
-
-	// 修改内存
-	domainXML.Memory.Value = memoryKB
-	domainXML.CurrentMemory.Value = memoryKB
-
-	// 重新序列化 XML
-	xmlBytes, err := xml.MarshalIndent(&domainXML, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshal domain XML: %w", err)
+// 持久化配置中 memoryKB 不超过 maxMemory 时只调整 currentMemory，超过时同步抬高 maxMemory；
+// 热修改只能在运行中域的 maxMemory 范围内进行，超出时返回 ErrHotplugLimitExceeded 且不修改任何配置
+// 持久化配置更新后热修改失败不返回 error，失败原因记录在结果的 LiveError 中
+func (c *Client) ModifyDomainMemory(domain libvirt.Domain, memoryKB uint64, live bool) (*ResizeResult, error) {
+	unlock := c.lockDomain(domain)
+	defer unlock()
+
+	running := live && c.isDomainRunning(domain)
+	if running {
+		_, maxMem, _, _, _, err := c.conn.DomainGetInfo(domain)
+		if err != nil {
+			return nil, fmt.Errorf("get domain info: %w", err)
+		}
+		if memoryKB > maxMem {
+			return nil, fmt.Errorf("memory %d KiB > max memory %d KiB: %w", memoryKB, maxMem, ErrHotplugLimitExceeded)
+		}
 	}
 
-	// 更新持久化配置
-	_, err = c.conn.DomainDefineXML(string(xmlBytes))
+	// 更新持久化配置，仅在超过上限时抬高 maxMemory
+	err := c.editDomainConfig(domain, func(domainXML *DomainXML) error {
+		if memoryKB > domainXML.Memory.Value {
+			domainXML.Memory.Value = memoryKB
+		}
+		domainXML.CurrentMemory.Value = memoryKB
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("define domain with new memory: %w", err)
+		return nil, fmt.Errorf("modify domain memory: %w", err)
 	}
+	result := &ResizeResult{Persisted: true}
 
-	// 如果请求热修改且域正在运行，尝试立即生效
-	if live {
-		state, _, err := c.conn.DomainGetState(domain, 0)
-		if err == nil && libvirt.DomainState(state) == libvirt.DomainRunning {
-			// 尝试热修改内存（可能会失败，某些虚拟机不支持）
-			_ = c.conn.DomainSetMemory(domain, memoryKB)
+	// 运行中的域通过 balloon 在上限内立即生效
+	if running {
+		if err := c.conn.DomainSetMemoryFlags(domain, memoryKB, uint32(libvirt.DomainMemLive)); err != nil {
+			result.LiveError = fmt.Errorf("set live memory: %w", err)
+		} else {
+			result.LiveApplied = true
 		}
 	}
 
-	return nil
+	return result, nil
 }
 
 // ModifyDomainVCPU 修改域的 VCPU 数量
 // vcpus: 新的 VCPU 数量
 // live: true=热修改（如果域正在运行），false=仅修改配置（需要重启生效）
-func (c *Client) ModifyDomainVCPU(domain libvirt.Domain, vcpus uint16, live bool) error {
-	// 获取持久化配置 XML（使用 DomainXMLInactive 标志）
-	xmlDesc, err := c.conn.DomainGetXMLDesc(domain, libvirt.DomainXMLInactive)
-	if err != nil {
-		return fmt.Errorf("get domain XML: %w", err)
-	}
-
-	// 解析 XML
-	var domainXML DomainXML
-	if err := xml.Unmarshal([]byte(xmlDesc), &domainXML); err != nil {
-		return fmt.Errorf("unmarshal domain XML: %w", err)
-	}
-
-	// 修改 VCPU
-	domainXML.VCPU.Value = int(vcpus)
-
-	// 重新序列化 XML
-	xmlBytes, err := xml.MarshalIndent(&domainXML, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshal domain XML: %w", err)
+// 持久化配置中 vcpus 不超过上限时只调整 current，超过时同步抬高上限；
+// 热修改只能在运行中域的 vcpu 上限范围内进行，超出时返回 ErrHotplugLimitExceeded 且不修改任何配置
+// 持久化配置更新后热修改失败不返回 error，失败原因记录在结果的 LiveError 中
+func (c *Client) ModifyDomainVCPU(domain libvirt.Domain, vcpus uint16, live bool) (*ResizeResult, error) {
+	unlock := c.lockDomain(domain)
+	defer unlock()
+
+	running := live && c.isDomainRunning(domain)
+	if running {
+		maxVcpus, err := c.conn.DomainGetVcpusFlags(domain, uint32(libvirt.DomainVCPULive|libvirt.DomainVCPUMaximum))
+		if err != nil {
+			return nil, fmt.Errorf("get max VCPU: %w", err)
+		}
+		if int32(vcpus) > maxVcpus {
+			return nil, fmt.Errorf("vcpus %d > max vcpus %d: %w", vcpus, maxVcpus, ErrHotplugLimitExceeded)
+		}
 	}
 
-	// 更新持久化配置
-	_, err = c.conn.DomainDefineXML(string(xmlBytes))
+	// 更新持久化配置，仅在超过上限时抬高上限；等于上限时省略 current
+	err := c.editDomainConfig(domain, func(domainXML *DomainXML) error {
+		if int(vcpus) > domainXML.VCPU.Value {
+			domainXML.VCPU.Value = int(vcpus)
+		}
+		domainXML.VCPU.Current = int(vcpus)
+		if domainXML.VCPU.Current == domainXML.VCPU.Value {
+			domainXML.VCPU.Current = 0
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("define domain with new VCPU: %w", err)
+		return nil, fmt.Errorf("modify domain VCPU: %w", err)
 	}
+	result := &ResizeResult{Persisted: true}
 
-	// 如果请求热修改且域正在运行，尝试立即生效
-	if live {
-		state, _, err := c.conn.DomainGetState(domain, 0)
-		if err == nil && libvirt.DomainState(state) == libvirt.DomainRunning {
-			// 尝试热修改 VCPU（可能会失败，某些虚拟机不支持）
-			_ = c.conn.DomainSetVcpusFlags(domain, uint32(vcpus), uint32(libvirt.DomainVCPULive))
+	// 运行中的域在上限内热插/热拔 vCPU
+	if running {
+		if err := c.conn.DomainSetVcpusFlags(domain, uint32(vcpus), uint32(libvirt.DomainVCPULive)); err != nil {
+			result.LiveError = fmt.Errorf("set live VCPU: %w", err)
+		} else {
+			result.LiveApplied = true
 		}
 	}
 
-	return nil
+	return result, nil
+}
+
+// isDomainRunning 判断域是否处于运行状态
+func (c *Client) isDomainRunning(domain libvirt.Domain) bool {
+	state, _, err := c.conn.DomainGetState(domain, 0)
+	return err == nil && libvirt.DomainState(state) == libvirt.DomainRunning
 }
 
 // SetDomainAutostart 设置域的自动启动状态
@@ -709,6 +803,55 @@ func (c *Client) SetDomainAutostart(domain libvirt.Domain, autostart bool) error
 	return nil
 }
 
+// RenameDomain 重命名域，libvirt 只允许重命名已关机且没有快照的域
+func (c *Client) RenameDomain(domain libvirt.Domain, newName string) error {
+	if _, err := c.conn.DomainRename(domain, libvirt.OptString{newName}, 0); err != nil {
+		return fmt.Errorf("failed to rename domain %s to %s: %w", domain.Name, newName, err)
+	}
+	return nil
+}
+
+// SetDomainBootOrder 设置域的启动顺序（修改持久化配置，下次启动生效）
+// devices: 按启动优先级排列的磁盘目标设备名，如 ["vda", "hda"]；未列出的设备不参与启动
+func (c *Client) SetDomainBootOrder(domain libvirt.Domain, devices []string) error {
+	if len(devices) == 0 {
+		return fmt.Errorf("boot devices is empty")
+	}
+
+	unlock := c.lockDomain(domain)
+	defer unlock()
+
+	return c.editDomainConfig(domain, func(domainXML *DomainXML) error {
+		// 清除原有启动配置
+		domainXML.OS.Boot = nil
+		for i := range domainXML.Devices.Disks {
+			domainXML.Devices.Disks[i].Boot = nil
+		}
+		for i := range domainXML.Devices.Interfaces {
+			domainXML.Devices.Interfaces[i].Boot = nil
+		}
+
+		// 按顺序设置设备级 boot order
+		for i, dev := range devices {
+			found := false
+			for j := range domainXML.Devices.Disks {
+				if domainXML.Devices.Disks[j].Target.Dev == dev {
+					if domainXML.Devices.Disks[j].Boot != nil {
+						return fmt.Errorf("duplicate boot device: %s", dev)
+					}
+					domainXML.Devices.Disks[j].Boot = &DomainBootOrder{Order: i + 1}
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("device %s not found in domain", dev)
+			}
+		}
+		return nil
+	})
+}
+
 // DeleteDomain 删除域
 // flags: 可以组合以下标志
 //   - libvirt.DomainUndefineManagedSave: 同时删除托管保存的镜像
@@ -777,10 +920,102 @@ func (c *Client) validateVMConfig(config *CreateVMConfig) error {
 		return fmt.Errorf("vCPU count is required and must be greater than 0")
 	}
 
+	if config.MaxMemory != 0 && config.MaxMemory < config.Memory {
+		return fmt.Errorf("max memory must not be less than memory")
+	}
+
+	if config.MaxVCPUs != 0 && config.MaxVCPUs < config.VCPUs {
+		return fmt.Errorf("max vCPU count must not be less than vCPU count")
+	}
+
 	if config.DiskPath == "" {
 		return fmt.Errorf("disk path is required")
 	}
 
+	if config.DiskBus != "" {
+		if _, err := diskDevicePrefix(config.DiskBus); err != nil {
+			return err
+		}
+	}
+
+	if err := validateArchitecture(config.Architecture); err != nil {
+		return err
+	}
+
+	switch config.AddressFamily {
+	case "", AddressFamilyIPv4, AddressFamilyIPv6, AddressFamilyDual:
+	default:
+		return fmt.Errorf("unsupported address family: %s (must be ipv4, ipv6 or dual)", config.AddressFamily)
+	}
+
+	if _, err := resolveDiskDriverOptions("qcow2", config.DiskDriver); err != nil {
+		return err
+	}
+
+	if err := config.Devices.Validate(); err != nil {
+		return err
+	}
+
+	if config.NetworkBandwidth != nil {
+		if err := config.NetworkBandwidth.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := ValidateDirectMode(config.NetworkType, config.DirectMode); err != nil {
+		return err
+	}
+
+	if err := ValidateOVSOptions(config.NetworkType, config.NetworkVLAN, config.OVSInterfaceID, config.MACSpoofCheck); err != nil {
+		return err
+	}
+
+	if config.CPUTune != nil {
+		if err := config.CPUTune.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if config.BlkioTune != nil {
+		if err := config.BlkioTune.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if config.NUMATune != nil {
+		if err := config.NUMATune.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if config.SPICE != nil {
+		if err := config.SPICE.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := ValidateQEMUArgs(config.QEMUArgs, config.QEMUArgsUnsafe); err != nil {
+		return err
+	}
+
+	if err := ValidateDirectKernelBoot(config.Kernel, config.Initrd, config.KernelCmdline); err != nil {
+		return err
+	}
+
+	if config.CloudInitSeedURL != "" && config.IgnitionPath != "" {
+		return fmt.Errorf("cloud-init seed URL and Ignition config cannot be used together")
+	}
+
+	switch config.SerialType {
+	case "", "pty", "file":
+	case "tcp":
+		if config.SerialTCPPort <= 0 || config.SerialTCPPort > 65535 {
+			return fmt.Errorf("serial TCP port is required and must be between 1 and 65535")
+		}
+	default:
+		return fmt.Errorf("unsupported serial type: %s (must be pty, file or tcp)", config.SerialType)
+	}
+
 	return nil
 }
 
@@ -809,34 +1044,114 @@ func (c *Client) setDefaultVMConfig(config *CreateVMConfig) {
 	if config.VNCSocket == "" {
 		config.VNCSocket = "/var/lib/jvp/qemu/" + config.Name + ".vnc"
 	}
+
+	if config.SerialType == "" {
+		config.SerialType = "pty"
+	}
+
+	if config.SerialLogPath == "" {
+		config.SerialLogPath = "/var/lib/jvp/qemu/" + config.Name + ".serial.log"
+	}
+
+	if config.SerialType == "tcp" && config.SerialTCPHost == "" {
+		config.SerialTCPHost = "127.0.0.1"
+	}
+
+	if config.DomainType == "" {
+		config.DomainType = c.detectDomainType(config.Architecture)
+	}
+}
+
+// detectDomainType 根据节点 capabilities 选择虚拟化类型
+// 节点不支持该架构的 KVM（如容器或 CI 中没有 /dev/kvm，或在 x86 节点上运行 aarch64 guest）时退化为 qemu（TCG 软件模拟）
+func (c *Client) detectDomainType(arch string) string {
+	caps, err := c.GetCapabilities()
+	if err != nil || caps.SupportsGuest(arch, "kvm") {
+		return "kvm"
+	}
+	return "qemu"
+}
+
+// nestedVirtualizationCPU 构建嵌套虚拟化的 CPU 配置
+// host-passthrough 把宿主机 CPU 原样暴露给实例，再显式 require vmx（Intel）或 svm（AMD），
+// 宿主机未开启 kvm_intel/kvm_amd 的 nested 参数时实例启动失败而不是静默丢失该特性
+func (c *Client) nestedVirtualizationCPU(config *CreateVMConfig) (*DomainCPU, error) {
+	if config.DomainType != "kvm" {
+		return nil, fmt.Errorf("nested virtualization requires kvm domain type, got %s", config.DomainType)
+	}
+	if config.Architecture != "x86_64" && config.Architecture != "i686" {
+		return nil, fmt.Errorf("nested virtualization is only supported on x86_64 and i686, got %s", config.Architecture)
+	}
+
+	caps, err := c.GetCapabilities()
+	if err != nil {
+		return nil, err
+	}
+
+	var feature string
+	switch caps.Host.CPU.Vendor {
+	case "Intel":
+		feature = "vmx"
+	case "AMD":
+		feature = "svm"
+	default:
+		return nil, fmt.Errorf("nested virtualization is not supported on host CPU vendor %q", caps.Host.CPU.Vendor)
+	}
+
+	return &DomainCPU{
+		Mode:     "host-passthrough",
+		Check:    "none",
+		Features: []DomainFeature{{Policy: "require", Name: feature}},
+	}, nil
 }
 
 // buildDomainXML 根据配置构建 DomainXML 结构
 func (c *Client) buildDomainXML(config *CreateVMConfig) (*DomainXML, error) {
+	if err := c.checkGuestArch(config); err != nil {
+		return nil, err
+	}
+
+	// memory 为 balloon 上限，currentMemory 为实际分配；vcpu 为热插上限，current 为在线数量
+	maxMemory := max(config.MaxMemory, config.Memory)
+	vcpu := DomainVCPU{
+		Placement: "static",
+		Value:     int(max(config.MaxVCPUs, config.VCPUs)),
+	}
+	if vcpu.Value > int(config.VCPUs) {
+		vcpu.Current = int(config.VCPUs)
+	}
+	if config.NUMATune != nil {
+		cpuset, err := c.numaCellCPUSet(config.NUMATune.Node)
+		if err != nil {
+			return nil, err
+		}
+		vcpu.CPUSet = cpuset
+	}
+
 	domain := &DomainXML{
-		Type: "kvm",
+		Type: config.DomainType,
 		Name: config.Name,
 		Memory: DomainMemory{
 			Unit:  "KiB",
-			Value: config.Memory,
+			Value: maxMemory,
 		},
 		CurrentMemory: DomainMemory{
 			Unit:  "KiB",
 			Value: config.Memory,
 		},
-		VCPU: DomainVCPU{
-			Placement: "static",
-			Value:     int(config.VCPUs),
-		},
+		VCPU:      vcpu,
+		CPUTune:   config.CPUTune.toXML(),
+		BlkioTune: config.BlkioTune.toXML(),
+		NUMATune:  config.NUMATune.toXML(),
 		OS: DomainOS{
 			Type: DomainOSType{
 				Arch:    config.Architecture,
 				Machine: config.MachineType,
 				Value:   config.OSType,
 			},
-			Boot: DomainBoot{
-				Dev: "hd",
-			},
+			Kernel:  config.Kernel,
+			Initrd:  config.Initrd,
+			Cmdline: config.KernelCmdline,
 		},
 		Features: &DomainFeatures{
 			ACPI: &DomainFeatureEnabled{},
@@ -851,9 +1166,132 @@ func (c *Client) buildDomainXML(config *CreateVMConfig) (*DomainXML, error) {
 		Devices:    c.buildDevices(config),
 	}
 
+	// 未指定启动顺序时沿用 <boot dev='hd'/>，否则按设备设置 boot order
+	// libvirt 不允许 <os><boot> 与设备级 <boot order> 同时存在
+	if len(config.BootOrder) == 0 {
+		domain.OS.Boot = &DomainBoot{Dev: "hd"}
+	} else if err := applyBootOrder(&domain.Devices, config.BootOrder); err != nil {
+		return nil, err
+	}
+
+	if config.NestedVirtualization {
+		cpu, err := c.nestedVirtualizationCPU(config)
+		if err != nil {
+			return nil, err
+		}
+		domain.CPU = cpu
+	}
+
+	// Fedora CoreOS/Flatcar 不读取 cloud-init，首次启动时 Ignition 从 fw_cfg 读取配置
+	if config.IgnitionPath != "" {
+		domain.SysInfo = &DomainSysInfo{
+			Type: "fwcfg",
+			Entries: []DomainSysInfoEntry{{
+				Name: IgnitionFWCfgName,
+				File: config.IgnitionPath,
+			}},
+		}
+	}
+
+	// cloud-init 从 SMBIOS system serial 中读取 ds=nocloud;s=<url>，通过网络拉取配置
+	if config.CloudInitSeedURL != "" {
+		domain.SysInfo = &DomainSysInfo{
+			Type: "smbios",
+			System: &DomainSysInfoSystem{
+				Entries: []DomainSysInfoEntry{{
+					Name:  "serial",
+					Value: "ds=nocloud;s=" + config.CloudInitSeedURL,
+				}},
+			},
+		}
+		domain.OS.SMBIOS = &DomainSMBIOS{Mode: "sysinfo"}
+	}
+
+	if len(config.QEMUArgs) > 0 {
+		cmdline := &DomainQEMUCommandline{}
+		for _, arg := range config.QEMUArgs {
+			cmdline.Args = append(cmdline.Args, DomainQEMUArg{Value: arg})
+		}
+		domain.QEMUCommandline = cmdline
+	}
+
+	// scsi、nvme 系统盘需要对应控制器；virtio-scsi 可绑定独立 iothread
+	iothreadID := 0
+	if config.DiskBus == "scsi" && config.DiskController.IOThread {
+		domain.IOThreads = 1
+		iothreadID = 1
+	}
+	if ctrl := newDiskController(config.DiskBus, config.DiskController, iothreadID); ctrl != nil {
+		domain.Devices.Controllers = append(domain.Devices.Controllers, *ctrl)
+	}
+
+	if err := applyArchDefaults(domain, config); err != nil {
+		return nil, err
+	}
+
+	createdAt := config.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	meta := jvpInstanceMetadata{
+		MACSpoofCheck: config.MACSpoofCheck,
+		CreatedAt:     createdAt.UTC().Format(time.RFC3339),
+		LoginUser:     config.LoginUser,
+	}
+	if config.AddressFamily != AddressFamilyIPv4 {
+		meta.AddressFamily = config.AddressFamily
+	}
+	domain.Metadata = newJVPMetadata(meta, config.Labels)
+
 	return domain, nil
 }
 
+// applyBootOrder 按启动设备类型为设备设置 boot order
+// hd 对应系统盘，cdrom 对应第一个光驱，network 对应第一块网卡
+func applyBootOrder(devices *DomainDevices, bootOrder []string) error {
+	seen := make(map[string]bool, len(bootOrder))
+	for i, dev := range bootOrder {
+		if seen[dev] {
+			return fmt.Errorf("duplicate boot device: %s", dev)
+		}
+		seen[dev] = true
+
+		order := &DomainBootOrder{Order: i + 1}
+		switch dev {
+		case "hd":
+			idx := findDiskIndex(devices.Disks, "disk")
+			if idx < 0 {
+				return fmt.Errorf("boot device hd: no disk found")
+			}
+			devices.Disks[idx].Boot = order
+		case "cdrom":
+			idx := findDiskIndex(devices.Disks, "cdrom")
+			if idx < 0 {
+				return fmt.Errorf("boot device cdrom: no cdrom found")
+			}
+			devices.Disks[idx].Boot = order
+		case "network":
+			if len(devices.Interfaces) == 0 {
+				return fmt.Errorf("boot device network: no interface found")
+			}
+			devices.Interfaces[0].Boot = order
+		default:
+			return fmt.Errorf("unsupported boot device: %s (must be hd, cdrom or network)", dev)
+		}
+	}
+	return nil
+}
+
+// findDiskIndex 返回第一个指定类型（disk/cdrom）磁盘的下标，不存在时返回 -1
+func findDiskIndex(disks []DomainDisk, device string) int {
+	for i, disk := range disks {
+		if disk.Device == device {
+			return i
+		}
+	}
+	return -1
+}
+
 // buildDevices 构建设备配置
 func (c *Client) buildDevices(config *CreateVMConfig) DomainDevices {
 	// 构建网络接口配置
@@ -868,9 +1306,13 @@ func (c *Client) buildDevices(config *CreateVMConfig) DomainDevices {
 			Network: config.NetworkSource,
 		}
 	case "direct":
+		mode := config.DirectMode
+		if mode == "" {
+			mode = DefaultDirectMode
+		}
 		netSource = DomainInterfaceSource{
 			Dev:  config.NetworkSource,
-			Mode: "bridge",
+			Mode: mode,
 		}
 	default:
 		// 默认使用 bridge
@@ -879,8 +1321,10 @@ func (c *Client) buildDevices(config *CreateVMConfig) DomainDevices {
 		}
 	}
 
+	serial, console := buildSerial(config)
+
 	devices := DomainDevices{
-		Emulator: "/usr/bin/qemu-system-" + config.Architecture,
+		Emulator: c.emulatorPath(config.Architecture, config.DomainType),
 		Disks:    c.buildDisks(config),
 		Interfaces: []DomainInterface{
 			{
@@ -889,29 +1333,17 @@ func (c *Client) buildDevices(config *CreateVMConfig) DomainDevices {
 				Model: DomainInterfaceModel{
 					Type: "virtio",
 				},
+				Bandwidth: config.NetworkBandwidth.toXML(),
 			},
 		},
-		Graphics: DomainGraphics{
-			Type:   "vnc",
-			Socket: config.VNCSocket,
-		},
-		Serial: DomainSerial{
-			Type: "pty",
-			Target: DomainSerialTarget{
-				Type: "isa-serial",
-				Port: 0,
-				Model: DomainSerialTargetModel{
-					Name: "isa-serial",
-				},
-			},
-		},
-		Console: DomainConsole{
-			Type: "pty",
-			Target: DomainConsoleTarget{
-				Type: "serial",
-				Port: 0,
+		Graphics: []DomainGraphics{
+			{
+				Type:   "vnc",
+				Socket: config.VNCSocket,
 			},
 		},
+		Serial:  serial,
+		Console: console,
 		Controllers: []DomainController{
 			{
 				Type:  "usb",
@@ -949,61 +1381,276 @@ func (c *Client) buildDevices(config *CreateVMConfig) DomainDevices {
 		MemBalloon: &DomainMemBalloon{
 			Model: "virtio",
 		},
-		RNG: &DomainRNG{
-			Model: "virtio",
-			Backend: &DomainRNGBackend{
-				Model: "random",
-				Value: "/dev/urandom",
-			},
-		},
+	}
+	if config.NetworkType == NetworkTypeOVS {
+		devices.Interfaces[0].connectOVS(config.NetworkSource, config.NetworkVLAN, config.OVSInterfaceID)
+	}
+	if config.NetworkFilter != "" {
+		devices.Interfaces[0].FilterRef = &DomainInterfaceFilterRef{Filter: config.NetworkFilter}
 	}
 
+	applyDeviceOptions(&devices, config)
+	config.SPICE.apply(&devices)
+
 	return devices
 }
 
-// fixVNCDirOwnership 修复 VNC socket 目录的所有权为 libvirt-qemu:kvm
-func (c *Client) fixVNCDirOwnership(dirPath string) error {
-	// 查找 libvirt-qemu 用户和 kvm 组
-	libvirtUser, err := user.Lookup("libvirt-qemu")
-	if err != nil {
-		// 如果找不到用户，返回错误（非致命，调用方会记录警告）
-		return fmt.Errorf("lookup libvirt-qemu user: %w", err)
+// Validate 校验可选设备的型号与动作
+func (opts *DeviceOptions) Validate() error {
+	switch opts.SoundModel {
+	case "", "ich9", "ich6", "ac97", "es1370", "sb16", "usb":
+	default:
+		return fmt.Errorf("unsupported sound model: %s", opts.SoundModel)
 	}
 
-	kvmGroup, err := user.LookupGroup("kvm")
-	if err != nil {
-		return fmt.Errorf("lookup kvm group: %w", err)
+	switch opts.WatchdogModel {
+	case "", "i6300esb", "ib700", "diag288":
+	default:
+		return fmt.Errorf("unsupported watchdog model: %s", opts.WatchdogModel)
 	}
 
-	uid, err := strconv.Atoi(libvirtUser.Uid)
-	if err != nil {
-		return fmt.Errorf("parse libvirt-qemu UID: %w", err)
+	switch opts.WatchdogAction {
+	case "", "reset", "shutdown", "poweroff", "pause", "none", "dump", "inject-nmi":
+	default:
+		return fmt.Errorf("unsupported watchdog action: %s", opts.WatchdogAction)
+	}
+	if opts.WatchdogAction != "" && opts.WatchdogModel == "" {
+		return fmt.Errorf("watchdog action requires watchdog model")
 	}
 
-	gid, err := strconv.Atoi(kvmGroup.Gid)
-	if err != nil {
-		return fmt.Errorf("parse kvm GID: %w", err)
+	return nil
+}
+
+// qemuArgAllowlist 默认允许透传的 QEMU 选项，均只影响 guest 可见的设备与固件信息
+// -set 可以改写 libvirt 生成的任意选项（如磁盘的 file=），不在白名单内
+var qemuArgAllowlist = map[string]bool{
+	"-device":     true,
+	"-global":     true,
+	"-smbios":     true,
+	"-fw_cfg":     true,
+	"-acpitable":  true,
+	"-cpu":        true,
+	"-rtc":        true,
+	"-overcommit": true,
+	"-msg":        true,
+}
+
+// qemuHostSubOptions 会让 QEMU 读取宿主机文件的子选项（如 -fw_cfg/-acpitable/-smbios 的 file=），需要 unsafe=true
+var qemuHostSubOptions = []string{"file", "path"}
+
+// qemuHostDeviceDrivers 把宿主机设备直通给 guest 的 -device 驱动，需要 unsafe=true
+var qemuHostDeviceDrivers = map[string]bool{
+	"usb-host":   true,
+	"pci-assign": true,
+	"vhost-scsi": true,
+}
+
+// qemuArgReadsHost 判断白名单选项的值是否访问宿主机文件或直通宿主机设备，返回原因
+func qemuArgReadsHost(option, value string) string {
+	for i, part := range strings.Split(value, ",") {
+		key, val, hasValue := strings.Cut(part, "=")
+		if hasValue && slices.Contains(qemuHostSubOptions, key) {
+			return fmt.Sprintf("sub-option %s= reads host files", key)
+		}
+		if option != "-device" {
+			continue
+		}
+		driver := ""
+		switch {
+		case i == 0 && !hasValue:
+			driver = key
+		case key == "driver":
+			driver = val
+		}
+		if qemuHostDeviceDrivers[driver] || strings.HasPrefix(driver, "vfio-") || strings.HasPrefix(driver, "vhost-scsi") {
+			return fmt.Sprintf("device %s passes a host device through", driver)
+		}
+	}
+	return ""
+}
+
+// qemuArgBlocklist 无论是否 unsafe 都禁止透传的 QEMU 选项：会接管或破坏 libvirt 对进程的管理，或逃逸宿主机隔离
+var qemuArgBlocklist = map[string]bool{
+	"-monitor":     true,
+	"-qmp":         true,
+	"-qmp-pretty":  true,
+	"-daemonize":   true,
+	"-runas":       true,
+	"-run-with":    true,
+	"-chroot":      true,
+	"-sandbox":     true,
+	"-pidfile":     true,
+	"-incoming":    true,
+	"-loadvm":      true,
+	"-writeconfig": true,
+	"-readconfig":  true,
+	"-D":           true,
+}
+
+// ValidateQEMUArgs 校验 qemu:commandline 附加参数
+// 以 - 开头的参数视为选项（--opt 等价于 -opt），其余为上一个选项的值；
+// 黑名单选项始终拒绝，白名单以外的选项、读取宿主机文件的子选项与宿主机设备直通需要 unsafe=true，
+// 并在返回的 warnings 中给出危险提示
+func ValidateQEMUArgs(args []string, unsafe bool) ([]string, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	warnings := []string{"qemu:commandline is unsupported by libvirt, the domain will be marked as tainted"}
+	option := ""
+	for _, arg := range args {
+		if arg == "" {
+			return nil, fmt.Errorf("empty QEMU argument")
+		}
+		value := arg
+		if strings.HasPrefix(arg, "-") {
+			option, value, _ = strings.Cut("-"+strings.TrimLeft(arg, "-"), "=")
+			switch {
+			case qemuArgBlocklist[option]:
+				return nil, fmt.Errorf("QEMU option %s is not allowed", option)
+			case qemuArgAllowlist[option]:
+			case unsafe:
+				warnings = append(warnings, fmt.Sprintf("QEMU option %s is outside the allowlist and may conflict with libvirt-managed configuration", option))
+			default:
+				return nil, fmt.Errorf("QEMU option %s is outside the allowlist, set unsafe to pass it through", option)
+			}
+		}
+		if !qemuArgAllowlist[option] || value == "" {
+			continue
+		}
+		if reason := qemuArgReadsHost(option, value); reason != "" {
+			if !unsafe {
+				return nil, fmt.Errorf("QEMU option %s: %s, set unsafe to pass it through", option, reason)
+			}
+			warnings = append(warnings, fmt.Sprintf("QEMU option %s: %s", option, reason))
+		}
+	}
+
+	return warnings, nil
+}
+
+// applyDeviceOptions 按 DeviceOptions 添加 TPM、声卡、看门狗与 RNG 设备
+func applyDeviceOptions(devices *DomainDevices, config *CreateVMConfig) {
+	opts := config.Devices
+
+	if opts.TPM {
+		// x86_64 上 tpm-crb 为 TPM 2.0 推荐型号，其他架构交给 libvirt 选择默认型号
+		model := ""
+		if config.Architecture == "x86_64" {
+			model = "tpm-crb"
+		}
+		devices.TPM = &DomainTPM{
+			Model: model,
+			Backend: &DomainTPMBackend{
+				Type:    "emulator",
+				Version: "2.0",
+			},
+		}
+	}
+
+	if opts.SoundModel != "" {
+		devices.Sounds = append(devices.Sounds, DomainSound{
+			Model: opts.SoundModel,
+		})
+	}
+
+	if opts.WatchdogModel != "" {
+		action := opts.WatchdogAction
+		if action == "" {
+			action = "reset"
+		}
+		devices.Watchdogs = append(devices.Watchdogs, DomainWatchdog{
+			Model:  opts.WatchdogModel,
+			Action: action,
+		})
+	}
+
+	if !opts.DisableRNG {
+		devices.RNG = &DomainRNG{
+			Model: "virtio",
+			Backend: &DomainRNGBackend{
+				Model: "random",
+				Value: "/dev/urandom",
+			},
+		}
+	}
+}
+
+// buildSerial 构建串口及其 console 配置
+// 串口输出始终写入日志文件：file 类型直接写入，pty/tcp 类型通过 <log> 由 virtlogd 记录
+func buildSerial(config *CreateVMConfig) (DomainSerial, DomainConsole) {
+	serial := DomainSerial{
+		Type: config.SerialType,
+		Target: DomainSerialTarget{
+			Type: "isa-serial",
+			Port: 0,
+			Model: DomainSerialTargetModel{
+				Name: "isa-serial",
+			},
+		},
+	}
+	console := DomainConsole{
+		Type: config.SerialType,
+		Target: DomainConsoleTarget{
+			Type: "serial",
+			Port: 0,
+		},
+	}
+
+	switch config.SerialType {
+	case "file":
+		serial.Source = &DomainCharSource{
+			Path:   config.SerialLogPath,
+			Append: "on",
+		}
+		console.Source = DomainConsoleSource{
+			Path: config.SerialLogPath,
+		}
+	case "tcp":
+		service := strconv.Itoa(config.SerialTCPPort)
+		serial.Source = &DomainCharSource{
+			Mode:    "bind",
+			Host:    config.SerialTCPHost,
+			Service: service,
+		}
+		serial.Protocol = &DomainCharProtocol{Type: "telnet"}
+		serial.Log = &DomainCharLog{
+			File:   config.SerialLogPath,
+			Append: "on",
+		}
+		console.Source = DomainConsoleSource{
+			Mode:    "bind",
+			Host:    config.SerialTCPHost,
+			Service: service,
+		}
+	default:
+		serial.Log = &DomainCharLog{
+			File:   config.SerialLogPath,
+			Append: "on",
+		}
 	}
 
-	// 修改目录所有权
-	return os.Chown(dirPath, uid, gid)
+	return serial, console
 }
 
 // buildDisks 构建磁盘配置
 func (c *Client) buildDisks(config *CreateVMConfig) []DomainDisk {
+	// 系统盘设备名随总线变化（vda、sda、nvme0n1），总线已在 validateVMConfig 中校验
+	systemDevice, err := firstDiskDevice(config.DiskBus)
+	if err != nil {
+		systemDevice = "vda"
+	}
+
 	disks := []DomainDisk{
 		{
 			Type:   "file",
 			Device: "disk",
-			Driver: DomainDiskDriver{
-				Name: "qemu",
-				Type: "qcow2",
-			},
+			Driver: diskDriver("qcow2", config.DiskDriver),
 			Source: DomainDiskSource{
 				File: config.DiskPath,
 			},
 			Target: DomainDiskTarget{
-				Dev: "vda",
+				Dev: systemDevice,
 				Bus: config.DiskBus,
 			},
 		},
@@ -1026,9 +1673,6 @@ func (c *Client) buildDisks(config *CreateVMConfig) []DomainDisk {
 				Bus: "ide",
 			},
 		})
-
-		// 如果有 ISO，从 CDROM 启动
-		// 注意：这需要在 OS.Boot 中设置，但我们已经在 buildDomainXML 中设置了
 	}
 
 	// 如果有 cloud-init ISO，添加 CDROM 设备
@@ -1082,6 +1726,159 @@ func (c *Client) CheckGuestAgentAvailable(domain libvirt.Domain) (bool, error) {
 	return true, nil
 }
 
+// FreezeDomainFS 通过 guest-agent 冻结文件系统（guest-fsfreeze-freeze），mountpoints 为空时冻结全部
+// 返回冻结的文件系统数量；冻结期间 guest 内的写操作会阻塞，调用方必须保证随后解冻
+func (c *Client) FreezeDomainFS(domainName string, mountpoints []string) (int, error) {
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return 0, fmt.Errorf("lookup domain: %w", err)
+	}
+
+	count, err := c.conn.DomainFsfreeze(domain, mountpoints, 0)
+	if err != nil {
+		return 0, fmt.Errorf("freeze filesystems: %w", err)
+	}
+	return int(count), nil
+}
+
+// ThawDomainFS 通过 guest-agent 解冻文件系统（guest-fsfreeze-thaw），mountpoints 为空时解冻全部
+// 返回解冻的文件系统数量，未冻结时返回 0
+func (c *Client) ThawDomainFS(domainName string, mountpoints []string) (int, error) {
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return 0, fmt.Errorf("lookup domain: %w", err)
+	}
+
+	count, err := c.conn.DomainFsthaw(domain, mountpoints, 0)
+	if err != nil {
+		return 0, fmt.Errorf("thaw filesystems: %w", err)
+	}
+	return int(count), nil
+}
+
+// GuestExecResult guest 内命令的执行结果
+type GuestExecResult struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+// guestExecPollInterval 轮询 guest-exec-status 的间隔
+const guestExecPollInterval = 500 * time.Millisecond
+
+// GuestExec 通过 guest-agent 在 guest 内执行命令（guest-exec）并等待退出，返回退出码与输出
+// 超过 timeout 仍未退出时返回错误，guest 内的进程不会被终止
+func (c *Client) GuestExec(domainName, path string, args []string, timeout time.Duration) (*GuestExecResult, error) {
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return nil, fmt.Errorf("lookup domain: %w", err)
+	}
+
+	var started struct {
+		PID int `json:"pid"`
+	}
+	if err := c.guestAgentCall(domain, "guest-exec", map[string]any{
+		"path":           path,
+		"arg":            args,
+		"capture-output": true,
+	}, &started); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		var status struct {
+			Exited   bool   `json:"exited"`
+			ExitCode int    `json:"exitcode"`
+			OutData  string `json:"out-data"`
+			ErrData  string `json:"err-data"`
+		}
+		if err := c.guestAgentCall(domain, "guest-exec-status", map[string]any{"pid": started.PID}, &status); err != nil {
+			return nil, err
+		}
+		if status.Exited {
+			stdout, _ := base64.StdEncoding.DecodeString(status.OutData)
+			stderr, _ := base64.StdEncoding.DecodeString(status.ErrData)
+			return &GuestExecResult{
+				ExitCode: status.ExitCode,
+				Stdout:   string(stdout),
+				Stderr:   string(stderr),
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("guest command %s (pid %d) did not exit within %s", path, started.PID, timeout)
+		}
+		time.Sleep(guestExecPollInterval)
+	}
+}
+
+// GuestOSInfo guest-get-osinfo 返回的 guest 操作系统信息，guest-agent 无法识别的字段为空
+type GuestOSInfo struct {
+	ID            string `json:"id"`             // 发行版 ID，如 ubuntu、centos，Windows 为 mswindows
+	Name          string `json:"name"`           // 操作系统名称
+	PrettyName    string `json:"pretty-name"`    // 完整名称，如 Ubuntu 22.04.4 LTS
+	Version       string `json:"version"`        // 版本描述
+	VersionID     string `json:"version-id"`     // 版本号，如 22.04
+	KernelRelease string `json:"kernel-release"` // 内核版本（Windows 为 build 号）
+	Machine       string `json:"machine"`        // guest 内核报告的架构，如 x86_64
+}
+
+// GetGuestOSInfo 通过 guest-agent 获取 guest 操作系统信息（guest-get-osinfo），需要 qemu-guest-agent 2.10 以上
+func (c *Client) GetGuestOSInfo(domainName string) (*GuestOSInfo, error) {
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return nil, fmt.Errorf("lookup domain: %w", err)
+	}
+
+	info := &GuestOSInfo{}
+	if err := c.guestAgentCall(domain, "guest-get-osinfo", map[string]any{}, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// guestAgentCall 执行一条 guest-agent 命令并把 return 字段解析到 result
+func (c *Client) guestAgentCall(domain libvirt.Domain, command string, arguments map[string]any, result any) error {
+	data, err := json.Marshal(map[string]any{
+		"execute":   command,
+		"arguments": arguments,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", command, err)
+	}
+	output, err := c.conn.QEMUDomainAgentCommand(domain, string(data), int32(libvirt.DomainAgentResponseTimeoutDefault), 0)
+	if err != nil {
+		return fmt.Errorf("%s: %w", command, err)
+	}
+	if len(output) == 0 {
+		return fmt.Errorf("%s: empty response", command)
+	}
+	response := struct {
+		Return json.RawMessage `json:"return"`
+	}{}
+	if err := json.Unmarshal([]byte(output[0]), &response); err != nil {
+		return fmt.Errorf("unmarshal %s response: %w", command, err)
+	}
+	if err := json.Unmarshal(response.Return, result); err != nil {
+		return fmt.Errorf("unmarshal %s result: %w", command, err)
+	}
+	return nil
+}
+
+// QEMUMonitorCommand 通过 libvirt 向运行中 domain 的 QEMU monitor 发送一条 QMP 命令（virDomainQemuMonitorCommand），返回原始 JSON 响应
+// 修改状态的命令会使 libvirt 把 domain 标记为 tainted，调用方应通过 pkg/qmp 限制为只读命令
+func (c *Client) QEMUMonitorCommand(domainName, command string) (string, error) {
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return "", fmt.Errorf("lookup domain: %w", err)
+	}
+	output, err := c.conn.QEMUDomainMonitorCommand(domain, command, 0)
+	if err != nil {
+		return "", fmt.Errorf("qemu monitor command: %w", err)
+	}
+	return output, nil
+}
+
 // DomainSnapshotXML 快照 XML 结构
 type DomainSnapshotXML struct {
 	XMLName      xml.Name                 `xml:"domainsnapshot"`
@@ -1400,14 +2197,20 @@ func (c *Client) getNetworkInfo(network libvirt.Network) (*NetworkInfo, error) {
 	netmask := ""
 	dhcpStart := ""
 	dhcpEnd := ""
-	if netXML.IP != nil {
-		ipAddress = netXML.IP.Address
-		netmask = netXML.IP.Netmask
-		if netXML.IP.DHCP != nil && len(netXML.IP.DHCP.Range) > 0 {
-			dhcpStart = netXML.IP.DHCP.Range[0].Start
-			dhcpEnd = netXML.IP.DHCP.Range[0].End
+	if ip := netXML.IPv4(); ip != nil {
+		ipAddress = ip.Address
+		netmask = ip.Netmask
+		if ip.DHCP != nil && len(ip.DHCP.Range) > 0 {
+			dhcpStart = ip.DHCP.Range[0].Start
+			dhcpEnd = ip.DHCP.Range[0].End
 		}
 	}
+	ipv6Address := ""
+	ipv6Prefix := 0
+	if ip := netXML.IPv6(); ip != nil {
+		ipv6Address = ip.Address
+		ipv6Prefix = ip.Prefix
+	}
 
 	// 格式化 UUID
 	uuidStr := fmt.Sprintf("%x", network.UUID)
@@ -1428,6 +2231,9 @@ func (c *Client) getNetworkInfo(network libvirt.Network) (*NetworkInfo, error) {
 		Netmask:    netmask,
 		DHCPStart:  dhcpStart,
 		DHCPEnd:    dhcpEnd,
+
+		IPv6Address: ipv6Address,
+		IPv6Prefix:  ipv6Prefix,
 	}, nil
 }
 
@@ -1453,15 +2259,25 @@ func (c *Client) CreateNetwork(config NetworkConfig) (*NetworkInfo, error) {
 
 	// 设置 IP 配置
 	if config.IPAddress != "" {
-		netXML.IP = &NetworkIP{
+		ip := NetworkIP{
 			Address: config.IPAddress,
 			Netmask: config.Netmask,
 		}
 		if config.DHCPStart != "" && config.DHCPEnd != "" {
-			netXML.IP.DHCP = &NetworkDHCP{
+			ip.DHCP = &NetworkDHCP{
 				Range: []NetworkDHCPRange{{Start: config.DHCPStart, End: config.DHCPEnd}},
 			}
 		}
+		netXML.IPs = append(netXML.IPs, ip)
+	}
+
+	// IPv6 不配置 DHCPv6 地址池，由 dnsmasq 发送 RA，guest 通过 SLAAC 获取地址
+	if config.IPv6Address != "" {
+		prefix := config.IPv6Prefix
+		if prefix == 0 {
+			prefix = 64
+		}
+		netXML.IPs = append(netXML.IPs, NetworkIP{Family: "ipv6", Address: config.IPv6Address, Prefix: prefix})
 	}
 
 	// 序列化为 XML
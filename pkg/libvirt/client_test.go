@@ -0,0 +1,43 @@
+package libvirt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateQEMUArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		safeOK   bool // 不设置 unsafe 时是否通过
+		unsafeOK bool // 设置 unsafe 后是否通过
+	}{
+		{name: "allowlisted device", args: []string{"-device", "virtio-balloon-pci,id=balloon1"}, safeOK: true, unsafeOK: true},
+		{name: "smbios fields", args: []string{"-smbios", "type=1,serial=abc"}, safeOK: true, unsafeOK: true},
+		{name: "fw_cfg string", args: []string{"-fw_cfg", "name=opt/jvp,string=hello"}, safeOK: true, unsafeOK: true},
+		{name: "outside allowlist", args: []string{"-no-hpet"}, unsafeOK: true},
+		{name: "set rewrites existing options", args: []string{"-set", "drive.drive0.file=/etc/shadow"}, unsafeOK: true},
+		{name: "fw_cfg file", args: []string{"-fw_cfg", "name=opt/jvp,file=/etc/shadow"}, unsafeOK: true},
+		{name: "acpitable file", args: []string{"-acpitable", "file=/root/table.aml"}, unsafeOK: true},
+		{name: "smbios file", args: []string{"-smbios", "file=/root/smbios.bin"}, unsafeOK: true},
+		{name: "inline option value", args: []string{"--fw_cfg=name=opt/jvp,file=/etc/shadow"}, unsafeOK: true},
+		{name: "device path", args: []string{"-device", "virtserialport,path=/tmp/sock"}, unsafeOK: true},
+		{name: "vfio passthrough", args: []string{"-device", "vfio-pci,host=0000:01:00.0"}, unsafeOK: true},
+		{name: "usb passthrough", args: []string{"-device", "usb-host,hostbus=1,hostaddr=2"}, unsafeOK: true},
+		{name: "driver property passthrough", args: []string{"-device", "driver=vfio-pci,host=0000:01:00.0"}, unsafeOK: true},
+		{name: "blocklisted", args: []string{"-monitor", "stdio"}},
+		{name: "empty argument", args: []string{"-device", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateQEMUArgs(tt.args, false)
+			assert.Equal(t, tt.safeOK, err == nil, "unsafe=false: %v", err)
+			warnings, err := ValidateQEMUArgs(tt.args, true)
+			assert.Equal(t, tt.unsafeOK, err == nil, "unsafe=true: %v", err)
+			if err == nil && !tt.safeOK {
+				assert.Greater(t, len(warnings), 1)
+			}
+		})
+	}
+}
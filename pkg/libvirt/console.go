@@ -1,17 +1,33 @@
 package libvirt
 
 import (
+	"bytes"
 	"encoding/xml"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
 
 	"github.com/digitalocean/go-libvirt"
 )
 
 // ConsoleInfo 控制台连接信息
 type ConsoleInfo struct {
-	VNCSocket    string `json:"vnc_socket"`    // VNC Unix Socket 路径
-	SerialDevice string `json:"serial_device"` // Serial PTY 设备路径
-	Type         string `json:"type"`          // 控制台类型: vnc, serial
+	VNCSocket     string `json:"vnc_socket"`      // VNC Unix Socket 路径
+	SPICEListen   string `json:"spice_listen"`    // SPICE 监听地址
+	SPICEPort     int    `json:"spice_port"`      // SPICE 端口（运行中由 libvirt 分配）
+	SPICETLSPort  int    `json:"spice_tls_port"`  // SPICE TLS 端口（宿主机开启 spice_tls 时分配）
+	SerialDevice  string `json:"serial_device"`   // Serial PTY 设备路径
+	SerialLogPath string `json:"serial_log_path"` // Serial 输出日志文件路径
+	Type          string `json:"type"`            // 控制台类型: vnc, serial
+}
+
+// ConsoleOutput 串口输出日志
+type ConsoleOutput struct {
+	LogPath   string `json:"log_path"`  // 日志文件路径
+	Output    string `json:"output"`    // 日志内容
+	Truncated bool   `json:"truncated"` // 是否只返回了日志末尾部分
 }
 
 // GetDomainConsoleInfo 获取 Domain 的控制台连接信息
@@ -31,11 +47,24 @@ func (c *Client) GetDomainConsoleInfo(domain libvirt.Domain) (*ConsoleInfo, erro
 	info := &ConsoleInfo{}
 
 	// 获取 VNC Socket 路径
-	if domainDef.Devices.Graphics.Type == "vnc" && domainDef.Devices.Graphics.Socket != "" {
-		info.VNCSocket = domainDef.Devices.Graphics.Socket
+	if socket := vncSocketPath(&domainDef); socket != "" {
+		info.VNCSocket = socket
 		info.Type = "vnc"
 	}
 
+	// 获取 SPICE 端口：autoport 分配的端口只在运行时 XML 中
+	if spice := spiceGraphics(&domainDef); spice != nil {
+		// 未运行时 autoport 的端口为 -1
+		info.SPICEPort = max(spice.Port, 0)
+		info.SPICETLSPort = max(spice.TLSPort, 0)
+		if spice.Listen != nil {
+			info.SPICEListen = spice.Listen.Address
+		}
+	}
+
+	// 获取 Serial 输出日志路径
+	info.SerialLogPath = serialLogPath(&domainDef.Devices.Serial)
+
 	// 获取 Serial Console PTY 设备路径
 	// Serial Console 的 PTY 路径在运行时由 libvirt 分配,需要从运行时 XML 中获取
 	if domainDef.Devices.Console.Type == "pty" {
@@ -54,3 +83,89 @@ func (c *Client) GetDomainConsoleInfo(domain libvirt.Domain) (*ConsoleInfo, erro
 
 	return info, nil
 }
+
+// serialLogPath 返回串口输出写入的日志文件路径，未配置时返回空字符串
+func serialLogPath(serial *DomainSerial) string {
+	if serial.Log != nil && serial.Log.File != "" {
+		return serial.Log.File
+	}
+	if serial.Type == "file" && serial.Source != nil {
+		return serial.Source.Path
+	}
+	return ""
+}
+
+// GetDomainConsoleOutput 读取 Domain 串口日志文件内容（本地或远程节点）
+// maxBytes > 0 时只返回日志末尾 maxBytes 字节
+func (c *Client) GetDomainConsoleOutput(domain libvirt.Domain, maxBytes int64) (*ConsoleOutput, error) {
+	xmlData, err := c.conn.DomainGetXMLDesc(domain, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain XML: %w", err)
+	}
+
+	var domainDef DomainXML
+	if err := xml.Unmarshal([]byte(xmlData), &domainDef); err != nil {
+		return nil, fmt.Errorf("failed to parse domain XML: %w", err)
+	}
+
+	logPath := serialLogPath(&domainDef.Devices.Serial)
+	if logPath == "" {
+		return nil, fmt.Errorf("serial log is not configured for domain %s", domain.Name)
+	}
+
+	output := &ConsoleOutput{LogPath: logPath}
+
+	if c.IsRemoteConnection() {
+		sshTarget, err := c.GetSSHTarget()
+		if err != nil {
+			return nil, err
+		}
+		command := fmt.Sprintf("cat '%s'", logPath)
+		if maxBytes > 0 {
+			command = fmt.Sprintf("stat -c %%s '%s' && tail -c %d '%s'", logPath, maxBytes, logPath)
+		}
+		cmd := exec.Command("ssh", "-o", "StrictHostKeyChecking=no", "-o", "BatchMode=yes", sshTarget, command)
+		data, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("read remote serial log: %w", err)
+		}
+		if maxBytes > 0 {
+			// 第一行为文件大小，其余为日志末尾内容
+			if idx := bytes.IndexByte(data, '\n'); idx >= 0 {
+				size, err := strconv.ParseInt(string(data[:idx]), 10, 64)
+				if err == nil {
+					output.Truncated = size > maxBytes
+				}
+				data = data[idx+1:]
+			}
+		}
+		output.Output = string(data)
+		return output, nil
+	}
+
+	f, err := os.Open(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("open serial log: %w", err)
+	}
+	defer f.Close()
+
+	if maxBytes > 0 {
+		stat, err := f.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("stat serial log: %w", err)
+		}
+		if stat.Size() > maxBytes {
+			if _, err := f.Seek(-maxBytes, io.SeekEnd); err != nil {
+				return nil, fmt.Errorf("seek serial log: %w", err)
+			}
+			output.Truncated = true
+		}
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("read serial log: %w", err)
+	}
+	output.Output = string(data)
+	return output, nil
+}
@@ -0,0 +1,174 @@
+package libvirt
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/digitalocean/go-libvirt"
+)
+
+// 支持通过 UpdateDomainDevice 修改的设备类型
+const (
+	DeviceTypeCDROM     = "cdrom"     // 更换或弹出光驱介质
+	DeviceTypeDisk      = "disk"      // 修改磁盘 driver 参数（cache、IO 模式、discard）
+	DeviceTypeInterface = "interface" // 修改网卡限速
+)
+
+// DeviceUpdate 设备更新参数，按 Type 读取对应字段
+type DeviceUpdate struct {
+	Type      string              // 设备类型：cdrom / disk / interface
+	Target    string              // cdrom 与 disk 为设备名（如 sda、vdb），interface 为 MAC 地址
+	Media     string              // cdrom：新介质文件路径，为空表示弹出
+	Driver    DiskDriverOptions   // disk：新的 driver 参数，为空的字段保持原值
+	Bandwidth *InterfaceBandwidth // interface：新的限速，为 nil 的方向保持原值，average 为 0 表示取消该方向限速
+}
+
+// Validate 校验设备更新参数
+func (u *DeviceUpdate) Validate() error {
+	switch u.Type {
+	case DeviceTypeCDROM:
+		return nil
+	case DeviceTypeDisk:
+		if u.Driver == (DiskDriverOptions{}) {
+			return fmt.Errorf("at least one of cache, io or discard is required for disk update")
+		}
+		return u.Driver.Validate()
+	case DeviceTypeInterface:
+		if u.Bandwidth == nil {
+			return fmt.Errorf("bandwidth is required for interface update")
+		}
+		return u.Bandwidth.Validate()
+	default:
+		return fmt.Errorf("unsupported device type: %s (must be cdrom, disk or interface)", u.Type)
+	}
+}
+
+// UpdateDomainDevice 通过 DomainUpdateDeviceFlags 修改已有设备的配置，返回修改是否已在运行态生效
+// domain 运行时 live+config 双写，关机时只修改持久化配置；
+// QEMU 不支持运行中修改磁盘 driver 参数，disk 更新只写入持久化配置，重启后生效
+func (c *Client) UpdateDomainDevice(domainName string, update DeviceUpdate) (bool, error) {
+	if err := update.Validate(); err != nil {
+		return false, err
+	}
+
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return false, fmt.Errorf("lookup domain: %w", err)
+	}
+
+	// 定位设备与更新之间不能有其他修改改变设备列表
+	unlock := c.lockDomain(domain)
+	defer unlock()
+
+	live := update.Type != DeviceTypeDisk && c.isDomainRunning(domain)
+
+	// 双写时以运行态为准定位设备，只改持久化配置时读取持久化 XML
+	var xmlFlags libvirt.DomainXMLFlags
+	if !live {
+		xmlFlags = libvirt.DomainXMLInactive
+	}
+	xmlDesc, err := c.conn.DomainGetXMLDesc(domain, xmlFlags)
+	if err != nil {
+		return false, fmt.Errorf("get domain XML: %w", err)
+	}
+	var domainXML DomainXML
+	if err := xml.Unmarshal([]byte(xmlDesc), &domainXML); err != nil {
+		return false, fmt.Errorf("unmarshal domain XML: %w", err)
+	}
+
+	var deviceXML string
+	switch update.Type {
+	case DeviceTypeCDROM:
+		disk, err := findDomainDisk(&domainXML, update.Target)
+		if err != nil {
+			return false, err
+		}
+		if disk.Device != "cdrom" {
+			return false, fmt.Errorf("device %s is not a cdrom", update.Target)
+		}
+		if disk.Source.File == update.Media {
+			// 介质未变化（包括已经是空光驱时再次弹出）
+			return live, nil
+		}
+		deviceXML = cdromDeviceXML(disk, update.Media)
+	case DeviceTypeDisk:
+		disk, err := findDomainDisk(&domainXML, update.Target)
+		if err != nil {
+			return false, err
+		}
+		if disk.Device != "disk" {
+			return false, fmt.Errorf("device %s is not a disk", update.Target)
+		}
+		update.Driver.apply(&disk.Driver)
+		if disk.Driver.IO == "native" && !directCacheMode(disk.Driver.Cache) {
+			return false, fmt.Errorf("disk io mode native requires cache mode none or directsync, got %q", disk.Driver.Cache)
+		}
+		if deviceXML, err = marshalDeviceXML("disk", disk); err != nil {
+			return false, fmt.Errorf("marshal disk XML: %w", err)
+		}
+	case DeviceTypeInterface:
+		var iface *DomainInterface
+		for i := range domainXML.Devices.Interfaces {
+			if strings.EqualFold(domainXML.Devices.Interfaces[i].MAC.Address, update.Target) {
+				iface = &domainXML.Devices.Interfaces[i]
+				break
+			}
+		}
+		if iface == nil {
+			return false, fmt.Errorf("interface %s not found in domain", update.Target)
+		}
+		merged := InterfaceBandwidth{}
+		if current := bandwidthFromXML(iface.Bandwidth); current != nil {
+			merged = *current
+		}
+		if update.Bandwidth.Inbound != nil {
+			merged.Inbound = update.Bandwidth.Inbound
+		}
+		if update.Bandwidth.Outbound != nil {
+			merged.Outbound = update.Bandwidth.Outbound
+		}
+		iface.Bandwidth = merged.toXML()
+		if deviceXML, err = marshalDeviceXML("interface", iface); err != nil {
+			return false, fmt.Errorf("marshal interface XML: %w", err)
+		}
+	}
+
+	flags := libvirt.DomainDeviceModifyConfig
+	if live {
+		flags |= libvirt.DomainDeviceModifyLive
+	}
+	if err := c.conn.DomainUpdateDeviceFlags(domain, deviceXML, flags); err != nil {
+		return false, fmt.Errorf("update %s %s: %w", update.Type, update.Target, err)
+	}
+	return live, nil
+}
+
+// findDomainDisk 按设备名查找磁盘
+func findDomainDisk(domainXML *DomainXML, device string) (*DomainDisk, error) {
+	for i := range domainXML.Devices.Disks {
+		if domainXML.Devices.Disks[i].Target.Dev == device {
+			return &domainXML.Devices.Disks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("device %s not found in domain", device)
+}
+
+// cdromDeviceXML 构造光驱设备 XML，media 为空时不带 source 即为空光驱
+// 保留 boot order 以免丢失启动配置
+func cdromDeviceXML(cdrom *DomainDisk, media string) string {
+	sourceXML := ""
+	if media != "" {
+		sourceXML = fmt.Sprintf("\n  <source file=\"%s\"/>", html.EscapeString(media))
+	}
+	bootXML := ""
+	if cdrom.Boot != nil {
+		bootXML = fmt.Sprintf("\n  <boot order=\"%d\"/>", cdrom.Boot.Order)
+	}
+	return fmt.Sprintf(`<disk type="file" device="cdrom">
+  <driver name="qemu" type="raw"/>%s
+  <target dev="%s" bus="%s"/>
+  <readonly/>%s
+</disk>`, sourceXML, cdrom.Target.Dev, cdrom.Target.Bus, bootXML)
+}
@@ -0,0 +1,29 @@
+package libvirt
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DirectModes network_type=direct（macvtap）的工作模式
+// vepa: 流量全部经外部交换机转发（交换机需支持 hairpin）；bridge: 同一物理口上的实例之间直接互通；
+// private: 同一物理口上的实例互相隔离；passthrough: 物理口（或 SR-IOV VF）独占给一个实例
+var DirectModes = []string{"vepa", "bridge", "private", "passthrough"}
+
+// DefaultDirectMode 未指定时的 macvtap 模式
+const DefaultDirectMode = "bridge"
+
+// ValidateDirectMode 校验 macvtap 模式，networkType 不是 direct 时不能指定模式
+func ValidateDirectMode(networkType, mode string) error {
+	if mode == "" {
+		return nil
+	}
+	if networkType != "direct" {
+		return fmt.Errorf("direct mode %q requires network type direct", mode)
+	}
+	if !slices.Contains(DirectModes, mode) {
+		return fmt.Errorf("unsupported direct mode: %q (must be one of %s)", mode, strings.Join(DirectModes, ", "))
+	}
+	return nil
+}
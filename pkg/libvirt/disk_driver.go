@@ -0,0 +1,104 @@
+package libvirt
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DiskCacheModes 磁盘 cache 模式（disk driver cache 属性），default 表示不设置、使用 QEMU 默认值
+var DiskCacheModes = []string{"default", "none", "writethrough", "writeback", "directsync", "unsafe"}
+
+// DiskIOModes 磁盘异步 IO 模式（disk driver io 属性）
+var DiskIOModes = []string{"native", "threads", "io_uring"}
+
+// DiskDiscardModes guest discard/trim 请求的处理方式（disk driver discard 属性）
+var DiskDiscardModes = []string{"unmap", "ignore"}
+
+// DiskDriverOptions 磁盘 driver 的 cache、IO 模式与 discard 参数，空字段使用卷格式对应的默认值
+type DiskDriverOptions struct {
+	Cache   string `json:"cache,omitempty"`   // cache 模式：default, none, writethrough, writeback, directsync, unsafe
+	IO      string `json:"io,omitempty"`      // IO 模式：native, threads, io_uring
+	Discard string `json:"discard,omitempty"` // discard 处理：unmap（回收空间）, ignore
+}
+
+// Validate 校验 driver 参数取值
+func (o *DiskDriverOptions) Validate() error {
+	if o.Cache != "" && !slices.Contains(DiskCacheModes, o.Cache) {
+		return fmt.Errorf("unsupported disk cache mode: %q (must be one of %s)", o.Cache, strings.Join(DiskCacheModes, ", "))
+	}
+	if o.IO != "" && !slices.Contains(DiskIOModes, o.IO) {
+		return fmt.Errorf("unsupported disk io mode: %q (must be one of %s)", o.IO, strings.Join(DiskIOModes, ", "))
+	}
+	if o.Discard != "" && !slices.Contains(DiskDiscardModes, o.Discard) {
+		return fmt.Errorf("unsupported disk discard mode: %q (must be one of %s)", o.Discard, strings.Join(DiskDiscardModes, ", "))
+	}
+	if o.IO == "native" && o.Cache != "" && !directCacheMode(o.Cache) {
+		return fmt.Errorf("disk io mode native requires cache mode none or directsync, got %q", o.Cache)
+	}
+	return nil
+}
+
+// directCacheMode 判断 cache 模式是否绕过宿主机页缓存（O_DIRECT），io=native 只能与这类模式搭配
+func directCacheMode(cache string) bool {
+	return cache == "none" || cache == "directsync"
+}
+
+// DefaultDiskDriverOptions 按卷格式返回默认 driver 参数
+// 两种格式都绕过宿主机页缓存（cache=none）并把 guest 的 trim 下发到卷上回收空间；
+// raw 卷空间已分配，使用 io=native；qcow2 按需分配，io=native 在分配元数据时会阻塞 vCPU，使用 io=threads
+func DefaultDiskDriverOptions(format string) DiskDriverOptions {
+	if format == "raw" {
+		return DiskDriverOptions{Cache: "none", IO: "native", Discard: "unmap"}
+	}
+	return DiskDriverOptions{Cache: "none", IO: "threads", Discard: "unmap"}
+}
+
+// resolveDiskDriverOptions 用卷格式的默认值补全未设置的字段
+// 只指定了经过页缓存的 cache 模式时，默认的 io=native 回退为 io=threads
+func resolveDiskDriverOptions(format string, opts *DiskDriverOptions) (DiskDriverOptions, error) {
+	resolved := DefaultDiskDriverOptions(format)
+	if opts == nil {
+		return resolved, nil
+	}
+	if err := opts.Validate(); err != nil {
+		return resolved, err
+	}
+	if opts.Cache != "" {
+		resolved.Cache = opts.Cache
+		if opts.IO == "" && resolved.IO == "native" && !directCacheMode(opts.Cache) {
+			resolved.IO = "threads"
+		}
+	}
+	if opts.IO != "" {
+		resolved.IO = opts.IO
+	}
+	if opts.Discard != "" {
+		resolved.Discard = opts.Discard
+	}
+	return resolved, nil
+}
+
+// apply 把 driver 参数写入 disk driver 元素，cache 为 default 时不设置该属性
+func (o DiskDriverOptions) apply(driver *DomainDiskDriver) {
+	if o.Cache != "" {
+		driver.Cache = o.Cache
+		if o.Cache == "default" {
+			driver.Cache = ""
+		}
+	}
+	if o.IO != "" {
+		driver.IO = o.IO
+	}
+	if o.Discard != "" {
+		driver.Discard = o.Discard
+	}
+}
+
+// diskDriver 构造 disk driver 元素，调用方需已通过 resolveDiskDriverOptions 校验
+func diskDriver(format string, opts *DiskDriverOptions) DomainDiskDriver {
+	driver := DomainDiskDriver{Name: "qemu", Type: format}
+	resolved, _ := resolveDiskDriverOptions(format, opts)
+	resolved.apply(&driver)
+	return driver
+}
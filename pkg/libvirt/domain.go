@@ -1,94 +1,310 @@
 package libvirt
 
 import (
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/digitalocean/go-libvirt"
 )
 
+const (
+	// detachDeviceTimeout 每轮等待 guest 释放设备的时间
+	detachDeviceTimeout = 10 * time.Second
+	// detachDeviceAttempts 热拔请求最多发送的次数
+	detachDeviceAttempts = 3
+	// detachDevicePollInterval 轮询 live XML 的间隔
+	detachDevicePollInterval = time.Second
+)
+
+// ErrDeviceBusy guest 未在超时时间内释放设备（如磁盘仍被挂载使用）
+var ErrDeviceBusy = errors.New("device busy")
+
+// AttachDiskConfig 附加磁盘配置参数
+type AttachDiskConfig struct {
+	VolumePath string               // 卷路径（必填），Block 为 true 时为宿主机块设备路径
+	Block      bool                 // 是否以 disk type='block' 直通宿主机块设备（/dev/sdX、LVM LV、zvol），格式固定为 raw
+	Device     string               // 目标设备名（可选，如 vdb、sdb、nvme0n2；为空时按总线自动分配）
+	Bus        string               // 磁盘总线类型：virtio, scsi, nvme（默认：virtio）
+	Format     string               // 磁盘格式：qcow2, raw（默认：qcow2）
+	Serial     string               // 磁盘序列号（可选，guest 内可通过 /dev/disk/by-id 稳定定位）
+	Controller DiskControllerConfig // 控制器参数（可选，仅在该总线尚无控制器、需要新建时生效）
+	Driver     *DiskDriverOptions   // driver 参数（可选，未设置的字段使用该格式的默认值）
+	Shareable  bool                 // 是否以共享盘挂载（<shareable/>），允许多个 domain 同时挂载同一个卷
+}
+
+// DiskControllerConfig virtio-scsi 控制器参数
+type DiskControllerConfig struct {
+	Queues   int  // 多队列数量（可选，通常设为 vCPU 数）
+	IOThread bool // 是否为控制器分配独立的 iothread
+}
+
+// nvmeDevicePrefix NVMe 盘设备名前缀，按 namespace 编号命名（nvme0n1、nvme0n2...）
+const nvmeDevicePrefix = "nvme0n"
+
+// maxNVMeNamespaces 单个 NVMe 控制器可分配的 namespace 数量
+const maxNVMeNamespaces = 256
+
+// diskDevicePrefix 返回总线对应的设备名前缀
+func diskDevicePrefix(bus string) (string, error) {
+	switch bus {
+	case "virtio":
+		return "vd", nil
+	case "scsi", "sata", "usb":
+		return "sd", nil
+	case "ide":
+		return "hd", nil
+	case "nvme":
+		return nvmeDevicePrefix, nil
+	default:
+		return "", fmt.Errorf("unsupported disk bus: %s", bus)
+	}
+}
+
+// firstDiskDevice 返回总线上第一块盘（系统盘）的设备名
+func firstDiskDevice(bus string) (string, error) {
+	prefix, err := diskDevicePrefix(bus)
+	if err != nil {
+		return "", err
+	}
+	if prefix == nvmeDevicePrefix {
+		return prefix + "1", nil
+	}
+	return prefix + "a", nil
+}
+
+// nextDiskDevice 按前缀分配下一个未被占用的设备名
+// 字母序设备名从 b 开始（vda/sda 通常为系统盘），z 用完后继续分配 aa..zz；NVMe 按 namespace 从 1 开始编号
+func nextDiskDevice(prefix string, used map[string]bool) (string, error) {
+	if prefix == nvmeDevicePrefix {
+		for n := 1; n <= maxNVMeNamespaces; n++ {
+			dev := prefix + strconv.Itoa(n)
+			if !used[dev] {
+				return dev, nil
+			}
+		}
+		return "", fmt.Errorf("no free device name with prefix %s", prefix)
+	}
+
+	for c := 'b'; c <= 'z'; c++ {
+		dev := prefix + string(c)
+		if !used[dev] {
+			return dev, nil
+		}
+	}
+	for c1 := 'a'; c1 <= 'z'; c1++ {
+		for c2 := 'a'; c2 <= 'z'; c2++ {
+			dev := prefix + string(c1) + string(c2)
+			if !used[dev] {
+				return dev, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no free device name with prefix %s", prefix)
+}
+
+// newDiskController 返回总线所需的磁盘控制器，virtio-blk、sata 等无需额外控制器时返回 nil
+// iothreadID 大于 0 时将 virtio-scsi 控制器绑定到该 iothread
+func newDiskController(bus string, config DiskControllerConfig, iothreadID int) *DomainController {
+	switch bus {
+	case "scsi":
+		ctrl := &DomainController{
+			Type:  "scsi",
+			Index: 0,
+			Model: "virtio-scsi",
+		}
+		if config.Queues > 0 || iothreadID > 0 {
+			ctrl.Driver = &DomainControllerDriver{
+				Queues:   config.Queues,
+				IOThread: iothreadID,
+			}
+		}
+		return ctrl
+	case "nvme":
+		return &DomainController{
+			Type:   "nvme",
+			Index:  0,
+			Serial: "jvp-nvme0",
+		}
+	default:
+		return nil
+	}
+}
+
+// marshalDeviceXML 将设备结构序列化为指定元素名的 XML（用于热插拔）
+func marshalDeviceXML(name string, device any) (string, error) {
+	var buf strings.Builder
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.EncodeElement(device, xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+		return "", err
+	}
+	if err := enc.Flush(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
 // AttachDiskToDomain 附加磁盘到 domain
-func (c *Client) AttachDiskToDomain(domainName, volumePath, device string) error {
+// domain 运行时同时热插拔并写入持久化配置，否则仅修改持久化配置
+// 返回实际使用的目标设备名
+func (c *Client) AttachDiskToDomain(domainName string, config *AttachDiskConfig) (string, error) {
+	if config == nil || config.VolumePath == "" {
+		return "", fmt.Errorf("volume path is required")
+	}
+
+	bus := config.Bus
+	if bus == "" {
+		bus = "virtio"
+	}
+	prefix, err := diskDevicePrefix(bus)
+	if err != nil {
+		return "", err
+	}
+	format := config.Format
+	if format == "" {
+		format = "qcow2"
+	}
+	if config.Block {
+		format = "raw"
+	}
+	if _, err := resolveDiskDriverOptions(format, config.Driver); err != nil {
+		return "", err
+	}
+
 	// 查找 domain
 	domain, err := c.conn.DomainLookupByName(domainName)
 	if err != nil {
-		return fmt.Errorf("lookup domain: %w", err)
+		return "", fmt.Errorf("lookup domain: %w", err)
 	}
 
+	// 串行化同一 domain 的设备修改，避免并发附加选中相同的设备名
+	unlock := c.lockDomain(domain)
+	defer unlock()
+
 	// 获取当前 domain XML
 	xmlDesc, err := c.conn.DomainGetXMLDesc(domain, 0)
 	if err != nil {
-		return fmt.Errorf("get domain XML: %w", err)
+		return "", fmt.Errorf("get domain XML: %w", err)
 	}
 
 	// 解析 XML
 	var domainXML DomainXML
 	if err := xml.Unmarshal([]byte(xmlDesc), &domainXML); err != nil {
-		return fmt.Errorf("unmarshal domain XML: %w", err)
+		return "", fmt.Errorf("unmarshal domain XML: %w", err)
 	}
 
-	// 检查设备是否已存在
+	// 检查设备名与卷是否已被使用
+	used := make(map[string]bool, len(domainXML.Devices.Disks))
 	for _, disk := range domainXML.Devices.Disks {
-		if disk.Target.Dev == device {
-			return fmt.Errorf("device %s already exists in domain", device)
+		used[disk.Target.Dev] = true
+		if disk.SourcePath() == config.VolumePath {
+			return "", fmt.Errorf("volume %s already attached as %s", config.VolumePath, disk.Target.Dev)
+		}
+	}
+
+	device := config.Device
+	if device == "" {
+		device, err = nextDiskDevice(prefix, used)
+		if err != nil {
+			return "", err
 		}
+	} else {
+		if used[device] {
+			return "", fmt.Errorf("device %s already exists in domain", device)
+		}
+		if !strings.HasPrefix(device, prefix) {
+			return "", fmt.Errorf("device %s does not match bus %s (expect prefix %s)", device, bus, prefix)
+		}
+	}
+
+	state, _, err := c.conn.DomainGetState(domain, 0)
+	if err != nil {
+		return "", fmt.Errorf("get domain state: %w", err)
+	}
+	flags := libvirt.DomainDeviceModifyConfig
+	if libvirt.DomainState(state) == libvirt.DomainRunning {
+		flags |= libvirt.DomainDeviceModifyLive
+	}
+
+	// scsi、nvme 总线需要先有对应控制器
+	if ctrl := newDiskController(bus, config.Controller, 0); ctrl != nil && !hasController(domainXML.Devices.Controllers, ctrl.Type) {
+		if bus == "scsi" && config.Controller.IOThread {
+			// 新增 iothread 并绑定到控制器，ID 顺延现有 iothread 数量
+			iothreadID := domainXML.IOThreads + 1
+			impact := libvirt.DomainAffectConfig
+			if flags&libvirt.DomainDeviceModifyLive != 0 {
+				impact |= libvirt.DomainAffectLive
+			}
+			if err := c.conn.DomainAddIothread(domain, uint32(iothreadID), impact); err != nil {
+				return "", fmt.Errorf("add iothread: %w", err)
+			}
+			ctrl = newDiskController(bus, config.Controller, iothreadID)
+		}
+		controllerXML, err := marshalDeviceXML("controller", ctrl)
+		if err != nil {
+			return "", fmt.Errorf("marshal %s controller XML: %w", ctrl.Type, err)
+		}
+		if err := c.conn.DomainAttachDeviceFlags(domain, controllerXML, uint32(flags)); err != nil {
+			return "", fmt.Errorf("attach %s controller: %w", ctrl.Type, err)
+		}
+	}
+
+	// 构建磁盘设备
+	diskXML, err := marshalDeviceXML("disk", newAttachedDisk(config, format, device, bus))
+	if err != nil {
+		return "", fmt.Errorf("marshal disk XML: %w", err)
 	}
 
-	// 添加新磁盘
-	newDisk := DomainDisk{
+	if err := c.conn.DomainAttachDeviceFlags(domain, diskXML, uint32(flags)); err != nil {
+		return "", fmt.Errorf("attach disk to domain: %w", err)
+	}
+
+	return device, nil
+}
+
+// newAttachedDisk 构建附加的磁盘设备，块设备使用 type='block' 与 source dev
+func newAttachedDisk(config *AttachDiskConfig, format, device, bus string) DomainDisk {
+	disk := DomainDisk{
 		Type:   "file",
 		Device: "disk",
-		Driver: DomainDiskDriver{
-			Name: "qemu",
-			Type: "qcow2",
-		},
+		Driver: diskDriver(format, config.Driver),
 		Source: DomainDiskSource{
-			File: volumePath,
+			File: config.VolumePath,
 		},
 		Target: DomainDiskTarget{
 			Dev: device,
-			Bus: "virtio",
+			Bus: bus,
 		},
+		Serial: config.Serial,
 	}
-
-	domainXML.Devices.Disks = append(domainXML.Devices.Disks, newDisk)
-
-	// 重新序列化 XML
-	xmlBytes, err := xml.MarshalIndent(&domainXML, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshal domain XML: %w", err)
+	if config.Shareable {
+		disk.Shareable = &struct{}{}
 	}
-
-	// 更新 domain 定义
-	_, err = c.conn.DomainDefineXML(string(xmlBytes))
-	if err != nil {
-		return fmt.Errorf("define domain with new disk: %w", err)
-	}
-
-	// 如果 domain 正在运行，需要热插拔磁盘
-	state, _, err := c.conn.DomainGetState(domain, 0)
-	if err != nil {
-		return fmt.Errorf("get domain state: %w", err)
+	if config.Block {
+		disk.Type = "block"
+		disk.Source = DomainDiskSource{Dev: config.VolumePath}
 	}
+	return disk
+}
 
-	if libvirt.DomainState(state) == libvirt.DomainRunning {
-		// 使用 AttachDeviceFlags 进行热插拔
-		diskXML := fmt.Sprintf(`<disk type="file" device="disk">
-  <driver name="qemu" type="qcow2"/>
-  <source file="%s"/>
-  <target dev="%s" bus="virtio"/>
-</disk>`, volumePath, device)
-
-		err = c.conn.DomainAttachDeviceFlags(domain, diskXML, uint32(libvirt.DomainDeviceModifyLive|libvirt.DomainDeviceModifyConfig))
-		if err != nil {
-			return fmt.Errorf("attach device to running domain: %w", err)
+// hasController 检查是否存在指定类型的控制器
+func hasController(controllers []DomainController, controllerType string) bool {
+	for _, ctrl := range controllers {
+		if ctrl.Type == controllerType {
+			return true
 		}
 	}
-
-	return nil
+	return false
 }
 
 // DetachDiskFromDomain 从 domain 分离磁盘
+// domain 运行时先热拔并等待 guest 确认（DEVICE_DELETED 事件），确认后才修改持久化配置；
+// guest 始终未释放设备时返回 ErrDeviceBusy，持久化配置保持不变
 func (c *Client) DetachDiskFromDomain(domainName, device string) error {
 	// 查找 domain
 	domain, err := c.conn.DomainLookupByName(domainName)
@@ -96,6 +312,9 @@ func (c *Client) DetachDiskFromDomain(domainName, device string) error {
 		return fmt.Errorf("lookup domain: %w", err)
 	}
 
+	unlock := c.lockDomain(domain)
+	defer unlock()
+
 	// 获取当前 domain XML
 	xmlDesc, err := c.conn.DomainGetXMLDesc(domain, 0)
 	if err != nil {
@@ -108,24 +327,21 @@ func (c *Client) DetachDiskFromDomain(domainName, device string) error {
 		return fmt.Errorf("unmarshal domain XML: %w", err)
 	}
 
-	// 查找并删除磁盘
+	// 查找磁盘
 	found := false
-	newDisks := make([]DomainDisk, 0, len(domainXML.Devices.Disks))
+	bus := ""
 	for _, disk := range domainXML.Devices.Disks {
 		if disk.Target.Dev == device {
 			found = true
-			continue
+			bus = disk.Target.Bus
+			break
 		}
-		newDisks = append(newDisks, disk)
 	}
-
 	if !found {
 		return fmt.Errorf("device %s not found in domain", device)
 	}
 
-	domainXML.Devices.Disks = newDisks
-
-	// 如果 domain 正在运行，需要热拔磁盘
+	// 如果 domain 正在运行，需要热拔磁盘并等待 guest 确认
 	state, _, err := c.conn.DomainGetState(domain, 0)
 	if err != nil {
 		return fmt.Errorf("get domain state: %w", err)
@@ -134,27 +350,153 @@ func (c *Client) DetachDiskFromDomain(domainName, device string) error {
 	if libvirt.DomainState(state) == libvirt.DomainRunning {
 		// 构建要分离的磁盘 XML（只需要关键字段）
 		diskXML := fmt.Sprintf(`<disk>
-  <target dev="%s" bus="virtio"/>
-</disk>`, device)
+  <target dev="%s" bus="%s"/>
+</disk>`, device, bus)
 
-		err = c.conn.DomainDetachDeviceFlags(domain, diskXML, uint32(libvirt.DomainDeviceModifyLive|libvirt.DomainDeviceModifyConfig))
-		if err != nil {
-			return fmt.Errorf("detach device from running domain: %w", err)
+		if err := c.detachLiveDevice(domain, diskXML, func() (bool, error) {
+			return c.liveDiskRemoved(domain, device)
+		}); err != nil {
+			return fmt.Errorf("detach %s from running domain: %w", device, err)
 		}
 	}
 
-	// 重新序列化 XML
-	xmlBytes, err := xml.MarshalIndent(&domainXML, "", "  ")
+	// 从持久化配置中移除磁盘
+	err = c.editDomainConfig(domain, func(inactiveDomain *DomainXML) error {
+		newDisks := make([]DomainDisk, 0, len(inactiveDomain.Devices.Disks))
+		for _, disk := range inactiveDomain.Devices.Disks {
+			if disk.Target.Dev == device {
+				continue
+			}
+			newDisks = append(newDisks, disk)
+		}
+		if len(newDisks) == len(inactiveDomain.Devices.Disks) {
+			// 仅热插拔、未写入持久化配置的磁盘，无需再更新定义
+			return errDomainConfigUnchanged
+		}
+		inactiveDomain.Devices.Disks = newDisks
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("marshal domain XML: %w", err)
+		return fmt.Errorf("remove disk from domain config: %w", err)
 	}
 
-	// 更新 domain 定义
-	_, err = c.conn.DomainDefineXML(string(xmlBytes))
+	return nil
+}
+
+// liveDiskRemoved 检查运行中 domain 的 live XML 是否已不包含指定磁盘
+func (c *Client) liveDiskRemoved(domain libvirt.Domain, device string) (bool, error) {
+	xmlDesc, err := c.conn.DomainGetXMLDesc(domain, 0)
 	if err != nil {
-		return fmt.Errorf("define domain without disk: %w", err)
+		return false, fmt.Errorf("get domain XML: %w", err)
 	}
 
+	var domainXML DomainXML
+	if err := xml.Unmarshal([]byte(xmlDesc), &domainXML); err != nil {
+		return false, fmt.Errorf("unmarshal domain XML: %w", err)
+	}
+
+	for _, disk := range domainXML.Devices.Disks {
+		if disk.Target.Dev == device {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// detachLiveDevice 热拔设备并等待 guest 释放
+// 热拔请求是异步的：libvirt 返回只代表请求已发给 guest，需要等到 DEVICE_DELETED
+// （libvirt 的 device-removed 事件）或 live XML 中设备消失才算完成。
+// 每轮等待 detachDeviceTimeout，超时后重发请求，共 detachDeviceAttempts 轮
+func (c *Client) detachLiveDevice(domain libvirt.Domain, deviceXML string, removed func() (bool, error)) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 订阅 device-removed 事件；订阅失败时退化为轮询 live XML
+	events, err := c.conn.SubscribeEvents(ctx, libvirt.DomainEventIDDeviceRemoved, libvirt.OptDomain{domain})
+	if err != nil {
+		events = nil
+	}
+
+	ticker := time.NewTicker(detachDevicePollInterval)
+	defer ticker.Stop()
+
+	for attempt := 1; attempt <= detachDeviceAttempts; attempt++ {
+		err := c.conn.DomainDetachDeviceFlags(domain, deviceXML, uint32(libvirt.DomainDeviceModifyLive))
+		if err != nil {
+			// 重发时设备可能恰好已被移除
+			if ok, checkErr := removed(); checkErr == nil && ok {
+				return nil
+			}
+			if attempt == 1 {
+				return err
+			}
+		}
+
+		timeout := time.NewTimer(detachDeviceTimeout)
+	wait:
+		for {
+			select {
+			case _, ok := <-events:
+				if !ok {
+					events = nil
+					continue
+				}
+				if done, checkErr := removed(); checkErr == nil && done {
+					timeout.Stop()
+					return nil
+				}
+			case <-ticker.C:
+				if done, checkErr := removed(); checkErr == nil && done {
+					timeout.Stop()
+					return nil
+				}
+			case <-timeout.C:
+				break wait
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: guest did not release device after %d attempts", ErrDeviceBusy, detachDeviceAttempts)
+}
+
+// EjectDomainCDROM 弹出 domain 光驱中的介质，光驱设备本身保留
+// domain 运行时同时修改运行态与持久化配置
+func (c *Client) EjectDomainCDROM(domainName, device string) error {
+	if _, err := c.UpdateDomainDevice(domainName, DeviceUpdate{Type: DeviceTypeCDROM, Target: device}); err != nil {
+		return fmt.Errorf("eject cdrom %s: %w", device, err)
+	}
+	return nil
+}
+
+// SetDomainDiskSource 修改持久化配置中磁盘的源文件与格式
+// 用于 blockcopy pivot 之后同步持久化配置（transient job 只切换运行时配置）
+func (c *Client) SetDomainDiskSource(domainName, device, sourcePath, format string) error {
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return fmt.Errorf("lookup domain: %w", err)
+	}
+
+	unlock := c.lockDomain(domain)
+	defer unlock()
+
+	err = c.editDomainConfig(domain, func(domainXML *DomainXML) error {
+		for i := range domainXML.Devices.Disks {
+			disk := &domainXML.Devices.Disks[i]
+			if disk.Target.Dev != device {
+				continue
+			}
+			disk.Type = "file"
+			disk.Source = DomainDiskSource{File: sourcePath}
+			if format != "" {
+				disk.Driver.Type = format
+			}
+			return nil
+		}
+		return fmt.Errorf("device %s not found in domain", device)
+	})
+	if err != nil {
+		return fmt.Errorf("set disk source: %w", err)
+	}
 	return nil
 }
 
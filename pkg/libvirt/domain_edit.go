@@ -0,0 +1,103 @@
+package libvirt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/digitalocean/go-libvirt"
+)
+
+// maxDomainEditAttempts 修改持久化配置时检测到并发修改后的最大尝试次数
+const maxDomainEditAttempts = 5
+
+// ErrDomainConfigConflict 持久化配置在多次重试中始终被其他修改覆盖（如节点上同时执行 virsh edit）
+var ErrDomainConfigConflict = errors.New("domain config was modified concurrently")
+
+// errDomainConfigUnchanged edit 返回该错误表示无需修改，editDomainConfig 不写入配置并返回 nil
+var errDomainConfigUnchanged = errors.New("domain config unchanged")
+
+// domainLocks 按 连接 URI/域 UUID 串行化同一个域的设备与配置修改
+// 同一节点可能存在多个 Client（缓存连接与临时连接），因此放在包级别共享
+var domainLocks sync.Map
+
+// lockDomain 获取域的修改锁，返回 unlock；锁不可重入，持有锁的方法之间不能互相调用
+func (c *Client) lockDomain(domain libvirt.Domain) func() {
+	key := c.uri + "/" + hex.EncodeToString(domain.UUID[:])
+	value, _ := domainLocks.LoadOrStore(key, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// editDomainConfig 以读-改-写方式修改域的持久化配置，调用方需持有 lockDomain
+// 写入前重新读取持久化配置并与修改所基于的版本比较（XML 哈希），不一致说明期间有 JVP 之外的修改，
+// 此时基于最新配置重新执行 edit；edit 可能被调用多次，不能有副作用
+// 该检测只是尽力而为：libvirt 没有条件写入，最后一次读取与 DomainDefineXML 之间的外部修改（如 virsh edit）仍可能被覆盖；
+// 定义失败且配置已被外部修改时同样按冲突重试，重试耗尽后返回 ErrDomainConfigConflict
+func (c *Client) editDomainConfig(domain libvirt.Domain, edit func(*DomainXML) error) error {
+	var lastErr error
+	for range maxDomainEditAttempts {
+		xmlDesc, err := c.conn.DomainGetXMLDesc(domain, libvirt.DomainXMLInactive)
+		if err != nil {
+			return fmt.Errorf("get domain XML: %w", err)
+		}
+
+		var domainXML DomainXML
+		if err := xml.Unmarshal([]byte(xmlDesc), &domainXML); err != nil {
+			return fmt.Errorf("unmarshal domain XML: %w", err)
+		}
+		if err := edit(&domainXML); err != nil {
+			if errors.Is(err, errDomainConfigUnchanged) {
+				return nil
+			}
+			return err
+		}
+
+		xmlBytes, err := xml.MarshalIndent(&domainXML, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal domain XML: %w", err)
+		}
+
+		changed, err := c.domainConfigChanged(domain, xmlDesc)
+		if err != nil {
+			return err
+		}
+		if changed {
+			lastErr = nil
+			continue
+		}
+
+		if _, err := c.conn.DomainDefineXML(string(xmlBytes)); err != nil {
+			// 定义失败可能是外部修改抢先写入导致（如同名设备已被删除），配置已变化时重试
+			if changed, getErr := c.domainConfigChanged(domain, xmlDesc); getErr == nil && changed {
+				lastErr = err
+				continue
+			}
+			return fmt.Errorf("define domain: %w", err)
+		}
+		return nil
+	}
+	if lastErr != nil {
+		return fmt.Errorf("domain %s: %w after %d attempts (last define error: %v)", domain.Name, ErrDomainConfigConflict, maxDomainEditAttempts, lastErr)
+	}
+	return fmt.Errorf("domain %s: %w after %d attempts", domain.Name, ErrDomainConfigConflict, maxDomainEditAttempts)
+}
+
+// domainConfigChanged 持久化配置是否已不同于 xmlDesc
+func (c *Client) domainConfigChanged(domain libvirt.Domain, xmlDesc string) (bool, error) {
+	current, err := c.conn.DomainGetXMLDesc(domain, libvirt.DomainXMLInactive)
+	if err != nil {
+		return false, fmt.Errorf("get domain XML: %w", err)
+	}
+	return domainConfigHash(current) != domainConfigHash(xmlDesc), nil
+}
+
+// domainConfigHash 持久化配置 XML 的哈希，用于检测读取与写入之间的并发修改
+func domainConfigHash(xmlDesc string) string {
+	sum := sha256.Sum256([]byte(xmlDesc))
+	return hex.EncodeToString(sum[:])
+}
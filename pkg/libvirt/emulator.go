@@ -0,0 +1,47 @@
+package libvirt
+
+// defaultEmulatorPath capabilities 中找不到该架构时使用的 emulator 路径
+func defaultEmulatorPath(arch string) string {
+	return "/usr/bin/qemu-system-" + arch
+}
+
+// Emulator 返回运行指定架构与虚拟化类型 hvm guest 的 emulator 路径，不支持时返回空字符串
+// 虚拟化类型下单独声明的 emulator（如 RHEL 系 kvm 使用的 /usr/libexec/qemu-kvm）优先于架构默认值
+func (c *CapabilitiesXML) Emulator(arch, domainType string) string {
+	for _, guest := range c.Guests {
+		if guest.OSType != "hvm" || guest.Arch.Name != arch {
+			continue
+		}
+		for _, domain := range guest.Arch.Domains {
+			if domain.Type != domainType {
+				continue
+			}
+			if domain.Emulator != "" {
+				return domain.Emulator
+			}
+			return guest.Arch.Emulator
+		}
+	}
+	return ""
+}
+
+// emulatorPath 获取 domain 使用的 emulator 路径
+// 优先使用缓存，其次从节点 capabilities 探测，探测失败时回退到 /usr/bin/qemu-system-<arch>；
+// 只缓存探测成功的结果，节点更换 QEMU 安装位置后重新连接即可刷新
+func (c *Client) emulatorPath(arch, domainType string) string {
+	key := arch + "/" + domainType
+	if path, ok := c.emulators.Load(key); ok {
+		return path.(string)
+	}
+
+	caps, err := c.GetCapabilities()
+	if err != nil {
+		return defaultEmulatorPath(arch)
+	}
+	path := caps.Emulator(arch, domainType)
+	if path == "" {
+		return defaultEmulatorPath(arch)
+	}
+	c.emulators.Store(key, path)
+	return path
+}
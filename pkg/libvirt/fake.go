@@ -0,0 +1,2076 @@
+package libvirt
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+)
+
+// FakeLibvirt 是 LibvirtClient 的内存实现
+// 维护 domain、storage pool、volume、snapshot 与 network 的状态机，
+// 用于 service 层集成测试验证完整流程（如 RunInstance → StopInstances → TerminateInstances），
+// 而不需要像 MockClient 那样为每个调用设置期望
+type FakeLibvirt struct {
+	mu sync.Mutex
+
+	hostname string
+	uri      string
+	remote   bool
+	nextID   int32
+
+	domains  map[string]*fakeDomain
+	pools    map[string]*fakePool
+	networks map[string]*NetworkInfo
+	filters  map[string]NWFilterXML
+	files    map[string][]byte // 节点上的文件（cloud-init user-data、远程文件等），按路径索引
+
+	agentResponses map[string]string // guest agent 命令 → 响应
+	commands       []string          // ExecuteRemoteCommand 执行过的命令
+}
+
+type fakeDomain struct {
+	domain        libvirt.Domain
+	state         libvirt.DomainState
+	memoryKB      uint64
+	maxMemoryKB   uint64
+	vcpus         uint16
+	maxVCPUs      uint16
+	autostart     bool
+	cpuTune       *CPUTune
+	blkioTune     *BlkioTune
+	numaTune      *NUMATune
+	disks         []DomainDisk
+	interfaces    []NetworkInterface
+	addressFamily string
+	macSpoofCheck bool
+	createdAt     time.Time
+	loginUser     string
+	labels        map[string]string
+	snapshots     []DomainSnapshotXML
+	startTime     *time.Time
+	consoleOutput string
+	agentReady    bool
+	frozenFS      int                      // 已冻结的文件系统数量
+	blockJobs     map[string]*fakeBlockJob // 按磁盘设备名索引
+}
+
+// fakeBlockJob 内存中的 block job，copy 与 active-commit 创建后即处于 ready 状态
+type fakeBlockJob struct {
+	info      BlockJobInfo
+	pivotPath string // pivot 后磁盘切换到的文件
+}
+
+type fakePool struct {
+	info     StoragePoolInfo
+	poolType string
+	volumes  map[string]*VolumeInfo
+}
+
+// fakePoolCapacity 内存存储池的默认容量（1 TiB）
+const fakePoolCapacity = 1 << 40
+
+// FakeScheme fake node 的 URI scheme（如 fake:///e2e），由 Connect 识别
+const FakeScheme = "fake"
+
+var (
+	fakeNodes   = make(map[string]*FakeLibvirt)
+	fakeNodesMu sync.Mutex
+)
+
+// FakeNode 返回 URI 对应的进程内共享 FakeLibvirt，首次访问时创建
+// 同一 URI 的多次连接看到同一份状态，与连接真实 libvirtd 的语义一致
+func FakeNode(uri string) *FakeLibvirt {
+	fakeNodesMu.Lock()
+	defer fakeNodesMu.Unlock()
+
+	if f, ok := fakeNodes[uri]; ok {
+		return f
+	}
+	f := NewFakeLibvirt()
+	f.uri = uri
+	fakeNodes[uri] = f
+	return f
+}
+
+// NewFakeLibvirt 创建空的内存 libvirt 客户端
+func NewFakeLibvirt() *FakeLibvirt {
+	return &FakeLibvirt{
+		hostname:       "fake-node",
+		uri:            "test:///default",
+		nextID:         1,
+		domains:        make(map[string]*fakeDomain),
+		pools:          make(map[string]*fakePool),
+		networks:       make(map[string]*NetworkInfo),
+		filters:        make(map[string]NWFilterXML),
+		files:          make(map[string][]byte),
+		agentResponses: make(map[string]string),
+	}
+}
+
+// ==================== 测试辅助方法 ====================
+
+// SetRemote 设置是否模拟远程节点连接
+func (f *FakeLibvirt) SetRemote(remote bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.remote = remote
+}
+
+// SetAgentResponse 设置 guest agent 命令的响应（按命令子串匹配），未设置的命令返回空结果
+func (f *FakeLibvirt) SetAgentResponse(command, response string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.agentResponses[command] = response
+}
+
+// SetGuestAgentReady 设置 domain 的 guest agent 是否可用
+func (f *FakeLibvirt) SetGuestAgentReady(domainName string, ready bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return err
+	}
+	d.agentReady = ready
+	return nil
+}
+
+// SetConsoleOutput 设置 domain 的串口输出内容
+func (f *FakeLibvirt) SetConsoleOutput(domainName, output string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return err
+	}
+	d.consoleOutput = output
+	return nil
+}
+
+// WriteFile 在节点上写入文件，供 ReadRemoteFile / ListRemoteFiles 读取
+func (f *FakeLibvirt) WriteFile(path string, content []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[path] = append([]byte(nil), content...)
+}
+
+// ExecutedCommands 返回 ExecuteRemoteCommand 执行过的命令
+func (f *FakeLibvirt) ExecutedCommands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.commands...)
+}
+
+// ==================== 内部查找 ====================
+
+func (f *FakeLibvirt) lookupDomain(name string) (*fakeDomain, error) {
+	d, ok := f.domains[name]
+	if !ok {
+		return nil, fmt.Errorf("domain %s not found", name)
+	}
+	return d, nil
+}
+
+func (f *FakeLibvirt) lookupPool(name string) (*fakePool, error) {
+	p, ok := f.pools[name]
+	if !ok {
+		return nil, fmt.Errorf("storage pool %s not found", name)
+	}
+	return p, nil
+}
+
+// findVolumeByPath 按路径查找卷，返回所属存储池与卷名
+func (f *FakeLibvirt) findVolumeByPath(path string) (*fakePool, string, bool) {
+	for _, p := range f.pools {
+		for name, v := range p.volumes {
+			if v.Path == path {
+				return p, name, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// addVolume 在存储池中登记卷，同名卷已存在时返回错误
+func (f *FakeLibvirt) addVolume(poolName, volumeName string, capacityB uint64, format string) (*VolumeInfo, error) {
+	p, err := f.lookupPool(poolName)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := p.volumes[volumeName]; exists {
+		return nil, fmt.Errorf("volume %s already exists in pool %s", volumeName, poolName)
+	}
+	vol := &VolumeInfo{
+		Name:      volumeName,
+		Path:      filepath.Join(p.info.Path, volumeName),
+		CapacityB: capacityB,
+		Format:    format,
+	}
+	p.volumes[volumeName] = vol
+	f.recalcPool(p)
+	return copyVolume(vol), nil
+}
+
+func (f *FakeLibvirt) recalcPool(p *fakePool) {
+	var allocation uint64
+	for _, v := range p.volumes {
+		allocation += v.AllocationB
+	}
+	p.info.AllocationB = allocation
+	p.info.AvailableB = p.info.CapacityB - allocation
+}
+
+func copyVolume(v *VolumeInfo) *VolumeInfo {
+	c := *v
+	return &c
+}
+
+// ==================== 连接信息 ====================
+
+func (f *FakeLibvirt) GetHostname() (string, error) {
+	return f.hostname, nil
+}
+
+// Close fake 节点按 URI 共享状态，关闭连接不清理数据
+func (f *FakeLibvirt) Close() error {
+	return nil
+}
+
+func (f *FakeLibvirt) GetLibvirtVersion() (string, error) {
+	return "10.0.0", nil
+}
+
+func (f *FakeLibvirt) GetNodeInfo() (*NodeInfo, error) {
+	return &NodeInfo{
+		Model:   "x86_64",
+		Memory:  16 * 1024 * 1024,
+		CPUs:    8,
+		MHz:     2400,
+		Nodes:   1,
+		Sockets: 1,
+		Cores:   4,
+		Threads: 2,
+	}, nil
+}
+
+// GetNUMACells 内存节点只有一个 cell，空闲内存为总内存减去运行中 domain 的内存
+func (f *FakeLibvirt) GetNUMACells() ([]NUMACell, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, _ := f.GetNodeInfo()
+	cell := NUMACell{ID: 0, MemoryKB: info.Memory, FreeMemoryKB: info.Memory}
+	for cpu := uint32(0); cpu < info.CPUs; cpu++ {
+		cell.CPUs = append(cell.CPUs, cpu)
+	}
+	for _, d := range f.domains {
+		if d.state == libvirt.DomainRunning {
+			cell.FreeMemoryKB -= min(d.memoryKB, cell.FreeMemoryKB)
+		}
+	}
+	return []NUMACell{cell}, nil
+}
+
+func (f *FakeLibvirt) GetCapabilities() (*CapabilitiesXML, error) {
+	return &CapabilitiesXML{
+		Host: CapabilitiesHost{
+			CPU: CapabilitiesCPU{Arch: "x86_64", Vendor: "Intel", Features: []CapabilitiesFeature{{Name: "vmx"}}},
+		},
+		Guests: []CapabilitiesGuest{{
+			OSType: "hvm",
+			Arch: CapabilitiesGuestArch{
+				Name:     "x86_64",
+				WordSize: "64",
+				Emulator: "/usr/bin/qemu-system-x86_64",
+				Machines: []CapabilitiesMachine{{Name: "pc-q35-8.2"}, {Name: "q35", Canonical: "pc-q35-8.2"}},
+				Domains:  []CapabilitiesGuestDomain{{Type: "qemu"}, {Type: "kvm"}},
+			},
+		}},
+	}, nil
+}
+
+func (f *FakeLibvirt) GetSysinfo() (*SysinfoXML, error) {
+	return &SysinfoXML{Type: "smbios"}, nil
+}
+
+// ==================== Domain 操作 ====================
+
+func (f *FakeLibvirt) GetVMSummaries() ([]libvirt.Domain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names := make([]string, 0, len(f.domains))
+	for name := range f.domains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]libvirt.Domain, 0, len(names))
+	for _, name := range names {
+		result = append(result, f.domains[name].domain)
+	}
+	return result, nil
+}
+
+func (f *FakeLibvirt) GetDomainInfo(domainUUID libvirt.UUID) (*DomainInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, d := range f.domains {
+		if d.domain.UUID != domainUUID {
+			continue
+		}
+		return &DomainInfo{
+			Name:        d.domain.Name,
+			UUID:        fmt.Sprintf("%x", d.domain.UUID),
+			State:       formatDomainState(uint8(d.state)),
+			MaxMemory:   d.maxMemoryKB,
+			Memory:      d.memoryKB,
+			VCPUs:       d.vcpus,
+			MaxVCPUs:    d.maxVCPUs,
+			OSType:      "hvm",
+			Autostart:   d.autostart,
+			Persistent:  true,
+			NetworkInfo: append([]NetworkInterface(nil), d.interfaces...),
+			CPUTune:     cpuTuneFromXML(d.cpuTune.toXML()),
+			BlkioTune:   blkioTuneFromXML(d.blkioTune.toXML()),
+			NUMATune:    numaTuneFromXML(d.numaTune.toXML()),
+			StartTime:   d.startTime,
+
+			AddressFamily: d.addressFamily,
+			MACSpoofCheck: d.macSpoofCheck,
+			CreatedAt:     &d.createdAt,
+			LoginUser:     d.loginUser,
+			Labels:        maps.Clone(d.labels),
+		}, nil
+	}
+	return nil, fmt.Errorf("domain %x not found", domainUUID)
+}
+
+func (f *FakeLibvirt) GetDomainByName(name string) (libvirt.Domain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(name)
+	if err != nil {
+		return libvirt.Domain{}, err
+	}
+	return d.domain, nil
+}
+
+func (f *FakeLibvirt) GetDomainState(domain libvirt.Domain) (uint8, uint32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint8(d.state), 0, nil
+}
+
+func (f *FakeLibvirt) CreateDomain(config *CreateVMConfig, autoStart bool) (libvirt.Domain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if config.Name == "" {
+		return libvirt.Domain{}, fmt.Errorf("invalid config: name is required")
+	}
+	if err := validateArchitecture(config.Architecture); err != nil {
+		return libvirt.Domain{}, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := ValidateDirectKernelBoot(config.Kernel, config.Initrd, config.KernelCmdline); err != nil {
+		return libvirt.Domain{}, fmt.Errorf("invalid config: %w", err)
+	}
+	if _, exists := f.domains[config.Name]; exists {
+		return libvirt.Domain{}, fmt.Errorf("domain %s already exists", config.Name)
+	}
+
+	var uuid libvirt.UUID
+	copy(uuid[:], fmt.Sprintf("%016d", f.nextID))
+	f.nextID++
+
+	bus := config.DiskBus
+	if bus == "" {
+		bus = "virtio"
+	}
+	systemDevice, err := firstDiskDevice(bus)
+	if err != nil {
+		return libvirt.Domain{}, err
+	}
+	disks := []DomainDisk{{
+		Type:   "file",
+		Device: "disk",
+		Driver: diskDriver("qcow2", config.DiskDriver),
+		Source: DomainDiskSource{File: config.DiskPath},
+		Target: DomainDiskTarget{Dev: systemDevice, Bus: bus},
+	}}
+	if config.ISOPath != "" {
+		disks = append(disks, DomainDisk{
+			Type:   "file",
+			Device: "cdrom",
+			Driver: DomainDiskDriver{Name: "qemu", Type: "raw"},
+			Source: DomainDiskSource{File: config.ISOPath},
+			Target: DomainDiskTarget{Dev: "hda", Bus: "ide"},
+		})
+	}
+
+	networkType := config.NetworkType
+	if networkType == "" {
+		networkType = "bridge"
+	}
+	networkSource := config.NetworkSource
+	if networkSource == "" {
+		networkSource = "br0"
+	}
+	interfaces := []NetworkInterface{{
+		Name:      fmt.Sprintf("vnet%d", len(f.domains)),
+		Type:      networkType,
+		Source:    networkSource,
+		MAC:       fmt.Sprintf("52:54:00:%02x:%02x:%02x", uuid[13], uuid[14], uuid[15]),
+		Model:     "virtio",
+		Bandwidth: bandwidthFromXML(config.NetworkBandwidth.toXML()),
+	}}
+	if config.NetworkFilter != "" {
+		if _, ok := f.filters[config.NetworkFilter]; !ok {
+			return libvirt.Domain{}, fmt.Errorf("nwfilter %s not found", config.NetworkFilter)
+		}
+		interfaces[0].Filter = config.NetworkFilter
+	}
+	if networkType == NetworkTypeOVS {
+		iface := DomainInterface{}
+		interfaceID := config.OVSInterfaceID
+		if interfaceID == "" {
+			interfaceID = fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+		}
+		iface.connectOVS(networkSource, config.NetworkVLAN, interfaceID)
+		interfaces[0].Type = iface.Type
+		interfaces[0].applyVirtualPort(&iface)
+	}
+
+	d := &fakeDomain{
+		domain:      libvirt.Domain{Name: config.Name, UUID: uuid, ID: -1},
+		interfaces:  interfaces,
+		state:       libvirt.DomainShutoff,
+		memoryKB:    config.Memory,
+		maxMemoryKB: max(config.MaxMemory, config.Memory),
+		vcpus:       config.VCPUs,
+		maxVCPUs:    max(config.MaxVCPUs, config.VCPUs),
+		autostart:   config.Autostart,
+		cpuTune:     cpuTuneFromXML(config.CPUTune.toXML()),
+		blkioTune:   blkioTuneFromXML(config.BlkioTune.toXML()),
+		numaTune:    numaTuneFromXML(config.NUMATune.toXML()),
+		disks:       disks,
+	}
+	if config.AddressFamily != AddressFamilyIPv4 {
+		d.addressFamily = config.AddressFamily
+	}
+	d.macSpoofCheck = config.MACSpoofCheck
+	d.loginUser = config.LoginUser
+	d.createdAt = config.CreatedAt.UTC().Truncate(time.Second)
+	if config.CreatedAt.IsZero() {
+		d.createdAt = time.Now().UTC().Truncate(time.Second)
+	}
+	if len(config.Labels) > 0 {
+		d.labels = maps.Clone(config.Labels)
+	}
+	f.domains[config.Name] = d
+
+	if autoStart {
+		f.startDomain(d)
+	}
+	return d.domain, nil
+}
+
+// startDomain 将 domain 切换到运行状态（调用方需持有锁）
+func (f *FakeLibvirt) startDomain(d *fakeDomain) {
+	now := time.Now()
+	d.state = libvirt.DomainRunning
+	d.domain.ID = f.nextID
+	d.startTime = &now
+	f.nextID++
+}
+
+// stopDomain 将 domain 切换到关机状态（调用方需持有锁）
+func (f *FakeLibvirt) stopDomain(d *fakeDomain) {
+	d.state = libvirt.DomainShutoff
+	d.domain.ID = -1
+	d.startTime = nil
+}
+
+func (f *FakeLibvirt) StartDomain(domain libvirt.Domain) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return err
+	}
+	if d.state == libvirt.DomainRunning {
+		return fmt.Errorf("domain %s is already running", domain.Name)
+	}
+	f.startDomain(d)
+	return nil
+}
+
+func (f *FakeLibvirt) StopDomain(domain libvirt.Domain) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return err
+	}
+	if d.state != libvirt.DomainRunning {
+		return fmt.Errorf("domain %s is not running", domain.Name)
+	}
+	f.stopDomain(d)
+	return nil
+}
+
+func (f *FakeLibvirt) RebootDomain(domain libvirt.Domain) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return err
+	}
+	if d.state != libvirt.DomainRunning {
+		return fmt.Errorf("domain %s is not running", domain.Name)
+	}
+	now := time.Now()
+	d.startTime = &now
+	return nil
+}
+
+func (f *FakeLibvirt) DestroyDomain(domain libvirt.Domain) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return err
+	}
+	if d.state != libvirt.DomainRunning && d.state != libvirt.DomainPaused {
+		return fmt.Errorf("domain %s is not running", domain.Name)
+	}
+	f.stopDomain(d)
+	return nil
+}
+
+func (f *FakeLibvirt) DeleteDomain(domain libvirt.Domain, flags libvirt.DomainUndefineFlagsValues) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return err
+	}
+	if len(d.snapshots) > 0 && flags&libvirt.DomainUndefineSnapshotsMetadata == 0 {
+		return fmt.Errorf("cannot delete domain %s with %d snapshots", domain.Name, len(d.snapshots))
+	}
+	delete(f.domains, domain.Name)
+	return nil
+}
+
+func (f *FakeLibvirt) ModifyDomainMemory(domain libvirt.Domain, memoryKB uint64, live bool) (*ResizeResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return nil, err
+	}
+	running := live && d.state == libvirt.DomainRunning
+	if running && memoryKB > d.maxMemoryKB {
+		return nil, fmt.Errorf("memory %d KiB > max memory %d KiB: %w", memoryKB, d.maxMemoryKB, ErrHotplugLimitExceeded)
+	}
+	d.memoryKB = memoryKB
+	d.maxMemoryKB = max(d.maxMemoryKB, memoryKB)
+	return &ResizeResult{Persisted: true, LiveApplied: running}, nil
+}
+
+func (f *FakeLibvirt) ModifyDomainVCPU(domain libvirt.Domain, vcpus uint16, live bool) (*ResizeResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return nil, err
+	}
+	if vcpus == 0 {
+		return nil, fmt.Errorf("vcpus must be greater than 0")
+	}
+	running := live && d.state == libvirt.DomainRunning
+	if running && vcpus > d.maxVCPUs {
+		return nil, fmt.Errorf("vcpus %d > max vcpus %d: %w", vcpus, d.maxVCPUs, ErrHotplugLimitExceeded)
+	}
+	d.vcpus = vcpus
+	d.maxVCPUs = max(d.maxVCPUs, vcpus)
+	return &ResizeResult{Persisted: true, LiveApplied: running}, nil
+}
+
+func (f *FakeLibvirt) SetDomainAutostart(domain libvirt.Domain, autostart bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return err
+	}
+	d.autostart = autostart
+	return nil
+}
+
+func (f *FakeLibvirt) RenameDomain(domain libvirt.Domain, newName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return err
+	}
+	if d.state == libvirt.DomainRunning || d.state == libvirt.DomainPaused {
+		return fmt.Errorf("cannot rename active domain %s", domain.Name)
+	}
+	if len(d.snapshots) > 0 {
+		return fmt.Errorf("cannot rename domain %s with snapshots", domain.Name)
+	}
+	if _, ok := f.domains[newName]; ok {
+		return fmt.Errorf("domain %s already exists", newName)
+	}
+	delete(f.domains, domain.Name)
+	d.domain.Name = newName
+	f.domains[newName] = d
+	return nil
+}
+
+func (f *FakeLibvirt) SetDomainBootOrder(domain libvirt.Domain, devices []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return err
+	}
+
+	order := make(map[string]int, len(devices))
+	for i, dev := range devices {
+		order[dev] = i + 1
+	}
+	found := 0
+	for i := range d.disks {
+		d.disks[i].Boot = nil
+		if o, ok := order[d.disks[i].Target.Dev]; ok {
+			d.disks[i].Boot = &DomainBootOrder{Order: o}
+			found++
+		}
+	}
+	if found != len(devices) {
+		return fmt.Errorf("boot device not found in domain %s", domain.Name)
+	}
+	return nil
+}
+
+func (f *FakeLibvirt) SetDomainInterfaceBandwidth(domainName, mac string, bandwidth InterfaceBandwidth) error {
+	if err := bandwidth.Validate(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return err
+	}
+	for i := range d.interfaces {
+		iface := &d.interfaces[i]
+		if !strings.EqualFold(iface.MAC, mac) {
+			continue
+		}
+		merged := InterfaceBandwidth{}
+		if iface.Bandwidth != nil {
+			merged = *iface.Bandwidth
+		}
+		if bandwidth.Inbound != nil {
+			merged.Inbound = bandwidth.Inbound
+		}
+		if bandwidth.Outbound != nil {
+			merged.Outbound = bandwidth.Outbound
+		}
+		iface.Bandwidth = bandwidthFromXML(merged.toXML())
+		return nil
+	}
+	return fmt.Errorf("interface %s not found in domain %s", mac, domainName)
+}
+
+func (f *FakeLibvirt) SetDomainLabels(domainName string, labels map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return err
+	}
+	d.labels = nil
+	if len(labels) > 0 {
+		d.labels = maps.Clone(labels)
+	}
+	return nil
+}
+
+func (f *FakeLibvirt) SetDomainCPUTune(domainName string, tune CPUTune) error {
+	if err := tune.Validate(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return err
+	}
+	merged := CPUTune{}
+	if d.cpuTune != nil {
+		merged = *d.cpuTune
+	}
+	if tune.Shares != 0 {
+		merged.Shares = tune.Shares
+	}
+	if tune.Period != 0 {
+		merged.Period = tune.Period
+	}
+	if tune.Quota != 0 {
+		merged.Quota = tune.Quota
+	}
+	d.cpuTune = cpuTuneFromXML(merged.toXML())
+	return nil
+}
+
+func (f *FakeLibvirt) SetDomainBlkioTune(domainName string, tune BlkioTune) error {
+	if err := tune.Validate(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return err
+	}
+	if tune.Weight != 0 {
+		d.blkioTune = &BlkioTune{Weight: tune.Weight}
+	}
+	return nil
+}
+
+func (f *FakeLibvirt) UpdateDomainDevice(domainName string, update DeviceUpdate) (bool, error) {
+	if err := update.Validate(); err != nil {
+		return false, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return false, err
+	}
+	live := update.Type != DeviceTypeDisk && d.state == libvirt.DomainRunning
+
+	if update.Type == DeviceTypeInterface {
+		for i := range d.interfaces {
+			iface := &d.interfaces[i]
+			if !strings.EqualFold(iface.MAC, update.Target) {
+				continue
+			}
+			merged := InterfaceBandwidth{}
+			if iface.Bandwidth != nil {
+				merged = *iface.Bandwidth
+			}
+			if update.Bandwidth.Inbound != nil {
+				merged.Inbound = update.Bandwidth.Inbound
+			}
+			if update.Bandwidth.Outbound != nil {
+				merged.Outbound = update.Bandwidth.Outbound
+			}
+			iface.Bandwidth = bandwidthFromXML(merged.toXML())
+			return live, nil
+		}
+		return false, fmt.Errorf("interface %s not found in domain", update.Target)
+	}
+
+	for i := range d.disks {
+		disk := &d.disks[i]
+		if disk.Target.Dev != update.Target {
+			continue
+		}
+		if disk.Device != update.Type {
+			return false, fmt.Errorf("device %s is not a %s", update.Target, update.Type)
+		}
+		if update.Type == DeviceTypeCDROM {
+			disk.Source = DomainDiskSource{File: update.Media}
+		} else {
+			update.Driver.apply(&disk.Driver)
+		}
+		return live, nil
+	}
+	return false, fmt.Errorf("device %s not found in domain", update.Target)
+}
+
+// ==================== Domain 磁盘操作 ====================
+
+// GetAllDomainStats 运行中的 domain 按启动以来时长的 10% 模拟 CPU 时间，磁盘占用取卷的分配大小，网络流量为 0
+func (f *FakeLibvirt) GetAllDomainStats() ([]DomainStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names := make([]string, 0, len(f.domains))
+	for name := range f.domains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]DomainStats, 0, len(names))
+	for _, name := range names {
+		d := f.domains[name]
+		stats := DomainStats{
+			Name:   d.domain.Name,
+			UUID:   fmt.Sprintf("%x", d.domain.UUID),
+			Active: d.state == libvirt.DomainRunning || d.state == libvirt.DomainPaused,
+		}
+		if stats.Active {
+			stats.VCPUs = uint32(d.vcpus)
+			stats.MemoryKB = d.memoryKB
+			if d.startTime != nil {
+				stats.CPUTime = uint64(time.Since(*d.startTime) / 10)
+			}
+		}
+		for _, disk := range d.disks {
+			if disk.Device != "disk" {
+				continue
+			}
+			p, volumeName, ok := f.findVolumeByPath(disk.Source.File)
+			if !ok {
+				continue
+			}
+			stats.DiskPhysicalBytes += p.volumes[volumeName].AllocationB
+			stats.DiskCapacityBytes += p.volumes[volumeName].CapacityB
+		}
+		result = append(result, stats)
+	}
+	return result, nil
+}
+
+func (f *FakeLibvirt) AttachDiskToDomain(domainName string, config *AttachDiskConfig) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return "", err
+	}
+
+	bus := config.Bus
+	if bus == "" {
+		bus = "virtio"
+	}
+	format := config.Format
+	if format == "" {
+		format = "qcow2"
+	}
+	if config.Block {
+		format = "raw"
+	}
+	if _, err := resolveDiskDriverOptions(format, config.Driver); err != nil {
+		return "", err
+	}
+
+	used := make(map[string]bool, len(d.disks))
+	for _, disk := range d.disks {
+		used[disk.Target.Dev] = true
+		if disk.SourcePath() == config.VolumePath {
+			return "", fmt.Errorf("volume %s is already attached to domain %s", config.VolumePath, domainName)
+		}
+	}
+
+	device := config.Device
+	if device == "" {
+		prefix, err := diskDevicePrefix(bus)
+		if err != nil {
+			return "", err
+		}
+		if device, err = nextDiskDevice(prefix, used); err != nil {
+			return "", err
+		}
+	} else if used[device] {
+		return "", fmt.Errorf("device %s is already in use", device)
+	}
+
+	d.disks = append(d.disks, newAttachedDisk(config, format, device, bus))
+	return device, nil
+}
+
+func (f *FakeLibvirt) DetachDiskFromDomain(domainName, device string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return err
+	}
+	for i, disk := range d.disks {
+		if disk.Target.Dev == device {
+			d.disks = append(d.disks[:i], d.disks[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("disk %s not found in domain %s", device, domainName)
+}
+
+func (f *FakeLibvirt) GetDomainDisks(domainName string) ([]DomainDisk, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return nil, err
+	}
+
+	disks := make([]DomainDisk, len(d.disks))
+	copy(disks, d.disks)
+	for i := range disks {
+		if p, name, ok := f.findVolumeByPath(disks[i].Source.File); ok {
+			disks[i].CapacityB = p.volumes[name].CapacityB
+			disks[i].AllocationB = p.volumes[name].AllocationB
+		}
+	}
+	return disks, nil
+}
+
+func (f *FakeLibvirt) SetDomainDiskSource(domainName, device, sourcePath, format string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return err
+	}
+	for i := range d.disks {
+		if d.disks[i].Target.Dev != device {
+			continue
+		}
+		d.disks[i].Source = DomainDiskSource{File: sourcePath}
+		if format != "" {
+			d.disks[i].Driver.Type = format
+		}
+		return nil
+	}
+	return fmt.Errorf("device %s not found in domain", device)
+}
+
+func (f *FakeLibvirt) EjectDomainCDROM(domainName, device string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return err
+	}
+	for i, disk := range d.disks {
+		if disk.Target.Dev != device {
+			continue
+		}
+		if disk.Device != "cdrom" {
+			return fmt.Errorf("device %s is not a cdrom", device)
+		}
+		d.disks[i].Source = DomainDiskSource{}
+		return nil
+	}
+	return fmt.Errorf("cdrom %s not found in domain %s", device, domainName)
+}
+
+// ==================== Storage Pool 操作 ====================
+
+func (f *FakeLibvirt) GetStoragePool(poolName string) (*StoragePoolInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, err := f.lookupPool(poolName)
+	if err != nil {
+		return nil, err
+	}
+	info := p.info
+	return &info, nil
+}
+
+func (f *FakeLibvirt) ListStoragePools() ([]*StoragePoolInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names := make([]string, 0, len(f.pools))
+	for name := range f.pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]*StoragePoolInfo, 0, len(names))
+	for _, name := range names {
+		info := f.pools[name].info
+		result = append(result, &info)
+	}
+	return result, nil
+}
+
+func (f *FakeLibvirt) EnsureStoragePool(poolName, poolType, poolPath string) error {
+	f.mu.Lock()
+	_, exists := f.pools[poolName]
+	f.mu.Unlock()
+
+	if exists {
+		return f.StartStoragePool(poolName)
+	}
+	return f.CreateStoragePool(poolName, poolType, poolPath)
+}
+
+func (f *FakeLibvirt) CreateStoragePool(poolName, poolType, poolPath string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.pools[poolName]; exists {
+		return fmt.Errorf("storage pool %s already exists", poolName)
+	}
+	info := StoragePoolInfo{
+		Name:       poolName,
+		State:      "Active",
+		CapacityB:  fakePoolCapacity,
+		AvailableB: fakePoolCapacity,
+		Path:       poolPath,
+		Type:       poolType,
+	}
+	if poolType == "zfs" {
+		info.Path, info.Source = "/dev/zvol/"+poolPath, poolPath
+	}
+	f.pools[poolName] = &fakePool{
+		info:     info,
+		poolType: poolType,
+		volumes:  make(map[string]*VolumeInfo),
+	}
+	return nil
+}
+
+func (f *FakeLibvirt) StartStoragePool(poolName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, err := f.lookupPool(poolName)
+	if err != nil {
+		return err
+	}
+	p.info.State = "Active"
+	return nil
+}
+
+func (f *FakeLibvirt) StopStoragePool(poolName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, err := f.lookupPool(poolName)
+	if err != nil {
+		return err
+	}
+	p.info.State = "Inactive"
+	return nil
+}
+
+func (f *FakeLibvirt) DeleteStoragePool(poolName string, deleteVolumes bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, err := f.lookupPool(poolName)
+	if err != nil {
+		return err
+	}
+	if len(p.volumes) > 0 && !deleteVolumes {
+		return fmt.Errorf("storage pool %s is not empty", poolName)
+	}
+	delete(f.pools, poolName)
+	return nil
+}
+
+func (f *FakeLibvirt) RefreshStoragePool(poolName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, err := f.lookupPool(poolName)
+	return err
+}
+
+// ==================== Storage Volume 操作 ====================
+
+func (f *FakeLibvirt) GetVolume(poolName, volumeName string) (*VolumeInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, err := f.lookupPool(poolName)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := p.volumes[volumeName]
+	if !ok {
+		return nil, fmt.Errorf("volume %s not found in pool %s", volumeName, poolName)
+	}
+	return copyVolume(v), nil
+}
+
+func (f *FakeLibvirt) ListVolumes(poolName string) ([]*VolumeInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, err := f.lookupPool(poolName)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(p.volumes))
+	for name := range p.volumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]*VolumeInfo, 0, len(names))
+	for _, name := range names {
+		result = append(result, copyVolume(p.volumes[name]))
+	}
+	return result, nil
+}
+
+func (f *FakeLibvirt) CreateVolume(poolName, volumeName string, sizeGB uint64, format string) (*VolumeInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if format == "" {
+		format = "qcow2"
+	}
+	return f.addVolume(poolName, volumeName, sizeGB*1024*1024*1024, format)
+}
+
+func (f *FakeLibvirt) CreateVolumeWithBackingStore(poolName, volumeName string, capacityGB uint64, format string, backingPath string, backingFormat string) (*VolumeInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, _, ok := f.findVolumeByPath(backingPath); !ok {
+		if _, ok := f.files[backingPath]; !ok {
+			return nil, fmt.Errorf("backing file %s not found", backingPath)
+		}
+	}
+	if format == "" {
+		format = "qcow2"
+	}
+	if backingFormat == "" {
+		backingFormat = "qcow2"
+	}
+	vol, err := f.addVolume(poolName, volumeName, capacityGB*1024*1024*1024, format)
+	if err != nil {
+		return nil, err
+	}
+	stored := f.pools[poolName].volumes[volumeName]
+	stored.BackingPath = backingPath
+	stored.BackingFormat = backingFormat
+	vol.BackingPath = backingPath
+	vol.BackingFormat = backingFormat
+	return vol, nil
+}
+
+func (f *FakeLibvirt) UploadFileToPool(poolName string, volumeName string, localFilePath string) (*VolumeInfo, error) {
+	content, err := os.ReadFile(localFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("read local file: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// 与真实实现一致：同名卷已存在时先删除
+	if p, err := f.lookupPool(poolName); err == nil {
+		delete(p.volumes, volumeName)
+	}
+	vol, err := f.addVolume(poolName, volumeName, uint64(len(content)), "raw")
+	if err != nil {
+		return nil, err
+	}
+	f.files[vol.Path] = content
+	return vol, nil
+}
+
+func (f *FakeLibvirt) ResizeVolume(poolName, volumeName string, newSizeGB uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, err := f.lookupPool(poolName)
+	if err != nil {
+		return err
+	}
+	v, ok := p.volumes[volumeName]
+	if !ok {
+		return fmt.Errorf("volume %s not found in pool %s", volumeName, poolName)
+	}
+	newSize := newSizeGB * 1024 * 1024 * 1024
+	if newSize < v.CapacityB {
+		return fmt.Errorf("cannot shrink volume %s", volumeName)
+	}
+	v.CapacityB = newSize
+	return nil
+}
+
+func (f *FakeLibvirt) DeleteVolume(poolName, volumeName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, err := f.lookupPool(poolName)
+	if err != nil {
+		return err
+	}
+	v, ok := p.volumes[volumeName]
+	if !ok {
+		return fmt.Errorf("volume %s not found in pool %s", volumeName, poolName)
+	}
+	delete(p.volumes, volumeName)
+	delete(f.files, v.Path)
+	f.recalcPool(p)
+	return nil
+}
+
+func (f *FakeLibvirt) DeleteVolumeByPath(volumePath string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, name, ok := f.findVolumeByPath(volumePath)
+	if !ok {
+		return fmt.Errorf("volume %s not found", volumePath)
+	}
+	delete(p.volumes, name)
+	delete(f.files, volumePath)
+	f.recalcPool(p)
+	return nil
+}
+
+// ==================== QEMU Guest Agent 操作 ====================
+
+func (f *FakeLibvirt) QemuAgentCommand(domain libvirt.Domain, command string, timeout uint32, flags uint32) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return "", err
+	}
+	if d.state != libvirt.DomainRunning || !d.agentReady {
+		return "", fmt.Errorf("guest agent is not connected")
+	}
+	for prefix, response := range f.agentResponses {
+		if strings.Contains(command, prefix) {
+			return response, nil
+		}
+	}
+	return `{"return":{}}`, nil
+}
+
+func (f *FakeLibvirt) CheckGuestAgentAvailable(domain libvirt.Domain) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return false, err
+	}
+	return d.state == libvirt.DomainRunning && d.agentReady, nil
+}
+
+func (f *FakeLibvirt) FreezeDomainFS(domainName string, mountpoints []string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return 0, err
+	}
+	if d.state != libvirt.DomainRunning || !d.agentReady {
+		return 0, fmt.Errorf("guest agent is not connected")
+	}
+	if d.frozenFS > 0 {
+		return 0, fmt.Errorf("filesystems of domain %s are already frozen", domainName)
+	}
+	d.frozenFS = max(len(mountpoints), 1)
+	return d.frozenFS, nil
+}
+
+func (f *FakeLibvirt) GuestExec(domainName, path string, args []string, timeout time.Duration) (*GuestExecResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return nil, err
+	}
+	if d.state != libvirt.DomainRunning || !d.agentReady {
+		return nil, fmt.Errorf("guest agent is not connected")
+	}
+	return &GuestExecResult{}, nil
+}
+
+func (f *FakeLibvirt) GetGuestOSInfo(domainName string) (*GuestOSInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return nil, err
+	}
+	if d.state != libvirt.DomainRunning || !d.agentReady {
+		return nil, fmt.Errorf("guest agent is not connected")
+	}
+	return &GuestOSInfo{
+		ID:            "ubuntu",
+		Name:          "Ubuntu",
+		PrettyName:    "Ubuntu 22.04.4 LTS",
+		Version:       "22.04.4 LTS (Jammy Jellyfish)",
+		VersionID:     "22.04",
+		KernelRelease: "5.15.0-105-generic",
+		Machine:       "x86_64",
+	}, nil
+}
+
+func (f *FakeLibvirt) ThawDomainFS(domainName string, mountpoints []string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return 0, err
+	}
+	if d.state != libvirt.DomainRunning || !d.agentReady {
+		return 0, fmt.Errorf("guest agent is not connected")
+	}
+	count := d.frozenFS
+	if len(mountpoints) > 0 {
+		count = min(len(mountpoints), d.frozenFS)
+	}
+	d.frozenFS -= count
+	return count, nil
+}
+
+// ==================== QEMU Monitor 操作 ====================
+
+// fakeQMPBlockJobTypes libvirt block job 类型对应的 QEMU job 类型
+var fakeQMPBlockJobTypes = map[string]string{
+	BlockJobTypePull:         "stream",
+	BlockJobTypeCopy:         "mirror",
+	BlockJobTypeCommit:       "commit",
+	BlockJobTypeActiveCommit: "commit",
+	BlockJobTypeBackup:       "backup",
+}
+
+// QEMUMonitorCommand 按 domain 的内存状态应答 query-status、query-block-jobs，其他命令返回空结果
+func (f *FakeLibvirt) QEMUMonitorCommand(domainName, command string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return "", err
+	}
+	if d.state != libvirt.DomainRunning && d.state != libvirt.DomainPaused {
+		return "", fmt.Errorf("domain is not running")
+	}
+	request := struct {
+		Execute string `json:"execute"`
+	}{}
+	if err := json.Unmarshal([]byte(command), &request); err != nil {
+		return "", fmt.Errorf("parse qmp command: %w", err)
+	}
+
+	var result any = map[string]any{}
+	switch request.Execute {
+	case "query-status":
+		status := "running"
+		if d.state == libvirt.DomainPaused {
+			status = "paused"
+		}
+		result = map[string]any{"status": status, "running": status == "running", "singlestep": false}
+	case "query-block-jobs":
+		devices := make([]string, 0, len(d.blockJobs))
+		for device := range d.blockJobs {
+			devices = append(devices, device)
+		}
+		sort.Strings(devices)
+		jobs := make([]map[string]any, 0, len(devices))
+		for _, device := range devices {
+			info := d.blockJobs[device].info
+			status := "running"
+			if info.Ready() {
+				status = "ready"
+			}
+			jobs = append(jobs, map[string]any{
+				"type":      fakeQMPBlockJobTypes[info.Type],
+				"device":    device,
+				"len":       info.End,
+				"offset":    info.Cur,
+				"speed":     info.Bandwidth << 20,
+				"busy":      false,
+				"paused":    false,
+				"ready":     info.Ready(),
+				"status":    status,
+				"io-status": "ok",
+			})
+		}
+		result = jobs
+	}
+	data, err := json.Marshal(map[string]any{"return": result})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ==================== Console 操作 ====================
+
+func (f *FakeLibvirt) GetDomainConsoleInfo(domain libvirt.Domain) (*ConsoleInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return nil, err
+	}
+	info := &ConsoleInfo{
+		VNCSocket:     fmt.Sprintf("/var/lib/jvp/qemu/%s.vnc", domain.Name),
+		SerialLogPath: fmt.Sprintf("/var/lib/jvp/qemu/%s.serial.log", domain.Name),
+		Type:          "vnc",
+	}
+	if d.state == libvirt.DomainRunning {
+		info.SerialDevice = fmt.Sprintf("/dev/pts/%d", d.domain.ID)
+	}
+	return info, nil
+}
+
+func (f *FakeLibvirt) GetDomainConsoleOutput(domain libvirt.Domain, maxBytes int64) (*ConsoleOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domain.Name)
+	if err != nil {
+		return nil, err
+	}
+	output := &ConsoleOutput{
+		LogPath: fmt.Sprintf("/var/lib/jvp/qemu/%s.serial.log", domain.Name),
+		Output:  d.consoleOutput,
+	}
+	if maxBytes > 0 && int64(len(output.Output)) > maxBytes {
+		output.Output = output.Output[int64(len(output.Output))-maxBytes:]
+		output.Truncated = true
+	}
+	return output, nil
+}
+
+// ==================== Snapshot 操作 ====================
+
+func (f *FakeLibvirt) ListSnapshots(domainName string) ([]string, error) {
+	snapshots, err := f.ListSnapshotXML(domainName)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(snapshots))
+	for _, snap := range snapshots {
+		names = append(names, snap.Name)
+	}
+	return names, nil
+}
+
+func (f *FakeLibvirt) CreateSnapshot(domainName string, snapshotXML string, flags libvirt.DomainSnapshotCreateFlags) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return err
+	}
+
+	var snap DomainSnapshotXML
+	if err := xml.Unmarshal([]byte(snapshotXML), &snap); err != nil {
+		return fmt.Errorf("parse snapshot XML: %w", err)
+	}
+	if snap.Name == "" {
+		snap.Name = fmt.Sprintf("%d", time.Now().Unix())
+	}
+	for _, existing := range d.snapshots {
+		if existing.Name == snap.Name {
+			return fmt.Errorf("snapshot %s already exists for domain %s", snap.Name, domainName)
+		}
+	}
+	snap.CreationTime = time.Now().Unix()
+	snap.State = strings.ToLower(formatDomainState(uint8(d.state)))
+	if len(d.snapshots) > 0 {
+		snap.Parent = &DomainSnapshotParentXML{Name: d.snapshots[len(d.snapshots)-1].Name}
+	}
+	d.snapshots = append(d.snapshots, snap)
+	return nil
+}
+
+func (f *FakeLibvirt) GetSnapshotXML(domainName, snapshotName string) (*DomainSnapshotXML, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return nil, err
+	}
+	for _, snap := range d.snapshots {
+		if snap.Name == snapshotName {
+			s := snap
+			return &s, nil
+		}
+	}
+	return nil, fmt.Errorf("snapshot %s not found for domain %s", snapshotName, domainName)
+}
+
+func (f *FakeLibvirt) ListSnapshotXML(domainName string) ([]DomainSnapshotXML, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return nil, err
+	}
+	return append([]DomainSnapshotXML(nil), d.snapshots...), nil
+}
+
+func (f *FakeLibvirt) DeleteSnapshot(domainName, snapshotName string, flags libvirt.DomainSnapshotDeleteFlags) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return err
+	}
+	for i, snap := range d.snapshots {
+		if snap.Name != snapshotName {
+			continue
+		}
+		// 子快照挂到被删除快照的父快照下
+		for j := range d.snapshots {
+			if d.snapshots[j].Parent != nil && d.snapshots[j].Parent.Name == snapshotName {
+				d.snapshots[j].Parent = snap.Parent
+			}
+		}
+		d.snapshots = append(d.snapshots[:i], d.snapshots[i+1:]...)
+		return nil
+	}
+	return fmt.Errorf("snapshot %s not found for domain %s", snapshotName, domainName)
+}
+
+func (f *FakeLibvirt) RevertToSnapshot(domainName, snapshotName string, flags libvirt.DomainSnapshotRevertFlags) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return err
+	}
+	for _, snap := range d.snapshots {
+		if snap.Name != snapshotName {
+			continue
+		}
+		switch {
+		case flags&libvirt.DomainSnapshotRevertRunning != 0:
+			if d.state != libvirt.DomainRunning {
+				f.startDomain(d)
+			}
+		case flags&libvirt.DomainSnapshotRevertPaused != 0:
+			d.state = libvirt.DomainPaused
+		case snap.State == "running":
+			if d.state != libvirt.DomainRunning {
+				f.startDomain(d)
+			}
+		default:
+			f.stopDomain(d)
+		}
+		return nil
+	}
+	return fmt.Errorf("snapshot %s not found for domain %s", snapshotName, domainName)
+}
+
+// ==================== Network Interface 操作 ====================
+
+func (f *FakeLibvirt) ListInterfaces() ([]libvirt.Interface, error) {
+	return []libvirt.Interface{{Name: "br0", Mac: "52:54:00:00:00:01"}}, nil
+}
+
+func (f *FakeLibvirt) GetInterfaceXMLDesc(iface libvirt.Interface) (string, error) {
+	return fmt.Sprintf("<interface type='bridge' name='%s'><mac address='%s'/></interface>", iface.Name, iface.Mac), nil
+}
+
+func (f *FakeLibvirt) ListNetworkDHCPLeases(networkName string) ([]DHCPLease, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.networks[networkName]; !ok {
+		return nil, fmt.Errorf("network %s not found", networkName)
+	}
+	return nil, nil
+}
+
+func (f *FakeLibvirt) ListNetworks() ([]string, error) {
+	networks, err := f.ListNetworksInfo()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(networks))
+	for _, n := range networks {
+		names = append(names, n.Name)
+	}
+	return names, nil
+}
+
+// ==================== Network 管理 ====================
+
+func (f *FakeLibvirt) ListNetworksInfo() ([]NetworkInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names := make([]string, 0, len(f.networks))
+	for name := range f.networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]NetworkInfo, 0, len(names))
+	for _, name := range names {
+		result = append(result, *f.networks[name])
+	}
+	return result, nil
+}
+
+func (f *FakeLibvirt) GetNetwork(name string) (*NetworkInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n, ok := f.networks[name]
+	if !ok {
+		return nil, fmt.Errorf("network %s not found", name)
+	}
+	info := *n
+	return &info, nil
+}
+
+func (f *FakeLibvirt) GetNetworkXMLDesc(name string) (string, error) {
+	n, err := f.GetNetwork(name)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("<network><name>%s</name><bridge name='%s'/></network>", n.Name, n.Bridge), nil
+}
+
+func (f *FakeLibvirt) CreateNetwork(config NetworkConfig) (*NetworkInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.networks[config.Name]; exists {
+		return nil, fmt.Errorf("network %s already exists", config.Name)
+	}
+	bridge := config.Bridge
+	if bridge == "" {
+		bridge = fmt.Sprintf("virbr%d", len(f.networks)+1)
+	}
+	mode := config.Mode
+	if mode == "" {
+		mode = "nat"
+	}
+	n := &NetworkInfo{
+		Name:       config.Name,
+		UUID:       fmt.Sprintf("%032d", len(f.networks)+1),
+		Bridge:     bridge,
+		Active:     true,
+		Persistent: true,
+		Autostart:  config.Autostart,
+		Mode:       mode,
+		IPAddress:  config.IPAddress,
+		Netmask:    config.Netmask,
+		DHCPStart:  config.DHCPStart,
+		DHCPEnd:    config.DHCPEnd,
+	}
+	if config.IPv6Address != "" {
+		n.IPv6Address = config.IPv6Address
+		n.IPv6Prefix = cmp.Or(config.IPv6Prefix, 64)
+	}
+	f.networks[config.Name] = n
+	info := *n
+	return &info, nil
+}
+
+func (f *FakeLibvirt) DeleteNetwork(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.networks[name]; !ok {
+		return fmt.Errorf("network %s not found", name)
+	}
+	delete(f.networks, name)
+	return nil
+}
+
+func (f *FakeLibvirt) StartNetwork(name string) error {
+	return f.updateNetwork(name, func(n *NetworkInfo) { n.Active = true })
+}
+
+func (f *FakeLibvirt) StopNetwork(name string) error {
+	return f.updateNetwork(name, func(n *NetworkInfo) { n.Active = false })
+}
+
+func (f *FakeLibvirt) SetNetworkAutostart(name string, autostart bool) error {
+	return f.updateNetwork(name, func(n *NetworkInfo) { n.Autostart = autostart })
+}
+
+func (f *FakeLibvirt) updateNetwork(name string, update func(*NetworkInfo)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n, ok := f.networks[name]
+	if !ok {
+		return fmt.Errorf("network %s not found", name)
+	}
+	update(n)
+	return nil
+}
+
+// ==================== NWFilter 操作 ====================
+
+func (f *FakeLibvirt) DefineNWFilter(filter NWFilterXML) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, ref := range filter.FilterRefs {
+		if _, ok := f.filters[ref.Filter]; !ok {
+			return fmt.Errorf("referenced nwfilter %s not found", ref.Filter)
+		}
+	}
+	f.filters[filter.Name] = filter
+	return nil
+}
+
+// UndefineNWFilter 与 libvirt 一致：被其他过滤器或运行中 domain 的网卡引用时拒绝删除，关机 domain 的引用不检查
+func (f *FakeLibvirt) UndefineNWFilter(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.filters[name]; !ok {
+		return fmt.Errorf("nwfilter %s not found", name)
+	}
+	for _, filter := range f.filters {
+		for _, ref := range filter.FilterRefs {
+			if ref.Filter == name {
+				return fmt.Errorf("undefine nwfilter %s: %w: referenced by %s", name, ErrNWFilterInUse, filter.Name)
+			}
+		}
+	}
+	for _, d := range f.domains {
+		if d.state != libvirt.DomainRunning {
+			continue
+		}
+		for _, iface := range d.interfaces {
+			if iface.Filter == name {
+				return fmt.Errorf("undefine nwfilter %s: %w: used by domain %s", name, ErrNWFilterInUse, d.domain.Name)
+			}
+		}
+	}
+	delete(f.filters, name)
+	return nil
+}
+
+func (f *FakeLibvirt) ListNWFilters() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	names := make([]string, 0, len(f.filters))
+	for name := range f.filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ==================== Node Device 操作 ====================
+
+func (f *FakeLibvirt) ListNodeDevices(cap string) ([]libvirt.NodeDevice, error) {
+	return nil, nil
+}
+
+func (f *FakeLibvirt) GetNodeDeviceXMLDesc(dev libvirt.NodeDevice) (string, error) {
+	return "", fmt.Errorf("node device %s not found", dev.Name)
+}
+
+// ==================== Remote File 操作 ====================
+
+func (f *FakeLibvirt) IsRemoteConnection() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.remote
+}
+
+func (f *FakeLibvirt) GetConnectionURI() string {
+	return f.uri
+}
+
+func (f *FakeLibvirt) GetSSHTarget() (string, error) {
+	if !f.IsRemoteConnection() {
+		return "", fmt.Errorf("not a remote connection")
+	}
+	return "root@" + f.hostname, nil
+}
+
+func (f *FakeLibvirt) ExecuteRemoteCommand(cmd string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.remote {
+		return fmt.Errorf("not a remote connection")
+	}
+	f.commands = append(f.commands, cmd)
+	return nil
+}
+
+func (f *FakeLibvirt) ReadRemoteFile(path string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.remote {
+		return nil, fmt.Errorf("not a remote connection")
+	}
+	content, ok := f.files[path]
+	if !ok {
+		return nil, fmt.Errorf("read remote file %s: no such file", path)
+	}
+	return append([]byte(nil), content...), nil
+}
+
+func (f *FakeLibvirt) ListRemoteFiles(dir, pattern string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.remote {
+		return nil, fmt.Errorf("not a remote connection")
+	}
+	var files []string
+	for path := range f.files {
+		if filepath.Dir(path) != filepath.Clean(dir) {
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ==================== Cloud-Init 操作 ====================
+
+func (f *FakeLibvirt) CreateCloudInitVolume(poolName, vmName, metaData, userData, networkConfig string) (*VolumeInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	volumeName := CloudInitVolumeName(vmName)
+	if p, err := f.lookupPool(poolName); err == nil {
+		delete(p.volumes, volumeName)
+	}
+	vol, err := f.addVolume(poolName, volumeName, uint64(len(metaData)+len(userData)+len(networkConfig)), "raw")
+	if err != nil {
+		return nil, err
+	}
+	// ISO 内容只保存 user-data，供 ReadCloudInitUserData 读取
+	f.files[vol.Path] = []byte(userData)
+	return vol, nil
+}
+
+func (f *FakeLibvirt) ReadCloudInitUserData(isoPath string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	content, ok := f.files[isoPath]
+	if !ok {
+		return "", fmt.Errorf("read user-data from %s: no such file", isoPath)
+	}
+	return string(content), nil
+}
+
+// ==================== Block job ====================
+
+// fakeRunningDisk 查找运行中 domain 的磁盘
+func (f *FakeLibvirt) fakeRunningDisk(domainName, device string) (*fakeDomain, *DomainDisk, error) {
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if d.state != libvirt.DomainRunning {
+		return nil, nil, fmt.Errorf("domain %s is not running", domainName)
+	}
+	for i := range d.disks {
+		if d.disks[i].Target.Dev == device {
+			if _, busy := d.blockJobs[device]; busy {
+				return nil, nil, fmt.Errorf("disk %s already has an active block job", device)
+			}
+			return d, &d.disks[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("device %s not found in domain", device)
+}
+
+// addBlockJob 登记 ready 状态的 block job
+func (d *fakeDomain) addBlockJob(device, jobType string, size uint64, pivotPath string) {
+	if d.blockJobs == nil {
+		d.blockJobs = make(map[string]*fakeBlockJob)
+	}
+	d.blockJobs[device] = &fakeBlockJob{
+		info:      BlockJobInfo{Type: jobType, Cur: size, End: size},
+		pivotPath: pivotPath,
+	}
+}
+
+// BlockPull 内存实现中 blockpull 立即完成：清除磁盘卷的 backing 信息
+func (f *FakeLibvirt) BlockPull(domainName, device string, bandwidthMiB uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, disk, err := f.fakeRunningDisk(domainName, device)
+	if err != nil {
+		return err
+	}
+	if p, name, ok := f.findVolumeByPath(disk.Source.File); ok {
+		p.volumes[name].BackingPath = ""
+		p.volumes[name].BackingFormat = ""
+	}
+	return nil
+}
+
+// BlockCommit 非 active commit 立即完成；active commit 进入 ready，pivot 后切换到 base
+func (f *FakeLibvirt) BlockCommit(domainName, device string, config BlockCommitConfig) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, disk, err := f.fakeRunningDisk(domainName, device)
+	if err != nil {
+		return err
+	}
+	if !config.Active {
+		return nil
+	}
+	base := config.Base
+	var size uint64
+	if p, name, ok := f.findVolumeByPath(disk.Source.File); ok {
+		size = p.volumes[name].CapacityB
+		if base == "" {
+			base = p.volumes[name].BackingPath
+		}
+	}
+	if base == "" {
+		return fmt.Errorf("disk %s has no backing file to commit into", device)
+	}
+	d.addBlockJob(device, BlockJobTypeActiveCommit, size, base)
+	return nil
+}
+
+// BlockCopy 创建后即处于 ready，pivot 后切换到目标文件
+func (f *FakeLibvirt) BlockCopy(domainName, device string, config BlockCopyConfig) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if config.DestPath == "" {
+		return fmt.Errorf("destination path is required")
+	}
+	d, disk, err := f.fakeRunningDisk(domainName, device)
+	if err != nil {
+		return err
+	}
+	var size uint64
+	if p, name, ok := f.findVolumeByPath(disk.Source.File); ok {
+		size = p.volumes[name].CapacityB
+	}
+	d.addBlockJob(device, BlockJobTypeCopy, size, config.DestPath)
+	d.blockJobs[device].info.Bandwidth = config.BandwidthMiB
+	return nil
+}
+
+func (f *FakeLibvirt) GetBlockJob(domainName, device string) (*BlockJobInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return nil, err
+	}
+	job, ok := d.blockJobs[device]
+	if !ok {
+		return nil, nil
+	}
+	info := job.info
+	return &info, nil
+}
+
+func (f *FakeLibvirt) AbortBlockJob(domainName, device string, pivot bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return err
+	}
+	job, ok := d.blockJobs[device]
+	if !ok {
+		return fmt.Errorf("no active block job on disk %s", device)
+	}
+	delete(d.blockJobs, device)
+	if !pivot {
+		return nil
+	}
+	for i := range d.disks {
+		if d.disks[i].Target.Dev == device {
+			d.disks[i].Source.File = job.pivotPath
+		}
+	}
+	return nil
+}
+
+func (f *FakeLibvirt) SetBlockJobSpeed(domainName, device string, bandwidthMiB uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, err := f.lookupDomain(domainName)
+	if err != nil {
+		return err
+	}
+	job, ok := d.blockJobs[device]
+	if !ok {
+		return fmt.Errorf("no active block job on disk %s", device)
+	}
+	job.info.Bandwidth = bandwidthMiB
+	return nil
+}
+
+func (f *FakeLibvirt) WaitBlockJob(ctx context.Context, domainName, device string, onProgress func(*BlockJobInfo)) (*BlockJobInfo, error) {
+	return waitBlockJob(ctx, f, domainName, device, onProgress)
+}
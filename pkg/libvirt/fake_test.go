@@ -0,0 +1,74 @@
+package libvirt
+
+import (
+	"testing"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeLibvirtDomainLifecycle(t *testing.T) {
+	f := NewFakeLibvirt()
+
+	domain, err := f.CreateDomain(&CreateVMConfig{Name: "vm-1", Memory: 1024 * 1024, VCPUs: 1, DiskPath: "/var/lib/jvp/vm-1.qcow2"}, false)
+	require.NoError(t, err)
+	_, err = f.CreateDomain(&CreateVMConfig{Name: "vm-1"}, false)
+	assert.Error(t, err, "duplicate domain name")
+
+	state, _, err := f.GetDomainState(domain)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(libvirt.DomainShutoff), state)
+	assert.Error(t, f.StopDomain(domain), "stopping a shut off domain")
+
+	require.NoError(t, f.StartDomain(domain))
+	state, _, err = f.GetDomainState(domain)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(libvirt.DomainRunning), state)
+	assert.Error(t, f.StartDomain(domain), "starting a running domain")
+
+	require.NoError(t, f.DestroyDomain(domain))
+	require.NoError(t, f.DeleteDomain(domain, 0))
+	_, err = f.GetDomainByName("vm-1")
+	assert.Error(t, err)
+}
+
+func TestFakeLibvirtStoragePoolAndVolumes(t *testing.T) {
+	f := NewFakeLibvirt()
+	require.NoError(t, f.CreateStoragePool("default", "dir", "/var/lib/jvp/images"))
+	assert.Error(t, f.CreateStoragePool("default", "dir", "/tmp"), "duplicate pool name")
+
+	vol, err := f.CreateVolume("default", "disk.qcow2", 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, "/var/lib/jvp/images/disk.qcow2", vol.Path)
+	assert.Equal(t, "qcow2", vol.Format)
+	_, err = f.CreateVolume("default", "disk.qcow2", 10, "")
+	assert.Error(t, err, "duplicate volume name")
+
+	overlay, err := f.CreateVolumeWithBackingStore("default", "overlay.qcow2", 20, "", vol.Path, "")
+	require.NoError(t, err)
+	assert.Equal(t, vol.Path, overlay.BackingPath)
+	_, err = f.CreateVolumeWithBackingStore("default", "orphan.qcow2", 20, "", "/missing.qcow2", "")
+	assert.Error(t, err, "missing backing file")
+
+	require.NoError(t, f.ResizeVolume("default", "disk.qcow2", 20))
+	assert.Error(t, f.ResizeVolume("default", "disk.qcow2", 5), "shrinking a volume")
+
+	assert.Error(t, f.DeleteStoragePool("default", false), "deleting a non-empty pool")
+	require.NoError(t, f.DeleteVolumeByPath(overlay.Path))
+	require.NoError(t, f.DeleteVolume("default", "disk.qcow2"))
+	volumes, err := f.ListVolumes("default")
+	require.NoError(t, err)
+	assert.Empty(t, volumes)
+	require.NoError(t, f.DeleteStoragePool("default", false))
+}
+
+func TestFakeNodeSharesState(t *testing.T) {
+	uri := "fake:///" + t.Name()
+	require.NoError(t, FakeNode(uri).CreateStoragePool("default", "dir", "/var/lib/jvp/images"))
+
+	_, err := FakeNode(uri).GetStoragePool("default")
+	assert.NoError(t, err)
+	_, err = FakeNode(uri + "-other").GetStoragePool("default")
+	assert.Error(t, err)
+}
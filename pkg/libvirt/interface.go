@@ -1,19 +1,48 @@
 package libvirt
 
 import (
+	"context"
+	"time"
+
 	"github.com/digitalocean/go-libvirt"
 )
 
 // LibvirtClient 定义 libvirt 客户端接口
-// 用于抽象 libvirt 操作，便于测试和 mock
+// 由按领域划分的子接口组合而成，Client、FakeLibvirt 与 MockClient 均完整实现；
+// 调用方应只依赖需要的子接口，便于测试和 mock
 type LibvirtClient interface {
+	HostManager
+	DomainManager
+	BlockJobManager
+	GuestAgentManager
+	MonitorManager
+	ConsoleManager
+	StorageManager
+	SnapshotManager
+	NetworkManager
+	NWFilterManager
+	RemoteManager
+	CloudInitManager
+}
+
+// HostManager 宿主机信息与设备（连接信息、PCI/USB 等 node device）
+type HostManager interface {
 	// 连接信息
 	GetHostname() (string, error)
 	GetLibvirtVersion() (string, error)
 	GetNodeInfo() (*NodeInfo, error)
-	GetCapabilities() (string, error)
-	GetSysinfo() (string, error)
+	GetNUMACells() ([]NUMACell, error)
+	GetCapabilities() (*CapabilitiesXML, error)
+	GetSysinfo() (*SysinfoXML, error)
+	Close() error
 
+	// Node Device 操作
+	ListNodeDevices(cap string) ([]libvirt.NodeDevice, error)
+	GetNodeDeviceXMLDesc(dev libvirt.NodeDevice) (string, error)
+}
+
+// DomainManager domain 生命周期、配置与磁盘
+type DomainManager interface {
 	// Domain 操作
 	GetVMSummaries() ([]libvirt.Domain, error)
 	GetDomainInfo(domainUUID libvirt.UUID) (*DomainInfo, error)
@@ -25,15 +54,61 @@ type LibvirtClient interface {
 	RebootDomain(domain libvirt.Domain) error
 	DestroyDomain(domain libvirt.Domain) error
 	DeleteDomain(domain libvirt.Domain, flags libvirt.DomainUndefineFlagsValues) error
-	ModifyDomainMemory(domain libvirt.Domain, memoryKB uint64, live bool) error
-	ModifyDomainVCPU(domain libvirt.Domain, vcpus uint16, live bool) error
+	RenameDomain(domain libvirt.Domain, newName string) error
+	ModifyDomainMemory(domain libvirt.Domain, memoryKB uint64, live bool) (*ResizeResult, error)
+	ModifyDomainVCPU(domain libvirt.Domain, vcpus uint16, live bool) (*ResizeResult, error)
 	SetDomainAutostart(domain libvirt.Domain, autostart bool) error
+	SetDomainBootOrder(domain libvirt.Domain, devices []string) error
+	SetDomainInterfaceBandwidth(domainName, mac string, bandwidth InterfaceBandwidth) error
+	SetDomainCPUTune(domainName string, tune CPUTune) error
+	SetDomainBlkioTune(domainName string, tune BlkioTune) error
+	SetDomainLabels(domainName string, labels map[string]string) error
+	UpdateDomainDevice(domainName string, update DeviceUpdate) (bool, error)
+	GetAllDomainStats() ([]DomainStats, error)
 
 	// Domain 磁盘操作
-	AttachDiskToDomain(domainName, volumePath, device string) error
+	AttachDiskToDomain(domainName string, config *AttachDiskConfig) (string, error)
 	DetachDiskFromDomain(domainName, device string) error
 	GetDomainDisks(domainName string) ([]DomainDisk, error)
+	EjectDomainCDROM(domainName, device string) error
+	SetDomainDiskSource(domainName, device, sourcePath, format string) error
+}
 
+// BlockJobManager 运行中 domain 的磁盘 block job（blockpull/blockcommit/blockcopy）
+// 是在线扁平化、在线迁移存储、在线快照合并的基础
+type BlockJobManager interface {
+	BlockPull(domainName, device string, bandwidthMiB uint64) error
+	BlockCommit(domainName, device string, config BlockCommitConfig) error
+	BlockCopy(domainName, device string, config BlockCopyConfig) error
+	GetBlockJob(domainName, device string) (*BlockJobInfo, error)
+	AbortBlockJob(domainName, device string, pivot bool) error
+	SetBlockJobSpeed(domainName, device string, bandwidthMiB uint64) error
+	WaitBlockJob(ctx context.Context, domainName, device string, onProgress func(*BlockJobInfo)) (*BlockJobInfo, error)
+}
+
+// GuestAgentManager QEMU Guest Agent 操作
+type GuestAgentManager interface {
+	QemuAgentCommand(domain libvirt.Domain, command string, timeout uint32, flags uint32) (string, error)
+	CheckGuestAgentAvailable(domain libvirt.Domain) (bool, error)
+	FreezeDomainFS(domainName string, mountpoints []string) (int, error)
+	ThawDomainFS(domainName string, mountpoints []string) (int, error)
+	GuestExec(domainName, path string, args []string, timeout time.Duration) (*GuestExecResult, error)
+	GetGuestOSInfo(domainName string) (*GuestOSInfo, error)
+}
+
+// MonitorManager QEMU monitor 通道（QMP），只读命令的白名单由 pkg/qmp 负责
+type MonitorManager interface {
+	QEMUMonitorCommand(domainName, command string) (string, error)
+}
+
+// ConsoleManager domain 控制台与串口输出
+type ConsoleManager interface {
+	GetDomainConsoleInfo(domain libvirt.Domain) (*ConsoleInfo, error)
+	GetDomainConsoleOutput(domain libvirt.Domain, maxBytes int64) (*ConsoleOutput, error)
+}
+
+// StorageManager 存储池与存储卷
+type StorageManager interface {
 	// Storage Pool 操作
 	GetStoragePool(poolName string) (*StoragePoolInfo, error)
 	ListStoragePools() ([]*StoragePoolInfo, error)
@@ -53,22 +128,20 @@ type LibvirtClient interface {
 	ResizeVolume(poolName, volumeName string, newSizeGB uint64) error
 	DeleteVolume(poolName, volumeName string) error
 	DeleteVolumeByPath(volumePath string) error
+}
 
-	// QEMU Guest Agent 操作
-	QemuAgentCommand(domain libvirt.Domain, command string, timeout uint32, flags uint32) (string, error)
-	CheckGuestAgentAvailable(domain libvirt.Domain) (bool, error)
-
-	// Console 操作
-	GetDomainConsoleInfo(domain libvirt.Domain) (*ConsoleInfo, error)
-
-	// Snapshot 操作
+// SnapshotManager domain 快照
+type SnapshotManager interface {
 	ListSnapshots(domainName string) ([]string, error)
 	CreateSnapshot(domainName string, snapshotXML string, flags libvirt.DomainSnapshotCreateFlags) error
 	GetSnapshotXML(domainName, snapshotName string) (*DomainSnapshotXML, error)
 	ListSnapshotXML(domainName string) ([]DomainSnapshotXML, error)
 	DeleteSnapshot(domainName, snapshotName string, flags libvirt.DomainSnapshotDeleteFlags) error
 	RevertToSnapshot(domainName, snapshotName string, flags libvirt.DomainSnapshotRevertFlags) error
+}
 
+// NetworkManager 宿主机网络接口与 libvirt 网络
+type NetworkManager interface {
 	// Network Interface 操作
 	ListInterfaces() ([]libvirt.Interface, error)
 	GetInterfaceXMLDesc(iface libvirt.Interface) (string, error)
@@ -84,19 +157,34 @@ type LibvirtClient interface {
 	StartNetwork(name string) error
 	StopNetwork(name string) error
 	SetNetworkAutostart(name string, autostart bool) error
+}
 
-	// Node Device 操作
-	ListNodeDevices(cap string) ([]libvirt.NodeDevice, error)
-	GetNodeDeviceXMLDesc(dev libvirt.NodeDevice) (string, error)
+// NWFilterManager libvirt 网络过滤器（nwfilter），安全组的规则由它在 tap 设备上下发
+type NWFilterManager interface {
+	DefineNWFilter(filter NWFilterXML) error
+	UndefineNWFilter(name string) error
+	ListNWFilters() ([]string, error)
+}
 
-	// Remote File 操作（用于远程节点）
+// RemoteManager 远程节点上的命令执行与文件读取（本地连接时部分方法不可用）
+type RemoteManager interface {
 	IsRemoteConnection() bool
 	GetConnectionURI() string
 	GetSSHTarget() (string, error)
 	ExecuteRemoteCommand(cmd string) error
 	ReadRemoteFile(path string) ([]byte, error)
 	ListRemoteFiles(dir, pattern string) ([]string, error)
+}
 
-	// Cloud-Init 操作
-	CreateCloudInitISO(outputDir, vmName, metaData, userData string) (string, error)
+// CloudInitManager cloud-init cidata 卷
+type CloudInitManager interface {
+	CreateCloudInitVolume(poolName, vmName, metaData, userData, networkConfig string) (*VolumeInfo, error)
+	ReadCloudInitUserData(isoPath string) (string, error)
 }
+
+var (
+	_ LibvirtClient = (*Client)(nil)
+	_ LibvirtClient = (*FakeLibvirt)(nil)
+	_ LibvirtClient = (*MockClient)(nil)
+	_ LibvirtClient = (*tracedClient)(nil)
+)
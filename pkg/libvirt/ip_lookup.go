@@ -4,28 +4,111 @@ import (
 	"bytes"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
 )
 
-// ResolveIPsByMAC 从 DHCP 租约和 ARP/neigh 解析给定 MAC 的 IP 列表
-func ResolveIPsByMAC(client LibvirtClient, mac string) ([]string, error) {
-	if mac == "" {
-		return nil, nil
+// DefaultLeaseCacheTTL DHCP 租约缓存的默认有效期
+const DefaultLeaseCacheTTL = 10 * time.Second
+
+// leaseCache 按节点连接 URI 缓存全部 libvirt 网络的 DHCP 租约，避免查询实例列表时为每块网卡重复拉取
+// 缓存在 TTL 到期或最早的租约过期时失效，释放租约后通过 InvalidateLeaseCache 立即失效
+var leaseCache = struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*leaseCacheEntry
+}{
+	ttl:     DefaultLeaseCacheTTL,
+	entries: make(map[string]*leaseCacheEntry),
+}
+
+// leaseCacheEntry 单个节点的租约缓存
+type leaseCacheEntry struct {
+	leases    []DHCPLease
+	expiresAt time.Time
+}
+
+// SetLeaseCacheTTL 设置 DHCP 租约缓存有效期，为 0 时不缓存
+func SetLeaseCacheTTL(ttl time.Duration) {
+	leaseCache.mu.Lock()
+	defer leaseCache.mu.Unlock()
+
+	leaseCache.ttl = ttl
+	clear(leaseCache.entries)
+}
+
+// InvalidateLeaseCache 使指定节点连接的 DHCP 租约缓存失效
+func InvalidateLeaseCache(uri string) {
+	leaseCache.mu.Lock()
+	defer leaseCache.mu.Unlock()
+
+	delete(leaseCache.entries, uri)
+}
+
+// Expired 租约在 now 时是否已过期，Expiry 为 0 的租约永不过期
+func (l DHCPLease) Expired(now time.Time) bool {
+	return l.Expiry != 0 && l.Expiry <= now.Unix()
+}
+
+// activeLeases 返回节点上全部 libvirt 网络未过期的 DHCP 租约，优先使用缓存
+func activeLeases(client interface {
+	NetworkManager
+	RemoteManager
+}) []DHCPLease {
+	uri := client.GetConnectionURI()
+	now := time.Now()
+
+	leaseCache.mu.Lock()
+	ttl := leaseCache.ttl
+	entry, ok := leaseCache.entries[uri]
+	leaseCache.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.leases
 	}
-	mac = strings.ToLower(mac)
-	ipSet := make(map[string]struct{})
 
-	// 1) 尝试读取 libvirt network DHCP leases
+	var leases []DHCPLease
+	expiresAt := now.Add(ttl)
 	networks, _ := client.ListNetworks()
 	for _, net := range networks {
-		leases, err := client.ListNetworkDHCPLeases(net)
+		networkLeases, err := client.ListNetworkDHCPLeases(net)
 		if err != nil {
 			continue
 		}
-		for _, l := range leases {
-			for _, m := range l.MACs {
-				if strings.ToLower(m) == mac && l.IP != "" {
-					ipSet[l.IP] = struct{}{}
-				}
+		for _, l := range networkLeases {
+			if l.Expired(now) {
+				continue
+			}
+			leases = append(leases, l)
+			if l.Expiry != 0 && time.Unix(l.Expiry, 0).Before(expiresAt) {
+				expiresAt = time.Unix(l.Expiry, 0)
+			}
+		}
+	}
+
+	if ttl > 0 {
+		leaseCache.mu.Lock()
+		leaseCache.entries[uri] = &leaseCacheEntry{leases: leases, expiresAt: expiresAt}
+		leaseCache.mu.Unlock()
+	}
+	return leases
+}
+
+// ResolveIPsByMAC 从 DHCP 租约和 ARP/neigh 解析给定 MAC 的 IP 列表，已过期的租约不参与解析
+func ResolveIPsByMAC(client interface {
+	NetworkManager
+	RemoteManager
+}, mac string) ([]string, error) {
+	if mac == "" {
+		return nil, nil
+	}
+	mac = strings.ToLower(mac)
+	ipSet := make(map[string]struct{})
+
+	// 1) 读取 libvirt network DHCP leases
+	for _, l := range activeLeases(client) {
+		for _, m := range l.MACs {
+			if strings.ToLower(m) == mac && l.IP != "" {
+				ipSet[l.IP] = struct{}{}
 			}
 		}
 	}
@@ -43,7 +126,27 @@ func ResolveIPsByMAC(client LibvirtClient, mac string) ([]string, error) {
 	return ips, nil
 }
 
-func lookupARPByMAC(client LibvirtClient, mac string) []string {
+// ResolveMACsByIP 从 DHCP 租约解析持有给定 IP 的网卡 MAC，已过期的租约不参与解析
+func ResolveMACsByIP(client interface {
+	NetworkManager
+	RemoteManager
+}, ip string) []string {
+	if ip == "" {
+		return nil
+	}
+	var macs []string
+	for _, l := range activeLeases(client) {
+		if l.IP != ip {
+			continue
+		}
+		for _, m := range l.MACs {
+			macs = append(macs, strings.ToLower(m))
+		}
+	}
+	return macs
+}
+
+func lookupARPByMAC(client RemoteManager, mac string) []string {
 	if client.IsRemoteConnection() {
 		if data, err := client.ReadRemoteFile("/proc/net/arp"); err == nil {
 			return parseProcNetARP(data, mac)
@@ -0,0 +1,28 @@
+package libvirt
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ValidateDirectKernelBoot 校验直接内核引导参数：initrd 与 cmdline 依赖 kernel，路径为节点上的绝对路径
+// 直接引导时 QEMU 跳过固件中的引导程序，由 -kernel/-initrd/-append 加载内核，磁盘上无需安装 bootloader
+func ValidateDirectKernelBoot(kernel, initrd, cmdline string) error {
+	if kernel == "" {
+		if initrd != "" || cmdline != "" {
+			return fmt.Errorf("initrd and kernel cmdline require a kernel")
+		}
+		return nil
+	}
+	if !filepath.IsAbs(kernel) {
+		return fmt.Errorf("kernel path must be absolute: %s", kernel)
+	}
+	if initrd != "" && !filepath.IsAbs(initrd) {
+		return fmt.Errorf("initrd path must be absolute: %s", initrd)
+	}
+	if strings.ContainsAny(cmdline, "\r\n\x00") {
+		return fmt.Errorf("kernel cmdline must not contain newlines or NUL characters")
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package libvirt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+)
+
+// jvpMetadataNamespace 域 metadata 中 JVP 自定义元素的命名空间
+const jvpMetadataNamespace = "https://github.com/jimyag/jvp/xmlns/instance/1.0"
+
+// 实例标签使用单独的命名空间，可以通过 DomainSetMetadata 整体替换而不影响创建时写入的实例属性
+const (
+	jvpLabelsNamespace = "https://github.com/jimyag/jvp/xmlns/labels/1.0"
+	jvpLabelsPrefix    = "jvpl"
+)
+
+// IP 地址族：实例网卡使用的地址族
+const (
+	AddressFamilyIPv4 = "ipv4"
+	AddressFamilyIPv6 = "ipv6"
+	AddressFamilyDual = "dual"
+)
+
+// DomainMetadata 域的 metadata 元素，原样保留其中各应用的自定义 XML，修改持久化配置时不会丢失
+type DomainMetadata struct {
+	InnerXML string `xml:",innerxml"`
+}
+
+// jvpInstanceMetadata JVP 写入域 metadata 的实例属性，libvirt 本身不使用
+type jvpInstanceMetadata struct {
+	XMLName       xml.Name `xml:"https://github.com/jimyag/jvp/xmlns/instance/1.0 instance"`
+	AddressFamily string   `xml:"https://github.com/jimyag/jvp/xmlns/instance/1.0 address-family,omitempty"`
+	MACSpoofCheck bool     `xml:"https://github.com/jimyag/jvp/xmlns/instance/1.0 mac-spoof-check,omitempty"`
+	CreatedAt     string   `xml:"https://github.com/jimyag/jvp/xmlns/instance/1.0 created-at,omitempty"` // RFC3339，UTC
+	LoginUser     string   `xml:"https://github.com/jimyag/jvp/xmlns/instance/1.0 login-user,omitempty"`
+}
+
+// jvpLabelsMetadata 域 metadata 中的实例标签，JVP 数据目录丢失时从中恢复
+type jvpLabelsMetadata struct {
+	XMLName xml.Name   `xml:"https://github.com/jimyag/jvp/xmlns/labels/1.0 labels"`
+	Labels  []jvpLabel `xml:"https://github.com/jimyag/jvp/xmlns/labels/1.0 label"`
+}
+
+// jvpLabel 单个标签
+type jvpLabel struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// newJVPMetadata 生成包含 JVP 实例属性与标签的 metadata 元素，使用 jvp 前缀以符合 libvirt 对命名空间的要求
+func newJVPMetadata(meta jvpInstanceMetadata, labels map[string]string) *DomainMetadata {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<jvp:instance xmlns:jvp="%s">`, jvpMetadataNamespace)
+	if meta.AddressFamily != "" {
+		buf.WriteString("<jvp:address-family>")
+		_ = xml.EscapeText(&buf, []byte(meta.AddressFamily))
+		buf.WriteString("</jvp:address-family>")
+	}
+	if meta.MACSpoofCheck {
+		buf.WriteString("<jvp:mac-spoof-check>true</jvp:mac-spoof-check>")
+	}
+	if meta.CreatedAt != "" {
+		buf.WriteString("<jvp:created-at>")
+		_ = xml.EscapeText(&buf, []byte(meta.CreatedAt))
+		buf.WriteString("</jvp:created-at>")
+	}
+	if meta.LoginUser != "" {
+		buf.WriteString("<jvp:login-user>")
+		_ = xml.EscapeText(&buf, []byte(meta.LoginUser))
+		buf.WriteString("</jvp:login-user>")
+	}
+	buf.WriteString("</jvp:instance>")
+	if len(labels) > 0 {
+		buf.WriteString(marshalJVPLabels(labels, jvpLabelsPrefix+":"))
+	}
+	return &DomainMetadata{InnerXML: buf.String()}
+}
+
+// marshalJVPLabels 生成标签元素，key 排序保证输出稳定
+// prefix 非空时带命名空间声明（直接写入域 XML），为空时生成 DomainSetMetadata 所需的不带命名空间的片段
+func marshalJVPLabels(labels map[string]string, prefix string) string {
+	var buf bytes.Buffer
+	if prefix != "" {
+		fmt.Fprintf(&buf, `<%slabels xmlns:%s="%s">`, prefix, jvpLabelsPrefix, jvpLabelsNamespace)
+	} else {
+		buf.WriteString("<labels>")
+	}
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		fmt.Fprintf(&buf, `<%slabel key="`, prefix)
+		_ = xml.EscapeText(&buf, []byte(key))
+		buf.WriteString(`" value="`)
+		_ = xml.EscapeText(&buf, []byte(labels[key]))
+		buf.WriteString(`"/>`)
+	}
+	fmt.Fprintf(&buf, "</%slabels>", prefix)
+	return buf.String()
+}
+
+// jvpInstance 从 metadata 中解析 JVP 实例属性，不存在或解析失败时返回零值
+func (m *DomainMetadata) jvpInstance() jvpInstanceMetadata {
+	var meta jvpInstanceMetadata
+	if !m.decodeElement(jvpMetadataNamespace, "instance", &meta) {
+		return jvpInstanceMetadata{}
+	}
+	return meta
+}
+
+// jvpLabels 从 metadata 中解析实例标签，不存在或解析失败时返回 nil
+func (m *DomainMetadata) jvpLabels() map[string]string {
+	var meta jvpLabelsMetadata
+	if !m.decodeElement(jvpLabelsNamespace, "labels", &meta) || len(meta.Labels) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(meta.Labels))
+	for _, label := range meta.Labels {
+		labels[label.Key] = label.Value
+	}
+	return labels
+}
+
+// decodeElement 解析 metadata 中指定命名空间与名称的元素，元素不存在或解析失败时返回 false
+func (m *DomainMetadata) decodeElement(space, local string, v any) bool {
+	if m == nil || m.InnerXML == "" {
+		return false
+	}
+	decoder := xml.NewDecoder(bytes.NewReader([]byte(m.InnerXML)))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Space != space || start.Name.Local != local {
+			continue
+		}
+		return decoder.DecodeElement(v, &start) == nil
+	}
+}
+
+// createdAt 解析 metadata 中记录的创建时间，未记录或格式错误时返回 nil
+func (m jvpInstanceMetadata) createdAt() *time.Time {
+	if m.CreatedAt == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, m.CreatedAt)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// SetDomainLabels 整体替换域 metadata 中的实例标签，labels 为空时删除标签元素
+// 同时修改持久化配置与运行中（含暂停）域的 live 配置，不需要重启实例
+func (c *Client) SetDomainLabels(domainName string, labels map[string]string) error {
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return fmt.Errorf("lookup domain: %w", err)
+	}
+
+	unlock := c.lockDomain(domain)
+	defer unlock()
+
+	flags := libvirt.DomainAffectConfig
+	if active, err := c.conn.DomainIsActive(domain); err == nil && active == 1 {
+		flags |= libvirt.DomainAffectLive
+	}
+	var metadata libvirt.OptString
+	if len(labels) > 0 {
+		metadata = libvirt.OptString{marshalJVPLabels(labels, "")}
+	}
+	if err := c.conn.DomainSetMetadata(domain, int32(libvirt.DomainMetadataElement), metadata,
+		libvirt.OptString{jvpLabelsPrefix}, libvirt.OptString{jvpLabelsNamespace}, flags); err != nil {
+		return fmt.Errorf("set domain labels: %w", err)
+	}
+	return nil
+}
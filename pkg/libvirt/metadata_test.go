@@ -0,0 +1,21 @@
+package libvirt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJVPMetadataRoundTrip(t *testing.T) {
+	metadata := newJVPMetadata(jvpInstanceMetadata{
+		AddressFamily: AddressFamilyDual,
+		CreatedAt:     "2026-01-15T10:07:00Z",
+		LoginUser:     "alice",
+	}, map[string]string{"env": "prod"})
+
+	meta := metadata.jvpInstance()
+	assert.Equal(t, AddressFamilyDual, meta.AddressFamily)
+	assert.Equal(t, "alice", meta.LoginUser)
+	assert.NotNil(t, meta.createdAt())
+	assert.Equal(t, map[string]string{"env": "prod"}, metadata.jvpLabels())
+}
@@ -1,6 +1,9 @@
 package libvirt
 
 import (
+	"context"
+	"time"
+
 	"github.com/digitalocean/go-libvirt"
 	"github.com/stretchr/testify/mock"
 )
@@ -17,6 +20,11 @@ func (m *MockClient) GetHostname() (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockClient) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
 func (m *MockClient) GetLibvirtVersion() (string, error) {
 	args := m.Called()
 	return args.String(0), args.Error(1)
@@ -30,14 +38,28 @@ func (m *MockClient) GetNodeInfo() (*NodeInfo, error) {
 	return args.Get(0).(*NodeInfo), args.Error(1)
 }
 
-func (m *MockClient) GetCapabilities() (string, error) {
+func (m *MockClient) GetNUMACells() ([]NUMACell, error) {
 	args := m.Called()
-	return args.String(0), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]NUMACell), args.Error(1)
 }
 
-func (m *MockClient) GetSysinfo() (string, error) {
+func (m *MockClient) GetCapabilities() (*CapabilitiesXML, error) {
 	args := m.Called()
-	return args.String(0), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*CapabilitiesXML), args.Error(1)
+}
+
+func (m *MockClient) GetSysinfo() (*SysinfoXML, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*SysinfoXML), args.Error(1)
 }
 
 // Domain 操作
@@ -103,27 +125,91 @@ func (m *MockClient) DeleteDomain(domain libvirt.Domain, flags libvirt.DomainUnd
 	return args.Error(0)
 }
 
-func (m *MockClient) ModifyDomainMemory(domain libvirt.Domain, memoryKB uint64, live bool) error {
+func (m *MockClient) ModifyDomainMemory(domain libvirt.Domain, memoryKB uint64, live bool) (*ResizeResult, error) {
 	args := m.Called(domain, memoryKB, live)
-	return args.Error(0)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ResizeResult), args.Error(1)
 }
 
-func (m *MockClient) ModifyDomainVCPU(domain libvirt.Domain, vcpus uint16, live bool) error {
+func (m *MockClient) ModifyDomainVCPU(domain libvirt.Domain, vcpus uint16, live bool) (*ResizeResult, error) {
 	args := m.Called(domain, vcpus, live)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ResizeResult), args.Error(1)
+}
+
+func (m *MockClient) SetDomainAutostart(domain libvirt.Domain, autostart bool) error {
+	args := m.Called(domain, autostart)
 	return args.Error(0)
 }
 
-// Domain 磁盘操作
-func (m *MockClient) AttachDiskToDomain(domainName, volumePath, device string) error {
-	args := m.Called(domainName, volumePath, device)
+func (m *MockClient) RenameDomain(domain libvirt.Domain, newName string) error {
+	args := m.Called(domain, newName)
+	return args.Error(0)
+}
+
+func (m *MockClient) SetDomainBootOrder(domain libvirt.Domain, devices []string) error {
+	args := m.Called(domain, devices)
+	return args.Error(0)
+}
+
+func (m *MockClient) SetDomainInterfaceBandwidth(domainName, mac string, bandwidth InterfaceBandwidth) error {
+	args := m.Called(domainName, mac, bandwidth)
+	return args.Error(0)
+}
+
+func (m *MockClient) SetDomainCPUTune(domainName string, tune CPUTune) error {
+	args := m.Called(domainName, tune)
 	return args.Error(0)
 }
 
+func (m *MockClient) SetDomainBlkioTune(domainName string, tune BlkioTune) error {
+	args := m.Called(domainName, tune)
+	return args.Error(0)
+}
+
+func (m *MockClient) SetDomainLabels(domainName string, labels map[string]string) error {
+	args := m.Called(domainName, labels)
+	return args.Error(0)
+}
+
+func (m *MockClient) UpdateDomainDevice(domainName string, update DeviceUpdate) (bool, error) {
+	args := m.Called(domainName, update)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockClient) GetAllDomainStats() ([]DomainStats, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]DomainStats), args.Error(1)
+}
+
+// Domain 磁盘操作
+func (m *MockClient) AttachDiskToDomain(domainName string, config *AttachDiskConfig) (string, error) {
+	args := m.Called(domainName, config)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockClient) DetachDiskFromDomain(domainName, device string) error {
 	args := m.Called(domainName, device)
 	return args.Error(0)
 }
 
+func (m *MockClient) EjectDomainCDROM(domainName, device string) error {
+	args := m.Called(domainName, device)
+	return args.Error(0)
+}
+
+func (m *MockClient) SetDomainDiskSource(domainName, device, sourcePath, format string) error {
+	args := m.Called(domainName, device, sourcePath, format)
+	return args.Error(0)
+}
+
 func (m *MockClient) GetDomainDisks(domainName string) ([]DomainDisk, error) {
 	args := m.Called(domainName)
 	if args.Get(0) == nil {
@@ -245,6 +331,37 @@ func (m *MockClient) CheckGuestAgentAvailable(domain libvirt.Domain) (bool, erro
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockClient) FreezeDomainFS(domainName string, mountpoints []string) (int, error) {
+	args := m.Called(domainName, mountpoints)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockClient) ThawDomainFS(domainName string, mountpoints []string) (int, error) {
+	args := m.Called(domainName, mountpoints)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockClient) GuestExec(domainName, path string, args []string, timeout time.Duration) (*GuestExecResult, error) {
+	ret := m.Called(domainName, path, args, timeout)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*GuestExecResult), ret.Error(1)
+}
+
+func (m *MockClient) GetGuestOSInfo(domainName string) (*GuestOSInfo, error) {
+	args := m.Called(domainName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*GuestOSInfo), args.Error(1)
+}
+
+func (m *MockClient) QEMUMonitorCommand(domainName, command string) (string, error) {
+	args := m.Called(domainName, command)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockClient) GetDomainConsoleInfo(domain libvirt.Domain) (*ConsoleInfo, error) {
 	args := m.Called(domain)
 	if args.Get(0) == nil {
@@ -253,6 +370,14 @@ func (m *MockClient) GetDomainConsoleInfo(domain libvirt.Domain) (*ConsoleInfo,
 	return args.Get(0).(*ConsoleInfo), args.Error(1)
 }
 
+func (m *MockClient) GetDomainConsoleOutput(domain libvirt.Domain, maxBytes int64) (*ConsoleOutput, error) {
+	args := m.Called(domain, maxBytes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ConsoleOutput), args.Error(1)
+}
+
 // Snapshot 操作
 func (m *MockClient) ListSnapshots(domainName string) ([]string, error) {
 	args := m.Called(domainName)
@@ -307,6 +432,91 @@ func (m *MockClient) GetInterfaceXMLDesc(iface libvirt.Interface) (string, error
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockClient) ListNetworkDHCPLeases(networkName string) ([]DHCPLease, error) {
+	args := m.Called(networkName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]DHCPLease), args.Error(1)
+}
+
+func (m *MockClient) ListNetworks() ([]string, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// Network 管理
+func (m *MockClient) ListNetworksInfo() ([]NetworkInfo, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]NetworkInfo), args.Error(1)
+}
+
+func (m *MockClient) GetNetwork(name string) (*NetworkInfo, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*NetworkInfo), args.Error(1)
+}
+
+func (m *MockClient) GetNetworkXMLDesc(name string) (string, error) {
+	args := m.Called(name)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockClient) CreateNetwork(config NetworkConfig) (*NetworkInfo, error) {
+	args := m.Called(config)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*NetworkInfo), args.Error(1)
+}
+
+func (m *MockClient) DeleteNetwork(name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
+
+func (m *MockClient) StartNetwork(name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
+
+func (m *MockClient) StopNetwork(name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
+
+func (m *MockClient) SetNetworkAutostart(name string, autostart bool) error {
+	args := m.Called(name, autostart)
+	return args.Error(0)
+}
+
+// NWFilter 操作
+func (m *MockClient) DefineNWFilter(filter NWFilterXML) error {
+	args := m.Called(filter)
+	return args.Error(0)
+}
+
+func (m *MockClient) UndefineNWFilter(name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
+
+func (m *MockClient) ListNWFilters() ([]string, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
 // Node Device 操作
 func (m *MockClient) ListNodeDevices(cap string) ([]libvirt.NodeDevice, error) {
 	args := m.Called(cap)
@@ -359,8 +569,16 @@ func (m *MockClient) ListRemoteFiles(dir, pattern string) ([]string, error) {
 }
 
 // Cloud-Init 操作
-func (m *MockClient) CreateCloudInitISO(outputDir, vmName, metaData, userData string) (string, error) {
-	args := m.Called(outputDir, vmName, metaData, userData)
+func (m *MockClient) CreateCloudInitVolume(poolName, vmName, metaData, userData, networkConfig string) (*VolumeInfo, error) {
+	args := m.Called(poolName, vmName, metaData, userData, networkConfig)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*VolumeInfo), args.Error(1)
+}
+
+func (m *MockClient) ReadCloudInitUserData(isoPath string) (string, error) {
+	args := m.Called(isoPath)
 	return args.String(0), args.Error(1)
 }
 
@@ -369,3 +587,45 @@ func (m *MockClient) CreateCloudInitISO(outputDir, vmName, metaData, userData st
 func NewMockClient() *MockClient {
 	return &MockClient{}
 }
+
+// Block job 操作
+func (m *MockClient) BlockPull(domainName, device string, bandwidthMiB uint64) error {
+	args := m.Called(domainName, device, bandwidthMiB)
+	return args.Error(0)
+}
+
+func (m *MockClient) BlockCommit(domainName, device string, config BlockCommitConfig) error {
+	args := m.Called(domainName, device, config)
+	return args.Error(0)
+}
+
+func (m *MockClient) BlockCopy(domainName, device string, config BlockCopyConfig) error {
+	args := m.Called(domainName, device, config)
+	return args.Error(0)
+}
+
+func (m *MockClient) GetBlockJob(domainName, device string) (*BlockJobInfo, error) {
+	args := m.Called(domainName, device)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*BlockJobInfo), args.Error(1)
+}
+
+func (m *MockClient) AbortBlockJob(domainName, device string, pivot bool) error {
+	args := m.Called(domainName, device, pivot)
+	return args.Error(0)
+}
+
+func (m *MockClient) SetBlockJobSpeed(domainName, device string, bandwidthMiB uint64) error {
+	args := m.Called(domainName, device, bandwidthMiB)
+	return args.Error(0)
+}
+
+func (m *MockClient) WaitBlockJob(ctx context.Context, domainName, device string, onProgress func(*BlockJobInfo)) (*BlockJobInfo, error) {
+	args := m.Called(ctx, domainName, device, onProgress)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*BlockJobInfo), args.Error(1)
+}
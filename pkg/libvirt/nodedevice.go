@@ -72,6 +72,84 @@ func ParseNodeDeviceXML(xmlData string) (*NodeDeviceXML, error) {
 	return &device, nil
 }
 
+// InterfaceXML 宿主机网络接口 XML 结构（virInterface）
+// Source: https://libvirt.org/formatnode.html
+type InterfaceXML struct {
+	XMLName   xml.Name            `xml:"interface"`
+	Type      string              `xml:"type,attr"` // ethernet, bridge, bond, vlan
+	Name      string              `xml:"name,attr"`
+	MAC       *InterfaceMAC       `xml:"mac"`
+	MTU       *InterfaceMTU       `xml:"mtu"`
+	Link      *NodeDeviceLink     `xml:"link"`
+	Protocols []InterfaceProtocol `xml:"protocol"`
+	Bridge    *InterfaceBridge    `xml:"bridge"`
+	Bond      *InterfaceBond      `xml:"bond"`
+	VLAN      *InterfaceVLAN      `xml:"vlan"`
+}
+
+// InterfaceMAC 接口 MAC 地址
+type InterfaceMAC struct {
+	Address string `xml:"address,attr"`
+}
+
+// InterfaceMTU 接口 MTU
+type InterfaceMTU struct {
+	Size int `xml:"size,attr"`
+}
+
+// InterfaceProtocol 接口协议配置（ipv4 / ipv6）
+type InterfaceProtocol struct {
+	Family string        `xml:"family,attr"`
+	IPs    []InterfaceIP `xml:"ip"`
+}
+
+// InterfaceIP 接口 IP 地址
+type InterfaceIP struct {
+	Address string `xml:"address,attr"`
+	Prefix  int    `xml:"prefix,attr"`
+}
+
+// InterfaceBridge 网桥配置及成员接口
+type InterfaceBridge struct {
+	STP        string         `xml:"stp,attr"`
+	Interfaces []InterfaceXML `xml:"interface"`
+}
+
+// InterfaceBond bond 配置及成员接口
+type InterfaceBond struct {
+	Mode       string         `xml:"mode,attr"`
+	Interfaces []InterfaceXML `xml:"interface"`
+}
+
+// InterfaceVLAN VLAN 配置，Interface 为父接口
+type InterfaceVLAN struct {
+	Tag       int           `xml:"tag,attr"`
+	Interface *InterfaceXML `xml:"interface"`
+}
+
+// ParseInterfaceXML 解析宿主机网络接口 XML
+func ParseInterfaceXML(xmlData string) (*InterfaceXML, error) {
+	var iface InterfaceXML
+	if err := xml.Unmarshal([]byte(xmlData), &iface); err != nil {
+		return nil, err
+	}
+	return &iface, nil
+}
+
+// CIDRs 返回接口上配置的地址段（如 192.168.1.10/24）
+func (i *InterfaceXML) CIDRs() []string {
+	var cidrs []string
+	for _, proto := range i.Protocols {
+		for _, ip := range proto.IPs {
+			if ip.Address == "" {
+				continue
+			}
+			cidrs = append(cidrs, fmt.Sprintf("%s/%d", ip.Address, ip.Prefix))
+		}
+	}
+	return cidrs
+}
+
 // IsPCIDevice 判断是否为 PCI 设备
 func (d *NodeDeviceXML) IsPCIDevice() bool {
 	return d.Capability.Type == "pci"
@@ -0,0 +1,163 @@
+package libvirt
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// NUMA 内存绑定模式（numatune memory mode）
+const (
+	NUMAModeStrict      = "strict"      // 只从指定 cell 分配内存，不足时分配失败
+	NUMAModePreferred   = "preferred"   // 优先从指定 cell 分配，不足时回退到其他 cell
+	NUMAModeRestrictive = "restrictive" // 通过 cgroup 限制内存只能从指定 cell 分配，运行中可迁移
+)
+
+// NUMATune 实例的 NUMA 绑定：vCPU 只在指定 cell 的 CPU 上调度，内存按 Mode 从该 cell 分配
+type NUMATune struct {
+	Node uint32 `json:"node"`           // 绑定的 NUMA cell ID
+	Mode string `json:"mode,omitempty"` // 内存绑定模式：strict（默认）/ preferred / restrictive
+}
+
+// Validate 校验 NUMA 绑定模式
+func (t *NUMATune) Validate() error {
+	switch t.Mode {
+	case "", NUMAModeStrict, NUMAModePreferred, NUMAModeRestrictive:
+		return nil
+	default:
+		return fmt.Errorf("unsupported numa mode: %s (must be strict, preferred or restrictive)", t.Mode)
+	}
+}
+
+// toXML 转换为 numatune 元素
+func (t *NUMATune) toXML() *DomainNUMATune {
+	if t == nil {
+		return nil
+	}
+	mode := t.Mode
+	if mode == "" {
+		mode = NUMAModeStrict
+	}
+	return &DomainNUMATune{
+		Memory: &DomainNUMATuneMemory{Mode: mode, Nodeset: strconv.FormatUint(uint64(t.Node), 10)},
+	}
+}
+
+// numaTuneFromXML 从 numatune 元素解析单 cell 绑定，绑定多个 cell 或自动放置时返回 nil
+func numaTuneFromXML(t *DomainNUMATune) *NUMATune {
+	if t == nil || t.Memory == nil {
+		return nil
+	}
+	node, err := strconv.ParseUint(t.Memory.Nodeset, 10, 32)
+	if err != nil {
+		return nil
+	}
+	return &NUMATune{Node: uint32(node), Mode: t.Memory.Mode}
+}
+
+// NUMACell 宿主机 NUMA cell 的 CPU 与内存
+type NUMACell struct {
+	ID           uint32   `json:"id"`             // cell ID
+	CPUs         []uint32 `json:"cpus"`           // cell 内的逻辑 CPU
+	MemoryKB     uint64   `json:"memory_kb"`      // cell 内存总量（KB）
+	FreeMemoryKB uint64   `json:"free_memory_kb"` // cell 当前空闲内存（KB）
+}
+
+// numaCellsFromCapabilities 从 capabilities 的 host topology 解析 NUMA cell（不含空闲内存）
+func numaCellsFromCapabilities(caps *CapabilitiesXML) []NUMACell {
+	cells := make([]NUMACell, 0, len(caps.Host.Topology.Cells.Cells))
+	for _, c := range caps.Host.Topology.Cells.Cells {
+		id, err := strconv.ParseUint(c.ID, 10, 32)
+		if err != nil {
+			continue
+		}
+		cell := NUMACell{ID: uint32(id)}
+		if memory, err := strconv.ParseUint(strings.TrimSpace(c.Memory.Value), 10, 64); err == nil {
+			cell.MemoryKB = memoryToKB(memory, c.Memory.Unit)
+		}
+		for _, cpu := range c.CPUs.CPUs {
+			if cpuID, err := strconv.ParseUint(cpu.ID, 10, 32); err == nil {
+				cell.CPUs = append(cell.CPUs, uint32(cpuID))
+			}
+		}
+		cells = append(cells, cell)
+	}
+	return cells
+}
+
+// memoryToKB 把 capabilities 中带单位的内存转换为 KB，libvirt 默认单位为 KiB
+func memoryToKB(value uint64, unit string) uint64 {
+	switch strings.ToLower(unit) {
+	case "b", "bytes":
+		return value / 1024
+	case "m", "mib":
+		return value * 1024
+	case "g", "gib":
+		return value * 1024 * 1024
+	default:
+		return value
+	}
+}
+
+// formatCPUSet 把 CPU 列表格式化为 libvirt cpuset 语法（如 0-3,8-11）
+func formatCPUSet(cpus []uint32) string {
+	sorted := slices.Clone(cpus)
+	slices.Sort(sorted)
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.FormatUint(uint64(sorted[i]), 10))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// GetNUMACells 读取宿主机 NUMA 拓扑以及每个 cell 的空闲内存
+func (c *Client) GetNUMACells() ([]NUMACell, error) {
+	caps, err := c.GetCapabilities()
+	if err != nil {
+		return nil, err
+	}
+	cells := numaCellsFromCapabilities(caps)
+	if len(cells) == 0 {
+		return cells, nil
+	}
+
+	maxID := uint32(0)
+	for _, cell := range cells {
+		maxID = max(maxID, cell.ID)
+	}
+	free, err := c.conn.NodeGetCellsFreeMemory(0, int32(maxID)+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cells free memory: %w", err)
+	}
+	for i := range cells {
+		if int(cells[i].ID) < len(free) {
+			cells[i].FreeMemoryKB = free[cells[i].ID] / 1024 // NodeGetCellsFreeMemory 返回字节
+		}
+	}
+	return cells, nil
+}
+
+// numaCellCPUSet 获取 cell 的 cpuset，用于把 vCPU 限定在该 cell 上调度
+func (c *Client) numaCellCPUSet(node uint32) (string, error) {
+	caps, err := c.GetCapabilities()
+	if err != nil {
+		return "", err
+	}
+	for _, cell := range numaCellsFromCapabilities(caps) {
+		if cell.ID == node && len(cell.CPUs) > 0 {
+			return formatCPUSet(cell.CPUs), nil
+		}
+	}
+	return "", fmt.Errorf("numa cell %d not found on host", node)
+}
@@ -0,0 +1,118 @@
+package libvirt
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+
+	"github.com/digitalocean/go-libvirt"
+)
+
+// ErrNWFilterInUse nwfilter 仍被运行中 domain 的网卡或其他 nwfilter 引用，libvirt 拒绝删除
+var ErrNWFilterInUse = errors.New("nwfilter is in use")
+
+// nwfilter 规则的动作与方向，方向以 guest 为准：in 为发往 guest 的流量，out 为 guest 发出的流量
+const (
+	NWFilterActionAccept = "accept"
+	NWFilterActionDrop   = "drop"
+
+	NWFilterDirectionIn    = "in"
+	NWFilterDirectionOut   = "out"
+	NWFilterDirectionInOut = "inout"
+)
+
+// NWFilterXML libvirt 网络过滤器定义（<filter>）
+// 同名过滤器重复定义时原地替换，libvirt 会为所有引用它的运行中网卡重新下发规则
+type NWFilterXML struct {
+	XMLName    xml.Name            `xml:"filter"`
+	Name       string              `xml:"name,attr"`
+	Chain      string              `xml:"chain,attr,omitempty"`
+	FilterRefs []NWFilterReference `xml:"filterref"`
+	Rules      []NWFilterRule      `xml:"rule"`
+}
+
+// NWFilterReference 引用另一个过滤器，其规则按各自的优先级合并到当前过滤器
+type NWFilterReference struct {
+	Filter string `xml:"filter,attr"`
+}
+
+// NWFilterRule 过滤规则，priority 越小越先匹配（-1000 到 1000）
+type NWFilterRule struct {
+	Action    string        `xml:"action,attr"`
+	Direction string        `xml:"direction,attr"`
+	Priority  int           `xml:"priority,attr"`
+	Match     NWFilterMatch `xml:",any"`
+}
+
+// NWFilterMatch 规则的协议匹配条件，元素名即协议：tcp、udp、icmp、all 及对应的 IPv6 版本（tcp-ipv6、icmpv6、all-ipv6 等）
+// 地址与端口属性描述报文本身：发往 guest 的报文 src 为对端、dst 为 guest
+type NWFilterMatch struct {
+	XMLName      xml.Name
+	SrcIPAddr    string `xml:"srcipaddr,attr,omitempty"`
+	SrcIPMask    string `xml:"srcipmask,attr,omitempty"` // 前缀长度
+	DstIPAddr    string `xml:"dstipaddr,attr,omitempty"`
+	DstIPMask    string `xml:"dstipmask,attr,omitempty"`
+	SrcPortStart int    `xml:"srcportstart,attr,omitempty"`
+	SrcPortEnd   int    `xml:"srcportend,attr,omitempty"`
+	DstPortStart int    `xml:"dstportstart,attr,omitempty"`
+	DstPortEnd   int    `xml:"dstportend,attr,omitempty"`
+	Type         *int   `xml:"type,attr,omitempty"` // ICMP 类型
+	Code         *int   `xml:"code,attr,omitempty"` // ICMP 代码
+	State        string `xml:"state,attr,omitempty"`
+}
+
+// NewNWFilterRule 创建匹配 protocol 的规则，地址、端口等条件由调用方填入 Match
+func NewNWFilterRule(action, direction string, priority int, protocol string) NWFilterRule {
+	return NWFilterRule{
+		Action:    action,
+		Direction: direction,
+		Priority:  priority,
+		Match:     NWFilterMatch{XMLName: xml.Name{Local: protocol}},
+	}
+}
+
+// DomainInterfaceFilterRef 网卡引用的网络过滤器，guest 启动时由 libvirt 在 tap 设备上实例化
+type DomainInterfaceFilterRef struct {
+	Filter string `xml:"filter,attr"`
+}
+
+// DefineNWFilter 定义网络过滤器，已存在时替换其规则
+func (c *Client) DefineNWFilter(filter NWFilterXML) error {
+	xmlData, err := xml.MarshalIndent(filter, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal nwfilter XML: %w", err)
+	}
+	if _, err := c.conn.NwfilterDefineXML(string(xmlData)); err != nil {
+		return fmt.Errorf("define nwfilter %s: %w", filter.Name, err)
+	}
+	return nil
+}
+
+// UndefineNWFilter 删除网络过滤器，仍被引用时返回 ErrNWFilterInUse
+func (c *Client) UndefineNWFilter(name string) error {
+	filter, err := c.conn.NwfilterLookupByName(name)
+	if err != nil {
+		return fmt.Errorf("lookup nwfilter %s: %w", name, err)
+	}
+	if err := c.conn.NwfilterUndefine(filter); err != nil {
+		var libvirtErr libvirt.Error
+		if errors.As(err, &libvirtErr) && libvirtErr.Code == uint32(libvirt.ErrOperationInvalid) {
+			return fmt.Errorf("undefine nwfilter %s: %w: %v", name, ErrNWFilterInUse, err)
+		}
+		return fmt.Errorf("undefine nwfilter %s: %w", name, err)
+	}
+	return nil
+}
+
+// ListNWFilters 列出节点上所有网络过滤器的名称
+func (c *Client) ListNWFilters() ([]string, error) {
+	filters, _, err := c.conn.ConnectListAllNwfilters(1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list nwfilters: %w", err)
+	}
+	names := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		names = append(names, filter.Name)
+	}
+	return names, nil
+}
@@ -0,0 +1,64 @@
+package libvirt
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// NetworkTypeOVS network_type=ovs：接入 Open vSwitch 网桥
+// 域 XML 中为 bridge 类型的 interface 加 virtualport type='openvswitch'，
+// 启动时由 libvirt 把 tap 设备加入 OVS 网桥，并在端口的 external_ids 中写入 iface-id（interfaceid）、attached-mac 与 vm-id
+const NetworkTypeOVS = "ovs"
+
+// VirtualPortOpenvSwitch Open vSwitch 端口的 virtualport 类型
+const VirtualPortOpenvSwitch = "openvswitch"
+
+// maxOVSVLANTag OVS access 端口 VLAN tag 上限（0 与 4095 保留）
+const maxOVSVLANTag = 4094
+
+// interfaceIDPattern OVS interfaceid 必须是 UUID，SDN 控制器（如 OVN 的 logical switch port）按它关联端口
+var interfaceIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ValidateOVSOptions 校验 OVS 网卡参数，networkType 不是 ovs 时不能指定 VLAN tag、interfaceid 与防 MAC 欺骗
+func ValidateOVSOptions(networkType string, vlan int, interfaceID string, macSpoofCheck bool) error {
+	if networkType != NetworkTypeOVS {
+		if vlan != 0 || interfaceID != "" || macSpoofCheck {
+			return fmt.Errorf("VLAN tag, interface ID and MAC spoof check require network type %s", NetworkTypeOVS)
+		}
+		return nil
+	}
+	if vlan < 0 || vlan > maxOVSVLANTag {
+		return fmt.Errorf("VLAN tag %d is out of range (must be between 1 and %d)", vlan, maxOVSVLANTag)
+	}
+	if interfaceID != "" && !interfaceIDPattern.MatchString(interfaceID) {
+		return fmt.Errorf("invalid interface ID %q (must be a UUID)", interfaceID)
+	}
+	return nil
+}
+
+// connectOVS 把 interface 改为接入 OVS 网桥：bridge 类型加 virtualport type='openvswitch'，
+// 未指定 interfaceid 时由 libvirt 生成
+func (iface *DomainInterface) connectOVS(bridge string, vlan int, interfaceID string) {
+	iface.Type = "bridge"
+	iface.Source = DomainInterfaceSource{Bridge: bridge}
+	iface.VirtualPort = &DomainInterfaceVirtualPort{Type: VirtualPortOpenvSwitch}
+	if interfaceID != "" {
+		iface.VirtualPort.Parameters = &DomainInterfaceVirtualPortParameters{InterfaceID: interfaceID}
+	}
+	if vlan != 0 {
+		iface.VLAN = &DomainInterfaceVLAN{Tags: []DomainInterfaceVLANTag{{ID: vlan}}}
+	}
+}
+
+// applyVirtualPort 把 interface 的 virtualport 与 VLAN 配置填入网卡信息
+func (n *NetworkInterface) applyVirtualPort(iface *DomainInterface) {
+	if iface.VirtualPort != nil {
+		n.VirtualPort = iface.VirtualPort.Type
+		if iface.VirtualPort.Parameters != nil {
+			n.InterfaceID = iface.VirtualPort.Parameters.InterfaceID
+		}
+	}
+	if iface.VLAN != nil && len(iface.VLAN.Tags) > 0 {
+		n.VLAN = iface.VLAN.Tags[0].ID
+	}
+}
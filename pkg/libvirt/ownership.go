@@ -0,0 +1,148 @@
+package libvirt
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 未配置用户与组时依次尝试的 QEMU 进程用户与组：Debian/Ubuntu 为 libvirt-qemu:kvm，RHEL 系为 qemu:qemu
+var (
+	defaultQEMUUsers  = []string{"libvirt-qemu", "qemu"}
+	defaultQEMUGroups = []string{"kvm", "qemu"}
+)
+
+// OwnershipPolicy JVP 代为创建的文件（VNC socket 目录、以 root 创建的卷）的属主修复策略
+type OwnershipPolicy struct {
+	Disabled bool   // 关闭属主修复，由 ACL、libvirt dynamic_ownership 或存储池 permissions 处理
+	User     string // QEMU 进程用户（名称或 UID），为空时依次尝试 libvirt-qemu、qemu
+	Group    string // QEMU 进程组（名称或 GID），为空时依次尝试 kvm、qemu
+}
+
+// IsZero 策略是否未配置
+func (p OwnershipPolicy) IsZero() bool {
+	return !p.Disabled && p.User == "" && p.Group == ""
+}
+
+// ownershipPolicies 全局默认策略与按连接 URI 配置的节点策略
+var ownershipPolicies = struct {
+	mu       sync.RWMutex
+	fallback OwnershipPolicy
+	byURI    map[string]OwnershipPolicy
+}{byURI: make(map[string]OwnershipPolicy)}
+
+// SetDefaultOwnershipPolicy 设置未单独配置策略的节点使用的属主修复策略
+func SetDefaultOwnershipPolicy(policy OwnershipPolicy) {
+	ownershipPolicies.mu.Lock()
+	defer ownershipPolicies.mu.Unlock()
+
+	ownershipPolicies.fallback = policy
+}
+
+// SetOwnershipPolicy 设置连接 URI 对应节点的属主修复策略，策略整体覆盖全局默认；零值表示使用全局默认
+// 应在建立连接前设置，连接时为已有 domain 准备 VNC socket 目录就会用到
+func SetOwnershipPolicy(uri string, policy OwnershipPolicy) {
+	ownershipPolicies.mu.Lock()
+	defer ownershipPolicies.mu.Unlock()
+
+	if policy.IsZero() {
+		delete(ownershipPolicies.byURI, uri)
+		return
+	}
+	ownershipPolicies.byURI[uri] = policy
+}
+
+// ownershipPolicyFor 返回连接 URI 生效的属主修复策略
+func ownershipPolicyFor(uri string) OwnershipPolicy {
+	ownershipPolicies.mu.RLock()
+	defer ownershipPolicies.mu.RUnlock()
+
+	if policy, ok := ownershipPolicies.byURI[uri]; ok {
+		return policy
+	}
+	return ownershipPolicies.fallback
+}
+
+// lookupOwner 在本机解析策略的用户与组，未配置时按默认候选依次查找
+func (p OwnershipPolicy) lookupOwner() (int, int, error) {
+	users := defaultQEMUUsers
+	if p.User != "" {
+		users = []string{p.User}
+	}
+	groups := defaultQEMUGroups
+	if p.Group != "" {
+		groups = []string{p.Group}
+	}
+
+	uid, err := lookupID(users, func(name string) (string, error) {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		return u.Uid, nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("lookup qemu user: %w", err)
+	}
+	gid, err := lookupID(groups, func(name string) (string, error) {
+		g, err := user.LookupGroup(name)
+		if err != nil {
+			return "", err
+		}
+		return g.Gid, nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("lookup qemu group: %w", err)
+	}
+	return uid, gid, nil
+}
+
+// lookupID 返回第一个可解析的候选名称的 ID，候选本身是数字时直接使用
+func lookupID(candidates []string, lookup func(string) (string, error)) (int, error) {
+	var lastErr error
+	for _, name := range candidates {
+		if id, err := strconv.Atoi(name); err == nil {
+			return id, nil
+		}
+		idStr, err := lookup(name)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			return 0, fmt.Errorf("parse id of %s: %w", name, err)
+		}
+		return id, nil
+	}
+	return 0, lastErr
+}
+
+// chownCommand 生成在远程节点上修改属主的 shell 命令，未配置用户与组时依次尝试默认候选，失败不报错
+func (p OwnershipPolicy) chownCommand(path string) string {
+	if p.User != "" || p.Group != "" {
+		owner := p.User
+		if p.Group != "" {
+			owner += ":" + p.Group
+		}
+		return fmt.Sprintf("chown '%s' '%s' 2>/dev/null || true", owner, path)
+	}
+
+	attempts := make([]string, 0, len(defaultQEMUUsers))
+	for i, u := range defaultQEMUUsers {
+		attempts = append(attempts, fmt.Sprintf("chown %s:%s '%s' 2>/dev/null", u, defaultQEMUGroups[i], path))
+	}
+	return strings.Join(attempts, " || ") + " || true"
+}
+
+// chownLocal 在本机按策略修改文件属主
+func (p OwnershipPolicy) chownLocal(path string) error {
+	uid, gid, err := p.lookupOwner()
+	if err != nil {
+		return err
+	}
+	return os.Chown(path, uid, gid)
+}
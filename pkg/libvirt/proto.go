@@ -10,10 +10,11 @@ type DomainXML struct {
 
 	// Basic metadata
 	// Source: https://libvirt.org/formatdomain.html#general-metadata
-	Name        string `xml:"name"`
-	UUID        string `xml:"uuid,omitempty"`        // RFC 4122 compliant UUID
-	Title       string `xml:"title,omitempty"`       // Short description without newlines
-	Description string `xml:"description,omitempty"` // Detailed human-readable description
+	Name        string          `xml:"name"`
+	UUID        string          `xml:"uuid,omitempty"`        // RFC 4122 compliant UUID
+	Title       string          `xml:"title,omitempty"`       // Short description without newlines
+	Description string          `xml:"description,omitempty"` // Detailed human-readable description
+	Metadata    *DomainMetadata `xml:"metadata,omitempty"`    // Application specific metadata, JVP stores instance attributes under its own namespace
 
 	// Memory configuration
 	// Source: https://libvirt.org/formatdomain.html#memory-allocation
@@ -22,13 +23,30 @@ type DomainXML struct {
 
 	// CPU configuration
 	// Source: https://libvirt.org/formatdomain.html#cpu-model-and-topology
-	VCPU DomainVCPU `xml:"vcpu"`
-	CPU  *DomainCPU `xml:"cpu,omitempty"` // Detailed CPU requirements (model, topology, features)
+	VCPU      DomainVCPU `xml:"vcpu"`
+	IOThreads int        `xml:"iothreads,omitempty"` // Number of IOThreads for disk / controller I/O offload
+	CPU       *DomainCPU `xml:"cpu,omitempty"`       // Detailed CPU requirements (model, topology, features)
+
+	// CPU tuning
+	// Source: https://libvirt.org/formatdomain.html#cpu-tuning
+	CPUTune *DomainCPUTune `xml:"cputune,omitempty"` // cgroup cpu shares, period and quota
+
+	// Block I/O tuning
+	// Source: https://libvirt.org/formatdomain.html#block-i-o-tuning
+	BlkioTune *DomainBlkioTune `xml:"blkiotune,omitempty"` // cgroup blkio/io weight of the whole domain
+
+	// NUMA node tuning
+	// Source: https://libvirt.org/formatdomain.html#numa-node-tuning
+	NUMATune *DomainNUMATune `xml:"numatune,omitempty"` // host NUMA nodes the guest memory is allocated from
 
 	// OS and boot
 	// Source: https://libvirt.org/formatdomain.html#operating-system-booting
 	OS DomainOS `xml:"os"`
 
+	// System information passed to the guest (fw_cfg entries or SMBIOS system strings)
+	// Source: https://libvirt.org/formatdomain.html#smbios-system-information
+	SysInfo *DomainSysInfo `xml:"sysinfo,omitempty"` // e.g. Ignition config for Fedora CoreOS/Flatcar, cloud-init NoCloud seed
+
 	// Hypervisor features
 	// Source: https://libvirt.org/formatdomain.html#hypervisor-features
 	Features *DomainFeatures `xml:"features,omitempty"` // ACPI, APIC, PAE, etc.
@@ -46,6 +64,39 @@ type DomainXML struct {
 	// Devices
 	// Source: https://libvirt.org/formatdomain.html#devices
 	Devices DomainDevices `xml:"devices"`
+
+	// QEMU command-line passthrough (libvirt marks the domain as tainted)
+	// Source: https://libvirt.org/drvqemu.html#pass-through-of-arbitrary-qemu-commands
+	QEMUCommandline *DomainQEMUCommandline `xml:"http://libvirt.org/schemas/domain/qemu/1.0 commandline,omitempty"`
+}
+
+// DomainSysInfo represents the sysinfo element of type fwcfg or smbios
+type DomainSysInfo struct {
+	Type    string               `xml:"type,attr"` // fwcfg, smbios
+	Entries []DomainSysInfoEntry `xml:"entry"`
+	System  *DomainSysInfoSystem `xml:"system,omitempty"` // SMBIOS type 1 strings
+}
+
+// DomainSysInfoSystem represents the SMBIOS system block, e.g. the serial entry
+type DomainSysInfoSystem struct {
+	Entries []DomainSysInfoEntry `xml:"entry"`
+}
+
+// DomainSysInfoEntry represents a single fw_cfg blob (read from File or given inline) or SMBIOS string
+type DomainSysInfoEntry struct {
+	Name  string `xml:"name,attr"`           // fw_cfg key, e.g. opt/com.coreos/config
+	File  string `xml:"file,attr,omitempty"` // host file providing the blob
+	Value string `xml:",chardata"`
+}
+
+// DomainQEMUCommandline represents extra QEMU arguments in the qemu XML namespace
+type DomainQEMUCommandline struct {
+	Args []DomainQEMUArg `xml:"arg"`
+}
+
+// DomainQEMUArg represents a single QEMU command-line argument
+type DomainQEMUArg struct {
+	Value string `xml:"value,attr"`
 }
 
 // DomainMemory represents memory configuration
@@ -57,13 +108,49 @@ type DomainMemory struct {
 // DomainVCPU represents virtual CPU configuration
 type DomainVCPU struct {
 	Placement string `xml:"placement,attr"`
-	Value     int    `xml:",chardata"`
+	CPUSet    string `xml:"cpuset,attr,omitempty"`  // vCPU 可调度的宿主机 CPU（如 0-3,8-11），为空时不限制
+	Current   int    `xml:"current,attr,omitempty"` // 当前在线 vCPU 数，为空时等于 Value
+	Value     int    `xml:",chardata"`              // vCPU 上限（热插上限）
+}
+
+// DomainCPUTune represents CPU tuning (cgroup cpu controller)
+// period/quota in microseconds, quota < 0 means unlimited
+type DomainCPUTune struct {
+	Shares uint64 `xml:"shares,omitempty"`
+	Period uint64 `xml:"period,omitempty"`
+	Quota  int64  `xml:"quota,omitempty"`
+}
+
+// DomainBlkioTune represents domain-wide block I/O tuning
+type DomainBlkioTune struct {
+	Weight uint `xml:"weight,omitempty"`
+}
+
+// DomainNUMATune represents host NUMA node tuning
+type DomainNUMATune struct {
+	Memory *DomainNUMATuneMemory `xml:"memory,omitempty"`
+}
+
+// DomainNUMATuneMemory represents the memory allocation policy
+type DomainNUMATuneMemory struct {
+	Mode    string `xml:"mode,attr,omitempty"`    // strict, preferred, interleave or restrictive
+	Nodeset string `xml:"nodeset,attr,omitempty"` // host NUMA nodes, e.g. 0 or 0-1
 }
 
 // DomainOS represents operating system configuration
 type DomainOS struct {
-	Type DomainOSType `xml:"type"`
-	Boot DomainBoot   `xml:"boot"`
+	Firmware string        `xml:"firmware,attr,omitempty"` // Automatic firmware selection: bios, efi
+	Type     DomainOSType  `xml:"type"`
+	Boot     *DomainBoot   `xml:"boot,omitempty"`    // Must be omitted when per-device boot order is used
+	Kernel   string        `xml:"kernel,omitempty"`  // Direct kernel boot: kernel image path on the host
+	Initrd   string        `xml:"initrd,omitempty"`  // Direct kernel boot: initramfs path on the host
+	Cmdline  string        `xml:"cmdline,omitempty"` // Direct kernel boot: kernel command line
+	SMBIOS   *DomainSMBIOS `xml:"smbios,omitempty"`  // mode=sysinfo is required for <sysinfo type='smbios'> to take effect
+}
+
+// DomainSMBIOS represents where the guest SMBIOS tables come from
+type DomainSMBIOS struct {
+	Mode string `xml:"mode,attr"` // emulate, host, sysinfo
 }
 
 // DomainOSType represents OS type details
@@ -84,7 +171,7 @@ type DomainDevices struct {
 	Emulator   string            `xml:"emulator"`
 	Disks      []DomainDisk      `xml:"disk"`
 	Interfaces []DomainInterface `xml:"interface"`
-	Graphics   DomainGraphics    `xml:"graphics"`
+	Graphics   []DomainGraphics  `xml:"graphics"`
 	Serial     DomainSerial      `xml:"serial"`
 	Console    DomainConsole     `xml:"console"`
 
@@ -97,6 +184,7 @@ type DomainDevices struct {
 	Hostdevs    []DomainHostdev    `xml:"hostdev,omitempty"`    // Host device passthrough
 	Watchdogs   []DomainWatchdog   `xml:"watchdog,omitempty"`   // Watchdog devices
 	Channels    []DomainChannel    `xml:"channel,omitempty"`    // Communication channels (guest agent)
+	RedirDevs   []DomainRedirDev   `xml:"redirdev,omitempty"`   // USB redirection over SPICE
 	MemBalloon  *DomainMemBalloon  `xml:"memballoon,omitempty"` // Memory balloon device
 	RNG         *DomainRNG         `xml:"rng,omitempty"`        // Random number generator
 	TPM         *DomainTPM         `xml:"tpm,omitempty"`        // TPM device
@@ -109,14 +197,28 @@ type DomainDisk struct {
 	Driver      DomainDiskDriver `xml:"driver"`
 	Source      DomainDiskSource `xml:"source"`
 	Target      DomainDiskTarget `xml:"target"`
-	CapacityB   uint64           `xml:"-"` // filled via StorageVolGetInfo
-	AllocationB uint64           `xml:"-"` // filled via StorageVolGetInfo
+	Serial      string           `xml:"serial,omitempty"` // Disk serial, exposed to guest via /dev/disk/by-id
+	Boot        *DomainBootOrder `xml:"boot,omitempty"`   // Per-device boot order
+	Shareable   *struct{}        `xml:"shareable"`        // Shared between domains (multi-attach), nil when not shared
+	CapacityB   uint64           `xml:"-"`                // filled via StorageVolGetInfo
+	AllocationB uint64           `xml:"-"`                // filled via StorageVolGetInfo
+}
+
+// SourcePath returns the file path for file disks or the host device path for block disks
+func (d DomainDisk) SourcePath() string {
+	if d.Source.File != "" {
+		return d.Source.File
+	}
+	return d.Source.Dev
 }
 
 // DomainDiskDriver represents disk driver configuration
 type DomainDiskDriver struct {
-	Name string `xml:"name,attr"`
-	Type string `xml:"type,attr"`
+	Name    string `xml:"name,attr"`
+	Type    string `xml:"type,attr"`
+	Cache   string `xml:"cache,attr,omitempty"`   // Cache mode: none, writethrough, writeback, directsync, unsafe
+	IO      string `xml:"io,attr,omitempty"`      // Async IO mode: native, threads, io_uring
+	Discard string `xml:"discard,attr,omitempty"` // Discard handling: unmap, ignore
 }
 
 // DomainDiskSource represents disk source configuration
@@ -124,6 +226,7 @@ type DomainDiskSource struct {
 	Pool   string `xml:"pool,attr,omitempty"`
 	Volume string `xml:"volume,attr,omitempty"`
 	File   string `xml:"file,attr,omitempty"`
+	Dev    string `xml:"dev,attr,omitempty"` // Host block device for type='block' disks
 }
 
 // DomainDiskTarget represents disk target configuration
@@ -134,11 +237,17 @@ type DomainDiskTarget struct {
 
 // DomainInterface represents a network interface
 type DomainInterface struct {
-	Type   string                `xml:"type,attr"`
-	Source DomainInterfaceSource `xml:"source"`
-	Target DomainInterfaceTarget `xml:"target"`
-	MAC    DomainInterfaceMAC    `xml:"mac"`
-	Model  DomainInterfaceModel  `xml:"model"`
+	Type      string                    `xml:"type,attr"`
+	Source    DomainInterfaceSource     `xml:"source"`
+	Target    DomainInterfaceTarget     `xml:"target"`
+	MAC       DomainInterfaceMAC        `xml:"mac"`
+	Model     DomainInterfaceModel      `xml:"model"`
+	Boot      *DomainBootOrder          `xml:"boot,omitempty"`      // Per-device boot order (PXE)
+	Bandwidth *DomainInterfaceBandwidth `xml:"bandwidth,omitempty"` // QoS
+
+	VirtualPort *DomainInterfaceVirtualPort `xml:"virtualport,omitempty"` // Open vSwitch port
+	VLAN        *DomainInterfaceVLAN        `xml:"vlan,omitempty"`        // VLAN tag of the switch port
+	FilterRef   *DomainInterfaceFilterRef   `xml:"filterref,omitempty"`   // nwfilter applied to the tap device
 }
 
 // DomainInterfaceSource represents network interface source
@@ -165,34 +274,100 @@ type DomainInterfaceModel struct {
 	Type string `xml:"type,attr"`
 }
 
+// DomainInterfaceVirtualPort represents the virtual switch port the interface is connected to
+// Source: https://libvirt.org/formatdomain.html#bridge-to-lan
+type DomainInterfaceVirtualPort struct {
+	Type       string                                `xml:"type,attr"`
+	Parameters *DomainInterfaceVirtualPortParameters `xml:"parameters,omitempty"`
+}
+
+// DomainInterfaceVirtualPortParameters represents virtualport parameters
+// interfaceid is stored as external_ids:iface-id of the Open vSwitch interface
+type DomainInterfaceVirtualPortParameters struct {
+	InterfaceID string `xml:"interfaceid,attr,omitempty"`
+}
+
+// DomainInterfaceVLAN represents VLAN tags of the switch port (access port when a single tag is set)
+type DomainInterfaceVLAN struct {
+	Tags []DomainInterfaceVLANTag `xml:"tag"`
+}
+
+// DomainInterfaceVLANTag represents a VLAN tag
+type DomainInterfaceVLANTag struct {
+	ID int `xml:"id,attr"`
+}
+
+// DomainInterfaceBandwidth represents interface QoS
+// Source: https://libvirt.org/formatnetwork.html#quality-of-service
+type DomainInterfaceBandwidth struct {
+	Inbound  *DomainBandwidthRate `xml:"inbound,omitempty"`
+	Outbound *DomainBandwidthRate `xml:"outbound,omitempty"`
+}
+
+// DomainBandwidthRate represents inbound/outbound traffic shaping
+// average/peak in KiB/s, burst in KiB
+type DomainBandwidthRate struct {
+	Average uint64 `xml:"average,attr,omitempty"`
+	Peak    uint64 `xml:"peak,attr,omitempty"`
+	Burst   uint64 `xml:"burst,attr,omitempty"`
+}
+
 // DHCPLease DHCP 租约信息（用于解析 IP）
 type DHCPLease struct {
 	IP        string   `json:"ipaddr"`
 	MACs      []string `json:"mac"`
 	Hostnames []string `json:"hostname,omitempty"`
-	Expiry    int64    `json:"expirytime,omitempty"`
+	Expiry    int64    `json:"expirytime,omitempty"` // 过期时间（Unix 秒），0 表示永不过期（如 infinite 租约）
 	ClientIDs []string `json:"clientid,omitempty"`
 	IAIDs     []string `json:"iaid,omitempty"`
 }
 
 // DomainGraphics represents graphics configuration
 type DomainGraphics struct {
-	Type     string                `xml:"type,attr"`
-	Port     int                   `xml:"port,attr,omitempty"`
-	Autoport string                `xml:"autoport,attr,omitempty"`
-	Socket   string                `xml:"socket,attr,omitempty"` // Unix socket path for VNC
-	Listen   *DomainGraphicsListen `xml:"listen,omitempty"`      // Use pointer so omitempty works correctly
+	Type        string                `xml:"type,attr"`
+	Port        int                   `xml:"port,attr,omitempty"`
+	TLSPort     int                   `xml:"tlsPort,attr,omitempty"` // SPICE TLS port, allocated when autoport is enabled
+	Autoport    string                `xml:"autoport,attr,omitempty"`
+	DefaultMode string                `xml:"defaultMode,attr,omitempty"` // SPICE channel mode: any, secure, insecure
+	Socket      string                `xml:"socket,attr,omitempty"`      // Unix socket path for VNC
+	Listen      *DomainGraphicsListen `xml:"listen,omitempty"`           // Use pointer so omitempty works correctly
 }
 
 // DomainGraphicsListen represents graphics listen configuration
 type DomainGraphicsListen struct {
-	Type string `xml:"type,attr"` // Required when listen element is present
+	Type    string `xml:"type,attr"`              // Required when listen element is present
+	Address string `xml:"address,attr,omitempty"` // Listen address when type is address
+	Socket  string `xml:"socket,attr,omitempty"`  // Unix socket path when type is socket
 }
 
 // DomainSerial represents serial device configuration
 type DomainSerial struct {
-	Type   string             `xml:"type,attr"`
-	Target DomainSerialTarget `xml:"target"`
+	Type     string              `xml:"type,attr"` // pty, file, tcp
+	Source   *DomainCharSource   `xml:"source,omitempty"`
+	Protocol *DomainCharProtocol `xml:"protocol,omitempty"`
+	Log      *DomainCharLog      `xml:"log,omitempty"`
+	Target   DomainSerialTarget  `xml:"target"`
+}
+
+// DomainCharSource represents character device source
+// Source: https://libvirt.org/formatdomain.html#character-device-backends
+type DomainCharSource struct {
+	Path    string `xml:"path,attr,omitempty"`    // file/pty path
+	Append  string `xml:"append,attr,omitempty"`  // on, off (type=file)
+	Mode    string `xml:"mode,attr,omitempty"`    // bind, connect (type=tcp)
+	Host    string `xml:"host,attr,omitempty"`    // type=tcp
+	Service string `xml:"service,attr,omitempty"` // TCP port (type=tcp)
+}
+
+// DomainCharProtocol represents character device protocol (type=tcp)
+type DomainCharProtocol struct {
+	Type string `xml:"type,attr"` // raw, telnet
+}
+
+// DomainCharLog represents character device output log file
+type DomainCharLog struct {
+	File   string `xml:"file,attr"`
+	Append string `xml:"append,attr,omitempty"` // on, off
 }
 
 // DomainSerialTarget represents serial target configuration
@@ -214,9 +389,12 @@ type DomainConsole struct {
 	Target DomainConsoleTarget `xml:"target"`
 }
 
-// DomainConsoleSource represents console source configuration (PTY path)
+// DomainConsoleSource represents console source configuration
 type DomainConsoleSource struct {
-	Path string `xml:"path,attr,omitempty"` // PTY device path (assigned at runtime)
+	Path    string `xml:"path,attr,omitempty"`    // PTY device path (assigned at runtime) or log file path
+	Mode    string `xml:"mode,attr,omitempty"`    // bind, connect (type=tcp)
+	Host    string `xml:"host,attr,omitempty"`    // type=tcp
+	Service string `xml:"service,attr,omitempty"` // TCP port (type=tcp)
 }
 
 // DomainConsoleTarget represents console target configuration
@@ -282,11 +460,17 @@ type DomainFeatures struct {
 	HyperV   *DomainHyperV         `xml:"hyperv,omitempty"`   // Hyper-V enlightenments for Windows guests
 	KVM      *DomainKVM            `xml:"kvm,omitempty"`      // KVM specific features
 	VMPort   *DomainFeatureState   `xml:"vmport,omitempty"`   // VMWare IO port emulation
+	GIC      *DomainFeatureGIC     `xml:"gic,omitempty"`      // ARM Generic Interrupt Controller
 }
 
 // DomainFeatureEnabled represents a simple enabled feature
 type DomainFeatureEnabled struct{}
 
+// DomainFeatureGIC represents ARM GIC configuration
+type DomainFeatureGIC struct {
+	Version string `xml:"version,attr,omitempty"` // 2, 3, host
+}
+
 // DomainFeatureState represents a feature with state
 type DomainFeatureState struct {
 	State string `xml:"state,attr,omitempty"` // on, off
@@ -360,9 +544,10 @@ type DomainTimerCatchUp struct {
 // DomainController represents a device controller
 // Source: https://libvirt.org/formatdomain.html#controllers
 type DomainController struct {
-	Type    string                  `xml:"type,attr"`            // usb, pci, scsi, ide, fdc, virtio-serial, ccid
+	Type    string                  `xml:"type,attr"`            // usb, pci, scsi, ide, fdc, virtio-serial, ccid, nvme
 	Index   int                     `xml:"index,attr"`           // Controller index
 	Model   string                  `xml:"model,attr,omitempty"` // Controller model
+	Serial  string                  `xml:"serial,omitempty"`     // NVMe controller serial
 	Driver  *DomainControllerDriver `xml:"driver,omitempty"`
 	Master  *DomainControllerMaster `xml:"master,omitempty"`
 	Address *DomainAddress          `xml:"address,omitempty"`
@@ -531,6 +716,13 @@ type DomainChannelTarget struct {
 	Port    int    `xml:"port,attr,omitempty"`    // Port for guestfwd
 }
 
+// DomainRedirDev represents a redirected device
+// Source: https://libvirt.org/formatdomain.html#redirected-devices
+type DomainRedirDev struct {
+	Bus  string `xml:"bus,attr"`  // usb
+	Type string `xml:"type,attr"` // spicevmc, tcp
+}
+
 // DomainMemBalloon represents memory balloon device
 // Source: https://libvirt.org/formatdomain.html#memory-balloon-device
 type DomainMemBalloon struct {
@@ -615,7 +807,27 @@ type NetworkXML struct {
 	UUID    string          `xml:"uuid,omitempty"`
 	Bridge  *NetworkBridge  `xml:"bridge,omitempty"`
 	Forward *NetworkForward `xml:"forward,omitempty"`
-	IP      *NetworkIP      `xml:"ip,omitempty"`
+	IPs     []NetworkIP     `xml:"ip,omitempty"` // one ip element per address family
+}
+
+// IPv4 returns the IPv4 configuration (ip element without family), or nil
+func (n *NetworkXML) IPv4() *NetworkIP {
+	for i := range n.IPs {
+		if n.IPs[i].Family == "" || n.IPs[i].Family == "ipv4" {
+			return &n.IPs[i]
+		}
+	}
+	return nil
+}
+
+// IPv6 returns the IPv6 configuration, or nil
+func (n *NetworkXML) IPv6() *NetworkIP {
+	for i := range n.IPs {
+		if n.IPs[i].Family == "ipv6" {
+			return &n.IPs[i]
+		}
+	}
+	return nil
 }
 
 // NetworkBridge represents network bridge configuration
@@ -632,6 +844,7 @@ type NetworkForward struct {
 
 // NetworkIP represents network IP configuration
 type NetworkIP struct {
+	Family  string       `xml:"family,attr,omitempty"`  // ipv4 (default), ipv6
 	Address string       `xml:"address,attr,omitempty"` // gateway IP
 	Netmask string       `xml:"netmask,attr,omitempty"` // subnet mask
 	Prefix  int          `xml:"prefix,attr,omitempty"`  // CIDR prefix
@@ -672,6 +885,9 @@ type NetworkInfo struct {
 	Netmask   string `json:"netmask"`    // Subnet mask
 	DHCPStart string `json:"dhcp_start"` // DHCP start IP
 	DHCPEnd   string `json:"dhcp_end"`   // DHCP end IP
+
+	IPv6Address string `json:"ipv6_address,omitempty"` // IPv6 gateway (e.g., fd00:100::1)
+	IPv6Prefix  int    `json:"ipv6_prefix,omitempty"`  // IPv6 prefix length, dnsmasq advertises RA for SLAAC when it is 64
 }
 
 // NetworkConfig represents configuration for creating a new network
@@ -684,4 +900,7 @@ type NetworkConfig struct {
 	DHCPStart string `json:"dhcp_start"` // e.g., 192.168.100.100
 	DHCPEnd   string `json:"dhcp_end"`   // e.g., 192.168.100.200
 	Autostart bool   `json:"autostart"`
+
+	IPv6Address string `json:"ipv6_address,omitempty"` // e.g., fd00:100::1 (optional, enables IPv6 with RA)
+	IPv6Prefix  int    `json:"ipv6_prefix,omitempty"`  // e.g., 64 (default 64)
 }
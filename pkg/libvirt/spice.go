@@ -0,0 +1,76 @@
+package libvirt
+
+import (
+	"fmt"
+	"net"
+)
+
+// SPICE 默认值
+const (
+	defaultSPICEListen       = "127.0.0.1"
+	defaultSPICEUSBRedirects = 2
+	maxSPICEUSBRedirects     = 4
+	spiceAgentChannelName    = "com.redhat.spice.0"
+)
+
+// SPICEConfig SPICE 图形协议配置，与 VNC 同时存在：VNC 供 Web 控制台使用，SPICE 供 remote-viewer 等桌面客户端使用
+type SPICEConfig struct {
+	Listen       string // 监听地址（默认：127.0.0.1），端口由 libvirt 自动分配
+	TLS          bool   // 是否强制所有通道使用 TLS（需要宿主机 qemu.conf 开启 spice_tls 并配置证书）
+	USBRedirects int    // USB 重定向通道数（默认：2，最多 4）
+}
+
+// Validate 校验 SPICE 配置
+func (s *SPICEConfig) Validate() error {
+	if s.Listen != "" && net.ParseIP(s.Listen) == nil {
+		return fmt.Errorf("invalid spice listen address: %s", s.Listen)
+	}
+	if s.USBRedirects < 0 || s.USBRedirects > maxSPICEUSBRedirects {
+		return fmt.Errorf("spice usb redirects must be between 0 and %d", maxSPICEUSBRedirects)
+	}
+	return nil
+}
+
+// apply 添加 SPICE graphics、vdagent 通道（剪贴板共享、分辨率自适应）与 USB 重定向设备
+func (s *SPICEConfig) apply(devices *DomainDevices) {
+	if s == nil {
+		return
+	}
+
+	listen := s.Listen
+	if listen == "" {
+		listen = defaultSPICEListen
+	}
+	graphics := DomainGraphics{
+		Type:     "spice",
+		Autoport: "yes",
+		Listen:   &DomainGraphicsListen{Type: "address", Address: listen},
+	}
+	if s.TLS {
+		graphics.DefaultMode = "secure"
+	}
+	devices.Graphics = append(devices.Graphics, graphics)
+
+	devices.Channels = append(devices.Channels, DomainChannel{
+		Type:   "spicevmc",
+		Target: &DomainChannelTarget{Type: "virtio", Name: spiceAgentChannelName},
+	})
+
+	redirects := s.USBRedirects
+	if redirects == 0 {
+		redirects = defaultSPICEUSBRedirects
+	}
+	for range redirects {
+		devices.RedirDevs = append(devices.RedirDevs, DomainRedirDev{Bus: "usb", Type: "spicevmc"})
+	}
+}
+
+// spiceGraphics 返回 domain 的 SPICE graphics，未配置时返回 nil
+func spiceGraphics(domainXML *DomainXML) *DomainGraphics {
+	for i := range domainXML.Devices.Graphics {
+		if domainXML.Devices.Graphics[i].Type == "spice" {
+			return &domainXML.Devices.Graphics[i]
+		}
+	}
+	return nil
+}
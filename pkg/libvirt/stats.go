@@ -0,0 +1,110 @@
+package libvirt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/digitalocean/go-libvirt"
+)
+
+// DomainStats domain 的累计资源统计（virConnectGetAllDomainStats）
+// CPU 时间与网络流量由 QEMU 进程累计，domain 重启后从 0 开始；未运行的 domain 只有磁盘占用
+type DomainStats struct {
+	Name              string `json:"name"`
+	UUID              string `json:"uuid"`
+	Active            bool   `json:"active"`              // QEMU 进程是否存在（运行或暂停）
+	VCPUs             uint32 `json:"vcpus"`               // 当前 vCPU 数，未运行时为 0
+	MemoryKB          uint64 `json:"memory_kb"`           // 当前内存（balloon），未运行时为 0
+	CPUTime           uint64 `json:"cpu_time"`            // 累计 CPU 时间（纳秒）
+	NetRxBytes        uint64 `json:"net_rx_bytes"`        // 全部网卡累计接收字节数
+	NetTxBytes        uint64 `json:"net_tx_bytes"`        // 全部网卡累计发送字节数
+	DiskPhysicalBytes uint64 `json:"disk_physical_bytes"` // 磁盘文件在宿主机上实际占用的字节数，不含 ISO 光盘
+	DiskCapacityBytes uint64 `json:"disk_capacity_bytes"` // 磁盘虚拟容量，不含 ISO 光盘
+}
+
+// domainStatsTypes 查询的统计分组
+const domainStatsTypes = libvirt.DomainStatsState | libvirt.DomainStatsCPUTotal | libvirt.DomainStatsBalloon |
+	libvirt.DomainStatsVCPU | libvirt.DomainStatsInterface | libvirt.DomainStatsBlock
+
+// GetAllDomainStats 一次查询节点上全部 domain 的 CPU、内存、网络与磁盘统计
+func (c *Client) GetAllDomainStats() ([]DomainStats, error) {
+	records, err := c.conn.ConnectGetAllDomainStats(nil, uint32(domainStatsTypes), 0)
+	if err != nil {
+		return nil, fmt.Errorf("get all domain stats: %w", err)
+	}
+	result := make([]DomainStats, 0, len(records))
+	for _, record := range records {
+		result = append(result, parseDomainStats(record))
+	}
+	return result, nil
+}
+
+// parseDomainStats 把统计参数汇总为 DomainStats，网卡与磁盘按设备累加
+func parseDomainStats(record libvirt.DomainStatsRecord) DomainStats {
+	stats := DomainStats{
+		Name: record.Dom.Name,
+		UUID: fmt.Sprintf("%x", record.Dom.UUID),
+	}
+	blockPaths := make(map[string]string)
+	blockValues := make(map[string]map[string]uint64)
+	for _, param := range record.Params {
+		field := param.Field
+		switch {
+		case field == "state.state":
+			state := libvirt.DomainState(typedParamUint64(param.Value))
+			stats.Active = state == libvirt.DomainRunning || state == libvirt.DomainPaused || state == libvirt.DomainPmsuspended
+		case field == "cpu.time":
+			stats.CPUTime = typedParamUint64(param.Value)
+		case field == "vcpu.current":
+			stats.VCPUs = uint32(typedParamUint64(param.Value))
+		case field == "balloon.current":
+			stats.MemoryKB = typedParamUint64(param.Value)
+		case strings.HasPrefix(field, "net.") && strings.HasSuffix(field, ".rx.bytes"):
+			stats.NetRxBytes += typedParamUint64(param.Value)
+		case strings.HasPrefix(field, "net.") && strings.HasSuffix(field, ".tx.bytes"):
+			stats.NetTxBytes += typedParamUint64(param.Value)
+		case strings.HasPrefix(field, "block."):
+			// block.<n>.path、block.<n>.physical、block.<n>.capacity
+			index, name, ok := strings.Cut(strings.TrimPrefix(field, "block."), ".")
+			if _, err := strconv.Atoi(index); !ok || err != nil {
+				continue
+			}
+			if name == "path" {
+				path, _ := param.Value.I.(string)
+				blockPaths[index] = path
+				continue
+			}
+			if blockValues[index] == nil {
+				blockValues[index] = make(map[string]uint64)
+			}
+			blockValues[index][name] = typedParamUint64(param.Value)
+		}
+	}
+	for index, values := range blockValues {
+		if strings.HasSuffix(strings.ToLower(blockPaths[index]), ".iso") {
+			continue
+		}
+		stats.DiskPhysicalBytes += values["physical"]
+		stats.DiskCapacityBytes += values["capacity"]
+	}
+	return stats
+}
+
+// typedParamUint64 把数值类型的 typed param 转换为 uint64，负数与非数值返回 0
+func typedParamUint64(value libvirt.TypedParamValue) uint64 {
+	switch v := value.I.(type) {
+	case uint64:
+		return v
+	case uint32:
+		return uint64(v)
+	case int64:
+		return uint64(max(v, 0))
+	case int32:
+		return uint64(max(v, 0))
+	case float64:
+		return uint64(max(v, 0))
+	default:
+		return 0
+	}
+}
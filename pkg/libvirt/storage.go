@@ -9,7 +9,6 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
-	"time"
 
 	"github.com/digitalocean/go-libvirt"
 	"github.com/rs/zerolog/log"
@@ -23,6 +22,8 @@ type StoragePoolInfo struct {
 	AllocationB uint64
 	AvailableB  uint64
 	Path        string
+	Type        string // 存储池类型：dir、fs、zfs 等
+	Source      string // 存储池的 source name，zfs 存储池为 dataset（如 tank/jvp）
 }
 
 // VolumeInfo 存储卷信息
@@ -32,15 +33,24 @@ type VolumeInfo struct {
 	CapacityB   uint64
 	AllocationB uint64
 	Format      string
+	// BackingPath 增量卷的 backing file 路径，非增量卷为空
+	BackingPath   string
+	BackingFormat string
 }
 
 // StoragePoolXML 存储池 XML 结构
 // Reference: https://libvirt.org/formatstorage.html
 type StoragePoolXML struct {
-	XMLName xml.Name   `xml:"pool"`
-	Type    string     `xml:"type,attr"`
-	Name    string     `xml:"name"`
-	Target  PoolTarget `xml:"target"`
+	XMLName xml.Name    `xml:"pool"`
+	Type    string      `xml:"type,attr"`
+	Name    string      `xml:"name"`
+	Source  *PoolSource `xml:"source,omitempty"`
+	Target  *PoolTarget `xml:"target,omitempty"`
+}
+
+// PoolSource 存储池来源配置
+type PoolSource struct {
+	Name string `xml:"name,omitempty"` // zfs 存储池为 dataset 名称
 }
 
 // PoolTarget 存储池目标配置
@@ -48,6 +58,18 @@ type PoolTarget struct {
 	Path string `xml:"path"`
 }
 
+// parsePoolTypeSource 从 pool XML 中提取存储池类型与 source name
+func parsePoolTypeSource(xmlDesc string) (string, string) {
+	var pool StoragePoolXML
+	if err := xml.Unmarshal([]byte(xmlDesc), &pool); err != nil {
+		return "", ""
+	}
+	if pool.Source == nil {
+		return pool.Type, ""
+	}
+	return pool.Type, pool.Source.Name
+}
+
 // VolumeXML 存储卷 XML 结构
 // Reference: https://libvirt.org/formatstorage.html#StorageVol
 type VolumeXML struct {
@@ -119,6 +141,7 @@ func (c *Client) GetStoragePool(poolName string) (*StoragePoolInfo, error) {
 	}
 
 	path := extractPoolPath(xmlDesc)
+	poolType, source := parsePoolTypeSource(xmlDesc)
 
 	return &StoragePoolInfo{
 		Name:        poolName,
@@ -127,6 +150,8 @@ func (c *Client) GetStoragePool(poolName string) (*StoragePoolInfo, error) {
 		AllocationB: allocation,
 		AvailableB:  available,
 		Path:        path,
+		Type:        poolType,
+		Source:      source,
 	}, nil
 }
 
@@ -155,6 +180,7 @@ func (c *Client) ListStoragePools() ([]*StoragePoolInfo, error) {
 		}
 
 		path := extractPoolPath(xmlDesc)
+		poolType, source := parsePoolTypeSource(xmlDesc)
 
 		result = append(result, &StoragePoolInfo{
 			Name:        name,
@@ -163,6 +189,8 @@ func (c *Client) ListStoragePools() ([]*StoragePoolInfo, error) {
 			AllocationB: allocation,
 			AvailableB:  available,
 			Path:        path,
+			Type:        poolType,
+			Source:      source,
 		})
 	}
 
@@ -193,12 +221,15 @@ func (c *Client) CreateStoragePool(poolName, poolType, poolPath string) error {
 	// 无论是本地还是远程节点，libvirt daemon 都会以正确的用户（通常是 root）创建目录
 
 	// 构建 XML 结构
+	// zfs 存储池的 poolPath 为已存在的 dataset（如 tank/jvp），卷为其下的 zvol，target 由 libvirt 默认为 /dev/zvol/<dataset>
 	poolXML := &StoragePoolXML{
 		Type: poolType,
 		Name: poolName,
-		Target: PoolTarget{
-			Path: poolPath,
-		},
+	}
+	if poolType == "zfs" {
+		poolXML.Source = &PoolSource{Name: poolPath}
+	} else {
+		poolXML.Target = &PoolTarget{Path: poolPath}
 	}
 
 	// 序列化为 XML
@@ -214,9 +245,11 @@ func (c *Client) CreateStoragePool(poolName, poolType, poolPath string) error {
 		return fmt.Errorf("define storage pool: %w", err)
 	}
 
-	// 构建 pool（创建目录结构）
-	if err := c.conn.StoragePoolBuild(pool, libvirt.StoragePoolBuildNew); err != nil {
-		return fmt.Errorf("build storage pool: %w", err)
+	// 构建 pool（创建目录结构），zfs 存储池使用已存在的 dataset，build 会尝试新建 zpool，跳过
+	if poolType != "zfs" {
+		if err := c.conn.StoragePoolBuild(pool, libvirt.StoragePoolBuildNew); err != nil {
+			return fmt.Errorf("build storage pool: %w", err)
+		}
 	}
 
 	// 启动 pool
@@ -261,13 +294,16 @@ func (c *Client) GetVolume(poolName, volumeName string) (*VolumeInfo, error) {
 		return nil, fmt.Errorf("get volume XML: %w", err)
 	}
 	format := extractVolumeFormat(xmlDesc)
+	backingPath, backingFormat := extractVolumeBacking(xmlDesc)
 
 	return &VolumeInfo{
-		Name:        volumeName,
-		Path:        path,
-		CapacityB:   capacity,
-		AllocationB: allocation,
-		Format:      format,
+		Name:          volumeName,
+		Path:          path,
+		CapacityB:     capacity,
+		AllocationB:   allocation,
+		Format:        format,
+		BackingPath:   backingPath,
+		BackingFormat: backingFormat,
 	}, nil
 }
 
@@ -303,13 +339,16 @@ func (c *Client) ListVolumes(poolName string) ([]*VolumeInfo, error) {
 		}
 		format := extractVolumeFormat(xmlDesc)
 		name := extractVolumeName(xmlDesc)
+		backingPath, backingFormat := extractVolumeBacking(xmlDesc)
 
 		result = append(result, &VolumeInfo{
-			Name:        name,
-			Path:        path,
-			CapacityB:   capacity,
-			AllocationB: allocation,
-			Format:      format,
+			Name:          name,
+			Path:          path,
+			CapacityB:     capacity,
+			AllocationB:   allocation,
+			Format:        format,
+			BackingPath:   backingPath,
+			BackingFormat: backingFormat,
 		})
 		_ = volType // 暂时不使用
 	}
@@ -457,11 +496,13 @@ func (c *Client) CreateVolumeWithBackingStore(poolName, volumeName string, capac
 	_ = volType
 
 	return &VolumeInfo{
-		Name:        volumeName,
-		Path:        path,
-		CapacityB:   capacity,
-		AllocationB: allocation,
-		Format:      format,
+		Name:          volumeName,
+		Path:          path,
+		CapacityB:     capacity,
+		AllocationB:   allocation,
+		Format:        format,
+		BackingPath:   backingPath,
+		BackingFormat: backingFormat,
 	}, nil
 }
 
@@ -701,8 +742,23 @@ func extractVolumeFormat(xmlDesc string) string {
 	return xmlDesc[formatStart : formatStart+formatEnd]
 }
 
-// fixVolumeOwnership 修复 volume 的所有权（从 pool 继承）
+// extractVolumeBacking 从卷 XML 中提取 backing store 的路径和格式
+func extractVolumeBacking(xmlDesc string) (string, string) {
+	var vol VolumeXML
+	if err := xml.Unmarshal([]byte(xmlDesc), &vol); err != nil || vol.BackingStore == nil {
+		return "", ""
+	}
+	return vol.BackingStore.Path, vol.BackingStore.Format.Type
+}
+
+// fixVolumeOwnership 修复以 root 创建的 volume 的所有权：优先从 pool 的 permissions 继承，pool 未配置时使用节点策略中指定的用户与组
+// 节点策略关闭属主修复时不做处理
 func fixVolumeOwnership(c *Client, vol libvirt.StorageVol, pool libvirt.StoragePool) error {
+	policy := ownershipPolicyFor(c.uri)
+	if policy.Disabled {
+		return nil
+	}
+
 	volPath, err := c.conn.StorageVolGetPath(vol)
 	if err != nil {
 		return err
@@ -731,6 +787,9 @@ func fixVolumeOwnership(c *Client, vol libvirt.StorageVol, pool libvirt.StorageP
 		if ownerID > 0 && groupID > 0 {
 			return os.Chown(volPath, int(ownerID), int(groupID))
 		}
+		if policy.User != "" || policy.Group != "" {
+			return policy.chownLocal(volPath)
+		}
 	}
 
 	return nil
@@ -823,10 +882,13 @@ func (c *Client) DeleteStoragePool(poolName string, deleteVolumes bool) error {
 		// 先停止 pool（如果正在运行）
 		_ = c.conn.StoragePoolDestroy(pool)
 
-		// 删除 pool（包括目录）
-		if err := c.conn.StoragePoolDelete(pool, libvirt.StoragePoolDeleteNormal); err != nil {
-			// 如果删除失败，可能是因为目录不为空或没有权限，只记录错误
-			log.Warn().Err(err).Str("pool", poolName).Msg("Failed to delete pool directory")
+		// 删除 pool（包括目录）；zfs 存储池的 delete 会执行 zpool destroy，dataset 由用户自行管理，跳过
+		xmlDesc, err := c.conn.StoragePoolGetXMLDesc(pool, 0)
+		if poolType, _ := parsePoolTypeSource(xmlDesc); err != nil || poolType != "zfs" {
+			if err := c.conn.StoragePoolDelete(pool, libvirt.StoragePoolDeleteNormal); err != nil {
+				// 如果删除失败，可能是因为目录不为空或没有权限，只记录错误
+				log.Warn().Err(err).Str("pool", poolName).Msg("Failed to delete pool directory")
+			}
 		}
 	} else {
 		// 只停止 pool，不删除目录
@@ -972,101 +1034,128 @@ func (c *Client) ListRemoteFiles(dir, pattern string) ([]string, error) {
 	return files, nil
 }
 
-// CreateCloudInitISO 在远程（或本地）节点创建 cloud-init ISO
-// 返回生成的 ISO 文件路径
-func (c *Client) CreateCloudInitISO(outputDir, vmName, metaData, userData string) (string, error) {
-	isoPath := fmt.Sprintf("%s/%s-cidata.iso", outputDir, vmName)
+// CloudInitVolumeSuffix cloud-init 数据卷（cidata ISO）的文件名后缀
+const CloudInitVolumeSuffix = "-cidata.iso"
 
-	if c.IsRemoteConnection() {
-		return c.createCloudInitISORemote(outputDir, vmName, metaData, userData, isoPath)
-	}
-	return c.createCloudInitISOLocal(outputDir, vmName, metaData, userData, isoPath)
+// CloudInitVolumeName 返回实例 cloud-init 数据卷的名称
+func CloudInitVolumeName(vmName string) string {
+	return vmName + CloudInitVolumeSuffix
+}
+
+// IsCloudInitVolume 根据卷名称或路径判断是否为 cloud-init 数据卷
+func IsCloudInitVolume(nameOrPath string) bool {
+	return strings.HasSuffix(nameOrPath, CloudInitVolumeSuffix)
+}
+
+// IgnitionVolumeSuffix 实例 Ignition 配置文件的卷名后缀
+const IgnitionVolumeSuffix = "-ignition.ign"
+
+// IgnitionFWCfgName Fedora CoreOS/Flatcar 读取 Ignition 配置的 fw_cfg 键
+const IgnitionFWCfgName = "opt/com.coreos/config"
+
+// IgnitionVolumeName 返回实例 Ignition 配置卷的名称
+func IgnitionVolumeName(vmName string) string {
+	return vmName + IgnitionVolumeSuffix
+}
+
+// IsIgnitionVolume 根据卷名称或路径判断是否为 Ignition 配置卷
+func IsIgnitionVolume(nameOrPath string) bool {
+	return strings.HasSuffix(nameOrPath, IgnitionVolumeSuffix)
 }
 
-// createCloudInitISOLocal 在本地创建 cloud-init ISO
-func (c *Client) createCloudInitISOLocal(outputDir, vmName, metaData, userData, isoPath string) (string, error) {
-	// 创建临时目录
+// CreateCloudInitVolume 生成 cloud-init ISO 并作为存储卷写入存储池
+// ISO 在本机生成后通过 UploadFileToPool 上传，由 libvirt 统一管理；同名卷已存在时会被覆盖
+// networkConfig 为空时不写入 network-config，guest 使用 cloud-init 的默认网络配置（第一块网卡 DHCPv4）
+func (c *Client) CreateCloudInitVolume(poolName, vmName, metaData, userData, networkConfig string) (*VolumeInfo, error) {
 	tmpDir, err := os.MkdirTemp("", "cloudinit-")
 	if err != nil {
-		return "", fmt.Errorf("create temp dir: %w", err)
+		return nil, fmt.Errorf("create temp dir: %w", err)
 	}
 	defer os.RemoveAll(tmpDir)
 
+	isoPath := tmpDir + "/" + CloudInitVolumeName(vmName)
+	if err := buildCloudInitISO(isoPath, metaData, userData, networkConfig); err != nil {
+		return nil, err
+	}
+
+	volume, err := c.UploadFileToPool(poolName, CloudInitVolumeName(vmName), isoPath)
+	if err != nil {
+		return nil, fmt.Errorf("upload cloud-init ISO to pool %s: %w", poolName, err)
+	}
+
+	return volume, nil
+}
+
+// buildCloudInitISO 在本机生成 cloud-init ISO 到指定路径
+func buildCloudInitISO(isoPath, metaData, userData, networkConfig string) error {
+	// 创建临时目录存放 ISO 内容
+	srcDir, err := os.MkdirTemp("", "cloudinit-src-")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(srcDir)
+
 	// 写入 meta-data
-	metaDataPath := tmpDir + "/meta-data"
+	metaDataPath := srcDir + "/meta-data"
 	if err := os.WriteFile(metaDataPath, []byte(metaData), 0o644); err != nil {
-		return "", fmt.Errorf("write meta-data: %w", err)
+		return fmt.Errorf("write meta-data: %w", err)
 	}
 
 	// 写入 user-data
-	userDataPath := tmpDir + "/user-data"
+	userDataPath := srcDir + "/user-data"
 	if err := os.WriteFile(userDataPath, []byte(userData), 0o644); err != nil {
-		return "", fmt.Errorf("write user-data: %w", err)
+		return fmt.Errorf("write user-data: %w", err)
+	}
+
+	// 写入 network-config（可选）
+	if networkConfig != "" {
+		networkConfigPath := srcDir + "/network-config"
+		if err := os.WriteFile(networkConfigPath, []byte(networkConfig), 0o644); err != nil {
+			return fmt.Errorf("write network-config: %w", err)
+		}
 	}
 
 	// 生成 ISO
 	var cmd *exec.Cmd
 	if _, err := exec.LookPath("genisoimage"); err == nil {
-		cmd = exec.Command("genisoimage", "-output", isoPath, "-volid", "cidata", "-joliet", "-rock", tmpDir)
+		cmd = exec.Command("genisoimage", "-output", isoPath, "-volid", "cidata", "-joliet", "-rock", srcDir)
 	} else if _, err := exec.LookPath("mkisofs"); err == nil {
-		cmd = exec.Command("mkisofs", "-output", isoPath, "-volid", "cidata", "-joliet", "-rock", tmpDir)
+		cmd = exec.Command("mkisofs", "-output", isoPath, "-volid", "cidata", "-joliet", "-rock", srcDir)
 	} else {
-		return "", fmt.Errorf("neither genisoimage nor mkisofs found")
+		return fmt.Errorf("neither genisoimage nor mkisofs found")
 	}
 
 	if output, err := cmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("create ISO: %w, output: %s", err, string(output))
+		return fmt.Errorf("create ISO: %w, output: %s", err, string(output))
 	}
 
-	return isoPath, nil
+	return nil
 }
 
-// createCloudInitISORemote 在远程节点创建 cloud-init ISO
-func (c *Client) createCloudInitISORemote(outputDir, vmName, metaData, userData, isoPath string) (string, error) {
-	sshTarget, err := c.GetSSHTarget()
-	if err != nil {
-		return "", err
-	}
-
-	// 在远程创建临时目录
-	tmpDir := fmt.Sprintf("/tmp/cloudinit-%s-%d", vmName, time.Now().UnixNano())
-	mkdirCmd := fmt.Sprintf("mkdir -p '%s'", tmpDir)
-	cmd := exec.Command("ssh", "-o", "StrictHostKeyChecking=no", "-o", "BatchMode=yes", sshTarget, mkdirCmd)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("create remote temp dir: %w, output: %s", err, string(output))
-	}
-
-	// 清理临时目录
-	defer func() {
-		cleanCmd := fmt.Sprintf("rm -rf '%s'", tmpDir)
-		cmd := exec.Command("ssh", "-o", "StrictHostKeyChecking=no", "-o", "BatchMode=yes", sshTarget, cleanCmd)
-		_ = cmd.Run()
-	}()
+// ReadCloudInitUserData 从 cloud-init ISO 中读取 user-data 内容
+// 依赖节点上的 isoinfo（genisoimage 包提供）
+func (c *Client) ReadCloudInitUserData(isoPath string) (string, error) {
+	extractCmd := fmt.Sprintf("isoinfo -R -i '%s' -x /user-data", isoPath)
 
-	// 写入 meta-data 到远程
-	metaDataPath := tmpDir + "/meta-data"
-	writeMetaCmd := fmt.Sprintf("cat > '%s'", metaDataPath)
-	cmd = exec.Command("ssh", "-o", "StrictHostKeyChecking=no", "-o", "BatchMode=yes", sshTarget, writeMetaCmd)
-	cmd.Stdin = strings.NewReader(metaData)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("write remote meta-data: %w, output: %s", err, string(output))
-	}
-
-	// 写入 user-data 到远程
-	userDataPath := tmpDir + "/user-data"
-	writeUserCmd := fmt.Sprintf("cat > '%s'", userDataPath)
-	cmd = exec.Command("ssh", "-o", "StrictHostKeyChecking=no", "-o", "BatchMode=yes", sshTarget, writeUserCmd)
-	cmd.Stdin = strings.NewReader(userData)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("write remote user-data: %w, output: %s", err, string(output))
+	var cmd *exec.Cmd
+	if c.IsRemoteConnection() {
+		sshTarget, err := c.GetSSHTarget()
+		if err != nil {
+			return "", err
+		}
+		cmd = exec.Command("ssh", "-o", "StrictHostKeyChecking=no", "-o", "BatchMode=yes", sshTarget, extractCmd)
+	} else {
+		cmd = exec.Command("isoinfo", "-R", "-i", isoPath, "-x", "/user-data")
 	}
 
-	// 在远程生成 ISO（尝试 genisoimage 或 mkisofs）
-	genISOCmd := fmt.Sprintf("(which genisoimage && genisoimage -output '%s' -volid cidata -joliet -rock '%s') || (which mkisofs && mkisofs -output '%s' -volid cidata -joliet -rock '%s')", isoPath, tmpDir, isoPath, tmpDir)
-	cmd = exec.Command("ssh", "-o", "StrictHostKeyChecking=no", "-o", "BatchMode=yes", sshTarget, genISOCmd)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("create remote ISO: %w, output: %s", err, string(output))
+	output, err := cmd.Output()
+	if err != nil {
+		var stderr string
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			stderr = string(exitErr.Stderr)
+		}
+		return "", fmt.Errorf("read user-data from %s: %w, stderr: %s", isoPath, err, stderr)
 	}
 
-	return isoPath, nil
+	return string(output), nil
 }
@@ -69,32 +69,57 @@ func ParseSysinfo(xmlData string) (*SysinfoXML, error) {
 	return &sysinfo, nil
 }
 
-// GetProcessorVersion 获取 CPU 型号名称
-func (s *SysinfoXML) GetProcessorVersion() string {
-	for _, entry := range s.Processor.Entries {
-		if entry.Name == "version" {
+// sysinfoEntry 获取指定名称的条目值，不存在时返回空字符串
+func sysinfoEntry(entries []SysinfoEntry, name string) string {
+	for _, entry := range entries {
+		if entry.Name == name {
 			return strings.TrimSpace(entry.Value)
 		}
 	}
 	return ""
 }
 
+// GetProcessorVersion 获取 CPU 型号名称
+func (s *SysinfoXML) GetProcessorVersion() string {
+	return sysinfoEntry(s.Processor.Entries, "version")
+}
+
 // GetProcessorMaxSpeed 获取 CPU 最大频率
 func (s *SysinfoXML) GetProcessorMaxSpeed() string {
-	for _, entry := range s.Processor.Entries {
-		if entry.Name == "max_speed" {
-			return strings.TrimSpace(entry.Value)
-		}
-	}
-	return ""
+	return sysinfoEntry(s.Processor.Entries, "max_speed")
 }
 
 // GetProcessorManufacturer 获取 CPU 制造商
 func (s *SysinfoXML) GetProcessorManufacturer() string {
-	for _, entry := range s.Processor.Entries {
-		if entry.Name == "manufacturer" {
-			return strings.TrimSpace(entry.Value)
-		}
-	}
-	return ""
+	return sysinfoEntry(s.Processor.Entries, "manufacturer")
+}
+
+// GetSystemManufacturer 获取整机制造商
+func (s *SysinfoXML) GetSystemManufacturer() string {
+	return sysinfoEntry(s.System.Entries, "manufacturer")
+}
+
+// GetSystemProduct 获取整机型号
+func (s *SysinfoXML) GetSystemProduct() string {
+	return sysinfoEntry(s.System.Entries, "product")
+}
+
+// GetSystemSerial 获取整机 SMBIOS 序列号，常用于资产管理
+func (s *SysinfoXML) GetSystemSerial() string {
+	return sysinfoEntry(s.System.Entries, "serial")
+}
+
+// GetSystemUUID 获取整机 SMBIOS UUID
+func (s *SysinfoXML) GetSystemUUID() string {
+	return sysinfoEntry(s.System.Entries, "uuid")
+}
+
+// GetBIOSVendor 获取 BIOS 厂商
+func (s *SysinfoXML) GetBIOSVendor() string {
+	return sysinfoEntry(s.BIOS.Entries, "vendor")
+}
+
+// GetBIOSVersion 获取 BIOS 版本
+func (s *SysinfoXML) GetBIOSVersion() string {
+	return sysinfoEntry(s.BIOS.Entries, "version")
 }
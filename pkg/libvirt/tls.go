@@ -0,0 +1,161 @@
+package libvirt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// libvirt TLS 默认端口、连接超时与证书位置（与 virsh 一致）
+const (
+	defaultTLSPort           = "16514"
+	defaultTLSDialTimeout    = 20 * time.Second
+	defaultTLSCACertPath     = "/etc/pki/CA/cacert.pem"
+	defaultTLSClientCertPath = "/etc/pki/libvirt/clientcert.pem"
+	defaultTLSClientKeyPath  = "/etc/pki/libvirt/private/clientkey.pem"
+)
+
+// TLSConfig qemu+tls 连接使用的证书，路径为 JVP 所在主机上的文件，未配置的路径使用 libvirt 默认位置
+type TLSConfig struct {
+	CACertPath         string // CA 证书（默认 /etc/pki/CA/cacert.pem）
+	ClientCertPath     string // 客户端证书（默认 /etc/pki/libvirt/clientcert.pem）
+	ClientKeyPath      string // 客户端私钥（默认 /etc/pki/libvirt/private/clientkey.pem）
+	InsecureSkipVerify bool   // 不校验 libvirtd 的服务端证书，仅用于测试环境
+}
+
+// IsZero 是否未配置证书
+func (c TLSConfig) IsZero() bool {
+	return c == TLSConfig{}
+}
+
+// tlsConfigs 按连接 URI 配置的节点证书
+var tlsConfigs = struct {
+	mu    sync.RWMutex
+	byURI map[string]TLSConfig
+}{byURI: make(map[string]TLSConfig)}
+
+// SetTLSConfig 设置连接 URI 对应节点的证书配置，零值表示使用 URI 中的 pkipath 或 libvirt 默认位置
+// 只影响之后建立的连接，已缓存的连接需要重新建立
+func SetTLSConfig(uri string, config TLSConfig) {
+	tlsConfigs.mu.Lock()
+	defer tlsConfigs.mu.Unlock()
+
+	if config.IsZero() {
+		delete(tlsConfigs.byURI, uri)
+		return
+	}
+	tlsConfigs.byURI[uri] = config
+}
+
+// tlsConfigFor 返回连接 URI 配置的节点证书
+func tlsConfigFor(uri string) TLSConfig {
+	tlsConfigs.mu.RLock()
+	defer tlsConfigs.mu.RUnlock()
+
+	return tlsConfigs.byURI[uri]
+}
+
+// IsTLSURI URI 是否通过 TLS 连接 libvirtd：qemu+tls://host/system，或不带传输方式但指定了主机的 qemu://host/system
+func IsTLSURI(uri string) bool {
+	parsedURI, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	return isTLSTransport(parsedURI)
+}
+
+func isTLSTransport(uri *url.URL) bool {
+	_, transport, ok := strings.Cut(uri.Scheme, "+")
+	if ok {
+		return transport == "tls"
+	}
+	return uri.Host != ""
+}
+
+// Load 读取证书文件并生成 tls.Config，serverName 为 libvirtd 主机名，用于校验服务端证书
+// 每次建立连接时重新读取，节点证书轮换后重连即可生效
+func (c TLSConfig) Load(serverName string) (*tls.Config, error) {
+	certPath := valueOrDefault(c.ClientCertPath, defaultTLSClientCertPath)
+	keyPath := valueOrDefault(c.ClientKeyPath, defaultTLSClientKeyPath)
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("load client certificate %s and key %s: %w", certPath, keyPath, err)
+	}
+
+	config := &tls.Config{
+		Certificates:       []tls.Certificate{cert},
+		ServerName:         serverName,
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec // 由节点配置显式开启
+		MinVersion:         tls.VersionTLS12,
+	}
+	caPath := valueOrDefault(c.CACertPath, defaultTLSCACertPath)
+	caPEM, err := os.ReadFile(caPath)
+	switch {
+	case err == nil:
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificate found in CA file %s", caPath)
+		}
+		config.RootCAs = pool
+	case c.InsecureSkipVerify && c.CACertPath == "" && errors.Is(err, os.ErrNotExist):
+		// 不校验服务端证书时默认位置的 CA 可以不存在
+	default:
+		return nil, fmt.Errorf("read CA certificate: %w", err)
+	}
+	return config, nil
+}
+
+func valueOrDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// tlsDialer 使用节点配置的证书连接 libvirtd 的 TLS 端口，实现 go-libvirt 的 socket.Dialer
+type tlsDialer struct {
+	host   string
+	port   string
+	config TLSConfig
+}
+
+func newTLSDialer(uri *url.URL, config TLSConfig) *tlsDialer {
+	port := uri.Port()
+	if port == "" {
+		port = defaultTLSPort
+	}
+	return &tlsDialer{host: uri.Hostname(), port: port, config: config}
+}
+
+// Dial 建立 TLS 连接并等待 libvirtd 对客户端证书的校验结果
+func (d *tlsDialer) Dial() (net.Conn, error) {
+	config, err := d.config.Load(d.host)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: defaultTLSDialTimeout}, "tcp", net.JoinHostPort(d.host, d.port), config)
+	if err != nil {
+		return nil, err
+	}
+
+	// 握手完成后 libvirtd 写入一个字节表示是否接受客户端证书（1 为接受）
+	_ = conn.SetReadDeadline(time.Now().Add(defaultTLSDialTimeout))
+	buf := make([]byte, 1)
+	if _, err := conn.Read(buf); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("read certificate check result: %w", err)
+	}
+	if buf[0] != 1 {
+		conn.Close()
+		return nil, errors.New("libvirtd rejected the client certificate or the client address, check tls_allowed_dn_list on the node")
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+	return conn, nil
+}
@@ -0,0 +1,726 @@
+package libvirt
+
+import (
+	"context"
+	"time"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/jimyag/jvp/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedClient 为每次 libvirt RPC 创建 span 的 LibvirtClient 装饰器
+// span 的父节点为创建装饰器时 ctx 中的 span（通常是 HTTP 请求），名称为 "libvirt.<方法名>"
+type tracedClient struct {
+	ctx    context.Context
+	client LibvirtClient
+}
+
+// Traced 返回把 libvirt 调用记录为 ctx 中 span 子节点的客户端
+// ctx 中没有有效 span（未启用追踪或后台任务）时原样返回 client，不产生额外开销
+func Traced(ctx context.Context, client LibvirtClient) LibvirtClient {
+	if traced, ok := client.(*tracedClient); ok {
+		client = traced.client
+	}
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return client
+	}
+	return &tracedClient{ctx: ctx, client: client}
+}
+
+// start 创建 libvirt 调用的 client 类型 span
+func (t *tracedClient) start(method string, attrs ...attribute.KeyValue) trace.Span {
+	_, span := tracing.Tracer().Start(t.ctx, "libvirt."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return span
+}
+
+// ==================== HostManager ====================
+
+func (t *tracedClient) GetHostname() (string, error) {
+	span := t.start("GetHostname")
+	r0, err := t.client.GetHostname()
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetLibvirtVersion() (string, error) {
+	span := t.start("GetLibvirtVersion")
+	r0, err := t.client.GetLibvirtVersion()
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetNodeInfo() (*NodeInfo, error) {
+	span := t.start("GetNodeInfo")
+	r0, err := t.client.GetNodeInfo()
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetNUMACells() ([]NUMACell, error) {
+	span := t.start("GetNUMACells")
+	r0, err := t.client.GetNUMACells()
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetCapabilities() (*CapabilitiesXML, error) {
+	span := t.start("GetCapabilities")
+	r0, err := t.client.GetCapabilities()
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetSysinfo() (*SysinfoXML, error) {
+	span := t.start("GetSysinfo")
+	r0, err := t.client.GetSysinfo()
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) Close() error {
+	return t.client.Close()
+}
+
+func (t *tracedClient) ListNodeDevices(cap string) ([]libvirt.NodeDevice, error) {
+	span := t.start("ListNodeDevices")
+	r0, err := t.client.ListNodeDevices(cap)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetNodeDeviceXMLDesc(dev libvirt.NodeDevice) (string, error) {
+	span := t.start("GetNodeDeviceXMLDesc")
+	r0, err := t.client.GetNodeDeviceXMLDesc(dev)
+	tracing.End(span, err)
+	return r0, err
+}
+
+// ==================== DomainManager ====================
+
+func (t *tracedClient) GetVMSummaries() ([]libvirt.Domain, error) {
+	span := t.start("GetVMSummaries")
+	r0, err := t.client.GetVMSummaries()
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetDomainInfo(domainUUID libvirt.UUID) (*DomainInfo, error) {
+	span := t.start("GetDomainInfo")
+	r0, err := t.client.GetDomainInfo(domainUUID)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetDomainByName(name string) (libvirt.Domain, error) {
+	span := t.start("GetDomainByName", attribute.String("libvirt.name", name))
+	r0, err := t.client.GetDomainByName(name)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetDomainState(domain libvirt.Domain) (uint8, uint32, error) {
+	span := t.start("GetDomainState", attribute.String("libvirt.domain_name", domain.Name))
+	r0, r1, err := t.client.GetDomainState(domain)
+	tracing.End(span, err)
+	return r0, r1, err
+}
+
+func (t *tracedClient) CreateDomain(config *CreateVMConfig, autoStart bool) (libvirt.Domain, error) {
+	span := t.start("CreateDomain")
+	r0, err := t.client.CreateDomain(config, autoStart)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) StartDomain(domain libvirt.Domain) error {
+	span := t.start("StartDomain", attribute.String("libvirt.domain_name", domain.Name))
+	err := t.client.StartDomain(domain)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) StopDomain(domain libvirt.Domain) error {
+	span := t.start("StopDomain", attribute.String("libvirt.domain_name", domain.Name))
+	err := t.client.StopDomain(domain)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) RebootDomain(domain libvirt.Domain) error {
+	span := t.start("RebootDomain", attribute.String("libvirt.domain_name", domain.Name))
+	err := t.client.RebootDomain(domain)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) DestroyDomain(domain libvirt.Domain) error {
+	span := t.start("DestroyDomain", attribute.String("libvirt.domain_name", domain.Name))
+	err := t.client.DestroyDomain(domain)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) DeleteDomain(domain libvirt.Domain, flags libvirt.DomainUndefineFlagsValues) error {
+	span := t.start("DeleteDomain", attribute.String("libvirt.domain_name", domain.Name))
+	err := t.client.DeleteDomain(domain, flags)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) RenameDomain(domain libvirt.Domain, newName string) error {
+	span := t.start("RenameDomain", attribute.String("libvirt.domain_name", domain.Name), attribute.String("libvirt.new_name", newName))
+	err := t.client.RenameDomain(domain, newName)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) ModifyDomainMemory(domain libvirt.Domain, memoryKB uint64, live bool) (*ResizeResult, error) {
+	span := t.start("ModifyDomainMemory", attribute.String("libvirt.domain_name", domain.Name))
+	result, err := t.client.ModifyDomainMemory(domain, memoryKB, live)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (t *tracedClient) ModifyDomainVCPU(domain libvirt.Domain, vcpus uint16, live bool) (*ResizeResult, error) {
+	span := t.start("ModifyDomainVCPU", attribute.String("libvirt.domain_name", domain.Name))
+	result, err := t.client.ModifyDomainVCPU(domain, vcpus, live)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (t *tracedClient) SetDomainAutostart(domain libvirt.Domain, autostart bool) error {
+	span := t.start("SetDomainAutostart", attribute.String("libvirt.domain_name", domain.Name))
+	err := t.client.SetDomainAutostart(domain, autostart)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) SetDomainBootOrder(domain libvirt.Domain, devices []string) error {
+	span := t.start("SetDomainBootOrder", attribute.String("libvirt.domain_name", domain.Name))
+	err := t.client.SetDomainBootOrder(domain, devices)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) SetDomainInterfaceBandwidth(domainName string, mac string, bandwidth InterfaceBandwidth) error {
+	span := t.start("SetDomainInterfaceBandwidth", attribute.String("libvirt.domain_name", domainName))
+	err := t.client.SetDomainInterfaceBandwidth(domainName, mac, bandwidth)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) SetDomainCPUTune(domainName string, tune CPUTune) error {
+	span := t.start("SetDomainCPUTune", attribute.String("libvirt.domain_name", domainName))
+	err := t.client.SetDomainCPUTune(domainName, tune)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) SetDomainBlkioTune(domainName string, tune BlkioTune) error {
+	span := t.start("SetDomainBlkioTune", attribute.String("libvirt.domain_name", domainName))
+	err := t.client.SetDomainBlkioTune(domainName, tune)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) SetDomainLabels(domainName string, labels map[string]string) error {
+	span := t.start("SetDomainLabels", attribute.String("libvirt.domain_name", domainName))
+	err := t.client.SetDomainLabels(domainName, labels)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) UpdateDomainDevice(domainName string, update DeviceUpdate) (bool, error) {
+	span := t.start("UpdateDomainDevice", attribute.String("libvirt.domain_name", domainName))
+	r0, err := t.client.UpdateDomainDevice(domainName, update)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetAllDomainStats() ([]DomainStats, error) {
+	span := t.start("GetAllDomainStats")
+	r0, err := t.client.GetAllDomainStats()
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) AttachDiskToDomain(domainName string, config *AttachDiskConfig) (string, error) {
+	span := t.start("AttachDiskToDomain", attribute.String("libvirt.domain_name", domainName))
+	r0, err := t.client.AttachDiskToDomain(domainName, config)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) DetachDiskFromDomain(domainName string, device string) error {
+	span := t.start("DetachDiskFromDomain", attribute.String("libvirt.domain_name", domainName), attribute.String("libvirt.device", device))
+	err := t.client.DetachDiskFromDomain(domainName, device)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) GetDomainDisks(domainName string) ([]DomainDisk, error) {
+	span := t.start("GetDomainDisks", attribute.String("libvirt.domain_name", domainName))
+	r0, err := t.client.GetDomainDisks(domainName)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) EjectDomainCDROM(domainName string, device string) error {
+	span := t.start("EjectDomainCDROM", attribute.String("libvirt.domain_name", domainName), attribute.String("libvirt.device", device))
+	err := t.client.EjectDomainCDROM(domainName, device)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) SetDomainDiskSource(domainName string, device string, sourcePath string, format string) error {
+	span := t.start("SetDomainDiskSource", attribute.String("libvirt.domain_name", domainName), attribute.String("libvirt.device", device), attribute.String("libvirt.source_path", sourcePath))
+	err := t.client.SetDomainDiskSource(domainName, device, sourcePath, format)
+	tracing.End(span, err)
+	return err
+}
+
+// ==================== BlockJobManager ====================
+
+func (t *tracedClient) BlockPull(domainName string, device string, bandwidthMiB uint64) error {
+	span := t.start("BlockPull", attribute.String("libvirt.domain_name", domainName), attribute.String("libvirt.device", device))
+	err := t.client.BlockPull(domainName, device, bandwidthMiB)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) BlockCommit(domainName string, device string, config BlockCommitConfig) error {
+	span := t.start("BlockCommit", attribute.String("libvirt.domain_name", domainName), attribute.String("libvirt.device", device))
+	err := t.client.BlockCommit(domainName, device, config)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) BlockCopy(domainName string, device string, config BlockCopyConfig) error {
+	span := t.start("BlockCopy", attribute.String("libvirt.domain_name", domainName), attribute.String("libvirt.device", device))
+	err := t.client.BlockCopy(domainName, device, config)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) GetBlockJob(domainName string, device string) (*BlockJobInfo, error) {
+	span := t.start("GetBlockJob", attribute.String("libvirt.domain_name", domainName), attribute.String("libvirt.device", device))
+	r0, err := t.client.GetBlockJob(domainName, device)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) AbortBlockJob(domainName string, device string, pivot bool) error {
+	span := t.start("AbortBlockJob", attribute.String("libvirt.domain_name", domainName), attribute.String("libvirt.device", device))
+	err := t.client.AbortBlockJob(domainName, device, pivot)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) SetBlockJobSpeed(domainName string, device string, bandwidthMiB uint64) error {
+	span := t.start("SetBlockJobSpeed", attribute.String("libvirt.domain_name", domainName), attribute.String("libvirt.device", device))
+	err := t.client.SetBlockJobSpeed(domainName, device, bandwidthMiB)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) WaitBlockJob(ctx context.Context, domainName string, device string, onProgress func(*BlockJobInfo)) (*BlockJobInfo, error) {
+	_, span := tracing.Tracer().Start(ctx, "libvirt.WaitBlockJob",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("libvirt.domain_name", domainName), attribute.String("libvirt.device", device)),
+	)
+	r0, err := t.client.WaitBlockJob(ctx, domainName, device, onProgress)
+	tracing.End(span, err)
+	return r0, err
+}
+
+// ==================== GuestAgentManager ====================
+
+func (t *tracedClient) QemuAgentCommand(domain libvirt.Domain, command string, timeout uint32, flags uint32) (string, error) {
+	span := t.start("QemuAgentCommand", attribute.String("libvirt.domain_name", domain.Name))
+	r0, err := t.client.QemuAgentCommand(domain, command, timeout, flags)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) CheckGuestAgentAvailable(domain libvirt.Domain) (bool, error) {
+	span := t.start("CheckGuestAgentAvailable", attribute.String("libvirt.domain_name", domain.Name))
+	r0, err := t.client.CheckGuestAgentAvailable(domain)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) FreezeDomainFS(domainName string, mountpoints []string) (int, error) {
+	span := t.start("FreezeDomainFS", attribute.String("libvirt.domain_name", domainName))
+	r0, err := t.client.FreezeDomainFS(domainName, mountpoints)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) ThawDomainFS(domainName string, mountpoints []string) (int, error) {
+	span := t.start("ThawDomainFS", attribute.String("libvirt.domain_name", domainName))
+	r0, err := t.client.ThawDomainFS(domainName, mountpoints)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GuestExec(domainName, path string, args []string, timeout time.Duration) (*GuestExecResult, error) {
+	span := t.start("GuestExec", attribute.String("libvirt.domain_name", domainName))
+	r0, err := t.client.GuestExec(domainName, path, args, timeout)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetGuestOSInfo(domainName string) (*GuestOSInfo, error) {
+	span := t.start("GetGuestOSInfo", attribute.String("libvirt.domain_name", domainName))
+	r0, err := t.client.GetGuestOSInfo(domainName)
+	tracing.End(span, err)
+	return r0, err
+}
+
+// ==================== MonitorManager ====================
+
+func (t *tracedClient) QEMUMonitorCommand(domainName, command string) (string, error) {
+	span := t.start("QEMUMonitorCommand", attribute.String("libvirt.domain_name", domainName))
+	r0, err := t.client.QEMUMonitorCommand(domainName, command)
+	tracing.End(span, err)
+	return r0, err
+}
+
+// ==================== ConsoleManager ====================
+
+func (t *tracedClient) GetDomainConsoleInfo(domain libvirt.Domain) (*ConsoleInfo, error) {
+	span := t.start("GetDomainConsoleInfo", attribute.String("libvirt.domain_name", domain.Name))
+	r0, err := t.client.GetDomainConsoleInfo(domain)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetDomainConsoleOutput(domain libvirt.Domain, maxBytes int64) (*ConsoleOutput, error) {
+	span := t.start("GetDomainConsoleOutput", attribute.String("libvirt.domain_name", domain.Name))
+	r0, err := t.client.GetDomainConsoleOutput(domain, maxBytes)
+	tracing.End(span, err)
+	return r0, err
+}
+
+// ==================== StorageManager ====================
+
+func (t *tracedClient) GetStoragePool(poolName string) (*StoragePoolInfo, error) {
+	span := t.start("GetStoragePool", attribute.String("libvirt.pool_name", poolName))
+	r0, err := t.client.GetStoragePool(poolName)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) ListStoragePools() ([]*StoragePoolInfo, error) {
+	span := t.start("ListStoragePools")
+	r0, err := t.client.ListStoragePools()
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) EnsureStoragePool(poolName string, poolType string, poolPath string) error {
+	span := t.start("EnsureStoragePool", attribute.String("libvirt.pool_name", poolName), attribute.String("libvirt.pool_path", poolPath))
+	err := t.client.EnsureStoragePool(poolName, poolType, poolPath)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) CreateStoragePool(poolName string, poolType string, poolPath string) error {
+	span := t.start("CreateStoragePool", attribute.String("libvirt.pool_name", poolName), attribute.String("libvirt.pool_path", poolPath))
+	err := t.client.CreateStoragePool(poolName, poolType, poolPath)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) StartStoragePool(poolName string) error {
+	span := t.start("StartStoragePool", attribute.String("libvirt.pool_name", poolName))
+	err := t.client.StartStoragePool(poolName)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) StopStoragePool(poolName string) error {
+	span := t.start("StopStoragePool", attribute.String("libvirt.pool_name", poolName))
+	err := t.client.StopStoragePool(poolName)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) DeleteStoragePool(poolName string, deleteVolumes bool) error {
+	span := t.start("DeleteStoragePool", attribute.String("libvirt.pool_name", poolName))
+	err := t.client.DeleteStoragePool(poolName, deleteVolumes)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) RefreshStoragePool(poolName string) error {
+	span := t.start("RefreshStoragePool", attribute.String("libvirt.pool_name", poolName))
+	err := t.client.RefreshStoragePool(poolName)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) GetVolume(poolName string, volumeName string) (*VolumeInfo, error) {
+	span := t.start("GetVolume", attribute.String("libvirt.pool_name", poolName), attribute.String("libvirt.volume_name", volumeName))
+	r0, err := t.client.GetVolume(poolName, volumeName)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) ListVolumes(poolName string) ([]*VolumeInfo, error) {
+	span := t.start("ListVolumes", attribute.String("libvirt.pool_name", poolName))
+	r0, err := t.client.ListVolumes(poolName)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) CreateVolume(poolName string, volumeName string, sizeGB uint64, format string) (*VolumeInfo, error) {
+	span := t.start("CreateVolume", attribute.String("libvirt.pool_name", poolName), attribute.String("libvirt.volume_name", volumeName))
+	r0, err := t.client.CreateVolume(poolName, volumeName, sizeGB, format)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) CreateVolumeWithBackingStore(poolName string, volumeName string, capacityGB uint64, format string, backingPath string, backingFormat string) (*VolumeInfo, error) {
+	span := t.start("CreateVolumeWithBackingStore", attribute.String("libvirt.pool_name", poolName), attribute.String("libvirt.volume_name", volumeName), attribute.String("libvirt.backing_path", backingPath))
+	r0, err := t.client.CreateVolumeWithBackingStore(poolName, volumeName, capacityGB, format, backingPath, backingFormat)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) UploadFileToPool(poolName string, volumeName string, localFilePath string) (*VolumeInfo, error) {
+	span := t.start("UploadFileToPool", attribute.String("libvirt.pool_name", poolName), attribute.String("libvirt.volume_name", volumeName), attribute.String("libvirt.local_file_path", localFilePath))
+	r0, err := t.client.UploadFileToPool(poolName, volumeName, localFilePath)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) ResizeVolume(poolName string, volumeName string, newSizeGB uint64) error {
+	span := t.start("ResizeVolume", attribute.String("libvirt.pool_name", poolName), attribute.String("libvirt.volume_name", volumeName))
+	err := t.client.ResizeVolume(poolName, volumeName, newSizeGB)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) DeleteVolume(poolName string, volumeName string) error {
+	span := t.start("DeleteVolume", attribute.String("libvirt.pool_name", poolName), attribute.String("libvirt.volume_name", volumeName))
+	err := t.client.DeleteVolume(poolName, volumeName)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) DeleteVolumeByPath(volumePath string) error {
+	span := t.start("DeleteVolumeByPath", attribute.String("libvirt.volume_path", volumePath))
+	err := t.client.DeleteVolumeByPath(volumePath)
+	tracing.End(span, err)
+	return err
+}
+
+// ==================== SnapshotManager ====================
+
+func (t *tracedClient) ListSnapshots(domainName string) ([]string, error) {
+	span := t.start("ListSnapshots", attribute.String("libvirt.domain_name", domainName))
+	r0, err := t.client.ListSnapshots(domainName)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) CreateSnapshot(domainName string, snapshotXML string, flags libvirt.DomainSnapshotCreateFlags) error {
+	span := t.start("CreateSnapshot", attribute.String("libvirt.domain_name", domainName))
+	err := t.client.CreateSnapshot(domainName, snapshotXML, flags)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) GetSnapshotXML(domainName string, snapshotName string) (*DomainSnapshotXML, error) {
+	span := t.start("GetSnapshotXML", attribute.String("libvirt.domain_name", domainName), attribute.String("libvirt.snapshot_name", snapshotName))
+	r0, err := t.client.GetSnapshotXML(domainName, snapshotName)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) ListSnapshotXML(domainName string) ([]DomainSnapshotXML, error) {
+	span := t.start("ListSnapshotXML", attribute.String("libvirt.domain_name", domainName))
+	r0, err := t.client.ListSnapshotXML(domainName)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) DeleteSnapshot(domainName string, snapshotName string, flags libvirt.DomainSnapshotDeleteFlags) error {
+	span := t.start("DeleteSnapshot", attribute.String("libvirt.domain_name", domainName), attribute.String("libvirt.snapshot_name", snapshotName))
+	err := t.client.DeleteSnapshot(domainName, snapshotName, flags)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) RevertToSnapshot(domainName string, snapshotName string, flags libvirt.DomainSnapshotRevertFlags) error {
+	span := t.start("RevertToSnapshot", attribute.String("libvirt.domain_name", domainName), attribute.String("libvirt.snapshot_name", snapshotName))
+	err := t.client.RevertToSnapshot(domainName, snapshotName, flags)
+	tracing.End(span, err)
+	return err
+}
+
+// ==================== NetworkManager ====================
+
+func (t *tracedClient) ListInterfaces() ([]libvirt.Interface, error) {
+	span := t.start("ListInterfaces")
+	r0, err := t.client.ListInterfaces()
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetInterfaceXMLDesc(iface libvirt.Interface) (string, error) {
+	span := t.start("GetInterfaceXMLDesc")
+	r0, err := t.client.GetInterfaceXMLDesc(iface)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) ListNetworkDHCPLeases(networkName string) ([]DHCPLease, error) {
+	span := t.start("ListNetworkDHCPLeases", attribute.String("libvirt.network_name", networkName))
+	r0, err := t.client.ListNetworkDHCPLeases(networkName)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) ListNetworks() ([]string, error) {
+	span := t.start("ListNetworks")
+	r0, err := t.client.ListNetworks()
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) ListNetworksInfo() ([]NetworkInfo, error) {
+	span := t.start("ListNetworksInfo")
+	r0, err := t.client.ListNetworksInfo()
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetNetwork(name string) (*NetworkInfo, error) {
+	span := t.start("GetNetwork", attribute.String("libvirt.name", name))
+	r0, err := t.client.GetNetwork(name)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) GetNetworkXMLDesc(name string) (string, error) {
+	span := t.start("GetNetworkXMLDesc", attribute.String("libvirt.name", name))
+	r0, err := t.client.GetNetworkXMLDesc(name)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) CreateNetwork(config NetworkConfig) (*NetworkInfo, error) {
+	span := t.start("CreateNetwork")
+	r0, err := t.client.CreateNetwork(config)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) DeleteNetwork(name string) error {
+	span := t.start("DeleteNetwork", attribute.String("libvirt.name", name))
+	err := t.client.DeleteNetwork(name)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) StartNetwork(name string) error {
+	span := t.start("StartNetwork", attribute.String("libvirt.name", name))
+	err := t.client.StartNetwork(name)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) StopNetwork(name string) error {
+	span := t.start("StopNetwork", attribute.String("libvirt.name", name))
+	err := t.client.StopNetwork(name)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) SetNetworkAutostart(name string, autostart bool) error {
+	span := t.start("SetNetworkAutostart", attribute.String("libvirt.name", name))
+	err := t.client.SetNetworkAutostart(name, autostart)
+	tracing.End(span, err)
+	return err
+}
+
+// ==================== NWFilterManager ====================
+
+func (t *tracedClient) DefineNWFilter(filter NWFilterXML) error {
+	span := t.start("DefineNWFilter", attribute.String("libvirt.name", filter.Name))
+	err := t.client.DefineNWFilter(filter)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) UndefineNWFilter(name string) error {
+	span := t.start("UndefineNWFilter", attribute.String("libvirt.name", name))
+	err := t.client.UndefineNWFilter(name)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) ListNWFilters() ([]string, error) {
+	span := t.start("ListNWFilters")
+	r0, err := t.client.ListNWFilters()
+	tracing.End(span, err)
+	return r0, err
+}
+
+// ==================== RemoteManager ====================
+
+func (t *tracedClient) IsRemoteConnection() bool {
+	return t.client.IsRemoteConnection()
+}
+
+func (t *tracedClient) GetConnectionURI() string {
+	return t.client.GetConnectionURI()
+}
+
+func (t *tracedClient) GetSSHTarget() (string, error) {
+	return t.client.GetSSHTarget()
+}
+
+func (t *tracedClient) ExecuteRemoteCommand(cmd string) error {
+	span := t.start("ExecuteRemoteCommand", attribute.String("libvirt.cmd", cmd))
+	err := t.client.ExecuteRemoteCommand(cmd)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *tracedClient) ReadRemoteFile(path string) ([]byte, error) {
+	span := t.start("ReadRemoteFile", attribute.String("libvirt.path", path))
+	r0, err := t.client.ReadRemoteFile(path)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) ListRemoteFiles(dir string, pattern string) ([]string, error) {
+	span := t.start("ListRemoteFiles", attribute.String("libvirt.dir", dir))
+	r0, err := t.client.ListRemoteFiles(dir, pattern)
+	tracing.End(span, err)
+	return r0, err
+}
+
+// ==================== CloudInitManager ====================
+
+func (t *tracedClient) CreateCloudInitVolume(poolName string, vmName string, metaData string, userData string, networkConfig string) (*VolumeInfo, error) {
+	span := t.start("CreateCloudInitVolume", attribute.String("libvirt.pool_name", poolName), attribute.String("libvirt.vm_name", vmName))
+	r0, err := t.client.CreateCloudInitVolume(poolName, vmName, metaData, userData, networkConfig)
+	tracing.End(span, err)
+	return r0, err
+}
+
+func (t *tracedClient) ReadCloudInitUserData(isoPath string) (string, error) {
+	span := t.start("ReadCloudInitUserData", attribute.String("libvirt.iso_path", isoPath))
+	r0, err := t.client.ReadCloudInitUserData(isoPath)
+	tracing.End(span, err)
+	return r0, err
+}
@@ -0,0 +1,324 @@
+package libvirt
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/digitalocean/go-libvirt"
+)
+
+// InterfaceBandwidth 网络接口限速（QoS），方向为 nil 表示该方向不限速
+type InterfaceBandwidth struct {
+	Inbound  *BandwidthRate `json:"inbound,omitempty"`  // 入方向（宿主机 → 虚拟机）
+	Outbound *BandwidthRate `json:"outbound,omitempty"` // 出方向（虚拟机 → 宿主机）
+}
+
+// BandwidthRate 单方向限速参数，Average 为 0 表示取消该方向限速
+type BandwidthRate struct {
+	Average uint64 `json:"average"`         // 平均速率（KiB/s）
+	Peak    uint64 `json:"peak,omitempty"`  // 峰值速率（KiB/s），不能小于 Average
+	Burst   uint64 `json:"burst,omitempty"` // 以峰值速率可突发的数据量（KiB）
+}
+
+// Validate 校验限速参数
+func (b *InterfaceBandwidth) Validate() error {
+	if err := b.Inbound.validate("inbound"); err != nil {
+		return err
+	}
+	return b.Outbound.validate("outbound")
+}
+
+func (r *BandwidthRate) validate(direction string) error {
+	if r == nil {
+		return nil
+	}
+	// DomainSetInterfaceParameters 以 unsigned int 传递参数
+	for _, v := range []uint64{r.Average, r.Peak, r.Burst} {
+		if v > math.MaxUint32 {
+			return fmt.Errorf("%s bandwidth value %d exceeds %d", direction, v, uint64(math.MaxUint32))
+		}
+	}
+	if r.Average == 0 && (r.Peak != 0 || r.Burst != 0) {
+		return fmt.Errorf("%s peak/burst requires average", direction)
+	}
+	if r.Peak != 0 && r.Peak < r.Average {
+		return fmt.Errorf("%s peak %d is less than average %d", direction, r.Peak, r.Average)
+	}
+	return nil
+}
+
+// toXML 转换为 interface 的 bandwidth 元素，未设置任何限速时返回 nil
+func (b *InterfaceBandwidth) toXML() *DomainInterfaceBandwidth {
+	if b == nil {
+		return nil
+	}
+	result := &DomainInterfaceBandwidth{
+		Inbound:  b.Inbound.toXML(),
+		Outbound: b.Outbound.toXML(),
+	}
+	if result.Inbound == nil && result.Outbound == nil {
+		return nil
+	}
+	return result
+}
+
+func (r *BandwidthRate) toXML() *DomainBandwidthRate {
+	if r == nil || r.Average == 0 {
+		return nil
+	}
+	return &DomainBandwidthRate{Average: r.Average, Peak: r.Peak, Burst: r.Burst}
+}
+
+// bandwidthFromXML 从 interface 的 bandwidth 元素解析限速
+func bandwidthFromXML(b *DomainInterfaceBandwidth) *InterfaceBandwidth {
+	if b == nil || (b.Inbound == nil && b.Outbound == nil) {
+		return nil
+	}
+	result := &InterfaceBandwidth{}
+	if b.Inbound != nil {
+		result.Inbound = &BandwidthRate{Average: b.Inbound.Average, Peak: b.Inbound.Peak, Burst: b.Inbound.Burst}
+	}
+	if b.Outbound != nil {
+		result.Outbound = &BandwidthRate{Average: b.Outbound.Average, Peak: b.Outbound.Peak, Burst: b.Outbound.Burst}
+	}
+	return result
+}
+
+// bandwidthParams 构造 DomainSetInterfaceParameters 参数，nil 方向不下发即保持原值
+func bandwidthParams(bandwidth InterfaceBandwidth) []libvirt.TypedParam {
+	var params []libvirt.TypedParam
+	add := func(direction string, r *BandwidthRate) {
+		if r == nil {
+			return
+		}
+		params = append(params,
+			libvirt.TypedParam{Field: direction + ".average", Value: *libvirt.NewTypedParamValueUint(uint32(r.Average))},
+			libvirt.TypedParam{Field: direction + ".peak", Value: *libvirt.NewTypedParamValueUint(uint32(r.Peak))},
+			libvirt.TypedParam{Field: direction + ".burst", Value: *libvirt.NewTypedParamValueUint(uint32(r.Burst))},
+		)
+	}
+	add("inbound", bandwidth.Inbound)
+	add("outbound", bandwidth.Outbound)
+	return params
+}
+
+// SetDomainInterfaceBandwidth 修改网卡限速（按 MAC 定位网卡）
+// 运行中的域立即生效并同时写入持久化配置，关机的域只修改持久化配置；
+// bandwidth 中为 nil 的方向保持原值，Average 为 0 的方向取消限速
+func (c *Client) SetDomainInterfaceBandwidth(domainName, mac string, bandwidth InterfaceBandwidth) error {
+	if err := bandwidth.Validate(); err != nil {
+		return err
+	}
+
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return fmt.Errorf("lookup domain: %w", err)
+	}
+
+	params := bandwidthParams(bandwidth)
+	if len(params) == 0 {
+		return nil
+	}
+
+	flags := libvirt.DomainAffectConfig
+	if c.isDomainRunning(domain) {
+		flags |= libvirt.DomainAffectLive
+	}
+
+	if err := c.conn.DomainSetInterfaceParameters(domain, strings.ToLower(mac), params, uint32(flags)); err != nil {
+		return fmt.Errorf("set interface parameters %s: %w", mac, err)
+	}
+	return nil
+}
+
+// CPU 调度参数取值范围（与 libvirt qemu 驱动一致）
+const (
+	minCPUShares = 2
+	maxCPUShares = 262144
+	minCPUPeriod = 1000
+	maxCPUPeriod = 1000000
+	minCPUQuota  = 1000
+	maxCPUQuota  = 17592186044415
+)
+
+// CPUTune CPU 调度参数（cputune，cgroup cpu 控制器），字段为 0 表示不设置/保持原值
+type CPUTune struct {
+	Shares uint64 `json:"shares,omitempty"` // CPU 权重，争抢时按比例分配，默认 1024（cgroup v2 下由 libvirt 换算为 cpu.weight）
+	Period uint64 `json:"period,omitempty"` // vCPU 调度周期（微秒）：1000-1000000
+	Quota  int64  `json:"quota,omitempty"`  // 每个周期内每个 vCPU 可用的 CPU 时间（微秒）：1000-17592186044415，负数表示不限制
+}
+
+// Validate 校验 CPU 调度参数范围
+func (t *CPUTune) Validate() error {
+	if t.Shares != 0 && (t.Shares < minCPUShares || t.Shares > maxCPUShares) {
+		return fmt.Errorf("cpu shares %d out of range [%d, %d]", t.Shares, minCPUShares, maxCPUShares)
+	}
+	if t.Period != 0 && (t.Period < minCPUPeriod || t.Period > maxCPUPeriod) {
+		return fmt.Errorf("cpu period %d out of range [%d, %d]", t.Period, minCPUPeriod, maxCPUPeriod)
+	}
+	if t.Quota > 0 && (t.Quota < minCPUQuota || t.Quota > maxCPUQuota) {
+		return fmt.Errorf("cpu quota %d out of range [%d, %d]", t.Quota, minCPUQuota, maxCPUQuota)
+	}
+	return nil
+}
+
+// toXML 转换为 cputune 元素，未设置任何参数时返回 nil
+func (t *CPUTune) toXML() *DomainCPUTune {
+	if t == nil || (t.Shares == 0 && t.Period == 0 && t.Quota == 0) {
+		return nil
+	}
+	result := &DomainCPUTune{Shares: t.Shares, Period: t.Period, Quota: t.Quota}
+	if result.Quota < 0 {
+		result.Quota = -1
+	}
+	return result
+}
+
+// cpuTuneFromXML 从 cputune 元素解析 CPU 调度参数
+func cpuTuneFromXML(t *DomainCPUTune) *CPUTune {
+	if t == nil || (t.Shares == 0 && t.Period == 0 && t.Quota == 0) {
+		return nil
+	}
+	return &CPUTune{Shares: t.Shares, Period: t.Period, Quota: t.Quota}
+}
+
+// getDomainConfig 从域当前 XML 读取 CPU 型号、CPU 调度参数、IO 权重与 NUMA 绑定，未配置的项保持为空
+func (c *Client) getDomainConfig(domain libvirt.Domain, info *DomainInfo) error {
+	xmlDesc, err := c.conn.DomainGetXMLDesc(domain, 0)
+	if err != nil {
+		return fmt.Errorf("get domain XML: %w", err)
+	}
+
+	var domainXML DomainXML
+	if err := xml.Unmarshal([]byte(xmlDesc), &domainXML); err != nil {
+		return fmt.Errorf("unmarshal domain XML: %w", err)
+	}
+	info.CPUModel = cpuModelFromXML(domainXML.CPU)
+	info.CPUTune = cpuTuneFromXML(domainXML.CPUTune)
+	info.BlkioTune = blkioTuneFromXML(domainXML.BlkioTune)
+	info.NUMATune = numaTuneFromXML(domainXML.NUMATune)
+	meta := domainXML.Metadata.jvpInstance()
+	info.AddressFamily = meta.AddressFamily
+	info.MACSpoofCheck = meta.MACSpoofCheck
+	info.CreatedAt = meta.createdAt()
+	info.LoginUser = meta.LoginUser
+	info.Labels = domainXML.Metadata.jvpLabels()
+	return nil
+}
+
+// cpuModelFromXML 返回 guest 看到的 CPU 型号：host-passthrough 与 host-model 返回模式名，自定义型号返回型号名
+// 运行中的 host-model 域在 live XML 中已展开为具体型号；未配置 cpu 元素时为空（由 hypervisor 使用默认型号）
+func cpuModelFromXML(cpu *DomainCPU) string {
+	if cpu == nil {
+		return ""
+	}
+	if cpu.Model != nil && cpu.Model.Value != "" && cpu.Mode != "host-passthrough" {
+		return cpu.Model.Value
+	}
+	return cpu.Mode
+}
+
+// SetDomainCPUTune 修改域的 CPU 权重与上限（cpu_shares、vcpu_period、vcpu_quota）
+// 运行中的域立即生效并同时写入持久化配置，关机的域只修改持久化配置；为 0 的字段保持原值
+func (c *Client) SetDomainCPUTune(domainName string, tune CPUTune) error {
+	if err := tune.Validate(); err != nil {
+		return err
+	}
+
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return fmt.Errorf("lookup domain: %w", err)
+	}
+
+	var params []libvirt.TypedParam
+	if tune.Shares != 0 {
+		params = append(params, libvirt.TypedParam{Field: "cpu_shares", Value: *libvirt.NewTypedParamValueUllong(tune.Shares)})
+	}
+	if tune.Period != 0 {
+		params = append(params, libvirt.TypedParam{Field: "vcpu_period", Value: *libvirt.NewTypedParamValueUllong(tune.Period)})
+	}
+	if tune.Quota != 0 {
+		params = append(params, libvirt.TypedParam{Field: "vcpu_quota", Value: *libvirt.NewTypedParamValueLlong(max(tune.Quota, -1))})
+	}
+	if len(params) == 0 {
+		return nil
+	}
+
+	flags := libvirt.DomainAffectConfig
+	if c.isDomainRunning(domain) {
+		flags |= libvirt.DomainAffectLive
+	}
+
+	if err := c.conn.DomainSetSchedulerParametersFlags(domain, params, uint32(flags)); err != nil {
+		return fmt.Errorf("set scheduler parameters: %w", err)
+	}
+	return nil
+}
+
+// 磁盘 IO 权重取值范围（与 libvirt qemu 驱动一致）
+const (
+	minBlkioWeight = 10
+	maxBlkioWeight = 1000
+)
+
+// BlkioTune 域级磁盘 IO 调优参数（blkiotune，cgroup blkio/io 控制器）
+// 与 per-disk iotune 的绝对限速不同，weight 只在磁盘 IO 争抢时按比例分配带宽
+type BlkioTune struct {
+	Weight uint `json:"weight,omitempty"` // IO 权重：10-1000，默认 500（cgroup v2 下由 libvirt 换算为 io.weight）
+}
+
+// Validate 校验 IO 权重范围
+func (t *BlkioTune) Validate() error {
+	if t.Weight != 0 && (t.Weight < minBlkioWeight || t.Weight > maxBlkioWeight) {
+		return fmt.Errorf("blkio weight %d out of range [%d, %d]", t.Weight, minBlkioWeight, maxBlkioWeight)
+	}
+	return nil
+}
+
+// toXML 转换为 blkiotune 元素，未设置权重时返回 nil
+func (t *BlkioTune) toXML() *DomainBlkioTune {
+	if t == nil || t.Weight == 0 {
+		return nil
+	}
+	return &DomainBlkioTune{Weight: t.Weight}
+}
+
+// blkioTuneFromXML 从 blkiotune 元素解析 IO 调优参数
+func blkioTuneFromXML(t *DomainBlkioTune) *BlkioTune {
+	if t == nil || t.Weight == 0 {
+		return nil
+	}
+	return &BlkioTune{Weight: t.Weight}
+}
+
+// SetDomainBlkioTune 修改域的磁盘 IO 权重
+// 运行中的域立即生效并同时写入持久化配置，关机的域只修改持久化配置；为 0 的字段保持原值
+func (c *Client) SetDomainBlkioTune(domainName string, tune BlkioTune) error {
+	if err := tune.Validate(); err != nil {
+		return err
+	}
+	if tune.Weight == 0 {
+		return nil
+	}
+
+	domain, err := c.conn.DomainLookupByName(domainName)
+	if err != nil {
+		return fmt.Errorf("lookup domain: %w", err)
+	}
+
+	params := []libvirt.TypedParam{
+		{Field: "weight", Value: *libvirt.NewTypedParamValueUint(uint32(tune.Weight))},
+	}
+
+	flags := libvirt.DomainAffectConfig
+	if c.isDomainRunning(domain) {
+		flags |= libvirt.DomainAffectLive
+	}
+
+	if err := c.conn.DomainSetBlkioParameters(domain, params, uint32(flags)); err != nil {
+		return fmt.Errorf("set blkio parameters: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package libvirt
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/digitalocean/go-libvirt"
+	"github.com/rs/zerolog/log"
+)
+
+// vncSocketPath 从 domain 配置中读取 VNC Unix socket 路径，未使用 socket 时返回空字符串
+// 新版 libvirt 会把 graphics 的 socket 属性规范化为 <listen type='socket' socket='...'/>，两处都需要检查
+func vncSocketPath(domainXML *DomainXML) string {
+	for _, graphics := range domainXML.Devices.Graphics {
+		if graphics.Type != "vnc" {
+			continue
+		}
+		if graphics.Socket != "" {
+			return graphics.Socket
+		}
+		if graphics.Listen != nil && graphics.Listen.Type == "socket" && graphics.Listen.Socket != "" {
+			return graphics.Listen.Socket
+		}
+	}
+	return ""
+}
+
+// prepareVNCSocketDir 创建 VNC socket 目录并按节点的属主修复策略设置所有者，使 QEMU 进程可以创建 socket
+func (c *Client) prepareVNCSocketDir(vncDir string) error {
+	policy := ownershipPolicyFor(c.uri)
+	if c.IsRemoteConnection() {
+		// 远程连接：通过 SSH 创建目录
+		if err := c.ExecuteRemoteCommand(fmt.Sprintf("mkdir -p '%s' && chmod 755 '%s'", vncDir, vncDir)); err != nil {
+			return fmt.Errorf("create VNC socket directory on remote: %w", err)
+		}
+		// 尝试设置目录所有者（可能失败，取决于远程系统配置）
+		if !policy.Disabled {
+			_ = c.ExecuteRemoteCommand(policy.chownCommand(vncDir))
+		}
+		return nil
+	}
+
+	// 本地连接：直接创建目录
+	if err := os.MkdirAll(vncDir, 0o755); err != nil {
+		return fmt.Errorf("create VNC socket directory: %w", err)
+	}
+	if policy.Disabled {
+		return nil
+	}
+	// 设置目录所有者为 QEMU 进程用户，以便 QEMU 进程可以创建 socket
+	if err := policy.chownLocal(vncDir); err != nil {
+		// 非致命错误，只记录警告
+		log.Warn().Err(err).Str("dir", vncDir).Msg("Failed to fix VNC directory ownership")
+	}
+	return nil
+}
+
+// domainVNCSocketDir 从持久化配置中读取 domain 的 VNC socket 目录，未使用 socket 时返回空字符串
+func (c *Client) domainVNCSocketDir(domain libvirt.Domain) (string, error) {
+	xmlDesc, err := c.conn.DomainGetXMLDesc(domain, libvirt.DomainXMLInactive)
+	if err != nil {
+		return "", fmt.Errorf("get domain XML: %w", err)
+	}
+	var domainXML DomainXML
+	if err := xml.Unmarshal([]byte(xmlDesc), &domainXML); err != nil {
+		return "", fmt.Errorf("unmarshal domain XML: %w", err)
+	}
+	socket := vncSocketPath(&domainXML)
+	if socket == "" {
+		return "", nil
+	}
+	return filepath.Dir(socket), nil
+}
+
+// prepareVNCSocketDirs 连接节点时一次性为所有持久化 domain 准备 VNC socket 目录
+// 宿主机重启后 libvirt autostart 的实例以及 JVP 之外启动的实例都依赖这些目录
+func (c *Client) prepareVNCSocketDirs() error {
+	domains, _, err := c.conn.ConnectListAllDomains(1, libvirt.ConnectListDomainsPersistent)
+	if err != nil {
+		return fmt.Errorf("list persistent domains: %w", err)
+	}
+
+	prepared := make(map[string]bool)
+	for _, domain := range domains {
+		dir, err := c.domainVNCSocketDir(domain)
+		if err != nil {
+			log.Warn().Err(err).Str("domain", domain.Name).Msg("Failed to read VNC socket of domain")
+			continue
+		}
+		if dir == "" || prepared[dir] {
+			continue
+		}
+		if err := c.prepareVNCSocketDir(dir); err != nil {
+			log.Warn().Err(err).Str("dir", dir).Msg("Failed to prepare VNC socket directory")
+			continue
+		}
+		prepared[dir] = true
+	}
+	return nil
+}
+
+// watchVNCSocketDirs 订阅 domain 生命周期事件，在 domain 被定义或修改时准备 VNC socket 目录
+// 覆盖通过 virsh define 等方式在 JVP 之外定义的实例；连接断开时退出
+func (c *Client) watchVNCSocketDirs() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := c.conn.LifecycleEvents(ctx)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("subscribe lifecycle events: %w", err)
+	}
+
+	go func() {
+		defer cancel()
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if libvirt.DomainEventType(event.Event) != libvirt.DomainEventDefined {
+					continue
+				}
+				dir, err := c.domainVNCSocketDir(event.Dom)
+				if err != nil || dir == "" {
+					continue
+				}
+				if err := c.prepareVNCSocketDir(dir); err != nil {
+					log.Warn().Err(err).Str("domain", event.Dom.Name).Msg("Failed to prepare VNC socket directory")
+				}
+			case <-c.conn.Disconnected():
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -7,6 +7,7 @@
 //   - 获取镜像信息（Info）
 //   - 检查镜像完整性（Check）
 //   - 创建空镜像（CreateEmpty）
+//   - 修改 backing file 或扁平化（Rebase）
 //
 // 所有操作都支持 context 超时控制，适合长时间运行的操作。
 //
@@ -25,4 +25,8 @@ type QemuImgClient interface {
 	DeleteSnapshot(ctx context.Context, imagePath, snapshotName string) error
 	// ListSnapshots 列出镜像的所有快照
 	ListSnapshots(ctx context.Context, imagePath string) ([]string, error)
+	// Rebase 修改镜像的 backing file，backingFile 为空时扁平化
+	Rebase(ctx context.Context, imagePath, backingFile, backingFormat string) error
+	// GetBackingFile 获取镜像的 backing file 路径
+	GetBackingFile(ctx context.Context, imagePath string) (string, error)
 }
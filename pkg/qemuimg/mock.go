@@ -79,3 +79,15 @@ func (m *MockClient) ListSnapshots(ctx context.Context, imagePath string) ([]str
 	}
 	return args.Get(0).([]string), args.Error(1)
 }
+
+// Rebase 实现 QemuImgClient 接口
+func (m *MockClient) Rebase(ctx context.Context, imagePath, backingFile, backingFormat string) error {
+	args := m.Called(ctx, imagePath, backingFile, backingFormat)
+	return args.Error(0)
+}
+
+// GetBackingFile 实现 QemuImgClient 接口
+func (m *MockClient) GetBackingFile(ctx context.Context, imagePath string) (string, error) {
+	args := m.Called(ctx, imagePath)
+	return args.String(0), args.Error(1)
+}
@@ -6,6 +6,9 @@ import (
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/jimyag/jvp/pkg/tracing"
 )
 
 // Client 封装 qemu-img 命令行工具的操作
@@ -48,11 +51,12 @@ func (c *Client) IsRemote() bool {
 
 // executeCommand 执行命令，支持本地和远程执行
 func (c *Client) executeCommand(ctx context.Context, args ...string) ([]byte, error) {
+	ctx, span := tracing.StartCommand(ctx, "qemu-img", args, c.sshTarget)
 	var cmd *exec.Cmd
 
 	if c.sshTarget != "" {
-		// 远程执行：通过 SSH
-		remoteCmd := c.qemuImgPath + " " + strings.Join(args, " ")
+		// 远程执行：通过 SSH，参数逐个转义，避免空参数（如 rebase -b ""）或路径中的空格被远程 shell 拆分
+		remoteCmd := c.qemuImgPath + " " + shellx.Join(args...)
 		cmd = exec.CommandContext(ctx, "ssh",
 			"-o", "StrictHostKeyChecking=no",
 			"-o", "BatchMode=yes",
@@ -64,7 +68,9 @@ func (c *Client) executeCommand(ctx context.Context, args ...string) ([]byte, er
 		cmd = exec.CommandContext(ctx, c.qemuImgPath, args...)
 	}
 
-	return cmd.CombinedOutput()
+	output, err := cmd.CombinedOutput()
+	tracing.End(span, err)
+	return output, err
 }
 
 // CreateFromBackingFile 从 backing file 创建新镜像
@@ -153,6 +159,36 @@ func (c *Client) Convert(ctx context.Context, inputFormat, outputFormat, inputFi
 	return nil
 }
 
+// Rebase 修改镜像的 backing file
+// backingFile 为空时把整条 backing 链的数据合入镜像，解除对 backing file 的依赖（扁平化）
+// 使用安全模式（不带 -u），镜像不能被运行中的虚拟机使用
+//
+// 参数：
+//   - imagePath: 镜像文件路径
+//   - backingFile: 新的 backing file 路径，为空表示扁平化
+//   - backingFormat: 新 backing file 的格式，backingFile 为空时忽略
+//
+// 示例：
+//
+//	err := client.Rebase(ctx, "/path/to/disk.qcow2", "", "")
+func (c *Client) Rebase(ctx context.Context, imagePath, backingFile, backingFormat string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	args := []string{"rebase", "-b", backingFile}
+	if backingFile != "" && backingFormat != "" {
+		args = append(args, "-F", backingFormat)
+	}
+	args = append(args, imagePath)
+
+	output, err := c.executeCommand(ctx, args...)
+	if err != nil {
+		return fmt.Errorf("failed to rebase image %s onto %q: %w, output: %s", imagePath, backingFile, err, string(output))
+	}
+
+	return nil
+}
+
 // Info 获取镜像信息
 // 返回 qemu-img info 的原始输出
 //
@@ -0,0 +1,123 @@
+package qmp
+
+import "sort"
+
+// allowedCommands 只读查询命令白名单，均不会改变 domain 状态
+var allowedCommands = map[string]bool{
+	"query-status":               true,
+	"query-version":              true,
+	"query-kvm":                  true,
+	"query-name":                 true,
+	"query-uuid":                 true,
+	"query-cpus-fast":            true,
+	"query-iothreads":            true,
+	"query-balloon":              true,
+	"query-memory-size-summary":  true,
+	"query-memory-devices":       true,
+	"query-memdev":               true,
+	"query-block":                true,
+	"query-blockstats":           true,
+	"query-block-jobs":           true,
+	"query-named-block-nodes":    true,
+	"query-jobs":                 true,
+	"query-migrate":              true,
+	"query-migrate-parameters":   true,
+	"query-migrate-capabilities": true,
+	"query-dirty-rate":           true,
+	"query-chardev":              true,
+	"query-vnc":                  true,
+	"query-spice":                true,
+	"query-hotpluggable-cpus":    true,
+}
+
+// IsAllowed 命令是否在只读白名单中
+func IsAllowed(command string) bool {
+	return allowedCommands[command]
+}
+
+// AllowedCommands 返回白名单中的全部命令，按名称排序
+func AllowedCommands() []string {
+	commands := make([]string, 0, len(allowedCommands))
+	for command := range allowedCommands {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+// Status query-status 的结果
+type Status struct {
+	Status     string `json:"status"`  // 运行状态，如 running、paused、inmigrate、postmigrate
+	Running    bool   `json:"running"` // vCPU 是否在运行
+	Singlestep bool   `json:"singlestep"`
+}
+
+// QueryStatus 查询虚拟机运行状态
+func (c *Client) QueryStatus() (*Status, error) {
+	status := &Status{}
+	if err := c.run("query-status", nil, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// BlockJob query-block-jobs 返回的 block job
+type BlockJob struct {
+	Type     string `json:"type"`      // stream、commit、mirror、backup
+	Device   string `json:"device"`    // 任务 ID，libvirt 创建的任务为 block node 名称
+	Len      int64  `json:"len"`       // 预计总字节数，mirror 期间随 guest 写入增长
+	Offset   int64  `json:"offset"`    // 已完成字节数
+	Speed    int64  `json:"speed"`     // 限速（字节/秒），0 表示不限速
+	Busy     bool   `json:"busy"`      // 是否正在处理数据
+	Paused   bool   `json:"paused"`    // 是否被暂停
+	Ready    bool   `json:"ready"`     // mirror/active-commit 是否已同步完成，可以 pivot
+	Status   string `json:"status"`    // 任务状态，如 running、ready、concluded
+	IOStatus string `json:"io-status"` // 任务的 I/O 状态：ok、failed、nospace
+}
+
+// QueryBlockJobs 查询 domain 正在执行的 block job
+func (c *Client) QueryBlockJobs() ([]BlockJob, error) {
+	var jobs []BlockJob
+	if err := c.run("query-block-jobs", nil, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// MigrationRAM 迁移中内存传输的统计
+type MigrationRAM struct {
+	Transferred      int64   `json:"transferred"`       // 已传输字节数
+	Remaining        int64   `json:"remaining"`         // 剩余字节数
+	Total            int64   `json:"total"`             // 内存总字节数
+	Duplicate        int64   `json:"duplicate"`         // 全零页数量
+	Normal           int64   `json:"normal"`            // 完整传输的页数量
+	NormalBytes      int64   `json:"normal-bytes"`      // 完整传输的字节数
+	DirtyPagesRate   int64   `json:"dirty-pages-rate"`  // 每秒产生的脏页数量
+	MBPS             float64 `json:"mbps"`              // 传输速率（Mbit/s）
+	DirtySyncCount   int64   `json:"dirty-sync-count"`  // 脏页同步轮数
+	PostcopyRequests int64   `json:"postcopy-requests"` // post-copy 阶段的缺页请求数量
+	PageSize         int64   `json:"page-size"`         // 页大小
+}
+
+// MigrationInfo query-migrate 的结果，没有迁移时除 Status 外均为空
+type MigrationInfo struct {
+	Status           string        `json:"status,omitempty"`            // none、setup、active、postcopy-active、completed、failed、cancelled 等
+	TotalTime        int64         `json:"total-time,omitempty"`        // 已耗时（毫秒）
+	ExpectedDowntime int64         `json:"expected-downtime,omitempty"` // 预计停机时间（毫秒）
+	Downtime         int64         `json:"downtime,omitempty"`          // 实际停机时间（毫秒），完成后有效
+	SetupTime        int64         `json:"setup-time,omitempty"`        // 准备阶段耗时（毫秒）
+	RAM              *MigrationRAM `json:"ram,omitempty"`
+	ErrorDesc        string        `json:"error-desc,omitempty"` // 失败原因
+}
+
+// QueryMigrate 查询迁移状态与内存传输统计
+func (c *Client) QueryMigrate() (*MigrationInfo, error) {
+	info := &MigrationInfo{}
+	if err := c.run("query-migrate", nil, info); err != nil {
+		return nil, err
+	}
+	if info.Status == "" {
+		info.Status = "none"
+	}
+	return info, nil
+}
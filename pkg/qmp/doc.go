@@ -0,0 +1,22 @@
+// Package qmp 通过 libvirt 的 QEMU monitor 通道执行 QMP 命令
+//
+// QEMU 由 libvirt 托管时没有独立的 QMP socket，命令经 virDomainQemuMonitorCommand 转发给 domain 的 monitor。
+// 该通道用于查询 libvirt API 覆盖不到的信息，例如 block job 的 ready/busy 状态、迁移的脏页速率等。
+//
+// 绕过 libvirt 修改 QEMU 状态会使 libvirt 记录的 domain 状态与 QEMU 不一致（libvirt 会把 domain 标记为 tainted），
+// 因此只允许执行白名单中的只读查询命令（AllowedCommands），其他命令返回 ErrCommandNotAllowed。
+//
+// 示例：
+//
+//	// monitor 通常是 libvirt.LibvirtClient
+//	client := qmp.New(monitor, "i-abc123")
+//
+//	// 查询 block job
+//	jobs, err := client.QueryBlockJobs()
+//
+//	// 查询迁移状态
+//	info, err := client.QueryMigrate()
+//
+//	// 执行任意白名单命令，返回 return 字段的原始 JSON
+//	result, err := client.Execute("query-blockstats", nil)
+package qmp
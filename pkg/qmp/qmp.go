@@ -0,0 +1,87 @@
+package qmp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrCommandNotAllowed 命令不在只读白名单中
+var ErrCommandNotAllowed = errors.New("qmp command not allowed")
+
+// Monitor 执行 QEMU monitor 命令的通道，libvirt.LibvirtClient 实现了该接口
+type Monitor interface {
+	QEMUMonitorCommand(domainName, command string) (string, error)
+}
+
+// Error QEMU 返回的 QMP 错误
+type Error struct {
+	Class string `json:"class"` // 错误类别，如 GenericError、CommandNotFound
+	Desc  string `json:"desc"`  // 错误描述
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("qmp error %s: %s", e.Class, e.Desc)
+}
+
+// Client 对一个 domain 执行 QMP 命令
+type Client struct {
+	monitor Monitor
+	domain  string
+}
+
+// New 创建 domain 的 QMP client
+func New(monitor Monitor, domainName string) *Client {
+	return &Client{
+		monitor: monitor,
+		domain:  domainName,
+	}
+}
+
+// Execute 执行一条白名单中的 QMP 命令，返回 return 字段的原始 JSON
+// QEMU 返回 error 时返回 *Error
+func (c *Client) Execute(command string, arguments map[string]any) (json.RawMessage, error) {
+	if !IsAllowed(command) {
+		return nil, fmt.Errorf("%w: %s", ErrCommandNotAllowed, command)
+	}
+
+	request := map[string]any{"execute": command}
+	if len(arguments) > 0 {
+		request["arguments"] = arguments
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s: %w", command, err)
+	}
+	output, err := c.monitor.QEMUMonitorCommand(c.domain, string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", command, err)
+	}
+
+	response := struct {
+		Return json.RawMessage `json:"return"`
+		Error  *Error          `json:"error"`
+	}{}
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		return nil, fmt.Errorf("unmarshal %s response: %w", command, err)
+	}
+	if response.Error != nil {
+		return nil, response.Error
+	}
+	if response.Return == nil {
+		return nil, fmt.Errorf("%s: response has no return", command)
+	}
+	return response.Return, nil
+}
+
+// run 执行命令并把 return 字段解析到 result
+func (c *Client) run(command string, arguments map[string]any, result any) error {
+	raw, err := c.Execute(command, arguments)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, result); err != nil {
+		return fmt.Errorf("unmarshal %s result: %w", command, err)
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+// Package shellx 提供拼接 shell 命令时的参数转义
+//
+// JVP 在远程节点上通过 ssh 执行命令，ssh 会把参数拼接为一条命令交给远程 shell 解释，
+// 路径、URL 等来自用户输入的参数必须逐个转义，否则其中的空格、引号或 ; 会被远程 shell 解释。
+//
+// 使用方式：
+//
+//	cmd := "rm -f " + shellx.Quote(path)
+//	remote := "qemu-img " + shellx.Join(args...)
+package shellx
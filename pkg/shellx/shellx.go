@@ -0,0 +1,17 @@
+package shellx
+
+import "strings"
+
+// Quote 用单引号包裹参数，参数内的单引号通过闭合引号、转义、重新打开的方式保留
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Join 逐个转义参数并以空格连接，空参数转义为一对单引号，不会被 shell 丢弃
+func Join(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = Quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,25 @@
+// Package tracing 封装 OpenTelemetry 分布式追踪
+//
+// 该包提供：
+//   - 初始化 OTLP/HTTP 导出器与全局 TracerProvider（Setup）
+//   - 创建子 span 并在结束时记录错误（Start / End）
+//   - 为外部命令（qemu-img、zfs、ssh 等）创建 span（StartCommand）
+//
+// 未配置导出地址时使用 OpenTelemetry 默认的 no-op 实现，span 不会被记录，开销可以忽略。
+// 上游通过 W3C traceparent 请求头传入的 trace 会被延续。
+//
+// 示例：
+//
+//	// 进程启动时初始化，退出前刷新未导出的 span
+//	shutdown, err := tracing.Setup(ctx, tracing.Config{
+//		Endpoint:    "http://otel-collector:4318",
+//		ServiceName: "jvp",
+//		SampleRatio: 1,
+//	})
+//	defer shutdown(context.Background())
+//
+//	// 在调用链中创建子 span
+//	ctx, span := tracing.Start(ctx, "CreateDisk", attribute.String("pool", "default"))
+//	err := createDisk(ctx)
+//	tracing.End(span, err)
+package tracing
@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 创建 span 使用的 tracer 名称
+const instrumentationName = "github.com/jimyag/jvp"
+
+// Config 追踪配置
+type Config struct {
+	Endpoint    string  // OTLP/HTTP 导出地址，如 http://otel-collector:4318，为空时不启用追踪
+	ServiceName string  // service.name 资源属性（默认：jvp）
+	SampleRatio float64 // 新 trace 的采样比例 (0, 1]，上游已采样的 trace 总是采样（默认：1）
+}
+
+// Setup 初始化 OTLP/HTTP 导出器并设置全局 TracerProvider 与 W3C trace context 传播
+// 返回的 shutdown 用于进程退出前刷新未导出的 span；Endpoint 为空时只设置传播器，shutdown 为空操作
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("create otlp exporter: %w", err)
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = "jvp"
+	}
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(attribute.String("service.name", serviceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create trace resource: %w", err)
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer 返回 JVP 使用的 tracer
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start 以 ctx 中的 span 为父节点创建子 span
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束 span，err 不为空时记录错误并把 span 状态设为 Error
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// StartCommand 为外部命令创建 client 类型的 span，span 名称为程序名加第一个参数（如 "qemu-img convert"）
+// sshTarget 为远程执行的 SSH 目标，本地执行时为空
+func StartCommand(ctx context.Context, program string, args []string, sshTarget string) (context.Context, trace.Span) {
+	name := program
+	if len(args) > 0 {
+		name += " " + args[0]
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("process.executable.name", program),
+		attribute.String("process.command_args", strings.Join(args, " ")),
+	}
+	if sshTarget != "" {
+		attrs = append(attrs, attribute.String("jvp.ssh_target", sshTarget))
+	}
+	return Tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// TraceID 返回 ctx 中 span 的 trace ID，没有有效 span 时返回空字符串
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
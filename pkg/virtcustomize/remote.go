@@ -0,0 +1,153 @@
+package virtcustomize
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/rs/zerolog"
+)
+
+// RemoteClient 通过 SSH 在远程节点上执行 virt-customize
+// 命令按 argv 逐个参数转义后交给远程 shell，密码经 stdin 写入远程临时文件，
+// 再以 --password user:file:PATH 传入，不出现在命令行中
+type RemoteClient struct {
+	sshTarget string // SSH 目标，格式 user@host
+	timeout   time.Duration
+}
+
+// 确保 RemoteClient 实现了 VirtCustomizeClient 接口
+var _ VirtCustomizeClient = (*RemoteClient)(nil)
+
+// NewRemoteClient 创建远程 virt-customize 客户端
+func NewRemoteClient(sshTarget string) *RemoteClient {
+	return &RemoteClient{
+		sshTarget: sshTarget,
+		timeout:   5 * time.Minute,
+	}
+}
+
+// ResetPassword 重置单个用户的密码
+func (c *RemoteClient) ResetPassword(ctx context.Context, diskPath string, username, password string) error {
+	return c.ResetMultiplePasswords(ctx, diskPath, map[string]string{username: password})
+}
+
+// ResetMultiplePasswords 重置多个用户的密码
+func (c *RemoteClient) ResetMultiplePasswords(ctx context.Context, diskPath string, users map[string]string) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().
+		Str("ssh_target", c.sshTarget).
+		Str("disk_path", diskPath).
+		Int("user_count", len(users)).
+		Msg("Resetting multiple passwords on remote node")
+
+	if len(users) == 0 {
+		return fmt.Errorf("no users specified")
+	}
+
+	// 按用户名排序，保证参数顺序稳定
+	usernames := make([]string, 0, len(users))
+	for username := range users {
+		if username == "" || strings.Contains(username, ":") {
+			return fmt.Errorf("invalid username: %q", username)
+		}
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	cmdCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if _, err := c.run(cmdCtx, nil, "test", "-f", diskPath); err != nil {
+		return fmt.Errorf("disk file not found on remote node: %s: %w", diskPath, err)
+	}
+
+	// 创建仅 owner 可读的临时目录存放密码文件，结束后清理
+	output, err := c.run(cmdCtx, nil, "mktemp", "-d")
+	if err != nil {
+		return fmt.Errorf("create remote temp dir: %w", err)
+	}
+	tmpDir := strings.TrimSpace(string(output))
+	defer func() {
+		if _, err := c.run(context.WithoutCancel(ctx), nil, "rm", "-rf", tmpDir); err != nil {
+			logger.Warn().
+				Err(err).
+				Str("tmp_dir", tmpDir).
+				Msg("Failed to remove remote password files")
+		}
+	}()
+
+	args := []string{"virt-customize", "-a", diskPath}
+	for i, username := range usernames {
+		passwordFile := path.Join(tmpDir, fmt.Sprintf("password-%d", i))
+		// 通过位置参数传递文件路径，密码内容只经过 stdin
+		writeCmd := []string{"sh", "-c", `umask 077 && cat > "$1"`, "sh", passwordFile}
+		if _, err := c.run(cmdCtx, strings.NewReader(users[username]), writeCmd...); err != nil {
+			return fmt.Errorf("write remote password file: %w", err)
+		}
+		args = append(args, "--password", fmt.Sprintf("%s:file:%s", username, passwordFile))
+	}
+
+	if output, err := c.run(cmdCtx, nil, args...); err != nil {
+		logger.Error().
+			Err(err).
+			Str("output", string(output)).
+			Msg("Failed to reset multiple passwords on remote node")
+		return fmt.Errorf("virt-customize failed: %w", err)
+	}
+
+	logger.Info().
+		Int("user_count", len(users)).
+		Msg("Multiple passwords reset successfully on remote node")
+
+	return nil
+}
+
+// ValidateDiskPath 验证远程磁盘路径是否有效
+func (c *RemoteClient) ValidateDiskPath(diskPath string) error {
+	ext := strings.ToLower(filepath.Ext(diskPath))
+	if ext != ".qcow2" {
+		return fmt.Errorf("unsupported disk format: %s (only qcow2 is supported)", ext)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	if _, err := c.run(ctx, nil, "test", "-f", diskPath); err != nil {
+		return fmt.Errorf("disk file not found on remote node: %s", diskPath)
+	}
+
+	return nil
+}
+
+// SetTimeout 设置命令超时时间
+func (c *RemoteClient) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// run 通过 SSH 执行 argv，每个参数单独转义，远程 shell 不会对参数内容做解释
+// 返回远程命令的 stdout；stderr（含 ssh 自身的告警）仅在失败时附加到错误中
+func (c *RemoteClient) run(ctx context.Context, stdin io.Reader, argv ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "ssh",
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "BatchMode=yes",
+		c.sshTarget,
+		shellx.Join(argv...),
+	)
+	cmd.Stdin = stdin
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return stdout.Bytes(), fmt.Errorf("ssh %s: %w, stderr: %s", argv[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,25 @@
+// Package zfs 封装 zfs 命令行工具的操作
+//
+// 该包提供了对 zvol 与快照常用操作的封装，包括：
+//   - 创建 zvol（CreateVolume）
+//   - 查询 dataset 信息（Get）
+//   - 调整 zvol 大小（SetVolSize）
+//   - 创建快照（Snapshot）
+//   - 从快照克隆（Clone）
+//   - 删除 dataset 或快照（Destroy）
+//
+// 快照与克隆都是写时复制，与数据量无关，通常在秒级完成。
+// 远程节点通过 SSH 执行 zfs 命令。
+//
+// 示例：
+//
+//	// 创建 client
+//	client := zfs.New("").WithSSHTarget("root@node1")
+//
+//	// 创建 20GB 的稀疏 zvol
+//	err := client.CreateVolume(ctx, "tank/jvp/vol-1", 20<<30, true)
+//
+//	// 对源卷打快照并克隆出新卷
+//	err = client.Snapshot(ctx, "tank/jvp/vol-1@base")
+//	err = client.Clone(ctx, "tank/jvp/vol-1@base", "tank/jvp/vol-2")
+package zfs
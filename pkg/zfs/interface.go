@@ -0,0 +1,20 @@
+package zfs
+
+import "context"
+
+// ZFSClient 定义了 zfs 客户端的接口
+// 用于抽象 zfs 操作，便于测试和 mock
+type ZFSClient interface {
+	// Get 查询 dataset 信息
+	Get(ctx context.Context, name string) (*Dataset, error)
+	// CreateVolume 创建 zvol
+	CreateVolume(ctx context.Context, name string, sizeBytes uint64, sparse bool) error
+	// SetVolSize 调整 zvol 容量
+	SetVolSize(ctx context.Context, name string, sizeBytes uint64) error
+	// Snapshot 创建快照
+	Snapshot(ctx context.Context, snapshot string) error
+	// Clone 从快照克隆出新的 dataset
+	Clone(ctx context.Context, snapshot, target string) error
+	// Destroy 删除 dataset 或快照
+	Destroy(ctx context.Context, name string, deferred bool) error
+}
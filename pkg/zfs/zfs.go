@@ -0,0 +1,184 @@
+package zfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jimyag/jvp/pkg/shellx"
+	"github.com/jimyag/jvp/pkg/tracing"
+)
+
+var (
+	// ErrDatasetNotFound dataset 或快照不存在
+	ErrDatasetNotFound = errors.New("dataset does not exist")
+	// ErrHasDependents dataset 存在子 dataset、快照或依赖它的克隆，不能直接删除
+	ErrHasDependents = errors.New("dataset has dependent snapshots or clones")
+)
+
+// Dataset zfs dataset（zvol、文件系统或快照）信息
+type Dataset struct {
+	Name    string
+	Type    string // volume / filesystem / snapshot
+	VolSize uint64 // zvol 容量（字节），非 zvol 为 0
+	Used    uint64 // 占用空间（字节）
+	Origin  string // 克隆来源快照，非克隆为空
+}
+
+// Client 封装 zfs 命令行工具的操作
+type Client struct {
+	zfsPath string
+	timeout time.Duration
+	// 远程执行相关
+	sshTarget string // SSH 目标，格式: user@host，为空表示本地执行
+}
+
+var _ ZFSClient = (*Client)(nil)
+
+// New 创建新的 zfs client
+// zfsPath 是 zfs 的路径，如果为空则使用默认的 "zfs"
+func New(zfsPath string) *Client {
+	if zfsPath == "" {
+		zfsPath = "zfs"
+	}
+	return &Client{
+		zfsPath: zfsPath,
+		timeout: 5 * time.Minute, // zfs 元数据操作通常在秒级完成
+	}
+}
+
+// WithTimeout 设置操作超时时间
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	c.timeout = timeout
+	return c
+}
+
+// WithSSHTarget 设置远程 SSH 目标，用于在远程主机上执行 zfs 命令
+// sshTarget 格式: user@host，为空表示本地执行
+func (c *Client) WithSSHTarget(sshTarget string) *Client {
+	c.sshTarget = sshTarget
+	return c
+}
+
+// IsRemote 检查是否为远程执行模式
+func (c *Client) IsRemote() bool {
+	return c.sshTarget != ""
+}
+
+// executeCommand 执行 zfs 命令，支持本地和远程执行，失败时按输出识别常见错误
+func (c *Client) executeCommand(ctx context.Context, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	ctx, span := tracing.StartCommand(ctx, "zfs", args, c.sshTarget)
+
+	var cmd *exec.Cmd
+	if c.sshTarget != "" {
+		cmd = exec.CommandContext(ctx, "ssh",
+			"-o", "StrictHostKeyChecking=no",
+			"-o", "BatchMode=yes",
+			c.sshTarget,
+			c.zfsPath+" "+shellx.Join(args...),
+		)
+	} else {
+		cmd = exec.CommandContext(ctx, c.zfsPath, args...)
+	}
+
+	output, err := cmd.CombinedOutput()
+	tracing.End(span, err)
+	if err != nil {
+		message := strings.TrimSpace(string(output))
+		switch {
+		case strings.Contains(message, "does not exist"):
+			return nil, fmt.Errorf("%w: %s", ErrDatasetNotFound, message)
+		case strings.Contains(message, "has children"), strings.Contains(message, "has dependent clones"):
+			return nil, fmt.Errorf("%w: %s", ErrHasDependents, message)
+		}
+		return nil, fmt.Errorf("zfs %s: %w, output: %s", args[0], err, message)
+	}
+	return output, nil
+}
+
+// Get 查询 dataset 信息
+func (c *Client) Get(ctx context.Context, name string) (*Dataset, error) {
+	output, err := c.executeCommand(ctx, "get", "-H", "-p", "-o", "property,value", "type,volsize,used,origin", name)
+	if err != nil {
+		return nil, err
+	}
+
+	dataset := &Dataset{Name: name}
+	for line := range strings.SplitSeq(strings.TrimSpace(string(output)), "\n") {
+		property, value, ok := strings.Cut(line, "\t")
+		if !ok || value == "-" {
+			continue
+		}
+		switch property {
+		case "type":
+			dataset.Type = value
+		case "volsize":
+			dataset.VolSize, _ = strconv.ParseUint(value, 10, 64)
+		case "used":
+			dataset.Used, _ = strconv.ParseUint(value, 10, 64)
+		case "origin":
+			dataset.Origin = value
+		}
+	}
+	return dataset, nil
+}
+
+// CreateVolume 创建 zvol，sparse 为 true 时不预留空间（thin provisioning）
+func (c *Client) CreateVolume(ctx context.Context, name string, sizeBytes uint64, sparse bool) error {
+	args := []string{"create"}
+	if sparse {
+		args = append(args, "-s")
+	}
+	args = append(args, "-V", strconv.FormatUint(sizeBytes, 10), name)
+	if _, err := c.executeCommand(ctx, args...); err != nil {
+		return fmt.Errorf("failed to create zvol %s: %w", name, err)
+	}
+	return nil
+}
+
+// SetVolSize 调整 zvol 容量，只应扩大，缩小会截断 guest 数据
+func (c *Client) SetVolSize(ctx context.Context, name string, sizeBytes uint64) error {
+	if _, err := c.executeCommand(ctx, "set", "volsize="+strconv.FormatUint(sizeBytes, 10), name); err != nil {
+		return fmt.Errorf("failed to resize zvol %s: %w", name, err)
+	}
+	return nil
+}
+
+// Snapshot 创建快照，snapshot 格式为 dataset@name
+func (c *Client) Snapshot(ctx context.Context, snapshot string) error {
+	if !strings.Contains(snapshot, "@") {
+		return fmt.Errorf("invalid snapshot name %q, must be dataset@name", snapshot)
+	}
+	if _, err := c.executeCommand(ctx, "snapshot", snapshot); err != nil {
+		return fmt.Errorf("failed to create snapshot %s: %w", snapshot, err)
+	}
+	return nil
+}
+
+// Clone 从快照克隆出新的 dataset，新 dataset 与快照共享数据块
+func (c *Client) Clone(ctx context.Context, snapshot, target string) error {
+	if _, err := c.executeCommand(ctx, "clone", snapshot, target); err != nil {
+		return fmt.Errorf("failed to clone %s to %s: %w", snapshot, target, err)
+	}
+	return nil
+}
+
+// Destroy 删除 dataset 或快照
+// deferred 只对快照生效：仍有克隆依赖时标记为延迟删除，最后一个克隆删除后自动回收
+func (c *Client) Destroy(ctx context.Context, name string, deferred bool) error {
+	args := []string{"destroy"}
+	if deferred {
+		args = append(args, "-d")
+	}
+	args = append(args, name)
+	if _, err := c.executeCommand(ctx, args...); err != nil {
+		return fmt.Errorf("failed to destroy %s: %w", name, err)
+	}
+	return nil
+}
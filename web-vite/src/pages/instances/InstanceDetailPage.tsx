@@ -104,6 +104,7 @@ export default function InstanceDetailPage() {
         body: JSON.stringify({
           node_name: nodeName,
           instance_ids: [instanceId],
+          include: ["interfaces", "disks"],
         }),
       });
       if (response.ok) {
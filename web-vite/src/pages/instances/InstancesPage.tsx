@@ -189,7 +189,7 @@ export default function InstancesPage() {
       const response = await fetch("/api/describe-instances", {
         method: "POST",
         headers: { "Content-Type": "application/json" },
-        body: JSON.stringify({ node_name: selectedNode }),
+        body: JSON.stringify({ node_name: selectedNode, include: ["interfaces"] }),
       });
       if (response.ok) {
         const data = await response.json();
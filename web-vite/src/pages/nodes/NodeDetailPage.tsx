@@ -188,6 +188,8 @@ export default function NodeDetailPage() {
         return "green";
       case "offline":
         return "red";
+      case "degraded":
+        return "orange";
       case "maintenance":
         return "yellow";
       default:
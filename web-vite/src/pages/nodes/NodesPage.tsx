@@ -13,6 +13,8 @@ interface Node {
   uri: string;
   type: string;
   state: string;
+  zone?: string;
+  rack?: string;
   created_at?: string;
   updated_at?: string;
 }
@@ -26,6 +28,8 @@ export default function NodesPage() {
     name: "",
     uri: "",
     type: "remote" as string,
+    zone: "",
+    rack: "",
   });
   const [creating, setCreating] = useState(false);
   const toast = useToast();
@@ -65,10 +69,12 @@ export default function NodesPage() {
         name: createForm.name,
         uri: createForm.uri,
         type: createForm.type,
+        zone: createForm.zone || undefined,
+        rack: createForm.rack || undefined,
       });
       toast.success(`Node ${createForm.name} created successfully`);
       setShowCreateModal(false);
-      setCreateForm({ name: "", uri: "", type: "remote" });
+      setCreateForm({ name: "", uri: "", type: "remote", zone: "", rack: "" });
       await fetchNodes();
     } catch (error: any) {
       console.error("Failed to create node:", error);
@@ -99,6 +105,8 @@ export default function NodesPage() {
         return "green";
       case "offline":
         return "red";
+      case "degraded":
+        return "orange";
       case "maintenance":
         return "yellow";
       default:
@@ -156,6 +164,15 @@ export default function NodesPage() {
         />
       ),
     },
+    {
+      key: "zone",
+      label: "Zone / Rack",
+      render: (_: unknown, node: Node) => (
+        <span className="text-sm text-gray-600">
+          {node.zone ? `${node.zone}${node.rack ? ` / ${node.rack}` : ""}` : "-"}
+        </span>
+      ),
+    },
     {
       key: "uri",
       label: "URI",
@@ -322,6 +339,37 @@ export default function NodesPage() {
                     <option value="hybrid">Hybrid</option>
                   </select>
                 </div>
+
+                <div className="grid grid-cols-2 gap-4">
+                  <div>
+                    <label className="block text-sm font-medium text-gray-700 mb-1">
+                      Zone
+                    </label>
+                    <input
+                      type="text"
+                      value={createForm.zone}
+                      onChange={(e) =>
+                        setCreateForm({ ...createForm, zone: e.target.value })
+                      }
+                      className="w-full px-3 py-2 border border-gray-300 rounded-md focus:outline-none focus:ring-2 focus:ring-blue-500"
+                      placeholder="e.g., zone-a"
+                    />
+                  </div>
+                  <div>
+                    <label className="block text-sm font-medium text-gray-700 mb-1">
+                      Rack
+                    </label>
+                    <input
+                      type="text"
+                      value={createForm.rack}
+                      onChange={(e) =>
+                        setCreateForm({ ...createForm, rack: e.target.value })
+                      }
+                      className="w-full px-3 py-2 border border-gray-300 rounded-md focus:outline-none focus:ring-2 focus:ring-blue-500"
+                      placeholder="e.g., rack-01"
+                    />
+                  </div>
+                </div>
               </div>
 
               <div className="flex gap-2 mt-6">
@@ -335,7 +383,7 @@ export default function NodesPage() {
                 <button
                   onClick={() => {
                     setShowCreateModal(false);
-                    setCreateForm({ name: "", uri: "", type: "remote" });
+                    setCreateForm({ name: "", uri: "", type: "remote", zone: "", rack: "" });
                   }}
                   disabled={creating}
                   className="flex-1 btn-secondary"
@@ -51,7 +51,9 @@ export default function SnapshotsPage() {
     snapshot_name: "",
     description: "",
     with_memory: false,
+    quiesce: false,
   });
+  const [mergeOnDelete, setMergeOnDelete] = useState(false);
 
   const initDoneRef = useRef(false);
   const lastUrlParamsRef = useRef("");
@@ -113,7 +115,7 @@ export default function SnapshotsPage() {
       const instancesRes = await fetch("/api/describe-instances", {
         method: "POST",
         headers: { "Content-Type": "application/json" },
-        body: JSON.stringify({ node_name: targetNode }),
+        body: JSON.stringify({ node_name: targetNode, include: ["disks"] }),
       });
       if (!instancesRes.ok) {
         toast.error("Failed to load instances");
@@ -190,7 +192,7 @@ export default function SnapshotsPage() {
       const res = await fetch("/api/describe-instances", {
         method: "POST",
         headers: { "Content-Type": "application/json" },
-        body: JSON.stringify({ node_name: nodeName }),
+        body: JSON.stringify({ node_name: nodeName, include: ["disks"] }),
       });
       if (res.ok) {
         const data = await res.json();
@@ -285,12 +287,13 @@ export default function SnapshotsPage() {
           snapshot_name: createForm.snapshot_name || undefined,
           description: createForm.description || undefined,
           with_memory: createForm.with_memory,
+          quiesce: createForm.quiesce,
         }),
       });
       if (res.ok) {
         toast.success("Snapshot created");
         setCreateModalOpen(false);
-        setCreateForm({ snapshot_name: "", description: "", with_memory: false });
+        setCreateForm({ snapshot_name: "", description: "", with_memory: false, quiesce: false });
         fetchSnapshots(selectedNode, selectedVM);
       } else {
         const data = await res.json().catch(() => ({}));
@@ -314,6 +317,7 @@ export default function SnapshotsPage() {
           node_name: selectedNode,
           vm_name: selectedVM,
           snapshot_name: targetSnapshot.name,
+          merge: mergeOnDelete,
         }),
       });
       if (res.ok) {
@@ -569,11 +573,27 @@ export default function SnapshotsPage() {
               className="rounded border-gray-300"
               checked={createForm.with_memory}
               onChange={(e) =>
-                setCreateForm((prev) => ({ ...prev, with_memory: e.target.checked }))
+                setCreateForm((prev) => ({
+                  ...prev,
+                  with_memory: e.target.checked,
+                  quiesce: e.target.checked ? false : prev.quiesce,
+                }))
               }
             />
             Include memory (may be slower and larger)
           </label>
+          <label className="inline-flex items-center gap-2 text-sm text-gray-700">
+            <input
+              type="checkbox"
+              className="rounded border-gray-300"
+              checked={createForm.quiesce}
+              disabled={createForm.with_memory}
+              onChange={(e) =>
+                setCreateForm((prev) => ({ ...prev, quiesce: e.target.checked }))
+              }
+            />
+            Quiesce file systems via guest agent (disk-only)
+          </label>
           <div className="flex justify-end gap-3 pt-2">
             <button
               className="btn-secondary"
@@ -597,12 +617,24 @@ export default function SnapshotsPage() {
         onClose={() => {
           setDeleteDialogOpen(false);
           setTargetSnapshot(null);
+          setMergeOnDelete(false);
         }}
         onConfirm={handleDeleteSnapshot}
         title="Delete snapshot"
         message={`Delete snapshot ${targetSnapshot?.name || ""}? This removes the snapshot overlay.`}
         confirmText="Delete"
         variant="danger"
+        extraContent={
+          <label className="inline-flex items-center gap-2 text-sm text-gray-700">
+            <input
+              type="checkbox"
+              className="rounded border-gray-300"
+              checked={mergeOnDelete}
+              onChange={(e) => setMergeOnDelete(e.target.checked)}
+            />
+            Merge overlay into its backing file (running VM, via blockcommit)
+          </label>
+        }
       />
 
       <ConfirmDialog
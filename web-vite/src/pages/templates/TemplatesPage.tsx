@@ -120,6 +120,7 @@ export default function TemplatesPage() {
   const [submitting, setSubmitting] = useState(false);
   const [templateToDelete, setTemplateToDelete] = useState<Template | null>(null);
   const [deleteVolume, setDeleteVolume] = useState(false);
+  const [forceDelete, setForceDelete] = useState(false);
   const [deleting, setDeleting] = useState(false);
   const [nodes, setNodes] = useState<NodeItem[]>([]);
   const [loadingNodes, setLoadingNodes] = useState(false);
@@ -580,6 +581,7 @@ export default function TemplatesPage() {
   const confirmDeleteTemplate = (template: Template) => {
     setTemplateToDelete(template);
     setDeleteVolume(false);
+    setForceDelete(false);
   };
 
   const handleDeleteTemplate = async () => {
@@ -591,6 +593,7 @@ export default function TemplatesPage() {
         node_name: templateToDelete.node_name,
         pool_name: templateToDelete.pool_name,
         delete_volume: deleteVolume,
+        force: deleteVolume && forceDelete,
       });
       toast.success(`Template ${templateToDelete.name} deleted`);
       setTemplateToDelete(null);
@@ -1115,6 +1118,16 @@ export default function TemplatesPage() {
                 />
                 Also delete backing volume ({templateToDelete.volume_name})
               </label>
+              {deleteVolume && (
+                <label className="flex items-center gap-2 text-sm text-gray-600">
+                  <input
+                    type="checkbox"
+                    checked={forceDelete}
+                    onChange={(e) => setForceDelete(e.target.checked)}
+                  />
+                  Flatten dependent disks first (instances must be stopped)
+                </label>
+              )}
               <div className="flex gap-3 pt-2">
                 <button
                   className="btn-secondary flex-1"